@@ -0,0 +1,53 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"nofx/market"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleIndicatorExport 导出指定币种/周期/时间区间每根K线的指标与分析器输出（CSV），用于离线研究
+// 参数：symbol, interval（默认1h）, start, end（RFC3339 或毫秒时间戳）, format（目前仅支持 csv）
+func (s *Server) handleIndicatorExport(c *gin.Context) {
+	symbol := strings.ToUpper(c.Query("symbol"))
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少symbol参数"})
+		return
+	}
+	interval := c.DefaultQuery("interval", "1h")
+	if format := c.DefaultQuery("format", "csv"); format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("不支持的导出格式: %s（目前仅支持 csv）", format)})
+		return
+	}
+
+	start, err := parseReplayTime(c.Query("start"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("start无效: %v", err)})
+		return
+	}
+	end, err := parseReplayTime(c.Query("end"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("end无效: %v", err)})
+		return
+	}
+	if !end.After(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end必须晚于start"})
+		return
+	}
+
+	rows, err := market.ExportIndicators(symbol, interval, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("导出指标失败: %v", err)})
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%s_%s_%s.csv", market.Normalize(symbol), interval, start.Format("20060102"), end.Format("20060102"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	if err := market.WriteIndicatorCSV(c.Writer, rows); err != nil {
+		c.Error(err)
+	}
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	_ "embed"
+	"log"
+	"net/http"
+	"nofx/market"
+	"nofx/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// metricsSampleInterval 状态类指标（WS分片健康）的采样间隔
+const metricsSampleInterval = time.Minute
+
+//go:embed dashboard/metrics.html
+var metricsDashboardHTML []byte
+
+// traderScopedMetrics 按交易员区分的指标（只返回当前用户自己的交易员）
+var traderScopedMetrics = map[string]bool{
+	metrics.DecisionLatency:    true,
+	metrics.AILatency:          true,
+	metrics.AIPromptTokens:     true,
+	metrics.AICompletionTokens: true,
+	metrics.Equity:             true,
+	metrics.PnL:                true,
+}
+
+// registerMetricsSamplers 注册WS分片健康的定时采样（行情监控可能晚于API服务启动，采样时再取）
+func registerMetricsSamplers() {
+	shards := func() []market.ShardHealth {
+		if market.WSMonitorCli == nil {
+			return nil
+		}
+		return market.WSMonitorCli.GetShardHealth()
+	}
+	metrics.RegisterSampler(metrics.WSShards, func() (float64, bool) {
+		health := shards()
+		return float64(len(health)), health != nil
+	})
+	metrics.RegisterSampler(metrics.WSConnectedShards, func() (float64, bool) {
+		health := shards()
+		connected := 0
+		for _, h := range health {
+			if h.Connected {
+				connected++
+			}
+		}
+		return float64(connected), health != nil
+	})
+	metrics.RegisterSampler(metrics.WSMaxMessageLag, func() (float64, bool) {
+		health := shards()
+		lag := 0.0
+		for _, h := range health {
+			if h.SecondsSinceMsg > lag {
+				lag = h.SecondsSinceMsg
+			}
+		}
+		return lag, len(health) > 0
+	})
+	metrics.StartSampling(metricsSampleInterval)
+}
+
+// handleMetricsDashboardPage 内置指标看板页面（页面本身不含数据，数据通过带登录令牌的 /api/metrics/dashboard 获取）
+func (s *Server) handleMetricsDashboardPage(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", metricsDashboardHTML)
+}
+
+// handleMetricsDashboardData 指标看板数据：指标序列、AI费用估算、WS分片实时状态
+func (s *Server) handleMetricsDashboardData(c *gin.Context) {
+	userID := c.GetString("user_id")
+	owned := make(map[string]bool)
+	if traders, err := s.database.GetTraders(userID); err == nil {
+		for _, t := range traders {
+			owned[t.ID] = true
+		}
+	} else {
+		log.Printf("⚠️ 获取用户 %s 的交易员失败: %v", userID, err)
+	}
+
+	series := visibleSeries(metrics.Snapshot(), owned)
+	inputPrice := s.systemConfigFloat("ai_price_input_per_million")
+	outputPrice := s.systemConfigFloat("ai_price_output_per_million")
+	response := gin.H{
+		"generated_at": time.Now(),
+		"series":       series,
+		"ai_cost":      aiCostSeries(series, inputPrice, outputPrice),
+		"ai_price":     gin.H{"input_per_million": inputPrice, "output_per_million": outputPrice},
+	}
+	if market.WSMonitorCli != nil {
+		response["ws_shards"] = market.WSMonitorCli.GetShardHealth()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// visibleSeries 过滤掉其他用户交易员的序列（全局指标对所有用户可见）
+func visibleSeries(all []metrics.Series, owned map[string]bool) []metrics.Series {
+	var series []metrics.Series
+	for _, ser := range all {
+		if traderScopedMetrics[ser.Name] && !owned[ser.Label] {
+			continue
+		}
+		series = append(series, ser)
+	}
+	return series
+}
+
+// systemConfigFloat 读取数值型系统配置（未设置或无效时返回0）
+func (s *Server) systemConfigFloat(key string) float64 {
+	value, err := s.database.GetSystemConfig(key)
+	if err != nil || value == "" {
+		return 0
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// aiCostSeries 按输入/输出单价（USD/百万token）把各交易员的token序列换算为累计费用（未配置单价时返回空）
+// 同一次请求的输入、输出token以相同时间戳记录，按时间戳配对
+func aiCostSeries(series []metrics.Series, inputPrice, outputPrice float64) []metrics.Series {
+	if inputPrice <= 0 && outputPrice <= 0 {
+		return []metrics.Series{}
+	}
+	completion := make(map[string]map[time.Time]float64)
+	for _, ser := range series {
+		if ser.Name != metrics.AICompletionTokens {
+			continue
+		}
+		byTime := make(map[time.Time]float64, len(ser.Points))
+		for _, p := range ser.Points {
+			byTime[p.Time] = p.Value
+		}
+		completion[ser.Label] = byTime
+	}
+
+	result := []metrics.Series{}
+	for _, ser := range series {
+		if ser.Name != metrics.AIPromptTokens {
+			continue
+		}
+		cost := metrics.Series{Name: "ai_cost_usd", Label: ser.Label, Points: make([]metrics.Point, 0, len(ser.Points))}
+		total := 0.0
+		for _, p := range ser.Points {
+			total += (p.Value*inputPrice + completion[ser.Label][p.Time]*outputPrice) / 1_000_000
+			cost.Points = append(cost.Points, metrics.Point{Time: p.Time, Value: total})
+		}
+		result = append(result, cost)
+	}
+	return result
+}
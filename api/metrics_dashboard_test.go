@@ -0,0 +1,45 @@
+package api
+
+import (
+	"math"
+	"nofx/metrics"
+	"testing"
+	"time"
+)
+
+func TestAICostSeries(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(3 * time.Minute)
+	series := []metrics.Series{
+		{Name: metrics.AIPromptTokens, Label: "t1", Points: []metrics.Point{{Time: t0, Value: 10000}, {Time: t1, Value: 20000}}},
+		{Name: metrics.AICompletionTokens, Label: "t1", Points: []metrics.Point{{Time: t0, Value: 1000}, {Time: t1, Value: 2000}}},
+		{Name: metrics.PnL, Label: "t2", Points: []metrics.Point{{Time: t0, Value: 5}}},
+	}
+
+	if got := aiCostSeries(series, 0, 0); len(got) != 0 {
+		t.Errorf("未配置单价时不应估算费用: %+v", got)
+	}
+
+	got := aiCostSeries(series, 0.5, 2)
+	if len(got) != 1 || got[0].Label != "t1" || len(got[0].Points) != 2 {
+		t.Fatalf("aiCostSeries = %+v", got)
+	}
+	// 10000*0.5/1e6 + 1000*2/1e6 = 0.007；累计再加 20000*0.5/1e6 + 2000*2/1e6 = 0.014
+	if math.Abs(got[0].Points[0].Value-0.007) > 1e-12 || math.Abs(got[0].Points[1].Value-0.021) > 1e-12 {
+		t.Errorf("累计费用 = %v, %v, want 0.007, 0.021", got[0].Points[0].Value, got[0].Points[1].Value)
+	}
+}
+
+func TestVisibleSeries(t *testing.T) {
+	all := []metrics.Series{
+		{Name: metrics.AILatency, Label: "mine"},
+		{Name: metrics.AILatency, Label: "other"},
+		{Name: metrics.AIPromptTokens, Label: "other"},
+		{Name: metrics.Equity, Label: "other"},
+		{Name: metrics.WSShards},
+	}
+	got := visibleSeries(all, map[string]bool{"mine": true})
+	if len(got) != 2 || got[0].Label != "mine" || got[1].Name != metrics.WSShards {
+		t.Errorf("visibleSeries = %+v, want own AI latency and global WS series only", got)
+	}
+}
@@ -10,8 +10,14 @@ import (
 	"nofx/config"
 	"nofx/crypto"
 	"nofx/decision"
+	"nofx/events"
 	"nofx/hook"
+	"nofx/logger"
+	"nofx/loglevel"
 	"nofx/manager"
+	"nofx/market"
+	"nofx/mcp"
+	"nofx/pool"
 	"nofx/trader"
 	"strconv"
 	"strings"
@@ -53,6 +59,7 @@ func NewServer(traderManager *manager.TraderManager, database *config.Database,
 
 	// 设置路由
 	s.setupRoutes()
+	registerMetricsSamplers()
 
 	return s
 }
@@ -75,6 +82,9 @@ func corsMiddleware() gin.HandlerFunc {
 
 // setupRoutes 设置路由
 func (s *Server) setupRoutes() {
+	// 内置指标看板页面（无需Prometheus/Grafana，数据接口需登录）
+	s.router.GET("/dashboard/metrics", s.handleMetricsDashboardPage)
+
 	// API路由组
 	api := s.router.Group("/api")
 	{
@@ -123,6 +133,7 @@ func (s *Server) setupRoutes() {
 
 			// AI交易员管理
 			protected.GET("/my-traders", s.handleTraderList)
+			protected.GET("/traders/summary", s.handleTraderSummary)
 			protected.GET("/traders/:id/config", s.handleGetTraderConfig)
 			protected.POST("/traders", s.handleCreateTrader)
 			protected.PUT("/traders/:id", s.handleUpdateTrader)
@@ -131,6 +142,9 @@ func (s *Server) setupRoutes() {
 			protected.POST("/traders/:id/stop", s.handleStopTrader)
 			protected.PUT("/traders/:id/prompt", s.handleUpdateTraderPrompt)
 			protected.POST("/traders/:id/sync-balance", s.handleSyncBalance)
+			protected.POST("/traders/:id/clone", s.handleCloneTrader)
+			protected.GET("/traders/:id/export", s.handleExportTraderBundle)
+			protected.POST("/traders/import", s.handleImportTraderBundle)
 
 			// AI模型配置
 			protected.GET("/models", s.handleGetModelConfigs)
@@ -148,10 +162,97 @@ func (s *Server) setupRoutes() {
 			protected.GET("/status", s.handleStatus)
 			protected.GET("/account", s.handleAccount)
 			protected.GET("/positions", s.handlePositions)
+			protected.GET("/positions/watch", s.handlePositionWatch)
 			protected.GET("/decisions", s.handleDecisions)
 			protected.GET("/decisions/latest", s.handleLatestDecisions)
+			protected.GET("/decisions/tags", s.handleDecisionTags)
+			protected.GET("/decisions/detail", s.handleDecisionDetail)
+			protected.GET("/decisions/by-order", s.handleDecisionByOrder)
 			protected.GET("/statistics", s.handleStatistics)
 			protected.GET("/performance", s.handlePerformance)
+			protected.GET("/trades/replay", s.handleTradeReplay)
+			protected.GET("/settlements", s.handleSettlements)
+			protected.GET("/tax-lots", s.handleTaxLots)
+			protected.GET("/candidates", s.handleCandidates)
+			protected.GET("/correlation", s.handleCorrelation)
+			protected.GET("/indicators", s.handleGetIndicatorSet)
+			protected.GET("/prompt/changes", s.handleGetPromptChanges)
+			protected.GET("/settings/schema", s.handleGetSettingsSchema)
+			protected.GET("/log-levels", s.handleGetLogLevels)
+			protected.PUT("/log-levels", s.handleSetLogLevel)
+			protected.PUT("/indicators", s.handleUpdateIndicatorSet)
+			protected.GET("/timeframes", s.handleGetTimeframes)
+			protected.PUT("/timeframes", s.handleUpdateTimeframes)
+			protected.GET("/timeframes/reliability", s.handleGetTimeframeReliability)
+			protected.GET("/execution/quality", s.handleExecutionQuality)
+			protected.GET("/model/quality", s.handleModelQuality)
+			protected.POST("/risk/preview", s.handleRiskPreview)
+			protected.POST("/simulate", s.handleSimulateDecision)
+
+			// 决策队列：查看待审批/执行中的决策，取消、修改仓位或强制执行
+			protected.GET("/decisions/queue", s.handleDecisionQueue)
+			protected.POST("/decisions/queue/:id/cancel", s.handleCancelQueuedDecision)
+			protected.POST("/decisions/queue/:id/edit", s.handleEditQueuedDecision)
+			protected.POST("/decisions/queue/:id/execute", s.handleExecuteQueuedDecision)
+
+			// 通知偏好（订阅事件、渠道、最低严重程度、每小时上限、汇总模式）
+			protected.GET("/notifications/prefs", s.handleGetNotificationPrefs)
+			protected.PUT("/notifications/prefs", s.handleUpdateNotificationPrefs)
+			protected.GET("/sampling", s.handleGetSamplingParams)
+			protected.PUT("/sampling", s.handleUpdateSamplingParams)
+			protected.GET("/funding/entry-rule", s.handleGetFundingEntryRule)
+			protected.PUT("/funding/entry-rule", s.handleUpdateFundingEntryRule)
+			protected.GET("/tool-budget", s.handleGetToolBudget)
+			protected.PUT("/tool-budget", s.handleUpdateToolBudget)
+			protected.POST("/alerts/trigger", s.handleTriggerAlertCycle)
+			protected.GET("/alerts/cycles", s.handleAlertCycleStatus)
+			protected.GET("/persona", s.handleGetRiskPersona)
+			protected.PUT("/persona", s.handleUpdateRiskPersona)
+			protected.GET("/circuit-breaker", s.handleGetCircuitBreaker)
+			protected.POST("/circuit-breaker/reset", s.handleResetCircuitBreaker)
+			protected.GET("/equity-guard", s.handleGetEquityGuard)
+			protected.PUT("/equity-guard", s.handleUpdateEquityGuard)
+			protected.POST("/equity-guard/acknowledge", s.handleAcknowledgeEquityAnomaly)
+			protected.GET("/maintenance", s.handleGetMaintenance)
+			protected.GET("/maintenance-windows", s.handleListMaintenanceWindows)
+			protected.POST("/maintenance-windows", s.handleAddMaintenanceWindow)
+			protected.DELETE("/maintenance-windows/:id", s.handleRemoveMaintenanceWindow)
+			protected.GET("/pause", s.handleGetPause)
+			protected.POST("/pause", s.handlePause)
+			protected.POST("/pause/resume", s.handleResume)
+			protected.GET("/watch-only", s.handleGetWatchOnly)
+			protected.PUT("/watch-only", s.handleUpdateWatchOnly)
+			protected.GET("/trade-ideas", s.handleListTradeIdeas)
+			protected.POST("/trade-ideas", s.handleSubmitTradeIdea)
+			protected.POST("/trade-ideas/:id/cancel", s.handleCancelTradeIdea)
+			protected.GET("/postmortems", s.handleListPostMortems)
+			protected.GET("/postmortems/:id", s.handleGetPostMortem)
+			protected.POST("/postmortems/:id/review", s.handleReviewPostMortem)
+			protected.GET("/trade-chart", s.handleGetTradeChart)
+
+			// AI输出格式合规统计（按模型+模板）
+			protected.GET("/model/conformance", s.handleModelConformance)
+			protected.GET("/events/stats", s.handleEventBusStats)
+			protected.GET("/templates/rollout", s.handleGetTemplateRollout)
+			protected.POST("/templates/rollout", s.handleStartTemplateRollout)
+			protected.POST("/templates/rollout/abort", s.handleAbortTemplateRollout)
+			protected.GET("/capital/rebalance", s.handleGetCapitalRebalance)
+			protected.PUT("/capital/rebalance", s.handleSetCapitalRebalance)
+			protected.POST("/capital/rebalance/run", s.handleRunCapitalRebalance)
+			protected.POST("/capital/rebalance/disable", s.handleDisableCapitalRebalance)
+
+			// 币种池数据源健康状态（AI500 / OI Top）
+			protected.GET("/pool/health", s.handlePoolHealth)
+
+			// 决策调度器指标（AI调用/行情获取排队深度）
+			protected.GET("/scheduler/metrics", s.handleSchedulerMetrics)
+			protected.GET("/metrics/dashboard", s.handleMetricsDashboardData)
+
+			// 行情WebSocket分片健康状态
+			protected.GET("/market/ws-health", s.handleMarketWSHealth)
+
+			// 历史指标导出（研究用，CSV）
+			protected.GET("/market/indicators/export", s.handleIndicatorExport)
 		}
 	}
 }
@@ -203,6 +304,18 @@ func (s *Server) handleGetSystemConfig(c *gin.Context) {
 	})
 }
 
+// handleGetSettingsSchema 全部可配置项的类型、默认值、约束和说明（?scope=system|risk|analyzer|trader 过滤），前端据此渲染设置表单
+func (s *Server) handleGetSettingsSchema(c *gin.Context) {
+	scope := c.Query("scope")
+	settings := make([]config.SettingSpec, 0)
+	for _, spec := range config.SettingSchema() {
+		if scope == "" || spec.Scope == scope {
+			settings = append(settings, spec)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"settings": settings})
+}
+
 // handleGetServerIP 获取服务器IP地址（用于白名单配置）
 func (s *Server) handleGetServerIP(c *gin.Context) {
 
@@ -653,6 +766,7 @@ type UpdateTraderRequest struct {
 	CustomPrompt        string  `json:"custom_prompt"`
 	OverrideBasePrompt  bool    `json:"override_base_prompt"`
 	IsCrossMargin       *bool   `json:"is_cross_margin"`
+	ChangeReason        string  `json:"change_reason"` // 修改原因（提示词相关配置变化时写入审计记录）
 }
 
 // handleUpdateTrader 更新交易员配置
@@ -736,6 +850,14 @@ func (s *Server) handleUpdateTrader(c *gin.Context) {
 		return
 	}
 
+	// 杠杆或自定义策略变化会改变 System Prompt，记录修改人和原因供审计
+	if btcEthLeverage != existingTrader.BTCETHLeverage || altcoinLeverage != existingTrader.AltcoinLeverage ||
+		req.CustomPrompt != existingTrader.CustomPrompt || req.OverrideBasePrompt != existingTrader.OverrideBasePrompt {
+		if err := s.database.NotePromptChangeIntent(traderID, queueOperator(c), req.ChangeReason); err != nil {
+			log.Printf("⚠️ 记录提示词修改意图失败: %v", err)
+		}
+	}
+
 	// 重新加载交易员到内存
 	err = s.traderManager.LoadUserTraders(s.database, userID)
 	if err != nil {
@@ -766,8 +888,7 @@ func (s *Server) handleDeleteTrader(c *gin.Context) {
 
 	// 如果交易员正在运行，先停止它
 	if trader, err := s.traderManager.GetTrader(traderID); err == nil {
-		status := trader.GetStatus()
-		if isRunning, ok := status["is_running"].(bool); ok && isRunning {
+		if trader.GetStatus().IsRunning {
 			trader.Stop()
 			log.Printf("⏹  已停止运行中的交易员: %s", traderID)
 		}
@@ -796,8 +917,7 @@ func (s *Server) handleStartTrader(c *gin.Context) {
 	}
 
 	// 检查交易员是否已经在运行
-	status := trader.GetStatus()
-	if isRunning, ok := status["is_running"].(bool); ok && isRunning {
+	if trader.GetStatus().IsRunning {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "交易员已在运行中"})
 		return
 	}
@@ -839,8 +959,7 @@ func (s *Server) handleStopTrader(c *gin.Context) {
 	}
 
 	// 检查交易员是否正在运行
-	status := trader.GetStatus()
-	if isRunning, ok := status["is_running"].(bool); ok && !isRunning {
+	if !trader.GetStatus().IsRunning {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "交易员已停止"})
 		return
 	}
@@ -866,6 +985,7 @@ func (s *Server) handleUpdateTraderPrompt(c *gin.Context) {
 	var req struct {
 		CustomPrompt       string `json:"custom_prompt"`
 		OverrideBasePrompt bool   `json:"override_base_prompt"`
+		ChangeReason       string `json:"change_reason"` // 修改原因（写入提示词变更审计）
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -879,6 +999,9 @@ func (s *Server) handleUpdateTraderPrompt(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("更新自定义prompt失败: %v", err)})
 		return
 	}
+	if err := s.database.NotePromptChangeIntent(traderID, queueOperator(c), req.ChangeReason); err != nil {
+		log.Printf("⚠️ 记录提示词修改意图失败: %v", err)
+	}
 
 	// 如果trader在内存中，更新其custom prompt和override设置
 	trader, err := s.traderManager.GetTrader(traderID)
@@ -891,6 +1014,184 @@ func (s *Server) handleUpdateTraderPrompt(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "自定义prompt已更新"})
 }
 
+// CloneTraderRequest 克隆交易员请求
+type CloneTraderRequest struct {
+	Name           string  `json:"name"`            // 为空时使用 "<原名称> (copy)"
+	InitialBalance float64 `json:"initial_balance"` // 为空时使用原交易员当前净值（未运行时沿用原初始资金）
+}
+
+// handleCloneTrader 克隆交易员：复制模型、交易所引用、风控与模板配置，新交易员拥有独立的决策日志和统计
+func (s *Server) handleCloneTrader(c *gin.Context) {
+	userID := c.GetString("user_id")
+	sourceID := c.Param("id")
+
+	var req CloneTraderRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.InitialBalance < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "初始资金不能为负数"})
+		return
+	}
+
+	source, _, _, err := s.database.GetTraderConfig(userID, sourceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = source.Name + " (copy)"
+	}
+
+	// 重新开始实验：默认以原交易员当前净值作为新的初始资金，收益率从零开始计算
+	initialBalance := req.InitialBalance
+	if initialBalance == 0 {
+		if at, err := s.traderManager.GetTrader(sourceID); err == nil {
+			if account, err := at.GetAccountInfo(); err == nil {
+				if equity, ok := account["total_equity"].(float64); ok && equity > 0 {
+					initialBalance = equity
+				}
+			}
+		}
+	}
+
+	newID := fmt.Sprintf("%s_%s_%d", source.ExchangeID, source.AIModelID, time.Now().Unix())
+	clone, err := s.database.CloneTrader(userID, sourceID, newID, name, initialBalance)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.traderManager.LoadUserTraders(s.database, userID); err != nil {
+		log.Printf("⚠️ 加载用户交易员到内存失败: %v", err)
+	}
+
+	log.Printf("✓ 克隆交易员成功: %s → %s (%s)", sourceID, newID, name)
+	c.JSON(http.StatusCreated, gin.H{
+		"trader_id":        clone.ID,
+		"trader_name":      clone.Name,
+		"source_trader_id": sourceID,
+		"initial_balance":  clone.InitialBalance,
+		"is_running":       false,
+	})
+}
+
+// handleExportTraderBundle 导出交易员配置包（风控、模板引用、分析器配置、扫描间隔；不含密钥），带本部署签名
+func (s *Server) handleExportTraderBundle(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	bundle, err := s.database.ExportTraderBundle(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=nofx-trader-%s.json", traderID))
+	c.JSON(http.StatusOK, bundle)
+}
+
+// ImportTraderBundleRequest 导入配置包请求（模型/交易所引用可替换为本部署中的配置）
+type ImportTraderBundleRequest struct {
+	Bundle *config.TraderBundle `json:"bundle" binding:"required"`
+	config.TraderBundleImport
+}
+
+// handleImportTraderBundle 校验签名和设置后按配置包创建新交易员（未运行）
+func (s *Server) handleImportTraderBundle(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req ImportTraderBundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.InitialBalance < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "初始资金不能为负数"})
+		return
+	}
+	if err := req.Bundle.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 引用的模型、交易所和模板必须在本部署中存在
+	aiModelID := req.AIModelID
+	if aiModelID == "" {
+		aiModelID = req.Bundle.Trader.AIModelID
+	}
+	exchangeID := req.ExchangeID
+	if exchangeID == "" {
+		exchangeID = req.Bundle.Trader.ExchangeID
+	}
+	if !s.hasAIModel(userID, aiModelID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("AI模型 %s 未配置，请通过 ai_model_id 指定本部署中的模型", aiModelID)})
+		return
+	}
+	if !s.hasExchange(userID, exchangeID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("交易所 %s 未配置，请通过 exchange_id 指定本部署中的交易所", exchangeID)})
+		return
+	}
+	if name := req.Bundle.Trader.SystemPromptTemplate; name != "" {
+		if _, err := decision.GetPromptTemplate(name); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("提示词模板 %s 不存在", name)})
+			return
+		}
+	}
+
+	req.AIModelID, req.ExchangeID = aiModelID, exchangeID
+	newID := fmt.Sprintf("%s_%s_%d", exchangeID, aiModelID, time.Now().Unix())
+	imported, err := s.database.ImportTraderBundle(userID, newID, req.Bundle, req.TraderBundleImport)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.traderManager.LoadUserTraders(s.database, userID); err != nil {
+		log.Printf("⚠️ 加载用户交易员到内存失败: %v", err)
+	}
+
+	log.Printf("✓ 导入交易员配置包成功: %s (%s)，签名指纹 %s", newID, imported.Name, req.Bundle.SignerFingerprint())
+	c.JSON(http.StatusCreated, gin.H{
+		"trader_id":          imported.ID,
+		"trader_name":        imported.Name,
+		"signer_fingerprint": req.Bundle.SignerFingerprint(),
+		"settings":           len(req.Bundle.Settings),
+		"is_running":         false,
+	})
+}
+
+// hasAIModel 用户是否配置了该AI模型
+func (s *Server) hasAIModel(userID, modelID string) bool {
+	models, err := s.database.GetAIModels(userID)
+	if err != nil {
+		return false
+	}
+	for _, m := range models {
+		if m.ID == modelID {
+			return true
+		}
+	}
+	return false
+}
+
+// hasExchange 用户是否配置了该交易所
+func (s *Server) hasExchange(userID, exchangeID string) bool {
+	exchanges, err := s.database.GetExchanges(userID)
+	if err != nil {
+		return false
+	}
+	for _, ex := range exchanges {
+		if ex.ID == exchangeID {
+			return true
+		}
+	}
+	return false
+}
+
 // handleSyncBalance 同步交易所余额到initial_balance（选项B：手动同步 + 选项C：智能检测）
 func (s *Server) handleSyncBalance(c *gin.Context) {
 	userID := c.GetString("user_id")
@@ -1246,10 +1547,7 @@ func (s *Server) handleTraderList(c *gin.Context) {
 		// 获取实时运行状态
 		isRunning := trader.IsRunning
 		if at, err := s.traderManager.GetTrader(trader.ID); err == nil {
-			status := at.GetStatus()
-			if running, ok := status["is_running"].(bool); ok {
-				isRunning = running
-			}
+			isRunning = at.GetStatus().IsRunning
 		}
 
 		// 返回完整的 AIModelID（如 "admin_deepseek"），不要截断
@@ -1267,6 +1565,45 @@ func (s *Server) handleTraderList(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// handleTraderSummary 当前用户所有交易员的汇总（状态、净值、日盈亏、持仓数、最近决策时间、健康标记）
+func (s *Server) handleTraderSummary(c *gin.Context) {
+	userID := c.GetString("user_id")
+	records, err := s.database.GetTraders(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取交易员列表失败: %v", err)})
+		return
+	}
+
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	summaries := s.traderManager.GetTraderSummaries(ids)
+
+	healthy := 0
+	totalEquity := 0.0
+	for i := range summaries {
+		// 未加载到内存的交易员只返回数据库中的基本信息
+		if summaries[i].TraderName == "" {
+			summaries[i].TraderName = records[i].Name
+			summaries[i].AIModel = records[i].AIModelID
+			summaries[i].Exchange = records[i].ExchangeID
+		}
+		if summaries[i].Healthy {
+			healthy++
+		}
+		totalEquity += summaries[i].TotalEquity
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"traders":       summaries,
+		"count":         len(summaries),
+		"healthy_count": healthy,
+		"total_equity":  totalEquity,
+		"generated_at":  time.Now(),
+	})
+}
+
 // handleGetTraderConfig 获取交易员详细配置
 func (s *Server) handleGetTraderConfig(c *gin.Context) {
 	userID := c.GetString("user_id")
@@ -1286,10 +1623,7 @@ func (s *Server) handleGetTraderConfig(c *gin.Context) {
 	// 获取实时运行状态
 	isRunning := traderConfig.IsRunning
 	if at, err := s.traderManager.GetTrader(traderID); err == nil {
-		status := at.GetStatus()
-		if running, ok := status["is_running"].(bool); ok {
-			isRunning = running
-		}
+		isRunning = at.GetStatus().IsRunning
 	}
 
 	// 返回完整的模型ID，不做转换，保持与前端模型列表一致
@@ -1312,6 +1646,9 @@ func (s *Server) handleGetTraderConfig(c *gin.Context) {
 		"use_oi_top":            traderConfig.UseOITop,
 		"is_running":            isRunning,
 	}
+	if lineage := s.database.GetTraderLineage(traderID); lineage != nil {
+		result["cloned_from"] = lineage
+	}
 
 	c.JSON(http.StatusOK, result)
 }
@@ -1449,6 +1786,53 @@ func (s *Server) handleLatestDecisions(c *gin.Context) {
 	c.JSON(http.StatusOK, records)
 }
 
+// handleDecisionDetail 单条决策记录详情（含产生的订单：客户端订单ID、交易所订单ID、成交结果）
+func (s *Server) handleDecisionDetail(c *gin.Context) {
+	at, _, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少决策记录ID（id）"})
+		return
+	}
+	detail, err := at.GetDecisionDetail(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// handleDecisionByOrder 按交易所订单ID或客户端订单ID追溯产生该订单的决策及AI理由
+func (s *Server) handleDecisionByOrder(c *gin.Context) {
+	at, _, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	clientOrderID := c.Query("client_order_id")
+	var exchangeOrderID int64
+	if raw := c.Query("order_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "order_id 无效"})
+			return
+		}
+		exchangeOrderID = id
+	}
+	if exchangeOrderID == 0 && clientOrderID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "需要 order_id 或 client_order_id"})
+		return
+	}
+	origin, err := at.FindOrderOrigin(exchangeOrderID, clientOrderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, origin)
+}
+
 // handleStatistics 统计信息
 func (s *Server) handleStatistics(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
@@ -1532,12 +1916,7 @@ func (s *Server) handleEquityHistory(c *gin.Context) {
 	}
 
 	// 从AutoTrader获取初始余额（用于计算盈亏百分比）
-	initialBalance := 0.0
-	if status := trader.GetStatus(); status != nil {
-		if ib, ok := status["initial_balance"].(float64); ok && ib > 0 {
-			initialBalance = ib
-		}
-	}
+	initialBalance := trader.GetStatus().InitialBalance
 
 	// 如果无法从status获取，且有历史记录，则从第一条记录获取
 	if initialBalance == 0 && len(records) > 0 {
@@ -1608,6 +1987,1171 @@ func (s *Server) handlePerformance(c *gin.Context) {
 	c.JSON(http.StatusOK, performance)
 }
 
+// handleCandidates 当前候选币种及来源（含候选池刷新状态和最近的行情异动）
+func (s *Server) handleCandidates(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	candidates, updatedAt := trader.GetCandidates()
+	snapshot, anomalies := pool.GetCandidateSnapshot()
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id":  traderID,
+		"candidates": candidates,
+		"updated_at": updatedAt,
+		"pool":       snapshot,
+		"anomalies":  anomalies,
+	})
+}
+
+// handleCorrelation 持仓与候选币种的滚动收益率相关性矩阵
+// 参数：interval（1h / 4h / 1d，默认4h）、lookback（回看K线数，默认按周期）、threshold（高相关阈值，默认0.8）
+func (s *Server) handleCorrelation(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	interval := c.DefaultQuery("interval", "4h")
+	if !market.ValidCorrelationInterval(interval) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval参数无效（可选 1h / 4h / 1d）"})
+		return
+	}
+	lookback, err := strconv.Atoi(c.DefaultQuery("lookback", "0"))
+	if err != nil || lookback < 0 || lookback > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lookback参数无效（0-500）"})
+		return
+	}
+	threshold, err := strconv.ParseFloat(c.DefaultQuery("threshold", "0.8"), 64)
+	if err != nil || threshold < 0 || threshold > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "threshold参数无效（0-1）"})
+		return
+	}
+
+	matrix, err := trader.GetCorrelationMatrix(interval, lookback)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id":         traderID,
+		"correlation":       matrix,
+		"highly_correlated": matrix.HighlyCorrelated(threshold),
+	})
+}
+
+// handleGetIndicatorSet 交易员指标集（未配置时 indicators 为空，附带默认指标集作为参考）
+func (s *Server) handleGetIndicatorSet(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	indicators := trader.GetIndicatorSet()
+	if indicators == nil {
+		indicators = []market.IndicatorDef{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id":  traderID,
+		"indicators": indicators,
+		"defaults":   market.DefaultIndicatorSet(),
+	})
+}
+
+// handleUpdateIndicatorSet 更新交易员指标集
+func (s *Server) handleUpdateIndicatorSet(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req struct {
+		Indicators []market.IndicatorDef `json:"indicators"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	indicators, err := trader.UpdateIndicatorSet(req.Indicators)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "indicators": indicators})
+}
+
+// handleGetTimeframes 交易员分析周期（附带可选周期和交易风格预设）
+func (s *Server) handleGetTimeframes(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id":  traderID,
+		"timeframes": trader.GetTimeframes(),
+		"supported":  market.SupportedTimeframes,
+		"presets":    market.TimeframePresets,
+	})
+}
+
+// handleGetTimeframeReliability 各币种各周期趋势信号的实际命中率（按已平仓交易统计，用于周期一致性加权）
+func (s *Server) handleGetTimeframeReliability(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "reliability": at.GetTimeframeReliability()})
+}
+
+// handleUpdateTimeframes 更新交易员分析周期（preset 与 timeframes 二选一）
+func (s *Server) handleUpdateTimeframes(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req struct {
+		Preset     string   `json:"preset"`
+		Timeframes []string `json:"timeframes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	value := req.Preset
+	if value == "" {
+		value = strings.Join(req.Timeframes, ",")
+	}
+
+	timeframes, err := trader.UpdateTimeframes(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "timeframes": timeframes})
+}
+
+// handleGetPromptChanges System Prompt 变更时间线（新→旧，用于对照绩效变化）
+func (s *Server) handleGetPromptChanges(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit 必须为正整数"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id": traderID,
+		"changes":   trader.GetPromptTimeline(limit),
+	})
+}
+
+// handleDecisionTags 按理由标签（信号/周期/价位/风险提示）检索最近的决策并统计标签分布
+func (s *Server) handleDecisionTags(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	cycles, err := strconv.Atoi(c.DefaultQuery("cycles", "200"))
+	if err != nil || cycles <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cycles 必须为正整数"})
+		return
+	}
+
+	records, err := trader.GetDecisionLogger().GetLatestRecords(cycles)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取决策日志失败: %v", err)})
+		return
+	}
+
+	filter := logger.ReasoningTagFilter{
+		Symbol:      c.Query("symbol"),
+		Signal:      c.Query("signal"),
+		Timeframe:   c.Query("timeframe"),
+		LevelKind:   c.Query("level"),
+		HasRiskNote: c.Query("risk_note") == "true",
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id": traderID,
+		"stats":     logger.SummarizeReasoningTags(records),
+		"actions":   logger.FilterTaggedActions(records, filter),
+	})
+}
+
+// handleGetLogLevels 各子系统日志级别
+func (s *Server) handleGetLogLevels(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"subsystems": loglevel.Status()})
+}
+
+// handleSetLogLevel 运行时调整子系统日志级别
+func (s *Server) handleSetLogLevel(c *gin.Context) {
+	var req struct {
+		Subsystem string `json:"subsystem" binding:"required"`
+		Level     string `json:"level" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := loglevel.SetLevel(req.Subsystem, req.Level); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("🔧 子系统 %s 日志级别已调整为 %s", req.Subsystem, req.Level)
+	c.JSON(http.StatusOK, gin.H{"subsystems": loglevel.Status()})
+}
+
+// handleExecutionQuality 成交执行质量报告（决策价 vs 成交价滑点，按币种/仓位大小/时段汇总；Maker挂单成交率与手续费节省）
+// 参数：days（统计最近N天，默认7，0=全部）
+func (s *Server) handleExecutionQuality(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days参数无效"})
+		return
+	}
+	var since time.Time
+	if days > 0 {
+		since = time.Now().AddDate(0, 0, -days)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id": traderID,
+		"report":    trader.GetExecutionQualityReport(since),
+		"maker":     trader.GetMakerStats(),
+		"policy":    trader.GetExecutionPolicy(),
+	})
+}
+
+// handleModelQuality 模型质量问题统计（幻觉持仓、未知币种、超出仓位上限）
+func (s *Server) handleModelQuality(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id": traderID,
+		"report":    trader.GetModelQualityReport(),
+	})
+}
+
+// handleRiskPreview 待执行决策的风险预览（保证金影响、强平价、开放风险变化、规则检查），供人工审批前确认
+func (s *Server) handleRiskPreview(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req decision.Decision
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Symbol == "" || req.Action == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol 和 action 不能为空"})
+		return
+	}
+
+	preview, err := trader.PreviewDecisionRisk(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("风险预览失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id": traderID,
+		"preview":   preview,
+	})
+}
+
+// handleSimulateDecision 沙盒模拟：按假设账户状态请求决策并做风险检查（不下单、不影响真实交易员）
+func (s *Server) handleSimulateDecision(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req struct {
+		Symbol  string                `json:"symbol" binding:"required"`
+		Account trader.SandboxAccount `json:"account"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Account.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := trader.SimulateDecision(req.Account, req.Symbol)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("沙盒模拟失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id": traderID,
+		"result":    result,
+	})
+}
+
+// queueOperator 决策队列操作人（优先使用邮箱，便于审计）
+func queueOperator(c *gin.Context) string {
+	if email := c.GetString("email"); email != "" {
+		return email
+	}
+	return c.GetString("user_id")
+}
+
+// queueTrader 解析 trader_id 并获取交易器
+func (s *Server) queueTrader(c *gin.Context) (*trader.AutoTrader, string, bool) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, "", false
+	}
+	at, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, "", false
+	}
+	return at, traderID, true
+}
+
+// handleDecisionQueue 决策队列及最近的人工操作日志
+func (s *Server) handleDecisionQueue(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id":         traderID,
+		"approval_required": at.IsApprovalRequired(),
+		"queue":             at.GetDecisionQueue(),
+		"journal":           at.GetDecisionJournal(50),
+	})
+}
+
+// handleCancelQueuedDecision 取消待审批决策
+func (s *Server) handleCancelQueuedDecision(c *gin.Context) {
+	at, _, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	if err := at.CancelQueuedDecision(c.Param("id"), queueOperator(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "决策已取消"})
+}
+
+// handleEditQueuedDecision 修改待审批开仓决策的仓位/杠杆
+func (s *Server) handleEditQueuedDecision(c *gin.Context) {
+	at, _, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	var req trader.DecisionEdit
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	item, preview, err := at.EditQueuedDecision(c.Param("id"), queueOperator(c), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "preview": preview})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"decision": item, "preview": preview})
+}
+
+// handleExecuteQueuedDecision 批准并执行待审批决策
+func (s *Server) handleExecuteQueuedDecision(c *gin.Context) {
+	at, _, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	item, action, err := at.ExecuteQueuedDecision(c.Param("id"), queueOperator(c))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if item == nil {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error(), "decision": item, "action": action})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"decision": item, "action": action})
+}
+
+// handleGetNotificationPrefs 通知偏好及推送统计
+func (s *Server) handleGetNotificationPrefs(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	prefs, stats := at.GetNotificationPrefs()
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id": traderID,
+		"prefs":     prefs,
+		"stats":     stats,
+	})
+}
+
+// handleUpdateNotificationPrefs 更新通知偏好
+func (s *Server) handleUpdateNotificationPrefs(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	prefs := logger.DefaultNotificationPrefs()
+	if err := c.ShouldBindJSON(&prefs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := at.UpdateNotificationPrefs(prefs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	prefs, _ = at.GetNotificationPrefs()
+	log.Printf("🔔 [%s] 通知偏好已更新: %+v", traderID, prefs)
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "prefs": prefs})
+}
+
+// handleGetSamplingParams 模型采样参数（配置值及实际发送值）
+func (s *Server) handleGetSamplingParams(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	configured, effective := at.GetSamplingParams()
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id":  traderID,
+		"configured": configured,
+		"effective":  effective,
+	})
+}
+
+// handleUpdateSamplingParams 更新模型采样参数（字段省略=使用默认值）
+func (s *Server) handleUpdateSamplingParams(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	var params mcp.SamplingParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := at.UpdateSamplingParams(params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	configured, effective := at.GetSamplingParams()
+	log.Printf("🎛️ [%s] 采样参数已更新: %+v", traderID, effective)
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "configured": configured, "effective": effective})
+}
+
+// handleGetTemplateRollout 当前（或最近一次）模板灰度
+func (s *Server) handleGetTemplateRollout(c *gin.Context) {
+	rollout := s.traderManager.GetTemplateRollout()
+	if rollout == nil || rollout.UserID != c.GetString("user_id") {
+		c.JSON(http.StatusOK, gin.H{"rollout": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rollout": rollout})
+}
+
+// handleStartTemplateRollout 开始模板灰度
+func (s *Server) handleStartTemplateRollout(c *gin.Context) {
+	var cfg manager.TemplateRolloutConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rollout, err := s.traderManager.StartTemplateRollout(s.database, c.GetString("user_id"), cfg)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rollout": rollout})
+}
+
+// handleAbortTemplateRollout 中止模板灰度
+func (s *Server) handleAbortTemplateRollout(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&req)
+	if current := s.traderManager.GetTemplateRollout(); current == nil || current.UserID != c.GetString("user_id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "没有进行中的模板灰度"})
+		return
+	}
+	rollout, err := s.traderManager.AbortTemplateRollout(s.database, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rollout": rollout})
+}
+
+// handleGetCapitalRebalance 资金再平衡计划和最近的调整日志
+func (s *Server) handleGetCapitalRebalance(c *gin.Context) {
+	userID := c.GetString("user_id")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	events, err := s.database.GetCapitalReallocations(userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"plan": s.traderManager.GetCapitalRebalance(userID), "events": events})
+}
+
+// handleSetCapitalRebalance 保存资金再平衡计划并立即执行一次
+func (s *Server) handleSetCapitalRebalance(c *gin.Context) {
+	var cfg manager.CapitalRebalanceConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	plan, events, err := s.traderManager.SetCapitalRebalance(s.database, c.GetString("user_id"), cfg)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "plan": plan})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"plan": plan, "events": events})
+}
+
+// handleRunCapitalRebalance 立即执行一次资金再平衡
+func (s *Server) handleRunCapitalRebalance(c *gin.Context) {
+	userID := c.GetString("user_id")
+	events, err := s.traderManager.RunCapitalRebalance(s.database, userID, "手动再平衡")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"plan": s.traderManager.GetCapitalRebalance(userID), "events": events})
+}
+
+// handleDisableCapitalRebalance 停用资金再平衡计划
+func (s *Server) handleDisableCapitalRebalance(c *gin.Context) {
+	plan, err := s.traderManager.DisableCapitalRebalance(s.database, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"plan": plan})
+}
+
+// handleGetFundingEntryRule 资金费结算前开仓限制窗口
+func (s *Server) handleGetFundingEntryRule(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "block_minutes": at.GetFundingEntryBlockMinutes()})
+}
+
+// handleUpdateFundingEntryRule 更新资金费结算前开仓限制窗口
+func (s *Server) handleUpdateFundingEntryRule(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		BlockMinutes int `json:"block_minutes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := at.SetFundingEntryBlockMinutes(req.BlockMinutes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("⏳ [%s] 资金费开仓窗口已更新: %d 分钟", traderID, req.BlockMinutes)
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "block_minutes": at.GetFundingEntryBlockMinutes()})
+}
+
+// handleGetToolBudget 每个决策周期模型可调用数据工具的次数
+func (s *Server) handleGetToolBudget(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "budget": at.GetToolCallBudget(), "max_budget": decision.MaxToolCallBudget})
+}
+
+// handleUpdateToolBudget 更新数据工具调用次数（0=关闭工具调用）
+func (s *Server) handleUpdateToolBudget(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Budget int `json:"budget"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := at.SetToolCallBudget(req.Budget); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("🔧 [%s] 数据工具调用次数已更新: %d", traderID, req.Budget)
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "budget": at.GetToolCallBudget(), "max_budget": decision.MaxToolCallBudget})
+}
+
+// handleTriggerAlertCycle 警报触发定向决策周期（可作为外部警报规则的Webhook）
+func (s *Server) handleTriggerAlertCycle(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	var alert decision.AlertTrigger
+	if err := c.ShouldBindJSON(&alert); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := at.TriggerAlertCycle(alert); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"trader_id": traderID, "status": at.GetAlertCycleStatus()})
+}
+
+// handleAlertCycleStatus 警报定向周期统计
+func (s *Server) handleAlertCycleStatus(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "status": at.GetAlertCycleStatus()})
+}
+
+// handleGetCircuitBreaker 熔断状态及剩余冷却时间
+func (s *Server) handleGetCircuitBreaker(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "circuit_breaker": at.GetCircuitBreakerStatus()})
+}
+
+// handlePositionWatch 持仓优先刷新状态（标记价、强平距离、止损状态、相对上个周期的逆向波动）
+func (s *Server) handlePositionWatch(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "position_watch": at.GetPositionWatchStatus()})
+}
+
+// handleResetCircuitBreaker 人工解除熔断冷却
+func (s *Server) handleResetCircuitBreaker(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	if err := at.ResetCircuitBreaker(c.GetString("user_id")); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "circuit_breaker": at.GetCircuitBreakerStatus()})
+}
+
+// handleGetEquityGuard 净值异常跳变保护状态（阈值、比较基准、未确认的异常）
+func (s *Server) handleGetEquityGuard(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "equity_guard": at.GetEquityGuardStatus()})
+}
+
+// handleUpdateEquityGuard 更新净值跳变告警阈值（0=关闭）
+func (s *Server) handleUpdateEquityGuard(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		ThresholdPct float64 `json:"threshold_pct"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := at.SetEquityJumpPct(req.ThresholdPct); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("🧊 [%s] 净值跳变阈值已更新: %.2f%%", traderID, req.ThresholdPct)
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "equity_guard": at.GetEquityGuardStatus()})
+}
+
+// handleAcknowledgeEquityAnomaly 确认净值异常（核实为划转等真实变动后解除开仓冻结）
+func (s *Server) handleAcknowledgeEquityAnomaly(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	if err := at.AcknowledgeEquityAnomaly(c.GetString("user_id")); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "equity_guard": at.GetEquityGuardStatus()})
+}
+
+// handleGetMaintenance 交易所维护状态
+func (s *Server) handleGetMaintenance(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "maintenance": at.GetMaintenanceStatus()})
+}
+
+// handleListMaintenanceWindows 已录入的交易所维护窗口
+func (s *Server) handleListMaintenanceWindows(c *gin.Context) {
+	windows, err := trader.ListMaintenanceWindows(s.database)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"windows": windows})
+}
+
+// handleAddMaintenanceWindow 录入交易所维护公告
+func (s *Server) handleAddMaintenanceWindow(c *gin.Context) {
+	var req trader.MaintenanceWindow
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	window, err := trader.AddMaintenanceWindow(s.database, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"window": window})
+}
+
+// handleRemoveMaintenanceWindow 删除维护窗口
+func (s *Server) handleRemoveMaintenanceWindow(c *gin.Context) {
+	if err := trader.RemoveMaintenanceWindow(s.database, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "维护窗口已删除"})
+}
+
+// pauseRequest 暂停/恢复请求（scope=global 作用于当前用户的所有交易员，scope=trader 作用于 ?trader_id 指定的交易员）
+type pauseRequest struct {
+	Scope           string `json:"scope"`
+	Mode            string `json:"mode"`
+	Reason          string `json:"reason"`
+	Note            string `json:"note"`
+	DurationMinutes int    `json:"duration_minutes"` // 自动恢复时长（0=需手动恢复）
+}
+
+// pauseTargets 解析暂停作用范围，返回要暂停/恢复的交易员ID
+// 没有管理员角色，scope=global 只覆盖当前用户自己的交易员，不会影响其他用户（系统级暂停 trading_pause 不通过API开放）
+func (s *Server) pauseTargets(c *gin.Context, scope string) ([]string, bool) {
+	switch scope {
+	case "global":
+		traders, err := s.database.GetTraders(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取交易员列表失败: %v", err)})
+			return nil, false
+		}
+		ids := make([]string, 0, len(traders))
+		for _, t := range traders {
+			ids = append(ids, t.ID)
+		}
+		if len(ids) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "没有可暂停的交易员"})
+			return nil, false
+		}
+		return ids, true
+	case "", "trader":
+		_, traderID, ok := s.queueTrader(c)
+		return []string{traderID}, ok
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "scope 必须为 global 或 trader"})
+	return nil, false
+}
+
+// handleGetPause 全局和交易员的暂停状态
+func (s *Server) handleGetPause(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "pause": at.GetPauseStatus()})
+}
+
+// handlePause 暂停交易（必须提供原因代码，可设置自动恢复时长）
+func (s *Server) handlePause(c *gin.Context) {
+	var req pauseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	traderIDs, ok := s.pauseTargets(c, req.Scope)
+	if !ok {
+		return
+	}
+	var pause trader.TradingPause
+	for _, traderID := range traderIDs {
+		var err error
+		pause, err = trader.SetTradingPause(s.database, traderID, trader.TradingPause{
+			Mode:   req.Mode,
+			Reason: req.Reason,
+			Note:   req.Note,
+			By:     queueOperator(c),
+		}, time.Duration(req.DurationMinutes)*time.Minute)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"pause": pause, "trader_ids": traderIDs})
+}
+
+// handleResume 解除暂停
+func (s *Server) handleResume(c *gin.Context) {
+	var req pauseRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	traderIDs, ok := s.pauseTargets(c, req.Scope)
+	if !ok {
+		return
+	}
+	for _, traderID := range traderIDs {
+		if err := trader.ClearTradingPause(s.database, traderID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	log.Printf("▶️ %s 解除暂停（trader_id=%v）", queueOperator(c), traderIDs)
+	c.JSON(http.StatusOK, gin.H{"message": "已恢复交易，下一决策周期生效", "trader_ids": traderIDs})
+}
+
+// handleGetWatchOnly 仅观察币种
+func (s *Server) handleGetWatchOnly(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "symbols": at.GetWatchOnlySymbols()})
+}
+
+// handleUpdateWatchOnly 设置仅观察币种（完整分析但不开仓，空列表=不限制）
+func (s *Server) handleUpdateWatchOnly(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Symbols []string `json:"symbols"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	symbols, err := at.SetWatchOnlySymbols(req.Symbols)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "symbols": symbols})
+}
+
+// handleListTradeIdeas 交易想法收件箱（可按 status 筛选）
+func (s *Server) handleListTradeIdeas(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "ideas": at.GetTradeIdeas(c.Query("status"))})
+}
+
+// handleSubmitTradeIdea 提交外部交易想法（下一个决策周期由AI评估，接受且通过风控后才执行）
+func (s *Server) handleSubmitTradeIdea(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Symbol    string `json:"symbol" binding:"required"`
+		Direction string `json:"direction" binding:"required"`
+		Thesis    string `json:"thesis" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	idea, err := at.SubmitTradeIdea(req.Symbol, req.Direction, req.Thesis, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"trader_id": traderID, "idea": idea})
+}
+
+// handleCancelTradeIdea 撤回尚未评估的交易想法
+func (s *Server) handleCancelTradeIdea(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	idea, err := at.CancelTradeIdea(c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "idea": idea})
+}
+
+// handleListPostMortems 亏损复盘包列表及触发规则
+func (s *Server) handleListPostMortems(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id":   traderID,
+		"rules":       at.GetPostMortemRules(),
+		"postmortems": at.GetPostMortems(),
+	})
+}
+
+// handleGetPostMortem 复盘包详情（交易、决策、行情快照、信心度校准及AI建议）
+func (s *Server) handleGetPostMortem(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	pm, err := at.GetPostMortem(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "postmortem": pm})
+}
+
+// handleGetTradeChart 交易图表PNG（决策记录中的 chart 文件名）
+func (s *Server) handleGetTradeChart(c *gin.Context) {
+	at, _, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	data, err := at.GetTradeChart(c.Query("file"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "image/png", data)
+}
+
+// handleReviewPostMortem 人工审核复盘建议
+func (s *Server) handleReviewPostMortem(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Status string `json:"status" binding:"required"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	pm, err := at.ReviewPostMortem(c.Param("id"), req.Status, c.GetString("user_id"), req.Note)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "postmortem": pm})
+}
+
+// handleGetRiskPersona 当前风险偏好档位及可选档位
+func (s *Server) handleGetRiskPersona(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id": traderID,
+		"persona":   at.GetRiskPersona(),
+		"available": decision.RiskPersonas(),
+	})
+}
+
+// handleUpdateRiskPersona 切换风险偏好档位（空字符串=使用全局设置）
+func (s *Server) handleUpdateRiskPersona(c *gin.Context) {
+	at, traderID, ok := s.queueTrader(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Persona string `json:"persona"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := at.SetRiskPersona(req.Persona); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("🎚 [%s] 风险偏好档位已更新: %s", traderID, req.Persona)
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "persona": at.GetRiskPersona()})
+}
+
+// handleEventBusStats 事件总线各主题发布数及订阅者处理/丢弃统计
+func (s *Server) handleEventBusStats(c *gin.Context) {
+	c.JSON(http.StatusOK, events.Default.Stats())
+}
+
+// handleModelConformance 各模型+模板组合的输出格式合规统计（JSON修复、后备解析、验证拒绝）
+func (s *Server) handleModelConformance(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"stats": decision.GetConformanceStats(),
+	})
+}
+
+// handlePoolHealth 币种池数据源健康状态（延迟、上次成功时间、结构校验、降级情况）
+func (s *Server) handlePoolHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"sources": pool.GetSourceHealth(),
+	})
+}
+
+// handleSchedulerMetrics 决策调度器指标（全局并发与各trader排队深度）
+func (s *Server) handleSchedulerMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"schedulers": decision.GetSchedulerMetrics(),
+	})
+}
+
+// handleMarketWSHealth 行情WebSocket各分片健康状态
+func (s *Server) handleMarketWSHealth(c *gin.Context) {
+	if market.WSMonitorCli == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "行情监控未启动"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"shards": market.WSMonitorCli.GetShardHealth(),
+	})
+}
+
 // authMiddleware JWT认证中间件
 func (s *Server) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -2019,17 +3563,94 @@ func (s *Server) Start() error {
 	log.Printf("  • DELETE /api/traders/:id    - 删除AI交易员")
 	log.Printf("  • POST /api/traders/:id/start - 启动AI交易员")
 	log.Printf("  • POST /api/traders/:id/stop  - 停止AI交易员")
+	log.Printf("  • POST /api/traders/:id/clone - 克隆AI交易员（复制配置，决策日志和统计从零开始）")
+	log.Printf("  • GET  /api/traders/:id/export - 导出交易员配置包（签名JSON，不含密钥）")
+	log.Printf("  • POST /api/traders/import - 校验签名后导入配置包创建新交易员")
 	log.Printf("  • GET  /api/models           - 获取AI模型配置")
 	log.Printf("  • PUT  /api/models           - 更新AI模型配置")
 	log.Printf("  • GET  /api/exchanges        - 获取交易所配置")
 	log.Printf("  • PUT  /api/exchanges        - 更新交易所配置")
+	log.Printf("  • GET  /api/traders/summary         - 所有交易员汇总（状态/净值/日盈亏/持仓数/最近决策/健康标记）")
 	log.Printf("  • GET  /api/status?trader_id=xxx     - 指定trader的系统状态")
 	log.Printf("  • GET  /api/account?trader_id=xxx    - 指定trader的账户信息")
 	log.Printf("  • GET  /api/positions?trader_id=xxx  - 指定trader的持仓列表")
+	log.Printf("  • GET  /api/positions/watch?trader_id=xxx - 持仓优先刷新状态（强平距离、止损状态、逆向波动ATR倍数）")
 	log.Printf("  • GET  /api/decisions?trader_id=xxx  - 指定trader的决策日志")
 	log.Printf("  • GET  /api/decisions/latest?trader_id=xxx - 指定trader的最新决策")
+	log.Printf("  • GET  /api/decisions/tags?trader_id=xxx&signal=RSI&timeframe=4h&level=support - 按理由标签检索决策及标签统计")
+	log.Printf("  • GET  /api/decisions/detail?trader_id=xxx&id=xxx - 决策记录详情及其产生的订单")
+	log.Printf("  • GET  /api/decisions/by-order?trader_id=xxx&order_id=xxx - 按订单ID（或client_order_id）追溯决策及AI理由")
 	log.Printf("  • GET  /api/statistics?trader_id=xxx - 指定trader的统计信息")
 	log.Printf("  • GET  /api/performance?trader_id=xxx - 指定trader的AI学习表现分析")
+	log.Printf("  • GET  /api/trades/replay?trader_id=xxx&symbol=xxx&open_time=xxx&close_time=xxx - 单笔交易回放数据")
+	log.Printf("  • GET  /api/settlements?trader_id=xxx&from=2025-01-01&to=2025-01-31 - 每日结算快照")
+	log.Printf("  • GET  /api/tax-lots?trader_id=xxx&start=2025-01-01&end=2025-12-31&format=csv - 税务批次导出（取得/处置配对、持有期）")
+	log.Printf("  • GET  /api/candidates?trader_id=xxx - 指定trader的当前候选币种及来源")
+	log.Printf("  • GET  /api/correlation?trader_id=xxx&interval=4h - 持仓与候选币种的收益率相关性矩阵")
+	log.Printf("  • GET  /api/indicators?trader_id=xxx - 指定trader的指标集配置")
+	log.Printf("  • PUT  /api/indicators?trader_id=xxx - 更新指标集（类型、周期、数据来源、K线周期）")
+	log.Printf("  • GET  /api/timeframes?trader_id=xxx - 指定trader的分析周期（附带可选周期和风格预设）")
+	log.Printf("  • PUT  /api/timeframes?trader_id=xxx - 更新分析周期（preset: scalper/intraday/swing 或 timeframes 列表）")
+	log.Printf("  • GET  /api/timeframes/reliability?trader_id=xxx - 各币种各周期趋势信号的实际命中率")
+	log.Printf("  • GET  /api/prompt/changes?trader_id=xxx&limit=50 - System Prompt 变更时间线（差异、修改人、原因）")
+	log.Printf("  • GET  /api/log-levels - 各子系统日志级别及采样抑制数")
+	log.Printf("  • PUT  /api/log-levels - 运行时调整子系统日志级别（market/decision/executor/ws）")
+	log.Printf("  • GET  /api/execution/quality?trader_id=xxx&days=7 - 指定trader的成交滑点与Maker挂单统计")
+	log.Printf("  • GET  /api/model/quality?trader_id=xxx - 指定trader的模型质量问题统计")
+	log.Printf("  • POST /api/risk/preview?trader_id=xxx - 待执行决策的风险预览（人工审批前）")
+	log.Printf("  • POST /api/simulate?trader_id=xxx - 沙盒模拟：假设账户（净值、持仓）下对指定币种的决策建议及风险检查")
+	log.Printf("  • GET  /api/decisions/queue?trader_id=xxx - 决策队列（待审批/执行中/已结束）及操作日志")
+	log.Printf("  • POST /api/decisions/queue/:id/cancel?trader_id=xxx  - 取消待审批决策")
+	log.Printf("  • POST /api/decisions/queue/:id/edit?trader_id=xxx    - 修改待审批开仓决策的仓位/杠杆（需通过风控检查）")
+	log.Printf("  • POST /api/decisions/queue/:id/execute?trader_id=xxx - 批准并执行待审批决策")
+	log.Printf("  • GET  /api/notifications/prefs?trader_id=xxx - 指定trader的通知偏好及推送统计")
+	log.Printf("  • PUT  /api/notifications/prefs?trader_id=xxx - 更新通知偏好")
+	log.Printf("  • GET  /api/sampling?trader_id=xxx - 模型采样参数（temperature/top_p/max_tokens/seed）")
+	log.Printf("  • PUT  /api/sampling?trader_id=xxx - 更新模型采样参数")
+	log.Printf("  • GET  /api/funding/entry-rule?trader_id=xxx - 资金费结算前不逆费率开仓的窗口（分钟）")
+	log.Printf("  • PUT  /api/funding/entry-rule?trader_id=xxx - 更新资金费开仓窗口（0=关闭）")
+	log.Printf("  • GET  /api/tool-budget?trader_id=xxx - 模型每周期可调用数据工具（K线/盘口/市场数据）的次数")
+	log.Printf("  • PUT  /api/tool-budget?trader_id=xxx - 更新数据工具调用次数（0=关闭）")
+	log.Printf("  • POST /api/alerts/trigger?trader_id=xxx - 警报触发该币种的定向决策周期（警报详情注入prompt）")
+	log.Printf("  • GET  /api/alerts/cycles?trader_id=xxx - 警报定向周期统计（排队/执行/冷却忽略）")
+	log.Printf("  • GET  /api/persona?trader_id=xxx - 当前风险偏好档位及可选档位")
+	log.Printf("  • PUT  /api/persona?trader_id=xxx - 切换风险偏好档位（同时调整提示词和风控上限）")
+	log.Printf("  • GET  /api/circuit-breaker?trader_id=xxx - 熔断状态及剩余冷却时间（重启后继续生效）")
+	log.Printf("  • POST /api/circuit-breaker/reset?trader_id=xxx - 人工解除熔断冷却")
+	log.Printf("  • GET  /api/equity-guard?trader_id=xxx - 净值异常跳变保护状态（未确认前冻结新开仓）")
+	log.Printf("  • PUT  /api/equity-guard?trader_id=xxx - 更新净值跳变告警阈值（%%，0=关闭）")
+	log.Printf("  • POST /api/equity-guard/acknowledge?trader_id=xxx - 确认净值异常并恢复开仓")
+	log.Printf("  • GET  /api/maintenance?trader_id=xxx - 交易所维护状态及维护期间暂缓的管理操作")
+	log.Printf("  • GET  /api/maintenance-windows - 已录入的交易所维护窗口")
+	log.Printf("  • POST /api/maintenance-windows - 录入交易所维护公告（期间暂停下单，结束后对账）")
+	log.Printf("  • DELETE /api/maintenance-windows/:id - 删除维护窗口")
+	log.Printf("  • GET  /api/pause?trader_id=xxx - 全局和交易员的暂停状态")
+	log.Printf("  • POST /api/pause - 暂停交易（scope=global 本用户全部交易员/trader，mode=entries/freeze，必填原因代码，可定时自动恢复）")
+	log.Printf("  • POST /api/pause/resume - 解除暂停")
+	log.Printf("  • GET  /api/watch-only?trader_id=xxx - 仅观察币种（完整分析，开仓决策自动转为wait）")
+	log.Printf("  • PUT  /api/watch-only?trader_id=xxx - 设置仅观察币种")
+	log.Printf("  • GET  /api/trade-ideas?trader_id=xxx - 交易想法收件箱及AI评估结果（可按status筛选）")
+	log.Printf("  • POST /api/trade-ideas?trader_id=xxx - 提交交易想法（AI评估接受且通过风控后执行）")
+	log.Printf("  • POST /api/trade-ideas/:id/cancel?trader_id=xxx - 撤回尚未评估的交易想法")
+	log.Printf("  • GET  /api/postmortems?trader_id=xxx - 连续亏损/日亏损触发的复盘包列表")
+	log.Printf("  • GET  /api/postmortems/:id?trader_id=xxx - 复盘包详情（交易、决策、行情、信心度校准、AI建议）")
+	log.Printf("  • POST /api/postmortems/:id/review?trader_id=xxx - 人工审核复盘建议（accepted/dismissed）")
+	log.Printf("  • GET  /api/trade-chart?trader_id=xxx&file=xxx - 成交时生成的交易图表PNG（文件名见决策记录 chart 字段）")
+	log.Printf("  • GET  /api/model/conformance   - 各模型+模板的输出格式合规统计")
+	log.Printf("  • GET  /api/events/stats        - 事件总线统计（kline.closed/decision.created/order.filled/risk.breached）")
+	log.Printf("  • GET  /api/templates/rollout - 当前模板灰度状态（灰度组/对照组合规率和收益率）")
+	log.Printf("  • POST /api/templates/rollout - 按比例或指定交易员灰度新模板，自动推广或回滚")
+	log.Printf("  • POST /api/templates/rollout/abort - 中止模板灰度并恢复原模板")
+	log.Printf("  • GET  /api/capital/rebalance - 资金再平衡计划和调整日志")
+	log.Printf("  • PUT  /api/capital/rebalance - 保存资金再平衡计划（fixed/performance）并立即执行一次")
+	log.Printf("  • POST /api/capital/rebalance/run - 立即执行资金再平衡")
+	log.Printf("  • POST /api/capital/rebalance/disable - 停用资金再平衡计划")
+	log.Printf("  • GET  /api/pool/health         - 币种池数据源健康状态")
+	log.Printf("  • GET  /api/scheduler/metrics   - 决策调度器排队指标")
+	log.Printf("  • GET  /api/metrics/dashboard   - 内置指标看板数据（决策耗时、AI费用、盈亏、WS健康）")
+	log.Printf("  • GET  /dashboard/metrics       - 内置指标看板页面")
+	log.Printf("  • GET  /api/market/ws-health    - 行情WebSocket分片健康状态")
+	log.Printf("  • GET  /api/market/indicators/export?symbol=xxx&interval=1h&start=xxx&end=xxx - 历史指标导出(CSV)")
 	log.Println()
 
 	return s.router.Run(addr)
@@ -2044,7 +3665,8 @@ func (s *Server) handleGetPromptTemplates(c *gin.Context) {
 	response := make([]map[string]interface{}, 0, len(templates))
 	for _, tmpl := range templates {
 		response = append(response, map[string]interface{}{
-			"name": tmpl.Name,
+			"name":    tmpl.Name,
+			"extends": tmpl.Extends,
 		})
 	}
 
@@ -2066,6 +3688,8 @@ func (s *Server) handleGetPromptTemplate(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"name":    template.Name,
 		"content": template.Content,
+		"extends": template.Extends,
+		"blocks":  template.Blocks,
 	})
 }
 
@@ -2269,9 +3893,9 @@ func (s *Server) handleGetPublicTraderConfig(c *gin.Context) {
 		"trader_name": trader.GetName(),
 		"ai_model":    trader.GetAIModel(),
 		"exchange":    trader.GetExchange(),
-		"is_running":  status["is_running"],
-		"ai_provider": status["ai_provider"],
-		"start_time":  status["start_time"],
+		"is_running":  status.IsRunning,
+		"ai_provider": status.AIProvider,
+		"start_time":  status.StartTime,
 	}
 
 	c.JSON(http.StatusOK, result)
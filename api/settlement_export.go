@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"nofx/logger"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseSettlementDate 解析 YYYY-MM-DD（本地时区），为空时使用默认值
+func parseSettlementDate(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return time.ParseInLocation(logger.SettlementDateLayout, value, time.Local)
+}
+
+// handleSettlements 每日结算快照（收盘净值、已实现盈亏、手续费、资金费）
+// 参数：trader_id, from, to（YYYY-MM-DD，默认最近30天）
+func (s *Server) handleSettlements(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	today := time.Now()
+	from, err := parseSettlementDate(c.Query("from"), today.AddDate(0, 0, -30))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("from无效: %v", err)})
+		return
+	}
+	to, err := parseSettlementDate(c.Query("to"), today)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("to无效: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id":   traderID,
+		"settlements": trader.GetSettlements(from.Format(logger.SettlementDateLayout), to.Format(logger.SettlementDateLayout)),
+	})
+}
+
+// handleTaxLots 税务批次导出：按取得/处置配对的已平仓交易及持有期
+// 参数：trader_id, start, end（YYYY-MM-DD，含两端，默认本年度）, format（json / csv，默认 json）
+func (s *Server) handleTaxLots(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	start, err := parseSettlementDate(c.Query("start"), time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("start无效: %v", err)})
+		return
+	}
+	end, err := parseSettlementDate(c.Query("end"), now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("end无效: %v", err)})
+		return
+	}
+	y, m, d := end.Date()
+	end = time.Date(y, m, d, 0, 0, 0, 0, time.Local).AddDate(0, 0, 1).Add(-time.Nanosecond)
+	if !end.After(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end必须晚于start"})
+		return
+	}
+
+	lots, err := trader.GetTaxLots(start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("生成税务批次失败: %v", err)})
+		return
+	}
+
+	switch format := c.DefaultQuery("format", "json"); format {
+	case "json":
+		c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "lots": lots})
+	case "csv":
+		filename := fmt.Sprintf("%s_tax_lots_%s_%s.csv", traderID, start.Format("20060102"), end.Format("20060102"))
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		if err := logger.WriteTaxLotCSV(c.Writer, lots); err != nil {
+			c.Error(err)
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("不支持的导出格式: %s（json / csv）", format)})
+	}
+}
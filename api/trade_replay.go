@@ -0,0 +1,223 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReplayMarker 回放图上的决策标记
+type ReplayMarker struct {
+	Time        time.Time `json:"time"`
+	CycleNumber int       `json:"cycle_number"`
+	Action      string    `json:"action"`
+	Price       float64   `json:"price"`
+	Quantity    float64   `json:"quantity"`
+	Leverage    int       `json:"leverage"`
+	Success     bool      `json:"success"`
+	Status      string    `json:"status,omitempty"` // executed / rejected / failed / skipped
+	Error       string    `json:"error,omitempty"`
+	Reasoning   string    `json:"reasoning,omitempty"` // AI给出的该决策理由
+}
+
+// ReplaySnapshot 开仓/平仓时刻的分析快照
+type ReplaySnapshot struct {
+	CycleNumber    int                       `json:"cycle_number"`
+	Timestamp      time.Time                 `json:"timestamp"`
+	CoTTrace       string                    `json:"cot_trace"`       // 该周期AI思维链
+	Reasoning      string                    `json:"reasoning"`       // 该币种决策理由
+	AccountState   logger.AccountSnapshot    `json:"account_state"`   // 账户快照
+	Positions      []logger.PositionSnapshot `json:"positions"`       // 持仓快照
+	CandidateCoins []string                  `json:"candidate_coins"` // 候选币种
+}
+
+// TradeReplay 单笔交易回放数据
+type TradeReplay struct {
+	TraderID      string          `json:"trader_id"`
+	Symbol        string          `json:"symbol"`
+	OpenTime      time.Time       `json:"open_time"`
+	CloseTime     time.Time       `json:"close_time"`
+	Interval      string          `json:"interval"`
+	Candles       []market.Kline  `json:"candles"`
+	Markers       []ReplayMarker  `json:"markers"`
+	EntrySnapshot *ReplaySnapshot `json:"entry_snapshot"`
+	ExitSnapshot  *ReplaySnapshot `json:"exit_snapshot"`
+}
+
+// replayInterval 根据持仓时长选择K线周期，保证图上K线数量适中
+func replayInterval(duration time.Duration) (string, time.Duration) {
+	switch {
+	case duration <= 10*time.Hour:
+		return "3m", 3 * time.Minute
+	case duration <= 48*time.Hour:
+		return "15m", 15 * time.Minute
+	case duration <= 8*24*time.Hour:
+		return "1h", time.Hour
+	default:
+		return "4h", 4 * time.Hour
+	}
+}
+
+// parseReplayTime 解析时间参数（支持 RFC3339 和毫秒时间戳）
+func parseReplayTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("时间参数不能为空")
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// decisionReasonings 从决策JSON中提取 symbol+action -> reasoning
+func decisionReasonings(record *logger.DecisionRecord) map[string]string {
+	result := make(map[string]string)
+	if record.DecisionJSON == "" {
+		return result
+	}
+	var decisions []decision.Decision
+	if err := json.Unmarshal([]byte(record.DecisionJSON), &decisions); err != nil {
+		return result
+	}
+	for _, d := range decisions {
+		result[d.Symbol+"|"+d.Action] = d.Reasoning
+	}
+	return result
+}
+
+// buildReplaySnapshot 生成分析快照
+func buildReplaySnapshot(record *logger.DecisionRecord, reasoning string) *ReplaySnapshot {
+	return &ReplaySnapshot{
+		CycleNumber:    record.CycleNumber,
+		Timestamp:      record.Timestamp,
+		CoTTrace:       record.CoTTrace,
+		Reasoning:      reasoning,
+		AccountState:   record.AccountState,
+		Positions:      record.Positions,
+		CandidateCoins: record.CandidateCoins,
+	}
+}
+
+// handleTradeReplay 交易回放数据：K线 + 决策标记 + 开/平仓时刻的分析快照
+// 参数：trader_id, symbol, open_time, close_time（可直接使用 /api/performance 返回的 recent_trades 字段）
+func (s *Server) handleTradeReplay(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	symbol := strings.ToUpper(c.Query("symbol"))
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少symbol参数"})
+		return
+	}
+	openTime, err := parseReplayTime(c.Query("open_time"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("open_time无效: %v", err)})
+		return
+	}
+	closeTime, err := parseReplayTime(c.Query("close_time"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("close_time无效: %v", err)})
+		return
+	}
+	if !closeTime.After(openTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "close_time必须晚于open_time"})
+		return
+	}
+
+	// 前后各留20根K线作为上下文
+	interval, step := replayInterval(closeTime.Sub(openTime))
+	padding := 20 * step
+	windowStart := openTime.Add(-padding)
+	windowEnd := closeTime.Add(padding)
+
+	candles, err := market.NewAPIClient().GetKlinesRange(symbol, interval, windowStart.UnixMilli(), windowEnd.UnixMilli())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取K线失败: %v", err)})
+		return
+	}
+
+	records, err := trader.GetDecisionLogger().GetRecordsBetween(windowStart, windowEnd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取决策日志失败: %v", err)})
+		return
+	}
+
+	replay := TradeReplay{
+		TraderID:  traderID,
+		Symbol:    symbol,
+		OpenTime:  openTime,
+		CloseTime: closeTime,
+		Interval:  interval,
+		Candles:   candles,
+		Markers:   []ReplayMarker{},
+	}
+
+	// 开/平仓快照取与交易时间最接近的那条成功动作所在周期
+	var entryDiff, exitDiff time.Duration = -1, -1
+	for _, record := range records {
+		reasonings := decisionReasonings(record)
+		for _, action := range record.Decisions {
+			if action.Symbol != symbol {
+				continue
+			}
+			reasoning := reasonings[action.Symbol+"|"+action.Action]
+			replay.Markers = append(replay.Markers, ReplayMarker{
+				Time:        action.Timestamp,
+				CycleNumber: record.CycleNumber,
+				Action:      action.Action,
+				Price:       action.Price,
+				Quantity:    action.Quantity,
+				Leverage:    action.Leverage,
+				Success:     action.Success,
+				Status:      action.Status,
+				Error:       action.Error,
+				Reasoning:   reasoning,
+			})
+
+			if !action.Success {
+				continue
+			}
+			switch {
+			case strings.HasPrefix(action.Action, "open_"):
+				diff := absDuration(action.Timestamp.Sub(openTime))
+				if entryDiff < 0 || diff < entryDiff {
+					entryDiff = diff
+					replay.EntrySnapshot = buildReplaySnapshot(record, reasoning)
+				}
+			case strings.Contains(action.Action, "close"):
+				diff := absDuration(action.Timestamp.Sub(closeTime))
+				if exitDiff < 0 || diff < exitDiff {
+					exitDiff = diff
+					replay.ExitSnapshot = buildReplaySnapshot(record, reasoning)
+				}
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, replay)
+}
+
+// absDuration 时长绝对值
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,47 @@
+package backtest
+
+import (
+	"fmt"
+	"nofx/market"
+	"time"
+)
+
+// oiPeriods 币安历史持仓量支持的统计周期
+var oiPeriods = []string{"5m", "15m", "30m", "1h", "2h", "4h", "6h", "12h", "1d"}
+
+// LoadData 获取回测所需的K线，以及按选项需要的资金费率和历史持仓量
+func LoadData(symbol, interval string, start, end time.Time, opts Options) (Data, error) {
+	data := Data{Symbol: symbol}
+	klines, err := market.FetchKlinesBetween(symbol, interval, start, end)
+	if err != nil {
+		return data, fmt.Errorf("获取K线失败: %w", err)
+	}
+	data.Klines, _ = market.SanitizeKlines(klines, interval)
+
+	if opts.ModelFunding {
+		if data.Funding, err = market.FetchFundingBetween(symbol, start, end); err != nil {
+			return data, fmt.Errorf("获取资金费率历史失败: %w", err)
+		}
+	}
+	if opts.OICapPct > 0 {
+		if data.OpenInterest, err = market.FetchOpenInterestBetween(symbol, oiPeriod(interval), start, end); err != nil {
+			return data, fmt.Errorf("获取持仓量历史失败: %w", err)
+		}
+	}
+	return data, nil
+}
+
+// oiPeriod 选择不大于K线周期的最大持仓量统计周期（最小5m）
+func oiPeriod(interval string) string {
+	step, err := market.IntervalDuration(interval)
+	if err != nil {
+		return oiPeriods[0]
+	}
+	period := oiPeriods[0]
+	for _, p := range oiPeriods {
+		if d, _ := market.IntervalDuration(p); d <= step {
+			period = p
+		}
+	}
+	return period
+}
@@ -0,0 +1,411 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"nofx/market"
+	"sort"
+)
+
+// 成交方式
+const (
+	LiquidityTaker = "taker" // 市价成交（默认）
+	LiquidityMaker = "maker" // 限价挂单，未成交则放弃
+	LiquidityAuto  = "auto"  // 先挂限价单，超时未成交转市价
+)
+
+// 同一根K线同时触及止损和止盈时的判定规则
+const (
+	TieBreakWorstCase = "worst_case" // 按止损处理（保守，默认）
+	TieBreakOHLCPath  = "ohlc_path"  // 按K线路径推断：阳线 O→L→H→C，阴线 O→H→L→C
+)
+
+// 止损/止盈触发价位的成交假设
+const (
+	FillOnTouch = "touch" // 价格触及即成交（默认）
+	FillOnCross = "cross" // 价格需穿过价位才算成交（仅触及不成交，更接近限价止盈的实际情况）
+)
+
+// 平仓成交假设（记录在 Trade.ExitAssumption 中，便于评估回测与实盘的偏差来源）
+const (
+	AssumptionGap          = "gap"                 // 跳空越过价位，按开盘价成交
+	AssumptionTouch        = "touch"               // 极值恰好等于价位，按触及成交
+	AssumptionCross        = "cross"               // 价格穿过价位
+	AssumptionBothWorst    = "both_hit_worst_case" // 同一根K线同时触及止损止盈，按止损处理
+	AssumptionBothOHLCPath = "both_hit_ohlc_path"  // 同一根K线同时触及止损止盈，按K线路径推断先后
+)
+
+// Signal 回测信号（与 AI 决策动作一致：open_long / open_short / close）
+type Signal struct {
+	Time       int64   `json:"time"` // 信号时间（毫秒），在之后第一根K线开盘执行
+	Action     string  `json:"action"`
+	SizeUSD    float64 `json:"size_usd"`
+	StopLoss   float64 `json:"stop_loss"`
+	TakeProfit float64 `json:"take_profit"`
+	LimitPrice float64 `json:"limit_price"` // 挂单价格（0=信号K线开盘价）
+}
+
+// Data 单币种回测数据
+type Data struct {
+	Symbol       string                `json:"symbol"`
+	Klines       []market.Kline        `json:"klines"`
+	Funding      []market.FundingPoint `json:"funding"`       // 资金费率结算记录（ModelFunding 时使用）
+	OpenInterest []market.OIPoint      `json:"open_interest"` // 历史持仓量（OICapPct > 0 时使用）
+}
+
+// Options 回测真实性选项（默认值对应旧的理想化回测：全部市价成交、无资金费、无流动性限制）
+type Options struct {
+	TakerFeeRate     float64 `json:"taker_fee_rate"`     // 默认 0.04%
+	MakerFeeRate     float64 `json:"maker_fee_rate"`     // 默认 0.02%
+	Liquidity        string  `json:"liquidity"`          // taker / maker / auto
+	MakerWaitCandles int     `json:"maker_wait_candles"` // 限价单最多等待K线数（默认3）
+	ModelFunding     bool    `json:"model_funding"`      // 计入持仓期间的资金费
+	OICapPct         float64 `json:"oi_cap_pct"`         // 单笔成交最多占持仓价值的百分比（0=不限制）
+	IntrabarTieBreak string  `json:"intrabar_tie_break"` // worst_case / ohlc_path
+	StopFill         string  `json:"stop_fill"`          // 止损/止盈成交假设：touch / cross
+}
+
+// Trade 回测成交记录
+type Trade struct {
+	Side           string  `json:"side"`
+	EntryTime      int64   `json:"entry_time"`
+	ExitTime       int64   `json:"exit_time"`
+	EntryPrice     float64 `json:"entry_price"`
+	ExitPrice      float64 `json:"exit_price"`
+	Quantity       float64 `json:"quantity"`
+	Liquidity      string  `json:"liquidity"` // 入场成交方式 maker / taker
+	Capped         bool    `json:"capped"`    // 仓位被持仓量流动性上限截断
+	ExitReason     string  `json:"exit_reason"`
+	ExitAssumption string  `json:"exit_assumption,omitempty"` // 止损止盈的成交假设 gap / touch / cross / both_hit_*
+	GrossPnL       float64 `json:"gross_pnl"`
+	Fees           float64 `json:"fees"`
+	Funding        float64 `json:"funding"` // 资金费收支（正=收入）
+	NetPnL         float64 `json:"net_pnl"`
+}
+
+// Result 回测结果
+type Result struct {
+	Symbol         string  `json:"symbol"`
+	Options        Options `json:"options"`
+	Trades         []Trade `json:"trades"`
+	GrossPnL       float64 `json:"gross_pnl"`
+	Fees           float64 `json:"fees"`
+	Funding        float64 `json:"funding"`
+	NetPnL         float64 `json:"net_pnl"`
+	MakerFills     int     `json:"maker_fills"`
+	TakerFills     int     `json:"taker_fills"`
+	MissedFills    int     `json:"missed_fills"` // maker 模式下未成交放弃的信号
+	CappedFills    int     `json:"capped_fills"`
+	SkippedCount   int     `json:"skipped_count"`   // 已有持仓或无K线可执行的信号
+	AmbiguousExits int     `json:"ambiguous_exits"` // 同一根K线同时触及止损和止盈（结果取决于判定规则）
+	TouchExits     int     `json:"touch_exits"`     // 仅触及价位即按成交处理（cross 模式下不会成交）
+}
+
+// position 回测中的持仓
+type position struct {
+	trade      Trade
+	stopLoss   float64
+	takeProfit float64
+}
+
+func (o *Options) applyDefaults() {
+	if o.TakerFeeRate <= 0 {
+		o.TakerFeeRate = 0.0004
+	}
+	if o.MakerFeeRate <= 0 {
+		o.MakerFeeRate = 0.0002
+	}
+	if o.Liquidity == "" {
+		o.Liquidity = LiquidityTaker
+	}
+	if o.MakerWaitCandles <= 0 {
+		o.MakerWaitCandles = 3
+	}
+	if o.IntrabarTieBreak == "" {
+		o.IntrabarTieBreak = TieBreakWorstCase
+	}
+	if o.StopFill == "" {
+		o.StopFill = FillOnTouch
+	}
+}
+
+// Run 按信号在历史K线上模拟交易（同一时间只持有一个仓位）
+// 止损/止盈按K线高低价判断，同一根K线同时触及时按 IntrabarTieBreak 规则处理（默认按止损）
+func Run(data Data, signals []Signal, opts Options) (*Result, error) {
+	opts.applyDefaults()
+	switch opts.Liquidity {
+	case LiquidityTaker, LiquidityMaker, LiquidityAuto:
+	default:
+		return nil, fmt.Errorf("无效的成交方式: %s", opts.Liquidity)
+	}
+	switch opts.IntrabarTieBreak {
+	case TieBreakWorstCase, TieBreakOHLCPath:
+	default:
+		return nil, fmt.Errorf("无效的同K线判定规则: %s", opts.IntrabarTieBreak)
+	}
+	switch opts.StopFill {
+	case FillOnTouch, FillOnCross:
+	default:
+		return nil, fmt.Errorf("无效的止损止盈成交假设: %s", opts.StopFill)
+	}
+	if len(data.Klines) == 0 {
+		return nil, fmt.Errorf("没有K线数据")
+	}
+
+	signals = append([]Signal(nil), signals...)
+	sort.SliceStable(signals, func(i, j int) bool { return signals[i].Time < signals[j].Time })
+
+	result := &Result{Symbol: data.Symbol, Options: opts, Trades: []Trade{}}
+	klines := data.Klines
+	var pos *position
+	next := 0
+
+	for i := 0; i < len(klines); i++ {
+		k := klines[i]
+
+		// 执行到期信号（在本根K线开盘执行）
+		for next < len(signals) && signals[next].Time <= k.OpenTime {
+			sig := signals[next]
+			next++
+			switch sig.Action {
+			case "close":
+				if pos == nil {
+					result.SkippedCount++
+					continue
+				}
+				result.closePosition(pos, k.OpenTime, k.Open, "signal", data, opts)
+				pos = nil
+			case "open_long", "open_short":
+				if pos != nil || sig.SizeUSD <= 0 {
+					result.SkippedCount++
+					continue
+				}
+				pos = result.openPosition(sig, klines, i, data, opts)
+			default:
+				result.SkippedCount++
+			}
+		}
+
+		if pos == nil || k.OpenTime < pos.trade.EntryTime {
+			continue
+		}
+		if exit, hit := checkExit(pos, k, opts); hit {
+			switch exit.assumption {
+			case AssumptionBothWorst, AssumptionBothOHLCPath:
+				result.AmbiguousExits++
+			case AssumptionTouch:
+				result.TouchExits++
+			}
+			pos.trade.ExitAssumption = exit.assumption
+			result.closePosition(pos, k.CloseTime, exit.price, exit.reason, data, opts)
+			pos = nil
+		}
+	}
+
+	result.SkippedCount += len(signals) - next
+	if pos != nil {
+		last := klines[len(klines)-1]
+		result.closePosition(pos, last.CloseTime, last.Close, "end_of_data", data, opts)
+	}
+	return result, nil
+}
+
+// openPosition 按成交方式模拟入场；maker 未成交返回 nil
+func (r *Result) openPosition(sig Signal, klines []market.Kline, i int, data Data, opts Options) *position {
+	side := "long"
+	if sig.Action == "open_short" {
+		side = "short"
+	}
+
+	entryIdx, entryPrice, liquidity := i, klines[i].Open, LiquidityTaker
+	if opts.Liquidity != LiquidityTaker {
+		limit := sig.LimitPrice
+		if limit <= 0 {
+			limit = klines[i].Open
+		}
+		filled := false
+		for j := i; j < len(klines) && j < i+opts.MakerWaitCandles; j++ {
+			// 价格需穿过挂单价才算成交（仅触及时队列位置未知）
+			if (side == "long" && klines[j].Low < limit) || (side == "short" && klines[j].High > limit) {
+				entryIdx, entryPrice, liquidity, filled = j, limit, LiquidityMaker, true
+				break
+			}
+		}
+		if !filled {
+			last := i + opts.MakerWaitCandles - 1
+			if opts.Liquidity == LiquidityMaker || last >= len(klines) {
+				r.MissedFills++
+				return nil
+			}
+			entryIdx, entryPrice = last, klines[last].Close
+		}
+	}
+
+	qty := sig.SizeUSD / entryPrice
+	capped := false
+	if opts.OICapPct > 0 {
+		if oi := oiAt(data.OpenInterest, klines[entryIdx].OpenTime); oi > 0 {
+			if maxQty := oi * opts.OICapPct / 100 / entryPrice; qty > maxQty {
+				qty, capped = maxQty, true
+				r.CappedFills++
+			}
+		}
+	}
+
+	feeRate := opts.TakerFeeRate
+	if liquidity == LiquidityMaker {
+		feeRate = opts.MakerFeeRate
+		r.MakerFills++
+	} else {
+		r.TakerFills++
+	}
+
+	entryTime := klines[entryIdx].OpenTime
+	if entryIdx != i || liquidity == LiquidityMaker {
+		entryTime = klines[entryIdx].CloseTime // 等待挂单成交的K线内不再检查止损止盈
+	}
+	return &position{trade: Trade{
+		Side:       side,
+		EntryTime:  entryTime,
+		EntryPrice: entryPrice,
+		Quantity:   qty,
+		Liquidity:  liquidity,
+		Capped:     capped,
+		Fees:       qty * entryPrice * feeRate,
+	}, stopLoss: sig.StopLoss, takeProfit: sig.TakeProfit}
+}
+
+// exitFill 止损/止盈触发结果
+type exitFill struct {
+	price      float64
+	reason     string
+	assumption string
+}
+
+// checkExit 检查K线是否触发止损/止盈
+// 开盘跳空越过价位按开盘价成交；同时触及时按判定规则决定先后
+func checkExit(pos *position, k market.Kline, opts Options) (exitFill, bool) {
+	long := pos.trade.Side == "long"
+	stop, take := pos.stopLoss, pos.takeProfit
+
+	// 多头止损/空头止盈在K线下方，多头止盈/空头止损在K线上方
+	below, above := stop, take
+	if !long {
+		below, above = take, stop
+	}
+	exitBelow := func(assumption string) exitFill {
+		price := math.Min(below, k.Open)
+		if long {
+			return exitFill{price, "stop_loss", assumption}
+		}
+		return exitFill{price, "take_profit", assumption}
+	}
+	exitAbove := func(assumption string) exitFill {
+		price := math.Max(above, k.Open)
+		if long {
+			return exitFill{price, "take_profit", assumption}
+		}
+		return exitFill{price, "stop_loss", assumption}
+	}
+
+	// 跳空：开盘价已越过价位
+	if below > 0 && k.Open <= below {
+		return exitBelow(AssumptionGap), true
+	}
+	if above > 0 && k.Open >= above {
+		return exitAbove(AssumptionGap), true
+	}
+
+	hitBelow := below > 0 && (k.Low < below || (opts.StopFill == FillOnTouch && k.Low == below))
+	hitAbove := above > 0 && (k.High > above || (opts.StopFill == FillOnTouch && k.High == above))
+
+	switch {
+	case hitBelow && hitAbove:
+		if opts.IntrabarTieBreak == TieBreakOHLCPath {
+			// 阳线先到低点再到高点，阴线先到高点再到低点
+			if k.Close >= k.Open {
+				return exitBelow(AssumptionBothOHLCPath), true
+			}
+			return exitAbove(AssumptionBothOHLCPath), true
+		}
+		if long {
+			return exitBelow(AssumptionBothWorst), true
+		}
+		return exitAbove(AssumptionBothWorst), true
+	case hitBelow:
+		return exitBelow(touchOrCross(k.Low == below)), true
+	case hitAbove:
+		return exitAbove(touchOrCross(k.High == above)), true
+	}
+	return exitFill{}, false
+}
+
+// touchOrCross 极值恰好等于价位时记为触及成交
+func touchOrCross(touched bool) string {
+	if touched {
+		return AssumptionTouch
+	}
+	return AssumptionCross
+}
+
+// closePosition 平仓并累计结果（平仓均按 Taker 计费：止损止盈为市价触发单）
+func (r *Result) closePosition(pos *position, exitTime int64, exitPrice float64, reason string, data Data, opts Options) {
+	t := pos.trade
+	t.ExitTime = exitTime
+	t.ExitPrice = exitPrice
+	t.ExitReason = reason
+
+	t.GrossPnL = (exitPrice - t.EntryPrice) * t.Quantity
+	if t.Side == "short" {
+		t.GrossPnL = -t.GrossPnL
+	}
+	t.Fees += t.Quantity * exitPrice * opts.TakerFeeRate
+	if opts.ModelFunding {
+		t.Funding = fundingBetween(t, data)
+	}
+	t.NetPnL = t.GrossPnL - t.Fees + t.Funding
+
+	r.Trades = append(r.Trades, t)
+	r.GrossPnL += t.GrossPnL
+	r.Fees += t.Fees
+	r.Funding += t.Funding
+	r.NetPnL += t.NetPnL
+}
+
+// fundingBetween 持仓期间的资金费（费率为正时多头支付、空头收取），按结算时刻所在K线收盘价计算仓位价值
+func fundingBetween(t Trade, data Data) float64 {
+	total := 0.0
+	for _, f := range data.Funding {
+		if f.Time <= t.EntryTime || f.Time > t.ExitTime {
+			continue
+		}
+		payment := t.Quantity * priceAt(data.Klines, f.Time) * f.Rate
+		if t.Side == "long" {
+			total -= payment
+		} else {
+			total += payment
+		}
+	}
+	return total
+}
+
+// priceAt 时刻所在K线的收盘价
+func priceAt(klines []market.Kline, ts int64) float64 {
+	idx := sort.Search(len(klines), func(i int) bool { return klines[i].OpenTime > ts }) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return klines[idx].Close
+}
+
+// oiAt 时刻之前最近一次的持仓价值（USDT）
+func oiAt(points []market.OIPoint, ts int64) float64 {
+	value := 0.0
+	for _, p := range points {
+		if p.Time > ts {
+			break
+		}
+		value = p.ValueUSD
+	}
+	return value
+}
@@ -0,0 +1,173 @@
+package backtest
+
+import (
+	"math"
+	"nofx/market"
+	"testing"
+)
+
+const hourMs = int64(3600 * 1000)
+
+// flatKlines 生成价格恒定的小时K线（open=close=price，high/low=price±1）
+func flatKlines(n int, price float64) []market.Kline {
+	klines := make([]market.Kline, n)
+	for i := range klines {
+		open := int64(i) * hourMs
+		klines[i] = market.Kline{OpenTime: open, CloseTime: open + hourMs - 1, Open: price, High: price + 1, Low: price - 1, Close: price, Volume: 100}
+	}
+	return klines
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestRunRealismOptions(t *testing.T) {
+	open := Signal{Time: 0, Action: "open_long", SizeUSD: 1000}
+	closeSig := Signal{Time: 10 * hourMs, Action: "close"}
+	funding := []market.FundingPoint{{Time: 8 * hourMs, Rate: 0.001}}
+
+	tests := []struct {
+		name        string
+		opts        Options
+		oi          []market.OIPoint
+		wantQty     float64
+		wantFees    float64
+		wantFunding float64
+		wantMaker   int
+		wantCapped  int
+	}{
+		{"默认理想化：市价成交无资金费", Options{}, nil, 10, 0.8, 0, 0, 0},
+		{"计入资金费：多头支付", Options{ModelFunding: true}, nil, 10, 0.8, -1, 0, 0},
+		{"持仓量上限截断仓位", Options{OICapPct: 1}, []market.OIPoint{{Time: 0, ValueUSD: 50000}}, 5, 0.4, 0, 0, 1},
+		{"挂单成交按Maker计费", Options{Liquidity: LiquidityMaker}, nil, 10, 0.6, 0, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := Data{Symbol: "BTCUSDT", Klines: flatKlines(12, 100), Funding: funding, OpenInterest: tt.oi}
+			result, err := Run(data, []Signal{open, closeSig}, tt.opts)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if len(result.Trades) != 1 {
+				t.Fatalf("trades = %d, want 1", len(result.Trades))
+			}
+			trade := result.Trades[0]
+			if !almostEqual(trade.Quantity, tt.wantQty) {
+				t.Errorf("quantity = %v, want %v", trade.Quantity, tt.wantQty)
+			}
+			if !almostEqual(trade.Fees, tt.wantFees) {
+				t.Errorf("fees = %v, want %v", trade.Fees, tt.wantFees)
+			}
+			if !almostEqual(trade.Funding, tt.wantFunding) {
+				t.Errorf("funding = %v, want %v", trade.Funding, tt.wantFunding)
+			}
+			if result.MakerFills != tt.wantMaker || result.CappedFills != tt.wantCapped {
+				t.Errorf("maker/capped = %d/%d, want %d/%d", result.MakerFills, result.CappedFills, tt.wantMaker, tt.wantCapped)
+			}
+			if !almostEqual(result.NetPnL, trade.GrossPnL-trade.Fees+trade.Funding) {
+				t.Errorf("net pnl = %v, inconsistent with trade", result.NetPnL)
+			}
+		})
+	}
+}
+
+func TestRunMakerMissedAndAutoFallback(t *testing.T) {
+	klines := flatKlines(10, 100)
+	// 挂单价远低于行情，始终无法成交
+	sig := Signal{Time: 0, Action: "open_long", SizeUSD: 1000, LimitPrice: 90}
+
+	result, err := Run(Data{Klines: klines}, []Signal{sig}, Options{Liquidity: LiquidityMaker})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Trades) != 0 || result.MissedFills != 1 {
+		t.Errorf("maker: trades = %d, missed = %d, want 0/1", len(result.Trades), result.MissedFills)
+	}
+
+	result, err = Run(Data{Klines: klines}, []Signal{sig}, Options{Liquidity: LiquidityAuto})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Trades) != 1 || result.TakerFills != 1 || result.Trades[0].Liquidity != LiquidityTaker {
+		t.Errorf("auto: trades = %d, taker fills = %d, want fallback to taker", len(result.Trades), result.TakerFills)
+	}
+}
+
+func TestRunStopBeforeTakeProfitInSameCandle(t *testing.T) {
+	klines := flatKlines(5, 100)
+	klines[2].High, klines[2].Low = 120, 80
+
+	sig := Signal{Time: 0, Action: "open_long", SizeUSD: 1000, StopLoss: 90, TakeProfit: 110}
+	result, err := Run(Data{Klines: klines}, []Signal{sig}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Trades) != 1 {
+		t.Fatalf("trades = %d, want 1", len(result.Trades))
+	}
+	if trade := result.Trades[0]; trade.ExitReason != "stop_loss" || trade.ExitPrice != 90 || trade.ExitAssumption != AssumptionBothWorst {
+		t.Errorf("exit = %s @ %v (%s), want stop_loss @ 90 (%s)", trade.ExitReason, trade.ExitPrice, trade.ExitAssumption, AssumptionBothWorst)
+	}
+	if result.AmbiguousExits != 1 {
+		t.Errorf("ambiguous exits = %d, want 1", result.AmbiguousExits)
+	}
+}
+
+func TestRunIntrabarExitAssumptions(t *testing.T) {
+	tests := []struct {
+		name           string
+		side           string
+		candle         market.Kline // 第3根K线（持仓期间）
+		opts           Options
+		wantReason     string
+		wantPrice      float64
+		wantAssumption string
+	}{
+		{"阳线按路径先到低点", "open_long", market.Kline{Open: 100, High: 120, Low: 80, Close: 115}, Options{IntrabarTieBreak: TieBreakOHLCPath}, "stop_loss", 90, AssumptionBothOHLCPath},
+		{"阴线按路径先到高点", "open_long", market.Kline{Open: 100, High: 120, Low: 80, Close: 85}, Options{IntrabarTieBreak: TieBreakOHLCPath}, "take_profit", 110, AssumptionBothOHLCPath},
+		{"空头最坏情况按止损", "open_short", market.Kline{Open: 100, High: 120, Low: 80, Close: 85}, Options{}, "stop_loss", 110, AssumptionBothWorst},
+		{"跳空低开按开盘价止损", "open_long", market.Kline{Open: 85, High: 86, Low: 84, Close: 85}, Options{}, "stop_loss", 85, AssumptionGap},
+		{"恰好触及止盈按触及成交", "open_long", market.Kline{Open: 100, High: 110, Low: 99, Close: 105}, Options{}, "take_profit", 110, AssumptionTouch},
+		{"穿过止盈", "open_long", market.Kline{Open: 100, High: 112, Low: 99, Close: 105}, Options{StopFill: FillOnCross}, "take_profit", 110, AssumptionCross},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			klines := flatKlines(5, 100)
+			k := tt.candle
+			k.OpenTime, k.CloseTime = klines[2].OpenTime, klines[2].CloseTime
+			klines[2] = k
+
+			sig := Signal{Time: 0, Action: tt.side, SizeUSD: 1000, StopLoss: 90, TakeProfit: 110}
+			if tt.side == "open_short" {
+				sig.StopLoss, sig.TakeProfit = 110, 90
+			}
+			result, err := Run(Data{Klines: klines}, []Signal{sig}, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			trade := result.Trades[0]
+			if trade.ExitReason != tt.wantReason || trade.ExitPrice != tt.wantPrice || trade.ExitAssumption != tt.wantAssumption {
+				t.Errorf("exit = %s @ %v (%s), want %s @ %v (%s)", trade.ExitReason, trade.ExitPrice, trade.ExitAssumption, tt.wantReason, tt.wantPrice, tt.wantAssumption)
+			}
+		})
+	}
+}
+
+func TestRunCrossFillIgnoresTouch(t *testing.T) {
+	klines := flatKlines(5, 100)
+	klines[2].High = 110 // 恰好触及止盈
+
+	sig := Signal{Time: 0, Action: "open_long", SizeUSD: 1000, TakeProfit: 110}
+	touch, _ := Run(Data{Klines: klines}, []Signal{sig}, Options{})
+	cross, _ := Run(Data{Klines: klines}, []Signal{sig}, Options{StopFill: FillOnCross})
+
+	if touch.Trades[0].ExitReason != "take_profit" || touch.TouchExits != 1 {
+		t.Errorf("touch: exit = %s, touch exits = %d", touch.Trades[0].ExitReason, touch.TouchExits)
+	}
+	if cross.Trades[0].ExitReason != "end_of_data" || cross.TouchExits != 0 {
+		t.Errorf("cross: exit = %s, touch exits = %d, want end_of_data", cross.Trades[0].ExitReason, cross.TouchExits)
+	}
+}
@@ -0,0 +1,278 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"nofx/market"
+)
+
+// fibRatios 从波段端点回撤的斐波那契比例
+var fibRatios = []float64{0.382, 0.5, 0.618}
+
+// AnalyzerParams 价格结构分析器参数（波段回看、支撑/阻力区间阈值、斐波那契灵敏度）
+type AnalyzerParams struct {
+	SwingLookback    int     `json:"swing_lookback"`     // 波段高低点回看K线数
+	ZoneLookback     int     `json:"zone_lookback"`      // 支撑/阻力区间回看K线数
+	ZoneThresholdPct float64 `json:"zone_threshold_pct"` // 收盘价位于区间底部/顶部该百分比内视为触及支撑/阻力
+	FibTolerancePct  float64 `json:"fib_tolerance_pct"`  // 收盘价距斐波那契回撤位该百分比内视为命中
+}
+
+// ParamGrid 参数搜索网格
+type ParamGrid struct {
+	SwingLookbacks []int     `json:"swing_lookbacks"`
+	ZoneLookbacks  []int     `json:"zone_lookbacks"`
+	ZoneThresholds []float64 `json:"zone_thresholds"`
+	FibTolerances  []float64 `json:"fib_tolerances"`
+}
+
+// DefaultParamGrid 默认搜索网格（中间值与指标导出使用的 fibLookback=50 / zoneLookback=20 一致）
+func DefaultParamGrid() ParamGrid {
+	return ParamGrid{
+		SwingLookbacks: []int{30, 50, 80},
+		ZoneLookbacks:  []int{10, 20, 40},
+		ZoneThresholds: []float64{10, 20, 30},
+		FibTolerances:  []float64{0.2, 0.5, 1.0},
+	}
+}
+
+// combinations 展开网格
+func (g ParamGrid) combinations() []AnalyzerParams {
+	var result []AnalyzerParams
+	for _, swing := range g.SwingLookbacks {
+		for _, zone := range g.ZoneLookbacks {
+			for _, threshold := range g.ZoneThresholds {
+				for _, tol := range g.FibTolerances {
+					result = append(result, AnalyzerParams{swing, zone, threshold, tol})
+				}
+			}
+		}
+	}
+	return result
+}
+
+// AnalyzerSignals 按分析器参数生成信号：
+// 收盘价靠近区间支撑且命中波段回撤位 → 做多（止损在支撑/波段低点下方，止盈在阻力）；阻力处反之
+func AnalyzerSignals(klines []market.Kline, p AnalyzerParams, sizeUSD float64) []Signal {
+	warmup := p.SwingLookback
+	if p.ZoneLookback > warmup {
+		warmup = p.ZoneLookback
+	}
+	var signals []Signal
+	for i := warmup; i < len(klines); i++ {
+		k := klines[i]
+		resistance, support := windowHighLow(klines, i, p.ZoneLookback)
+		swingHigh, swingLow := windowHighLow(klines, i, p.SwingLookback)
+		if resistance <= support || swingHigh <= swingLow || k.Close <= 0 {
+			continue
+		}
+		zonePct := (k.Close - support) / (resistance - support) * 100
+		swing := swingHigh - swingLow
+
+		switch {
+		case zonePct <= p.ZoneThresholdPct && nearAny(k.Close, swingHigh, -swing, p.FibTolerancePct):
+			signals = append(signals, Signal{
+				Time: k.CloseTime + 1, Action: "open_long", SizeUSD: sizeUSD,
+				StopLoss: math.Min(support, swingLow) * 0.998, TakeProfit: resistance,
+			})
+		case zonePct >= 100-p.ZoneThresholdPct && nearAny(k.Close, swingLow, swing, p.FibTolerancePct):
+			signals = append(signals, Signal{
+				Time: k.CloseTime + 1, Action: "open_short", SizeUSD: sizeUSD,
+				StopLoss: math.Max(resistance, swingHigh) * 1.002, TakeProfit: support,
+			})
+		}
+	}
+	return signals
+}
+
+// nearAny 价格是否在 origin + move×ratio 的任一回撤位附近
+func nearAny(price, origin, move, tolerancePct float64) bool {
+	for _, ratio := range fibRatios {
+		level := origin + move*ratio
+		if math.Abs(price-level)/price*100 <= tolerancePct {
+			return true
+		}
+	}
+	return false
+}
+
+// windowHighLow 截至第 i 根K线（含）最近 lookback 根K线的最高价和最低价
+func windowHighLow(klines []market.Kline, i, lookback int) (float64, float64) {
+	start := i - lookback + 1
+	if start < 0 {
+		start = 0
+	}
+	high, low := klines[start].High, klines[start].Low
+	for j := start + 1; j <= i; j++ {
+		high = math.Max(high, klines[j].High)
+		low = math.Min(low, klines[j].Low)
+	}
+	return high, low
+}
+
+// WalkForwardConfig 滚动前推验证配置
+type WalkForwardConfig struct {
+	TrainCandles int     `json:"train_candles"` // 样本内窗口K线数
+	TestCandles  int     `json:"test_candles"`  // 样本外窗口K线数（每折向前滚动该长度）
+	SizeUSD      float64 `json:"size_usd"`      // 每笔仓位价值
+	Options      Options `json:"options"`       // 回测真实性选项
+}
+
+// WalkForwardFold 单折结果
+type WalkForwardFold struct {
+	Index          int            `json:"index"`
+	TrainStart     int64          `json:"train_start"`
+	TestStart      int64          `json:"test_start"`
+	TestEnd        int64          `json:"test_end"`
+	Best           AnalyzerParams `json:"best"`
+	InSamplePnL    float64        `json:"in_sample_pnl"`
+	OutOfSamplePnL float64        `json:"out_of_sample_pnl"`
+	TestTrades     int            `json:"test_trades"`
+}
+
+// WalkForwardReport 优化报告
+type WalkForwardReport struct {
+	Symbol         string             `json:"symbol"`
+	Folds          []WalkForwardFold  `json:"folds"`
+	Selected       AnalyzerParams     `json:"selected"`      // 最终选用参数（各折最常被选中的参数）
+	Stability      float64            `json:"stability"`     // 选用参数被选中的折数占比（0~1）
+	ParamCV        map[string]float64 `json:"param_cv"`      // 各参数在各折最优值上的变异系数（越小越稳定）
+	InSamplePnL    float64            `json:"in_sample_pnl"` // 各折样本内净利合计
+	OutOfSamplePnL float64            `json:"out_of_sample_pnl"`
+	Efficiency     float64            `json:"efficiency"` // 样本外/样本内净利（前推效率）
+}
+
+// WalkForward 滚动前推优化：每折在样本内窗口上网格搜索净利最高的参数，再在紧随其后的样本外窗口上验证
+func WalkForward(data Data, grid ParamGrid, cfg WalkForwardConfig) (*WalkForwardReport, error) {
+	if cfg.TrainCandles <= 0 || cfg.TestCandles <= 0 {
+		return nil, fmt.Errorf("样本内/样本外窗口必须大于0")
+	}
+	if cfg.SizeUSD <= 0 {
+		cfg.SizeUSD = 1000
+	}
+	candidates := grid.combinations()
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("参数网格为空")
+	}
+	ranges := walkForwardFolds(len(data.Klines), cfg.TrainCandles, cfg.TestCandles)
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("K线不足：至少需要 %d 根，当前 %d 根", cfg.TrainCandles+cfg.TestCandles, len(data.Klines))
+	}
+
+	report := &WalkForwardReport{Symbol: data.Symbol}
+	for idx, r := range ranges {
+		window := data.Klines[r[0]:r[2]]
+		testStart := data.Klines[r[1]].OpenTime
+		train := data
+		train.Klines = data.Klines[r[0]:r[1]]
+		test := data
+		test.Klines = data.Klines[r[1]:r[2]]
+
+		fold := WalkForwardFold{Index: idx, TrainStart: window[0].OpenTime, TestStart: testStart, TestEnd: window[len(window)-1].CloseTime}
+		bestPnL := math.Inf(-1)
+		var bestTest []Signal
+		for _, p := range candidates {
+			// 整个窗口一起生成信号，样本外起点的指标可以使用样本内的历史K线
+			trainSignals, testSignals := splitSignals(AnalyzerSignals(window, p, cfg.SizeUSD), testStart)
+			result, err := Run(train, trainSignals, cfg.Options)
+			if err != nil {
+				return nil, err
+			}
+			if result.NetPnL > bestPnL {
+				bestPnL = result.NetPnL
+				fold.Best = p
+				bestTest = testSignals
+			}
+		}
+		fold.InSamplePnL = bestPnL
+
+		result, err := Run(test, bestTest, cfg.Options)
+		if err != nil {
+			return nil, err
+		}
+		fold.OutOfSamplePnL = result.NetPnL
+		fold.TestTrades = len(result.Trades)
+		report.Folds = append(report.Folds, fold)
+	}
+
+	summarizeFolds(report)
+	return report, nil
+}
+
+// walkForwardFolds 计算每折的 [样本内起点, 样本外起点, 样本外终点) 下标
+func walkForwardFolds(n, train, test int) [][3]int {
+	var ranges [][3]int
+	for start := 0; start+train+test <= n; start += test {
+		ranges = append(ranges, [3]int{start, start + train, start + train + test})
+	}
+	return ranges
+}
+
+// splitSignals 按样本外起点拆分信号
+func splitSignals(signals []Signal, testStart int64) (train, test []Signal) {
+	for _, s := range signals {
+		if s.Time < testStart {
+			train = append(train, s)
+		} else {
+			test = append(test, s)
+		}
+	}
+	return train, test
+}
+
+// summarizeFolds 汇总各折：选出最常被选中的参数（同票数取样本外净利合计更高者），计算稳定性
+func summarizeFolds(report *WalkForwardReport) {
+	votes := make(map[AnalyzerParams]int)
+	oos := make(map[AnalyzerParams]float64)
+	var swing, zone, threshold, tol []float64
+	for _, f := range report.Folds {
+		votes[f.Best]++
+		oos[f.Best] += f.OutOfSamplePnL
+		report.InSamplePnL += f.InSamplePnL
+		report.OutOfSamplePnL += f.OutOfSamplePnL
+		swing = append(swing, float64(f.Best.SwingLookback))
+		zone = append(zone, float64(f.Best.ZoneLookback))
+		threshold = append(threshold, f.Best.ZoneThresholdPct)
+		tol = append(tol, f.Best.FibTolerancePct)
+	}
+	if len(report.Folds) == 0 {
+		return
+	}
+
+	bestVotes := 0
+	for _, f := range report.Folds {
+		p := f.Best
+		if votes[p] > bestVotes || (votes[p] == bestVotes && oos[p] > oos[report.Selected]) {
+			report.Selected, bestVotes = p, votes[p]
+		}
+	}
+	report.Stability = float64(bestVotes) / float64(len(report.Folds))
+	report.ParamCV = map[string]float64{
+		"swing_lookback":     coefficientOfVariation(swing),
+		"zone_lookback":      coefficientOfVariation(zone),
+		"zone_threshold_pct": coefficientOfVariation(threshold),
+		"fib_tolerance_pct":  coefficientOfVariation(tol),
+	}
+	if report.InSamplePnL > 0 {
+		report.Efficiency = report.OutOfSamplePnL / report.InSamplePnL
+	}
+}
+
+// coefficientOfVariation 变异系数（标准差/均值）
+func coefficientOfVariation(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	if mean == 0 {
+		return 0
+	}
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(variance/float64(len(values))) / math.Abs(mean)
+}
@@ -0,0 +1,55 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWalkForwardFolds(t *testing.T) {
+	tests := []struct {
+		name      string
+		n         int
+		wantFolds int
+	}{
+		{"不足一折", 120, 0},
+		{"恰好一折", 150, 1},
+		{"按样本外长度滚动", 260, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folds := walkForwardFolds(tt.n, 100, 50)
+			if len(folds) != tt.wantFolds {
+				t.Fatalf("folds = %d, want %d", len(folds), tt.wantFolds)
+			}
+			for i, f := range folds {
+				if f[1]-f[0] != 100 || f[2]-f[1] != 50 || f[0] != i*50 {
+					t.Errorf("fold %d = %v", i, f)
+				}
+			}
+		})
+	}
+}
+
+func TestSummarizeFoldsStability(t *testing.T) {
+	a := AnalyzerParams{SwingLookback: 50, ZoneLookback: 20, ZoneThresholdPct: 20, FibTolerancePct: 0.5}
+	b := AnalyzerParams{SwingLookback: 80, ZoneLookback: 20, ZoneThresholdPct: 20, FibTolerancePct: 0.5}
+	report := &WalkForwardReport{Folds: []WalkForwardFold{
+		{Best: a, InSamplePnL: 100, OutOfSamplePnL: 20},
+		{Best: b, InSamplePnL: 100, OutOfSamplePnL: 50},
+		{Best: a, InSamplePnL: 100, OutOfSamplePnL: 10},
+	}}
+	summarizeFolds(report)
+
+	if report.Selected != a {
+		t.Errorf("selected = %+v, want %+v", report.Selected, a)
+	}
+	if math.Abs(report.Stability-2.0/3) > 1e-9 {
+		t.Errorf("stability = %v, want 2/3", report.Stability)
+	}
+	if math.Abs(report.Efficiency-80.0/300) > 1e-9 {
+		t.Errorf("efficiency = %v, want %v", report.Efficiency, 80.0/300)
+	}
+	if report.ParamCV["zone_lookback"] != 0 || report.ParamCV["swing_lookback"] <= 0 {
+		t.Errorf("param cv = %v", report.ParamCV)
+	}
+}
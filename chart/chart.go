@@ -0,0 +1,266 @@
+// Package chart 交易图表快照：K线 + 支撑/阻力区域 + 入场/止损/止盈标记，输出PNG（仅依赖标准库，用于通知和交易日志复盘）
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"nofx/market"
+)
+
+const (
+	defaultWidth  = 800
+	defaultHeight = 450
+	marginLeft    = 10
+	marginRight   = 90 // 右侧价格刻度
+	marginY       = 20
+)
+
+var (
+	colorBackground = color.RGBA{0x13, 0x17, 0x22, 0xff}
+	colorGrid       = color.RGBA{0x2a, 0x2e, 0x39, 0xff}
+	colorUp         = color.RGBA{0x26, 0xa6, 0x9a, 0xff}
+	colorDown       = color.RGBA{0xef, 0x53, 0x50, 0xff}
+	colorEntry      = color.RGBA{0x42, 0xa5, 0xf5, 0xff}
+	colorExit       = color.RGBA{0xff, 0xca, 0x28, 0xff}
+	colorText       = color.RGBA{0xd1, 0xd4, 0xdc, 0xff}
+	colorSupport    = color.RGBA{0x26, 0xa6, 0x9a, 0x40}
+	colorResistance = color.RGBA{0xef, 0x53, 0x50, 0x40}
+)
+
+// 区域类型
+const (
+	ZoneSupport    = "support"
+	ZoneResistance = "resistance"
+)
+
+// Zone 价格区域（支撑/阻力）
+type Zone struct {
+	Low  float64
+	High float64
+	Kind string // support / resistance
+}
+
+// TradeMarkers 交易标记（价格为0的标记不绘制）
+type TradeMarkers struct {
+	Side       string // long / short
+	Entry      float64
+	StopLoss   float64
+	TakeProfit float64
+	Exit       float64 // 平仓价（平仓图表）
+	Zones      []Zone
+}
+
+// Options 图表尺寸（零值使用 800x450）
+type Options struct {
+	Width  int
+	Height int
+}
+
+// RenderTrade 绘制交易图表：K线（旧 → 新）、区域和交易价位线，最后一根K线右侧标出入场方向
+func RenderTrade(klines []market.Kline, markers TradeMarkers, opts Options) ([]byte, error) {
+	if len(klines) == 0 {
+		return nil, fmt.Errorf("没有K线数据")
+	}
+	if opts.Width <= 0 {
+		opts.Width = defaultWidth
+	}
+	if opts.Height <= 0 {
+		opts.Height = defaultHeight
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	fillRect(img, img.Bounds(), colorBackground)
+
+	low, high := priceRange(klines, markers)
+	c := &canvas{
+		img:   img,
+		low:   low,
+		high:  high,
+		top:   marginY,
+		bot:   opts.Height - marginY,
+		left:  marginLeft,
+		right: opts.Width - marginRight,
+	}
+
+	c.drawGrid()
+	for _, z := range markers.Zones {
+		c.drawZone(z)
+	}
+	c.drawCandles(klines)
+	c.drawLevel(markers.TakeProfit, colorUp, true)
+	c.drawLevel(markers.StopLoss, colorDown, true)
+	c.drawLevel(markers.Entry, colorEntry, false)
+	c.drawLevel(markers.Exit, colorExit, false)
+	c.drawEntryArrow(markers)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("编码PNG失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// priceRange 价格纵轴范围（包含全部K线和标记价位，上下留5%边距）
+func priceRange(klines []market.Kline, markers TradeMarkers) (float64, float64) {
+	low, high := math.MaxFloat64, -math.MaxFloat64
+	include := func(p float64) {
+		if p <= 0 {
+			return
+		}
+		low = math.Min(low, p)
+		high = math.Max(high, p)
+	}
+	for _, k := range klines {
+		include(k.Low)
+		include(k.High)
+	}
+	for _, p := range []float64{markers.Entry, markers.StopLoss, markers.TakeProfit, markers.Exit} {
+		include(p)
+	}
+	for _, z := range markers.Zones {
+		include(z.Low)
+		include(z.High)
+	}
+	if high <= low {
+		high = low*1.01 + 1e-9
+		low = low * 0.99
+	}
+	pad := (high - low) * 0.05
+	return low - pad, high + pad
+}
+
+type canvas struct {
+	img                   *image.RGBA
+	low, high             float64
+	top, bot, left, right int
+}
+
+// y 价格对应的纵坐标
+func (c *canvas) y(price float64) int {
+	ratio := (price - c.low) / (c.high - c.low)
+	return c.bot - int(math.Round(ratio*float64(c.bot-c.top)))
+}
+
+func (c *canvas) drawGrid() {
+	const lines = 5
+	for i := 0; i <= lines; i++ {
+		price := c.low + (c.high-c.low)*float64(i)/lines
+		y := c.y(price)
+		for x := c.left; x < c.right; x += 4 {
+			c.img.Set(x, y, colorGrid)
+		}
+		drawText(c.img, c.right+6, y-5, formatPrice(price), colorText)
+	}
+}
+
+func (c *canvas) drawZone(z Zone) {
+	if z.Low <= 0 || z.High <= 0 {
+		return
+	}
+	clr := colorSupport
+	if z.Kind == ZoneResistance {
+		clr = colorResistance
+	}
+	y1, y2 := c.y(z.High), c.y(z.Low)
+	if y2-y1 < 2 {
+		y1, y2 = y1-1, y1+1
+	}
+	blendRect(c.img, image.Rect(c.left, y1, c.right, y2+1), clr)
+}
+
+func (c *canvas) drawCandles(klines []market.Kline) {
+	slot := float64(c.right-c.left-20) / float64(len(klines))
+	body := int(math.Max(1, slot*0.6))
+	for i, k := range klines {
+		clr := colorUp
+		if k.Close < k.Open {
+			clr = colorDown
+		}
+		cx := c.left + int(slot*float64(i)+slot/2)
+		vline(c.img, cx, c.y(k.High), c.y(k.Low), clr)
+		top, bottom := c.y(math.Max(k.Open, k.Close)), c.y(math.Min(k.Open, k.Close))
+		fillRect(c.img, image.Rect(cx-body/2, top, cx-body/2+body, bottom+1), clr)
+	}
+}
+
+// drawLevel 水平价位线（dashed 为虚线）并在右侧刻度标出价格
+func (c *canvas) drawLevel(price float64, clr color.RGBA, dashed bool) {
+	if price <= 0 {
+		return
+	}
+	y := c.y(price)
+	for x := c.left; x < c.right; x++ {
+		if dashed && (x/6)%2 == 1 {
+			continue
+		}
+		c.img.Set(x, y, clr)
+	}
+	fillRect(c.img, image.Rect(c.right+2, y-7, c.img.Bounds().Dx(), y+8), clr)
+	drawText(c.img, c.right+6, y-5, formatPrice(price), colorBackground)
+}
+
+// drawEntryArrow 在最后一根K线右侧标出入场方向（多 ▲ / 空 ▼）
+func (c *canvas) drawEntryArrow(markers TradeMarkers) {
+	if markers.Entry <= 0 {
+		return
+	}
+	x, y := c.right-10, c.y(markers.Entry)
+	// 多单：入场线下方 ▲；空单：入场线上方 ▼
+	for i := 0; i < 7; i++ {
+		dy := 2 + i
+		if markers.Side == "short" {
+			dy = -dy
+		}
+		for dx := -i; dx <= i; dx++ {
+			c.img.Set(x+dx, y+dy, colorEntry)
+		}
+	}
+}
+
+func fillRect(img *image.RGBA, r image.Rectangle, clr color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, clr)
+		}
+	}
+}
+
+// blendRect 半透明填充（按 clr.A 混合）
+func blendRect(img *image.RGBA, r image.Rectangle, clr color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	alpha := float64(clr.A) / 255
+	mix := func(dst, src uint8) uint8 {
+		return uint8(float64(dst)*(1-alpha) + float64(src)*alpha)
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			dst := img.RGBAAt(x, y)
+			img.SetRGBA(x, y, color.RGBA{mix(dst.R, clr.R), mix(dst.G, clr.G), mix(dst.B, clr.B), 0xff})
+		}
+	}
+}
+
+func vline(img *image.RGBA, x, y1, y2 int, clr color.RGBA) {
+	if y1 > y2 {
+		y1, y2 = y2, y1
+	}
+	for y := y1; y <= y2; y++ {
+		img.Set(x, y, clr)
+	}
+}
+
+// formatPrice 刻度价格（按量级保留有效数字）
+func formatPrice(price float64) string {
+	switch {
+	case price >= 1000:
+		return fmt.Sprintf("%.1f", price)
+	case price >= 1:
+		return fmt.Sprintf("%.4f", price)
+	}
+	return fmt.Sprintf("%.6f", price)
+}
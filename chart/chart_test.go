@@ -0,0 +1,57 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"nofx/market"
+	"testing"
+)
+
+func TestRenderTrade(t *testing.T) {
+	var klines []market.Kline
+	for i := 0; i < 60; i++ {
+		base := 100 + float64(i%10)
+		klines = append(klines, market.Kline{Open: base, High: base + 2, Low: base - 2, Close: base + 1})
+	}
+	markers := TradeMarkers{
+		Side: "long", Entry: 105, StopLoss: 95, TakeProfit: 120,
+		Zones: []Zone{{Low: 97, High: 98, Kind: ZoneSupport}, {Low: 111, High: 112, Kind: ZoneResistance}},
+	}
+
+	tests := []struct {
+		name       string
+		opts       Options
+		wantWidth  int
+		wantHeight int
+	}{
+		{"默认尺寸", Options{}, defaultWidth, defaultHeight},
+		{"自定义尺寸", Options{Width: 400, Height: 300}, 400, 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := RenderTrade(klines, markers, tt.opts)
+			if err != nil {
+				t.Fatalf("RenderTrade() error = %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("输出不是有效PNG: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != tt.wantWidth || b.Dy() != tt.wantHeight {
+				t.Errorf("尺寸 = %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+
+	if _, err := RenderTrade(nil, markers, Options{}); err == nil {
+		t.Errorf("没有K线时应返回错误")
+	}
+}
+
+func TestPriceRange(t *testing.T) {
+	klines := []market.Kline{{High: 110, Low: 100}}
+	low, high := priceRange(klines, TradeMarkers{StopLoss: 90, TakeProfit: 130})
+	if low >= 90 || high <= 130 {
+		t.Errorf("priceRange = [%.2f, %.2f], 应包含止损和止盈价位", low, high)
+	}
+}
@@ -0,0 +1,42 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+)
+
+// glyphs 3x5 点阵字形（价格刻度只需要数字、小数点和负号），每行3位
+var glyphs = map[rune][5]uint8{
+	'0': {0b111, 0b101, 0b101, 0b101, 0b111},
+	'1': {0b010, 0b110, 0b010, 0b010, 0b111},
+	'2': {0b111, 0b001, 0b111, 0b100, 0b111},
+	'3': {0b111, 0b001, 0b111, 0b001, 0b111},
+	'4': {0b101, 0b101, 0b111, 0b001, 0b001},
+	'5': {0b111, 0b100, 0b111, 0b001, 0b111},
+	'6': {0b111, 0b100, 0b111, 0b101, 0b111},
+	'7': {0b111, 0b001, 0b010, 0b010, 0b010},
+	'8': {0b111, 0b101, 0b111, 0b101, 0b111},
+	'9': {0b111, 0b101, 0b111, 0b001, 0b111},
+	'.': {0b000, 0b000, 0b000, 0b000, 0b010},
+	'-': {0b000, 0b000, 0b111, 0b000, 0b000},
+}
+
+const glyphScale = 2 // 放大倍数（每个字形 6x10 像素）
+
+// drawText 在 (x, y) 处绘制文本（左上角），不支持的字符留空
+func drawText(img *image.RGBA, x, y int, text string, clr color.RGBA) {
+	for _, ch := range text {
+		glyph, ok := glyphs[ch]
+		if ok {
+			for row := 0; row < 5; row++ {
+				for col := 0; col < 3; col++ {
+					if glyph[row]&(1<<(2-col)) == 0 {
+						continue
+					}
+					fillRect(img, image.Rect(x+col*glyphScale, y+row*glyphScale, x+(col+1)*glyphScale, y+(row+1)*glyphScale), clr)
+				}
+			}
+		}
+		x += 4 * glyphScale
+	}
+}
@@ -0,0 +1,99 @@
+package clock
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock 时间来源（生产使用系统时钟，测试可注入手动时钟使输出可复现）
+type Clock interface {
+	Now() time.Time
+}
+
+// IDGenerator 唯一ID生成器（WebSocket请求ID等）
+type IDGenerator interface {
+	NextID() int64
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// System 系统时钟
+var System Clock = systemClock{}
+
+// Since 按指定时钟计算距 t 经过的时间
+func Since(c Clock, t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Manual 手动时钟：时间只在 Set/Advance 时变化
+type Manual struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManual 创建手动时钟
+func NewManual(t time.Time) *Manual {
+	return &Manual{now: t}
+}
+
+// Now 当前时间
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// Set 设置当前时间
+func (m *Manual) Set(t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = t
+}
+
+// Advance 时间前进 d
+func (m *Manual) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = m.now.Add(d)
+}
+
+// nanoIDs 以时钟纳秒时间戳为ID，同一纳秒（或时钟回拨）时在上一个ID基础上递增，保证单调唯一
+type nanoIDs struct {
+	clock Clock
+	mu    sync.Mutex
+	last  int64
+}
+
+// NewNanoIDs 创建基于时钟的ID生成器
+func NewNanoIDs(c Clock) IDGenerator {
+	return &nanoIDs{clock: c}
+}
+
+func (g *nanoIDs) NextID() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	id := g.clock.Now().UnixNano()
+	if id <= g.last {
+		id = g.last + 1
+	}
+	g.last = id
+	return id
+}
+
+// Sequence 从指定值开始递增的确定性ID生成器（测试用）
+type Sequence struct {
+	next int64
+}
+
+// NewSequence 创建序列ID生成器，第一个ID为 start
+func NewSequence(start int64) *Sequence {
+	return &Sequence{next: start - 1}
+}
+
+// NextID 下一个ID
+func (s *Sequence) NextID() int64 {
+	return atomic.AddInt64(&s.next, 1)
+}
@@ -0,0 +1,40 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNanoIDsMonotonic(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	c := NewManual(start)
+	ids := NewNanoIDs(c)
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		want    int64
+	}{
+		{"首个ID为当前纳秒时间戳", 0, start.UnixNano()},
+		{"同一时刻递增", 0, start.UnixNano() + 1},
+		{"时钟前进后使用新时间戳", time.Second, start.Add(time.Second).UnixNano()},
+		{"时钟回拨仍然递增", -time.Minute, start.Add(time.Second).UnixNano() + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.Advance(tt.advance)
+			if got := ids.NextID(); got != tt.want {
+				t.Errorf("NextID() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSequence(t *testing.T) {
+	s := NewSequence(100)
+	for want := int64(100); want < 103; want++ {
+		if got := s.NextID(); got != want {
+			t.Errorf("NextID() = %d, want %d", got, want)
+		}
+	}
+}
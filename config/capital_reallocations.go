@@ -0,0 +1,63 @@
+package config
+
+import "time"
+
+// CapitalReallocation 一次资金再平衡中单个交易员的分配调整
+type CapitalReallocation struct {
+	UserID       string    `json:"user_id"`
+	TraderID     string    `json:"trader_id"`
+	Mode         string    `json:"mode"` // fixed / performance
+	OldWeightPct float64   `json:"old_weight_pct"`
+	NewWeightPct float64   `json:"new_weight_pct"`
+	OldCapital   float64   `json:"old_capital"`
+	NewCapital   float64   `json:"new_capital"`
+	ReturnPct    float64   `json:"return_pct"` // 回看期内已实现收益率（performance 模式）
+	Reason       string    `json:"reason"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// SaveCapitalReallocations 批量保存资金再平衡日志
+func (d *Database) SaveCapitalReallocations(events []CapitalReallocation) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, e := range events {
+		if _, err := tx.Exec(`
+			INSERT INTO capital_reallocations (user_id, trader_id, mode, old_weight_pct, new_weight_pct,
+				old_capital, new_capital, return_pct, reason)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, e.UserID, e.TraderID, e.Mode, e.OldWeightPct, e.NewWeightPct,
+			e.OldCapital, e.NewCapital, e.ReturnPct, e.Reason); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetCapitalReallocations 获取用户最近的资金再平衡日志（新 → 旧）
+func (d *Database) GetCapitalReallocations(userID string, limit int) ([]CapitalReallocation, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := d.db.Query(`
+		SELECT user_id, trader_id, mode, old_weight_pct, new_weight_pct, old_capital, new_capital,
+			return_pct, reason, created_at
+		FROM capital_reallocations WHERE user_id = ? ORDER BY id DESC LIMIT ?`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []CapitalReallocation{}
+	for rows.Next() {
+		var e CapitalReallocation
+		if err := rows.Scan(&e.UserID, &e.TraderID, &e.Mode, &e.OldWeightPct, &e.NewWeightPct,
+			&e.OldCapital, &e.NewCapital, &e.ReturnPct, &e.Reason, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
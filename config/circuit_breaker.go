@@ -0,0 +1,40 @@
+package config
+
+import (
+	"database/sql"
+	"time"
+)
+
+// CircuitBreakerState 交易员熔断状态（触发时间、冷却到期时间、原因、累计触发次数）
+type CircuitBreakerState struct {
+	TraderID      string    `json:"trader_id"`
+	TrippedAt     time.Time `json:"tripped_at"`
+	CooldownUntil time.Time `json:"cooldown_until"`
+	Reason        string    `json:"reason"`
+	TripCount     int       `json:"trip_count"`
+}
+
+// SaveCircuitBreaker 保存熔断状态
+func (d *Database) SaveCircuitBreaker(state *CircuitBreakerState) error {
+	_, err := d.db.Exec(`
+		INSERT OR REPLACE INTO circuit_breakers (trader_id, tripped_at, cooldown_until, reason, trip_count, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, state.TraderID, state.TrippedAt.UTC(), state.CooldownUntil.UTC(), state.Reason, state.TripCount)
+	return err
+}
+
+// GetCircuitBreaker 获取熔断状态（从未触发返回 nil）
+func (d *Database) GetCircuitBreaker(traderID string) (*CircuitBreakerState, error) {
+	var state CircuitBreakerState
+	err := d.db.QueryRow(`
+		SELECT trader_id, tripped_at, cooldown_until, reason, trip_count
+		FROM circuit_breakers WHERE trader_id = ?
+	`, traderID).Scan(&state.TraderID, &state.TrippedAt, &state.CooldownUntil, &state.Reason, &state.TripCount)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
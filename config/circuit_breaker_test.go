@@ -0,0 +1,36 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerPersistence(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	state, err := db.GetCircuitBreaker("trader-1")
+	if err != nil || state != nil {
+		t.Fatalf("未触发时应返回 nil, got %+v, err %v", state, err)
+	}
+
+	trippedAt := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	want := &CircuitBreakerState{
+		TraderID:      "trader-1",
+		TrippedAt:     trippedAt,
+		CooldownUntil: trippedAt.Add(time.Hour),
+		Reason:        "当日亏损 5.20%",
+		TripCount:     2,
+	}
+	if err := db.SaveCircuitBreaker(want); err != nil {
+		t.Fatalf("保存熔断状态失败: %v", err)
+	}
+
+	got, err := db.GetCircuitBreaker("trader-1")
+	if err != nil || got == nil {
+		t.Fatalf("读取熔断状态失败: %v", err)
+	}
+	if !got.TrippedAt.Equal(want.TrippedAt) || !got.CooldownUntil.Equal(want.CooldownUntil) || got.Reason != want.Reason || got.TripCount != want.TripCount {
+		t.Errorf("熔断状态 = %+v, want %+v", got, want)
+	}
+}
@@ -17,6 +17,13 @@ type LeverageConfig struct {
 type LogConfig struct {
 	Level    string          `json:"level"`    // 日志级别: debug, info, warn, error (默认: info)
 	Telegram *TelegramConfig `json:"telegram"` // Telegram推送配置（可选）
+	Webhook  *WebhookConfig  `json:"webhook"`  // Webhook推送配置（可选）
+}
+
+// WebhookConfig Webhook事件推送配置（JSON POST，交易图表以base64附带）
+type WebhookConfig struct {
+	Enabled bool   `json:"enabled"` // 是否启用（默认: false）
+	URL     string `json:"url"`     // 推送地址
 }
 
 // TelegramConfig Telegram推送配置（简化版，只保留必需字段）
@@ -175,6 +175,60 @@ func (d *Database) createTables() error {
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`,
 
+		// 分析器参数表（滚动前推优化选出的每个币种参数）
+		`CREATE TABLE IF NOT EXISTS analyzer_configs (
+			symbol TEXT PRIMARY KEY,
+			params TEXT NOT NULL,
+			stability REAL DEFAULT 0,
+			out_of_sample_pnl REAL DEFAULT 0,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// 熔断冷却表（每个交易员最近一次熔断及冷却到期时间，重启后继续生效）
+		`CREATE TABLE IF NOT EXISTS circuit_breakers (
+			trader_id TEXT PRIMARY KEY,
+			tripped_at DATETIME NOT NULL,
+			cooldown_until DATETIME NOT NULL,
+			reason TEXT DEFAULT '',
+			trip_count INTEGER DEFAULT 0,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// 决策-订单映射表（从订单追溯到产生它的AI决策及理由）
+		`CREATE TABLE IF NOT EXISTS decision_orders (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			trader_id TEXT NOT NULL,
+			decision_id TEXT NOT NULL,
+			cycle_number INTEGER DEFAULT 0,
+			symbol TEXT NOT NULL,
+			action TEXT NOT NULL,
+			client_order_id TEXT DEFAULT '',
+			exchange_order_id INTEGER DEFAULT 0,
+			order_type TEXT DEFAULT '',
+			status TEXT DEFAULT '',
+			filled_qty REAL DEFAULT 0,
+			avg_price REAL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_decision_orders_decision ON decision_orders(trader_id, decision_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_decision_orders_exchange ON decision_orders(trader_id, exchange_order_id)`,
+
+		// 资金再平衡日志（多个交易员共用一个账户时的资金分配调整）
+		`CREATE TABLE IF NOT EXISTS capital_reallocations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id TEXT NOT NULL,
+			trader_id TEXT NOT NULL,
+			mode TEXT NOT NULL,
+			old_weight_pct REAL DEFAULT 0,
+			new_weight_pct REAL DEFAULT 0,
+			old_capital REAL DEFAULT 0,
+			new_capital REAL DEFAULT 0,
+			return_pct REAL DEFAULT 0,
+			reason TEXT DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_capital_reallocations_user ON capital_reallocations(user_id, created_at)`,
+
 		// 触发器：自动更新 updated_at
 		`CREATE TRIGGER IF NOT EXISTS update_users_updated_at
 			AFTER UPDATE ON users
@@ -294,18 +348,8 @@ func (d *Database) initDefaultData() error {
 	}
 
 	// 初始化系统配置 - 创建所有字段，设置默认值，后续由config.json同步更新
-	systemConfigs := map[string]string{
-		"beta_mode":            "false",                                                                               // 默认关闭内测模式
-		"api_server_port":      "8080",                                                                                // 默认API端口
-		"use_default_coins":    "true",                                                                                // 默认使用内置币种列表
-		"default_coins":        `["BTCUSDT","ETHUSDT","SOLUSDT","BNBUSDT","XRPUSDT","DOGEUSDT","ADAUSDT","HYPEUSDT"]`, // 默认币种列表（JSON格式）
-		"max_daily_loss":       "10.0",                                                                                // 最大日损失百分比
-		"max_drawdown":         "20.0",                                                                                // 最大回撤百分比
-		"stop_trading_minutes": "60",                                                                                  // 停止交易时间（分钟）
-		"btc_eth_leverage":     "5",                                                                                   // BTC/ETH杠杆倍数
-		"altcoin_leverage":     "5",                                                                                   // 山寨币杠杆倍数
-		"jwt_secret":           "",                                                                                    // JWT密钥，默认为空，由config.json或系统生成
-	}
+	// 配置项及默认值在 settings_schema.go 中登记（同时提供给 /api/settings/schema）
+	systemConfigs := systemConfigDefaults()
 
 	for key, value := range systemConfigs {
 		_, err := d.db.Exec(`
@@ -953,6 +997,12 @@ func (d *Database) UpdateTraderCustomPrompt(userID, id string, customPrompt stri
 	return err
 }
 
+// UpdateTraderSystemPromptTemplate 更新交易员系统提示词模板（模板灰度推广时批量写入）
+func (d *Database) UpdateTraderSystemPromptTemplate(id, templateName string) error {
+	_, err := d.db.Exec(`UPDATE traders SET system_prompt_template = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, templateName, id)
+	return err
+}
+
 // UpdateTraderInitialBalance 更新交易员初始余额（用于自动同步交易所实际余额）
 func (d *Database) UpdateTraderInitialBalance(userID, id string, newBalance float64) error {
 	_, err := d.db.Exec(`UPDATE traders SET initial_balance = ? WHERE id = ? AND user_id = ?`, newBalance, id, userID)
@@ -1043,6 +1093,37 @@ func (d *Database) SetSystemConfig(key, value string) error {
 	return err
 }
 
+// AnalyzerConfig 币种分析器参数（params 为 JSON）
+type AnalyzerConfig struct {
+	Symbol         string    `json:"symbol"`
+	Params         string    `json:"params"`
+	Stability      float64   `json:"stability"`
+	OutOfSamplePnL float64   `json:"out_of_sample_pnl"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// SaveAnalyzerConfig 保存币种分析器参数
+func (d *Database) SaveAnalyzerConfig(cfg *AnalyzerConfig) error {
+	_, err := d.db.Exec(`
+		INSERT OR REPLACE INTO analyzer_configs (symbol, params, stability, out_of_sample_pnl, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, cfg.Symbol, cfg.Params, cfg.Stability, cfg.OutOfSamplePnL)
+	return err
+}
+
+// GetAnalyzerConfig 获取币种分析器参数
+func (d *Database) GetAnalyzerConfig(symbol string) (*AnalyzerConfig, error) {
+	var cfg AnalyzerConfig
+	err := d.db.QueryRow(`
+		SELECT symbol, params, stability, out_of_sample_pnl, updated_at
+		FROM analyzer_configs WHERE symbol = ?
+	`, symbol).Scan(&cfg.Symbol, &cfg.Params, &cfg.Stability, &cfg.OutOfSamplePnL, &cfg.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
 // CreateUserSignalSource 创建用户信号源配置
 func (d *Database) CreateUserSignalSource(userID, coinPoolURL, oiTopURL string) error {
 	_, err := d.db.Exec(`
@@ -0,0 +1,81 @@
+package config
+
+import "time"
+
+// DecisionOrder 决策与其产生的订单的映射（一条决策可能产生多笔订单）
+type DecisionOrder struct {
+	TraderID        string    `json:"trader_id"`
+	DecisionID      string    `json:"decision_id"` // 决策记录ID（decision_logs 中的文件名）
+	CycleNumber     int       `json:"cycle_number"`
+	Symbol          string    `json:"symbol"`
+	Action          string    `json:"action"`
+	ClientOrderID   string    `json:"client_order_id"`
+	ExchangeOrderID int64     `json:"exchange_order_id"`
+	OrderType       string    `json:"order_type"` // market / maker
+	Status          string    `json:"status"`
+	FilledQty       float64   `json:"filled_qty"`
+	AvgPrice        float64   `json:"avg_price"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// SaveDecisionOrders 批量保存决策-订单映射
+func (d *Database) SaveDecisionOrders(orders []DecisionOrder) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, o := range orders {
+		if _, err := tx.Exec(`
+			INSERT INTO decision_orders (trader_id, decision_id, cycle_number, symbol, action, client_order_id,
+				exchange_order_id, order_type, status, filled_qty, avg_price)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, o.TraderID, o.DecisionID, o.CycleNumber, o.Symbol, o.Action, o.ClientOrderID,
+			o.ExchangeOrderID, o.OrderType, o.Status, o.FilledQty, o.AvgPrice); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetDecisionOrders 获取某条决策记录产生的全部订单
+func (d *Database) GetDecisionOrders(traderID, decisionID string) ([]DecisionOrder, error) {
+	return d.queryDecisionOrders(`WHERE trader_id = ? AND decision_id = ?`, traderID, decisionID)
+}
+
+// FindDecisionOrder 按交易所订单ID或客户端订单ID查找订单映射（未找到返回 nil）
+func (d *Database) FindDecisionOrder(traderID string, exchangeOrderID int64, clientOrderID string) (*DecisionOrder, error) {
+	var orders []DecisionOrder
+	var err error
+	if clientOrderID != "" {
+		orders, err = d.queryDecisionOrders(`WHERE trader_id = ? AND client_order_id = ?`, traderID, clientOrderID)
+	} else {
+		orders, err = d.queryDecisionOrders(`WHERE trader_id = ? AND exchange_order_id = ?`, traderID, exchangeOrderID)
+	}
+	if err != nil || len(orders) == 0 {
+		return nil, err
+	}
+	return &orders[len(orders)-1], nil
+}
+
+func (d *Database) queryDecisionOrders(where string, args ...interface{}) ([]DecisionOrder, error) {
+	rows, err := d.db.Query(`
+		SELECT trader_id, decision_id, cycle_number, symbol, action, client_order_id, exchange_order_id,
+			order_type, status, filled_qty, avg_price, created_at
+		FROM decision_orders `+where+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []DecisionOrder{}
+	for rows.Next() {
+		var o DecisionOrder
+		if err := rows.Scan(&o.TraderID, &o.DecisionID, &o.CycleNumber, &o.Symbol, &o.Action, &o.ClientOrderID,
+			&o.ExchangeOrderID, &o.OrderType, &o.Status, &o.FilledQty, &o.AvgPrice, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
@@ -0,0 +1,55 @@
+package config
+
+import "testing"
+
+func TestDecisionOrders(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	orders := []DecisionOrder{
+		{TraderID: "trader-1", DecisionID: "decision_20250301_080000_cycle3", CycleNumber: 3, Symbol: "BTCUSDT", Action: "open_long", ExchangeOrderID: 101, OrderType: "maker", Status: "CANCELED", FilledQty: 0.002, AvgPrice: 95000},
+		{TraderID: "trader-1", DecisionID: "decision_20250301_080000_cycle3", CycleNumber: 3, Symbol: "BTCUSDT", Action: "open_long", ClientOrderID: "x-abc", ExchangeOrderID: 102, OrderType: "market", Status: "FILLED", FilledQty: 0.001, AvgPrice: 95010},
+		{TraderID: "trader-2", DecisionID: "decision_20250301_080000_cycle3", Symbol: "ETHUSDT", Action: "close_short", ExchangeOrderID: 101},
+	}
+	if err := db.SaveDecisionOrders(orders); err != nil {
+		t.Fatalf("保存订单映射失败: %v", err)
+	}
+
+	got, err := db.GetDecisionOrders("trader-1", "decision_20250301_080000_cycle3")
+	if err != nil || len(got) != 2 {
+		t.Fatalf("决策订单 = %+v, err %v, want 2 条", got, err)
+	}
+	if got[0].ExchangeOrderID != 101 || got[1].ClientOrderID != "x-abc" {
+		t.Errorf("订单顺序或字段错误: %+v", got)
+	}
+
+	tests := []struct {
+		name            string
+		traderID        string
+		exchangeOrderID int64
+		clientOrderID   string
+		wantAction      string
+	}{
+		{"按交易所订单ID", "trader-1", 101, "", "open_long"},
+		{"按客户端订单ID", "trader-1", 0, "x-abc", "open_long"},
+		{"按交易员隔离", "trader-2", 101, "", "close_short"},
+		{"未找到", "trader-1", 999, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := db.FindDecisionOrder(tt.traderID, tt.exchangeOrderID, tt.clientOrderID)
+			if err != nil {
+				t.Fatalf("查询失败: %v", err)
+			}
+			if tt.wantAction == "" {
+				if order != nil {
+					t.Errorf("期望未找到, got %+v", order)
+				}
+				return
+			}
+			if order == nil || order.Action != tt.wantAction {
+				t.Errorf("订单映射 = %+v, want action %s", order, tt.wantAction)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PromptChangeIntent 提示词相关配置的修改意图（由API写入，交易员下一次检测到 System Prompt 变化时读取并写入审计记录）
+type PromptChangeIntent struct {
+	User   string    `json:"user"`
+	Reason string    `json:"reason"`
+	Time   time.Time `json:"time"`
+}
+
+// NotePromptChangeIntent 记录谁因何修改了交易员的提示词相关配置（保存在系统配置中，交易员重新加载后仍可读取）
+func (d *Database) NotePromptChangeIntent(traderID, user, reason string) error {
+	data, err := json.Marshal(PromptChangeIntent{User: user, Reason: reason, Time: time.Now()})
+	if err != nil {
+		return err
+	}
+	return d.SetSystemConfig("prompt_change_intent:"+traderID, string(data))
+}
+
+// TakePromptChangeIntent 读取并清除修改意图（超过 maxAge 的意图视为与本次变化无关）
+func (d *Database) TakePromptChangeIntent(traderID string, maxAge time.Duration) (user, reason string, ok bool) {
+	key := "prompt_change_intent:" + traderID
+	value, err := d.GetSystemConfig(key)
+	if err != nil || value == "" {
+		return "", "", false
+	}
+	d.SetSystemConfig(key, "")
+
+	var intent PromptChangeIntent
+	if err := json.Unmarshal([]byte(value), &intent); err != nil {
+		return "", "", false
+	}
+	if maxAge > 0 && time.Since(intent.Time) > maxAge {
+		return "", "", false
+	}
+	return intent.User, intent.Reason, true
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 配置项作用域
+const (
+	SettingScopeSystem   = "system"   // 系统配置（system_config 表）
+	SettingScopeRisk     = "risk"     // 风控配置（system_config 表）
+	SettingScopeAnalyzer = "analyzer" // 行情分析/提示词数据配置（system_config 表）
+	SettingScopeTrader   = "trader"   // 交易员配置（traders 表，创建/更新交易员接口的字段）
+)
+
+// 配置项类型
+const (
+	SettingTypeBool   = "bool"
+	SettingTypeInt    = "int"
+	SettingTypeFloat  = "float"
+	SettingTypeString = "string"
+	SettingTypeEnum   = "enum"
+	SettingTypeJSON   = "json"
+)
+
+// SettingSpec 配置项定义（类型、默认值、约束、说明），前端据此自动渲染设置表单
+type SettingSpec struct {
+	Key         string   `json:"key"`
+	Scope       string   `json:"scope"`
+	Type        string   `json:"type"`
+	Default     string   `json:"default"`              // 默认值（与 system_config 一致使用字符串表示，空字符串表示未设置）
+	Min         *float64 `json:"min,omitempty"`        // 数值下限（含）
+	Max         *float64 `json:"max,omitempty"`        // 数值上限（含）
+	Options     []string `json:"options,omitempty"`    // enum 可选值
+	PerTrader   bool     `json:"per_trader,omitempty"` // 支持 <key>:<trader_id> 交易员级覆盖
+	Sensitive   bool     `json:"sensitive,omitempty"`  // 敏感配置，前端不回显
+	Description string   `json:"description"`
+}
+
+// StoredInSystemConfig 是否保存在 system_config 表
+func (s SettingSpec) StoredInSystemConfig() bool {
+	return s.Scope != SettingScopeTrader
+}
+
+// Validate 校验配置值是否符合类型和约束（空字符串视为未设置，总是合法）
+func (s SettingSpec) Validate(value string) error {
+	if value == "" {
+		return nil
+	}
+	switch s.Type {
+	case SettingTypeBool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%s 必须为 true 或 false", s.Key)
+		}
+	case SettingTypeInt, SettingTypeFloat:
+		var n float64
+		if s.Type == SettingTypeInt {
+			i, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s 必须为整数: %s", s.Key, value)
+			}
+			n = float64(i)
+		} else {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("%s 必须为数字: %s", s.Key, value)
+			}
+			n = f
+		}
+		if s.Min != nil && n < *s.Min {
+			return fmt.Errorf("%s 不能小于 %g", s.Key, *s.Min)
+		}
+		if s.Max != nil && n > *s.Max {
+			return fmt.Errorf("%s 不能大于 %g", s.Key, *s.Max)
+		}
+	case SettingTypeEnum:
+		for _, option := range s.Options {
+			if value == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s 可选值为 %s: %s", s.Key, strings.Join(s.Options, " / "), value)
+	case SettingTypeJSON:
+		if !json.Valid([]byte(value)) {
+			return fmt.Errorf("%s 不是合法的JSON", s.Key)
+		}
+	}
+	return nil
+}
+
+func bound(v float64) *float64 { return &v }
+
+// settingSchema 全部可配置项（新增配置项时在此登记，system_config 默认值由此初始化）
+var settingSchema = []SettingSpec{
+	// 系统
+	{Key: "beta_mode", Scope: SettingScopeSystem, Type: SettingTypeBool, Default: "false", Description: "内测模式（注册需要内测码）"},
+	{Key: "api_server_port", Scope: SettingScopeSystem, Type: SettingTypeInt, Default: "8080", Min: bound(1), Max: bound(65535), Description: "API服务端口"},
+	{Key: "use_default_coins", Scope: SettingScopeSystem, Type: SettingTypeBool, Default: "true", Description: "使用内置币种列表"},
+	{Key: "default_coins", Scope: SettingScopeSystem, Type: SettingTypeJSON, Default: `["BTCUSDT","ETHUSDT","SOLUSDT","BNBUSDT","XRPUSDT","DOGEUSDT","ADAUSDT","HYPEUSDT"]`, Description: "默认币种列表（JSON数组）"},
+	{Key: "decision_schema_version", Scope: SettingScopeSystem, Type: SettingTypeEnum, Default: "2", Options: []string{"1", "2"}, Description: "AI决策输出格式版本（1=裸数组，2=带schema_version的包装对象）"},
+	{Key: "execution_policy", Scope: SettingScopeSystem, Type: SettingTypeEnum, Default: "hint", Options: []string{"market", "hint", "maker_preferred"}, Description: "开仓下单策略：market / hint（AI给出post_only时挂Maker单）/ maker_preferred"},
+	{Key: "template_auto_switch", Scope: SettingScopeSystem, Type: SettingTypeBool, Default: "false", Description: "模板连续输出不合规时自动切换到更合规的模板"},
+	{Key: "stop_watchdog_emergency_close", Scope: SettingScopeSystem, Type: SettingTypeBool, Default: "false", Description: "开仓后止损多次补挂失败时紧急平仓"},
+	{Key: "decision_approval_required", Scope: SettingScopeSystem, Type: SettingTypeBool, Default: "false", Description: "决策需人工审批后执行（通过决策队列API批准/修改/取消）"},
+	{Key: "notification_prefs", Scope: SettingScopeSystem, Type: SettingTypeJSON, PerTrader: true, Description: "通知偏好JSON（事件过滤、限频、汇总）"},
+	{Key: "allocation_mode", Scope: SettingScopeSystem, Type: SettingTypeEnum, Default: "ai", Options: []string{"ai", "risk_parity"}, Description: "仓位分配模式：ai（AI决定仓位）/ risk_parity（按ATR风险平价重新分配同周期开仓信号）"},
+	{Key: "sampling_params", Scope: SettingScopeSystem, Type: SettingTypeJSON, PerTrader: true, Description: "模型采样参数JSON（temperature/top_p/max_tokens/seed，空=温度0.5、AI_MAX_TOKENS）"},
+	{Key: "clock_drift_alert_ms", Scope: SettingScopeSystem, Type: SettingTypeInt, Default: "1000", Min: bound(1), Description: "本地时钟与交易所服务器时间偏差告警阈值（毫秒，偏移会自动校正到签名时间戳）"},
+	{Key: "accounting_decimals", Scope: SettingScopeSystem, Type: SettingTypeInt, Default: "8", Min: bound(0), Max: bound(12), Description: "记账金额（每日结算、税务批次）保留的小数位数"},
+	{Key: "tool_call_budget", Scope: SettingScopeSystem, Type: SettingTypeInt, Default: "0", Min: bound(0), PerTrader: true, Description: "每个决策周期模型可调用数据工具的次数（0=关闭）"},
+	{Key: "trade_chart_enabled", Scope: SettingScopeSystem, Type: SettingTypeBool, Default: "true", Description: "成交后生成交易图表，随通知推送并写入决策日志"},
+	{Key: "maintenance_check", Scope: SettingScopeSystem, Type: SettingTypeBool, Default: "false", Description: "币安交易员查询系统状态接口自动识别维护（该接口为现货系统状态，只在全站维护时与合约一致；合约维护请录入维护公告）"},
+	{Key: "reporting_currency", Scope: SettingScopeSystem, Type: SettingTypeString, Description: "报告货币（如EUR/CNY，空=不换算）"},
+	{Key: "alert_cooldown_minutes", Scope: SettingScopeSystem, Type: SettingTypeInt, Default: "5", Min: bound(0), Description: "同一币种警报触发定向决策周期的最小间隔（分钟）"},
+	{Key: "postmortem_loss_streak", Scope: SettingScopeSystem, Type: SettingTypeInt, Default: "3", Min: bound(0), Description: "连续亏损多少笔自动生成复盘包（0=关闭）"},
+	{Key: "postmortem_daily_loss", Scope: SettingScopeSystem, Type: SettingTypeFloat, Min: bound(0), Description: "当日亏损百分比达到该值时生成复盘包（为空使用交易员最大日亏损，0=关闭）"},
+	{Key: "postmortem_ai_analysis", Scope: SettingScopeSystem, Type: SettingTypeBool, Default: "false", Description: "复盘包生成后是否调用AI给出规则/模板调整建议（仅供人工审核）"},
+	{Key: "watch_only_symbols", Scope: SettingScopeSystem, Type: SettingTypeString, PerTrader: true, Description: "仅观察币种（逗号分隔），进入prompt完整分析但开仓决策自动转为 wait"},
+	{Key: "position_refresh_secs", Scope: SettingScopeSystem, Type: SettingTypeInt, Default: "10", Min: bound(0), Description: "持仓优先刷新间隔（秒，0=关闭）"},
+	{Key: "position_atr_trigger", Scope: SettingScopeSystem, Type: SettingTypeFloat, Default: "2", Min: bound(0), Description: "持仓逆向波动超过 N×ATR 时触发紧急持仓管理周期"},
+	{Key: "flow_window_minutes", Scope: SettingScopeSystem, Type: SettingTypeInt, Default: "15", Min: bound(1), Description: "持仓资金流（持仓量、主动成交量）变化的观察窗口（分钟）"},
+	{Key: "flow_oi_trigger_pct", Scope: SettingScopeSystem, Type: SettingTypeFloat, Default: "3", Min: bound(0), Description: "窗口内持仓量上升超过 N% 且价格逆向时触发紧急持仓管理周期（0=关闭）"},
+	{Key: "flow_delta_trigger", Scope: SettingScopeSystem, Type: SettingTypeFloat, Default: "0.3", Min: bound(0), Max: bound(1), Description: "窗口内逆向净主动成交占比超过该值且价格逆向时触发紧急持仓管理周期（0=关闭）"},
+	{Key: "ai_price_input_per_million", Scope: SettingScopeSystem, Type: SettingTypeFloat, Default: "0", Min: bound(0), Description: "AI输入token单价（USD/百万token），用于指标看板估算AI费用（0=不估算）"},
+	{Key: "ai_price_output_per_million", Scope: SettingScopeSystem, Type: SettingTypeFloat, Default: "0", Min: bound(0), Description: "AI输出token单价（USD/百万token），用于指标看板估算AI费用（0=不估算）"},
+	{Key: "jwt_secret", Scope: SettingScopeSystem, Type: SettingTypeString, Sensitive: true, Description: "JWT密钥（为空由config.json或系统生成）"},
+
+	// 风控
+	{Key: "max_daily_loss", Scope: SettingScopeRisk, Type: SettingTypeFloat, Default: "10.0", Min: bound(0), Max: bound(100), Description: "最大日损失百分比"},
+	{Key: "max_drawdown", Scope: SettingScopeRisk, Type: SettingTypeFloat, Default: "20.0", Min: bound(0), Max: bound(100), Description: "最大回撤百分比"},
+	{Key: "stop_trading_minutes", Scope: SettingScopeRisk, Type: SettingTypeInt, Default: "60", Min: bound(0), Description: "触发风控后停止交易时间（分钟）"},
+	{Key: "max_open_risk_pct", Scope: SettingScopeRisk, Type: SettingTypeFloat, Default: "5.0", Min: bound(0), Max: bound(100), Description: "总开放风险上限（占净值百分比）"},
+	{Key: "vol_target_pct", Scope: SettingScopeRisk, Type: SettingTypeFloat, Default: "0", Min: bound(0), Description: "波动率目标（单仓位预测日波动占净值百分比，0=不启用）"},
+	{Key: "liquidity_max_oi_pct", Scope: SettingScopeRisk, Type: SettingTypeFloat, Default: "0.1", Min: bound(0), Max: bound(100), Description: "单币种仓位价值上限：持仓量价值的百分比（0=不限制）"},
+	{Key: "liquidity_max_volume_pct", Scope: SettingScopeRisk, Type: SettingTypeFloat, Default: "0.1", Min: bound(0), Max: bound(100), Description: "单币种仓位价值上限：24小时成交额的百分比（0=不限制）"},
+	{Key: "funding_block_minutes", Scope: SettingScopeRisk, Type: SettingTypeInt, Default: "0", Min: bound(0), PerTrader: true, Description: "资金费结算前N分钟内不逆费率方向开仓（0=不启用）"},
+	{Key: "reentry_suppress_minutes", Scope: SettingScopeRisk, Type: SettingTypeInt, Default: "30", Min: bound(0), PerTrader: true, Description: "开仓被风控拒绝或被止损后N分钟内，在相近价格同向重复开仓转为 wait（0=关闭）"},
+	{Key: "reentry_suppress_price_pct", Scope: SettingScopeRisk, Type: SettingTypeFloat, Default: "1.5", Min: bound(0), Max: bound(100), PerTrader: true, Description: "重复开仓抑制的价格范围：当前价与被拒绝/止损价格的偏差百分比不超过该值视为相近"},
+	{Key: "equity_jump_pct", Scope: SettingScopeRisk, Type: SettingTypeFloat, Default: "30", Min: bound(0), PerTrader: true, Description: "相邻周期账户净值跳变超过该百分比时冻结新开仓并告警（0=关闭）"},
+	{Key: "risk_persona", Scope: SettingScopeRisk, Type: SettingTypeEnum, Options: []string{"conservative", "balanced", "aggressive"}, PerTrader: true, Description: "风险偏好档位，同时调整提示词和风控上限（为空不启用）"},
+	{Key: "btc_eth_leverage", Scope: SettingScopeRisk, Type: SettingTypeInt, Default: "5", Min: bound(1), Max: bound(50), Description: "BTC/ETH默认杠杆倍数"},
+	{Key: "altcoin_leverage", Scope: SettingScopeRisk, Type: SettingTypeInt, Default: "5", Min: bound(1), Max: bound(20), Description: "山寨币默认杠杆倍数"},
+
+	// 行情分析
+	{Key: "prompt_verbosity", Scope: SettingScopeAnalyzer, Type: SettingTypeEnum, Default: "standard", Options: []string{"brief", "standard", "full"}, Description: "市场数据输出详细程度：brief（每周期一行）/ standard / full（含原始价格位）"},
+	{Key: "indicator_set", Scope: SettingScopeAnalyzer, Type: SettingTypeJSON, PerTrader: true, Description: "指标集JSON（为空使用内置指标）"},
+	{Key: "market_analysis_url", Scope: SettingScopeAnalyzer, Type: SettingTypeString, Description: "独立市场分析服务地址（为空在本进程计算；令牌使用环境变量 MARKET_ANALYSIS_TOKEN）"},
+	{Key: "candidate_data_cache", Scope: SettingScopeAnalyzer, Type: SettingTypeBool, Default: "true", Description: "非持仓候选币种复用一根K线（最短分析周期）内的缓存分析，持仓币种始终每周期重新计算"},
+	{Key: "analysis_timeframes", Scope: SettingScopeAnalyzer, Type: SettingTypeString, Default: "3m,4h", Description: "默认分析周期（逗号分隔或预设 scalper/intraday/swing；交易员级为 timeframes:<trader_id>）"},
+
+	// 交易员
+	{Key: "initial_balance", Scope: SettingScopeTrader, Type: SettingTypeFloat, Min: bound(0), Description: "初始资金（为空使用交易所账户净值）"},
+	{Key: "scan_interval_minutes", Scope: SettingScopeTrader, Type: SettingTypeInt, Default: "3", Min: bound(3), Description: "决策周期间隔（分钟）"},
+	{Key: "btc_eth_leverage", Scope: SettingScopeTrader, Type: SettingTypeInt, Min: bound(0), Max: bound(50), Description: "BTC/ETH杠杆倍数（0=使用系统默认）"},
+	{Key: "altcoin_leverage", Scope: SettingScopeTrader, Type: SettingTypeInt, Min: bound(0), Max: bound(20), Description: "山寨币杠杆倍数（0=使用系统默认）"},
+	{Key: "trading_symbols", Scope: SettingScopeTrader, Type: SettingTypeString, Description: "交易币种（逗号分隔，为空使用默认币种/信号源）"},
+	{Key: "use_coin_pool", Scope: SettingScopeTrader, Type: SettingTypeBool, Default: "false", Description: "使用COIN POOL信号源"},
+	{Key: "use_oi_top", Scope: SettingScopeTrader, Type: SettingTypeBool, Default: "false", Description: "使用OI TOP信号源"},
+	{Key: "custom_prompt", Scope: SettingScopeTrader, Type: SettingTypeString, Description: "自定义交易策略prompt"},
+	{Key: "override_base_prompt", Scope: SettingScopeTrader, Type: SettingTypeBool, Default: "false", Description: "自定义prompt覆盖基础prompt"},
+	{Key: "system_prompt_template", Scope: SettingScopeTrader, Type: SettingTypeString, Default: "default", Description: "系统提示词模板名称"},
+	{Key: "is_cross_margin", Scope: SettingScopeTrader, Type: SettingTypeBool, Default: "true", Description: "全仓模式（false=逐仓）"},
+}
+
+// SettingSchema 全部配置项定义（副本）
+func SettingSchema() []SettingSpec {
+	result := make([]SettingSpec, len(settingSchema))
+	copy(result, settingSchema)
+	return result
+}
+
+// FindSetting 按作用域和键查找配置项定义（scope 为空时返回第一个匹配的键）
+func FindSetting(scope, key string) (SettingSpec, bool) {
+	for _, spec := range settingSchema {
+		if spec.Key == key && (scope == "" || spec.Scope == scope) {
+			return spec, true
+		}
+	}
+	return SettingSpec{}, false
+}
+
+// systemConfigDefaults system_config 表的默认值
+func systemConfigDefaults() map[string]string {
+	defaults := make(map[string]string)
+	for _, spec := range settingSchema {
+		if spec.StoredInSystemConfig() {
+			defaults[spec.Key] = spec.Default
+		}
+	}
+	return defaults
+}
@@ -0,0 +1,79 @@
+package config
+
+import "testing"
+
+func TestSettingSchemaDefaultsValid(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, spec := range SettingSchema() {
+		id := spec.Scope + "/" + spec.Key
+		if seen[id] {
+			t.Errorf("duplicate setting %s", id)
+		}
+		seen[id] = true
+		if spec.Description == "" {
+			t.Errorf("%s missing description", id)
+		}
+		if spec.Type == SettingTypeEnum && len(spec.Options) == 0 {
+			t.Errorf("%s enum without options", id)
+		}
+		if err := spec.Validate(spec.Default); err != nil {
+			t.Errorf("%s default invalid: %v", id, err)
+		}
+	}
+
+	// 同一个键在 system_config 中只能有一个定义
+	stored := make(map[string]bool)
+	for _, spec := range SettingSchema() {
+		if !spec.StoredInSystemConfig() {
+			continue
+		}
+		if stored[spec.Key] {
+			t.Errorf("system_config key %s defined twice", spec.Key)
+		}
+		stored[spec.Key] = true
+	}
+}
+
+func TestSettingSpecValidate(t *testing.T) {
+	leverage, _ := FindSetting(SettingScopeRisk, "btc_eth_leverage")
+	persona, _ := FindSetting("", "risk_persona")
+	prefs, _ := FindSetting("", "notification_prefs")
+	chart, _ := FindSetting("", "trade_chart_enabled")
+	tests := []struct {
+		name    string
+		spec    SettingSpec
+		value   string
+		wantErr bool
+	}{
+		{"整数范围内", leverage, "20", false},
+		{"超出上限", leverage, "51", true},
+		{"低于下限", leverage, "0", true},
+		{"非整数", leverage, "2.5", true},
+		{"空值视为未设置", leverage, "", false},
+		{"枚举可选值", persona, "balanced", false},
+		{"枚举非法值", persona, "yolo", true},
+		{"合法JSON", prefs, `{"events":["trade"]}`, false},
+		{"非法JSON", prefs, `{events`, true},
+		{"布尔值", chart, "true", false},
+		{"非法布尔值", chart, "yes", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.spec.Validate(tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q) err = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSystemConfigDefaultsFromSchema(t *testing.T) {
+	defaults := systemConfigDefaults()
+	for key, want := range map[string]string{"btc_eth_leverage": "5", "prompt_verbosity": "standard", "jwt_secret": ""} {
+		if got, ok := defaults[key]; !ok || got != want {
+			t.Errorf("default %s = %q (present %v), want %q", key, got, ok, want)
+		}
+	}
+	if _, ok := defaults["scan_interval_minutes"]; ok {
+		t.Error("trader-scope settings must not be stored in system_config")
+	}
+}
@@ -0,0 +1,247 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"nofx/market"
+	"strings"
+	"time"
+)
+
+// TraderBundleVersion 配置包格式版本
+const TraderBundleVersion = 1
+
+// bundleSigningKeyConfig 本部署的配置包签名私钥（ed25519 seed，base64；首次导出时生成）
+const bundleSigningKeyConfig = "bundle_signing_key"
+
+// TraderBundle 可分享的交易员配置包（不含API密钥、余额等部署相关信息）
+type TraderBundle struct {
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Trader     TraderBundleSetup `json:"trader"`
+	Settings   map[string]string `json:"settings"`  // 交易员级设置（风险档位、指标集、采样参数等，键为 traderScopedConfigKeys）
+	Signer     string            `json:"signer"`    // 签名公钥（base64）
+	Signature  string            `json:"signature"` // 对除 signer/signature 外内容的 ed25519 签名（base64）
+}
+
+// TraderBundleSetup 配置包中的交易员设置（模型/交易所只保存引用ID，导入时可替换）
+type TraderBundleSetup struct {
+	Name                 string `json:"name"`
+	AIModelID            string `json:"ai_model_id"`
+	ExchangeID           string `json:"exchange_id"`
+	ScanIntervalMinutes  int    `json:"scan_interval_minutes"`
+	BTCETHLeverage       int    `json:"btc_eth_leverage"`
+	AltcoinLeverage      int    `json:"altcoin_leverage"`
+	TradingSymbols       string `json:"trading_symbols"`
+	UseCoinPool          bool   `json:"use_coin_pool"`
+	UseOITop             bool   `json:"use_oi_top"`
+	CustomPrompt         string `json:"custom_prompt"`
+	OverrideBasePrompt   bool   `json:"override_base_prompt"`
+	SystemPromptTemplate string `json:"system_prompt_template"`
+	IsCrossMargin        bool   `json:"is_cross_margin"`
+}
+
+// TraderBundleImport 导入时由用户指定的部署相关信息
+type TraderBundleImport struct {
+	Name           string  `json:"name"`            // 为空使用配置包中的名称
+	AIModelID      string  `json:"ai_model_id"`     // 为空使用配置包中的引用
+	ExchangeID     string  `json:"exchange_id"`     // 为空使用配置包中的引用
+	InitialBalance float64 `json:"initial_balance"` // 初始资金
+}
+
+// ExportTraderBundle 导出交易员配置包并用本部署的签名密钥签名
+func (d *Database) ExportTraderBundle(userID, traderID string) (*TraderBundle, error) {
+	trader, _, _, err := d.GetTraderConfig(userID, traderID)
+	if err != nil {
+		return nil, fmt.Errorf("交易员 %s 不存在: %w", traderID, err)
+	}
+
+	bundle := &TraderBundle{
+		Version:    TraderBundleVersion,
+		ExportedAt: time.Now().UTC().Truncate(time.Second),
+		Trader: TraderBundleSetup{
+			Name:                 trader.Name,
+			AIModelID:            trader.AIModelID,
+			ExchangeID:           trader.ExchangeID,
+			ScanIntervalMinutes:  trader.ScanIntervalMinutes,
+			BTCETHLeverage:       trader.BTCETHLeverage,
+			AltcoinLeverage:      trader.AltcoinLeverage,
+			TradingSymbols:       trader.TradingSymbols,
+			UseCoinPool:          trader.UseCoinPool,
+			UseOITop:             trader.UseOITop,
+			CustomPrompt:         trader.CustomPrompt,
+			OverrideBasePrompt:   trader.OverrideBasePrompt,
+			SystemPromptTemplate: trader.SystemPromptTemplate,
+			IsCrossMargin:        trader.IsCrossMargin,
+		},
+		Settings: make(map[string]string),
+	}
+	for _, key := range traderScopedConfigKeys {
+		if value, err := d.GetSystemConfig(key + ":" + traderID); err == nil && value != "" {
+			bundle.Settings[key] = value
+		}
+	}
+
+	key, err := d.bundleSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	payload, err := bundle.signingPayload()
+	if err != nil {
+		return nil, err
+	}
+	bundle.Signer = base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	bundle.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+	return bundle, nil
+}
+
+// ImportTraderBundle 校验配置包后创建新交易员（未运行状态）并写入交易员级设置
+func (d *Database) ImportTraderBundle(userID, newID string, bundle *TraderBundle, opts TraderBundleImport) (*TraderRecord, error) {
+	if err := bundle.Validate(); err != nil {
+		return nil, err
+	}
+
+	setup := bundle.Trader
+	trader := &TraderRecord{
+		ID:                   newID,
+		UserID:               userID,
+		Name:                 firstNonEmpty(strings.TrimSpace(opts.Name), setup.Name),
+		AIModelID:            firstNonEmpty(opts.AIModelID, setup.AIModelID),
+		ExchangeID:           firstNonEmpty(opts.ExchangeID, setup.ExchangeID),
+		InitialBalance:       opts.InitialBalance,
+		ScanIntervalMinutes:  setup.ScanIntervalMinutes,
+		BTCETHLeverage:       setup.BTCETHLeverage,
+		AltcoinLeverage:      setup.AltcoinLeverage,
+		TradingSymbols:       setup.TradingSymbols,
+		UseCoinPool:          setup.UseCoinPool,
+		UseOITop:             setup.UseOITop,
+		CustomPrompt:         setup.CustomPrompt,
+		OverrideBasePrompt:   setup.OverrideBasePrompt,
+		SystemPromptTemplate: setup.SystemPromptTemplate,
+		IsCrossMargin:        setup.IsCrossMargin,
+	}
+	if err := d.CreateTrader(trader); err != nil {
+		return nil, fmt.Errorf("创建交易员失败: %w", err)
+	}
+	for key, value := range bundle.Settings {
+		if err := d.SetSystemConfig(key+":"+newID, value); err != nil {
+			return nil, fmt.Errorf("写入交易员设置 %s 失败: %w", key, err)
+		}
+	}
+	return trader, nil
+}
+
+// Validate 校验签名、版本和各项设置的取值范围（不检查模型/交易所/模板在本部署是否存在）
+func (b *TraderBundle) Validate() error {
+	if b.Version != TraderBundleVersion {
+		return fmt.Errorf("不支持的配置包版本 %d（当前 %d）", b.Version, TraderBundleVersion)
+	}
+	signer, err := base64.StdEncoding.DecodeString(b.Signer)
+	if err != nil || len(signer) != ed25519.PublicKeySize {
+		return fmt.Errorf("配置包签名公钥无效")
+	}
+	signature, err := base64.StdEncoding.DecodeString(b.Signature)
+	if err != nil {
+		return fmt.Errorf("配置包签名无效")
+	}
+	payload, err := b.signingPayload()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(ed25519.PublicKey(signer), payload, signature) {
+		return fmt.Errorf("配置包签名校验失败（内容已被修改）")
+	}
+
+	setup := b.Trader
+	if setup.AIModelID == "" || setup.ExchangeID == "" {
+		return fmt.Errorf("配置包缺少AI模型或交易所引用")
+	}
+	if setup.BTCETHLeverage < 1 || setup.BTCETHLeverage > 50 {
+		return fmt.Errorf("BTC/ETH杠杆必须在1-50倍之间")
+	}
+	if setup.AltcoinLeverage < 1 || setup.AltcoinLeverage > 20 {
+		return fmt.Errorf("山寨币杠杆必须在1-20倍之间")
+	}
+	if setup.ScanIntervalMinutes < 3 {
+		return fmt.Errorf("扫描间隔不能小于3分钟")
+	}
+	for _, symbol := range strings.Split(setup.TradingSymbols, ",") {
+		symbol = strings.TrimSpace(symbol)
+		if symbol != "" && !strings.HasSuffix(strings.ToUpper(symbol), "USDT") {
+			return fmt.Errorf("无效的币种格式: %s，必须以USDT结尾", symbol)
+		}
+	}
+
+	allowed := make(map[string]bool, len(traderScopedConfigKeys))
+	for _, key := range traderScopedConfigKeys {
+		allowed[key] = true
+	}
+	for key, value := range b.Settings {
+		if !allowed[key] {
+			return fmt.Errorf("配置包包含不支持的设置 %s", key)
+		}
+		if key == "timeframes" {
+			if _, err := market.ParseTimeframes(value); err != nil {
+				return fmt.Errorf("设置 timeframes 无效: %w", err)
+			}
+		}
+		if strings.HasPrefix(strings.TrimSpace(value), "{") || strings.HasPrefix(strings.TrimSpace(value), "[") {
+			if !json.Valid([]byte(value)) {
+				return fmt.Errorf("设置 %s 不是有效的JSON", key)
+			}
+		}
+	}
+	return nil
+}
+
+// SignerFingerprint 签名公钥指纹（SHA-256前16位十六进制，用于确认配置包来源）
+func (b *TraderBundle) SignerFingerprint() string {
+	signer, err := base64.StdEncoding.DecodeString(b.Signer)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(signer)
+	return hex.EncodeToString(sum[:8])
+}
+
+// signingPayload 签名内容：去掉 signer/signature 后的JSON
+func (b *TraderBundle) signingPayload() ([]byte, error) {
+	unsigned := *b
+	unsigned.Signer = ""
+	unsigned.Signature = ""
+	return json.Marshal(unsigned)
+}
+
+// bundleSigningKey 获取本部署的配置包签名私钥，不存在时生成
+func (d *Database) bundleSigningKey() (ed25519.PrivateKey, error) {
+	if value, err := d.GetSystemConfig(bundleSigningKeyConfig); err == nil && value != "" {
+		seed, err := base64.StdEncoding.DecodeString(value)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("配置包签名密钥已损坏")
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("生成配置包签名密钥失败: %w", err)
+	}
+	if err := d.SetSystemConfig(bundleSigningKeyConfig, base64.StdEncoding.EncodeToString(key.Seed())); err != nil {
+		return nil, fmt.Errorf("保存配置包签名密钥失败: %w", err)
+	}
+	return key, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
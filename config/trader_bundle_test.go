@@ -0,0 +1,102 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTraderBundleRoundTrip(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	userID := createBundleSource(t, db)
+	if err := db.SetSystemConfig("risk_persona:src", "conservative"); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := db.ExportTraderBundle(userID, "src")
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	data, _ := json.Marshal(bundle)
+	if bundle.Settings["risk_persona"] != "conservative" {
+		t.Fatalf("配置包缺少交易员级设置: %+v", bundle.Settings)
+	}
+
+	// 序列化后重新解析，签名仍然有效
+	var parsed TraderBundle
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := db.ImportTraderBundle(userID, "dst", &parsed, TraderBundleImport{InitialBalance: 500})
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	got, _, _, err := db.GetTraderConfig(userID, "dst")
+	if err != nil {
+		t.Fatalf("读取导入的交易员失败: %v", err)
+	}
+	if imported.Name != "趋势策略" || got.BTCETHLeverage != 8 || got.SystemPromptTemplate != "aggressive" || got.InitialBalance != 500 {
+		t.Errorf("imported = %+v", got)
+	}
+	if value, _ := db.GetSystemConfig("risk_persona:dst"); value != "conservative" {
+		t.Errorf("交易员级设置未导入: %q", value)
+	}
+}
+
+func TestTraderBundleValidate(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	userID := createBundleSource(t, db)
+	signed, err := db.ExportTraderBundle(userID, "src")
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(b *TraderBundle)
+		wantErr bool
+	}{
+		{"签名有效", func(b *TraderBundle) {}, false},
+		{"修改杠杆后签名失效", func(b *TraderBundle) { b.Trader.BTCETHLeverage = 50 }, true},
+		{"添加设置后签名失效", func(b *TraderBundle) { b.Settings["risk_persona"] = "aggressive" }, true},
+		{"缺少签名", func(b *TraderBundle) { b.Signature = "" }, true},
+		{"版本不支持", func(b *TraderBundle) { b.Version = 99 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := *signed
+			b.Settings = make(map[string]string)
+			for k, v := range signed.Settings {
+				b.Settings[k] = v
+			}
+			tt.mutate(&b)
+			if err := b.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// createBundleSource 创建导出用的交易员（含其引用的AI模型和交易所）
+func createBundleSource(t *testing.T, db *Database) string {
+	t.Helper()
+	userID := "test-user-001"
+	if err := db.CreateAIModel(userID, "bundle_deepseek", "DeepSeek", "deepseek", true, "sk-test", ""); err != nil {
+		t.Fatalf("创建AI模型失败: %v", err)
+	}
+	if err := db.CreateExchange(userID, "bundle_binance", "Binance", "cex", true, "key", "secret", false, "", "", "", ""); err != nil {
+		t.Fatalf("创建交易所失败: %v", err)
+	}
+	source := &TraderRecord{
+		ID: "src", UserID: userID, Name: "趋势策略", AIModelID: "bundle_deepseek", ExchangeID: "bundle_binance",
+		InitialBalance: 1000, ScanIntervalMinutes: 5, BTCETHLeverage: 8, AltcoinLeverage: 3,
+		TradingSymbols: "BTCUSDT,SOLUSDT", CustomPrompt: "只做趋势", SystemPromptTemplate: "aggressive",
+	}
+	if err := db.CreateTrader(source); err != nil {
+		t.Fatalf("创建交易员失败: %v", err)
+	}
+	return userID
+}
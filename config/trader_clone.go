@@ -0,0 +1,65 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// traderScopedConfigKeys 以 "<key>:<trader_id>" 形式保存在系统配置中的交易员级设置（克隆时一并复制）
+var traderScopedConfigKeys = []string{"notification_prefs", "indicator_set", "sampling_params", "funding_block_minutes", "risk_persona", "watch_only_symbols", "timeframes", "tool_call_budget", "equity_jump_pct"}
+
+// TraderLineage 克隆来源记录
+type TraderLineage struct {
+	SourceTraderID string    `json:"source_trader_id"`
+	ClonedAt       time.Time `json:"cloned_at"`
+}
+
+// CloneTrader 复制交易员配置（AI模型、交易所引用、杠杆/币种/信号源、提示词模板与自定义策略、交易员级设置）
+// 新交易员使用新的ID，决策日志、队列、统计等运行状态均从零开始；原交易员及其历史保持不变
+func (d *Database) CloneTrader(userID, sourceID, newID, name string, initialBalance float64) (*TraderRecord, error) {
+	source, _, _, err := d.GetTraderConfig(userID, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("交易员 %s 不存在: %w", sourceID, err)
+	}
+
+	clone := *source
+	clone.ID = newID
+	clone.Name = name
+	clone.IsRunning = false
+	if initialBalance > 0 {
+		clone.InitialBalance = initialBalance
+	}
+	if err := d.CreateTrader(&clone); err != nil {
+		return nil, fmt.Errorf("创建克隆交易员失败: %w", err)
+	}
+
+	for _, key := range traderScopedConfigKeys {
+		value, err := d.GetSystemConfig(key + ":" + sourceID)
+		if err != nil || value == "" {
+			continue
+		}
+		if err := d.SetSystemConfig(key+":"+newID, value); err != nil {
+			return nil, fmt.Errorf("复制交易员设置 %s 失败: %w", key, err)
+		}
+	}
+
+	lineage, _ := json.Marshal(TraderLineage{SourceTraderID: sourceID, ClonedAt: time.Now()})
+	if err := d.SetSystemConfig("cloned_from:"+newID, string(lineage)); err != nil {
+		return nil, fmt.Errorf("保存克隆来源失败: %w", err)
+	}
+	return &clone, nil
+}
+
+// GetTraderLineage 获取交易员的克隆来源（非克隆的交易员返回 nil）
+func (d *Database) GetTraderLineage(traderID string) *TraderLineage {
+	value, err := d.GetSystemConfig("cloned_from:" + traderID)
+	if err != nil || value == "" {
+		return nil
+	}
+	var lineage TraderLineage
+	if err := json.Unmarshal([]byte(value), &lineage); err != nil {
+		return nil
+	}
+	return &lineage
+}
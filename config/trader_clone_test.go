@@ -0,0 +1,68 @@
+package config
+
+import "testing"
+
+func TestCloneTrader(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	userID := "test-user-001"
+	if err := db.CreateAIModel(userID, "clone_deepseek", "DeepSeek", "deepseek", true, "sk-test", ""); err != nil {
+		t.Fatalf("创建AI模型失败: %v", err)
+	}
+	if err := db.CreateExchange(userID, "clone_binance", "Binance", "cex", true, "key", "secret", false, "", "", "", ""); err != nil {
+		t.Fatalf("创建交易所失败: %v", err)
+	}
+	source := &TraderRecord{
+		ID: "src", UserID: userID, Name: "实验A", AIModelID: "clone_deepseek", ExchangeID: "clone_binance",
+		InitialBalance: 1000, ScanIntervalMinutes: 5, IsRunning: true, BTCETHLeverage: 8, AltcoinLeverage: 3,
+		TradingSymbols: "BTCUSDT,SOLUSDT", CustomPrompt: "只做趋势", SystemPromptTemplate: "aggressive", IsCrossMargin: true,
+	}
+	if err := db.CreateTrader(source); err != nil {
+		t.Fatalf("创建交易员失败: %v", err)
+	}
+	if err := db.SetSystemConfig("indicator_set:src", `[{"type":"ema","period":50,"timeframe":"4h"}]`); err != nil {
+		t.Fatal(err)
+	}
+
+	clone, err := db.CloneTrader(userID, "src", "dst", "实验A (copy)", 1200)
+	if err != nil {
+		t.Fatalf("克隆失败: %v", err)
+	}
+
+	got, _, _, err := db.GetTraderConfig(userID, "dst")
+	if err != nil {
+		t.Fatalf("读取克隆交易员失败: %v", err)
+	}
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"名称使用新名称", got.Name == "实验A (copy)" && clone.Name == got.Name},
+		{"初始资金重置", got.InitialBalance == 1200},
+		{"不继承运行状态", !got.IsRunning},
+		{"复制风控配置", got.BTCETHLeverage == 8 && got.AltcoinLeverage == 3 && got.IsCrossMargin},
+		{"复制模板和策略", got.SystemPromptTemplate == "aggressive" && got.CustomPrompt == "只做趋势"},
+		{"复制交易币种", got.TradingSymbols == "BTCUSDT,SOLUSDT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.ok {
+				t.Errorf("clone = %+v", got)
+			}
+		})
+	}
+
+	if value, _ := db.GetSystemConfig("indicator_set:dst"); value == "" {
+		t.Error("交易员级设置未复制")
+	}
+	if lineage := db.GetTraderLineage("dst"); lineage == nil || lineage.SourceTraderID != "src" {
+		t.Errorf("lineage = %+v", lineage)
+	}
+	if db.GetTraderLineage("src") != nil {
+		t.Error("原交易员不应有克隆来源")
+	}
+	if _, err := db.CloneTrader(userID, "missing", "x", "x", 0); err == nil {
+		t.Error("克隆不存在的交易员应失败")
+	}
+}
@@ -0,0 +1,45 @@
+package decision
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AlertTrigger 触发定向决策周期的警报（如价格进入新的需求区）
+type AlertTrigger struct {
+	Rule      string            `json:"rule"`                // 警报规则名称
+	Symbol    string            `json:"symbol"`              // 触发币种（定向周期只分析该币种）
+	Message   string            `json:"message"`             // 警报描述
+	Price     float64           `json:"price,omitempty"`     // 触发时价格
+	Value     float64           `json:"value,omitempty"`     // 触发指标值
+	Threshold float64           `json:"threshold,omitempty"` // 规则阈值
+	Details   map[string]string `json:"details,omitempty"`   // 其他上下文（区域上下沿、周期等）
+	FiredAt   time.Time         `json:"fired_at"`
+}
+
+// formatAlertTrigger 定向决策周期的警报说明（放在用户prompt开头）
+func formatAlertTrigger(t *AlertTrigger) string {
+	var sb strings.Builder
+	sb.WriteString("## ⚡ 警报触发的定向决策\n")
+	sb.WriteString(fmt.Sprintf("规则: %s | 币种: %s | 触发时间: %s\n", t.Rule, t.Symbol, t.FiredAt.Format("15:04:05")))
+	if t.Message != "" {
+		sb.WriteString(fmt.Sprintf("说明: %s\n", t.Message))
+	}
+	if t.Price > 0 || t.Value != 0 || t.Threshold != 0 {
+		sb.WriteString(fmt.Sprintf("触发价 %.4f | 指标值 %.4f | 阈值 %.4f\n", t.Price, t.Value, t.Threshold))
+	}
+	if len(t.Details) > 0 {
+		keys := make([]string, 0, len(t.Details))
+		for k := range t.Details {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sb.WriteString(fmt.Sprintf("- %s: %s\n", k, t.Details[k]))
+		}
+	}
+	sb.WriteString("本周期由警报提前触发（非定时周期），只需针对该币种给出决策；若警报不构成入场理由，请输出 wait。\n\n")
+	return sb.String()
+}
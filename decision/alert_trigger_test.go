@@ -0,0 +1,34 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatAlertTrigger(t *testing.T) {
+	alert := &AlertTrigger{
+		Rule:    "demand_zone",
+		Symbol:  "SOLUSDT",
+		Message: "价格进入4h需求区",
+		Price:   182.5,
+		Details: map[string]string{"zone_high": "184", "zone_low": "180"},
+		FiredAt: time.Date(2025, 1, 1, 8, 30, 0, 0, time.UTC),
+	}
+	text := formatAlertTrigger(alert)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"规则与币种", "规则: demand_zone | 币种: SOLUSDT"},
+		{"警报说明", "说明: 价格进入4h需求区"},
+		{"触发价", "触发价 182.5000"},
+		{"详情按键排序", "- zone_high: 184\n- zone_low: 180"},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(text, tt.want) {
+			t.Errorf("%s: 输出缺少 %q\n%s", tt.name, tt.want, text)
+		}
+	}
+}
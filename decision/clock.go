@@ -0,0 +1,21 @@
+package decision
+
+import (
+	"nofx/clock"
+	"time"
+)
+
+var decisionClock clock.Clock = clock.System
+
+// SetClock 替换decision包使用的时钟（决策时间戳、持仓时长、合规统计、AI调用排队耗时）
+func SetClock(c clock.Clock) {
+	decisionClock = c
+}
+
+func now() time.Time {
+	return decisionClock.Now()
+}
+
+func since(t time.Time) time.Duration {
+	return clock.Since(decisionClock, t)
+}
@@ -0,0 +1,37 @@
+package decision
+
+import (
+	"nofx/clock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildUserPromptHoldingDuration(t *testing.T) {
+	fixed := clock.NewManual(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	SetClock(fixed)
+	defer SetClock(clock.System)
+
+	tests := []struct {
+		name   string
+		opened time.Duration
+		want   string
+	}{
+		{"不足一小时按分钟", 45 * time.Minute, "持仓时长45分钟"},
+		{"超过一小时按小时和分钟", 2*time.Hour + 5*time.Minute, "持仓时长2小时5分钟"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &Context{
+				Positions: []PositionInfo{{
+					Symbol:     "BTCUSDT",
+					Side:       "long",
+					UpdateTime: fixed.Now().Add(-tt.opened).UnixMilli(),
+				}},
+			}
+			if prompt := buildUserPrompt(ctx); !strings.Contains(prompt, tt.want) {
+				t.Errorf("prompt missing %q", tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package decision
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 输出不合规类型
+const (
+	ConformanceJSONRepair      = "json_repair"      // <decision> 内容不是合法JSON，需要修复（全角字符/零宽字符等）才能解析
+	ConformanceMissingTag      = "missing_tag"      // 未使用 <decision> 标签，全文搜索JSON
+	ConformanceSafeFallback    = "safe_fallback"    // 未输出JSON，生成保底wait决策
+	ConformanceSchemaDowngrade = "schema_downgrade" // 未按要求的版本输出，按旧格式解析
+	ConformanceRejected        = "rejected"         // 解析失败或部分决策验证失败
+)
+
+// Conformance 单次AI响应的格式合规情况
+type Conformance struct {
+	Repaired bool     `json:"repaired"` // 需要JSON修复
+	Fallback bool     `json:"fallback"` // 走了后备解析路径
+	Rejected bool     `json:"rejected"` // 解析/验证被拒绝
+	Issues   []string `json:"issues,omitempty"`
+}
+
+// Conformant 是否完全合规（无需修复、无后备解析、未被拒绝）
+func (c Conformance) Conformant() bool {
+	return !c.Repaired && !c.Fallback && !c.Rejected
+}
+
+// assessConformance 评估AI响应的格式合规情况
+func assessConformance(aiResponse string, fd *FullDecision, parseErr error, requestedVersion string) Conformance {
+	var c Conformance
+	if match := reDecisionTag.FindStringSubmatch(aiResponse); match != nil && len(match) > 1 {
+		// 标签内容（去掉代码块包装）不是合法JSON、但最终解析成功，说明经过了修复
+		payload := strings.TrimSpace(match[1])
+		if m := reJSONFence.FindStringSubmatch(payload); m != nil && len(m) > 1 {
+			payload = strings.TrimSpace(m[1])
+		}
+		if !json.Valid([]byte(payload)) && parseErr == nil {
+			c.Repaired = true
+			c.Issues = append(c.Issues, ConformanceJSONRepair)
+		}
+	} else {
+		c.Fallback = true
+		c.Issues = append(c.Issues, ConformanceMissingTag)
+	}
+	if fd != nil && len(fd.Decisions) == 1 && fd.Decisions[0].Symbol == "ALL" && fd.Decisions[0].Action == "wait" {
+		c.Fallback = true
+		c.Issues = append(c.Issues, ConformanceSafeFallback)
+	}
+	if fd != nil && fd.SchemaVersion != "" && fd.SchemaVersion != requestedVersion {
+		c.Fallback = true
+		c.Issues = append(c.Issues, ConformanceSchemaDowngrade)
+	}
+	if parseErr != nil || (fd != nil && len(fd.Rejected) > 0) {
+		c.Rejected = true
+		c.Issues = append(c.Issues, ConformanceRejected)
+	}
+	return c
+}
+
+// ConformanceStats 模型+模板组合的输出合规统计
+type ConformanceStats struct {
+	Model               string         `json:"model"`
+	Template            string         `json:"template"`
+	Responses           int            `json:"responses"`            // 响应总数
+	Conformant          int            `json:"conformant"`           // 完全合规的响应数
+	Repaired            int            `json:"repaired"`             // 需要JSON修复
+	Fallback            int            `json:"fallback"`             // 走后备解析
+	Rejected            int            `json:"rejected"`             // 解析/验证被拒绝
+	ConformancePct      float64        `json:"conformance_pct"`      // 合规率
+	ConsecutiveFailures int            `json:"consecutive_failures"` // 当前连续不合规次数
+	Issues              map[string]int `json:"issues"`               // 按问题类型统计
+	LastSeen            time.Time      `json:"last_seen"`
+}
+
+// conformanceTracker 全局输出合规统计（多个trader共享，按模型+模板聚合）
+type conformanceTracker struct {
+	mu    sync.Mutex
+	stats map[string]*ConformanceStats
+}
+
+var globalConformance = &conformanceTracker{stats: make(map[string]*ConformanceStats)}
+
+// RecordConformance 记录一次AI响应的合规情况，返回该模型+模板的最新统计
+func RecordConformance(model, template string, c Conformance) ConformanceStats {
+	return globalConformance.record(model, template, c)
+}
+
+// GetConformanceStats 获取所有模型+模板组合的合规统计（按模型、模板排序）
+func GetConformanceStats() []ConformanceStats {
+	return globalConformance.all()
+}
+
+// SelectConformantTemplate 为模型选择合规率最高的其他模板
+// 只考虑样本数 ≥ minSamples 且合规率高于当前模板的候选；都不满足时若 default 模板尚无样本则试用 default，否则返回空字符串
+func SelectConformantTemplate(model, current string, candidates []string, minSamples int) string {
+	return globalConformance.selectTemplate(model, current, candidates, minSamples)
+}
+
+func (t *conformanceTracker) record(model, template string, c Conformance) ConformanceStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := model + "|" + template
+	s, ok := t.stats[key]
+	if !ok {
+		s = &ConformanceStats{Model: model, Template: template, Issues: make(map[string]int)}
+		t.stats[key] = s
+	}
+	s.Responses++
+	s.LastSeen = now()
+	if c.Conformant() {
+		s.Conformant++
+		s.ConsecutiveFailures = 0
+	} else {
+		s.ConsecutiveFailures++
+	}
+	if c.Repaired {
+		s.Repaired++
+	}
+	if c.Fallback {
+		s.Fallback++
+	}
+	if c.Rejected {
+		s.Rejected++
+	}
+	for _, issue := range c.Issues {
+		s.Issues[issue]++
+	}
+	s.ConformancePct = float64(s.Conformant) / float64(s.Responses) * 100
+	return copyConformanceStats(s)
+}
+
+func (t *conformanceTracker) all() []ConformanceStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]ConformanceStats, 0, len(t.stats))
+	for _, s := range t.stats {
+		result = append(result, copyConformanceStats(s))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Model != result[j].Model {
+			return result[i].Model < result[j].Model
+		}
+		return result[i].Template < result[j].Template
+	})
+	return result
+}
+
+func (t *conformanceTracker) selectTemplate(model, current string, candidates []string, minSamples int) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	threshold := 0.0
+	if s, ok := t.stats[model+"|"+current]; ok {
+		threshold = s.ConformancePct
+	}
+
+	best, bestPct := "", threshold
+	for _, name := range candidates {
+		if name == current {
+			continue
+		}
+		s, ok := t.stats[model+"|"+name]
+		if !ok || s.Responses < minSamples {
+			continue
+		}
+		if s.ConformancePct > bestPct {
+			best, bestPct = name, s.ConformancePct
+		}
+	}
+	if best != "" || current == "default" {
+		return best
+	}
+	for _, name := range candidates {
+		if _, tried := t.stats[model+"|"+name]; name == "default" && !tried {
+			return name
+		}
+	}
+	return ""
+}
+
+func copyConformanceStats(s *ConformanceStats) ConformanceStats {
+	c := *s
+	c.Issues = make(map[string]int, len(s.Issues))
+	for k, v := range s.Issues {
+		c.Issues[k] = v
+	}
+	return c
+}
@@ -0,0 +1,106 @@
+package decision
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAssessConformance(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   string
+		fd         *FullDecision
+		err        error
+		conformant bool
+		issues     []string
+	}{
+		{
+			name:       "标准v2输出",
+			response:   "分析...<decision>{\"schema_version\":\"2\",\"decisions\":[]}</decision>",
+			fd:         &FullDecision{SchemaVersion: "2"},
+			conformant: true,
+		},
+		{
+			name:     "全角字符需要修复",
+			response: "<decision>［{\u201csymbol\u201d:\u201cBTCUSDT\u201d}］</decision>",
+			fd:       &FullDecision{SchemaVersion: "2"},
+			issues:   []string{ConformanceJSONRepair},
+		},
+		{
+			name:     "无标签且只输出思维链",
+			response: "市场震荡，继续观望",
+			fd:       &FullDecision{SchemaVersion: "1", Decisions: []Decision{{Symbol: "ALL", Action: "wait"}}},
+			issues:   []string{ConformanceMissingTag, ConformanceSafeFallback, ConformanceSchemaDowngrade},
+		},
+		{
+			name:     "验证失败",
+			response: "<decision>{\"schema_version\":\"2\",\"decisions\":[]}</decision>",
+			fd:       &FullDecision{SchemaVersion: "2"},
+			err:      errors.New("决策验证失败"),
+			issues:   []string{ConformanceRejected},
+		},
+		{
+			name:     "部分决策验证失败",
+			response: "<decision>{\"schema_version\":\"2\",\"decisions\":[]}</decision>",
+			fd:       &FullDecision{SchemaVersion: "2", Rejected: []RejectedDecision{{Reason: "杠杆超限"}}},
+			issues:   []string{ConformanceRejected},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := assessConformance(tt.response, tt.fd, tt.err, SchemaVersionV2)
+			if c.Conformant() != tt.conformant {
+				t.Errorf("Conformant() = %v, want %v (issues %v)", c.Conformant(), tt.conformant, c.Issues)
+			}
+			if len(c.Issues) != len(tt.issues) {
+				t.Fatalf("issues = %v, want %v", c.Issues, tt.issues)
+			}
+			for i := range c.Issues {
+				if c.Issues[i] != tt.issues[i] {
+					t.Errorf("issues[%d] = %s, want %s", i, c.Issues[i], tt.issues[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSelectConformantTemplate(t *testing.T) {
+	tracker := &conformanceTracker{stats: make(map[string]*ConformanceStats)}
+	bad := Conformance{Fallback: true, Issues: []string{ConformanceMissingTag}}
+	for i := 0; i < 5; i++ {
+		tracker.record("deepseek", "adaptive", bad)
+		tracker.record("deepseek", "aggressive", Conformance{})
+	}
+	tracker.record("deepseek", "conservative", Conformance{}) // 样本不足
+	candidates := []string{"adaptive", "aggressive", "conservative", "default"}
+
+	if got := tracker.selectTemplate("deepseek", "adaptive", candidates, 5); got != "aggressive" {
+		t.Errorf("selectTemplate = %s, want aggressive", got)
+	}
+	if got := tracker.selectTemplate("deepseek", "aggressive", candidates, 5); got != "default" {
+		t.Errorf("没有更合规的模板时应试用 default, got %s", got)
+	}
+	if got := tracker.selectTemplate("qwen", "default", candidates, 5); got != "" {
+		t.Errorf("没有样本时不应切换, got %s", got)
+	}
+}
+
+func TestParseFullDecisionPartialRejection(t *testing.T) {
+	response := "<decision>{\"schema_version\": \"2\", \"decisions\": [" +
+		"{\"symbol\": \"ETHUSDT\", \"action\": \"close_long\", \"reasoning\": \"止盈\"}," +
+		"{\"symbol\": \"SOLUSDT\", \"action\": \"open_long\", \"leverage\": 50, \"position_size_usd\": 500, \"stop_loss\": 95, \"take_profit\": 120, \"reasoning\": \"突破\"}," +
+		"{\"symbol\": \"BTCUSDT\", \"action\": \"hold\", \"reasoning\": \"持有\"}]}</decision>"
+
+	fd, err := parseFullDecisionResponse(response, 2000, 10, 5, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fd.Decisions) != 2 || fd.Decisions[0].Symbol != "ETHUSDT" || fd.Decisions[1].Symbol != "BTCUSDT" {
+		t.Errorf("valid decisions = %+v, want ETHUSDT and BTCUSDT", fd.Decisions)
+	}
+	if len(fd.Rejected) != 1 || fd.Rejected[0].Decision.Symbol != "SOLUSDT" || !strings.Contains(fd.Rejected[0].Reason, "决策 #2") {
+		t.Errorf("rejected = %+v, want SOLUSDT as #2", fd.Rejected)
+	}
+}
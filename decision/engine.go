@@ -2,12 +2,15 @@ package decision
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"nofx/loglevel"
 	"nofx/market"
 	"nofx/mcp"
 	"nofx/pool"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
@@ -72,8 +75,7 @@ type OITopData struct {
 // Context 交易上下文（传递给AI的完整信息）
 type Context struct {
 	CurrentTime     string                  `json:"current_time"`
-	RuntimeMinutes  int                     `json:"runtime_minutes"`
-	CallCount       int                     `json:"call_count"`
+	RecentHistory   string                  `json:"recent_history"` // 近期回顾（运行时长、净值轨迹、开平仓与风控事件）
 	Account         AccountInfo             `json:"account"`
 	Positions       []PositionInfo          `json:"positions"`
 	CandidateCoins  []CandidateCoin         `json:"candidate_coins"`
@@ -82,6 +84,23 @@ type Context struct {
 	Performance     interface{}             `json:"-"` // 历史表现分析（logger.PerformanceAnalysis）
 	BTCETHLeverage  int                     `json:"-"` // BTC/ETH杠杆倍数（从配置读取）
 	AltcoinLeverage int                     `json:"-"` // 山寨币杠杆倍数（从配置读取）
+	TraderID        string                  `json:"-"` // 所属trader（用于全局公平调度）
+	SymbolMemories  map[string]string       `json:"-"` // 币种记忆摘要（symbol -> 最近交易结果与备注）
+	SchemaVersion   string                  `json:"-"` // 要求AI使用的决策输出版本（为空使用当前版本）
+	Verbosity       string                  `json:"-"` // 市场数据输出详细程度：brief / standard / full（为空使用 standard）
+	Liquidity       LiquidityLimits         `json:"-"` // 流动性仓位上限（按持仓量/24h成交额限制单币种仓位）
+	Correlations    []market.CorrelatedPair `json:"-"` // 持仓与候选币种中的高相关币种对（4h收益率）
+	Indicators      []market.IndicatorDef   `json:"-"` // 交易员配置的指标集（为空时只输出固定指标）
+	Timeframes      []string                `json:"-"` // 交易员选择的分析周期（短 → 长，为空使用默认 3m/4h）
+	CandidateMaxAge time.Duration           `json:"-"` // 非持仓候选币种可复用的缓存分析时长（0=每周期重新计算）
+	Trigger         *AlertTrigger           `json:"-"` // 警报触发的定向周期（为空表示定时周期）
+	Persona         *RiskPersona            `json:"-"` // 风险偏好档位（为空使用模板默认规则）
+	TradeIdeas      []TradeIdeaBrief        `json:"-"` // 待AI评估的外部交易想法
+	WatchOnly       []string                `json:"-"` // 仅观察币种（完整分析但不允许开仓）
+	ToolBudget      int                     `json:"-"` // 本周期允许模型调用数据工具的次数（0 = 关闭工具调用）
+
+	TimeframeReliability map[string]map[string]TimeframeReliability `json:"-"` // 币种 -> 周期 -> 趋势信号实际命中率（用于周期一致性加权）
+	StaleData            map[string][]market.StaleTimeframe         `json:"-"` // 最新K线落后于预期的币种（新开仓会被转为 wait）
 }
 
 // Decision AI的交易决策
@@ -98,21 +117,67 @@ type Decision struct {
 	// 调整参数（新增）
 	NewStopLoss     float64 `json:"new_stop_loss,omitempty"`    // 用于 update_stop_loss
 	NewTakeProfit   float64 `json:"new_take_profit,omitempty"`  // 用于 update_take_profit
-	ClosePercentage float64 `json:"close_percentage,omitempty"` // 用于 partial_close (0-100)
+	ClosePercentage float64 `json:"close_percentage,omitempty"` // 用于 partial_close (0-100)，按实时持仓数量的百分比
+	ReducePct       float64 `json:"reduce_pct,omitempty"`       // close_percentage 的同义字段（验证时合并到 ClosePercentage）
+	SizeUSD         float64 `json:"size_usd,omitempty"`         // 名义价值：开仓时合并到 PositionSizeUSD，partial_close 时为减仓名义价值
+	QtyContracts    float64 `json:"qty_contracts,omitempty"`    // 用于 partial_close，按合约数量（币数）减仓
 
 	// 通用参数
 	Confidence int     `json:"confidence,omitempty"` // 信心度 (0-100)
 	RiskUSD    float64 `json:"risk_usd,omitempty"`   // 最大美元风险
 	Reasoning  string  `json:"reasoning"`
+
+	// 从理由中提取的结构化标签（系统生成，AI无需输出）
+	Tags *ReasoningTags `json:"tags,omitempty"`
+
+	// 下单路由提示（可选）
+	Routing *RoutingHint `json:"routing,omitempty"`
+
+	// 对应的外部交易想法（评估交易想法时填写）
+	IdeaID string `json:"idea_id,omitempty"`
+}
+
+// RoutingHint AI给出的开仓下单方式提示
+type RoutingHint struct {
+	PostOnly   bool    `json:"post_only"`             // 希望以Maker挂单入场（非紧急信号，如区间边缘限价）
+	LimitPrice float64 `json:"limit_price,omitempty"` // 挂单价格（为空或不在被动方向时使用当前价）
+	Urgency    string  `json:"urgency,omitempty"`     // high=时效性强，必须立即成交
 }
 
 // FullDecision AI的完整决策（包含思维链）
 type FullDecision struct {
-	SystemPrompt string     `json:"system_prompt"` // 系统提示词（发送给AI的系统prompt）
-	UserPrompt   string     `json:"user_prompt"`   // 发送给AI的输入prompt
-	CoTTrace     string     `json:"cot_trace"`     // 思维链分析（AI输出）
-	Decisions    []Decision `json:"decisions"`     // 具体决策列表
-	Timestamp    time.Time  `json:"timestamp"`
+	SystemPrompt  string             `json:"system_prompt"`         // 系统提示词（发送给AI的系统prompt）
+	UserPrompt    string             `json:"user_prompt"`           // 发送给AI的输入prompt
+	CoTTrace      string             `json:"cot_trace"`             // 思维链分析（AI输出）
+	Decisions     []Decision         `json:"decisions"`             // 具体决策列表
+	SchemaVersion string             `json:"schema_version"`        // AI实际输出的决策格式版本
+	Incidents     []DecisionIncident `json:"incidents,omitempty"`   // 与实际持仓/prompt不符的决策（模型质量问题）
+	Conformance   Conformance        `json:"conformance"`           // AI输出格式合规情况
+	Rejected      []RejectedDecision `json:"rejected,omitempty"`    // 未通过验证的决策（不影响其余决策执行）
+	Degradation   *PromptDegradation `json:"degradation,omitempty"` // 上下文超限后降级重试（模型本周期看到的数据被精简）
+	Sampling      mcp.SamplingParams `json:"sampling"`              // 实际发送的采样参数（温度、top_p、max_tokens、seed）
+	ToolCalls     []ToolCall         `json:"tool_calls,omitempty"`  // 模型在决策前请求的数据工具调用
+	Timestamp     time.Time          `json:"timestamp"`
+}
+
+// RejectedDecision 未通过验证的决策及原因
+type RejectedDecision struct {
+	Decision Decision `json:"decision"`
+	Reason   string   `json:"reason"`
+}
+
+// PromptDegradation 上下文超限降级记录
+type PromptDegradation struct {
+	FromVerbosity string `json:"from_verbosity"`
+	ToVerbosity   string `json:"to_verbosity"`
+	OriginalChars int    `json:"original_chars"` // 原 User Prompt 长度
+	ReducedChars  int    `json:"reduced_chars"`  // 降级后 User Prompt 长度
+	Error         string `json:"error"`          // 提供商返回的超限错误
+}
+
+// String 降级说明（写入决策记录）
+func (d *PromptDegradation) String() string {
+	return fmt.Sprintf("上下文超限，市场数据由 %s 降级为 %s 重试（%d → %d 字符）", d.FromVerbosity, d.ToVerbosity, d.OriginalChars, d.ReducedChars)
 }
 
 // GetFullDecision 获取AI的完整交易决策（批量分析所有币种和持仓）
@@ -122,28 +187,89 @@ func GetFullDecision(ctx *Context, mcpClient *mcp.Client) (*FullDecision, error)
 
 // GetFullDecisionWithCustomPrompt 获取AI的完整交易决策（支持自定义prompt和模板选择）
 func GetFullDecisionWithCustomPrompt(ctx *Context, mcpClient *mcp.Client, customPrompt string, overrideBase bool, templateName string) (*FullDecision, error) {
-	// 1. 为所有币种获取市场数据
-	if err := fetchMarketDataForContext(ctx); err != nil {
+	// 1. 为所有币种获取市场数据（全局限流，多trader之间轮询；单币种交易员走快速路径，不参与排队）
+	var err error
+	if symbol := singleSymbolFastPath(ctx); symbol != "" {
+		err = fetchSingleSymbolMarketData(ctx, symbol)
+	} else {
+		releaseFetch := marketFetchScheduler.Acquire(ctx.TraderID)
+		err = fetchMarketDataForContext(ctx)
+		releaseFetch()
+	}
+	if err != nil {
 		return nil, fmt.Errorf("获取市场数据失败: %w", err)
 	}
 
 	// 2. 构建 System Prompt（固定规则）和 User Prompt（动态数据）
-	systemPrompt := buildSystemPromptWithCustom(ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage, customPrompt, overrideBase, templateName)
+	schemaVersion := normalizeSchemaVersion(ctx.SchemaVersion)
+	systemPrompt := buildSystemPromptWithCustom(ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage, customPrompt, overrideBase, templateName, schemaVersion, ctx.Persona)
 	userPrompt := buildUserPrompt(ctx)
+	if ctx.ToolBudget > 0 {
+		systemPrompt += formatToolUsage(ctx.ToolBudget)
+	}
 
-	// 3. 调用AI API（使用 system + user prompt）
+	// 3. 调用AI API（使用 system + user prompt，全局限流，多trader之间轮询）
+	releaseAI := aiCallScheduler.Acquire(ctx.TraderID)
 	aiResponse, err := mcpClient.CallWithMessages(systemPrompt, userPrompt)
+	releaseAI()
+
+	// 上下文超限：以最精简的市场数据重建 User Prompt 并重试一次
+	var degradation *PromptDegradation
+	if errors.Is(err, mcp.ErrContextOverflow) {
+		if fromVerbosity := market.NormalizeVerbosity(ctx.Verbosity); fromVerbosity != market.VerbosityBrief {
+			ctx.Verbosity = market.VerbosityBrief
+			reducedPrompt := buildUserPrompt(ctx)
+			degradation = &PromptDegradation{
+				FromVerbosity: fromVerbosity,
+				ToVerbosity:   market.VerbosityBrief,
+				OriginalChars: len(userPrompt),
+				ReducedChars:  len(reducedPrompt),
+				Error:         err.Error(),
+			}
+			loglevel.Warnf(loglevel.Decision, "⚠️  [%s] %s", ctx.TraderID, degradation)
+			userPrompt = reducedPrompt
+
+			releaseAI = aiCallScheduler.Acquire(ctx.TraderID)
+			aiResponse, err = mcpClient.CallWithMessages(systemPrompt, userPrompt)
+			releaseAI()
+			if err != nil {
+				return nil, fmt.Errorf("调用AI API失败（%s）: %w", degradation, err)
+			}
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("调用AI API失败: %w", err)
 	}
 
+	// 模型请求了额外数据：执行工具并继续对话，直到输出最终决策或次数用完
+	var toolCalls []ToolCall
+	if ctx.ToolBudget > 0 {
+		aiResponse, toolCalls, err = runToolLoop(ctx, mcpClient, systemPrompt, userPrompt, aiResponse)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// 4. 解析AI响应
-	decision, err := parseFullDecisionResponse(aiResponse, ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+	decision, err := parseFullDecisionResponse(aiResponse, ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage, liquidityCaps(ctx.MarketDataMap, ctx.Liquidity))
+	decision.Conformance = assessConformance(aiResponse, decision, err, schemaVersion)
+	decision.Degradation = degradation
+	decision.Sampling = mcpClient.EffectiveSampling()
+	decision.ToolCalls = toolCalls
 	if err != nil {
 		return decision, fmt.Errorf("解析AI响应失败: %w", err)
 	}
+	if decision.SchemaVersion != schemaVersion {
+		loglevel.Warnf(loglevel.Decision, "⚠️  AI输出的决策格式版本 v%s 与要求的 v%s 不一致（已按 v%s 解析）", decision.SchemaVersion, schemaVersion, decision.SchemaVersion)
+	}
 
-	decision.Timestamp = time.Now()
+	// 5. 交叉校验决策与实际持仓/候选币种（仅记录，不拦截）
+	decision.Incidents = DetectHallucinations(ctx, decision.Decisions)
+	for _, incident := range decision.Incidents {
+		loglevel.Warnf(loglevel.Decision, "⚠️  模型质量问题 [%s] %s %s: %s", incident.Type, incident.Symbol, incident.Action, incident.Detail)
+	}
+
+	decision.Timestamp = now()
 	decision.SystemPrompt = systemPrompt // 保存系统prompt
 	decision.UserPrompt = userPrompt     // 保存输入prompt
 	return decision, nil
@@ -178,12 +304,34 @@ func fetchMarketDataForContext(ctx *Context) error {
 		positionSymbols[pos.Symbol] = true
 	}
 
+	// 分层获取：持仓币种每周期重新计算（平仓/止损判断依赖最新数据），
+	// 非持仓候选币种可复用不超过 CandidateMaxAge（一根K线）的缓存分析，减少大候选池的API和计算开销
+	cachedCount := 0
 	for symbol := range symbolSet {
-		data, err := market.Get(symbol)
+		var data *market.Data
+		var err error
+		cached := false
+		if positionSymbols[symbol] {
+			data, err = market.GetFreshWithTimeframes(symbol, ctx.Timeframes)
+		} else {
+			data, cached, err = market.GetCachedWithTimeframes(symbol, ctx.Timeframes, ctx.CandidateMaxAge)
+		}
 		if err != nil {
 			// 单个币种失败不影响整体，只记录错误
 			continue
 		}
+		if cached {
+			cachedCount++
+		}
+		if len(ctx.Indicators) > 0 {
+			data.Indicators = market.ComputeIndicators(data, ctx.Indicators)
+		}
+
+		// 上报成交量/OI观测值，出现异动时候选池会提前刷新（缓存数据在计算时已上报）
+		if !cached && data.LongerTermContext != nil && data.OpenInterest != nil {
+			pool.ReportMarketActivity(symbol, data.LongerTermContext.CurrentVolume,
+				data.LongerTermContext.AverageVolume, data.OpenInterest.Latest)
+		}
 
 		// ⚠️ 流动性过滤：持仓价值低于阈值的币种不做（多空都不做）
 		// 持仓价值 = 持仓量 × 当前价格
@@ -197,8 +345,8 @@ func fetchMarketDataForContext(ctx *Context) error {
 			oiValue := data.OpenInterest.Latest * data.CurrentPrice
 			oiValueInMillions := oiValue / 1_000_000 // 转换为百万美元单位
 			if oiValueInMillions < minOIThresholdMillions {
-				log.Printf("⚠️  %s 持仓价值过低(%.2fM USD < %.1fM)，跳过此币种 [持仓量:%.0f × 价格:%.4f]",
-					symbol, oiValueInMillions, minOIThresholdMillions, data.OpenInterest.Latest, data.CurrentPrice)
+				loglevel.Sampledf(loglevel.Decision, loglevel.LevelInfo, "low_oi:"+symbol, loglevel.DefaultSampleInterval,
+					"⚠️  %s 持仓价值过低(%.2fM USD < %.1fM)，跳过此币种 [持仓量:%.0f × 价格:%.4f]", symbol, oiValueInMillions, minOIThresholdMillions, data.OpenInterest.Latest, data.CurrentPrice)
 				continue
 			}
 		}
@@ -206,6 +354,10 @@ func fetchMarketDataForContext(ctx *Context) error {
 		ctx.MarketDataMap[symbol] = data
 	}
 
+	if cachedCount > 0 {
+		loglevel.Debugf(loglevel.Decision, "♻️  %d/%d 个币种复用缓存分析（≤%s）", cachedCount, len(symbolSet), ctx.CandidateMaxAge)
+	}
+
 	// 加载OI Top数据（不影响主流程）
 	oiPositions, err := pool.GetOITopPositions()
 	if err == nil {
@@ -223,9 +375,68 @@ func fetchMarketDataForContext(ctx *Context) error {
 		}
 	}
 
+	// 高相关币种对（不影响主流程）
+	ctx.Correlations = findCorrelatedPairs(ctx.MarketDataMap)
+
+	// 行情数据陈旧的币种（WS延迟、交易所故障时最新K线不再更新）
+	ctx.StaleData = staleMarketData(ctx.MarketDataMap)
+	for symbol, tfs := range ctx.StaleData {
+		loglevel.Warnf(loglevel.Decision, "⚠️  %s 行情数据延迟（%s），本周期禁止新开仓", symbol, tfs[0])
+	}
+
 	return nil
 }
 
+// findCorrelatedPairs 计算已获取市场数据的币种之间4h收益率相关性，返回最相关的若干对
+func findCorrelatedPairs(marketDataMap map[string]*market.Data) []market.CorrelatedPair {
+	const (
+		promptCorrelationThreshold = 0.8
+		maxPromptCorrelationPairs  = 10
+	)
+	if len(marketDataMap) < 2 {
+		return nil
+	}
+	symbols := make([]string, 0, len(marketDataMap))
+	for symbol := range marketDataMap {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	matrix, err := market.GetCorrelationMatrix(symbols, "4h", 0)
+	if err != nil {
+		log.Printf("⚠️  计算币种相关性失败: %v", err)
+		return nil
+	}
+	pairs := matrix.HighlyCorrelated(promptCorrelationThreshold)
+	if len(pairs) > maxPromptCorrelationPairs {
+		pairs = pairs[:maxPromptCorrelationPairs]
+	}
+	return pairs
+}
+
+// writeCorrelations 输出高相关币种对（精简形式），提示AI避免同向叠加高相关仓位
+func writeCorrelations(sb *strings.Builder, ctx *Context) {
+	if len(ctx.Correlations) == 0 {
+		return
+	}
+	held := make(map[string]string)
+	for _, pos := range ctx.Positions {
+		held[pos.Symbol] = pos.Side
+	}
+	mark := func(symbol string) string {
+		if side, ok := held[symbol]; ok {
+			return fmt.Sprintf("%s(持%s)", symbol, side)
+		}
+		return symbol
+	}
+
+	sb.WriteString("## 高相关币种（4h收益率相关系数）\n\n")
+	for _, p := range ctx.Correlations {
+		sb.WriteString(fmt.Sprintf("%s ~ %s: %.2f\n", mark(p.A), mark(p.B), p.Correlation))
+	}
+	sb.WriteString("同向持有多个高相关币种等于放大同一风险敞口，新开仓前请考虑已有仓位\n\n")
+}
+
 // calculateMaxCandidates 根据账户状态计算需要分析的候选币种数量
 func calculateMaxCandidates(ctx *Context) int {
 	// ⚠️ 重要：限制候选币种数量，避免 Prompt 过大
@@ -256,14 +467,14 @@ func calculateMaxCandidates(ctx *Context) int {
 }
 
 // buildSystemPromptWithCustom 构建包含自定义内容的 System Prompt
-func buildSystemPromptWithCustom(accountEquity float64, btcEthLeverage, altcoinLeverage int, customPrompt string, overrideBase bool, templateName string) string {
+func buildSystemPromptWithCustom(accountEquity float64, btcEthLeverage, altcoinLeverage int, customPrompt string, overrideBase bool, templateName, schemaVersion string, persona *RiskPersona) string {
 	// 如果覆盖基础prompt且有自定义prompt，只使用自定义prompt
 	if overrideBase && customPrompt != "" {
 		return customPrompt
 	}
 
 	// 获取基础prompt（使用指定的模板）
-	basePrompt := buildSystemPrompt(accountEquity, btcEthLeverage, altcoinLeverage, templateName)
+	basePrompt := buildSystemPrompt(accountEquity, btcEthLeverage, altcoinLeverage, templateName, schemaVersion, persona)
 
 	// 如果没有自定义prompt，直接返回基础prompt
 	if customPrompt == "" {
@@ -282,9 +493,19 @@ func buildSystemPromptWithCustom(accountEquity float64, btcEthLeverage, altcoinL
 	return sb.String()
 }
 
+// promptAuditReferenceEquity 计算规范化 System Prompt 时使用的固定账户净值
+// （System Prompt 中的仓位上限随净值浮动，固定净值后只有规则/模板/杠杆变化才会产生差异）
+const promptAuditReferenceEquity = 1000.0
+
+// CanonicalSystemPrompt 构建用于变更审计的规范化 System Prompt（与实际发送的内容一致，仅净值固定）
+func CanonicalSystemPrompt(btcEthLeverage, altcoinLeverage int, customPrompt string, overrideBase bool, templateName, schemaVersion string, persona *RiskPersona) string {
+	return buildSystemPromptWithCustom(promptAuditReferenceEquity, btcEthLeverage, altcoinLeverage, customPrompt, overrideBase, templateName, normalizeSchemaVersion(schemaVersion), persona)
+}
+
 // buildSystemPrompt 构建 System Prompt（使用模板+动态部分）
-func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage int, templateName string) string {
+func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage int, templateName, schemaVersion string, persona *RiskPersona) string {
 	var sb strings.Builder
+	rules := promptPersona(persona)
 
 	// 1. 加载提示词模板（核心交易策略部分）
 	if templateName == "" {
@@ -309,10 +530,13 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage in
 		sb.WriteString("\n\n")
 	}
 
+	// 风险偏好档位（持仓周期、信心度门槛）
+	writeRiskPersona(&sb, persona)
+
 	// 2. 硬约束（风险控制）- 动态生成
 	sb.WriteString("# 硬约束（风险控制）\n\n")
-	sb.WriteString("1. 风险回报比: 必须 ≥ 1:3（冒1%风险，赚3%+收益）\n")
-	sb.WriteString("2. 最多持仓: 3个币种（质量>数量）\n")
+	sb.WriteString(fmt.Sprintf("1. 风险回报比: 必须 ≥ 1:%g（冒1%%风险，赚%g%%+收益）\n", rules.MinRiskReward, rules.MinRiskReward))
+	sb.WriteString(fmt.Sprintf("2. 最多持仓: %d个币种（质量>数量）\n", rules.MaxPositions))
 	sb.WriteString(fmt.Sprintf("3. 单币仓位: 山寨%.0f-%.0f U | BTC/ETH %.0f-%.0f U\n",
 		accountEquity*0.8, accountEquity*1.5, accountEquity*5, accountEquity*10))
 	sb.WriteString(fmt.Sprintf("4. 杠杆限制: **山寨币最大%dx杠杆** | **BTC/ETH最大%dx杠杆** (⚠️ 严格执行，不可超过)\n", altcoinLeverage, btcEthLeverage))
@@ -328,26 +552,48 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage in
 	sb.WriteString("- 简洁分析你的思考过程 \n")
 	sb.WriteString("</reasoning>\n\n")
 	sb.WriteString("<decision>\n")
-	sb.WriteString("```json\n[\n")
-	sb.WriteString(fmt.Sprintf("  {\"symbol\": \"BTCUSDT\", \"action\": \"open_short\", \"leverage\": %d, \"position_size_usd\": %.0f, \"stop_loss\": 97000, \"take_profit\": 91000, \"confidence\": 85, \"risk_usd\": 300, \"reasoning\": \"下跌趋势+MACD死叉\"},\n", btcEthLeverage, accountEquity*5))
-	sb.WriteString("  {\"symbol\": \"ETHUSDT\", \"action\": \"close_long\", \"reasoning\": \"止盈离场\"}\n")
-	sb.WriteString("]\n```\n")
+	writeDecisionExample(&sb, accountEquity, btcEthLeverage, schemaVersion)
 	sb.WriteString("</decision>\n\n")
 	sb.WriteString("## 字段说明\n\n")
-	sb.WriteString("- `action`: open_long | open_short | close_long | close_short | hold | wait\n")
-	sb.WriteString("- `confidence`: 0-100（开仓建议≥75）\n")
-	sb.WriteString("- 开仓时必填: leverage, position_size_usd, stop_loss, take_profit, confidence, risk_usd, reasoning\n\n")
+	if schemaVersion != SchemaVersionV1 {
+		sb.WriteString(fmt.Sprintf("- `schema_version`: 固定为 \"%s\"（必填，系统据此选择解析格式）\n", schemaVersion))
+	}
+	sb.WriteString("- `action`: open_long | open_short | close_long | close_short | partial_close | hold | wait\n")
+	sb.WriteString(fmt.Sprintf("- `confidence`: 0-100（开仓建议≥%d）\n", rules.MinConfidence))
+	sb.WriteString("- 开仓时必填: leverage, position_size_usd, stop_loss, take_profit, confidence, risk_usd, reasoning\n")
+	sb.WriteString("- 部分平仓 `partial_close` 三选一（含义不同，只能填一个）: `reduce_pct` 按实时持仓数量的百分比（0-100）| `size_usd` 减仓名义价值（USDT）| `qty_contracts` 减仓合约数量（币数）\n")
+	sb.WriteString("- `position_size_usd` / `size_usd` 永远是USDT名义价值，不是币数；close_long / close_short 为全部平仓，不要附带数量\n")
+	sb.WriteString("- 翻仓: 持有多仓时直接给出 open_short（或持空仓时给出 open_long），系统会先平掉反向仓位再开新仓\n")
+	sb.WriteString("- `routing`（可选，仅开仓）: {\"post_only\": true, \"limit_price\": 95000} 表示信号不紧急、希望挂单Maker入场；{\"urgency\": \"high\"} 表示必须立即市价成交\n\n")
 
 	return sb.String()
 }
 
+// writeSymbolMemory 写入币种记忆（最近交易结果和备注），提醒AI避免重复近期的错误
+func writeSymbolMemory(sb *strings.Builder, ctx *Context, symbol string) {
+	if memory, ok := ctx.SymbolMemories[symbol]; ok && memory != "" {
+		sb.WriteString(fmt.Sprintf("📝 近期记忆: %s\n\n", memory))
+	}
+}
+
 // buildUserPrompt 构建 User Prompt（动态数据）
 func buildUserPrompt(ctx *Context) string {
 	var sb strings.Builder
 
 	// 系统状态
-	sb.WriteString(fmt.Sprintf("时间: %s | 周期: #%d | 运行: %d分钟\n\n",
-		ctx.CurrentTime, ctx.CallCount, ctx.RuntimeMinutes))
+	sb.WriteString(fmt.Sprintf("时间: %s\n\n", ctx.CurrentTime))
+
+	// 警报触发的定向周期
+	if ctx.Trigger != nil {
+		sb.WriteString(formatAlertTrigger(ctx.Trigger))
+	}
+
+	// 近期回顾
+	if ctx.RecentHistory != "" {
+		sb.WriteString("## 近期回顾\n")
+		sb.WriteString(ctx.RecentHistory)
+		sb.WriteString("\n")
+	}
 
 	// BTC 市场
 	if btcData, hasBTC := ctx.MarketDataMap["BTCUSDT"]; hasBTC {
@@ -372,7 +618,7 @@ func buildUserPrompt(ctx *Context) string {
 			// 计算持仓时长
 			holdingDuration := ""
 			if pos.UpdateTime > 0 {
-				durationMs := time.Now().UnixMilli() - pos.UpdateTime
+				durationMs := now().UnixMilli() - pos.UpdateTime
 				durationMin := durationMs / (1000 * 60) // 转换为分钟
 				if durationMin < 60 {
 					holdingDuration = fmt.Sprintf(" | 持仓时长%d分钟", durationMin)
@@ -387,10 +633,13 @@ func buildUserPrompt(ctx *Context) string {
 				i+1, pos.Symbol, strings.ToUpper(pos.Side),
 				pos.EntryPrice, pos.MarkPrice, pos.UnrealizedPnLPct,
 				pos.Leverage, pos.MarginUsed, pos.LiquidationPrice, holdingDuration))
+			writeSymbolMemory(&sb, ctx, pos.Symbol)
+			writeStaleness(&sb, ctx, pos.Symbol)
+			writeTimeframeAlignment(&sb, ctx, pos.Symbol)
 
 			// 使用FormatMarketData输出完整市场数据
 			if marketData, ok := ctx.MarketDataMap[pos.Symbol]; ok {
-				sb.WriteString(market.Format(marketData))
+				sb.WriteString(market.FormatWithVerbosity(marketData, ctx.Verbosity))
 				sb.WriteString("\n")
 			}
 		}
@@ -398,6 +647,22 @@ func buildUserPrompt(ctx *Context) string {
 		sb.WriteString("当前持仓: 无\n\n")
 	}
 
+	// 外部交易想法（需逐条给出接受/拒绝）
+	if len(ctx.TradeIdeas) > 0 {
+		sb.WriteString(formatTradeIdeas(ctx.TradeIdeas))
+	}
+
+	// 仅观察币种（可分析点评，不可开仓）
+	if len(ctx.WatchOnly) > 0 {
+		sb.WriteString(formatWatchOnly(ctx.WatchOnly))
+	}
+
+	// 自定义分析周期（提示模型市场数据中的周期已不是默认的 3m/4h）
+	if !market.IsDefaultTimeframes(ctx.Timeframes) {
+		sb.WriteString(fmt.Sprintf("分析周期: %s（日内序列 %s，长期背景 %s）\n\n",
+			strings.Join(ctx.Timeframes, " / "), ctx.Timeframes[0], ctx.Timeframes[len(ctx.Timeframes)-1]))
+	}
+
 	// 候选币种（完整市场数据）
 	sb.WriteString(fmt.Sprintf("## 候选币种 (%d个)\n\n", len(ctx.MarketDataMap)))
 	displayedCount := 0
@@ -414,14 +679,23 @@ func buildUserPrompt(ctx *Context) string {
 		} else if len(coin.Sources) == 1 && coin.Sources[0] == "oi_top" {
 			sourceTags = " (OI_Top持仓增长)"
 		}
+		if isWatchOnly(ctx, coin.Symbol) {
+			sourceTags += " (👀 仅观察，不可开仓)"
+		}
 
 		// 使用FormatMarketData输出完整市场数据
 		sb.WriteString(fmt.Sprintf("### %d. %s%s\n\n", displayedCount, coin.Symbol, sourceTags))
-		sb.WriteString(market.Format(marketData))
+		writeSymbolMemory(&sb, ctx, coin.Symbol)
+		writeLiquidityCap(&sb, ctx, coin.Symbol)
+		writeStaleness(&sb, ctx, coin.Symbol)
+		writeTimeframeAlignment(&sb, ctx, coin.Symbol)
+		sb.WriteString(market.FormatWithVerbosity(marketData, ctx.Verbosity))
 		sb.WriteString("\n")
 	}
 	sb.WriteString("\n")
 
+	writeCorrelations(&sb, ctx)
+
 	// 夏普比率（直接传值，不要复杂格式化）
 	if ctx.Performance != nil {
 		// 直接从interface{}中提取SharpeRatio
@@ -442,31 +716,57 @@ func buildUserPrompt(ctx *Context) string {
 	return sb.String()
 }
 
+// writeDecisionExample 按决策输出版本写入 <decision> 中的JSON示例
+func writeDecisionExample(sb *strings.Builder, accountEquity float64, btcEthLeverage int, schemaVersion string) {
+	items := []string{
+		fmt.Sprintf("{\"symbol\": \"BTCUSDT\", \"action\": \"open_short\", \"leverage\": %d, \"position_size_usd\": %.0f, \"stop_loss\": 97000, \"take_profit\": 91000, \"confidence\": 85, \"risk_usd\": 300, \"reasoning\": \"下跌趋势+MACD死叉\"}", btcEthLeverage, accountEquity*5),
+		"{\"symbol\": \"ETHUSDT\", \"action\": \"close_long\", \"reasoning\": \"止盈离场\"}",
+		"{\"symbol\": \"SOLUSDT\", \"action\": \"partial_close\", \"reduce_pct\": 50, \"reasoning\": \"接近阻力位，先减半锁定利润\"}",
+	}
+
+	sb.WriteString("```json\n")
+	if schemaVersion == SchemaVersionV1 {
+		sb.WriteString("[\n")
+		sb.WriteString("  " + strings.Join(items, ",\n  ") + "\n")
+		sb.WriteString("]\n")
+	} else {
+		sb.WriteString("{\n")
+		sb.WriteString(fmt.Sprintf("  \"schema_version\": \"%s\",\n", schemaVersion))
+		sb.WriteString("  \"decisions\": [\n")
+		sb.WriteString("    " + strings.Join(items, ",\n    ") + "\n")
+		sb.WriteString("  ]\n")
+		sb.WriteString("}\n")
+	}
+	sb.WriteString("```\n")
+}
+
 // parseFullDecisionResponse 解析AI的完整决策响应
-func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthLeverage, altcoinLeverage int) (*FullDecision, error) {
+func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthLeverage, altcoinLeverage int, liquidityCaps map[string]float64) (*FullDecision, error) {
 	// 1. 提取思维链
 	cotTrace := extractCoTTrace(aiResponse)
 
-	// 2. 提取JSON决策列表
-	decisions, err := extractDecisions(aiResponse)
+	// 2. 提取JSON决策列表（按 schema_version 选择解析格式）
+	decisions, schemaVersion, err := extractVersionedDecisions(aiResponse)
 	if err != nil {
 		return &FullDecision{
-			CoTTrace:  cotTrace,
-			Decisions: []Decision{},
+			CoTTrace:      cotTrace,
+			Decisions:     []Decision{},
+			SchemaVersion: schemaVersion,
 		}, fmt.Errorf("提取决策失败: %w", err)
 	}
 
-	// 3. 验证决策
-	if err := validateDecisions(decisions, accountEquity, btcEthLeverage, altcoinLeverage); err != nil {
-		return &FullDecision{
-			CoTTrace:  cotTrace,
-			Decisions: decisions,
-		}, fmt.Errorf("决策验证失败: %w", err)
+	// 3. 提取理由标签，验证决策（逐条验证，未通过的单独记录，其余决策照常执行）
+	tagDecisions(decisions)
+	valid, rejected := validateDecisions(decisions, accountEquity, btcEthLeverage, altcoinLeverage, liquidityCaps)
+	for _, r := range rejected {
+		loglevel.Warnf(loglevel.Decision, "🚫 决策 %s %s 验证失败: %s", r.Decision.Symbol, r.Decision.Action, r.Reason)
 	}
 
 	return &FullDecision{
-		CoTTrace:  cotTrace,
-		Decisions: decisions,
+		CoTTrace:      cotTrace,
+		Decisions:     valid,
+		SchemaVersion: schemaVersion,
+		Rejected:      rejected,
 	}, nil
 }
 
@@ -474,20 +774,20 @@ func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthL
 func extractCoTTrace(response string) string {
 	// 方法1: 优先尝试提取 <reasoning> 标签内容
 	if match := reReasoningTag.FindStringSubmatch(response); match != nil && len(match) > 1 {
-		log.Printf("✓ 使用 <reasoning> 标签提取思维链")
+		loglevel.Debugf(loglevel.Decision, "✓ 使用 <reasoning> 标签提取思维链")
 		return strings.TrimSpace(match[1])
 	}
 
 	// 方法2: 如果没有 <reasoning> 标签，但有 <decision> 标签，提取 <decision> 之前的内容
 	if decisionIdx := strings.Index(response, "<decision>"); decisionIdx > 0 {
-		log.Printf("✓ 提取 <decision> 标签之前的内容作为思维链")
+		loglevel.Debugf(loglevel.Decision, "✓ 提取 <decision> 标签之前的内容作为思维链")
 		return strings.TrimSpace(response[:decisionIdx])
 	}
 
 	// 方法3: 后备方案 - 查找JSON数组的开始位置
 	jsonStart := strings.Index(response, "[")
 	if jsonStart > 0 {
-		log.Printf("⚠️  使用旧版格式（[ 字符分离）提取思维链")
+		loglevel.Warnf(loglevel.Decision, "⚠️  使用旧版格式（[ 字符分离）提取思维链")
 		return strings.TrimSpace(response[:jsonStart])
 	}
 
@@ -509,11 +809,11 @@ func extractDecisions(response string) ([]Decision, error) {
 	var jsonPart string
 	if match := reDecisionTag.FindStringSubmatch(s); match != nil && len(match) > 1 {
 		jsonPart = strings.TrimSpace(match[1])
-		log.Printf("✓ 使用 <decision> 标签提取JSON")
+		loglevel.Debugf(loglevel.Decision, "✓ 使用 <decision> 标签提取JSON")
 	} else {
 		// 后备方案：使用整个响应
 		jsonPart = s
-		log.Printf("⚠️  未找到 <decision> 标签，使用全文搜索JSON")
+		loglevel.Warnf(loglevel.Decision, "⚠️  未找到 <decision> 标签，使用全文搜索JSON")
 	}
 
 	// 修复 jsonPart 中的全角字符
@@ -655,14 +955,18 @@ func compactArrayOpen(s string) string {
 	return reArrayOpenSpace.ReplaceAllString(strings.TrimSpace(s), "[{")
 }
 
-// validateDecisions 验证所有决策（需要账户信息和杠杆配置）
-func validateDecisions(decisions []Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int) error {
+// validateDecisions 逐条验证决策（需要账户信息和杠杆配置），返回通过的决策和被拒绝的决策
+func validateDecisions(decisions []Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int, liquidityCaps map[string]float64) ([]Decision, []RejectedDecision) {
+	valid := make([]Decision, 0, len(decisions))
+	var rejected []RejectedDecision
 	for i, decision := range decisions {
-		if err := validateDecision(&decision, accountEquity, btcEthLeverage, altcoinLeverage); err != nil {
-			return fmt.Errorf("决策 #%d 验证失败: %w", i+1, err)
+		if err := validateDecision(&decision, accountEquity, btcEthLeverage, altcoinLeverage, liquidityCaps[decision.Symbol]); err != nil {
+			rejected = append(rejected, RejectedDecision{Decision: decision, Reason: fmt.Sprintf("决策 #%d 验证失败: %v", i+1, err)})
+			continue
 		}
+		valid = append(valid, decision)
 	}
-	return nil
+	return valid, rejected
 }
 
 // findMatchingBracket 查找匹配的右括号
@@ -687,8 +991,14 @@ func findMatchingBracket(s string, start int) int {
 	return -1
 }
 
+// ValidateDecision 验证单个决策（供人工审批前的风险预览使用，规则与AI决策解析时一致）
+// liquidityCap 为该币种的流动性仓位上限（0=不限制）
+func ValidateDecision(d *Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int, liquidityCap float64) error {
+	return validateDecision(d, accountEquity, btcEthLeverage, altcoinLeverage, liquidityCap)
+}
+
 // validateDecision 验证单个决策的有效性
-func validateDecision(d *Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int) error {
+func validateDecision(d *Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int, liquidityCap float64) error {
 	// 验证action
 	validActions := map[string]bool{
 		"open_long":          true,
@@ -706,15 +1016,19 @@ func validateDecision(d *Decision, accountEquity float64, btcEthLeverage, altcoi
 		return fmt.Errorf("无效的action: %s", d.Action)
 	}
 
+	// 仓位字段含义检查（size_usd / reduce_pct / qty_contracts 不能混用）
+	if err := normalizeSizing(d); err != nil {
+		return err
+	}
+
 	// 开仓操作必须提供完整参数
 	if d.Action == "open_long" || d.Action == "open_short" {
 		// 根据币种使用配置的杠杆上限
-		maxLeverage := altcoinLeverage          // 山寨币使用配置的杠杆
-		maxPositionValue := accountEquity * 1.5 // 山寨币最多1.5倍账户净值
+		maxLeverage := altcoinLeverage // 山寨币使用配置的杠杆
 		if d.Symbol == "BTCUSDT" || d.Symbol == "ETHUSDT" {
-			maxLeverage = btcEthLeverage          // BTC和ETH使用配置的杠杆
-			maxPositionValue = accountEquity * 10 // BTC/ETH最多10倍账户净值
+			maxLeverage = btcEthLeverage // BTC和ETH使用配置的杠杆
 		}
+		maxPositionValue := equityPositionCap(d.Symbol, accountEquity) // BTC/ETH最多10倍、山寨币最多1.5倍账户净值
 
 		if d.Leverage <= 0 || d.Leverage > maxLeverage {
 			return fmt.Errorf("杠杆必须在1-%d之间（%s，当前配置上限%d倍）: %d", maxLeverage, d.Symbol, maxLeverage, d.Leverage)
@@ -738,6 +1052,13 @@ func validateDecision(d *Decision, accountEquity float64, btcEthLeverage, altcoi
 			}
 		}
 
+		// 流动性上限更严格时以流动性为准（低流动性山寨币不能按净值倍数开满）
+		if liquidityCap > 0 && liquidityCap < maxPositionValue {
+			if d.PositionSizeUSD > liquidityCap*1.01 {
+				return fmt.Errorf("%s 流动性不足，单币种仓位价值不能超过%.0f USDT（按持仓量/24h成交额计算），实际: %.0f", d.Symbol, liquidityCap, d.PositionSizeUSD)
+			}
+		}
+
 		// 验证仓位价值上限（加1%容差以避免浮点数精度问题）
 		tolerance := maxPositionValue * 0.01 // 1%容差
 		if d.PositionSizeUSD > maxPositionValue+tolerance {
@@ -809,9 +1130,9 @@ func validateDecision(d *Decision, accountEquity float64, btcEthLeverage, altcoi
 		}
 	}
 
-	// 部分平仓验证
+	// 部分平仓验证（数量字段已在 normalizeSizing 中检查，这里只检查百分比范围）
 	if d.Action == "partial_close" {
-		if d.ClosePercentage <= 0 || d.ClosePercentage > 100 {
+		if d.ClosePercentage > 100 {
 			return fmt.Errorf("平仓百分比必须在0-100之间: %.1f", d.ClosePercentage)
 		}
 	}
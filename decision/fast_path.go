@@ -0,0 +1,42 @@
+package decision
+
+import (
+	"fmt"
+	"nofx/loglevel"
+	"nofx/market"
+)
+
+// singleSymbolFastPath 单币种交易员（如只做BTC的 taro 模板）返回该币种，否则返回空
+// 只有候选币种恰好一个、且所有持仓都在该币种上时才走快速路径（否则持仓币种仍需完整获取）
+func singleSymbolFastPath(ctx *Context) string {
+	if len(ctx.CandidateCoins) != 1 {
+		return ""
+	}
+	symbol := ctx.CandidateCoins[0].Symbol
+	for _, pos := range ctx.Positions {
+		if pos.Symbol != symbol {
+			return ""
+		}
+	}
+	return symbol
+}
+
+// fetchSingleSymbolMarketData 单币种快速路径：只获取该币种数据
+// 跳过全局获取排队、OI Top 加载、持仓价值过滤和相关性计算，让3分钟K线收盘后的管理决策尽快发出
+func fetchSingleSymbolMarketData(ctx *Context, symbol string) error {
+	start := now()
+	ctx.MarketDataMap = make(map[string]*market.Data, 1)
+	ctx.OITopDataMap = make(map[string]*OITopData)
+
+	data, err := market.GetWithTimeframes(symbol, ctx.Timeframes)
+	if err != nil {
+		return fmt.Errorf("获取 %s 市场数据失败: %w", symbol, err)
+	}
+	if len(ctx.Indicators) > 0 {
+		data.Indicators = market.ComputeIndicators(data, ctx.Indicators)
+	}
+	ctx.MarketDataMap[symbol] = data
+
+	loglevel.Debugf(loglevel.Decision, "⚡ [%s] 单币种快速路径: %s 市场数据耗时 %v", ctx.TraderID, symbol, since(start))
+	return nil
+}
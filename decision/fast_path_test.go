@@ -0,0 +1,28 @@
+package decision
+
+import "testing"
+
+func TestSingleSymbolFastPath(t *testing.T) {
+	btc := []CandidateCoin{{Symbol: "BTCUSDT", Sources: []string{"custom"}}}
+
+	tests := []struct {
+		name       string
+		candidates []CandidateCoin
+		positions  []PositionInfo
+		want       string
+	}{
+		{"单币种无持仓", btc, nil, "BTCUSDT"},
+		{"单币种且持仓同币种", btc, []PositionInfo{{Symbol: "BTCUSDT", Side: "long"}}, "BTCUSDT"},
+		{"持有其他币种走完整路径", btc, []PositionInfo{{Symbol: "ETHUSDT", Side: "short"}}, ""},
+		{"多个候选币种", append(btc, CandidateCoin{Symbol: "ETHUSDT"}), nil, ""},
+		{"没有候选币种", nil, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &Context{CandidateCoins: tt.candidates, Positions: tt.positions}
+			if got := singleSymbolFastPath(ctx); got != tt.want {
+				t.Errorf("singleSymbolFastPath = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package decision
+
+import "fmt"
+
+// MaxPositions 最多同时持仓的币种数（与 System Prompt 硬约束一致）
+const MaxPositions = 3
+
+// 模型质量问题类型
+const (
+	IncidentNonexistentPosition = "nonexistent_position" // 平仓/持有/调整了不存在的持仓
+	IncidentWrongSide           = "wrong_side"           // 平仓方向与实际持仓相反
+	IncidentUnknownSymbol       = "unknown_symbol"       // 币种不在prompt中（持仓和候选币种都没有）
+	IncidentNoFreeSlot          = "no_free_slot"         // 开仓超过持仓数量上限
+	IncidentAlreadyOpen         = "already_open"         // 对已有同方向持仓重复开仓
+)
+
+// DecisionIncident 决策与实际账户/prompt不符的模型质量问题
+type DecisionIncident struct {
+	Symbol string `json:"symbol"`
+	Action string `json:"action"`
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+}
+
+// DetectHallucinations 将决策与实际持仓、prompt中的币种、剩余仓位数交叉校验
+// 只标记问题，不修改决策（执行层另有持仓方向校验和风控）
+func DetectHallucinations(ctx *Context, decisions []Decision) []DecisionIncident {
+	held := make(map[string]string) // symbol -> side（同币种双向持仓时记录最后一个）
+	heldSides := make(map[string]bool)
+	for _, pos := range ctx.Positions {
+		held[pos.Symbol] = pos.Side
+		heldSides[pos.Symbol+"_"+pos.Side] = true
+	}
+	inPrompt := func(symbol string) bool {
+		if _, ok := held[symbol]; ok {
+			return true
+		}
+		_, ok := ctx.MarketDataMap[symbol]
+		return ok
+	}
+
+	// 本批次平仓会释放仓位
+	openSymbols := make(map[string]bool, len(held))
+	for symbol := range held {
+		openSymbols[symbol] = true
+	}
+	for _, d := range decisions {
+		if (d.Action == "close_long" && heldSides[d.Symbol+"_long"]) || (d.Action == "close_short" && heldSides[d.Symbol+"_short"]) {
+			delete(openSymbols, d.Symbol)
+		}
+	}
+
+	var incidents []DecisionIncident
+	add := func(d Decision, incidentType, format string, args ...interface{}) {
+		incidents = append(incidents, DecisionIncident{
+			Symbol: d.Symbol,
+			Action: d.Action,
+			Type:   incidentType,
+			Detail: fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, d := range decisions {
+		// 安全回退生成的保底决策
+		if d.Symbol == "ALL" && d.Action == "wait" {
+			continue
+		}
+		if !inPrompt(d.Symbol) {
+			add(d, IncidentUnknownSymbol, "%s 不在当前持仓和候选币种中", d.Symbol)
+			continue
+		}
+
+		switch d.Action {
+		case "close_long", "close_short":
+			side := "long"
+			if d.Action == "close_short" {
+				side = "short"
+			}
+			if heldSides[d.Symbol+"_"+side] {
+				continue
+			}
+			if actual, ok := held[d.Symbol]; ok {
+				add(d, IncidentWrongSide, "%s 实际持有 %s 仓，而不是 %s 仓", d.Symbol, actual, side)
+			} else {
+				add(d, IncidentNonexistentPosition, "%s 没有持仓", d.Symbol)
+			}
+
+		case "hold", "update_stop_loss", "update_take_profit", "partial_close":
+			if _, ok := held[d.Symbol]; !ok {
+				add(d, IncidentNonexistentPosition, "%s 没有持仓，无法 %s", d.Symbol, d.Action)
+			}
+
+		case "open_long", "open_short":
+			side := "long"
+			if d.Action == "open_short" {
+				side = "short"
+			}
+			if heldSides[d.Symbol+"_"+side] {
+				add(d, IncidentAlreadyOpen, "%s 已有 %s 仓", d.Symbol, side)
+				continue
+			}
+			// 翻仓或同币种加开不占用新仓位
+			if openSymbols[d.Symbol] {
+				continue
+			}
+			if len(openSymbols) >= MaxPositions {
+				add(d, IncidentNoFreeSlot, "已持有/计划持有 %d 个币种，达到上限 %d", len(openSymbols), MaxPositions)
+				continue
+			}
+			openSymbols[d.Symbol] = true
+		}
+	}
+	return incidents
+}
@@ -0,0 +1,84 @@
+package decision
+
+import (
+	"nofx/market"
+	"testing"
+)
+
+func TestDetectHallucinations(t *testing.T) {
+	ctx := &Context{
+		Positions: []PositionInfo{
+			{Symbol: "BTCUSDT", Side: "long"},
+			{Symbol: "ETHUSDT", Side: "short"},
+			{Symbol: "SOLUSDT", Side: "long"},
+		},
+		MarketDataMap: map[string]*market.Data{
+			"BTCUSDT":  {},
+			"ETHUSDT":  {},
+			"SOLUSDT":  {},
+			"DOGEUSDT": {},
+			"XRPUSDT":  {},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		decisions []Decision
+		want      []string
+	}{
+		{
+			name:      "正常平仓和持有",
+			decisions: []Decision{{Symbol: "BTCUSDT", Action: "close_long"}, {Symbol: "ETHUSDT", Action: "hold"}},
+			want:      nil,
+		},
+		{
+			name:      "平仓方向相反",
+			decisions: []Decision{{Symbol: "ETHUSDT", Action: "close_long"}},
+			want:      []string{IncidentWrongSide},
+		},
+		{
+			name:      "调整不存在的持仓",
+			decisions: []Decision{{Symbol: "DOGEUSDT", Action: "update_stop_loss"}, {Symbol: "XRPUSDT", Action: "close_short"}},
+			want:      []string{IncidentNonexistentPosition, IncidentNonexistentPosition},
+		},
+		{
+			name:      "币种不在prompt中",
+			decisions: []Decision{{Symbol: "PEPEUSDT", Action: "open_long"}},
+			want:      []string{IncidentUnknownSymbol},
+		},
+		{
+			name:      "仓位已满时开新仓",
+			decisions: []Decision{{Symbol: "DOGEUSDT", Action: "open_long"}},
+			want:      []string{IncidentNoFreeSlot},
+		},
+		{
+			name:      "先平仓释放仓位再开新仓",
+			decisions: []Decision{{Symbol: "SOLUSDT", Action: "close_long"}, {Symbol: "DOGEUSDT", Action: "open_long"}},
+			want:      nil,
+		},
+		{
+			name:      "翻仓不占用新仓位，同方向重复开仓",
+			decisions: []Decision{{Symbol: "ETHUSDT", Action: "open_long"}, {Symbol: "BTCUSDT", Action: "open_long"}},
+			want:      []string{IncidentAlreadyOpen},
+		},
+		{
+			name:      "安全回退决策不计入",
+			decisions: []Decision{{Symbol: "ALL", Action: "wait"}},
+			want:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incidents := DetectHallucinations(ctx, tt.decisions)
+			if len(incidents) != len(tt.want) {
+				t.Fatalf("incidents = %+v, want types %v", incidents, tt.want)
+			}
+			for i, incident := range incidents {
+				if incident.Type != tt.want[i] {
+					t.Errorf("incident[%d].Type = %s, want %s", i, incident.Type, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package decision
+
+import (
+	"fmt"
+	"math"
+	"nofx/market"
+	"strings"
+)
+
+// LiquidityLimits 流动性仓位上限（按持仓量价值和24小时成交额的百分比限制单币种仓位，0=不启用该维度）
+type LiquidityLimits struct {
+	MaxOIPct     float64 // 仓位价值 ≤ 持仓量价值 × 该百分比
+	MaxVolumePct float64 // 仓位价值 ≤ 24小时成交额 × 该百分比
+}
+
+// Enabled 是否启用任一维度
+func (l LiquidityLimits) Enabled() bool {
+	return l.MaxOIPct > 0 || l.MaxVolumePct > 0
+}
+
+// LiquidityMaxPositionUSD 按流动性计算单币种最大仓位价值（USDT），取各维度中较小者；缺少数据时返回0（不限制）
+func LiquidityMaxPositionUSD(data *market.Data, limits LiquidityLimits) float64 {
+	if data == nil || data.CurrentPrice <= 0 {
+		return 0
+	}
+	maxUSD := 0.0
+	apply := func(cap float64) {
+		if cap > 0 && (maxUSD == 0 || cap < maxUSD) {
+			maxUSD = cap
+		}
+	}
+	if limits.MaxOIPct > 0 && data.OpenInterest != nil {
+		apply(data.OpenInterest.Latest * data.CurrentPrice * limits.MaxOIPct / 100)
+	}
+	if limits.MaxVolumePct > 0 {
+		apply(data.QuoteVolume24h * limits.MaxVolumePct / 100)
+	}
+	return maxUSD
+}
+
+// liquidityCaps 计算所有币种的流动性仓位上限（symbol -> USDT，无数据的币种不包含）
+func liquidityCaps(marketDataMap map[string]*market.Data, limits LiquidityLimits) map[string]float64 {
+	caps := make(map[string]float64)
+	if !limits.Enabled() {
+		return caps
+	}
+	for symbol, data := range marketDataMap {
+		if cap := LiquidityMaxPositionUSD(data, limits); cap > 0 {
+			caps[symbol] = cap
+		}
+	}
+	return caps
+}
+
+// writeLiquidityCap 在币种市场数据前写入流动性仓位上限（仅在该上限比净值倍数上限更严格时提示）
+func writeLiquidityCap(sb *strings.Builder, ctx *Context, symbol string) {
+	cap, ok := liquidityCaps(map[string]*market.Data{symbol: ctx.MarketDataMap[symbol]}, ctx.Liquidity)[symbol]
+	if !ok || cap >= equityPositionCap(symbol, ctx.Account.TotalEquity) {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("流动性仓位上限: %.0f USDT（按持仓量/24h成交额计算，position_size_usd 不得超过该值）\n\n", math.Floor(cap)))
+}
+
+// equityPositionCap 按账户净值倍数计算的单币种仓位上限（BTC/ETH 10倍，山寨币1.5倍）
+func equityPositionCap(symbol string, accountEquity float64) float64 {
+	if symbol == "BTCUSDT" || symbol == "ETHUSDT" {
+		return accountEquity * 10
+	}
+	return accountEquity * 1.5
+}
@@ -0,0 +1,90 @@
+package decision
+
+import (
+	"nofx/market"
+	"strings"
+	"testing"
+)
+
+func TestLiquidityMaxPositionUSD(t *testing.T) {
+	limits := LiquidityLimits{MaxOIPct: 0.1, MaxVolumePct: 0.1}
+
+	tests := []struct {
+		name   string
+		data   *market.Data
+		limits LiquidityLimits
+		want   float64
+	}{
+		{
+			name:   "持仓量更小时按持仓量",
+			data:   &market.Data{CurrentPrice: 2, OpenInterest: &market.OIData{Latest: 1_000_000}, QuoteVolume24h: 5_000_000},
+			limits: limits,
+			want:   2000,
+		},
+		{
+			name:   "成交额更小时按成交额",
+			data:   &market.Data{CurrentPrice: 2, OpenInterest: &market.OIData{Latest: 10_000_000}, QuoteVolume24h: 3_000_000},
+			limits: limits,
+			want:   3000,
+		},
+		{
+			name:   "缺少持仓量时只按成交额",
+			data:   &market.Data{CurrentPrice: 2, QuoteVolume24h: 3_000_000},
+			limits: limits,
+			want:   3000,
+		},
+		{
+			name:   "没有数据不限制",
+			data:   &market.Data{CurrentPrice: 2, OpenInterest: &market.OIData{}},
+			limits: limits,
+			want:   0,
+		},
+		{
+			name:   "未启用不限制",
+			data:   &market.Data{CurrentPrice: 2, OpenInterest: &market.OIData{Latest: 1_000_000}, QuoteVolume24h: 5_000_000},
+			limits: LiquidityLimits{},
+			want:   0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LiquidityMaxPositionUSD(tt.data, tt.limits); got != tt.want {
+				t.Errorf("LiquidityMaxPositionUSD() = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDecisionLiquidityCap(t *testing.T) {
+	open := func(size float64) Decision {
+		return Decision{Symbol: "XYZUSDT", Action: "open_long", Leverage: 3, PositionSizeUSD: size, StopLoss: 95, TakeProfit: 120}
+	}
+
+	tests := []struct {
+		name    string
+		size    float64
+		cap     float64
+		wantErr string
+	}{
+		{"流动性上限内", 1500, 2000, ""},
+		{"超出流动性上限", 2500, 2000, "流动性不足"},
+		{"流动性上限宽松时按净值倍数", 2500, 100000, ""},
+		{"没有流动性数据按净值倍数", 2500, 0, ""},
+		{"超出净值倍数", 4000, 100000, "1.5倍账户净值"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := open(tt.size)
+			err := validateDecision(&d, 2000, 10, 5, tt.cap)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package decision
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 风险偏好档位
+const (
+	PersonaConservative = "conservative"
+	PersonaBalanced     = "balanced"
+	PersonaAggressive   = "aggressive"
+)
+
+// RiskPersona 风险偏好档位：同时调整提示词措辞（持仓周期、信心度门槛）和风控数值上限
+type RiskPersona struct {
+	Name           string  `json:"name"`
+	Label          string  `json:"label"`
+	Description    string  `json:"description"`
+	HoldingHorizon string  `json:"holding_horizon"`   // 提示词中的持仓周期描述
+	MinConfidence  int     `json:"min_confidence"`    // 开仓最低信心度（提示词+执行前校验）
+	MinRiskReward  float64 `json:"min_risk_reward"`   // 最低风险回报比（不低于验证硬约束3.0）
+	MaxPositions   int     `json:"max_positions"`     // 最多同时持仓币种数
+	LeverageScale  float64 `json:"leverage_scale"`    // 配置杠杆上限的缩放比例（≤1）
+	MaxOpenRiskPct float64 `json:"max_open_risk_pct"` // 总开放风险上限（净值百分比）
+}
+
+// riskPersonas 内置风险偏好档位（按保守→激进排列）
+var riskPersonas = []RiskPersona{
+	{
+		Name:           PersonaConservative,
+		Label:          "保守",
+		Description:    "只做趋势明确、结构清晰的机会，宁可错过不可做错",
+		HoldingHorizon: "4小时-3天，顺大周期趋势持有，不做逆势和短线博弈",
+		MinConfidence:  85,
+		MinRiskReward:  4,
+		MaxPositions:   2,
+		LeverageScale:  0.5,
+		MaxOpenRiskPct: 3,
+	},
+	{
+		Name:           PersonaBalanced,
+		Label:          "均衡",
+		Description:    "在趋势与波段之间平衡，信号确认后入场",
+		HoldingHorizon: "1小时-1天，信号确认后入场，结构破坏即离场",
+		MinConfidence:  75,
+		MinRiskReward:  3,
+		MaxPositions:   3,
+		LeverageScale:  1,
+		MaxOpenRiskPct: 5,
+	},
+	{
+		Name:           PersonaAggressive,
+		Label:          "激进",
+		Description:    "积极参与突破与反转，接受更高的回撤换取更多机会",
+		HoldingHorizon: "15分钟-12小时，可参与短线突破和反转，快进快出",
+		MinConfidence:  65,
+		MinRiskReward:  3,
+		MaxPositions:   5,
+		LeverageScale:  1,
+		MaxOpenRiskPct: 8,
+	},
+}
+
+// GetRiskPersona 按名称获取风险偏好档位
+func GetRiskPersona(name string) (RiskPersona, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, p := range riskPersonas {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return RiskPersona{}, false
+}
+
+// RiskPersonas 所有内置风险偏好档位
+func RiskPersonas() []RiskPersona {
+	return append([]RiskPersona(nil), riskPersonas...)
+}
+
+// ScaleLeverage 按档位缩放杠杆上限（至少1倍）
+func (p RiskPersona) ScaleLeverage(leverage int) int {
+	if p.LeverageScale <= 0 || p.LeverageScale >= 1 {
+		return leverage
+	}
+	scaled := int(float64(leverage) * p.LeverageScale)
+	if scaled < 1 {
+		scaled = 1
+	}
+	return scaled
+}
+
+// promptPersona 提示词使用的档位（未设置时使用均衡档，措辞与原固定规则一致）
+func promptPersona(persona *RiskPersona) RiskPersona {
+	if persona != nil {
+		return *persona
+	}
+	p, _ := GetRiskPersona(PersonaBalanced)
+	return p
+}
+
+// writeRiskPersona 写入风险偏好说明（未设置档位时不输出）
+func writeRiskPersona(sb *strings.Builder, persona *RiskPersona) {
+	if persona == nil {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("# 风险偏好: %s\n\n", persona.Label))
+	sb.WriteString(fmt.Sprintf("- %s\n", persona.Description))
+	sb.WriteString(fmt.Sprintf("- 持仓周期: %s\n", persona.HoldingHorizon))
+	sb.WriteString(fmt.Sprintf("- 信心度低于 %d 时不开仓，输出 wait\n\n", persona.MinConfidence))
+}
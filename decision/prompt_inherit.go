@@ -0,0 +1,218 @@
+package decision
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 模板继承语法（指令独占一行时连同换行一起移除，不影响原有排版）：
+//
+//	基础模板用 {{block "名称"}} ... {{end}} 标出可覆盖的块，块内为默认内容
+//	派生模板以 {{extends "基础模板"}} 开头，只用 {{define "名称"}} ... {{end}} 覆盖需要改动的块，
+//	块内可用 {{super}} 引用基础模板中该块的内容（在其前后追加说明）
+//
+// 派生模板可以被再次继承；派生模板中 define 之外不允许出现正文，避免改动被静默丢弃
+var promptDirective = regexp.MustCompile(`\{\{\s*(?:(extends|block|define)\s+"([^"]+)"|(end|super))\s*\}\}`)
+
+// superMarker 块内容中 {{super}} 的占位符（解析后替换为基础模板的块内容）
+const superMarker = "\x00super\x00"
+
+// promptPart 模板正文片段：普通文本或块引用
+type promptPart struct {
+	text  string
+	block string
+}
+
+// parsedPrompt 解析后的单个模板文件
+type parsedPrompt struct {
+	extends string
+	parts   []promptPart      // 基础模板正文（派生模板为空）
+	blocks  map[string]string // 基础模板为块默认内容，派生模板为覆盖内容
+	order   []string          // 块出现顺序
+}
+
+// promptDirectiveSpan 指令的实际移除范围：独占一行时扩展到整行（含行尾换行）
+func promptDirectiveSpan(raw string, start, end int) (int, int) {
+	lineStart := strings.LastIndexByte(raw[:start], '\n') + 1
+	if strings.TrimSpace(raw[lineStart:start]) != "" {
+		return start, end
+	}
+	lineEnd := strings.IndexByte(raw[end:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(raw) - end
+	} else {
+		lineEnd++
+	}
+	if strings.TrimSpace(raw[end:end+lineEnd]) != "" {
+		return start, end
+	}
+	return lineStart, end + lineEnd
+}
+
+// parsePromptTemplate 解析模板中的继承指令
+func parsePromptTemplate(raw string) (*parsedPrompt, error) {
+	p := &parsedPrompt{blocks: make(map[string]string)}
+	var current string // 当前所在块（空=块外）
+	var content strings.Builder
+	cursor := 0
+
+	for i, m := range promptDirective.FindAllStringSubmatchIndex(raw, -1) {
+		start, end := promptDirectiveSpan(raw, m[0], m[1])
+		if start < cursor {
+			start = cursor
+		}
+		text := raw[cursor:start]
+		cursor = end
+
+		keyword, name := "", ""
+		if m[2] >= 0 {
+			keyword, name = raw[m[2]:m[3]], raw[m[4]:m[5]]
+		} else {
+			keyword = raw[m[6]:m[7]]
+		}
+
+		if current != "" {
+			content.WriteString(text)
+		} else if p.extends != "" {
+			if strings.TrimSpace(text) != "" {
+				return nil, fmt.Errorf("派生模板只能包含 define 块，块外出现正文: %q", firstLine(text))
+			}
+		} else if text != "" {
+			p.parts = append(p.parts, promptPart{text: text})
+		}
+
+		switch keyword {
+		case "extends":
+			if i != 0 || strings.TrimSpace(raw[:m[0]]) != "" {
+				return nil, fmt.Errorf("extends 必须位于模板开头")
+			}
+			p.extends = name
+		case "block", "define":
+			if current != "" {
+				return nil, fmt.Errorf("块 %s 内不能嵌套块 %s", current, name)
+			}
+			if (keyword == "define") != (p.extends != "") {
+				return nil, fmt.Errorf("%s \"%s\": 基础模板用 block 定义块，派生模板用 define 覆盖块", keyword, name)
+			}
+			if _, exists := p.blocks[name]; exists {
+				return nil, fmt.Errorf("块 %s 重复定义", name)
+			}
+			current = name
+			content.Reset()
+			if keyword == "block" {
+				p.parts = append(p.parts, promptPart{block: name})
+			}
+		case "super":
+			if current == "" || p.extends == "" {
+				return nil, fmt.Errorf("super 只能在派生模板的 define 块内使用")
+			}
+			content.WriteString(superMarker)
+		case "end":
+			if current == "" {
+				return nil, fmt.Errorf("多余的 end")
+			}
+			p.blocks[current] = content.String()
+			p.order = append(p.order, current)
+			current = ""
+		}
+	}
+
+	if current != "" {
+		return nil, fmt.Errorf("块 %s 缺少 end", current)
+	}
+	rest := raw[cursor:]
+	if p.extends != "" {
+		if strings.TrimSpace(rest) != "" {
+			return nil, fmt.Errorf("派生模板只能包含 define 块，块外出现正文: %q", firstLine(rest))
+		}
+	} else if rest != "" {
+		p.parts = append(p.parts, promptPart{text: rest})
+	}
+	return p, nil
+}
+
+// firstLine 文本的第一行非空内容（用于错误提示）
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// resolvePromptTemplates 解析全部模板的继承关系，返回展开后的模板和各模板的解析错误（出错的模板不加载）
+func resolvePromptTemplates(raw map[string]string) (map[string]*PromptTemplate, map[string]error) {
+	parsed := make(map[string]*parsedPrompt, len(raw))
+	errs := make(map[string]error)
+	for name, content := range raw {
+		p, err := parsePromptTemplate(content)
+		if err != nil {
+			errs[name] = err
+			continue
+		}
+		parsed[name] = p
+	}
+
+	// resolve 沿继承链展开：返回最顶层基础模板的正文和合并后的块内容
+	var resolve func(name string, chain []string) ([]promptPart, map[string]string, error)
+	resolve = func(name string, chain []string) ([]promptPart, map[string]string, error) {
+		for _, visited := range chain {
+			if visited == name {
+				return nil, nil, fmt.Errorf("模板循环继承: %s → %s", strings.Join(chain, " → "), name)
+			}
+		}
+		p, ok := parsed[name]
+		if !ok {
+			if err, failed := errs[name]; failed {
+				return nil, nil, fmt.Errorf("基础模板 %s 解析失败: %w", name, err)
+			}
+			return nil, nil, fmt.Errorf("基础模板不存在: %s", name)
+		}
+		if p.extends == "" {
+			return p.parts, p.blocks, nil
+		}
+
+		parts, baseBlocks, err := resolve(p.extends, append(chain, name))
+		if err != nil {
+			return nil, nil, err
+		}
+		blocks := make(map[string]string, len(baseBlocks))
+		for block, content := range baseBlocks {
+			blocks[block] = content
+		}
+		for _, block := range p.order {
+			base, exists := baseBlocks[block]
+			if !exists {
+				return nil, nil, fmt.Errorf("块 %s 在基础模板 %s 中不存在", block, p.extends)
+			}
+			blocks[block] = strings.ReplaceAll(p.blocks[block], superMarker, base)
+		}
+		return parts, blocks, nil
+	}
+
+	templates := make(map[string]*PromptTemplate, len(parsed))
+	for name, p := range parsed {
+		parts, blocks, err := resolve(name, nil)
+		if err != nil {
+			errs[name] = err
+			continue
+		}
+		var sb strings.Builder
+		for _, part := range parts {
+			if part.block != "" {
+				sb.WriteString(blocks[part.block])
+			} else {
+				sb.WriteString(part.text)
+			}
+		}
+		templates[name] = &PromptTemplate{
+			Name:    name,
+			Content: sb.String(),
+			Extends: p.extends,
+			Blocks:  append([]string(nil), p.order...),
+		}
+	}
+	return templates, errs
+}
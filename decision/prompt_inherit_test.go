@@ -0,0 +1,129 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+const testBasePrompt = `你是交易AI。
+
+{{block "strategy"}}
+# 开仓标准
+信心度 ≥ 75 才开仓
+{{end}}
+# 决策流程
+先评估持仓
+
+{{block "risk_notes"}}
+记住: 风险回报比1:3是底线
+{{end}}
+`
+
+func TestResolvePromptTemplatesStandalone(t *testing.T) {
+	templates, errs := resolvePromptTemplates(map[string]string{"base": testBasePrompt})
+	if len(errs) > 0 {
+		t.Fatalf("解析失败: %v", errs)
+	}
+	want := "你是交易AI。\n\n# 开仓标准\n信心度 ≥ 75 才开仓\n# 决策流程\n先评估持仓\n\n记住: 风险回报比1:3是底线\n"
+	if got := templates["base"].Content; got != want {
+		t.Errorf("独占一行的指令应连同换行一起移除:\ngot  %q\nwant %q", got, want)
+	}
+	if blocks := templates["base"].Blocks; strings.Join(blocks, ",") != "strategy,risk_notes" {
+		t.Errorf("Blocks = %v", blocks)
+	}
+}
+
+func TestResolvePromptTemplatesExtends(t *testing.T) {
+	raw := map[string]string{
+		"base": testBasePrompt,
+		"taro": `{{extends "base"}}
+
+{{define "strategy"}}
+# 开仓标准
+多周期共振才开仓
+{{end}}
+`,
+		"taro_strict": `{{extends "taro"}}
+{{define "risk_notes"}}
+{{super}}- 单日最多2笔
+{{end}}
+`,
+	}
+	templates, errs := resolvePromptTemplates(raw)
+	if len(errs) > 0 {
+		t.Fatalf("解析失败: %v", errs)
+	}
+
+	taro := templates["taro"]
+	if taro.Extends != "base" || !strings.Contains(taro.Content, "多周期共振才开仓") || strings.Contains(taro.Content, "信心度 ≥ 75") {
+		t.Errorf("派生模板应只替换 strategy 块: %+v", taro)
+	}
+	if !strings.Contains(taro.Content, "# 决策流程") || !strings.Contains(taro.Content, "记住: 风险回报比1:3是底线") {
+		t.Errorf("未覆盖的块和正文应沿用基础模板: %q", taro.Content)
+	}
+
+	strict := templates["taro_strict"].Content
+	if !strings.Contains(strict, "多周期共振才开仓") {
+		t.Errorf("多级继承应保留中间模板的覆盖: %q", strict)
+	}
+	if !strings.Contains(strict, "记住: 风险回报比1:3是底线\n- 单日最多2笔\n") {
+		t.Errorf("super 应展开为基础模板的块内容: %q", strict)
+	}
+}
+
+func TestResolvePromptTemplatesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "覆盖不存在的块", content: "{{extends \"base\"}}\n{{define \"output\"}}x\n{{end}}\n", want: "不存在"},
+		{name: "基础模板不存在", content: "{{extends \"missing\"}}\n", want: "基础模板不存在"},
+		{name: "派生模板块外正文", content: "{{extends \"base\"}}\n多出来的说明\n", want: "块外出现正文"},
+		{name: "缺少end", content: "{{block \"a\"}}\n内容\n", want: "缺少 end"},
+		{name: "嵌套块", content: "{{block \"a\"}}\n{{block \"b\"}}\n{{end}}\n{{end}}\n", want: "不能嵌套"},
+		{name: "extends不在开头", content: "说明\n{{extends \"base\"}}\n", want: "开头"},
+		{name: "基础模板使用super", content: "{{block \"a\"}}{{super}}{{end}}\n", want: "super"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templates, errs := resolvePromptTemplates(map[string]string{"base": testBasePrompt, "variant": tt.content})
+			if _, loaded := templates["variant"]; loaded {
+				t.Fatal("解析失败的模板不应加载")
+			}
+			if err := errs["variant"]; err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("错误 = %v, want 包含 %q", err, tt.want)
+			}
+			if _, ok := templates["base"]; !ok {
+				t.Error("其他模板的错误不应影响基础模板加载")
+			}
+		})
+	}
+}
+
+func TestResolvePromptTemplatesCycle(t *testing.T) {
+	_, errs := resolvePromptTemplates(map[string]string{
+		"a": "{{extends \"b\"}}\n",
+		"b": "{{extends \"a\"}}\n",
+	})
+	if err := errs["a"]; err == nil || !strings.Contains(err.Error(), "循环继承") {
+		t.Errorf("循环继承应报错，得到 %v", err)
+	}
+}
+
+func TestDefaultPromptDefinesBlocks(t *testing.T) {
+	pm := NewPromptManager()
+	if err := pm.LoadTemplates("../prompts"); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	template, err := pm.GetTemplate("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(template.Blocks, ",") != "strategy,position_sizing,risk_notes" {
+		t.Errorf("default 模板块 = %v", template.Blocks)
+	}
+	if strings.Contains(template.Content, "{{") {
+		t.Error("展开后的模板不应残留继承指令")
+	}
+}
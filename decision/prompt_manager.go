@@ -11,8 +11,10 @@ import (
 
 // PromptTemplate 系统提示词模板
 type PromptTemplate struct {
-	Name    string // 模板名称（文件名，不含扩展名）
-	Content string // 模板内容
+	Name    string   // 模板名称（文件名，不含扩展名）
+	Content string   // 模板内容（已展开继承的块）
+	Extends string   // 继承的基础模板（空=独立模板）
+	Blocks  []string // 基础模板定义的块 / 派生模板覆盖的块
 }
 
 // PromptManager 提示词管理器
@@ -66,9 +68,9 @@ func (pm *PromptManager) LoadTemplates(dir string) error {
 		return nil
 	}
 
-	// 加载每个模板文件
+	// 读取每个模板文件
+	raw := make(map[string]string, len(files))
 	for _, file := range files {
-		// 读取文件内容
 		content, err := os.ReadFile(file)
 		if err != nil {
 			log.Printf("⚠️  读取提示词文件失败 %s: %v", file, err)
@@ -77,15 +79,21 @@ func (pm *PromptManager) LoadTemplates(dir string) error {
 
 		// 提取文件名（不含扩展名）作为模板名称
 		fileName := filepath.Base(file)
-		templateName := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+		raw[strings.TrimSuffix(fileName, filepath.Ext(fileName))] = string(content)
+	}
 
-		// 存储模板
-		pm.templates[templateName] = &PromptTemplate{
-			Name:    templateName,
-			Content: string(content),
+	// 展开模板继承（派生模板只定义与基础模板不同的块）
+	templates, errs := resolvePromptTemplates(raw)
+	for name, err := range errs {
+		log.Printf("⚠️  提示词模板 %s 解析失败，未加载: %v", name, err)
+	}
+	for name, template := range templates {
+		pm.templates[name] = template
+		if template.Extends != "" {
+			log.Printf("  📄 加载提示词模板: %s (继承 %s，覆盖 %s)", name, template.Extends, strings.Join(template.Blocks, ", "))
+		} else {
+			log.Printf("  📄 加载提示词模板: %s", name)
 		}
-
-		log.Printf("  📄 加载提示词模板: %s (%s)", templateName, fileName)
 	}
 
 	return nil
@@ -0,0 +1,122 @@
+package decision
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ReasoningTags 从决策理由中提取的结构化标签（用于按信号/周期/价位统计和检索决策）
+type ReasoningTags struct {
+	Signals    []string `json:"signals,omitempty"`    // 引用的信号类型（MACD、RSI、open_interest…）
+	Timeframes []string `json:"timeframes,omitempty"` // 引用的周期（3m、1h、4h…）
+	Levels     []string `json:"levels,omitempty"`     // 引用的价位/区域（support:95000、resistance:3500-3550）
+	RiskNotes  []string `json:"risk_notes,omitempty"` // 风险提示原句
+}
+
+// reasoningSignalPatterns 信号类型及其匹配规则（按顺序输出）
+var reasoningSignalPatterns = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"MACD", regexp.MustCompile(`(?i)macd`)},
+	{"RSI", regexp.MustCompile(`(?i)rsi`)},
+	{"EMA", regexp.MustCompile(`(?i)ema\d*|均线`)},
+	{"ATR", regexp.MustCompile(`(?i)\batr`)},
+	{"BOLL", regexp.MustCompile(`(?i)boll|布林`)},
+	{"volume", regexp.MustCompile(`(?i)成交量|放量|缩量|volume`)},
+	{"open_interest", regexp.MustCompile(`(?i)\boi\b|持仓量|open interest`)},
+	{"funding_rate", regexp.MustCompile(`(?i)资金费率|funding`)},
+	{"divergence", regexp.MustCompile(`(?i)背离|divergence`)},
+	{"breakout", regexp.MustCompile(`(?i)突破|跌破|breakout|breakdown`)},
+	{"trend", regexp.MustCompile(`(?i)趋势|trend`)},
+}
+
+var (
+	reTimeframeCode    = regexp.MustCompile(`(?i)\b(1m|3m|5m|15m|30m|1h|2h|4h|1d)\b`)
+	reTimeframeChinese = regexp.MustCompile(`(\d+)\s*(分钟|小时)`)
+	reLevelRef         = regexp.MustCompile(`(?i)(支撑|阻力|压力|support|resistance|止损|止盈|目标|stop|target)[^0-9\n]{0,8}(\d+(?:\.\d+)?)(?:\s*[-~～]\s*(\d+(?:\.\d+)?))?`)
+	reRiskClauseSplit  = regexp.MustCompile(`[。；;！!\n]|\.\s`)
+	reRiskNote         = regexp.MustCompile(`(?i)风险|谨慎|注意|警惕|回撤|强平|爆仓|risk|caution`)
+	reRiskReward       = regexp.MustCompile(`(?i)风险回报|风险收益|盈亏比|risk[/ -]?reward`)
+)
+
+const (
+	maxRiskNotes     = 3
+	maxRiskNoteRunes = 60
+)
+
+// levelKinds 价位关键词 → 标签类型
+var levelKinds = map[string]string{
+	"支撑": "support", "support": "support",
+	"阻力": "resistance", "压力": "resistance", "resistance": "resistance",
+	"止损": "stop", "stop": "stop",
+	"止盈": "target", "目标": "target", "target": "target",
+}
+
+// ExtractReasoningTags 用轻量正则从决策理由中提取信号类型、周期、价位和风险提示
+func ExtractReasoningTags(reasoning string) ReasoningTags {
+	var tags ReasoningTags
+	if strings.TrimSpace(reasoning) == "" {
+		return tags
+	}
+
+	for _, p := range reasoningSignalPatterns {
+		if p.re.MatchString(reasoning) {
+			tags.Signals = append(tags.Signals, p.name)
+		}
+	}
+
+	for _, m := range reTimeframeCode.FindAllStringSubmatch(reasoning, -1) {
+		tags.Timeframes = appendUnique(tags.Timeframes, strings.ToLower(m[1]))
+	}
+	for _, m := range reTimeframeChinese.FindAllStringSubmatch(reasoning, -1) {
+		unit := "m"
+		if m[2] == "小时" {
+			unit = "h"
+		}
+		tags.Timeframes = appendUnique(tags.Timeframes, m[1]+unit)
+	}
+	if strings.Contains(reasoning, "日线") {
+		tags.Timeframes = appendUnique(tags.Timeframes, "1d")
+	}
+
+	for _, m := range reLevelRef.FindAllStringSubmatch(reasoning, -1) {
+		level := levelKinds[strings.ToLower(m[1])] + ":" + m[2]
+		if m[3] != "" {
+			level += "-" + m[3]
+		}
+		tags.Levels = appendUnique(tags.Levels, level)
+	}
+
+	for _, clause := range reRiskClauseSplit.Split(reasoning, -1) {
+		clause = strings.TrimSpace(clause)
+		if clause == "" || !reRiskNote.MatchString(clause) || reRiskReward.MatchString(clause) {
+			continue
+		}
+		if runes := []rune(clause); len(runes) > maxRiskNoteRunes {
+			clause = string(runes[:maxRiskNoteRunes]) + "…"
+		}
+		tags.RiskNotes = appendUnique(tags.RiskNotes, clause)
+		if len(tags.RiskNotes) >= maxRiskNotes {
+			break
+		}
+	}
+	return tags
+}
+
+// tagDecisions 为每条决策附加理由标签
+func tagDecisions(decisions []Decision) {
+	for i := range decisions {
+		tags := ExtractReasoningTags(decisions[i].Reasoning)
+		decisions[i].Tags = &tags
+	}
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
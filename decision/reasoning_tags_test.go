@@ -0,0 +1,43 @@
+package decision
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractReasoningTags(t *testing.T) {
+	tests := []struct {
+		name      string
+		reasoning string
+		want      ReasoningTags
+	}{
+		{"空理由", "", ReasoningTags{}},
+		{
+			"信号与周期",
+			"4h MACD金叉，RSI 58，3分钟K线放量突破",
+			ReasoningTags{Signals: []string{"MACD", "RSI", "volume", "breakout"}, Timeframes: []string{"4h", "3m"}},
+		},
+		{
+			"价位与区域",
+			"回踩支撑 95000-95500 附近做多，止损 94200，目标 98000",
+			ReasoningTags{Levels: []string{"support:95000-95500", "stop:94200", "target:98000"}},
+		},
+		{
+			"风险提示排除风险回报比",
+			"风险回报比 1:3。资金费率偏高需谨慎；注意周末流动性风险",
+			ReasoningTags{Signals: []string{"funding_rate"}, RiskNotes: []string{"资金费率偏高需谨慎", "注意周末流动性风险"}},
+		},
+		{
+			"英文理由",
+			"1h bearish divergence on RSI, OI rising, resistance 3550",
+			ReasoningTags{Signals: []string{"RSI", "open_interest", "divergence"}, Timeframes: []string{"1h"}, Levels: []string{"resistance:3550"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractReasoningTags(tt.reasoning); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractReasoningTags = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
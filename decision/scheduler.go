@@ -0,0 +1,175 @@
+package decision
+
+import (
+	"log"
+	"nofx/loglevel"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FairScheduler 全局并发限制 + 按trader轮询的公平调度器
+// 多个trader共用一个进程时，防止决策周期堆积导致AI接口/行情接口被同时打满，
+// 并保证单个trader排再多的请求也不会饿死其他trader
+type FairScheduler struct {
+	name          string
+	maxConcurrent int
+
+	mu      sync.Mutex
+	running int
+	queues  map[string][]chan struct{} // traderID -> 等待中的请求
+	ring    []string                   // 有等待请求的trader（轮询顺序）
+	next    int                        // 下一个被唤醒的trader下标
+
+	totalAcquired int64
+	totalWaited   int64
+	totalWaitTime time.Duration
+	maxWaitTime   time.Duration
+}
+
+// SchedulerMetrics 调度器指标
+type SchedulerMetrics struct {
+	Name          string         `json:"name"`
+	MaxConcurrent int            `json:"max_concurrent"` // 并发上限
+	Running       int            `json:"running"`        // 正在执行的请求数
+	QueueDepth    int            `json:"queue_depth"`    // 排队总数
+	TraderQueues  map[string]int `json:"trader_queues"`  // 各trader排队数
+	TotalAcquired int64          `json:"total_acquired"` // 累计获得执行权次数
+	TotalWaited   int64          `json:"total_waited"`   // 累计需要排队的次数
+	AvgWaitMs     float64        `json:"avg_wait_ms"`    // 平均排队时长（仅统计排过队的请求）
+	MaxWaitMs     float64        `json:"max_wait_ms"`    // 最长排队时长
+}
+
+// NewFairScheduler 创建公平调度器
+func NewFairScheduler(name string, maxConcurrent int) *FairScheduler {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+	return &FairScheduler{
+		name:          name,
+		maxConcurrent: maxConcurrent,
+		queues:        make(map[string][]chan struct{}),
+	}
+}
+
+// Acquire 获取执行权（阻塞直到轮到该trader），返回释放函数
+func (s *FairScheduler) Acquire(traderID string) func() {
+	s.mu.Lock()
+	if s.running < s.maxConcurrent && len(s.ring) == 0 {
+		s.running++
+		s.totalAcquired++
+		s.mu.Unlock()
+		return s.releaseOnce()
+	}
+
+	ch := make(chan struct{})
+	if len(s.queues[traderID]) == 0 {
+		s.ring = append(s.ring, traderID)
+	}
+	s.queues[traderID] = append(s.queues[traderID], ch)
+	s.mu.Unlock()
+
+	start := now()
+	<-ch
+	waited := since(start)
+
+	s.mu.Lock()
+	s.totalAcquired++
+	s.totalWaited++
+	s.totalWaitTime += waited
+	if waited > s.maxWaitTime {
+		s.maxWaitTime = waited
+	}
+	s.mu.Unlock()
+
+	if waited > 10*time.Second {
+		loglevel.Infof(loglevel.Decision, "⏳ [%s] trader %s 排队 %.1fs 后获得执行权", s.name, traderID, waited.Seconds())
+	}
+	return s.releaseOnce()
+}
+
+// releaseOnce 返回只生效一次的释放函数
+func (s *FairScheduler) releaseOnce() func() {
+	var once sync.Once
+	return func() {
+		once.Do(s.release)
+	}
+}
+
+// release 释放执行权：有排队时按轮询顺序直接移交给下一个trader，否则归还名额
+func (s *FairScheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.ring) == 0 {
+		s.running--
+		return
+	}
+
+	if s.next >= len(s.ring) {
+		s.next = 0
+	}
+	traderID := s.ring[s.next]
+	queue := s.queues[traderID]
+	ch := queue[0]
+	if len(queue) == 1 {
+		delete(s.queues, traderID)
+		s.ring = append(s.ring[:s.next], s.ring[s.next+1:]...)
+		// 删除后 next 已指向下一个trader，无需自增
+	} else {
+		s.queues[traderID] = queue[1:]
+		s.next++
+	}
+	close(ch)
+}
+
+// Metrics 获取调度器指标
+func (s *FairScheduler) Metrics() SchedulerMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metrics := SchedulerMetrics{
+		Name:          s.name,
+		MaxConcurrent: s.maxConcurrent,
+		Running:       s.running,
+		TraderQueues:  make(map[string]int, len(s.queues)),
+		TotalAcquired: s.totalAcquired,
+		TotalWaited:   s.totalWaited,
+		MaxWaitMs:     float64(s.maxWaitTime.Milliseconds()),
+	}
+	for traderID, queue := range s.queues {
+		metrics.TraderQueues[traderID] = len(queue)
+		metrics.QueueDepth += len(queue)
+	}
+	if s.totalWaited > 0 {
+		metrics.AvgWaitMs = float64(s.totalWaitTime.Milliseconds()) / float64(s.totalWaited)
+	}
+	return metrics
+}
+
+// 全局调度器：AI调用和行情获取分别限流
+// 并发上限可通过环境变量 MAX_CONCURRENT_AI_CALLS / MAX_CONCURRENT_MARKET_FETCHES 配置
+var (
+	aiCallScheduler      = NewFairScheduler("ai_call", envInt("MAX_CONCURRENT_AI_CALLS", 3))
+	marketFetchScheduler = NewFairScheduler("market_fetch", envInt("MAX_CONCURRENT_MARKET_FETCHES", 5))
+)
+
+// GetSchedulerMetrics 获取所有全局调度器的指标
+func GetSchedulerMetrics() []SchedulerMetrics {
+	return []SchedulerMetrics{
+		aiCallScheduler.Metrics(),
+		marketFetchScheduler.Metrics(),
+	}
+}
+
+// envInt 读取正整数环境变量，无效时使用默认值
+func envInt(key string, defaultValue int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+		log.Printf("⚠️  环境变量 %s=%s 无效，使用默认值 %d", key, v, defaultValue)
+	}
+	return defaultValue
+}
@@ -0,0 +1,66 @@
+package decision
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFairSchedulerRoundRobin(t *testing.T) {
+	s := NewFairScheduler("test", 1)
+
+	// 占住唯一的执行名额
+	release := s.Acquire("holder")
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+
+	queued := 0
+	enqueue := func(traderID string) {
+		queued++
+		want := queued
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := s.Acquire(traderID)
+			mu.Lock()
+			order = append(order, traderID)
+			mu.Unlock()
+			r()
+		}()
+		// 等待本次请求进入队列（总排队数达到已入队数），保证入队顺序确定
+		for s.Metrics().QueueDepth < want {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// trader A 连续排3个请求，随后 B、C 各排1个
+	enqueue("A")
+	enqueue("A")
+	enqueue("A")
+	enqueue("B")
+	enqueue("C")
+
+	if depth := s.Metrics().QueueDepth; depth != 5 {
+		t.Fatalf("QueueDepth = %d, want 5", depth)
+	}
+
+	release()
+	wg.Wait()
+
+	expected := []string{"A", "B", "C", "A", "A"}
+	if len(order) != len(expected) {
+		t.Fatalf("order = %v, want %v", order, expected)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("order = %v, want %v", order, expected)
+		}
+	}
+
+	metrics := s.Metrics()
+	if metrics.Running != 0 || metrics.QueueDepth != 0 {
+		t.Errorf("Running = %d, QueueDepth = %d, want 0, 0", metrics.Running, metrics.QueueDepth)
+	}
+}
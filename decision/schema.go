@@ -0,0 +1,154 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// 决策输出格式版本
+// v1: 旧版格式，<decision> 中直接输出决策数组 [{...}]
+// v2: 带版本号的包装对象 {"schema_version": "2", "decisions": [{...}]}
+const (
+	SchemaVersionV1 = "1"
+	SchemaVersionV2 = "2"
+
+	// CurrentSchemaVersion 新生成的prompt默认使用的版本
+	CurrentSchemaVersion = SchemaVersionV2
+)
+
+// decisionSchemaParsers 各版本的决策解析器（按AI输出中的 schema_version 选择）
+var decisionSchemaParsers = map[string]func(raw json.RawMessage) ([]Decision, error){
+	SchemaVersionV1: parseDecisionArray,
+	SchemaVersionV2: parseDecisionArray,
+}
+
+// decisionEnvelope 带版本号的决策输出
+type decisionEnvelope struct {
+	SchemaVersion json.RawMessage `json:"schema_version"`
+	Decisions     json.RawMessage `json:"decisions"`
+}
+
+// IsSupportedSchemaVersion 是否为支持的决策输出版本
+func IsSupportedSchemaVersion(version string) bool {
+	_, ok := decisionSchemaParsers[version]
+	return ok
+}
+
+// normalizeSchemaVersion 规整版本号（为空或不支持时使用当前版本）
+func normalizeSchemaVersion(version string) string {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if !IsSupportedSchemaVersion(version) {
+		if version != "" {
+			log.Printf("⚠️  不支持的决策输出版本 '%s'，使用 v%s", version, CurrentSchemaVersion)
+		}
+		return CurrentSchemaVersion
+	}
+	return version
+}
+
+// extractVersionedDecisions 提取决策并识别输出版本
+// 优先解析带 schema_version 的包装对象，找不到时按 v1（裸数组）解析，保证旧模板/旧输出继续可用
+func extractVersionedDecisions(response string) ([]Decision, string, error) {
+	s := fixMissingQuotes(removeInvisibleRunes(response))
+	if match := reDecisionTag.FindStringSubmatch(s); match != nil && len(match) > 1 {
+		s = match[1]
+	}
+
+	envelopeJSON := findSchemaEnvelope(s)
+	if envelopeJSON == "" {
+		decisions, err := extractDecisions(response)
+		return decisions, SchemaVersionV1, err
+	}
+
+	var envelope decisionEnvelope
+	if err := json.Unmarshal([]byte(envelopeJSON), &envelope); err != nil {
+		return nil, "", fmt.Errorf("JSON解析失败: %w\nJSON内容: %s", err, envelopeJSON)
+	}
+
+	// schema_version 允许写成字符串或数字
+	var version string
+	if err := json.Unmarshal(envelope.SchemaVersion, &version); err != nil {
+		var number json.Number
+		if err := json.Unmarshal(envelope.SchemaVersion, &number); err != nil {
+			return nil, "", fmt.Errorf("schema_version 格式无效: %s", string(envelope.SchemaVersion))
+		}
+		version = number.String()
+	}
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+
+	parser, ok := decisionSchemaParsers[version]
+	if !ok {
+		return nil, version, fmt.Errorf("不支持的决策输出版本: %s", version)
+	}
+	if len(envelope.Decisions) == 0 {
+		return nil, version, fmt.Errorf("v%s 输出缺少 decisions 字段", version)
+	}
+
+	decisions, err := parser(envelope.Decisions)
+	return decisions, version, err
+}
+
+// parseDecisionArray 解析决策数组（v1/v2 共用）
+func parseDecisionArray(raw json.RawMessage) ([]Decision, error) {
+	jsonContent := compactArrayOpen(string(raw))
+	if jsonContent == "[]" {
+		return []Decision{}, nil
+	}
+	if err := validateJSONFormat(jsonContent); err != nil {
+		return nil, fmt.Errorf("JSON格式验证失败: %w\nJSON内容: %s", err, jsonContent)
+	}
+
+	var decisions []Decision
+	if err := json.Unmarshal([]byte(jsonContent), &decisions); err != nil {
+		return nil, fmt.Errorf("JSON解析失败: %w\nJSON内容: %s", err, jsonContent)
+	}
+	return decisions, nil
+}
+
+// findSchemaEnvelope 查找包含 "schema_version" 的最外层JSON对象，找不到时返回空字符串
+func findSchemaEnvelope(s string) string {
+	keyIdx := strings.Index(s, `"schema_version"`)
+	if keyIdx < 0 {
+		return ""
+	}
+	start := strings.LastIndex(s[:keyIdx], "{")
+	if start < 0 {
+		return ""
+	}
+	end := findMatchingBrace(s, start)
+	if end < 0 {
+		return ""
+	}
+	return s[start : end+1]
+}
+
+// findMatchingBrace 查找匹配的右花括号（跳过字符串中的括号）
+func findMatchingBrace(s string, start int) int {
+	depth := 0
+	inString := false
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
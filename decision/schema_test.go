@@ -0,0 +1,80 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractVersionedDecisions(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    string
+		wantVersion string
+		wantCount   int
+		wantErr     bool
+	}{
+		{
+			name:        "旧版裸数组按v1解析",
+			response:    "<reasoning>观望</reasoning>\n<decision>\n```json\n[{\"symbol\": \"BTCUSDT\", \"action\": \"wait\", \"reasoning\": \"无机会\"}]\n```\n</decision>",
+			wantVersion: SchemaVersionV1,
+			wantCount:   1,
+		},
+		{
+			name:        "v2包装对象",
+			response:    "<reasoning>分析</reasoning>\n<decision>\n```json\n{\"schema_version\": \"2\", \"decisions\": [{\"symbol\": \"ETHUSDT\", \"action\": \"close_long\", \"reasoning\": \"止盈{离场}\"}, {\"symbol\": \"BTCUSDT\", \"action\": \"hold\", \"reasoning\": \"持有\"}]}\n```\n</decision>",
+			wantVersion: SchemaVersionV2,
+			wantCount:   2,
+		},
+		{
+			name:        "版本号为数字",
+			response:    "<decision>{\"schema_version\": 2, \"decisions\": [{\"symbol\": \"BTCUSDT\", \"action\": \"wait\", \"reasoning\": \"\"}]}</decision>",
+			wantVersion: SchemaVersionV2,
+			wantCount:   1,
+		},
+		{
+			name:        "空决策列表",
+			response:    "<decision>{\"schema_version\": \"2\", \"decisions\": []}</decision>",
+			wantVersion: SchemaVersionV2,
+			wantCount:   0,
+		},
+		{
+			name:        "不支持的版本",
+			response:    "<decision>{\"schema_version\": \"9\", \"decisions\": []}</decision>",
+			wantVersion: "9",
+			wantErr:     true,
+		},
+		{
+			name:        "缺少decisions字段",
+			response:    "<decision>{\"schema_version\": \"2\"}</decision>",
+			wantVersion: SchemaVersionV2,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decisions, version, err := extractVersionedDecisions(tt.response)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("version = %q, want %q", version, tt.wantVersion)
+			}
+			if !tt.wantErr && len(decisions) != tt.wantCount {
+				t.Errorf("len(decisions) = %d, want %d", len(decisions), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestBuildSystemPromptSchemaVersion(t *testing.T) {
+	v2 := buildSystemPrompt(1000, 5, 5, "default", SchemaVersionV2, nil)
+	if !strings.Contains(v2, `"schema_version": "2"`) {
+		t.Errorf("v2 prompt 缺少 schema_version 示例")
+	}
+
+	v1 := buildSystemPrompt(1000, 5, 5, "default", SchemaVersionV1, nil)
+	if strings.Contains(v1, "schema_version") {
+		t.Errorf("v1 prompt 不应包含 schema_version")
+	}
+}
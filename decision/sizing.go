@@ -0,0 +1,119 @@
+package decision
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// 仓位数量字段的含义（每条决策只能使用一种，避免把数量误当成USDT或百分比）：
+//   - size_usd（同义 position_size_usd）: 名义价值（USDT，含杠杆）。开仓为仓位大小，partial_close 为减仓的名义价值
+//   - reduce_pct（同义 close_percentage）: 按实时持仓数量的百分比减仓（0-100，仅 partial_close）
+//   - qty_contracts: 合约数量（币数），仅 partial_close，超过实时持仓时按全部持仓平仓
+
+// normalizeSizing 合并同义字段并检查仓位字段的含义是否与动作一致
+// 规整后开仓只使用 PositionSizeUSD；部分平仓使用 ClosePercentage / SizeUSD / QtyContracts 中的一个
+func normalizeSizing(d *Decision) error {
+	usd, err := mergeSynonym("size_usd", d.SizeUSD, "position_size_usd", d.PositionSizeUSD)
+	if err != nil {
+		return err
+	}
+	pct, err := mergeSynonym("reduce_pct", d.ReducePct, "close_percentage", d.ClosePercentage)
+	if err != nil {
+		return err
+	}
+	if usd < 0 || pct < 0 || d.QtyContracts < 0 {
+		return fmt.Errorf("size_usd、reduce_pct、qty_contracts 不能为负")
+	}
+	d.ReducePct = 0
+
+	switch d.Action {
+	case "open_long", "open_short":
+		if pct > 0 || d.QtyContracts > 0 {
+			return fmt.Errorf("开仓只能用 size_usd 指定仓位名义价值，不支持 reduce_pct / qty_contracts")
+		}
+		d.PositionSizeUSD, d.SizeUSD, d.ClosePercentage = usd, 0, 0
+
+	case "partial_close":
+		set := 0
+		for _, v := range []float64{usd, pct, d.QtyContracts} {
+			if v > 0 {
+				set++
+			}
+		}
+		if set == 0 {
+			return fmt.Errorf("部分平仓必须提供 reduce_pct、size_usd、qty_contracts 之一")
+		}
+		if set > 1 {
+			return fmt.Errorf("部分平仓只能使用 reduce_pct、size_usd、qty_contracts 中的一个（含义不同，不能同时填写）")
+		}
+		d.SizeUSD, d.PositionSizeUSD, d.ClosePercentage = usd, 0, pct
+
+	case "close_long", "close_short":
+		// 全部平仓：仅允许显式的 100%，其余数量说明是想部分平仓，按含义不明拒绝而不是擅自全平
+		if usd > 0 || d.QtyContracts > 0 || (pct > 0 && pct < 100) {
+			return fmt.Errorf("%s 为全部平仓，部分减仓请使用 partial_close 并填写 reduce_pct / size_usd / qty_contracts", d.Action)
+		}
+		d.SizeUSD, d.ClosePercentage = 0, 0
+	}
+	return nil
+}
+
+// mergeSynonym 合并两个同义字段（都填写且不一致时报错）
+func mergeSynonym(name string, value float64, alias string, aliasValue float64) (float64, error) {
+	if value > 0 && aliasValue > 0 && math.Abs(value-aliasValue) > 1e-9 {
+		return 0, fmt.Errorf("%s (%g) 与 %s (%g) 同义但取值不一致", name, value, alias, aliasValue)
+	}
+	if value > 0 {
+		return value, nil
+	}
+	return aliasValue, nil
+}
+
+// reducePct 减仓百分比（未经验证规整的决策可能只填写了同义字段 reduce_pct）
+func (d *Decision) reducePct() float64 {
+	if d.ClosePercentage > 0 {
+		return d.ClosePercentage
+	}
+	return d.ReducePct
+}
+
+// ReduceQuantity 按实时持仓数量和当前价格计算部分平仓数量（不超过持仓数量）
+func (d *Decision) ReduceQuantity(positionQty, price float64) (float64, error) {
+	if positionQty <= 0 {
+		return 0, fmt.Errorf("持仓数量为0")
+	}
+	var quantity float64
+	switch pct := d.reducePct(); {
+	case pct > 0:
+		if pct > 100 {
+			return 0, fmt.Errorf("平仓百分比必须在 0-100 之间，当前: %.1f", pct)
+		}
+		quantity = positionQty * pct / 100
+	case d.QtyContracts > 0:
+		quantity = d.QtyContracts
+	case d.SizeUSD > 0:
+		if price <= 0 {
+			return 0, fmt.Errorf("按 size_usd 减仓需要有效的当前价格")
+		}
+		quantity = d.SizeUSD / price
+	default:
+		return 0, fmt.Errorf("部分平仓缺少 reduce_pct、size_usd 或 qty_contracts")
+	}
+	return math.Min(quantity, positionQty), nil
+}
+
+// ReduceDescription 部分平仓数量的可读描述（用于日志）
+func (d *Decision) ReduceDescription() string {
+	var parts []string
+	if pct := d.reducePct(); pct > 0 {
+		parts = append(parts, fmt.Sprintf("%.1f%%", pct))
+	}
+	if d.SizeUSD > 0 {
+		parts = append(parts, fmt.Sprintf("%.2f USDT", d.SizeUSD))
+	}
+	if d.QtyContracts > 0 {
+		parts = append(parts, fmt.Sprintf("%g 张", d.QtyContracts))
+	}
+	return strings.Join(parts, " / ")
+}
@@ -0,0 +1,123 @@
+package decision
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSizing(t *testing.T) {
+	tests := []struct {
+		name    string
+		d       Decision
+		want    Decision
+		wantErr string
+	}{
+		{
+			name: "开仓 size_usd 合并到 position_size_usd",
+			d:    Decision{Action: "open_long", SizeUSD: 500},
+			want: Decision{Action: "open_long", PositionSizeUSD: 500},
+		},
+		{
+			name:    "开仓同义字段取值不一致",
+			d:       Decision{Action: "open_long", SizeUSD: 500, PositionSizeUSD: 300},
+			wantErr: "取值不一致",
+		},
+		{
+			name:    "开仓不支持合约数量",
+			d:       Decision{Action: "open_short", PositionSizeUSD: 500, QtyContracts: 2},
+			wantErr: "开仓只能用 size_usd",
+		},
+		{
+			name: "部分平仓 reduce_pct 合并到 close_percentage",
+			d:    Decision{Action: "partial_close", ReducePct: 30},
+			want: Decision{Action: "partial_close", ClosePercentage: 30},
+		},
+		{
+			name: "部分平仓 position_size_usd 视为减仓名义价值",
+			d:    Decision{Action: "partial_close", PositionSizeUSD: 200},
+			want: Decision{Action: "partial_close", SizeUSD: 200},
+		},
+		{
+			name:    "部分平仓混用百分比和数量",
+			d:       Decision{Action: "partial_close", ClosePercentage: 50, QtyContracts: 1},
+			wantErr: "只能使用",
+		},
+		{
+			name:    "部分平仓缺少数量",
+			d:       Decision{Action: "partial_close"},
+			wantErr: "必须提供",
+		},
+		{
+			name: "全部平仓允许100%",
+			d:    Decision{Action: "close_long", ReducePct: 100},
+			want: Decision{Action: "close_long"},
+		},
+		{
+			name:    "全部平仓附带部分数量",
+			d:       Decision{Action: "close_short", ReducePct: 40},
+			wantErr: "partial_close",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tt.d
+			err := normalizeSizing(&d)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v, want 包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("意外错误: %v", err)
+			}
+			if d != tt.want {
+				t.Errorf("规整结果 = %+v, want %+v", d, tt.want)
+			}
+		})
+	}
+}
+
+func TestReduceQuantity(t *testing.T) {
+	tests := []struct {
+		name    string
+		d       Decision
+		want    float64
+		wantErr bool
+	}{
+		{name: "百分比", d: Decision{ClosePercentage: 25}, want: 2.5},
+		{name: "未规整的 reduce_pct", d: Decision{ReducePct: 50}, want: 5},
+		{name: "名义价值按当前价换算", d: Decision{SizeUSD: 400}, want: 2},
+		{name: "合约数量", d: Decision{QtyContracts: 3}, want: 3},
+		{name: "超过持仓按全部持仓", d: Decision{QtyContracts: 30}, want: 10},
+		{name: "百分比超过100", d: Decision{ClosePercentage: 150}, wantErr: true},
+		{name: "缺少数量", d: Decision{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.d.ReduceQuantity(10, 200)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReduceQuantity error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ReduceQuantity = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDecisionPartialClose(t *testing.T) {
+	d := Decision{Symbol: "SOLUSDT", Action: "partial_close", ReducePct: 40}
+	if err := ValidateDecision(&d, 1000, 5, 3, 0); err != nil {
+		t.Fatalf("ValidateDecision: %v", err)
+	}
+	if d.ClosePercentage != 40 || d.ReducePct != 0 {
+		t.Errorf("验证后应规整为 close_percentage=40: %+v", d)
+	}
+
+	d = Decision{Symbol: "SOLUSDT", Action: "partial_close", ClosePercentage: 40, SizeUSD: 100}
+	if err := ValidateDecision(&d, 1000, 5, 3, 0); err == nil {
+		t.Error("混用 reduce_pct 和 size_usd 应被拒绝")
+	}
+}
@@ -0,0 +1,31 @@
+package decision
+
+import (
+	"fmt"
+	"nofx/market"
+	"strings"
+)
+
+// staleMarketData 行情数据陈旧的币种（symbol -> 落后的周期）
+func staleMarketData(marketDataMap map[string]*market.Data) map[string][]market.StaleTimeframe {
+	stale := make(map[string][]market.StaleTimeframe)
+	for symbol, data := range marketDataMap {
+		if tfs := data.StaleTimeframes(); len(tfs) > 0 {
+			stale[symbol] = tfs
+		}
+	}
+	return stale
+}
+
+// writeStaleness 行情数据陈旧时在币种市场数据前写入警告（该币种新开仓会被自动转为 wait）
+func writeStaleness(sb *strings.Builder, ctx *Context, symbol string) {
+	tfs := ctx.StaleData[symbol]
+	if len(tfs) == 0 {
+		return
+	}
+	descs := make([]string, len(tfs))
+	for i, tf := range tfs {
+		descs[i] = tf.String()
+	}
+	sb.WriteString(fmt.Sprintf("⚠️ 行情数据延迟: %s。以下数据可能已冻结，不要对该币种开仓（开仓决策会被自动转为 wait），持仓管理请结合当前价谨慎判断\n\n", strings.Join(descs, "；")))
+}
@@ -0,0 +1,96 @@
+package decision
+
+import (
+	"fmt"
+	"math"
+	"nofx/market"
+	"sort"
+	"strings"
+)
+
+// TimeframeReliability 某币种某周期趋势信号的实际命中率（由交易员按已平仓交易统计）
+type TimeframeReliability struct {
+	HitRate float64 `json:"hit_rate"` // 平滑后的命中率（无样本为0.5）
+	Samples int     `json:"samples"`
+}
+
+// TimeframeAlignment 各周期趋势方向按可靠度加权融合的结果
+type TimeframeAlignment struct {
+	Trends map[string]int `json:"trends"` // 周期 -> 方向（1 多 / -1 空 / 0 不明确）
+	Bias   float64        `json:"bias"`   // 加权倾向（-1 ~ 1，正为偏多）
+}
+
+// fuseTimeframes 按可靠度加权融合各周期方向：权重为命中率，未统计的周期按0.5处理
+func fuseTimeframes(trends map[string]int, reliability map[string]TimeframeReliability) float64 {
+	var weighted, total float64
+	for tf, dir := range trends {
+		weight := 0.5
+		if r, ok := reliability[tf]; ok {
+			weight = r.HitRate
+		}
+		weighted += float64(dir) * weight
+		total += weight
+	}
+	if total == 0 {
+		return 0
+	}
+	return weighted / total
+}
+
+// AlignTimeframes 计算币种的周期一致性（少于2个周期时返回 nil）
+func AlignTimeframes(data *market.Data, reliability map[string]TimeframeReliability) *TimeframeAlignment {
+	trends := data.TimeframeTrends()
+	if len(trends) < 2 {
+		return nil
+	}
+	return &TimeframeAlignment{Trends: trends, Bias: fuseTimeframes(trends, reliability)}
+}
+
+// writeTimeframeAlignment 输出周期一致性摘要（各周期方向、实际命中率和加权倾向）
+// 例：🧭 周期一致性: 3m ↑(命中62%/13笔) 4h ↓(命中48%/9笔) → 加权倾向 +0.12 偏多
+func writeTimeframeAlignment(sb *strings.Builder, ctx *Context, symbol string) {
+	data, ok := ctx.MarketDataMap[symbol]
+	if !ok {
+		return
+	}
+	reliability := ctx.TimeframeReliability[symbol]
+	alignment := AlignTimeframes(data, reliability)
+	if alignment == nil {
+		return
+	}
+
+	timeframes := make([]string, 0, len(alignment.Trends))
+	for tf := range alignment.Trends {
+		timeframes = append(timeframes, tf)
+	}
+	sort.Slice(timeframes, func(i, j int) bool {
+		di, _ := market.IntervalDuration(timeframes[i])
+		dj, _ := market.IntervalDuration(timeframes[j])
+		return di < dj
+	})
+
+	parts := make([]string, 0, len(timeframes))
+	for _, tf := range timeframes {
+		arrow := "→"
+		switch alignment.Trends[tf] {
+		case 1:
+			arrow = "↑"
+		case -1:
+			arrow = "↓"
+		}
+		if r, ok := reliability[tf]; ok && r.Samples > 0 {
+			parts = append(parts, fmt.Sprintf("%s %s(命中%.0f%%/%d笔)", tf, arrow, r.HitRate*100, r.Samples))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s %s(无样本)", tf, arrow))
+		}
+	}
+
+	lean := "中性"
+	if math.Abs(alignment.Bias) >= 0.2 {
+		lean = "偏多"
+		if alignment.Bias < 0 {
+			lean = "偏空"
+		}
+	}
+	sb.WriteString(fmt.Sprintf("🧭 周期一致性: %s → 加权倾向 %+.2f %s\n\n", strings.Join(parts, " "), alignment.Bias, lean))
+}
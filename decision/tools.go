@@ -0,0 +1,232 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"nofx/loglevel"
+	"nofx/market"
+	"nofx/mcp"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxToolCallBudget 单周期工具调用次数上限（防止模型反复请求数据拖慢决策）
+	MaxToolCallBudget = 8
+	maxToolKlines     = 100 // klines 工具单次最多返回的K线根数
+	defaultToolKlines = 30
+	defaultToolDepth  = 10
+	maxToolResultLen  = 6000 // 单个工具结果写入对话的最大字符数
+)
+
+var reToolCallTag = regexp.MustCompile(`(?s)<tool_call>(.*?)</tool_call>`)
+
+// ToolCall 模型在决策过程中请求的一次工具调用
+type ToolCall struct {
+	Tool       string `json:"tool"`
+	Symbol     string `json:"symbol,omitempty"`
+	Interval   string `json:"interval,omitempty"` // klines：K线周期
+	Limit      int    `json:"limit,omitempty"`    // klines：K线根数；orderbook：盘口档数
+	Error      string `json:"error,omitempty"`
+	ResultLen  int    `json:"result_len"` // 返回给模型的结果长度（字符）
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// String 工具调用摘要（用于决策日志）
+func (c ToolCall) String() string {
+	desc := c.Tool + " " + c.Symbol
+	if c.Interval != "" {
+		desc += " " + c.Interval
+	}
+	if c.Limit > 0 {
+		desc += fmt.Sprintf(" ×%d", c.Limit)
+	}
+	if c.Error != "" {
+		return fmt.Sprintf("%s ❌ %s", desc, c.Error)
+	}
+	return fmt.Sprintf("%s ✓ %d字符 %dms", desc, c.ResultLen, c.DurationMs)
+}
+
+// parseToolCalls 提取模型输出中的工具调用请求（已输出 <decision> 时视为最终决策，不再执行工具）
+func parseToolCalls(response string) ([]ToolCall, error) {
+	if reDecisionTag.MatchString(response) {
+		return nil, nil
+	}
+	var calls []ToolCall
+	for _, m := range reToolCallTag.FindAllStringSubmatch(response, -1) {
+		var call ToolCall
+		if err := json.Unmarshal([]byte(strings.TrimSpace(m[1])), &call); err != nil {
+			return calls, fmt.Errorf("工具调用不是有效JSON: %w", err)
+		}
+		call.Tool = strings.ToLower(strings.TrimSpace(call.Tool))
+		call.Symbol = market.Normalize(strings.TrimSpace(call.Symbol))
+		call.Interval = strings.ToLower(strings.TrimSpace(call.Interval))
+		calls = append(calls, call)
+	}
+	return calls, nil
+}
+
+// executeToolCall 执行工具调用（数据来自 market 包），返回写入对话的结果文本
+func executeToolCall(ctx *Context, call *ToolCall) string {
+	start := time.Now()
+	result, err := runTool(ctx, call)
+	call.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		call.Error = err.Error()
+		result = "错误: " + err.Error()
+	}
+	if len(result) > maxToolResultLen {
+		result = result[:maxToolResultLen] + "\n...（结果过长已截断）"
+	}
+	call.ResultLen = len(result)
+	return fmt.Sprintf("<tool_result tool=\"%s\" symbol=\"%s\">\n%s\n</tool_result>", call.Tool, call.Symbol, result)
+}
+
+func runTool(ctx *Context, call *ToolCall) (string, error) {
+	if call.Symbol == "" || call.Symbol == "USDT" {
+		return "", fmt.Errorf("缺少 symbol")
+	}
+	switch call.Tool {
+	case "klines":
+		if !market.IsSupportedTimeframe(call.Interval) {
+			return "", fmt.Errorf("不支持的K线周期 %q（可选 %s）", call.Interval, strings.Join(market.SupportedTimeframes, " / "))
+		}
+		if call.Limit <= 0 {
+			call.Limit = defaultToolKlines
+		}
+		if call.Limit > maxToolKlines {
+			call.Limit = maxToolKlines
+		}
+		klines, err := market.NewAPIClient().GetKlines(call.Symbol, call.Interval, call.Limit)
+		if err != nil {
+			return "", err
+		}
+		return formatToolKlines(klines), nil
+	case "orderbook":
+		switch {
+		case call.Limit <= 0:
+			call.Limit = defaultToolDepth
+		case call.Limit <= 5:
+			call.Limit = 5
+		case call.Limit <= 10:
+			call.Limit = 10
+		default:
+			call.Limit = market.DepthSnapshotLevels
+		}
+		book, err := market.NewAPIClient().GetOrderBook(call.Symbol, call.Limit)
+		if err != nil {
+			return "", err
+		}
+		return formatToolOrderBook(book), nil
+	case "market_data":
+		data, err := market.GetWithTimeframes(call.Symbol, ctx.Timeframes)
+		if err != nil {
+			return "", err
+		}
+		if len(ctx.Indicators) > 0 {
+			data.Indicators = market.ComputeIndicators(data, ctx.Indicators)
+		}
+		return market.FormatWithVerbosity(data, ctx.Verbosity), nil
+	}
+	return "", fmt.Errorf("未知工具 %q（可用 klines / orderbook / market_data）", call.Tool)
+}
+
+// formatToolKlines K线结果（时间 开 高 低 收 量，旧 → 新）
+func formatToolKlines(klines []market.Kline) string {
+	var sb strings.Builder
+	sb.WriteString("time(UTC) open high low close volume\n")
+	for _, k := range klines {
+		sb.WriteString(fmt.Sprintf("%s %.6g %.6g %.6g %.6g %.4g\n",
+			time.UnixMilli(k.OpenTime).UTC().Format("01-02 15:04"), k.Open, k.High, k.Low, k.Close, k.Volume))
+	}
+	return sb.String()
+}
+
+// formatToolOrderBook 盘口结果（价差和买卖盘量比便于模型判断流动性）
+func formatToolOrderBook(book *market.OrderBook) string {
+	var sb strings.Builder
+	var bidQty, askQty float64
+	for _, l := range book.Bids {
+		bidQty += l.Quantity
+	}
+	for _, l := range book.Asks {
+		askQty += l.Quantity
+	}
+	if len(book.Bids) > 0 && len(book.Asks) > 0 {
+		bestBid, bestAsk := book.Bids[0].Price, book.Asks[0].Price
+		sb.WriteString(fmt.Sprintf("best_bid %.6g best_ask %.6g spread %.4f%%\n", bestBid, bestAsk, (bestAsk-bestBid)/bestBid*100))
+	}
+	if askQty > 0 {
+		sb.WriteString(fmt.Sprintf("bid/ask quantity ratio %.2f\n", bidQty/askQty))
+	}
+	sb.WriteString("asks (price quantity):\n")
+	for i := len(book.Asks) - 1; i >= 0; i-- {
+		sb.WriteString(fmt.Sprintf("  %.6g %.4g\n", book.Asks[i].Price, book.Asks[i].Quantity))
+	}
+	sb.WriteString("bids (price quantity):\n")
+	for _, l := range book.Bids {
+		sb.WriteString(fmt.Sprintf("  %.6g %.4g\n", l.Price, l.Quantity))
+	}
+	return sb.String()
+}
+
+// formatToolUsage 工具调用说明（追加到 System Prompt，仅在开启工具调用时输出）
+func formatToolUsage(budget int) string {
+	return fmt.Sprintf(`
+
+# 🔧 数据工具（可选）
+
+如果现有数据不足以做出判断，可以先请求额外数据，再输出最终决策。本周期最多调用 %d 次工具。
+请求方式：只输出一个或多个 <tool_call> 标签（不要同时输出 <decision>），每个标签内是一个JSON对象：
+- {"tool":"klines","symbol":"SOLUSDT","interval":"1d","limit":30} — K线（interval 可选 %s，limit 最多 %d）
+- {"tool":"orderbook","symbol":"BTCUSDT","limit":10} — 盘口（limit 可选 5 / 10 / 20）
+- {"tool":"market_data","symbol":"ETHUSDT"} — 完整市场数据（适用于不在候选列表中的币种）
+工具结果会以 <tool_result> 返回给你。数据足够时直接按要求输出 <reasoning> 和 <decision>；次数用完后必须直接给出最终决策。`,
+		budget, strings.Join(market.SupportedTimeframes, "/"), maxToolKlines)
+}
+
+// runToolLoop 工具调用循环：模型请求数据 → 执行工具 → 返回结果，直到模型输出最终决策或次数用完
+func runToolLoop(ctx *Context, mcpClient *mcp.Client, systemPrompt, userPrompt, aiResponse string) (string, []ToolCall, error) {
+	var calls []ToolCall
+	conversation := []mcp.Message{{Role: "user", Content: userPrompt}}
+	for {
+		requested, err := parseToolCalls(aiResponse)
+		if err == nil && len(requested) == 0 {
+			return aiResponse, calls, nil
+		}
+
+		var results []string
+		if err != nil {
+			// 格式错误也占用一次调用次数，避免模型反复输出无效请求
+			calls = append(calls, ToolCall{Tool: "invalid", Error: err.Error()})
+			results = append(results, "工具调用格式错误: "+err.Error())
+		}
+		for _, call := range requested {
+			if len(calls) >= ctx.ToolBudget {
+				break
+			}
+			results = append(results, executeToolCall(ctx, &call))
+			loglevel.Infof(loglevel.Decision, "🔧 [%s] 工具调用 %s", ctx.TraderID, call)
+			calls = append(calls, call)
+		}
+		if len(calls) >= ctx.ToolBudget {
+			results = append(results, fmt.Sprintf("工具调用次数已用完（%d/%d），请基于现有数据直接输出 <reasoning> 和 <decision>。", len(calls), ctx.ToolBudget))
+		} else {
+			results = append(results, fmt.Sprintf("剩余工具调用次数: %d", ctx.ToolBudget-len(calls)))
+		}
+		conversation = append(conversation,
+			mcp.Message{Role: "assistant", Content: aiResponse},
+			mcp.Message{Role: "user", Content: strings.Join(results, "\n\n")})
+
+		releaseAI := aiCallScheduler.Acquire(ctx.TraderID)
+		aiResponse, err = mcpClient.CallWithConversation(systemPrompt, conversation)
+		releaseAI()
+		if err != nil {
+			return "", calls, fmt.Errorf("工具调用后再次调用AI失败（已调用%d次工具）: %w", len(calls), err)
+		}
+		if len(calls) >= ctx.ToolBudget {
+			return aiResponse, calls, nil
+		}
+	}
+}
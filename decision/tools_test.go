@@ -0,0 +1,49 @@
+package decision
+
+import "testing"
+
+func TestParseToolCalls(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     []ToolCall
+		wantErr  bool
+	}{
+		{
+			"单个K线请求",
+			`需要日线确认趋势 <tool_call>{"tool":"klines","symbol":"sol","interval":"1D","limit":30}</tool_call>`,
+			[]ToolCall{{Tool: "klines", Symbol: "SOLUSDT", Interval: "1d", Limit: 30}},
+			false,
+		},
+		{
+			"多个请求",
+			`<tool_call>{"tool":"orderbook","symbol":"BTCUSDT"}</tool_call><tool_call>{"tool":"Market_Data","symbol":"ETHUSDT"}</tool_call>`,
+			[]ToolCall{{Tool: "orderbook", Symbol: "BTCUSDT"}, {Tool: "market_data", Symbol: "ETHUSDT"}},
+			false,
+		},
+		{
+			"已输出最终决策",
+			`<tool_call>{"tool":"klines","symbol":"BTCUSDT","interval":"1d"}</tool_call><decision>[]</decision>`,
+			nil,
+			false,
+		},
+		{"没有工具调用", `<reasoning>观望</reasoning>`, nil, false},
+		{"无效JSON", `<tool_call>{"tool":"klines",</tool_call>`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseToolCalls(tt.response)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseToolCalls() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseToolCalls() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("call[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package decision
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TradeIdeaBrief 提交给AI评估的外部交易想法
+type TradeIdeaBrief struct {
+	ID        string
+	Symbol    string
+	Direction string // long / short
+	Thesis    string
+}
+
+// formatTradeIdeas 待评估的交易想法（要求AI逐条给出带 idea_id 的接受/拒绝决策）
+func formatTradeIdeas(ideas []TradeIdeaBrief) string {
+	var sb strings.Builder
+	sb.WriteString("## 📥 待评估的外部交易想法\n")
+	sb.WriteString("请结合当前行情逐条评估，每条想法必须输出一条带 `idea_id` 的决策：接受则给出该方向完整的开仓决策（open_long/open_short，照常满足全部风控约束），拒绝则输出 action=wait 并在 reasoning 中说明理由。\n")
+	for _, idea := range ideas {
+		direction := "做多"
+		if idea.Direction == "short" {
+			direction = "做空"
+		}
+		sb.WriteString(fmt.Sprintf("- [idea_id=%s] %s %s | 论点: %s\n", idea.ID, idea.Symbol, direction, idea.Thesis))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
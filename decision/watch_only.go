@@ -0,0 +1,21 @@
+package decision
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isWatchOnly 币种是否为仅观察（出现在prompt中供分析，但不允许开仓）
+func isWatchOnly(ctx *Context, symbol string) bool {
+	for _, s := range ctx.WatchOnly {
+		if s == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// formatWatchOnly 仅观察币种说明（开仓决策会被自动转为 wait）
+func formatWatchOnly(symbols []string) string {
+	return fmt.Sprintf("## 👀 仅观察币种\n%s 仅用于跟踪形态：可以分析和点评（输出 wait 并在 reasoning 中写明观点），不要对其开仓，开仓决策会被自动转为 wait。\n\n", strings.Join(symbols, "、"))
+}
@@ -0,0 +1,156 @@
+package events
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// subscriberBuffer 每个订阅者的事件缓冲（满时丢弃新事件，不阻塞发布方）
+const subscriberBuffer = 256
+
+// Topic 带类型的事件主题（发布与订阅在编译期保证负载类型一致）
+type Topic[T any] struct {
+	Name string
+}
+
+// Bus 进程内事件总线：发布方不直接依赖订阅方，各订阅者在独立goroutine中按发布顺序处理事件
+type Bus struct {
+	mu        sync.RWMutex
+	seq       int
+	subs      map[string][]*subscriber
+	published sync.Map // topic -> *int64
+}
+
+type subscriber struct {
+	id      int
+	name    string
+	ch      chan any
+	handled int64
+	dropped int64
+}
+
+// SubscriberStats 单个订阅者的处理统计
+type SubscriberStats struct {
+	Topic   string `json:"topic"`
+	Name    string `json:"name"`
+	Pending int    `json:"pending"` // 缓冲中等待处理的事件数
+	Handled int64  `json:"handled"`
+	Dropped int64  `json:"dropped"` // 缓冲已满被丢弃的事件数
+}
+
+// BusStats 事件总线统计
+type BusStats struct {
+	Published   map[string]int64  `json:"published"` // 各主题已发布事件数
+	Subscribers []SubscriberStats `json:"subscribers"`
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{subs: make(map[string][]*subscriber)}
+}
+
+// Default 全局事件总线（market、decision、trader、logger 等模块通过它解耦）
+var Default = NewBus()
+
+// Subscribe 在全局总线上订阅主题，返回取消订阅函数
+func Subscribe[T any](topic Topic[T], name string, handler func(T)) func() {
+	return SubscribeOn(Default, topic, name, handler)
+}
+
+// Publish 向全局总线发布事件
+func Publish[T any](topic Topic[T], event T) {
+	PublishOn(Default, topic, event)
+}
+
+// SubscribeOn 在指定总线上订阅主题（name 用于统计和日志），返回取消订阅函数
+func SubscribeOn[T any](b *Bus, topic Topic[T], name string, handler func(T)) func() {
+	b.mu.Lock()
+	b.seq++
+	sub := &subscriber{id: b.seq, name: name, ch: make(chan any, subscriberBuffer)}
+	b.subs[topic.Name] = append(b.subs[topic.Name], sub)
+	b.mu.Unlock()
+
+	go func() {
+		for event := range sub.ch {
+			deliver(topic.Name, sub, handler, event.(T))
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			subs := b.subs[topic.Name]
+			for i, s := range subs {
+				if s.id == sub.id {
+					b.subs[topic.Name] = append(subs[:i:i], subs[i+1:]...)
+					break
+				}
+			}
+			close(sub.ch)
+		})
+	}
+}
+
+// PublishOn 向指定总线发布事件（非阻塞：订阅者缓冲已满时丢弃并计数）
+func PublishOn[T any](b *Bus, topic Topic[T], event T) {
+	counter, _ := b.published.LoadOrStore(topic.Name, new(int64))
+	atomic.AddInt64(counter.(*int64), 1)
+
+	// 持有读锁发送，保证取消订阅（写锁下关闭通道）不会与发送并发
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, sub := range b.subs[topic.Name] {
+		select {
+		case sub.ch <- event:
+		default:
+			if atomic.AddInt64(&sub.dropped, 1) == 1 {
+				log.Printf("⚠️  事件总线: 订阅者 %s 处理过慢，丢弃 %s 事件", sub.name, topic.Name)
+			}
+		}
+	}
+}
+
+// deliver 调用订阅者处理函数（处理函数 panic 不影响总线和其他订阅者）
+func deliver[T any](topic string, sub *subscriber, handler func(T), event T) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ 事件总线: 订阅者 %s 处理 %s 事件 panic: %v", sub.name, topic, r)
+		}
+	}()
+	handler(event)
+	atomic.AddInt64(&sub.handled, 1)
+}
+
+// Stats 各主题发布数及订阅者处理情况
+func (b *Bus) Stats() BusStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	stats := BusStats{Published: make(map[string]int64), Subscribers: []SubscriberStats{}}
+	b.published.Range(func(topic, counter any) bool {
+		stats.Published[topic.(string)] = atomic.LoadInt64(counter.(*int64))
+		return true
+	})
+	for topic, subs := range b.subs {
+		for _, sub := range subs {
+			stats.Subscribers = append(stats.Subscribers, SubscriberStats{
+				Topic:   topic,
+				Name:    sub.name,
+				Pending: len(sub.ch),
+				Handled: atomic.LoadInt64(&sub.handled),
+				Dropped: atomic.LoadInt64(&sub.dropped),
+			})
+		}
+	}
+	sort.Slice(stats.Subscribers, func(i, j int) bool {
+		if stats.Subscribers[i].Topic != stats.Subscribers[j].Topic {
+			return stats.Subscribers[i].Topic < stats.Subscribers[j].Topic
+		}
+		return stats.Subscribers[i].Name < stats.Subscribers[j].Name
+	})
+	return stats
+}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBusPublishSubscribe(t *testing.T) {
+	bus := NewBus()
+	topic := Topic[int]{Name: "test.topic"}
+
+	var mu sync.Mutex
+	var got []int
+	done := make(chan struct{})
+	unsubscribe := SubscribeOn(bus, topic, "collector", func(v int) {
+		mu.Lock()
+		got = append(got, v)
+		if len(got) == 3 {
+			close(done)
+		}
+		mu.Unlock()
+	})
+	// panic 的订阅者不影响其他订阅者
+	unsubscribePanic := SubscribeOn(bus, topic, "panicker", func(int) { panic("boom") })
+	defer unsubscribePanic()
+
+	for i := 1; i <= 3; i++ {
+		PublishOn(bus, topic, i)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("订阅者未收到全部事件")
+	}
+	mu.Lock()
+	if got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Errorf("事件顺序 = %v, want [1 2 3]", got)
+	}
+	mu.Unlock()
+
+	unsubscribe()
+	unsubscribe() // 重复取消订阅是安全的
+	PublishOn(bus, topic, 4)
+
+	stats := bus.Stats()
+	if stats.Published["test.topic"] != 4 {
+		t.Errorf("published = %d, want 4", stats.Published["test.topic"])
+	}
+	if len(stats.Subscribers) != 1 || stats.Subscribers[0].Name != "panicker" {
+		t.Errorf("subscribers = %+v, want only panicker", stats.Subscribers)
+	}
+}
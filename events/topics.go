@@ -0,0 +1,58 @@
+package events
+
+import "time"
+
+// 内置主题
+var (
+	KlineClosed     = Topic[KlineClosedEvent]{Name: "kline.closed"}         // WebSocket K线收盘
+	DecisionCreated = Topic[DecisionCreatedEvent]{Name: "decision.created"} // AI输出决策（执行前）
+	OrderFilled     = Topic[OrderFilledEvent]{Name: "order.filled"}         // 开仓/平仓成交
+	RiskBreached    = Topic[RiskBreachedEvent]{Name: "risk.breached"}       // 触发风控规则
+)
+
+// KlineClosedEvent K线收盘
+type KlineClosedEvent struct {
+	Symbol    string  `json:"symbol"`
+	Interval  string  `json:"interval"` // 3m / 4h
+	OpenTime  int64   `json:"open_time"`
+	CloseTime int64   `json:"close_time"`
+	Open      float64 `json:"open"`
+	High      float64 `json:"high"`
+	Low       float64 `json:"low"`
+	Close     float64 `json:"close"`
+	Volume    float64 `json:"volume"`
+}
+
+// DecisionCreatedEvent AI输出的单条决策
+type DecisionCreatedEvent struct {
+	TraderID   string    `json:"trader_id"`
+	Cycle      int       `json:"cycle"`
+	Symbol     string    `json:"symbol"`
+	Action     string    `json:"action"`
+	Confidence int       `json:"confidence"`
+	Reasoning  string    `json:"reasoning"`
+	Time       time.Time `json:"time"`
+}
+
+// OrderFilledEvent 订单成交
+type OrderFilledEvent struct {
+	TraderID string    `json:"trader_id"`
+	Symbol   string    `json:"symbol"`
+	Action   string    `json:"action"`
+	Side     string    `json:"side"` // BUY / SELL
+	Quantity float64   `json:"quantity"`
+	Price    float64   `json:"price"` // 成交均价
+	OrderID  int64     `json:"order_id"`
+	Time     time.Time `json:"time"`
+}
+
+// RiskBreachedEvent 风控规则拒绝或触发
+type RiskBreachedEvent struct {
+	TraderID string    `json:"trader_id"`
+	Symbol   string    `json:"symbol,omitempty"`
+	Action   string    `json:"action,omitempty"`
+	Rule     string    `json:"rule"` // open_risk / persona / loss_streak / daily_loss ...
+	Detail   string    `json:"detail"`
+	Critical bool      `json:"critical"` // 需要立即人工处理（止损缺失、回撤平仓等）
+	Time     time.Time `json:"time"`
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"nofx/market"
+	"os"
+	"time"
+)
+
+// runExportIndicators 命令行导出历史指标（研究用）
+// 用法: nofx export-indicators -symbol BTCUSDT -interval 1h -start 2025-01-01 -end 2025-02-01 [-out btc_1h.csv]
+func runExportIndicators(args []string) error {
+	fs := flag.NewFlagSet("export-indicators", flag.ContinueOnError)
+	symbol := fs.String("symbol", "", "币种（如 BTCUSDT）")
+	interval := fs.String("interval", "1h", "K线周期（如 3m / 15m / 1h / 4h / 1d）")
+	startStr := fs.String("start", "", "开始时间（2006-01-02 或 RFC3339）")
+	endStr := fs.String("end", "", "结束时间（2006-01-02 或 RFC3339，默认当前时间）")
+	out := fs.String("out", "", "输出文件（默认输出到标准输出）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *symbol == "" || *startStr == "" {
+		fs.Usage()
+		return fmt.Errorf("必须指定 -symbol 和 -start")
+	}
+
+	start, err := parseExportTime(*startStr)
+	if err != nil {
+		return fmt.Errorf("-start 无效: %w", err)
+	}
+	end := time.Now()
+	if *endStr != "" {
+		if end, err = parseExportTime(*endStr); err != nil {
+			return fmt.Errorf("-end 无效: %w", err)
+		}
+	}
+
+	rows, err := market.ExportIndicators(*symbol, *interval, start, end)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := market.WriteIndicatorCSV(w, rows); err != nil {
+		return err
+	}
+	if *out != "" {
+		log.Printf("✓ 已导出 %s %s 共%d根K线的指标到 %s", market.Normalize(*symbol), *interval, len(rows), *out)
+	}
+	return nil
+}
+
+// parseExportTime 解析日期（2006-01-02，UTC）或 RFC3339 时间
+func parseExportTime(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
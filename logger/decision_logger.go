@@ -5,26 +5,44 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math"
+	"nofx/mcp"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 )
 
 // DecisionRecord 决策记录
 type DecisionRecord struct {
-	Timestamp      time.Time          `json:"timestamp"`       // 决策时间
-	CycleNumber    int                `json:"cycle_number"`    // 周期编号
-	SystemPrompt   string             `json:"system_prompt"`   // 系统提示词（发送给AI的系统prompt）
-	InputPrompt    string             `json:"input_prompt"`    // 发送给AI的输入prompt
-	CoTTrace       string             `json:"cot_trace"`       // AI思维链（输出）
-	DecisionJSON   string             `json:"decision_json"`   // 决策JSON
-	AccountState   AccountSnapshot    `json:"account_state"`   // 账户状态快照
-	Positions      []PositionSnapshot `json:"positions"`       // 持仓快照
-	CandidateCoins []string           `json:"candidate_coins"` // 候选币种列表
-	Decisions      []DecisionAction   `json:"decisions"`       // 执行的决策
-	ExecutionLog   []string           `json:"execution_log"`   // 执行日志
-	Success        bool               `json:"success"`         // 是否成功
-	ErrorMessage   string             `json:"error_message"`   // 错误信息（如果有）
+	ID                string              `json:"id,omitempty"`                 // 记录ID（日志文件名，关联订单映射）
+	Timestamp         time.Time           `json:"timestamp"`                    // 决策时间
+	CycleNumber       int                 `json:"cycle_number"`                 // 周期编号
+	SystemPrompt      string              `json:"system_prompt"`                // 系统提示词（发送给AI的系统prompt）
+	InputPrompt       string              `json:"input_prompt"`                 // 发送给AI的输入prompt
+	CoTTrace          string              `json:"cot_trace"`                    // AI思维链（输出）
+	DecisionJSON      string              `json:"decision_json"`                // 决策JSON
+	SchemaVersion     string              `json:"schema_version"`               // AI输出的决策格式版本
+	AccountState      AccountSnapshot     `json:"account_state"`                // 账户状态快照
+	Positions         []PositionSnapshot  `json:"positions"`                    // 持仓快照
+	CandidateCoins    []string            `json:"candidate_coins"`              // 候选币种列表
+	Decisions         []DecisionAction    `json:"decisions"`                    // 执行的决策
+	ExecutionLog      []string            `json:"execution_log"`                // 执行日志
+	Success           bool                `json:"success"`                      // 是否成功
+	ErrorMessage      string              `json:"error_message"`                // 错误信息（如果有）
+	ModelIncidents    []ModelIncident     `json:"model_incidents,omitempty"`    // 模型质量问题（幻觉持仓、未知币种等）
+	ConformanceIssues []string            `json:"conformance_issues,omitempty"` // AI输出格式不合规项（JSON修复、后备解析、验证失败）
+	PromptDegradation string              `json:"prompt_degradation,omitempty"` // 上下文超限降级说明（本周期模型看到的是精简数据）
+	Sampling          *mcp.SamplingParams `json:"sampling,omitempty"`           // 本周期实际使用的模型采样参数（用于复现输出）
+	ToolCalls         []string            `json:"tool_calls,omitempty"`         // 模型决策前请求的数据工具调用摘要
+}
+
+// ModelIncident 模型质量问题（决策引用了不存在的持仓、prompt外的币种或超出仓位上限）
+type ModelIncident struct {
+	Symbol string `json:"symbol"`
+	Action string `json:"action"`
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
 }
 
 // AccountSnapshot 账户状态快照
@@ -50,17 +68,61 @@ type PositionSnapshot struct {
 
 // DecisionAction 决策动作
 type DecisionAction struct {
-	Action    string    `json:"action"`    // open_long, open_short, close_long, close_short, update_stop_loss, update_take_profit, partial_close
-	Symbol    string    `json:"symbol"`    // 币种
-	Quantity  float64   `json:"quantity"`  // 数量（部分平仓时使用）
-	Leverage  int       `json:"leverage"`  // 杠杆（开仓时）
-	Price     float64   `json:"price"`     // 执行价格
-	OrderID   int64     `json:"order_id"`  // 订单ID
-	Timestamp time.Time `json:"timestamp"` // 执行时间
-	Success   bool      `json:"success"`   // 是否成功
-	Error     string    `json:"error"`     // 错误信息
+	Action    string    `json:"action"`               // open_long, open_short, close_long, close_short, update_stop_loss, update_take_profit, partial_close
+	Symbol    string    `json:"symbol"`               // 币种
+	Quantity  float64   `json:"quantity"`             // 数量（部分平仓时使用）
+	Leverage  int       `json:"leverage"`             // 杠杆（开仓时）
+	Price     float64   `json:"price"`                // 执行价格
+	FillPrice float64   `json:"fill_price,omitempty"` // 实际成交均价
+	OrderID   int64     `json:"order_id"`             // 订单ID
+	Timestamp time.Time `json:"timestamp"`            // 执行时间
+	Success   bool      `json:"success"`              // 是否成功
+	Status    string    `json:"status,omitempty"`     // executed / rejected / failed / skipped
+	Error     string    `json:"error"`                // 错误信息
+
+	// AI理由及提取的结构化标签（按信号/周期/价位检索和统计决策）
+	Confidence int      `json:"confidence,omitempty"` // AI信心度（用于校准统计）
+	Reasoning  string   `json:"reasoning,omitempty"`
+	Signals    []string `json:"signals,omitempty"`    // 引用的信号类型
+	Timeframes []string `json:"timeframes,omitempty"` // 引用的周期
+	Levels     []string `json:"levels,omitempty"`     // 引用的价位/区域（support:95000）
+	RiskNotes  []string `json:"risk_notes,omitempty"` // 风险提示
+
+	// 下单前的前20档盘口快照（分析入场质量与盘口厚度的关系）
+	Depth *DepthSnapshot `json:"depth,omitempty"`
+
+	// 对应的外部交易想法ID（AI评估交易想法时给出）
+	IdeaID string `json:"idea_id,omitempty"`
+
+	// 本决策产生的全部订单（Maker挂单+市价补齐时有多笔）
+	Orders []OrderRef `json:"orders,omitempty"`
+
+	// 成交时的交易图表文件名（位于日志目录 charts/ 下，K线+支撑阻力+入场/止损/止盈）
+	Chart string `json:"chart,omitempty"`
+
+	// 平仓已实现盈亏（USDT，按持仓均价估算，未扣手续费）及按平仓日汇率换算的报告货币金额
+	RealizedPnL float64          `json:"realized_pnl,omitempty"`
+	Reporting   *ReportingAmount `json:"reporting,omitempty"`
+}
+
+// OrderRef 决策产生的订单（客户端订单ID、交易所订单ID及成交结果）
+type OrderRef struct {
+	ClientOrderID   string  `json:"client_order_id,omitempty"` // 部分交易所/Maker挂单不返回
+	ExchangeOrderID int64   `json:"exchange_order_id"`
+	Type            string  `json:"type"`   // market / maker
+	Status          string  `json:"status"` // FILLED / PARTIALLY_FILLED / CANCELED ...
+	FilledQty       float64 `json:"filled_qty"`
+	AvgPrice        float64 `json:"avg_price"`
 }
 
+// 单条决策的处理状态（同一批次中某条失败不影响其余决策）
+const (
+	DecisionStatusExecuted = "executed" // 执行成功
+	DecisionStatusRejected = "rejected" // 未通过验证，未执行
+	DecisionStatusFailed   = "failed"   // 执行失败
+	DecisionStatusSkipped  = "skipped"  // 未执行（前置操作失败、等待人工审批等）
+)
+
 // DecisionLogger 决策日志记录器
 type DecisionLogger struct {
 	logDir      string
@@ -99,6 +161,7 @@ func (l *DecisionLogger) LogDecision(record *DecisionRecord) error {
 	filename := fmt.Sprintf("decision_%s_cycle%d.json",
 		record.Timestamp.Format("20060102_150405"),
 		record.CycleNumber)
+	record.ID = strings.TrimSuffix(filename, ".json")
 
 	filepath := filepath.Join(l.logDir, filename)
 
@@ -117,6 +180,11 @@ func (l *DecisionLogger) LogDecision(record *DecisionRecord) error {
 	return nil
 }
 
+// ChartDir 交易图表目录
+func (l *DecisionLogger) ChartDir() string {
+	return filepath.Join(l.logDir, "charts")
+}
+
 // GetLatestRecords 获取最近N条记录（按时间正序：从旧到新）
 func (l *DecisionLogger) GetLatestRecords(n int) ([]*DecisionRecord, error) {
 	files, err := ioutil.ReadDir(l.logDir)
@@ -156,6 +224,23 @@ func (l *DecisionLogger) GetLatestRecords(n int) ([]*DecisionRecord, error) {
 	return records, nil
 }
 
+// GetRecord 按记录ID获取单条决策记录
+func (l *DecisionLogger) GetRecord(id string) (*DecisionRecord, error) {
+	if !strings.HasPrefix(id, "decision_") || strings.ContainsAny(id, `/\.`) {
+		return nil, fmt.Errorf("无效的决策记录ID: %s", id)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(l.logDir, id+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("读取决策记录失败: %w", err)
+	}
+	var record DecisionRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("解析决策记录失败: %w", err)
+	}
+	record.ID = id
+	return &record, nil
+}
+
 // GetRecordByDate 获取指定日期的所有记录
 func (l *DecisionLogger) GetRecordByDate(date time.Time) ([]*DecisionRecord, error) {
 	dateStr := date.Format("20060102")
@@ -184,6 +269,57 @@ func (l *DecisionLogger) GetRecordByDate(date time.Time) ([]*DecisionRecord, err
 	return records, nil
 }
 
+// GetRecordsBetween 获取时间区间内的所有记录（按时间正序）
+// 通过文件名中的时间戳过滤，避免读取区间外的文件
+func (l *DecisionLogger) GetRecordsBetween(start, end time.Time) ([]*DecisionRecord, error) {
+	files, err := ioutil.ReadDir(l.logDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取日志目录失败: %w", err)
+	}
+
+	var records []*DecisionRecord
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		// 文件名格式：decision_YYYYMMDD_HHMMSS_cycleN.json
+		name := file.Name()
+		if len(name) < len("decision_20060102_150405") {
+			continue
+		}
+		fileTime, err := time.ParseInLocation("20060102_150405", name[len("decision_"):len("decision_20060102_150405")], time.Local)
+		if err != nil {
+			continue
+		}
+		// 文件名精度为秒，两端各放宽1秒
+		if fileTime.Before(start.Add(-time.Second)) || fileTime.After(end.Add(time.Second)) {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(l.logDir, name))
+		if err != nil {
+			continue
+		}
+
+		var record DecisionRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			continue
+		}
+		if record.Timestamp.Before(start) || record.Timestamp.After(end) {
+			continue
+		}
+
+		records = append(records, &record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+
+	return records, nil
+}
+
 // CleanOldRecords 清理N天前的旧记录
 func (l *DecisionLogger) CleanOldRecords(days int) error {
 	cutoffTime := time.Now().AddDate(0, 0, -days)
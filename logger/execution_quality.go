@@ -0,0 +1,277 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const maxExecutionRecords = 2000 // 保留的最近成交记录数
+
+// ExecutionRecord 单次成交的执行质量记录
+type ExecutionRecord struct {
+	Time          time.Time `json:"time"`
+	Symbol        string    `json:"symbol"`
+	Action        string    `json:"action"`
+	Side          string    `json:"side"`                     // buy / sell
+	Quantity      float64   `json:"quantity"`                 // 成交数量
+	NotionalUSD   float64   `json:"notional_usd"`             // 成交名义价值
+	DecisionPrice float64   `json:"decision_price"`           // AI决策时的价格（预期价格）
+	ArrivalPrice  float64   `json:"arrival_price"`            // 下单前的市场价格
+	FillPrice     float64   `json:"fill_price"`               // 实际成交均价
+	FillSource    string    `json:"fill_source"`              // exchange=交易所返回的成交均价, market_price=下单后市价近似
+	SlippageBps   float64   `json:"slippage_bps"`             // 相对决策价格的滑点（基点，正数=不利）
+	ArrivalBps    float64   `json:"arrival_bps"`              // 相对下单前价格的滑点（基点，正数=不利）
+	SpreadBps     float64   `json:"spread_bps,omitempty"`     // 下单前买一卖一价差（基点）
+	BookDepthUSD  float64   `json:"book_depth_usd,omitempty"` // 下单前吃单一侧前20档名义价值
+}
+
+// DepthSnapshot 执行时的盘口快照（每档为 [价格, 数量]）
+type DepthSnapshot struct {
+	Time        time.Time    `json:"time"`
+	Bids        [][2]float64 `json:"bids"`
+	Asks        [][2]float64 `json:"asks"`
+	SpreadBps   float64      `json:"spread_bps"`
+	BidDepthUSD float64      `json:"bid_depth_usd"`
+	AskDepthUSD float64      `json:"ask_depth_usd"`
+}
+
+// SideDepthUSD 吃单一侧的盘口深度（买入吃卖盘，卖出吃买盘）
+func (d *DepthSnapshot) SideDepthUSD(side string) float64 {
+	if side == "sell" {
+		return d.BidDepthUSD
+	}
+	return d.AskDepthUSD
+}
+
+// SlippageStats 滑点统计
+type SlippageStats struct {
+	Count         int     `json:"count"`
+	AvgBps        float64 `json:"avg_bps"`
+	MedianBps     float64 `json:"median_bps"`
+	P90Bps        float64 `json:"p90_bps"`
+	WorstBps      float64 `json:"worst_bps"`
+	AvgArrivalBps float64 `json:"avg_arrival_bps"`
+	ExchangeFills int     `json:"exchange_fills"` // 使用交易所成交均价的记录数（其余为市价近似）
+}
+
+// ExecutionQualityReport 执行质量报告（按币种/仓位大小/时段汇总）
+type ExecutionQualityReport struct {
+	Overall      SlippageStats            `json:"overall"`
+	BySymbol     map[string]SlippageStats `json:"by_symbol"`
+	BySizeBucket map[string]SlippageStats `json:"by_size_bucket"`
+	ByHourUTC    map[string]SlippageStats `json:"by_hour_utc"`
+	ByBookImpact map[string]SlippageStats `json:"by_book_impact"` // 按成交额占盘口深度比例分桶（无盘口快照的记录不计入）
+	Since        time.Time                `json:"since"`
+	Recent       []ExecutionRecord        `json:"recent"` // 最近20笔（新→旧）
+}
+
+// ExecutionQualityStore 成交执行质量记录（每个trader独立，持久化到决策日志目录下）
+type ExecutionQualityStore struct {
+	mu       sync.RWMutex
+	filePath string
+	records  []ExecutionRecord
+}
+
+// NewExecutionQualityStore 创建执行质量存储（放在子目录中，避免被当作决策记录读取）
+func NewExecutionQualityStore(logDir string) *ExecutionQualityStore {
+	dir := filepath.Join(logDir, "execution")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		fmt.Printf("⚠ 创建执行质量目录失败: %v\n", err)
+	}
+
+	store := &ExecutionQualityStore{
+		filePath: filepath.Join(dir, "executions.json"),
+	}
+	if data, err := ioutil.ReadFile(store.filePath); err == nil {
+		if err := json.Unmarshal(data, &store.records); err != nil {
+			fmt.Printf("⚠ 解析执行质量记录失败: %v\n", err)
+			store.records = nil
+		}
+	}
+	return store
+}
+
+// Record 记录一次成交（自动计算滑点）
+func (s *ExecutionQualityStore) Record(rec ExecutionRecord) error {
+	if rec.FillPrice <= 0 {
+		return nil
+	}
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	if rec.DecisionPrice <= 0 {
+		rec.DecisionPrice = rec.ArrivalPrice
+	}
+	rec.SlippageBps = slippageBps(rec.Side, rec.DecisionPrice, rec.FillPrice)
+	rec.ArrivalBps = slippageBps(rec.Side, rec.ArrivalPrice, rec.FillPrice)
+	if rec.NotionalUSD == 0 {
+		rec.NotionalUSD = rec.Quantity * rec.FillPrice
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = append(s.records, rec)
+	if len(s.records) > maxExecutionRecords {
+		s.records = s.records[len(s.records)-maxExecutionRecords:]
+	}
+
+	data, err := json.MarshalIndent(s.records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化执行质量记录失败: %w", err)
+	}
+	if err := ioutil.WriteFile(s.filePath, data, 0600); err != nil {
+		return fmt.Errorf("写入执行质量记录失败: %w", err)
+	}
+	return nil
+}
+
+// Report 生成执行质量报告（since 为零值时统计全部记录）
+func (s *ExecutionQualityStore) Report(since time.Time) ExecutionQualityReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var records []ExecutionRecord
+	for _, rec := range s.records {
+		if rec.Time.Before(since) {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return buildExecutionReport(records, since)
+}
+
+// buildExecutionReport 汇总滑点统计
+func buildExecutionReport(records []ExecutionRecord, since time.Time) ExecutionQualityReport {
+	report := ExecutionQualityReport{
+		Overall:      computeSlippageStats(records),
+		BySymbol:     make(map[string]SlippageStats),
+		BySizeBucket: make(map[string]SlippageStats),
+		ByHourUTC:    make(map[string]SlippageStats),
+		ByBookImpact: make(map[string]SlippageStats),
+		Since:        since,
+		Recent:       []ExecutionRecord{},
+	}
+
+	bySymbol := make(map[string][]ExecutionRecord)
+	bySize := make(map[string][]ExecutionRecord)
+	byHour := make(map[string][]ExecutionRecord)
+	byImpact := make(map[string][]ExecutionRecord)
+	for _, rec := range records {
+		bySymbol[rec.Symbol] = append(bySymbol[rec.Symbol], rec)
+		bucket := sizeBucket(rec.NotionalUSD)
+		bySize[bucket] = append(bySize[bucket], rec)
+		hour := fmt.Sprintf("%02d", rec.Time.UTC().Hour())
+		byHour[hour] = append(byHour[hour], rec)
+		if rec.BookDepthUSD > 0 {
+			impact := bookImpactBucket(rec.NotionalUSD / rec.BookDepthUSD)
+			byImpact[impact] = append(byImpact[impact], rec)
+		}
+	}
+	for k, v := range bySymbol {
+		report.BySymbol[k] = computeSlippageStats(v)
+	}
+	for k, v := range bySize {
+		report.BySizeBucket[k] = computeSlippageStats(v)
+	}
+	for k, v := range byHour {
+		report.ByHourUTC[k] = computeSlippageStats(v)
+	}
+	for k, v := range byImpact {
+		report.ByBookImpact[k] = computeSlippageStats(v)
+	}
+
+	for i := len(records) - 1; i >= 0 && len(report.Recent) < 20; i-- {
+		report.Recent = append(report.Recent, records[i])
+	}
+	return report
+}
+
+// computeSlippageStats 计算一组成交的滑点统计
+func computeSlippageStats(records []ExecutionRecord) SlippageStats {
+	stats := SlippageStats{Count: len(records)}
+	if len(records) == 0 {
+		return stats
+	}
+
+	values := make([]float64, 0, len(records))
+	sum, arrivalSum := 0.0, 0.0
+	stats.WorstBps = math.Inf(-1)
+	for _, rec := range records {
+		values = append(values, rec.SlippageBps)
+		sum += rec.SlippageBps
+		arrivalSum += rec.ArrivalBps
+		stats.WorstBps = math.Max(stats.WorstBps, rec.SlippageBps)
+		if rec.FillSource == "exchange" {
+			stats.ExchangeFills++
+		}
+	}
+	sort.Float64s(values)
+
+	stats.AvgBps = sum / float64(len(records))
+	stats.AvgArrivalBps = arrivalSum / float64(len(records))
+	stats.MedianBps = percentile(values, 0.5)
+	stats.P90Bps = percentile(values, 0.9)
+	return stats
+}
+
+// slippageBps 计算滑点（基点）：买入成交价高于预期、卖出成交价低于预期为正（不利）
+func slippageBps(side string, expected, fill float64) float64 {
+	if expected <= 0 || fill <= 0 {
+		return 0
+	}
+	bps := (fill - expected) / expected * 10000
+	if side == "sell" {
+		bps = -bps
+	}
+	return bps
+}
+
+// sizeBucket 按成交名义价值分桶
+func sizeBucket(notional float64) string {
+	switch {
+	case notional <= 0:
+		return "unknown"
+	case notional < 100:
+		return "<100"
+	case notional < 500:
+		return "100-500"
+	case notional < 2000:
+		return "500-2000"
+	default:
+		return ">=2000"
+	}
+}
+
+// bookImpactBucket 按成交额占吃单一侧盘口深度的比例分桶（比例越高说明盘口越薄）
+func bookImpactBucket(ratio float64) string {
+	switch {
+	case ratio < 0.01:
+		return "<1%"
+	case ratio < 0.05:
+		return "1-5%"
+	case ratio < 0.2:
+		return "5-20%"
+	default:
+		return ">=20%"
+	}
+}
+
+// percentile 已排序数组的分位数（最近秩法）
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
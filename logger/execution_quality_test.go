@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestExecutionQualityStoreReport(t *testing.T) {
+	dir := t.TempDir()
+	store := NewExecutionQualityStore(dir)
+	at := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+
+	records := []ExecutionRecord{
+		// 买入成交价高于决策价 → 不利滑点 +10bps
+		{Time: at, Symbol: "BTCUSDT", Side: "buy", Quantity: 0.01, DecisionPrice: 100000, ArrivalPrice: 100050, FillPrice: 100100, FillSource: "exchange", BookDepthUSD: 200000},
+		// 卖出成交价低于决策价 → 不利滑点 +20bps
+		{Time: at, Symbol: "BTCUSDT", Side: "sell", Quantity: 0.01, DecisionPrice: 100000, ArrivalPrice: 100000, FillPrice: 99800, FillSource: "exchange", BookDepthUSD: 10000},
+		// 卖出成交价高于决策价 → 有利滑点 -50bps，缺少决策价时使用下单前价格
+		{Time: at.Add(time.Hour), Symbol: "SOLUSDT", Side: "sell", Quantity: 1, ArrivalPrice: 200, FillPrice: 201, FillSource: "market_price"},
+	}
+	for _, rec := range records {
+		if err := store.Record(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := store.Report(time.Time{})
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"总笔数", float64(report.Overall.Count), 3},
+		{"BTC平均滑点", report.BySymbol["BTCUSDT"].AvgBps, 15},
+		{"SOL平均滑点", report.BySymbol["SOLUSDT"].AvgBps, -50},
+		{"最差滑点", report.Overall.WorstBps, 20},
+		{"交易所成交均价笔数", float64(report.Overall.ExchangeFills), 2},
+		{"500-2000仓位桶", float64(report.BySizeBucket["500-2000"].Count), 2},
+		{"100-500仓位桶", float64(report.BySizeBucket["100-500"].Count), 1},
+		{"08点时段", float64(report.ByHourUTC["08"].Count), 2},
+		{"盘口占比<1%", float64(report.ByBookImpact["<1%"].Count), 1},
+		{"盘口占比5-20%滑点", report.ByBookImpact["5-20%"].AvgBps, 20},
+		{"无盘口快照不计入", float64(len(report.ByBookImpact)), 2},
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.got-tt.want) > 1e-6 {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	// 重新加载后记录保留
+	reloaded := NewExecutionQualityStore(dir)
+	if got := reloaded.Report(time.Time{}).Overall.Count; got != 3 {
+		t.Errorf("重新加载后记录数 = %d, want 3", got)
+	}
+}
@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// 汇率按日缓存（UTC日期），USDT按1:1视为USD；数据来源为欧洲央行每日参考汇率（周末/节假日使用前一个工作日的汇率）
+const fxRateURL = "https://api.frankfurter.app/%s?from=USD&to=%s"
+
+// ReportingAmount 以报告货币表示的金额（汇率为平仓时所在日期的 1 USD → 报告货币）
+type ReportingAmount struct {
+	Currency string          `json:"currency"`
+	Rate     decimal.Decimal `json:"rate"`
+	Amount   decimal.Decimal `json:"amount"`
+	RateDate string          `json:"rate_date"` // 汇率日期 YYYY-MM-DD（UTC）
+}
+
+// FXRateFetcher 获取指定日期 1 USD 兑换目标货币的汇率
+type FXRateFetcher func(date, currency string) (decimal.Decimal, error)
+
+// FXRateCache 每日汇率缓存（持久化到决策日志目录下，同一天同一货币只请求一次）
+type FXRateCache struct {
+	mu       sync.Mutex
+	filePath string
+	rates    map[string]decimal.Decimal // "2006-01-02:EUR" -> 汇率
+	failures map[string]string          // 获取失败的 key -> 失败当天日期（当天不再重试，避免每个周期/批次重复请求）
+	fetch    FXRateFetcher
+}
+
+// NewFXRateCache 创建汇率缓存（放在 memory 子目录中，避免被当作决策记录读取）
+func NewFXRateCache(logDir string) *FXRateCache {
+	dir := filepath.Join(logDir, "memory")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		fmt.Printf("⚠ 创建记忆目录失败: %v\n", err)
+	}
+
+	cache := &FXRateCache{
+		filePath: filepath.Join(dir, "fx_rates.json"),
+		rates:    make(map[string]decimal.Decimal),
+		failures: make(map[string]string),
+		fetch:    fetchFXRate,
+	}
+	if data, err := os.ReadFile(cache.filePath); err == nil {
+		if err := json.Unmarshal(data, &cache.rates); err != nil {
+			fmt.Printf("⚠ 解析汇率缓存失败: %v\n", err)
+			cache.rates = make(map[string]decimal.Decimal)
+		}
+	}
+	return cache
+}
+
+// NormalizeCurrency 报告货币代码转大写（USDT按USD处理）
+func NormalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "USDT" {
+		return "USD"
+	}
+	return currency
+}
+
+// Rate 指定时间所在日期（UTC）1 USD 兑换目标货币的汇率
+func (c *FXRateCache) Rate(currency string, at time.Time) (decimal.Decimal, string, error) {
+	currency = NormalizeCurrency(currency)
+	date := at.UTC().Format(SettlementDateLayout)
+	if currency == "USD" {
+		return decimal.NewFromInt(1), date, nil
+	}
+	if len(currency) != 3 {
+		return decimal.Zero, date, fmt.Errorf("无效的货币代码: %s", currency)
+	}
+
+	key := date + ":" + currency
+	today := time.Now().UTC().Format(SettlementDateLayout)
+	c.mu.Lock()
+	if rate, ok := c.rates[key]; ok {
+		c.mu.Unlock()
+		return rate, date, nil
+	}
+	if c.failures[key] == today {
+		c.mu.Unlock()
+		return decimal.Zero, date, fmt.Errorf("%s %s 汇率今日获取失败，暂不重试", date, currency)
+	}
+	c.mu.Unlock()
+
+	// 网络请求不持锁，避免阻塞其他换算
+	rate, err := c.fetch(date, currency)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.failures[key] = today
+		return decimal.Zero, date, fmt.Errorf("获取 %s %s 汇率失败: %w", date, currency, err)
+	}
+	delete(c.failures, key)
+	c.rates[key] = rate
+	if err := c.saveLocked(); err != nil {
+		fmt.Printf("⚠ 保存汇率缓存失败: %v\n", err)
+	}
+	return rate, date, nil
+}
+
+// Convert 将USDT金额按 at 所在日期的汇率换算为报告货币
+func (c *FXRateCache) Convert(amount decimal.Decimal, currency string, at time.Time) (*ReportingAmount, error) {
+	rate, date, err := c.Rate(currency, at)
+	if err != nil {
+		return nil, err
+	}
+	return &ReportingAmount{
+		Currency: NormalizeCurrency(currency),
+		Rate:     rate,
+		Amount:   roundMoney(amount.Mul(rate)),
+		RateDate: date,
+	}, nil
+}
+
+func (c *FXRateCache) saveLocked() error {
+	data, err := json.MarshalIndent(c.rates, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化汇率缓存失败: %w", err)
+	}
+	if err := os.WriteFile(c.filePath, data, 0600); err != nil {
+		return fmt.Errorf("写入汇率缓存失败: %w", err)
+	}
+	return nil
+}
+
+// fetchFXRate 从汇率接口获取指定日期的汇率
+func fetchFXRate(date, currency string) (decimal.Decimal, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(fmt.Sprintf(fxRateURL, date, currency))
+	if err != nil {
+		return decimal.Zero, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return decimal.Zero, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	var body struct {
+		Rates map[string]decimal.Decimal `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return decimal.Zero, fmt.Errorf("解析汇率失败: %w", err)
+	}
+	rate, ok := body.Rates[currency]
+	if !ok || !rate.IsPositive() {
+		return decimal.Zero, fmt.Errorf("不支持的货币 %s", currency)
+	}
+	return rate, nil
+}
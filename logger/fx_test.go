@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func newTestFXRateCache(logDir string, fetch FXRateFetcher) *FXRateCache {
+	cache := NewFXRateCache(logDir)
+	cache.fetch = fetch
+	return cache
+}
+
+func TestFXRateCacheConvert(t *testing.T) {
+	dir := t.TempDir()
+	calls := 0
+	cache := newTestFXRateCache(dir, func(date, currency string) (decimal.Decimal, error) {
+		calls++
+		if currency != "EUR" {
+			t.Errorf("fetch currency = %s, want EUR", currency)
+		}
+		if date == "2025-03-01" {
+			return decimal.RequireFromString("0.9"), nil
+		}
+		return decimal.RequireFromString("0.8"), nil
+	})
+
+	at := time.Date(2025, 3, 1, 15, 0, 0, 0, time.UTC)
+	got, err := cache.Convert(decimal.RequireFromString("12.345"), "eur", at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Currency != "EUR" || got.RateDate != "2025-03-01" || !got.Amount.Equal(roundMoney(decimal.RequireFromString("11.1105"))) {
+		t.Errorf("Convert = %+v, want EUR 11.1105 @ 2025-03-01", got)
+	}
+
+	// 同一天再次换算命中缓存，不再请求
+	if _, err := cache.Convert(decimal.NewFromInt(1), "EUR", at.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("fetch calls = %d, want 1 (cache hit)", calls)
+	}
+
+	// 缓存持久化后新实例直接读取
+	reloaded := newTestFXRateCache(dir, func(string, string) (decimal.Decimal, error) {
+		t.Error("reloaded cache should not fetch")
+		return decimal.Zero, nil
+	})
+	if rate, _, err := reloaded.Rate("EUR", at); err != nil || !rate.Equal(decimal.RequireFromString("0.9")) {
+		t.Errorf("reloaded rate = %v, %v, want 0.9", rate, err)
+	}
+
+	// 不同日期缓存未命中，重新请求
+	if got, err := cache.Convert(decimal.NewFromInt(10), "EUR", at.AddDate(0, 0, 1)); err != nil || !got.Amount.Equal(decimal.NewFromInt(8)) || got.RateDate != "2025-03-02" {
+		t.Errorf("next day Convert = %+v, %v, want EUR 8 @ 2025-03-02", got, err)
+	}
+	if calls != 2 {
+		t.Errorf("fetch calls = %d, want 2 (cache miss)", calls)
+	}
+}
+
+func TestFXRateCacheUSDAndFailures(t *testing.T) {
+	calls := 0
+	cache := newTestFXRateCache(t.TempDir(), func(date, currency string) (decimal.Decimal, error) {
+		calls++
+		return decimal.Zero, errors.New("unavailable")
+	})
+	at := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	if got, err := cache.Convert(decimal.NewFromInt(5), "USDT", at); err != nil || got.Currency != "USD" || !got.Amount.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("USDT Convert = %+v, %v, want USD 5 without fetch", got, err)
+	}
+	if _, _, err := cache.Rate("EURO", at); err == nil {
+		t.Error("invalid currency code should fail")
+	}
+
+	// 失败当天缓存，不重复请求
+	for i := 0; i < 3; i++ {
+		if _, err := cache.Convert(decimal.NewFromInt(1), "JPY", at); err == nil {
+			t.Fatal("expected fetch failure")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("fetch calls = %d, want 1 (failure cached for the day)", calls)
+	}
+}
+
+func TestWriteTaxLotCSVReporting(t *testing.T) {
+	lot := TaxLot{
+		Symbol: "BTCUSDT", Side: "long", Quantity: 1,
+		AcquiredAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), DisposedAt: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+		GainLoss: decimal.NewFromInt(10), Term: "short",
+		Reporting: &ReportingAmount{Currency: "EUR", Rate: decimal.RequireFromString("0.9"), Amount: decimal.NewFromInt(9), RateDate: "2025-03-02"},
+	}
+	var buf bytes.Buffer
+	if err := WriteTaxLotCSV(&buf, []TaxLot{lot, {Symbol: "ETHUSDT"}}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := rows[0]
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[name] = i
+	}
+	for name, want := range map[string]string{"reporting_currency": "EUR", "fx_rate": "0.9", "gain_loss_reporting": "9"} {
+		i, ok := columns[name]
+		if !ok {
+			t.Fatalf("missing column %s in %v", name, header)
+		}
+		if rows[1][i] != want {
+			t.Errorf("%s = %q, want %q", name, rows[1][i], want)
+		}
+		if rows[2][i] != "" {
+			t.Errorf("%s without reporting = %q, want empty", name, rows[2][i])
+		}
+	}
+}
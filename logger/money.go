@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/shopspring/decimal"
+)
+
+// 记账层（结算快照、税务批次）使用十进制金额，避免float64累加产生的舍入漂移；分析统计仍使用float64
+
+const (
+	defaultAccountingDecimals = 8
+	maxAccountingDecimals     = 12
+)
+
+// accountingDecimals 记账金额保留的小数位数（系统配置 accounting_decimals）
+var accountingDecimals int32 = defaultAccountingDecimals
+
+// SetAccountingDecimals 设置记账金额保留的小数位数（0~12，启动时根据系统配置设置）
+func SetAccountingDecimals(n int) error {
+	if n < 0 || n > maxAccountingDecimals {
+		return fmt.Errorf("记账精度必须在 0~%d 位之间: %d", maxAccountingDecimals, n)
+	}
+	atomic.StoreInt32(&accountingDecimals, int32(n))
+	return nil
+}
+
+// roundMoney 按记账精度四舍五入
+func roundMoney(d decimal.Decimal) decimal.Decimal {
+	return d.Round(atomic.LoadInt32(&accountingDecimals))
+}
+
+// Money 将浮点金额转为记账金额
+func Money(v float64) decimal.Decimal {
+	return roundMoney(decimal.NewFromFloat(v))
+}
+
+// MoneyMul 数量 × 价格（× 费率…）按十进制相乘后舍入，用于名义价值和手续费
+func MoneyMul(values ...float64) decimal.Decimal {
+	result := decimal.NewFromInt(1)
+	for _, v := range values {
+		result = result.Mul(decimal.NewFromFloat(v))
+	}
+	return roundMoney(result)
+}
+
+// ParseMoney 解析交易所返回的金额字符串（保留交易所给出的全部精度）
+func ParseMoney(s string) (decimal.Decimal, error) {
+	return decimal.NewFromString(s)
+}
@@ -0,0 +1,366 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 通知事件类型
+const (
+	EventTrade    = "trade"    // 开仓/平仓成交
+	EventRisk     = "risk"     // 风控拒绝、止损缺失、回撤平仓
+	EventApproval = "approval" // 决策等待人工审批
+	EventError    = "error"    // 周期/执行错误
+	EventSystem   = "system"   // 启动、停止等
+)
+
+// 通知严重程度
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical" // 不受频率限制和汇总模式影响，始终立即推送
+)
+
+// 通知渠道
+const (
+	ChannelTelegram = "telegram"
+	ChannelConsole  = "console"
+	ChannelWebhook  = "webhook"
+)
+
+var severityRank = map[string]int{SeverityInfo: 0, SeverityWarning: 1, SeverityCritical: 2}
+
+// NotificationPrefs 通知偏好（每个trader独立）
+type NotificationPrefs struct {
+	Events                []string `json:"events"`                  // 订阅的事件类型（空=全部）
+	Channels              []string `json:"channels"`                // 推送渠道 telegram / console / webhook
+	MinSeverity           string   `json:"min_severity"`            // 最低严重程度 info / warning / critical
+	MaxPerHour            int      `json:"max_per_hour"`            // 每小时最多即时推送条数（0=不限制），超出部分并入汇总
+	DigestMode            bool     `json:"digest_mode"`             // 汇总模式：非critical通知按周期合并成一条推送
+	DigestIntervalMinutes int      `json:"digest_interval_minutes"` // 汇总周期（分钟）
+}
+
+// DefaultNotificationPrefs 默认通知偏好
+func DefaultNotificationPrefs() NotificationPrefs {
+	return NotificationPrefs{
+		Channels:              []string{ChannelTelegram},
+		MinSeverity:           SeverityWarning,
+		MaxPerHour:            20,
+		DigestIntervalMinutes: 60,
+	}
+}
+
+// Normalize 校验并补全默认值
+func (p *NotificationPrefs) Normalize() error {
+	if p.MinSeverity == "" {
+		p.MinSeverity = SeverityWarning
+	}
+	if _, ok := severityRank[p.MinSeverity]; !ok {
+		return fmt.Errorf("无效的最低严重程度: %s", p.MinSeverity)
+	}
+	for _, ch := range p.Channels {
+		if ch != ChannelTelegram && ch != ChannelConsole && ch != ChannelWebhook {
+			return fmt.Errorf("无效的通知渠道: %s", ch)
+		}
+	}
+	if p.MaxPerHour < 0 {
+		return fmt.Errorf("每小时推送上限不能为负数")
+	}
+	if p.DigestIntervalMinutes <= 0 {
+		p.DigestIntervalMinutes = 60
+	}
+	return nil
+}
+
+// Notification 一条通知
+type Notification struct {
+	Time     time.Time `json:"time"`
+	Event    string    `json:"event"`
+	Severity string    `json:"severity"`
+	Message  string    `json:"message"`
+}
+
+// NotificationChannel 通知推送渠道
+type NotificationChannel interface {
+	Send(text string) error
+}
+
+// ImageChannel 支持附带图片的通知渠道（不支持的渠道只推送文字）
+type ImageChannel interface {
+	SendImage(caption string, png []byte) error
+}
+
+// consoleChannel 控制台输出
+type consoleChannel struct{}
+
+func (consoleChannel) Send(text string) error {
+	fmt.Printf("🔔 %s\n", text)
+	return nil
+}
+
+// telegramChannel Telegram推送（异步）
+type telegramChannel struct {
+	sender *TelegramSender
+}
+
+func (c telegramChannel) Send(text string) error {
+	c.sender.SendAsync(escapeMarkdown(text))
+	return nil
+}
+
+func (c telegramChannel) SendImage(caption string, png []byte) error {
+	c.sender.SendPhotoAsync(escapeMarkdown(caption), png)
+	return nil
+}
+
+var (
+	channelsMu sync.RWMutex
+	channels   = map[string]NotificationChannel{ChannelConsole: consoleChannel{}}
+)
+
+// SetupTelegramNotifications 注册Telegram通知渠道
+func SetupTelegramNotifications(botToken string, chatID int64) error {
+	sender, err := NewTelegramSender(botToken, chatID)
+	if err != nil {
+		return err
+	}
+	RegisterNotificationChannel(ChannelTelegram, telegramChannel{sender: sender})
+	return nil
+}
+
+// RegisterNotificationChannel 注册通知渠道（同名覆盖）
+func RegisterNotificationChannel(name string, ch NotificationChannel) {
+	channelsMu.Lock()
+	defer channelsMu.Unlock()
+	channels[name] = ch
+}
+
+func getNotificationChannel(name string) (NotificationChannel, bool) {
+	channelsMu.RLock()
+	defer channelsMu.RUnlock()
+	ch, ok := channels[name]
+	return ch, ok
+}
+
+// NotifierStats 通知统计
+type NotifierStats struct {
+	Sent       int       `json:"sent"`      // 即时推送
+	Filtered   int       `json:"filtered"`  // 被事件/严重程度过滤
+	Throttled  int       `json:"throttled"` // 超出每小时上限，并入汇总
+	Digested   int       `json:"digested"`  // 进入汇总的通知数
+	Digests    int       `json:"digests"`   // 已推送的汇总条数
+	Pending    int       `json:"pending"`   // 当前待汇总的通知数
+	LastSentAt time.Time `json:"last_sent_at"`
+}
+
+// Notifier 按偏好过滤、限频、汇总后推送通知
+type Notifier struct {
+	mu         sync.Mutex
+	name       string // 通知前缀（trader名称）
+	prefs      NotificationPrefs
+	sentTimes  []time.Time // 最近一小时的即时推送时间
+	digest     []Notification
+	lastDigest time.Time
+	stats      NotifierStats
+	now        func() time.Time
+}
+
+// NewNotifier 创建通知器
+func NewNotifier(name string, prefs NotificationPrefs) *Notifier {
+	if err := prefs.Normalize(); err != nil {
+		prefs = DefaultNotificationPrefs()
+	}
+	return &Notifier{name: name, prefs: prefs, now: time.Now, lastDigest: time.Now()}
+}
+
+// SetPrefs 更新通知偏好
+func (n *Notifier) SetPrefs(prefs NotificationPrefs) error {
+	if err := prefs.Normalize(); err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.prefs = prefs
+	return nil
+}
+
+// Prefs 当前通知偏好
+func (n *Notifier) Prefs() NotificationPrefs {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.prefs
+}
+
+// Stats 通知统计
+func (n *Notifier) Stats() NotifierStats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	s := n.stats
+	s.Pending = len(n.digest)
+	return s
+}
+
+// Notify 推送通知：先按事件和严重程度过滤；critical 立即推送；其余在汇总模式或超出每小时上限时并入汇总
+func (n *Notifier) Notify(event, severity, format string, args ...interface{}) {
+	n.notify(nil, event, severity, format, args...)
+}
+
+// NotifyWithImage 推送附带图片（如交易图表PNG）的通知；并入汇总时只保留文字
+func (n *Notifier) NotifyWithImage(image []byte, event, severity, format string, args ...interface{}) {
+	n.notify(image, event, severity, format, args...)
+}
+
+func (n *Notifier) notify(image []byte, event, severity, format string, args ...interface{}) {
+	note := Notification{Time: n.now(), Event: event, Severity: severity, Message: fmt.Sprintf(format, args...)}
+
+	n.mu.Lock()
+	var outgoing []string
+	var immediate string
+	switch {
+	case !n.wantsLocked(note):
+		n.stats.Filtered++
+	case severity == SeverityCritical:
+		immediate = n.formatLocked(note)
+		n.recordSentLocked(note.Time)
+	case n.prefs.DigestMode:
+		n.digest = append(n.digest, note)
+		n.stats.Digested++
+	case n.prefs.MaxPerHour > 0 && n.sentLastHourLocked(note.Time) >= n.prefs.MaxPerHour:
+		n.digest = append(n.digest, note)
+		n.stats.Throttled++
+		n.stats.Digested++
+	default:
+		immediate = n.formatLocked(note)
+		n.recordSentLocked(note.Time)
+	}
+	if text, ok := n.digestDueLocked(note.Time); ok {
+		outgoing = append(outgoing, text)
+	}
+	channelNames := append([]string(nil), n.prefs.Channels...)
+	n.mu.Unlock()
+
+	if immediate != "" {
+		deliverWithImage(channelNames, immediate, image)
+	}
+	deliver(channelNames, outgoing)
+}
+
+// FlushDue 汇总周期已到时推送汇总（由交易周期定期调用）
+func (n *Notifier) FlushDue() {
+	n.mu.Lock()
+	text, ok := n.digestDueLocked(n.now())
+	channelNames := append([]string(nil), n.prefs.Channels...)
+	n.mu.Unlock()
+	if ok {
+		deliver(channelNames, []string{text})
+	}
+}
+
+// wantsLocked 是否订阅该通知（critical 不受事件过滤影响）
+func (n *Notifier) wantsLocked(note Notification) bool {
+	if severityRank[note.Severity] < severityRank[n.prefs.MinSeverity] {
+		return false
+	}
+	if note.Severity == SeverityCritical || len(n.prefs.Events) == 0 {
+		return true
+	}
+	for _, e := range n.prefs.Events {
+		if e == note.Event {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Notifier) sentLastHourLocked(now time.Time) int {
+	cutoff := now.Add(-time.Hour)
+	kept := n.sentTimes[:0]
+	for _, t := range n.sentTimes {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	n.sentTimes = kept
+	return len(kept)
+}
+
+func (n *Notifier) recordSentLocked(now time.Time) {
+	n.sentTimes = append(n.sentTimes, now)
+	n.stats.Sent++
+	n.stats.LastSentAt = now
+}
+
+// digestDueLocked 汇总周期已到且有待汇总通知时生成汇总文本并清空
+func (n *Notifier) digestDueLocked(now time.Time) (string, bool) {
+	if len(n.digest) == 0 {
+		n.lastDigest = now
+		return "", false
+	}
+	if now.Sub(n.lastDigest) < time.Duration(n.prefs.DigestIntervalMinutes)*time.Minute {
+		return "", false
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📬 [%s] 通知汇总（%d 条）\n", n.name, len(n.digest)))
+	for _, note := range n.digest {
+		sb.WriteString(fmt.Sprintf("%s %s %s\n", note.Time.Format("15:04"), severityEmoji(note.Severity), note.Message))
+	}
+	n.digest = nil
+	n.lastDigest = now
+	n.stats.Digests++
+	return strings.TrimRight(sb.String(), "\n"), true
+}
+
+func (n *Notifier) formatLocked(note Notification) string {
+	return fmt.Sprintf("%s [%s] %s", severityEmoji(note.Severity), n.name, note.Message)
+}
+
+func severityEmoji(severity string) string {
+	switch severity {
+	case SeverityCritical:
+		return "🚨"
+	case SeverityWarning:
+		return "⚠️"
+	}
+	return "ℹ️"
+}
+
+// deliver 推送到各渠道（未注册的渠道忽略）
+func deliver(channelNames []string, texts []string) {
+	for _, text := range texts {
+		for _, name := range channelNames {
+			ch, ok := getNotificationChannel(name)
+			if !ok {
+				continue
+			}
+			if err := ch.Send(text); err != nil {
+				fmt.Printf("⚠ 通知推送失败 (%s): %v\n", name, err)
+			}
+		}
+	}
+}
+
+// deliverWithImage 推送单条通知，支持图片的渠道附带图片（image 为空时等同 deliver）
+func deliverWithImage(channelNames []string, text string, image []byte) {
+	if len(image) == 0 {
+		deliver(channelNames, []string{text})
+		return
+	}
+	for _, name := range channelNames {
+		ch, ok := getNotificationChannel(name)
+		if !ok {
+			continue
+		}
+		var err error
+		if imgCh, ok := ch.(ImageChannel); ok {
+			err = imgCh.SendImage(text, image)
+		} else {
+			err = ch.Send(text)
+		}
+		if err != nil {
+			fmt.Printf("⚠ 通知推送失败 (%s): %v\n", name, err)
+		}
+	}
+}
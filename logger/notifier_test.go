@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingChannel struct {
+	texts []string
+}
+
+func (c *recordingChannel) Send(text string) error {
+	c.texts = append(c.texts, text)
+	return nil
+}
+
+func newTestNotifier(t *testing.T, prefs NotificationPrefs) (*Notifier, *recordingChannel, *time.Time) {
+	ch := &recordingChannel{}
+	name := "test_" + t.Name()
+	RegisterNotificationChannel(name, ch)
+	prefs.Channels = []string{name}
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	n := &Notifier{name: "trader", prefs: prefs, now: func() time.Time { return now }, lastDigest: now}
+	return n, ch, &now
+}
+
+func TestNotifierFilterAndThrottle(t *testing.T) {
+	n, ch, now := newTestNotifier(t, NotificationPrefs{
+		Events:                []string{EventTrade},
+		MinSeverity:           SeverityInfo,
+		MaxPerHour:            2,
+		DigestIntervalMinutes: 60,
+	})
+
+	tests := []struct {
+		name      string
+		event     string
+		severity  string
+		wantTexts int
+	}{
+		{"订阅事件即时推送", EventTrade, SeverityInfo, 1},
+		{"未订阅事件被过滤", EventError, SeverityWarning, 1},
+		{"达到每小时上限前推送", EventTrade, SeverityInfo, 2},
+		{"超出上限并入汇总", EventTrade, SeverityInfo, 2},
+		{"critical不受过滤和上限影响", EventError, SeverityCritical, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n.Notify(tt.event, tt.severity, "msg")
+			if len(ch.texts) != tt.wantTexts {
+				t.Errorf("sent = %d, want %d", len(ch.texts), tt.wantTexts)
+			}
+		})
+	}
+
+	stats := n.Stats()
+	if stats.Filtered != 1 || stats.Throttled != 1 || stats.Pending != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	*now = now.Add(61 * time.Minute)
+	n.FlushDue()
+	if len(ch.texts) != 4 || !strings.Contains(ch.texts[3], "通知汇总（1 条）") {
+		t.Errorf("digest not flushed: %v", ch.texts)
+	}
+}
+
+func TestNotifierDigestMode(t *testing.T) {
+	n, ch, now := newTestNotifier(t, NotificationPrefs{
+		MinSeverity:           SeverityInfo,
+		DigestMode:            true,
+		DigestIntervalMinutes: 30,
+	})
+
+	n.Notify(EventTrade, SeverityInfo, "open BTC")
+	n.Notify(EventRisk, SeverityWarning, "risk cap")
+	if len(ch.texts) != 0 {
+		t.Fatalf("digest mode sent immediately: %v", ch.texts)
+	}
+
+	*now = now.Add(31 * time.Minute)
+	n.Notify(EventTrade, SeverityInfo, "close BTC")
+	if len(ch.texts) != 1 || !strings.Contains(ch.texts[0], "通知汇总（3 条）") {
+		t.Errorf("texts = %v, want one digest with 3 entries", ch.texts)
+	}
+}
+
+func TestNotificationPrefsNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefs   NotificationPrefs
+		wantErr bool
+	}{
+		{"默认值有效", DefaultNotificationPrefs(), false},
+		{"无效严重程度", NotificationPrefs{MinSeverity: "fatal"}, true},
+		{"无效渠道", NotificationPrefs{Channels: []string{"email"}}, true},
+		{"负数上限", NotificationPrefs{MaxPerHour: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.prefs.Normalize(); (err != nil) != tt.wantErr {
+				t.Errorf("Normalize() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
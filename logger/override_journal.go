@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const maxOverrideEntries = 1000 // 保留的最近人工操作记录数
+
+// OverrideEntry 决策队列操作记录（入队、取消、修改、强制执行等，记录操作人）
+type OverrideEntry struct {
+	Time       time.Time `json:"time"`
+	DecisionID string    `json:"decision_id"`
+	Symbol     string    `json:"symbol"`
+	Action     string    `json:"action"`    // 决策动作 open_long / close_short ...
+	Operation  string    `json:"operation"` // queued / cancel / edit / execute / superseded
+	User       string    `json:"user"`      // 操作人（system=系统自动）
+	Detail     string    `json:"detail"`
+	Success    bool      `json:"success"`
+}
+
+// OverrideJournal 决策队列操作日志（每个trader独立，持久化到决策日志目录下）
+type OverrideJournal struct {
+	mu       sync.RWMutex
+	filePath string
+	entries  []OverrideEntry
+}
+
+// NewOverrideJournal 创建操作日志（放在子目录中，避免被当作决策记录读取）
+func NewOverrideJournal(logDir string) *OverrideJournal {
+	dir := filepath.Join(logDir, "journal")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		fmt.Printf("⚠ 创建操作日志目录失败: %v\n", err)
+	}
+
+	journal := &OverrideJournal{
+		filePath: filepath.Join(dir, "decision_overrides.json"),
+	}
+	if data, err := ioutil.ReadFile(journal.filePath); err == nil {
+		if err := json.Unmarshal(data, &journal.entries); err != nil {
+			fmt.Printf("⚠ 解析操作日志失败: %v\n", err)
+			journal.entries = nil
+		}
+	}
+	return journal
+}
+
+// Append 追加一条操作记录
+func (j *OverrideJournal) Append(entry OverrideEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.entries = append(j.entries, entry)
+	if len(j.entries) > maxOverrideEntries {
+		j.entries = j.entries[len(j.entries)-maxOverrideEntries:]
+	}
+
+	data, err := json.MarshalIndent(j.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化操作日志失败: %w", err)
+	}
+	if err := ioutil.WriteFile(j.filePath, data, 0600); err != nil {
+		return fmt.Errorf("写入操作日志失败: %w", err)
+	}
+	return nil
+}
+
+// Recent 最近N条操作记录（新→旧）
+func (j *OverrideJournal) Recent(n int) []OverrideEntry {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	result := make([]OverrideEntry, 0, n)
+	for i := len(j.entries) - 1; i >= 0 && len(result) < n; i-- {
+		result = append(result, j.entries[i])
+	}
+	return result
+}
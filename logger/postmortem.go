@@ -0,0 +1,261 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxPostMortemCoT = 2000 // 复盘包中每条思维链保留的字符数
+
+// 复盘触发类型
+const (
+	PostMortemLossStreak = "loss_streak" // 连续亏损
+	PostMortemDailyLoss  = "daily_loss"  // 当日亏损超限
+)
+
+// 复盘审核状态
+const (
+	PostMortemPending   = "pending_review" // 等待人工审核
+	PostMortemAccepted  = "accepted"       // 已采纳建议
+	PostMortemDismissed = "dismissed"      // 已忽略
+)
+
+// PostMortem 亏损复盘包（交易、决策、行情快照、信心度校准统计及可选的AI分析）
+type PostMortem struct {
+	ID          string               `json:"id"`
+	Key         string               `json:"key"` // 去重键：同一次连亏/同一天只生成一次
+	Trigger     string               `json:"trigger"`
+	Detail      string               `json:"detail"`
+	CreatedAt   time.Time            `json:"created_at"`
+	WindowStart time.Time            `json:"window_start"`
+	Trades      []TradeOutcome       `json:"trades"`
+	Decisions   []PostMortemDecision `json:"decisions"`
+	Market      map[string]string    `json:"market"` // 相关币种当前行情快照
+	Calibration []CalibrationBucket  `json:"calibration"`
+	Analysis    string               `json:"analysis,omitempty"` // AI分析及规则/模板调整建议
+	AnalysisErr string               `json:"analysis_error,omitempty"`
+	Status      string               `json:"status"`
+	ReviewedBy  string               `json:"reviewed_by,omitempty"`
+	ReviewNote  string               `json:"review_note,omitempty"`
+	ReviewedAt  time.Time            `json:"reviewed_at,omitempty"`
+	Account     *PostMortemAccount   `json:"account,omitempty"`
+}
+
+// PostMortemAccount 触发时的账户状态
+type PostMortemAccount struct {
+	OpeningEquity float64 `json:"opening_equity"` // 当日开盘净值
+	Equity        float64 `json:"equity"`         // 触发时净值
+	DailyPnLPct   float64 `json:"daily_pnl_pct"`
+}
+
+// PostMortemDecision 复盘窗口内的决策周期摘要
+type PostMortemDecision struct {
+	Timestamp   time.Time        `json:"timestamp"`
+	CycleNumber int              `json:"cycle_number"`
+	Actions     []DecisionAction `json:"actions"`
+	CoTTrace    string           `json:"cot_trace"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// CalibrationBucket 按开仓信心度分档的实际胜率
+type CalibrationBucket struct {
+	Range   string  `json:"range"` // 如 "80-89"
+	Trades  int     `json:"trades"`
+	Wins    int     `json:"wins"`
+	WinRate float64 `json:"win_rate"`
+	AvgPnL  float64 `json:"avg_pnl"`
+}
+
+// LossStreak 最近连续亏损笔数（trades 为新→旧）
+func LossStreak(trades []TradeOutcome) int {
+	streak := 0
+	for _, trade := range trades {
+		if trade.PnL >= 0 {
+			break
+		}
+		streak++
+	}
+	return streak
+}
+
+// SummarizeDecisions 将决策记录压缩为复盘摘要（跳过无动作且无错误的周期）
+func SummarizeDecisions(records []*DecisionRecord) []PostMortemDecision {
+	result := []PostMortemDecision{}
+	for _, record := range records {
+		if len(record.Decisions) == 0 && record.ErrorMessage == "" {
+			continue
+		}
+		cot := record.CoTTrace
+		if runes := []rune(cot); len(runes) > maxPostMortemCoT {
+			cot = string(runes[:maxPostMortemCoT]) + "..."
+		}
+		result = append(result, PostMortemDecision{
+			Timestamp:   record.Timestamp,
+			CycleNumber: record.CycleNumber,
+			Actions:     record.Decisions,
+			CoTTrace:    cot,
+			Error:       record.ErrorMessage,
+		})
+	}
+	return result
+}
+
+// ConfidenceCalibration 按开仓时的AI信心度统计交易胜率（开仓记录按币种+方向+时间匹配）
+func ConfidenceCalibration(trades []TradeOutcome, records []*DecisionRecord) []CalibrationBucket {
+	confidence := make(map[string]int)
+	for _, record := range records {
+		for _, action := range record.Decisions {
+			if action.Success && action.Confidence > 0 && strings.HasPrefix(action.Action, "open_") {
+				key := fmt.Sprintf("%s_%s_%d", action.Symbol, strings.TrimPrefix(action.Action, "open_"), action.Timestamp.Unix())
+				confidence[key] = action.Confidence
+			}
+		}
+	}
+
+	ranges := []struct {
+		label    string
+		min, max int
+	}{
+		{"未知", 0, 0},
+		{"<70", 1, 69},
+		{"70-79", 70, 79},
+		{"80-89", 80, 89},
+		{"90+", 90, 100},
+	}
+	buckets := make([]CalibrationBucket, len(ranges))
+	for i, r := range ranges {
+		buckets[i].Range = r.label
+	}
+	for _, trade := range trades {
+		c := confidence[fmt.Sprintf("%s_%s_%d", trade.Symbol, trade.Side, trade.OpenTime.Unix())]
+		for i, r := range ranges {
+			if c < r.min || c > r.max {
+				continue
+			}
+			buckets[i].Trades++
+			buckets[i].AvgPnL += trade.PnL
+			if trade.PnL > 0 {
+				buckets[i].Wins++
+			}
+			break
+		}
+	}
+
+	result := []CalibrationBucket{}
+	for _, bucket := range buckets {
+		if bucket.Trades == 0 {
+			continue
+		}
+		bucket.WinRate = float64(bucket.Wins) / float64(bucket.Trades) * 100
+		bucket.AvgPnL /= float64(bucket.Trades)
+		result = append(result, bucket)
+	}
+	return result
+}
+
+// PostMortemStore 亏损复盘包存储（每个复盘包一个JSON文件）
+type PostMortemStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewPostMortemStore 创建复盘包存储（放在子目录中，避免被当作决策记录读取）
+func NewPostMortemStore(logDir string) *PostMortemStore {
+	dir := filepath.Join(logDir, "postmortem")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		fmt.Printf("⚠ 创建复盘目录失败: %v\n", err)
+	}
+	return &PostMortemStore{dir: dir}
+}
+
+// Save 保存（覆盖）复盘包
+func (s *PostMortemStore) Save(pm *PostMortem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(pm, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化复盘包失败: %w", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(s.dir, pm.ID+".json"), data, 0600); err != nil {
+		return fmt.Errorf("写入复盘包失败: %w", err)
+	}
+	return nil
+}
+
+// Get 读取复盘包
+func (s *PostMortemStore) Get(id string) (*PostMortem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getLocked(id)
+}
+
+func (s *PostMortemStore) getLocked(id string) (*PostMortem, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return nil, fmt.Errorf("无效的复盘ID: %s", id)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, id+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("复盘包不存在: %s", id)
+	}
+	var pm PostMortem
+	if err := json.Unmarshal(data, &pm); err != nil {
+		return nil, fmt.Errorf("解析复盘包失败: %w", err)
+	}
+	return &pm, nil
+}
+
+// List 列出复盘包（新→旧，不含决策明细）
+func (s *PostMortemStore) List() []PostMortem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, _ := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	result := []PostMortem{}
+	for _, file := range files {
+		pm, err := s.getLocked(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			continue
+		}
+		pm.Decisions = nil
+		result = append(result, *pm)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+	return result
+}
+
+// HasKey 是否已为该去重键生成过复盘包
+func (s *PostMortemStore) HasKey(key string) bool {
+	for _, pm := range s.List() {
+		if pm.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Review 人工审核复盘建议
+func (s *PostMortemStore) Review(id, status, user, note string) (*PostMortem, error) {
+	if status != PostMortemAccepted && status != PostMortemDismissed {
+		return nil, fmt.Errorf("status 必须为 %s 或 %s", PostMortemAccepted, PostMortemDismissed)
+	}
+	pm, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	pm.Status = status
+	pm.ReviewedBy = user
+	pm.ReviewNote = note
+	pm.ReviewedAt = time.Now()
+	if err := s.Save(pm); err != nil {
+		return nil, err
+	}
+	return pm, nil
+}
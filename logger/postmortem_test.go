@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLossStreak(t *testing.T) {
+	tests := []struct {
+		name string
+		pnls []float64 // 新→旧
+		want int
+	}{
+		{"无交易", nil, 0},
+		{"最近一笔盈利", []float64{5, -1, -2}, 0},
+		{"连续亏损后盈利", []float64{-1, -2, -3, 4, -5}, 3},
+		{"全部亏损", []float64{-1, -1}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var trades []TradeOutcome
+			for _, pnl := range tt.pnls {
+				trades = append(trades, TradeOutcome{PnL: pnl})
+			}
+			if got := LossStreak(trades); got != tt.want {
+				t.Errorf("LossStreak = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfidenceCalibration(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	open := func(symbol string, at time.Time, confidence int) DecisionAction {
+		return DecisionAction{Symbol: symbol, Action: "open_long", Timestamp: at, Success: true, Confidence: confidence}
+	}
+	records := []*DecisionRecord{{Decisions: []DecisionAction{
+		open("BTCUSDT", t0, 92),
+		open("ETHUSDT", t0.Add(time.Hour), 95),
+		open("SOLUSDT", t0.Add(2*time.Hour), 72),
+	}}}
+	trades := []TradeOutcome{
+		{Symbol: "BTCUSDT", Side: "long", OpenTime: t0, PnL: -10},
+		{Symbol: "ETHUSDT", Side: "long", OpenTime: t0.Add(time.Hour), PnL: 20},
+		{Symbol: "SOLUSDT", Side: "long", OpenTime: t0.Add(2 * time.Hour), PnL: 5},
+		{Symbol: "DOGEUSDT", Side: "short", OpenTime: t0, PnL: -1},
+	}
+
+	got := ConfidenceCalibration(trades, records)
+	want := map[string]struct {
+		trades  int
+		winRate float64
+	}{
+		"未知":    {1, 0},
+		"70-79": {1, 100},
+		"90+":   {2, 50},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d buckets, want %d: %+v", len(got), len(want), got)
+	}
+	for _, bucket := range got {
+		w, ok := want[bucket.Range]
+		if !ok || bucket.Trades != w.trades || bucket.WinRate != w.winRate {
+			t.Errorf("bucket %s = %+v, want %+v", bucket.Range, bucket, w)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxPromptChangeEntries = 500 // 保留的最近提示词变更记录数
+	maxPromptDiffLines     = 400 // 单条记录保存的差异行数上限
+)
+
+// PromptInputs 生成 System Prompt 的输入（用于推断变更原因）
+type PromptInputs struct {
+	Template         string `json:"template"`
+	SchemaVersion    string `json:"schema_version"`
+	BTCETHLeverage   int    `json:"btc_eth_leverage"`
+	AltcoinLeverage  int    `json:"altcoin_leverage"`
+	OverrideBase     bool   `json:"override_base"`
+	CustomPromptHash string `json:"custom_prompt_hash"` // 自定义策略内容的哈希（全文已体现在差异中）
+	Persona          string `json:"persona,omitempty"`  // 风险偏好档位
+}
+
+// PromptChange System Prompt 变更记录
+type PromptChange struct {
+	Time        time.Time    `json:"time"`
+	CycleNumber int          `json:"cycle_number"` // 首次使用新提示词的决策周期
+	Equity      float64      `json:"equity"`       // 变更时账户净值（便于与绩效变化对照）
+	Hash        string       `json:"hash"`
+	PrevHash    string       `json:"prev_hash"`
+	Author      string       `json:"author"`   // 修改人（system=未通过API修改，如模板文件变更）
+	Reason      string       `json:"reason"`   // 修改人填写的原因
+	Detected    string       `json:"detected"` // 根据输入变化推断的变更内容
+	Inputs      PromptInputs `json:"inputs"`
+	Added       int          `json:"added"`
+	Removed     int          `json:"removed"`
+	Diff        []string     `json:"diff"` // "+ 行" / "- 行"
+}
+
+// PromptAuditLog System Prompt 变更审计日志（每个trader独立，持久化到决策日志目录下）
+type PromptAuditLog struct {
+	mu         sync.RWMutex
+	filePath   string
+	promptPath string // 最近一次提示词全文（重启后仍能计算差异）
+	entries    []PromptChange
+	lastPrompt string
+}
+
+// NewPromptAuditLog 创建提示词变更审计日志
+func NewPromptAuditLog(logDir string) *PromptAuditLog {
+	dir := filepath.Join(logDir, "journal")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		fmt.Printf("⚠ 创建提示词审计目录失败: %v\n", err)
+	}
+
+	audit := &PromptAuditLog{
+		filePath:   filepath.Join(dir, "prompt_changes.json"),
+		promptPath: filepath.Join(dir, "system_prompt_latest.txt"),
+	}
+	if data, err := ioutil.ReadFile(audit.filePath); err == nil {
+		if err := json.Unmarshal(data, &audit.entries); err != nil {
+			fmt.Printf("⚠ 解析提示词审计日志失败: %v\n", err)
+			audit.entries = nil
+		}
+	}
+	if data, err := ioutil.ReadFile(audit.promptPath); err == nil {
+		audit.lastPrompt = string(data)
+	}
+	return audit
+}
+
+// PromptHash 提示词内容哈希（短格式）
+func PromptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Changed 提示词与最近一次记录是否不同
+func (a *PromptAuditLog) Changed(prompt string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.entries) == 0 || a.entries[len(a.entries)-1].Hash != PromptHash(prompt)
+}
+
+// Record 提示词变化时追加一条变更记录（未变化返回 nil）
+// change 中 Time/CycleNumber/Equity/Author/Reason/Inputs 由调用方填写，哈希、差异与推断原因在此计算
+func (a *PromptAuditLog) Record(prompt string, change PromptChange) (*PromptChange, error) {
+	hash := PromptHash(prompt)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var prev *PromptChange
+	if len(a.entries) > 0 {
+		prev = &a.entries[len(a.entries)-1]
+		if prev.Hash == hash {
+			return nil, nil
+		}
+		change.PrevHash = prev.Hash
+	}
+
+	if change.Time.IsZero() {
+		change.Time = time.Now()
+	}
+	if change.Author == "" {
+		change.Author = "system"
+	}
+	change.Hash = hash
+	if prev == nil {
+		change.Detected = InferPromptChange(nil, change.Inputs)
+	} else {
+		change.Detected = InferPromptChange(&prev.Inputs, change.Inputs)
+	}
+
+	diff := DiffLines(a.lastPrompt, prompt)
+	for _, line := range diff {
+		if strings.HasPrefix(line, "+") {
+			change.Added++
+		} else {
+			change.Removed++
+		}
+	}
+	if len(diff) > maxPromptDiffLines {
+		diff = append(diff[:maxPromptDiffLines], fmt.Sprintf("... 省略 %d 行差异", len(diff)-maxPromptDiffLines))
+	}
+	change.Diff = diff
+
+	a.entries = append(a.entries, change)
+	if len(a.entries) > maxPromptChangeEntries {
+		a.entries = a.entries[len(a.entries)-maxPromptChangeEntries:]
+	}
+	a.lastPrompt = prompt
+
+	data, err := json.MarshalIndent(a.entries, "", "  ")
+	if err != nil {
+		return &change, fmt.Errorf("序列化提示词审计日志失败: %w", err)
+	}
+	if err := ioutil.WriteFile(a.filePath, data, 0600); err != nil {
+		return &change, fmt.Errorf("写入提示词审计日志失败: %w", err)
+	}
+	if err := ioutil.WriteFile(a.promptPath, []byte(prompt), 0600); err != nil {
+		return &change, fmt.Errorf("保存最新提示词失败: %w", err)
+	}
+	return &change, nil
+}
+
+// Timeline 最近N条变更记录（新→旧）
+func (a *PromptAuditLog) Timeline(n int) []PromptChange {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	result := make([]PromptChange, 0, n)
+	for i := len(a.entries) - 1; i >= 0 && len(result) < n; i-- {
+		result = append(result, a.entries[i])
+	}
+	return result
+}
+
+// InferPromptChange 根据输入变化推断变更内容（输入均未变化时说明是模板文件或内置规则本身被修改）
+func InferPromptChange(prev *PromptInputs, cur PromptInputs) string {
+	if prev == nil {
+		return "初始版本"
+	}
+
+	var parts []string
+	if prev.Template != cur.Template {
+		parts = append(parts, fmt.Sprintf("模板切换 %s → %s", prev.Template, cur.Template))
+	}
+	if prev.BTCETHLeverage != cur.BTCETHLeverage {
+		parts = append(parts, fmt.Sprintf("BTC/ETH杠杆 %dx → %dx", prev.BTCETHLeverage, cur.BTCETHLeverage))
+	}
+	if prev.AltcoinLeverage != cur.AltcoinLeverage {
+		parts = append(parts, fmt.Sprintf("山寨币杠杆 %dx → %dx", prev.AltcoinLeverage, cur.AltcoinLeverage))
+	}
+	if prev.SchemaVersion != cur.SchemaVersion {
+		parts = append(parts, fmt.Sprintf("决策格式 v%s → v%s", prev.SchemaVersion, cur.SchemaVersion))
+	}
+	if prev.OverrideBase != cur.OverrideBase {
+		if cur.OverrideBase {
+			parts = append(parts, "开启覆盖基础提示词")
+		} else {
+			parts = append(parts, "关闭覆盖基础提示词")
+		}
+	}
+	if prev.CustomPromptHash != cur.CustomPromptHash {
+		parts = append(parts, "自定义策略修改")
+	}
+	if len(parts) == 0 {
+		return "模板内容变更"
+	}
+	return strings.Join(parts, "；")
+}
+
+// DiffLines 按行计算差异（最长公共子序列），只返回变化的行："- 旧行" / "+ 新行"
+func DiffLines(oldText, newText string) []string {
+	var a, b []string
+	if oldText != "" {
+		a = strings.Split(oldText, "\n")
+	}
+	if newText != "" {
+		b = strings.Split(newText, "\n")
+	}
+
+	// lcs[i][j] = a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var diff []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, "- "+a[i])
+			i++
+		default:
+			diff = append(diff, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, "+ "+b[j])
+	}
+	return diff
+}
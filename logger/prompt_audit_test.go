@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want []string
+	}{
+		{"内容相同", "a\nb", "a\nb", nil},
+		{"初始版本全部新增", "", "a\nb", []string{"+ a", "+ b"}},
+		{"修改中间一行", "a\nb\nc", "a\nx\nc", []string{"- b", "+ x"}},
+		{"末尾追加", "a\nb", "a\nb\nc", []string{"+ c"}},
+		{"删除开头", "a\nb\nc", "b\nc", []string{"- a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffLines(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffLines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInferPromptChange(t *testing.T) {
+	base := PromptInputs{Template: "default", SchemaVersion: "2", BTCETHLeverage: 5, AltcoinLeverage: 5, CustomPromptHash: "h1"}
+
+	tests := []struct {
+		name   string
+		modify func(in *PromptInputs)
+		want   string
+	}{
+		{"输入未变化视为模板内容变更", func(in *PromptInputs) {}, "模板内容变更"},
+		{"模板切换", func(in *PromptInputs) { in.Template = "aggressive" }, "模板切换 default → aggressive"},
+		{"杠杆调整", func(in *PromptInputs) { in.BTCETHLeverage = 10; in.AltcoinLeverage = 3 }, "BTC/ETH杠杆 5x → 10x；山寨币杠杆 5x → 3x"},
+		{"自定义策略修改", func(in *PromptInputs) { in.CustomPromptHash = "h2" }, "自定义策略修改"},
+		{"开启覆盖", func(in *PromptInputs) { in.OverrideBase = true }, "开启覆盖基础提示词"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cur := base
+			tt.modify(&cur)
+			if got := InferPromptChange(&base, cur); got != tt.want {
+				t.Errorf("InferPromptChange = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := InferPromptChange(nil, base); got != "初始版本" {
+		t.Errorf("first record = %q, want 初始版本", got)
+	}
+}
+
+func TestPromptAuditLogRecord(t *testing.T) {
+	dir := t.TempDir()
+	audit := NewPromptAuditLog(dir)
+
+	first, err := audit.Record("a\nb", PromptChange{Inputs: PromptInputs{Template: "default"}})
+	if err != nil || first == nil || first.Author != "system" || first.Added != 2 {
+		t.Fatalf("first = %+v, err = %v", first, err)
+	}
+	if again, _ := audit.Record("a\nb", PromptChange{}); again != nil {
+		t.Errorf("unchanged prompt recorded: %+v", again)
+	}
+
+	// 重新加载后仍能基于上次提示词计算差异
+	reloaded := NewPromptAuditLog(dir)
+	second, err := reloaded.Record("a\nc", PromptChange{Author: "ops@example.com", Reason: "收紧止损", Inputs: PromptInputs{Template: "default"}})
+	if err != nil || second == nil {
+		t.Fatalf("second = %+v, err = %v", second, err)
+	}
+	if second.PrevHash != first.Hash || !reflect.DeepEqual(second.Diff, []string{"- b", "+ c"}) {
+		t.Errorf("second = %+v", second)
+	}
+
+	timeline := reloaded.Timeline(10)
+	if len(timeline) != 2 || timeline[0].Reason != "收紧止损" || timeline[1].Detected != "初始版本" {
+		t.Errorf("timeline = %+v", timeline)
+	}
+}
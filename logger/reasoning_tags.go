@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"sort"
+	"strings"
+)
+
+// ReasoningTagFilter 按理由标签检索决策的条件（为空的条件不过滤）
+type ReasoningTagFilter struct {
+	Symbol      string // 币种
+	Signal      string // 信号类型（MACD、RSI、open_interest…，不区分大小写）
+	Timeframe   string // 周期（4h…）
+	LevelKind   string // 价位类型（support / resistance / stop / target）
+	HasRiskNote bool   // 只返回带风险提示的决策
+}
+
+// TaggedAction 带周期编号的决策动作
+type TaggedAction struct {
+	CycleNumber int `json:"cycle_number"`
+	DecisionAction
+}
+
+// ReasoningTagStat 标签出现次数及其中成功执行的次数
+type ReasoningTagStat struct {
+	Kind     string `json:"kind"` // signal / timeframe / level / risk_note
+	Tag      string `json:"tag"`
+	Count    int    `json:"count"`
+	Executed int    `json:"executed"`
+}
+
+func containsFold(list []string, value string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// levelKind 价位标签的类型部分（support:95000 → support）
+func levelKind(level string) string {
+	if idx := strings.Index(level, ":"); idx >= 0 {
+		return level[:idx]
+	}
+	return level
+}
+
+// matches 决策动作是否满足检索条件
+func (f ReasoningTagFilter) matches(a DecisionAction) bool {
+	if f.Symbol != "" && !strings.EqualFold(a.Symbol, f.Symbol) {
+		return false
+	}
+	if f.Signal != "" && !containsFold(a.Signals, f.Signal) {
+		return false
+	}
+	if f.Timeframe != "" && !containsFold(a.Timeframes, f.Timeframe) {
+		return false
+	}
+	if f.LevelKind != "" {
+		found := false
+		for _, level := range a.Levels {
+			if strings.EqualFold(levelKind(level), f.LevelKind) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return !f.HasRiskNote || len(a.RiskNotes) > 0
+}
+
+// FilterTaggedActions 按理由标签检索决策动作（新→旧）
+func FilterTaggedActions(records []*DecisionRecord, filter ReasoningTagFilter) []TaggedAction {
+	result := []TaggedAction{}
+	for i := len(records) - 1; i >= 0; i-- {
+		for _, a := range records[i].Decisions {
+			if filter.matches(a) {
+				result = append(result, TaggedAction{CycleNumber: records[i].CycleNumber, DecisionAction: a})
+			}
+		}
+	}
+	return result
+}
+
+// SummarizeReasoningTags 统计各标签出现次数（价位按类型聚合），按类型、次数排序
+func SummarizeReasoningTags(records []*DecisionRecord) []ReasoningTagStat {
+	stats := make(map[string]*ReasoningTagStat)
+	add := func(kind, tag string, executed bool) {
+		key := kind + "|" + tag
+		s, ok := stats[key]
+		if !ok {
+			s = &ReasoningTagStat{Kind: kind, Tag: tag}
+			stats[key] = s
+		}
+		s.Count++
+		if executed {
+			s.Executed++
+		}
+	}
+
+	for _, record := range records {
+		for _, a := range record.Decisions {
+			for _, signal := range a.Signals {
+				add("signal", signal, a.Success)
+			}
+			for _, tf := range a.Timeframes {
+				add("timeframe", tf, a.Success)
+			}
+			seen := make(map[string]bool)
+			for _, level := range a.Levels {
+				if kind := levelKind(level); !seen[kind] {
+					seen[kind] = true
+					add("level", kind, a.Success)
+				}
+			}
+			if len(a.RiskNotes) > 0 {
+				add("risk_note", "risk_note", a.Success)
+			}
+		}
+	}
+
+	result := make([]ReasoningTagStat, 0, len(stats))
+	for _, s := range stats {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Kind != result[j].Kind {
+			return result[i].Kind < result[j].Kind
+		}
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Tag < result[j].Tag
+	})
+	return result
+}
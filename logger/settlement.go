@@ -0,0 +1,316 @@
+package logger
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	SettlementDateLayout = "2006-01-02"
+	longTermHolding      = 365 * 24 * time.Hour // 持有超过一年视为长期
+	settlementSourceExch = "exchange"           // 已实现盈亏/手续费/资金费来自交易所流水
+	settlementSourceEst  = "estimated"          // 根据决策记录估算（手续费按费率，资金费未计入）
+)
+
+// DailySettlement 每日结算快照（按本地时区自然日，金额为十进制，JSON中以字符串表示）
+type DailySettlement struct {
+	Date          string          `json:"date"` // YYYY-MM-DD
+	OpeningEquity decimal.Decimal `json:"opening_equity"`
+	ClosingEquity decimal.Decimal `json:"closing_equity"`
+	RealizedPnL   decimal.Decimal `json:"realized_pnl"` // 当日平仓已实现盈亏（未扣手续费）
+	Fees          decimal.Decimal `json:"fees"`         // 当日手续费
+	Funding       decimal.Decimal `json:"funding"`      // 当日资金费收支（正=收入）
+	ClosedLots    int             `json:"closed_lots"`  // 当日处置的批次数
+	Source        string          `json:"source"`       // exchange / estimated
+	SettledAt     time.Time       `json:"settled_at"`
+
+	Reporting *ReportingAmount `json:"reporting,omitempty"` // 已实现盈亏按当日汇率换算为报告货币（配置 reporting_currency 时）
+}
+
+// TaxLot 税务批次：一次开仓（取得）与一次平仓（处置）的配对，部分平仓拆分为多个批次（先进先出）
+// 数量和价格为成交数据（float64），金额字段为十进制记账金额
+type TaxLot struct {
+	Symbol           string          `json:"symbol"`
+	Side             string          `json:"side"`
+	Quantity         float64         `json:"quantity"`
+	AcquiredAt       time.Time       `json:"acquired_at"`
+	AcquisitionPrice float64         `json:"acquisition_price"`
+	DisposedAt       time.Time       `json:"disposed_at"`
+	DisposalPrice    float64         `json:"disposal_price"`
+	CostBasis        decimal.Decimal `json:"cost_basis"` // 多头=买入金额，空头=回补买入金额
+	Proceeds         decimal.Decimal `json:"proceeds"`   // 多头=卖出金额，空头=开空卖出金额
+	Fees             decimal.Decimal `json:"fees"`       // 开仓+平仓手续费（按批次数量分摊）
+	GainLoss         decimal.Decimal `json:"gain_loss"`  // Proceeds - CostBasis - Fees
+	HoldingDays      float64         `json:"holding_days"`
+	Term             string          `json:"term"` // short / long（持有超过一年）
+
+	Reporting *ReportingAmount `json:"reporting,omitempty"` // GainLoss 按处置日汇率换算为报告货币（配置 reporting_currency 时）
+}
+
+// openLot 尚未处置的开仓批次
+type openLot struct {
+	quantity float64
+	price    float64
+	time     time.Time
+}
+
+// actionPrice 成交价（优先实际成交均价）
+func actionPrice(a DecisionAction) float64 {
+	if a.FillPrice > 0 {
+		return a.FillPrice
+	}
+	return a.Price
+}
+
+// BuildTaxLots 按时间顺序把成功执行的开平仓动作配对成税务批次（同币种同方向先进先出）
+// records 需覆盖处置批次对应的开仓时间，早于 records 的开仓无法配对会被忽略；feeRate 为估算手续费率
+func BuildTaxLots(records []*DecisionRecord, feeRate float64) []TaxLot {
+	var actions []DecisionAction
+	for _, record := range records {
+		for _, a := range record.Decisions {
+			if a.Success && a.Quantity > 0 && actionPrice(a) > 0 {
+				actions = append(actions, a)
+			}
+		}
+	}
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].Timestamp.Before(actions[j].Timestamp) })
+
+	open := make(map[string][]*openLot)
+	var lots []TaxLot
+	for _, a := range actions {
+		price := actionPrice(a)
+		switch a.Action {
+		case "open_long", "open_short":
+			side := a.Action[len("open_"):]
+			open[a.Symbol+"_"+side] = append(open[a.Symbol+"_"+side], &openLot{
+				quantity: a.Quantity,
+				price:    price,
+				time:     a.Timestamp,
+			})
+		case "close_long", "close_short", "auto_close_long", "auto_close_short", "partial_close":
+			side := positionSideOfClose(a, open)
+			key := a.Symbol + "_" + side
+			remaining := a.Quantity
+			queue := open[key]
+			for len(queue) > 0 && remaining > 1e-12 {
+				lot := queue[0]
+				qty := lot.quantity
+				if qty > remaining {
+					qty = remaining
+				}
+				lots = append(lots, newTaxLot(a.Symbol, side, qty, lot, price, a.Timestamp, feeRate))
+				lot.quantity -= qty
+				remaining -= qty
+				if lot.quantity <= 1e-12 {
+					queue = queue[1:]
+				}
+			}
+			open[key] = queue
+		}
+	}
+	return lots
+}
+
+// positionSideOfClose 平仓动作对应的持仓方向（partial_close 根据未处置批次判断）
+func positionSideOfClose(a DecisionAction, open map[string][]*openLot) string {
+	switch a.Action {
+	case "close_long", "auto_close_long":
+		return "long"
+	case "close_short", "auto_close_short":
+		return "short"
+	}
+	if len(open[a.Symbol+"_long"]) > 0 {
+		return "long"
+	}
+	return "short"
+}
+
+func newTaxLot(symbol, side string, qty float64, lot *openLot, disposalPrice float64, disposedAt time.Time, feeRate float64) TaxLot {
+	t := TaxLot{
+		Symbol:           symbol,
+		Side:             side,
+		Quantity:         qty,
+		AcquiredAt:       lot.time,
+		AcquisitionPrice: lot.price,
+		DisposedAt:       disposedAt,
+		DisposalPrice:    disposalPrice,
+		HoldingDays:      disposedAt.Sub(lot.time).Hours() / 24,
+		Term:             "short",
+	}
+	acquired, disposed := MoneyMul(qty, lot.price), MoneyMul(qty, disposalPrice)
+	t.Fees = MoneyMul(qty, lot.price, feeRate).Add(MoneyMul(qty, disposalPrice, feeRate))
+	if side == "long" {
+		t.CostBasis, t.Proceeds = acquired, disposed
+	} else {
+		t.CostBasis, t.Proceeds = disposed, acquired
+	}
+	t.GainLoss = t.Proceeds.Sub(t.CostBasis).Sub(t.Fees)
+	if disposedAt.Sub(lot.time) > longTermHolding {
+		t.Term = "long"
+	}
+	return t
+}
+
+// BuildDailySettlement 根据当日决策记录和当日处置的批次估算结算快照
+// prevClosing 为前一日收盘净值（为零时使用当日第一条记录的净值）
+func BuildDailySettlement(date time.Time, dayRecords []*DecisionRecord, lots []TaxLot, prevClosing decimal.Decimal, feeRate float64) DailySettlement {
+	s := DailySettlement{Date: date.Format(SettlementDateLayout), OpeningEquity: prevClosing, Source: settlementSourceEst}
+	if len(dayRecords) > 0 {
+		if !s.OpeningEquity.IsPositive() {
+			s.OpeningEquity = Money(dayRecords[0].AccountState.TotalBalance)
+		}
+		s.ClosingEquity = Money(dayRecords[len(dayRecords)-1].AccountState.TotalBalance)
+	} else {
+		s.ClosingEquity = prevClosing
+	}
+
+	for _, record := range dayRecords {
+		for _, a := range record.Decisions {
+			if a.Success && a.Quantity > 0 {
+				s.Fees = s.Fees.Add(MoneyMul(a.Quantity, actionPrice(a), feeRate))
+			}
+		}
+	}
+	for _, lot := range lots {
+		if lot.DisposedAt.Format(SettlementDateLayout) != s.Date {
+			continue
+		}
+		s.ClosedLots++
+		s.RealizedPnL = s.RealizedPnL.Add(lot.Proceeds.Sub(lot.CostBasis))
+	}
+	return s
+}
+
+// ApplyExchangeIncome 用交易所流水覆盖估算的已实现盈亏、手续费和资金费
+func (s *DailySettlement) ApplyExchangeIncome(realizedPnL, fees, funding decimal.Decimal) {
+	s.RealizedPnL = realizedPnL
+	s.Fees = fees
+	s.Funding = funding
+	s.Source = settlementSourceExch
+}
+
+// SettlementStore 每日结算记录（每个trader独立，持久化到决策日志目录下）
+type SettlementStore struct {
+	mu       sync.RWMutex
+	filePath string
+	entries  []DailySettlement // 按日期升序
+}
+
+// NewSettlementStore 创建结算记录存储
+func NewSettlementStore(logDir string) *SettlementStore {
+	dir := filepath.Join(logDir, "journal")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		fmt.Printf("⚠ 创建结算记录目录失败: %v\n", err)
+	}
+
+	store := &SettlementStore{filePath: filepath.Join(dir, "settlements.json")}
+	if data, err := ioutil.ReadFile(store.filePath); err == nil {
+		if err := json.Unmarshal(data, &store.entries); err != nil {
+			fmt.Printf("⚠ 解析结算记录失败: %v\n", err)
+			store.entries = nil
+		}
+	}
+	return store
+}
+
+// Upsert 保存结算记录（同一日期覆盖）
+func (s *SettlementStore) Upsert(entry DailySettlement) error {
+	if entry.SettledAt.IsZero() {
+		entry.SettledAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	replaced := false
+	for i := range s.entries {
+		if s.entries[i].Date == entry.Date {
+			s.entries[i] = entry
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		s.entries = append(s.entries, entry)
+		sort.Slice(s.entries, func(i, j int) bool { return s.entries[i].Date < s.entries[j].Date })
+	}
+
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化结算记录失败: %w", err)
+	}
+	if err := ioutil.WriteFile(s.filePath, data, 0600); err != nil {
+		return fmt.Errorf("写入结算记录失败: %w", err)
+	}
+	return nil
+}
+
+// Latest 最近一条结算记录
+func (s *SettlementStore) Latest() (DailySettlement, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.entries) == 0 {
+		return DailySettlement{}, false
+	}
+	return s.entries[len(s.entries)-1], true
+}
+
+// Range 日期区间内的结算记录（含两端，YYYY-MM-DD）
+func (s *SettlementStore) Range(from, to string) []DailySettlement {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []DailySettlement{}
+	for _, e := range s.entries {
+		if e.Date >= from && e.Date <= to {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// taxLotCSVHeader 税务批次CSV表头
+var taxLotCSVHeader = []string{
+	"symbol", "side", "quantity",
+	"acquired_at", "acquisition_price", "disposed_at", "disposal_price",
+	"cost_basis", "proceeds", "fees", "gain_loss", "holding_days", "term",
+	"reporting_currency", "fx_rate", "gain_loss_reporting",
+}
+
+// WriteTaxLotCSV 输出税务批次CSV（时间为RFC3339）
+func WriteTaxLotCSV(w io.Writer, lots []TaxLot) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(taxLotCSVHeader); err != nil {
+		return err
+	}
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, lot := range lots {
+		row := []string{
+			lot.Symbol, lot.Side, f(lot.Quantity),
+			lot.AcquiredAt.Format(time.RFC3339), f(lot.AcquisitionPrice),
+			lot.DisposedAt.Format(time.RFC3339), f(lot.DisposalPrice),
+			lot.CostBasis.String(), lot.Proceeds.String(), lot.Fees.String(), lot.GainLoss.String(),
+			strconv.FormatFloat(lot.HoldingDays, 'f', 2, 64), lot.Term,
+			"", "", "",
+		}
+		if r := lot.Reporting; r != nil {
+			row[len(row)-3], row[len(row)-2], row[len(row)-1] = r.Currency, r.Rate.String(), r.Amount.String()
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestBuildTaxLots(t *testing.T) {
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)
+	at := func(hours int) time.Time { return day.Add(time.Duration(hours) * time.Hour) }
+	action := func(name string, qty, price float64, hours int) DecisionAction {
+		return DecisionAction{Action: name, Symbol: "BTCUSDT", Quantity: qty, Price: price, Timestamp: at(hours), Success: true}
+	}
+
+	records := []*DecisionRecord{
+		{Decisions: []DecisionAction{action("open_long", 1, 100, 0)}},
+		{Decisions: []DecisionAction{action("open_long", 1, 110, 2)}},
+		{Decisions: []DecisionAction{action("partial_close", 1.5, 120, 4)}},
+		{Decisions: []DecisionAction{action("close_long", 0.5, 130, 30)}},
+		{Decisions: []DecisionAction{action("open_short", 2, 130, 31), {Action: "close_short", Symbol: "BTCUSDT", Quantity: 2, Timestamp: at(32)}}},
+		{Decisions: []DecisionAction{{Action: "close_short", Symbol: "BTCUSDT", Quantity: 2, Price: 120, FillPrice: 125, Timestamp: at(33), Success: true}}},
+	}
+
+	lots := BuildTaxLots(records, 0)
+	tests := []struct {
+		name     string
+		lot      int
+		qty      float64
+		acquired float64
+		disposed float64
+		gain     string
+	}{
+		{"部分平仓先处置最早批次", 0, 1, 100, 120, "20"},
+		{"部分平仓剩余数量取自第二批", 1, 0.5, 110, 120, "5"},
+		{"次日平掉剩余批次", 2, 0.5, 110, 130, "10"},
+		{"空头按成交均价处置", 3, 2, 130, 125, "10"},
+	}
+	if len(lots) != len(tests) {
+		t.Fatalf("lots = %d, want %d: %+v", len(lots), len(tests), lots)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lot := lots[tt.lot]
+			if lot.Quantity != tt.qty || lot.AcquisitionPrice != tt.acquired || lot.DisposalPrice != tt.disposed || !lot.GainLoss.Equal(decimal.RequireFromString(tt.gain)) {
+				t.Errorf("lot = %+v, want qty %v %v→%v gain %v", lot, tt.qty, tt.acquired, tt.disposed, tt.gain)
+			}
+		})
+	}
+
+	settlement := BuildDailySettlement(day, records[:3], lots, decimal.NewFromInt(1000), 0.001)
+	if settlement.ClosedLots != 2 || !settlement.RealizedPnL.Equal(decimal.NewFromInt(25)) || settlement.Source != settlementSourceEst {
+		t.Errorf("settlement = %+v, want 2 lots / 25 realized", settlement)
+	}
+	// 手续费：100 + 110 + 180 名义价值 × 0.1%（十进制运算，结果精确等于 0.39）
+	if !settlement.Fees.Equal(decimal.RequireFromString("0.39")) {
+		t.Errorf("fees = %v, want 0.39", settlement.Fees)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTaxLotCSV(&buf, lots); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != len(lots)+1 {
+		t.Errorf("csv lines = %d, want %d", len(lines), len(lots)+1)
+	}
+}
+
+func TestMoneyNoFloatDrift(t *testing.T) {
+	// float64 累加 0.1 十次得到 0.9999999999999999，十进制记账应精确为 1
+	total := decimal.Zero
+	floatTotal := 0.0
+	for i := 0; i < 10; i++ {
+		total = total.Add(Money(0.1))
+		floatTotal += 0.1
+	}
+	if floatTotal == 1 {
+		t.Skip("float64 累加未出现误差")
+	}
+	if !total.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("total = %s, want 1", total)
+	}
+
+	if got := MoneyMul(0.3, 3); !got.Equal(decimal.RequireFromString("0.9")) {
+		t.Errorf("MoneyMul(0.3, 3) = %s, want 0.9", got)
+	}
+	if err := SetAccountingDecimals(13); err == nil {
+		t.Error("SetAccountingDecimals(13) should fail")
+	}
+}
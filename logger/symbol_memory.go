@@ -0,0 +1,208 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxMemoryTrades  = 5                  // 每个币种保留的最近交易数
+	maxMemoryNotes   = 3                  // 每个币种保留的备注数
+	memoryNoteWindow = 7 * 24 * time.Hour // 自动归纳备注的统计窗口
+)
+
+// SymbolTradeMemory 单笔已平仓交易的记忆
+type SymbolTradeMemory struct {
+	Side       string    `json:"side"`        // long/short
+	OpenPrice  float64   `json:"open_price"`  // 开仓价
+	ClosePrice float64   `json:"close_price"` // 平仓价
+	PnLPct     float64   `json:"pnl_pct"`     // 价格盈亏百分比（未乘杠杆）
+	Reason     string    `json:"reason"`      // 平仓原因：ai_close / stop_loss / take_profit / auto_close / exchange_close
+	ClosedAt   time.Time `json:"closed_at"`   // 平仓时间
+}
+
+// SymbolMemory 单个币种的策略记忆
+type SymbolMemory struct {
+	Symbol string              `json:"symbol"`
+	Trades []SymbolTradeMemory `json:"trades"` // 最近的交易（从旧到新）
+	Notes  []string            `json:"notes"`  // 手动/外部添加的备注
+}
+
+// SymbolMemoryStore 按币种保存的策略记忆（每个trader独立，持久化到决策日志目录下）
+type SymbolMemoryStore struct {
+	mu       sync.RWMutex
+	filePath string
+	memories map[string]*SymbolMemory
+}
+
+// NewSymbolMemoryStore 创建币种记忆存储（放在子目录中，避免被当作决策记录读取）
+func NewSymbolMemoryStore(logDir string) *SymbolMemoryStore {
+	dir := filepath.Join(logDir, "memory")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		fmt.Printf("⚠ 创建记忆目录失败: %v\n", err)
+	}
+
+	store := &SymbolMemoryStore{
+		filePath: filepath.Join(dir, "symbol_memory.json"),
+		memories: make(map[string]*SymbolMemory),
+	}
+	if data, err := ioutil.ReadFile(store.filePath); err == nil {
+		if err := json.Unmarshal(data, &store.memories); err != nil {
+			fmt.Printf("⚠ 解析币种记忆失败: %v\n", err)
+			store.memories = make(map[string]*SymbolMemory)
+		}
+	}
+	return store
+}
+
+// get 获取币种记忆（调用方需持有写锁）
+func (s *SymbolMemoryStore) get(symbol string) *SymbolMemory {
+	memory, ok := s.memories[symbol]
+	if !ok {
+		memory = &SymbolMemory{Symbol: symbol}
+		s.memories[symbol] = memory
+	}
+	return memory
+}
+
+// RecordTrade 记录一笔已平仓交易
+func (s *SymbolMemoryStore) RecordTrade(symbol string, trade SymbolTradeMemory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if trade.ClosedAt.IsZero() {
+		trade.ClosedAt = time.Now()
+	}
+	if trade.OpenPrice > 0 {
+		trade.PnLPct = (trade.ClosePrice - trade.OpenPrice) / trade.OpenPrice * 100
+		if trade.Side == "short" {
+			trade.PnLPct = -trade.PnLPct
+		}
+	}
+
+	memory := s.get(symbol)
+	memory.Trades = append(memory.Trades, trade)
+	if len(memory.Trades) > maxMemoryTrades {
+		memory.Trades = memory.Trades[len(memory.Trades)-maxMemoryTrades:]
+	}
+	return s.saveLocked()
+}
+
+// AddNote 为币种添加备注
+func (s *SymbolMemoryStore) AddNote(symbol, note string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	memory := s.get(symbol)
+	memory.Notes = append(memory.Notes, note)
+	if len(memory.Notes) > maxMemoryNotes {
+		memory.Notes = memory.Notes[len(memory.Notes)-maxMemoryNotes:]
+	}
+	return s.saveLocked()
+}
+
+// Get 获取币种记忆副本
+func (s *SymbolMemoryStore) Get(symbol string) (SymbolMemory, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	memory, ok := s.memories[symbol]
+	if !ok {
+		return SymbolMemory{}, false
+	}
+	cp := SymbolMemory{
+		Symbol: memory.Symbol,
+		Trades: append([]SymbolTradeMemory(nil), memory.Trades...),
+		Notes:  append([]string(nil), memory.Notes...),
+	}
+	return cp, true
+}
+
+// Summary 生成注入prompt的一行记忆摘要（没有记忆时返回空字符串）
+// 例：最近3笔: 多-1.8%(止损@3.45) 多-1.2%(止损@3.44) 空+2.3% | 近7天多单止损2次，集中在3.44附近
+func (s *SymbolMemoryStore) Summary(symbol string) string {
+	memory, ok := s.Get(symbol)
+	if !ok || (len(memory.Trades) == 0 && len(memory.Notes) == 0) {
+		return ""
+	}
+
+	var parts []string
+	if len(memory.Trades) > 0 {
+		var trades []string
+		for i := len(memory.Trades) - 1; i >= 0; i-- {
+			trades = append(trades, formatTradeMemory(memory.Trades[i]))
+		}
+		parts = append(parts, fmt.Sprintf("最近%d笔: %s", len(memory.Trades), strings.Join(trades, " ")))
+	}
+	parts = append(parts, deriveMemoryNotes(memory.Trades, time.Now())...)
+	parts = append(parts, memory.Notes...)
+
+	return strings.Join(parts, " | ")
+}
+
+// formatTradeMemory 格式化单笔交易记忆
+func formatTradeMemory(t SymbolTradeMemory) string {
+	side := "多"
+	if t.Side == "short" {
+		side = "空"
+	}
+	result := fmt.Sprintf("%s%+.1f%%", side, t.PnLPct)
+	switch t.Reason {
+	case "stop_loss":
+		result += fmt.Sprintf("(止损@%.4g)", t.ClosePrice)
+	case "take_profit":
+		result += fmt.Sprintf("(止盈@%.4g)", t.ClosePrice)
+	}
+	return result
+}
+
+// deriveMemoryNotes 从近期交易中归纳值得注意的模式（同方向多次止损、连续亏损）
+func deriveMemoryNotes(trades []SymbolTradeMemory, now time.Time) []string {
+	var notes []string
+
+	for _, side := range []string{"long", "short"} {
+		count := 0
+		priceSum := 0.0
+		for _, t := range trades {
+			if t.Side == side && t.Reason == "stop_loss" && now.Sub(t.ClosedAt) <= memoryNoteWindow {
+				count++
+				priceSum += t.ClosePrice
+			}
+		}
+		if count >= 2 {
+			sideName := "多单"
+			if side == "short" {
+				sideName = "空单"
+			}
+			notes = append(notes, fmt.Sprintf("近7天%s止损%d次，集中在%.4g附近", sideName, count, priceSum/float64(count)))
+		}
+	}
+
+	losses := 0
+	for i := len(trades) - 1; i >= 0 && trades[i].PnLPct < 0; i-- {
+		losses++
+	}
+	if losses >= 3 {
+		notes = append(notes, fmt.Sprintf("已连续亏损%d笔", losses))
+	}
+
+	return notes
+}
+
+// saveLocked 持久化到文件（调用方需持有写锁）
+func (s *SymbolMemoryStore) saveLocked() error {
+	data, err := json.MarshalIndent(s.memories, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化币种记忆失败: %w", err)
+	}
+	if err := ioutil.WriteFile(s.filePath, data, 0600); err != nil {
+		return fmt.Errorf("写入币种记忆失败: %w", err)
+	}
+	return nil
+}
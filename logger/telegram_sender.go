@@ -8,11 +8,20 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// telegramMessage 待发送的消息（photo 不为空时以图片+说明发送）
+type telegramMessage struct {
+	text  string
+	photo []byte
+}
+
+// telegramCaptionLimit Telegram图片说明的最大字符数
+const telegramCaptionLimit = 1024
+
 // TelegramSender Telegram消息发送器（异步）
 type TelegramSender struct {
 	bot           *tgbotapi.BotAPI
 	chatID        int64
-	msgChan       chan string
+	msgChan       chan telegramMessage
 	retryCount    int
 	retryInterval time.Duration
 	wg            sync.WaitGroup
@@ -33,9 +42,9 @@ func NewTelegramSender(botToken string, chatID int64) (*TelegramSender, error) {
 	sender := &TelegramSender{
 		bot:           bot,
 		chatID:        chatID,
-		msgChan:       make(chan string, 20), // 固定缓冲区大小: 20
-		retryCount:    3,                     // 固定重试次数: 3
-		retryInterval: 3 * time.Second,       // 固定重试间隔: 3秒
+		msgChan:       make(chan telegramMessage, 20), // 固定缓冲区大小: 20
+		retryCount:    3,                              // 固定重试次数: 3
+		retryInterval: 3 * time.Second,                // 固定重试间隔: 3秒
 		stopChan:      make(chan struct{}),
 	}
 
@@ -53,6 +62,18 @@ func (s *TelegramSender) Start() {
 
 // SendAsync 异步发送消息（非阻塞）
 func (s *TelegramSender) SendAsync(message string) {
+	s.enqueue(telegramMessage{text: message})
+}
+
+// SendPhotoAsync 异步发送PNG图片（caption 为图片说明，超过Telegram上限时截断）
+func (s *TelegramSender) SendPhotoAsync(caption string, png []byte) {
+	if runes := []rune(caption); len(runes) > telegramCaptionLimit {
+		caption = string(runes[:telegramCaptionLimit-1]) + "…"
+	}
+	s.enqueue(telegramMessage{text: caption, photo: png})
+}
+
+func (s *TelegramSender) enqueue(message telegramMessage) {
 	select {
 	case s.msgChan <- message:
 		// 成功写入缓冲区
@@ -82,7 +103,7 @@ func (s *TelegramSender) listenAndSend() {
 }
 
 // sendWithRetry 发送消息（带重试）
-func (s *TelegramSender) sendWithRetry(message string) {
+func (s *TelegramSender) sendWithRetry(message telegramMessage) {
 	var err error
 	for i := 0; i < s.retryCount; i++ {
 		err = s.send(message)
@@ -103,8 +124,16 @@ func (s *TelegramSender) sendWithRetry(message string) {
 }
 
 // send 发送单条消息
-func (s *TelegramSender) send(message string) error {
-	msg := tgbotapi.NewMessage(s.chatID, message)
+func (s *TelegramSender) send(message telegramMessage) error {
+	if len(message.photo) > 0 {
+		photo := tgbotapi.NewPhoto(s.chatID, tgbotapi.FileBytes{Name: "chart.png", Bytes: message.photo})
+		photo.Caption = message.text
+		photo.ParseMode = tgbotapi.ModeMarkdown
+		_, err := s.bot.Send(photo)
+		return err
+	}
+
+	msg := tgbotapi.NewMessage(s.chatID, message.text)
 	msg.ParseMode = tgbotapi.ModeMarkdown
 
 	_, err := s.bot.Send(msg)
@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// TimeframeHitStats 单个币种单个周期的信号命中统计
+type TimeframeHitStats struct {
+	Hits  int `json:"hits"`  // 开仓时该周期趋势方向与之后实际价格方向一致的次数
+	Total int `json:"total"` // 有明确趋势方向的已平仓交易数
+}
+
+// Reliability 平滑后的命中率（(hits+1)/(total+2)，没有样本时为0.5）
+func (s TimeframeHitStats) Reliability() float64 {
+	return float64(s.Hits+1) / float64(s.Total+2)
+}
+
+// timeframeReliabilityFile 持久化格式
+type timeframeReliabilityFile struct {
+	Stats   map[string]map[string]*TimeframeHitStats `json:"stats"`   // symbol -> timeframe -> 统计
+	Entries map[string]map[string]int                `json:"entries"` // symbol_side -> 开仓时各周期趋势方向（平仓时结算）
+}
+
+// TimeframeHitStore 按币种、周期统计趋势信号的实际命中率（每个trader独立，持久化到决策日志目录下）
+type TimeframeHitStore struct {
+	mu       sync.RWMutex
+	filePath string
+	data     timeframeReliabilityFile
+}
+
+// NewTimeframeHitStore 创建周期可靠度存储
+func NewTimeframeHitStore(logDir string) *TimeframeHitStore {
+	dir := filepath.Join(logDir, "memory")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		fmt.Printf("⚠ 创建记忆目录失败: %v\n", err)
+	}
+
+	store := &TimeframeHitStore{filePath: filepath.Join(dir, "timeframe_reliability.json")}
+	if data, err := ioutil.ReadFile(store.filePath); err == nil {
+		if err := json.Unmarshal(data, &store.data); err != nil {
+			fmt.Printf("⚠ 解析周期可靠度失败: %v\n", err)
+		}
+	}
+	if store.data.Stats == nil {
+		store.data.Stats = make(map[string]map[string]*TimeframeHitStats)
+	}
+	if store.data.Entries == nil {
+		store.data.Entries = make(map[string]map[string]int)
+	}
+	return store
+}
+
+// RecordEntry 记录开仓时各周期的趋势方向（同方向重复开仓时覆盖）
+func (s *TimeframeHitStore) RecordEntry(symbol, side string, trends map[string]int) error {
+	if len(trends) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := make(map[string]int, len(trends))
+	for tf, dir := range trends {
+		entry[tf] = dir
+	}
+	s.data.Entries[symbol+"_"+side] = entry
+	return s.saveLocked()
+}
+
+// RecordOutcome 平仓时结算开仓记录：价格实际方向与各周期趋势方向比较（pnlPct 为价格盈亏百分比，0 不计入）
+func (s *TimeframeHitStore) RecordOutcome(symbol, side string, pnlPct float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := symbol + "_" + side
+	entry, ok := s.data.Entries[key]
+	if !ok {
+		return nil
+	}
+	delete(s.data.Entries, key)
+	if pnlPct != 0 {
+		scoreTimeframes(s.stats(symbol), entry, side, pnlPct)
+	}
+	return s.saveLocked()
+}
+
+// scoreTimeframes 按实际价格方向为各周期计分（趋势不明确的周期不计入）
+func scoreTimeframes(stats map[string]*TimeframeHitStats, trends map[string]int, side string, pnlPct float64) {
+	priceUp := (side == "long") == (pnlPct > 0)
+	for tf, dir := range trends {
+		if dir == 0 {
+			continue
+		}
+		st, ok := stats[tf]
+		if !ok {
+			st = &TimeframeHitStats{}
+			stats[tf] = st
+		}
+		st.Total++
+		if (dir > 0) == priceUp {
+			st.Hits++
+		}
+	}
+}
+
+// stats 获取币种统计（调用方需持有写锁）
+func (s *TimeframeHitStore) stats(symbol string) map[string]*TimeframeHitStats {
+	stats, ok := s.data.Stats[symbol]
+	if !ok {
+		stats = make(map[string]*TimeframeHitStats)
+		s.data.Stats[symbol] = stats
+	}
+	return stats
+}
+
+// Get 币种各周期的命中统计副本
+func (s *TimeframeHitStore) Get(symbol string) map[string]TimeframeHitStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]TimeframeHitStats, len(s.data.Stats[symbol]))
+	for tf, st := range s.data.Stats[symbol] {
+		result[tf] = *st
+	}
+	return result
+}
+
+// All 全部币种的命中统计副本
+func (s *TimeframeHitStore) All() map[string]map[string]TimeframeHitStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]map[string]TimeframeHitStats, len(s.data.Stats))
+	for symbol, stats := range s.data.Stats {
+		result[symbol] = make(map[string]TimeframeHitStats, len(stats))
+		for tf, st := range stats {
+			result[symbol][tf] = *st
+		}
+	}
+	return result
+}
+
+func (s *TimeframeHitStore) saveLocked() error {
+	data, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化周期可靠度失败: %w", err)
+	}
+	if err := ioutil.WriteFile(s.filePath, data, 0600); err != nil {
+		return fmt.Errorf("写入周期可靠度失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package logger
+
+import "testing"
+
+func TestScoreTimeframes(t *testing.T) {
+	trends := map[string]int{"3m": 1, "1h": -1, "4h": 0}
+	tests := []struct {
+		name     string
+		side     string
+		pnlPct   float64
+		wantHits map[string]int
+	}{
+		{"多单盈利：上涨命中多头周期", "long", 2, map[string]int{"3m": 1, "1h": 0}},
+		{"多单亏损：下跌命中空头周期", "long", -1, map[string]int{"3m": 0, "1h": 1}},
+		{"空单盈利：下跌命中空头周期", "short", 3, map[string]int{"3m": 0, "1h": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := make(map[string]*TimeframeHitStats)
+			scoreTimeframes(stats, trends, tt.side, tt.pnlPct)
+			if _, ok := stats["4h"]; ok {
+				t.Errorf("趋势不明确的周期不应计分")
+			}
+			for tf, hits := range tt.wantHits {
+				if stats[tf] == nil || stats[tf].Total != 1 || stats[tf].Hits != hits {
+					t.Errorf("%s stats = %+v, want hits %d/1", tf, stats[tf], hits)
+				}
+			}
+		})
+	}
+}
+
+func TestTimeframeHitStatsReliability(t *testing.T) {
+	if got := (TimeframeHitStats{}).Reliability(); got != 0.5 {
+		t.Errorf("无样本 Reliability() = %v, want 0.5", got)
+	}
+	if got := (TimeframeHitStats{Hits: 7, Total: 8}).Reliability(); got != 0.8 {
+		t.Errorf("Reliability() = %v, want 0.8", got)
+	}
+}
@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const maxTradeIdeas = 500 // 保留的最近交易想法数
+
+// 交易想法状态
+const (
+	IdeaStatusPending   = "pending"   // 等待AI在下一个周期评估
+	IdeaStatusAccepted  = "accepted"  // AI接受，等待人工审批执行
+	IdeaStatusExecuted  = "executed"  // AI接受且通过风控执行成功
+	IdeaStatusRejected  = "rejected"  // AI评估后拒绝
+	IdeaStatusBlocked   = "blocked"   // AI接受但未通过风控规则或执行失败
+	IdeaStatusExpired   = "expired"   // 多个周期未得到AI评估或超过有效期
+	IdeaStatusCancelled = "cancelled" // 提交人撤回
+)
+
+// TradeIdea 外部研究提交的交易想法及AI评估结果
+type TradeIdea struct {
+	ID          string    `json:"id"`
+	Symbol      string    `json:"symbol"`
+	Direction   string    `json:"direction"` // long / short
+	Thesis      string    `json:"thesis"`
+	SubmittedBy string    `json:"submitted_by"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	Status      string    `json:"status"`
+	Attempts    int       `json:"attempts"`            // 已提交给AI评估的周期数
+	Verdict     string    `json:"verdict,omitempty"`   // AI给出的决策动作
+	Reasoning   string    `json:"reasoning,omitempty"` // AI理由
+	Detail      string    `json:"detail,omitempty"`    // 风控拒绝/执行失败原因
+	EvaluatedAt time.Time `json:"evaluated_at,omitempty"`
+	Cycle       int       `json:"cycle,omitempty"` // 评估所在的决策周期
+}
+
+// TradeIdeaStore 交易想法收件箱（每个trader独立，持久化到决策日志目录下）
+type TradeIdeaStore struct {
+	mu       sync.RWMutex
+	filePath string
+	ideas    []TradeIdea // 按提交时间升序
+}
+
+// NewTradeIdeaStore 创建交易想法收件箱
+func NewTradeIdeaStore(logDir string) *TradeIdeaStore {
+	dir := filepath.Join(logDir, "journal")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		fmt.Printf("⚠ 创建交易想法目录失败: %v\n", err)
+	}
+
+	store := &TradeIdeaStore{filePath: filepath.Join(dir, "trade_ideas.json")}
+	if data, err := ioutil.ReadFile(store.filePath); err == nil {
+		if err := json.Unmarshal(data, &store.ideas); err != nil {
+			fmt.Printf("⚠ 解析交易想法失败: %v\n", err)
+			store.ideas = nil
+		}
+	}
+	return store
+}
+
+// Add 提交交易想法
+func (s *TradeIdeaStore) Add(idea TradeIdea) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ideas = append(s.ideas, idea)
+	if len(s.ideas) > maxTradeIdeas {
+		s.ideas = s.ideas[len(s.ideas)-maxTradeIdeas:]
+	}
+	return s.saveLocked()
+}
+
+// Update 修改指定想法（fn 返回 false 时不保存）
+func (s *TradeIdeaStore) Update(id string, fn func(idea *TradeIdea) bool) (TradeIdea, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.ideas {
+		if s.ideas[i].ID != id {
+			continue
+		}
+		if !fn(&s.ideas[i]) {
+			return s.ideas[i], nil
+		}
+		return s.ideas[i], s.saveLocked()
+	}
+	return TradeIdea{}, fmt.Errorf("交易想法不存在: %s", id)
+}
+
+// List 按状态筛选（status 为空返回全部，新→旧）
+func (s *TradeIdeaStore) List(status string) []TradeIdea {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []TradeIdea{}
+	for _, idea := range s.ideas {
+		if status == "" || idea.Status == status {
+			result = append(result, idea)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].SubmittedAt.After(result[j].SubmittedAt) })
+	return result
+}
+
+func (s *TradeIdeaStore) saveLocked() error {
+	data, err := json.MarshalIndent(s.ideas, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化交易想法失败: %w", err)
+	}
+	if err := ioutil.WriteFile(s.filePath, data, 0600); err != nil {
+		return fmt.Errorf("写入交易想法失败: %w", err)
+	}
+	return nil
+}
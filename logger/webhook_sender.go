@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// webhookPayload Webhook推送内容（附带图片时为base64编码的PNG）
+type webhookPayload struct {
+	Text           string `json:"text"`
+	ImagePNGBase64 string `json:"image_png_base64,omitempty"`
+}
+
+// webhookChannel 以JSON POST推送到自定义Webhook（异步，失败只打印日志）
+type webhookChannel struct {
+	url    string
+	client *http.Client
+}
+
+func (c webhookChannel) Send(text string) error {
+	go c.post(webhookPayload{Text: text})
+	return nil
+}
+
+func (c webhookChannel) SendImage(caption string, png []byte) error {
+	go c.post(webhookPayload{Text: caption, ImagePNGBase64: base64.StdEncoding.EncodeToString(png)})
+	return nil
+}
+
+func (c webhookChannel) post(payload webhookPayload) {
+	body, _ := json.Marshal(payload)
+	resp, err := c.client.Post(c.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		fmt.Printf("⚠ Webhook推送失败: %v\n", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		fmt.Printf("⚠ Webhook推送失败: HTTP %d\n", resp.StatusCode)
+	}
+}
+
+// SetupWebhookNotifications 注册Webhook通知渠道
+func SetupWebhookNotifications(url string) error {
+	if url == "" {
+		return fmt.Errorf("Webhook URL不能为空")
+	}
+	RegisterNotificationChannel(ChannelWebhook, webhookChannel{url: url, client: &http.Client{Timeout: 10 * time.Second}})
+	return nil
+}
@@ -0,0 +1,198 @@
+package loglevel
+
+import (
+	"fmt"
+	"log"
+	"nofx/clock"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 子系统
+const (
+	Market   = "market"   // 行情数据获取与指标计算
+	Decision = "decision" // 上下文构建、AI调用与决策解析
+	Executor = "executor" // 下单、撤单、杠杆/仓位模式设置
+	WS       = "ws"       // WebSocket连接、订阅与消息分发
+)
+
+// 日志级别（从低到高）
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+var levelRank = map[string]int{LevelDebug: 0, LevelInfo: 1, LevelWarn: 2, LevelError: 3}
+
+// DefaultSampleInterval 高频消息默认采样间隔（同一键在间隔内只输出一次）
+const DefaultSampleInterval = 5 * time.Minute
+
+// sampleRetention 有未报告抑制条数的采样键在窗口过期后继续保留的时长（等待下次输出时附带抑制数）
+const sampleRetention = time.Hour
+
+// SubsystemStatus 子系统日志级别与采样统计
+type SubsystemStatus struct {
+	Subsystem  string `json:"subsystem"`
+	Level      string `json:"level"`
+	Suppressed int64  `json:"suppressed"` // 因采样被抑制的消息数
+}
+
+type sampleState struct {
+	last       time.Time
+	interval   time.Duration
+	suppressed int
+}
+
+var (
+	mu         sync.Mutex
+	levels     = map[string]string{Market: LevelInfo, Decision: LevelInfo, Executor: LevelInfo, WS: LevelInfo}
+	suppressed = map[string]int64{}
+	samples    = map[string]*sampleState{}
+	lastPrune  time.Time
+	logClock   clock.Clock = clock.System
+)
+
+// SetClock 替换采样使用的时钟
+func SetClock(c clock.Clock) {
+	mu.Lock()
+	defer mu.Unlock()
+	logClock = c
+}
+
+// SetLevel 运行时设置子系统日志级别
+func SetLevel(subsystem, level string) error {
+	level = strings.ToLower(strings.TrimSpace(level))
+	if _, ok := levelRank[level]; !ok {
+		return fmt.Errorf("无效的日志级别: %s（可选 debug / info / warn / error）", level)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := levels[subsystem]; !ok {
+		return fmt.Errorf("未知的子系统: %s", subsystem)
+	}
+	levels[subsystem] = level
+	return nil
+}
+
+// Status 各子系统当前日志级别（按名称排序）
+func Status() []SubsystemStatus {
+	mu.Lock()
+	defer mu.Unlock()
+	result := make([]SubsystemStatus, 0, len(levels))
+	for name, level := range levels {
+		result = append(result, SubsystemStatus{Subsystem: name, Level: level, Suppressed: suppressed[name]})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Subsystem < result[j].Subsystem })
+	return result
+}
+
+// Enabled 子系统是否输出该级别日志
+func Enabled(subsystem, level string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabledLocked(subsystem, level)
+}
+
+func enabledLocked(subsystem, level string) bool {
+	current, ok := levels[subsystem]
+	if !ok {
+		return true
+	}
+	return levelRank[level] >= levelRank[current]
+}
+
+// Debugf 输出调试日志
+func Debugf(subsystem, format string, args ...interface{}) {
+	logf(subsystem, LevelDebug, format, args...)
+}
+
+// Infof 输出普通日志
+func Infof(subsystem, format string, args ...interface{}) {
+	logf(subsystem, LevelInfo, format, args...)
+}
+
+// Warnf 输出警告日志
+func Warnf(subsystem, format string, args ...interface{}) {
+	logf(subsystem, LevelWarn, format, args...)
+}
+
+func logf(subsystem, level, format string, args ...interface{}) {
+	if Enabled(subsystem, level) {
+		log.Printf(format, args...)
+	}
+}
+
+// Sampledf 高频消息采样输出：同一 key 在 interval 内只输出一次，下次输出时附带期间被抑制的条数
+// 子系统为 debug 级别时不采样（排查问题时需要看到每一条）
+func Sampledf(subsystem, level, key string, interval time.Duration, format string, args ...interface{}) {
+	mu.Lock()
+	if !enabledLocked(subsystem, level) {
+		mu.Unlock()
+		return
+	}
+	skipped := 0
+	if levels[subsystem] != LevelDebug {
+		t := logClock.Now()
+		pruneSamplesLocked(t)
+		k := subsystem + "|" + key
+		state, ok := samples[k]
+		if !ok {
+			state = &sampleState{}
+			samples[k] = state
+		}
+		state.interval = interval
+		if ok && t.Sub(state.last) < interval {
+			state.suppressed++
+			suppressed[subsystem]++
+			mu.Unlock()
+			return
+		}
+		skipped = state.suppressed
+		state.last = t
+		state.suppressed = 0
+	}
+	mu.Unlock()
+
+	msg := fmt.Sprintf(format, args...)
+	if skipped > 0 {
+		msg += fmt.Sprintf("（%s内另有 %d 条同类日志已省略）", interval, skipped)
+	}
+	log.Print(msg)
+}
+
+// pruneSamplesLocked 清理采样窗口已过期的键（每个默认采样间隔最多清理一次），避免键持续累积
+// 过期键下次出现时本来就会直接输出；仍有未报告抑制条数的键额外保留 sampleRetention
+func pruneSamplesLocked(t time.Time) {
+	if elapsed := t.Sub(lastPrune); elapsed >= 0 && elapsed < DefaultSampleInterval {
+		return
+	}
+	lastPrune = t
+	for k, state := range samples {
+		idle := t.Sub(state.last)
+		if idle >= state.interval && (state.suppressed == 0 || idle >= state.interval+sampleRetention) {
+			delete(samples, k)
+		}
+	}
+}
+
+// Apply 按 "market=debug,ws=warn" 格式批量设置级别（用于环境变量 NOFX_LOG_LEVELS）
+func Apply(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("无效的日志级别配置: %s（格式 子系统=级别）", item)
+		}
+		if err := SetLevel(strings.TrimSpace(parts[0]), parts[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
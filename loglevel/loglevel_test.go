@@ -0,0 +1,109 @@
+package loglevel
+
+import (
+	"bytes"
+	"log"
+	"nofx/clock"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampledf(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer log.SetOutput(os.Stderr)
+
+	manual := clock.NewManual(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	SetClock(manual)
+	defer SetClock(clock.System)
+
+	tests := []struct {
+		name    string
+		level   string
+		advance time.Duration
+		want    string // 空=不应输出
+	}{
+		{"首条输出", LevelInfo, 0, "msg"},
+		{"间隔内抑制", LevelInfo, time.Minute, ""},
+		{"再次抑制", LevelInfo, time.Minute, ""},
+		{"间隔后输出并附带抑制数", LevelInfo, 5 * time.Minute, "另有 2 条"},
+		{"debug级别不采样", LevelDebug, time.Second, "msg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetLevel(Market, tt.level); err != nil {
+				t.Fatal(err)
+			}
+			buf.Reset()
+			manual.Advance(tt.advance)
+			Sampledf(Market, LevelInfo, "key", DefaultSampleInterval, "msg")
+			got := buf.String()
+			if tt.want == "" && got != "" {
+				t.Errorf("expected suppression, got %q", got)
+			}
+			if tt.want != "" && !strings.Contains(got, tt.want) {
+				t.Errorf("output %q missing %q", got, tt.want)
+			}
+		})
+	}
+	SetLevel(Market, LevelInfo)
+}
+
+func TestSampledfPrunesExpiredKeys(t *testing.T) {
+	log.SetOutput(&bytes.Buffer{})
+	defer log.SetOutput(os.Stderr)
+
+	manual := clock.NewManual(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	SetClock(manual)
+	defer SetClock(clock.System)
+
+	for _, key := range []string{"a", "b", "pending"} {
+		Sampledf(WS, LevelInfo, "prune-"+key, time.Minute, "msg")
+	}
+	Sampledf(WS, LevelInfo, "prune-pending", time.Minute, "msg") // 抑制1条，等待下次输出时报告
+	manual.Advance(DefaultSampleInterval)
+	Sampledf(WS, LevelInfo, "prune-d", time.Minute, "msg")
+
+	exists := func(key string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		_, ok := samples[WS+"|prune-"+key]
+		return ok
+	}
+	for _, key := range []string{"a", "b"} {
+		if exists(key) {
+			t.Errorf("expired key %s not pruned", key)
+		}
+	}
+	if !exists("d") {
+		t.Error("active key pruned")
+	}
+	if !exists("pending") {
+		t.Error("key with unreported suppressed messages pruned before retention")
+	}
+
+	manual.Advance(sampleRetention + time.Minute)
+	Sampledf(WS, LevelInfo, "prune-e", time.Minute, "msg")
+	if exists("pending") || exists("d") {
+		t.Error("keys past retention not pruned")
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	if err := SetLevel("unknown", LevelDebug); err == nil {
+		t.Error("expected error for unknown subsystem")
+	}
+	if err := SetLevel(WS, "verbose"); err == nil {
+		t.Error("expected error for invalid level")
+	}
+	if err := SetLevel(WS, "WARN"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetLevel(WS, LevelInfo)
+	if Enabled(WS, LevelInfo) || !Enabled(WS, LevelError) {
+		t.Error("warn level should hide info and show error")
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"nofx/auth"
 	"nofx/config"
 	"nofx/crypto"
+	"nofx/logger"
+	"nofx/loglevel"
 	"nofx/manager"
 	"nofx/market"
 	"nofx/pool"
@@ -151,6 +153,42 @@ func loadBetaCodesToDatabase(database *config.Database) error {
 }
 
 func main() {
+	// 子命令：导出历史指标（不启动交易系统）
+	if len(os.Args) > 1 && os.Args[1] == "export-indicators" {
+		if err := runExportIndicators(os.Args[2:]); err != nil {
+			log.Fatalf("❌ 导出指标失败: %v", err)
+		}
+		return
+	}
+	// 子命令：滚动前推优化分析器参数（不启动交易系统）
+	if len(os.Args) > 1 && os.Args[1] == "optimize" {
+		if err := runOptimize(os.Args[2:]); err != nil {
+			log.Fatalf("❌ 参数优化失败: %v", err)
+		}
+		return
+	}
+	// 子命令：按信号文件回测（不启动交易系统）
+	if len(os.Args) > 1 && os.Args[1] == "backtest" {
+		if err := runBacktest(os.Args[2:]); err != nil {
+			log.Fatalf("❌ 回测失败: %v", err)
+		}
+		return
+	}
+	// 子命令：币安合约测试网端到端冒烟测试（不启动交易系统）
+	if len(os.Args) > 1 && os.Args[1] == "smoke" {
+		if err := runSmoke(os.Args[2:]); err != nil {
+			log.Fatalf("❌ 冒烟测试失败: %v", err)
+		}
+		return
+	}
+	// 子命令：独立运行市场分析服务（不启动交易系统）
+	if len(os.Args) > 1 && os.Args[1] == "analysis-service" {
+		if err := runAnalysisService(os.Args[2:]); err != nil {
+			log.Fatalf("❌ 市场分析服务退出: %v", err)
+		}
+		return
+	}
+
 	fmt.Println("╔════════════════════════════════════════════════════════════╗")
 	fmt.Println("║    🤖 AI多模型交易系统 - 支持 DeepSeek & Qwen            ║")
 	fmt.Println("╚════════════════════════════════════════════════════════════╝")
@@ -172,6 +210,30 @@ func main() {
 		log.Fatalf("❌ 读取config.json失败: %v", err)
 	}
 
+	// 初始化事件通知渠道（Telegram / Webhook，可选）
+	if configFile.Log != nil && configFile.Log.Telegram != nil && configFile.Log.Telegram.Enabled {
+		tg := configFile.Log.Telegram
+		if err := logger.SetupTelegramNotifications(tg.BotToken, tg.ChatID); err != nil {
+			log.Printf("⚠️  初始化Telegram通知失败: %v", err)
+		} else {
+			log.Printf("✅ Telegram通知已启用")
+		}
+	}
+	if configFile.Log != nil && configFile.Log.Webhook != nil && configFile.Log.Webhook.Enabled {
+		if err := logger.SetupWebhookNotifications(configFile.Log.Webhook.URL); err != nil {
+			log.Printf("⚠️  初始化Webhook通知失败: %v", err)
+		} else {
+			log.Printf("✅ Webhook通知已启用")
+		}
+	}
+
+	// 子系统日志级别（如 NOFX_LOG_LEVELS=market=debug,ws=warn，运行中可通过 /api/log-levels 调整）
+	if spec := os.Getenv("NOFX_LOG_LEVELS"); spec != "" {
+		if err := loglevel.Apply(spec); err != nil {
+			log.Printf("⚠️  NOFX_LOG_LEVELS 无效: %v", err)
+		}
+	}
+
 	log.Printf("📋 初始化配置数据库: %s", dbPath)
 	database, err := config.NewDatabase(dbPath)
 	if err != nil {
@@ -263,6 +325,25 @@ func main() {
 		log.Printf("✓ 已配置OI Top API")
 	}
 
+	// 记账金额精度（每日结算、税务批次等记账层使用十进制金额）
+	if decimalsStr, _ := database.GetSystemConfig("accounting_decimals"); decimalsStr != "" {
+		if decimals, err := strconv.Atoi(decimalsStr); err == nil {
+			if err := logger.SetAccountingDecimals(decimals); err != nil {
+				log.Printf("⚠️  %v", err)
+			}
+		}
+	}
+
+	// 市场分析服务（可选）：多币种分析在独立进程中运行，请求失败时退回本地计算
+	analysisURL := strings.TrimSpace(os.Getenv("MARKET_ANALYSIS_URL"))
+	if analysisURL == "" {
+		analysisURL, _ = database.GetSystemConfig("market_analysis_url")
+	}
+	if analysisURL != "" {
+		market.SetProvider(market.NewRemoteProvider(analysisURL, strings.TrimSpace(os.Getenv("MARKET_ANALYSIS_TOKEN")), 0, true))
+		log.Printf("✓ 已配置市场分析服务: %s", analysisURL)
+	}
+
 	// 创建TraderManager
 	traderManager := manager.NewTraderManager()
 
@@ -271,6 +352,8 @@ func main() {
 	if err != nil {
 		log.Fatalf("❌ 加载交易员失败: %v", err)
 	}
+	traderManager.RecoverTemplateRollout(database)
+	traderManager.StartCapitalRebalancer(database)
 
 	// 获取数据库中的所有交易员配置（用于显示，使用default用户）
 	traders, err := database.GetTraders("default")
@@ -333,6 +416,11 @@ func main() {
 		}
 	}()
 
+	// 各交易员选择的分析周期随WS监控一起订阅
+	for _, t := range traderManager.GetAllTraders() {
+		market.EnsureTimeframes(t.GetTimeframes())
+	}
+
 	// 启动流行情数据 - 默认使用所有交易员设置的币种 如果没有设置币种 则优先使用系统默认
 	go market.NewWSMonitor(150).Start(database.GetCustomCoins())
 	//go market.NewWSMonitor(150).Start([]string{}) //这里是一个使用方式 传入空的话 则使用market市场的所有币种
@@ -0,0 +1,386 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"nofx/config"
+	"nofx/trader"
+	"sort"
+	"time"
+)
+
+// 资金再平衡模式
+const (
+	RebalanceFixed       = "fixed"       // 按固定目标比例
+	RebalancePerformance = "performance" // 按回看期已实现收益率调整（赚钱的交易员分到更多资金）
+)
+
+const (
+	capitalRebalanceKey    = "capital_rebalance" // 各用户的再平衡计划在系统配置中的键（JSON，userID -> 计划）
+	rebalanceCheckInterval = 10 * time.Minute    // 检查到期计划的间隔
+)
+
+// CapitalRebalanceConfig 资金再平衡配置（多个交易员共用一个交易所账户）
+type CapitalRebalanceConfig struct {
+	TraderIDs     []string           `json:"trader_ids"`        // 共用账户的交易员（至少2个，同一交易所）
+	Mode          string             `json:"mode"`              // fixed / performance
+	Targets       map[string]float64 `json:"targets,omitempty"` // fixed 模式各交易员目标比例（自动归一化到100%）
+	IntervalHours float64            `json:"interval_hours"`    // 再平衡间隔（默认24）
+	LookbackHours float64            `json:"lookback_hours"`    // performance 模式收益率回看期（默认等于间隔）
+	MinWeightPct  float64            `json:"min_weight_pct"`    // 单个交易员最低比例（默认10）
+	MaxWeightPct  float64            `json:"max_weight_pct"`    // 单个交易员最高比例（默认70）
+	MinChangePct  float64            `json:"min_change_pct"`    // 比例变化小于该值（百分点）时不调整（默认1）
+}
+
+// normalize 填充默认值并校验
+func (c *CapitalRebalanceConfig) normalize() error {
+	if len(c.TraderIDs) < 2 {
+		return fmt.Errorf("资金再平衡至少需要2个交易员")
+	}
+	switch c.Mode {
+	case RebalanceFixed:
+		for _, id := range c.TraderIDs {
+			if c.Targets[id] <= 0 {
+				return fmt.Errorf("交易员 %s 缺少目标比例", id)
+			}
+		}
+	case RebalancePerformance:
+	default:
+		return fmt.Errorf("未知的再平衡模式: %s（可选 fixed / performance）", c.Mode)
+	}
+	if c.IntervalHours <= 0 {
+		c.IntervalHours = 24
+	}
+	if c.LookbackHours <= 0 {
+		c.LookbackHours = c.IntervalHours
+	}
+	if c.MinWeightPct <= 0 {
+		c.MinWeightPct = 10
+	}
+	if c.MaxWeightPct <= 0 {
+		c.MaxWeightPct = 70
+	}
+	if c.MinChangePct <= 0 {
+		c.MinChangePct = 1
+	}
+	n := float64(len(c.TraderIDs))
+	if c.MinWeightPct*n > 100 || c.MaxWeightPct*n < 100 || c.MinWeightPct > c.MaxWeightPct {
+		return fmt.Errorf("比例上下限无法满足 %d 个交易员合计100%%（最低 %.1f%%，最高 %.1f%%）", len(c.TraderIDs), c.MinWeightPct, c.MaxWeightPct)
+	}
+	return nil
+}
+
+// CapitalRebalancePlan 用户的资金再平衡计划
+type CapitalRebalancePlan struct {
+	UserID     string                 `json:"user_id"`
+	Config     CapitalRebalanceConfig `json:"config"`
+	Enabled    bool                   `json:"enabled"`
+	CreatedAt  time.Time              `json:"created_at"`
+	LastRunAt  time.Time              `json:"last_run_at"`
+	NextRunAt  time.Time              `json:"next_run_at"`
+	LastResult string                 `json:"last_result,omitempty"`
+}
+
+// copyRebalancePlan 深拷贝（API返回时避免与调度协程共享切片/map）
+func copyRebalancePlan(p *CapitalRebalancePlan) *CapitalRebalancePlan {
+	c := *p
+	c.Config.TraderIDs = append([]string(nil), p.Config.TraderIDs...)
+	if p.Config.Targets != nil {
+		c.Config.Targets = make(map[string]float64, len(p.Config.Targets))
+		for id, v := range p.Config.Targets {
+			c.Config.Targets[id] = v
+		}
+	}
+	return &c
+}
+
+// SetCapitalRebalance 保存并启用用户的资金再平衡计划，立即执行一次再平衡
+func (tm *TraderManager) SetCapitalRebalance(database *config.Database, userID string, cfg CapitalRebalanceConfig) (*CapitalRebalancePlan, []config.CapitalReallocation, error) {
+	if err := cfg.normalize(); err != nil {
+		return nil, nil, err
+	}
+	exchange := ""
+	for _, id := range cfg.TraderIDs {
+		at, err := tm.GetTrader(id)
+		if err != nil || !isUserTrader(id, userID) {
+			return nil, nil, fmt.Errorf("交易员 %s 不存在", id)
+		}
+		if exchange != "" && at.GetExchange() != exchange {
+			return nil, nil, fmt.Errorf("交易员 %s 的交易所（%s）与其他交易员（%s）不同，不能共用资金", id, at.GetExchange(), exchange)
+		}
+		exchange = at.GetExchange()
+	}
+
+	tm.rebalanceMu.Lock()
+	if tm.rebalancePlans == nil {
+		tm.rebalancePlans = make(map[string]*CapitalRebalancePlan)
+	}
+	tm.rebalancePlans[userID] = &CapitalRebalancePlan{
+		UserID:    userID,
+		Config:    cfg,
+		Enabled:   true,
+		CreatedAt: time.Now(),
+		NextRunAt: time.Now(),
+	}
+	tm.saveCapitalRebalance(database)
+	tm.rebalanceMu.Unlock()
+	log.Printf("⚖️  用户 %s 资金再平衡计划已保存: %s 模式，%d 个交易员，每 %.0f 小时", userID, cfg.Mode, len(cfg.TraderIDs), cfg.IntervalHours)
+
+	events, err := tm.RunCapitalRebalance(database, userID, "计划创建")
+	return tm.GetCapitalRebalance(userID), events, err
+}
+
+// DisableCapitalRebalance 停用用户的资金再平衡计划（已分配的比例保持不变）
+func (tm *TraderManager) DisableCapitalRebalance(database *config.Database, userID string) (*CapitalRebalancePlan, error) {
+	tm.rebalanceMu.Lock()
+	defer tm.rebalanceMu.Unlock()
+	plan, ok := tm.rebalancePlans[userID]
+	if !ok {
+		return nil, fmt.Errorf("没有资金再平衡计划")
+	}
+	plan.Enabled = false
+	tm.saveCapitalRebalance(database)
+	log.Printf("⏸ 用户 %s 资金再平衡计划已停用", userID)
+	return copyRebalancePlan(plan), nil
+}
+
+// GetCapitalRebalance 获取用户的资金再平衡计划（没有时返回 nil）
+func (tm *TraderManager) GetCapitalRebalance(userID string) *CapitalRebalancePlan {
+	tm.rebalanceMu.Lock()
+	defer tm.rebalanceMu.Unlock()
+	plan, ok := tm.rebalancePlans[userID]
+	if !ok {
+		return nil
+	}
+	return copyRebalancePlan(plan)
+}
+
+// RunCapitalRebalance 按计划重新分配共用账户的资金：更新各交易员的资金比例和初始余额，并记录调整日志
+func (tm *TraderManager) RunCapitalRebalance(database *config.Database, userID, reason string) ([]config.CapitalReallocation, error) {
+	tm.rebalanceMu.Lock()
+	defer tm.rebalanceMu.Unlock()
+	plan, ok := tm.rebalancePlans[userID]
+	if !ok {
+		return nil, fmt.Errorf("没有资金再平衡计划")
+	}
+	cfg := plan.Config
+
+	events, err := tm.rebalance(userID, cfg, reason)
+	now := time.Now()
+	plan.LastRunAt = now
+	plan.NextRunAt = now.Add(time.Duration(cfg.IntervalHours * float64(time.Hour)))
+	if err != nil {
+		plan.LastResult = "失败: " + err.Error()
+	} else {
+		plan.LastResult = fmt.Sprintf("调整 %d 个交易员", len(events))
+	}
+	tm.saveCapitalRebalance(database)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) > 0 && database != nil {
+		if err := database.SaveCapitalReallocations(events); err != nil {
+			log.Printf("⚠️  保存资金再平衡日志失败: %v", err)
+		}
+	}
+	return events, nil
+}
+
+// rebalance 计算新比例并应用到交易员
+func (tm *TraderManager) rebalance(userID string, cfg CapitalRebalanceConfig, reason string) ([]config.CapitalReallocation, error) {
+	traders := make(map[string]*trader.AutoTrader, len(cfg.TraderIDs))
+	for _, id := range cfg.TraderIDs {
+		at, err := tm.GetTrader(id)
+		if err != nil {
+			return nil, fmt.Errorf("交易员 %s 不存在", id)
+		}
+		traders[id] = at
+	}
+
+	// 共用账户的总净值（任一交易员查询到的都是整个账户）
+	account, err := traders[cfg.TraderIDs[0]].GetAccountInfo()
+	if err != nil {
+		return nil, fmt.Errorf("获取账户净值失败: %w", err)
+	}
+	totalEquity, _ := account["total_equity"].(float64)
+	if totalEquity <= 0 {
+		return nil, fmt.Errorf("账户净值无效: %.2f", totalEquity)
+	}
+
+	current := make(map[string]float64, len(traders))
+	returns := make(map[string]float64, len(traders))
+	capital := make(map[string]float64, len(traders))
+	lookback := time.Duration(cfg.LookbackHours * float64(time.Hour))
+	for id, at := range traders {
+		current[id] = at.GetCapitalWeight()
+		capital[id] = at.GetStatus().InitialBalance
+		if cfg.Mode != RebalancePerformance {
+			continue
+		}
+		pnl, err := at.RealizedPnLWithin(lookback)
+		if err != nil {
+			log.Printf("⚠️  [%s] 资金再平衡：分析已实现盈亏失败: %v", id, err)
+			continue
+		}
+		if capital[id] > 0 {
+			returns[id] = pnl / capital[id] * 100
+		}
+	}
+
+	weights := computeRebalanceWeights(cfg, current, returns)
+	var events []config.CapitalReallocation
+	for _, id := range cfg.TraderIDs {
+		newWeight := weights[id]
+		if current[id] > 0 && math.Abs(newWeight-current[id]) < cfg.MinChangePct {
+			continue
+		}
+		newCapital := totalEquity * newWeight / 100
+		if err := traders[id].SetCapitalAllocation(newWeight, newCapital); err != nil {
+			log.Printf("⚠️  [%s] 资金再平衡失败: %v", id, err)
+			continue
+		}
+		events = append(events, config.CapitalReallocation{
+			UserID:       userID,
+			TraderID:     id,
+			Mode:         cfg.Mode,
+			OldWeightPct: current[id],
+			NewWeightPct: newWeight,
+			OldCapital:   capital[id],
+			NewCapital:   newCapital,
+			ReturnPct:    returns[id],
+			Reason:       reason,
+			CreatedAt:    time.Now(),
+		})
+	}
+	log.Printf("⚖️  用户 %s 资金再平衡完成（%s）: 账户净值 %.2f USDT，调整 %d 个交易员", userID, reason, totalEquity, len(events))
+	return events, nil
+}
+
+// computeRebalanceWeights 计算新的资金比例（合计100%，并限制在上下限之间）
+// fixed: 目标比例归一化；performance: 当前比例 ×（1 + 回看期收益率），未分配过的交易员按平均比例起算
+func computeRebalanceWeights(cfg CapitalRebalanceConfig, current, returns map[string]float64) map[string]float64 {
+	raw := make(map[string]float64, len(cfg.TraderIDs))
+	equal := 100 / float64(len(cfg.TraderIDs))
+	for _, id := range cfg.TraderIDs {
+		switch cfg.Mode {
+		case RebalanceFixed:
+			raw[id] = cfg.Targets[id]
+		default:
+			base := current[id]
+			if base <= 0 {
+				base = equal
+			}
+			raw[id] = base * math.Max(0.01, 1+returns[id]/100)
+		}
+	}
+	return clampWeights(raw, cfg.MinWeightPct, cfg.MaxWeightPct)
+}
+
+// clampWeights 归一化到100%并限制上下限：超出的交易员固定在边界，剩余比例按原比例分给其余交易员
+func clampWeights(raw map[string]float64, minPct, maxPct float64) map[string]float64 {
+	ids := make([]string, 0, len(raw))
+	for id := range raw {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	result := make(map[string]float64, len(raw))
+	fixed := make(map[string]bool, len(raw))
+	for range ids {
+		fixedSum, freeSum := 0.0, 0.0
+		for _, id := range ids {
+			if fixed[id] {
+				fixedSum += result[id]
+			} else {
+				freeSum += raw[id]
+			}
+		}
+		if freeSum <= 0 {
+			break
+		}
+		scale := (100 - fixedSum) / freeSum
+		var over, under []string
+		for _, id := range ids {
+			if fixed[id] {
+				continue
+			}
+			result[id] = raw[id] * scale
+			if result[id] > maxPct {
+				over = append(over, id)
+			} else if result[id] < minPct {
+				under = append(under, id)
+			}
+		}
+		// 每轮只固定一侧越界的交易员，避免两侧同时固定后合计不足100%
+		switch {
+		case len(over) > 0:
+			for _, id := range over {
+				result[id], fixed[id] = maxPct, true
+			}
+		case len(under) > 0:
+			for _, id := range under {
+				result[id], fixed[id] = minPct, true
+			}
+		default:
+			return result
+		}
+	}
+	return result
+}
+
+// StartCapitalRebalancer 加载已保存的再平衡计划，并定期执行到期的计划
+func (tm *TraderManager) StartCapitalRebalancer(database *config.Database) {
+	if value, err := database.GetSystemConfig(capitalRebalanceKey); err == nil && value != "" {
+		plans := make(map[string]*CapitalRebalancePlan)
+		if err := json.Unmarshal([]byte(value), &plans); err != nil {
+			log.Printf("⚠️  解析资金再平衡计划失败: %v", err)
+		} else {
+			tm.rebalanceMu.Lock()
+			tm.rebalancePlans = plans
+			tm.rebalanceMu.Unlock()
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(rebalanceCheckInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			for _, userID := range tm.dueRebalancePlans(time.Now()) {
+				if _, err := tm.RunCapitalRebalance(database, userID, "定时再平衡"); err != nil {
+					log.Printf("⚠️  用户 %s 定时资金再平衡失败: %v", userID, err)
+				}
+			}
+		}
+	}()
+}
+
+// dueRebalancePlans 已到执行时间的启用计划
+func (tm *TraderManager) dueRebalancePlans(now time.Time) []string {
+	tm.rebalanceMu.Lock()
+	defer tm.rebalanceMu.Unlock()
+	var due []string
+	for userID, plan := range tm.rebalancePlans {
+		if plan.Enabled && !now.Before(plan.NextRunAt) {
+			due = append(due, userID)
+		}
+	}
+	sort.Strings(due)
+	return due
+}
+
+// saveCapitalRebalance 持久化再平衡计划（调用方持有 rebalanceMu）
+func (tm *TraderManager) saveCapitalRebalance(database *config.Database) {
+	if database == nil {
+		return
+	}
+	data, err := json.Marshal(tm.rebalancePlans)
+	if err != nil {
+		return
+	}
+	if err := database.SetSystemConfig(capitalRebalanceKey, string(data)); err != nil {
+		log.Printf("⚠️  保存资金再平衡计划失败: %v", err)
+	}
+}
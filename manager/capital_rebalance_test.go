@@ -0,0 +1,52 @@
+package manager
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputeRebalanceWeights(t *testing.T) {
+	ids := []string{"a", "b", "c"}
+	tests := []struct {
+		name    string
+		cfg     CapitalRebalanceConfig
+		current map[string]float64
+		returns map[string]float64
+		want    map[string]float64
+	}{
+		{
+			"固定目标归一化",
+			CapitalRebalanceConfig{TraderIDs: ids, Mode: RebalanceFixed, Targets: map[string]float64{"a": 2, "b": 1, "c": 1}, MinWeightPct: 10, MaxWeightPct: 70},
+			nil, nil,
+			map[string]float64{"a": 50, "b": 25, "c": 25},
+		},
+		{
+			"首次按平均比例",
+			CapitalRebalanceConfig{TraderIDs: ids, Mode: RebalancePerformance, MinWeightPct: 10, MaxWeightPct: 70},
+			map[string]float64{}, map[string]float64{},
+			map[string]float64{"a": 100.0 / 3, "b": 100.0 / 3, "c": 100.0 / 3},
+		},
+		{
+			"收益高的分到更多",
+			CapitalRebalanceConfig{TraderIDs: []string{"a", "b"}, Mode: RebalancePerformance, MinWeightPct: 10, MaxWeightPct: 90},
+			map[string]float64{"a": 50, "b": 50}, map[string]float64{"a": 20, "b": -20},
+			map[string]float64{"a": 60, "b": 40},
+		},
+		{
+			"超过上限截断后重新分配",
+			CapitalRebalanceConfig{TraderIDs: ids, Mode: RebalanceFixed, Targets: map[string]float64{"a": 90, "b": 5, "c": 5}, MinWeightPct: 10, MaxWeightPct: 70},
+			nil, nil,
+			map[string]float64{"a": 70, "b": 15, "c": 15},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeRebalanceWeights(tt.cfg, tt.current, tt.returns)
+			for id, want := range tt.want {
+				if math.Abs(got[id]-want) > 1e-6 {
+					t.Errorf("weight[%s] = %.4f, want %.4f (all: %v)", id, got[id], want, got)
+				}
+			}
+		})
+	}
+}
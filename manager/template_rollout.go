@@ -0,0 +1,519 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"nofx/config"
+	"nofx/decision"
+	"nofx/trader"
+	"sort"
+	"time"
+)
+
+// 模板灰度状态
+const (
+	RolloutRunning    = "running"
+	RolloutPromoted   = "promoted"
+	RolloutRolledBack = "rolled_back"
+	RolloutAborted    = "aborted"
+)
+
+// 灰度评估结论
+const (
+	RolloutVerdictContinue = "continue"
+	RolloutVerdictPromote  = "promote"
+	RolloutVerdictRollback = "rollback"
+)
+
+const (
+	templateRolloutKey   = "template_rollout" // 灰度状态在系统配置中的键
+	rolloutCheckInterval = 5 * time.Minute    // 灰度评估间隔
+)
+
+// TemplateRolloutConfig 模板灰度配置
+type TemplateRolloutConfig struct {
+	Template              string   `json:"template"`                 // 新模板名称
+	Percent               float64  `json:"percent"`                  // 灰度比例（0~100，按交易员ID哈希稳定选取，至少1个）
+	TraderIDs             []string `json:"trader_ids,omitempty"`     // 指定灰度交易员（如影子交易员），非空时忽略 percent
+	MinCycles             int      `json:"min_cycles"`               // 每个灰度交易员在新模板下至少完成的周期数（默认10）
+	MaxHours              float64  `json:"max_hours"`                // 超过该时长仍未满足样本数则回滚（默认48）
+	MaxConformanceDropPct float64  `json:"max_conformance_drop_pct"` // 合规率比对照组低超过该值时回滚（百分点，默认10）
+	MaxReturnDropPct      float64  `json:"max_return_drop_pct"`      // 收益率比对照组低超过该值时回滚（百分点，默认2）
+}
+
+// normalize 填充默认值并校验
+func (c *TemplateRolloutConfig) normalize() error {
+	if _, err := decision.GetPromptTemplate(c.Template); err != nil {
+		return fmt.Errorf("模板不存在: %s", c.Template)
+	}
+	if len(c.TraderIDs) == 0 && (c.Percent <= 0 || c.Percent > 100) {
+		return fmt.Errorf("灰度比例必须在 (0,100] 之间: %v", c.Percent)
+	}
+	if c.MinCycles <= 0 {
+		c.MinCycles = 10
+	}
+	if c.MaxHours <= 0 {
+		c.MaxHours = 48
+	}
+	if c.MaxConformanceDropPct <= 0 {
+		c.MaxConformanceDropPct = 10
+	}
+	if c.MaxReturnDropPct <= 0 {
+		c.MaxReturnDropPct = 2
+	}
+	return nil
+}
+
+// RolloutMember 参与灰度的交易员（灰度组或对照组）
+type RolloutMember struct {
+	TraderID     string  `json:"trader_id"`
+	PrevTemplate string  `json:"prev_template"` // 灰度开始前的模板（回滚时恢复）
+	StartEquity  float64 `json:"start_equity"`
+	// 开始时的合规计数（灰度组为新模板，对照组为原模板），用于计算灰度期间的增量
+	StartResponses  int `json:"start_responses"`
+	StartConformant int `json:"start_conformant"`
+	// 灰度组在原模板下的历史合规计数（没有对照组时作为基线）
+	BaselineResponses  int `json:"baseline_responses"`
+	BaselineConformant int `json:"baseline_conformant"`
+	// 灰度期间的表现
+	Responses  int     `json:"responses"`
+	Conformant int     `json:"conformant"`
+	ReturnPct  float64 `json:"return_pct"`
+}
+
+// RolloutEvaluation 一次灰度评估结果
+type RolloutEvaluation struct {
+	Time                  time.Time `json:"time"`
+	MinCanaryCycles       int       `json:"min_canary_cycles"` // 灰度交易员在新模板下完成的最少周期数
+	CanaryConformancePct  float64   `json:"canary_conformance_pct"`
+	ControlConformancePct float64   `json:"control_conformance_pct"` // 对照组（无对照组时为灰度组原模板历史）合规率
+	ConformanceDelta      float64   `json:"conformance_delta"`
+	CanaryReturnPct       float64   `json:"canary_return_pct"`
+	ControlReturnPct      float64   `json:"control_return_pct"`
+	ReturnDelta           float64   `json:"return_delta"`
+	Verdict               string    `json:"verdict"`
+	Reason                string    `json:"reason"`
+}
+
+// TemplateRollout 模板灰度发布
+type TemplateRollout struct {
+	ID             string                `json:"id"`
+	UserID         string                `json:"user_id"`
+	Config         TemplateRolloutConfig `json:"config"`
+	Status         string                `json:"status"`
+	Canary         []RolloutMember       `json:"canary"`
+	Control        []RolloutMember       `json:"control"`
+	StartedAt      time.Time             `json:"started_at"`
+	FinishedAt     time.Time             `json:"finished_at"`
+	LastEvaluation *RolloutEvaluation    `json:"last_evaluation,omitempty"`
+	Reason         string                `json:"reason,omitempty"` // 结束原因
+}
+
+// copyRollout 深拷贝（API返回时避免与评估协程共享切片）
+func copyRollout(r *TemplateRollout) *TemplateRollout {
+	c := *r
+	c.Config.TraderIDs = append([]string(nil), r.Config.TraderIDs...)
+	c.Canary = append([]RolloutMember(nil), r.Canary...)
+	c.Control = append([]RolloutMember(nil), r.Control...)
+	if r.LastEvaluation != nil {
+		eval := *r.LastEvaluation
+		c.LastEvaluation = &eval
+	}
+	return &c
+}
+
+// selectCanaries 按 种子+交易员ID 的哈希稳定排序，取前 percent% 作为灰度组（至少1个）
+func selectCanaries(eligible []string, percent float64, seed string) (canary, control []string) {
+	ids := append([]string(nil), eligible...)
+	hashOf := func(id string) uint32 {
+		h := fnv.New32a()
+		h.Write([]byte(seed + "|" + id))
+		return h.Sum32()
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		hi, hj := hashOf(ids[i]), hashOf(ids[j])
+		if hi != hj {
+			return hi < hj
+		}
+		return ids[i] < ids[j]
+	})
+
+	n := int(math.Ceil(float64(len(ids)) * percent / 100))
+	if n < 1 {
+		n = 1
+	}
+	if n > len(ids) {
+		n = len(ids)
+	}
+	return ids[:n], ids[n:]
+}
+
+// groupConformance 组内合规率（按响应数加权）
+func groupConformance(members []RolloutMember, baseline bool) (float64, int) {
+	responses, conformant := 0, 0
+	for _, m := range members {
+		if baseline {
+			responses += m.BaselineResponses
+			conformant += m.BaselineConformant
+		} else {
+			responses += m.Responses
+			conformant += m.Conformant
+		}
+	}
+	if responses == 0 {
+		return 0, 0
+	}
+	return float64(conformant) / float64(responses) * 100, responses
+}
+
+// groupReturn 组内平均收益率
+func groupReturn(members []RolloutMember) float64 {
+	if len(members) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, m := range members {
+		total += m.ReturnPct
+	}
+	return total / float64(len(members))
+}
+
+// evaluateRollout 比较灰度组与对照组的合规率和收益率，给出继续/推广/回滚结论
+// 样本数满足 MinCycles 后才做判断；没有对照组时合规率与灰度组原模板历史比较，不比较收益率
+func evaluateRollout(cfg TemplateRolloutConfig, canary, control []RolloutMember, elapsed time.Duration) RolloutEvaluation {
+	eval := RolloutEvaluation{Time: time.Now(), Verdict: RolloutVerdictContinue}
+	if len(canary) == 0 {
+		eval.Verdict, eval.Reason = RolloutVerdictRollback, "没有灰度交易员"
+		return eval
+	}
+
+	eval.MinCanaryCycles = canary[0].Responses
+	for _, m := range canary[1:] {
+		if m.Responses < eval.MinCanaryCycles {
+			eval.MinCanaryCycles = m.Responses
+		}
+	}
+	eval.CanaryConformancePct, _ = groupConformance(canary, false)
+	eval.CanaryReturnPct = groupReturn(canary)
+
+	hasControl := len(control) > 0
+	var controlResponses int
+	if hasControl {
+		eval.ControlConformancePct, controlResponses = groupConformance(control, false)
+		eval.ControlReturnPct = groupReturn(control)
+		eval.ReturnDelta = eval.CanaryReturnPct - eval.ControlReturnPct
+	} else {
+		eval.ControlConformancePct, controlResponses = groupConformance(canary, true)
+	}
+	if controlResponses > 0 {
+		eval.ConformanceDelta = eval.CanaryConformancePct - eval.ControlConformancePct
+	}
+
+	if eval.MinCanaryCycles < cfg.MinCycles {
+		if elapsed > time.Duration(cfg.MaxHours*float64(time.Hour)) {
+			eval.Verdict = RolloutVerdictRollback
+			eval.Reason = fmt.Sprintf("超过 %.0f 小时仍未满足样本数（最少 %d/%d 周期）", cfg.MaxHours, eval.MinCanaryCycles, cfg.MinCycles)
+		} else {
+			eval.Reason = fmt.Sprintf("样本不足（最少 %d/%d 周期）", eval.MinCanaryCycles, cfg.MinCycles)
+		}
+		return eval
+	}
+	if controlResponses > 0 && eval.ConformanceDelta < -cfg.MaxConformanceDropPct {
+		eval.Verdict = RolloutVerdictRollback
+		eval.Reason = fmt.Sprintf("合规率 %.1f%% 比对照组 %.1f%% 低 %.1f 个百分点（阈值 %.1f）",
+			eval.CanaryConformancePct, eval.ControlConformancePct, -eval.ConformanceDelta, cfg.MaxConformanceDropPct)
+		return eval
+	}
+	if hasControl && eval.ReturnDelta < -cfg.MaxReturnDropPct {
+		eval.Verdict = RolloutVerdictRollback
+		eval.Reason = fmt.Sprintf("收益率 %+.2f%% 比对照组 %+.2f%% 低 %.2f 个百分点（阈值 %.2f）",
+			eval.CanaryReturnPct, eval.ControlReturnPct, -eval.ReturnDelta, cfg.MaxReturnDropPct)
+		return eval
+	}
+	eval.Verdict = RolloutVerdictPromote
+	eval.Reason = fmt.Sprintf("合规率差 %+.1f、收益率差 %+.2f 均在阈值内", eval.ConformanceDelta, eval.ReturnDelta)
+	return eval
+}
+
+// latestEquity 交易员最近一个周期记录的净值
+func latestEquity(at *trader.AutoTrader) float64 {
+	records, err := at.GetDecisionLogger().GetLatestRecords(1)
+	if err != nil || len(records) == 0 {
+		return 0
+	}
+	return records[0].AccountState.TotalBalance
+}
+
+// StartTemplateRollout 对用户名下运行中的交易员开始模板灰度（同一时间只允许一个灰度）
+func (tm *TraderManager) StartTemplateRollout(database *config.Database, userID string, cfg TemplateRolloutConfig) (*TemplateRollout, error) {
+	if err := cfg.normalize(); err != nil {
+		return nil, err
+	}
+
+	tm.rolloutMu.Lock()
+	defer tm.rolloutMu.Unlock()
+	if tm.rollout != nil && tm.rollout.Status == RolloutRunning {
+		return nil, fmt.Errorf("已有进行中的模板灰度: %s", tm.rollout.ID)
+	}
+
+	// 可参与灰度的交易员：用户名下运行中、且尚未使用新模板
+	traders := make(map[string]*trader.AutoTrader)
+	var eligible []string
+	for id, at := range tm.GetAllTraders() {
+		if !isUserTrader(id, userID) || !at.IsRunning() || at.GetSystemPromptTemplate() == cfg.Template {
+			continue
+		}
+		traders[id] = at
+		eligible = append(eligible, id)
+	}
+	sort.Strings(eligible)
+
+	var canaryIDs, controlIDs []string
+	if len(cfg.TraderIDs) > 0 {
+		selected := make(map[string]bool)
+		for _, id := range cfg.TraderIDs {
+			if _, ok := traders[id]; !ok {
+				return nil, fmt.Errorf("交易员 %s 不存在、未运行或已在使用模板 %s", id, cfg.Template)
+			}
+			selected[id] = true
+			canaryIDs = append(canaryIDs, id)
+		}
+		for _, id := range eligible {
+			if !selected[id] {
+				controlIDs = append(controlIDs, id)
+			}
+		}
+	} else {
+		if len(eligible) == 0 {
+			return nil, fmt.Errorf("没有可参与灰度的运行中交易员")
+		}
+		canaryIDs, controlIDs = selectCanaries(eligible, cfg.Percent, cfg.Template)
+	}
+
+	now := time.Now()
+	rollout := &TemplateRollout{
+		ID:        fmt.Sprintf("%s-%d", cfg.Template, now.Unix()),
+		UserID:    userID,
+		Config:    cfg,
+		Status:    RolloutRunning,
+		StartedAt: now,
+	}
+	for _, id := range controlIDs {
+		at := traders[id]
+		prev := at.GetSystemPromptTemplate()
+		counts := at.GetTemplateConformance(prev)
+		rollout.Control = append(rollout.Control, RolloutMember{
+			TraderID:        id,
+			PrevTemplate:    prev,
+			StartEquity:     latestEquity(at),
+			StartResponses:  counts.Responses,
+			StartConformant: counts.Conformant,
+		})
+	}
+	for _, id := range canaryIDs {
+		at := traders[id]
+		prev := at.GetSystemPromptTemplate()
+		baseline := at.GetTemplateConformance(prev)
+		counts := at.GetTemplateConformance(cfg.Template)
+		rollout.Canary = append(rollout.Canary, RolloutMember{
+			TraderID:           id,
+			PrevTemplate:       prev,
+			StartEquity:        latestEquity(at),
+			StartResponses:     counts.Responses,
+			StartConformant:    counts.Conformant,
+			BaselineResponses:  baseline.Responses,
+			BaselineConformant: baseline.Conformant,
+		})
+		at.SetSystemPromptTemplate(cfg.Template)
+		log.Printf("🐤 [%s] 模板灰度 %s: %s → %s", id, rollout.ID, prev, cfg.Template)
+	}
+
+	tm.rollout = rollout
+	tm.saveTemplateRollout(database)
+	log.Printf("🐤 模板灰度 %s 开始: 灰度 %d 个交易员，对照 %d 个", rollout.ID, len(rollout.Canary), len(rollout.Control))
+
+	stop := make(chan struct{})
+	tm.rolloutStop = stop
+	go tm.monitorTemplateRollout(database, stop)
+	return copyRollout(rollout), nil
+}
+
+// monitorTemplateRollout 定期评估灰度，直到推广、回滚或中止
+func (tm *TraderManager) monitorTemplateRollout(database *config.Database, stop chan struct{}) {
+	ticker := time.NewTicker(rolloutCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if done := tm.EvaluateTemplateRollout(database); done {
+				return
+			}
+		}
+	}
+}
+
+// refreshMember 更新成员在灰度期间的合规计数和收益率，交易员已不存在时返回 false
+func (tm *TraderManager) refreshMember(m *RolloutMember, template string) (*trader.AutoTrader, bool) {
+	at, err := tm.GetTrader(m.TraderID)
+	if err != nil {
+		return nil, false
+	}
+	counts := at.GetTemplateConformance(template)
+	m.Responses = counts.Responses - m.StartResponses
+	m.Conformant = counts.Conformant - m.StartConformant
+	if equity := latestEquity(at); m.StartEquity > 0 && equity > 0 {
+		m.ReturnPct = (equity - m.StartEquity) / m.StartEquity * 100
+	}
+	return at, true
+}
+
+// EvaluateTemplateRollout 评估进行中的灰度并自动推广或回滚，灰度已结束时返回 true
+func (tm *TraderManager) EvaluateTemplateRollout(database *config.Database) bool {
+	tm.rolloutMu.Lock()
+	defer tm.rolloutMu.Unlock()
+
+	r := tm.rollout
+	if r == nil || r.Status != RolloutRunning {
+		return true
+	}
+
+	var eval RolloutEvaluation
+	switched := ""
+	for i := range r.Canary {
+		at, ok := tm.refreshMember(&r.Canary[i], r.Config.Template)
+		if !ok {
+			continue
+		}
+		if current := at.GetSystemPromptTemplate(); current != r.Config.Template && switched == "" {
+			switched = fmt.Sprintf("灰度交易员 %s 已切换到模板 %s（输出不合规自动切换或人工修改）", r.Canary[i].TraderID, current)
+		}
+	}
+	for i := range r.Control {
+		tm.refreshMember(&r.Control[i], r.Control[i].PrevTemplate)
+	}
+
+	if switched != "" {
+		eval = RolloutEvaluation{Time: time.Now(), Verdict: RolloutVerdictRollback, Reason: switched}
+	} else {
+		eval = evaluateRollout(r.Config, r.Canary, r.Control, time.Since(r.StartedAt))
+	}
+	r.LastEvaluation = &eval
+
+	switch eval.Verdict {
+	case RolloutVerdictPromote:
+		tm.promoteRollout(database, r)
+	case RolloutVerdictRollback:
+		tm.rollbackRollout(r, RolloutRolledBack, eval.Reason)
+	default:
+		log.Printf("🐤 模板灰度 %s 评估: %s（合规率差 %+.1f，收益率差 %+.2f）", r.ID, eval.Reason, eval.ConformanceDelta, eval.ReturnDelta)
+	}
+	tm.saveTemplateRollout(database)
+	return r.Status != RolloutRunning
+}
+
+// promoteRollout 推广新模板到灰度组和对照组，并写入数据库
+func (tm *TraderManager) promoteRollout(database *config.Database, r *TemplateRollout) {
+	members := append(append([]RolloutMember(nil), r.Canary...), r.Control...)
+	for _, m := range members {
+		if at, err := tm.GetTrader(m.TraderID); err == nil {
+			at.SetSystemPromptTemplate(r.Config.Template)
+		}
+		if database != nil {
+			if err := database.UpdateTraderSystemPromptTemplate(m.TraderID, r.Config.Template); err != nil {
+				log.Printf("⚠️  [%s] 保存推广模板失败: %v", m.TraderID, err)
+			}
+		}
+	}
+	r.Status = RolloutPromoted
+	r.FinishedAt = time.Now()
+	r.Reason = r.LastEvaluation.Reason
+	log.Printf("✅ 模板灰度 %s 已推广到 %d 个交易员: %s", r.ID, len(members), r.Reason)
+}
+
+// rollbackRollout 恢复灰度交易员原来的模板（灰度期间未写数据库，无需回写）
+func (tm *TraderManager) rollbackRollout(r *TemplateRollout, status, reason string) {
+	for _, m := range r.Canary {
+		if at, err := tm.GetTrader(m.TraderID); err == nil {
+			at.SetSystemPromptTemplate(m.PrevTemplate)
+		}
+	}
+	r.Status = status
+	r.FinishedAt = time.Now()
+	r.Reason = reason
+	log.Printf("↩️  模板灰度 %s 已回滚（%s）: %s", r.ID, status, reason)
+}
+
+// AbortTemplateRollout 人工中止进行中的灰度并恢复原模板
+func (tm *TraderManager) AbortTemplateRollout(database *config.Database, reason string) (*TemplateRollout, error) {
+	tm.rolloutMu.Lock()
+	defer tm.rolloutMu.Unlock()
+
+	r := tm.rollout
+	if r == nil || r.Status != RolloutRunning {
+		return nil, fmt.Errorf("没有进行中的模板灰度")
+	}
+	if reason == "" {
+		reason = "人工中止"
+	}
+	if tm.rolloutStop != nil {
+		close(tm.rolloutStop)
+		tm.rolloutStop = nil
+	}
+	tm.rollbackRollout(r, RolloutAborted, reason)
+	tm.saveTemplateRollout(database)
+	return copyRollout(r), nil
+}
+
+// GetTemplateRollout 获取当前（或最近一次）模板灰度
+func (tm *TraderManager) GetTemplateRollout() *TemplateRollout {
+	tm.rolloutMu.Lock()
+	defer tm.rolloutMu.Unlock()
+	if tm.rollout == nil {
+		return nil
+	}
+	return copyRollout(tm.rollout)
+}
+
+// RecoverTemplateRollout 启动时加载上次的灰度记录
+// 灰度期间的模板只在内存中生效，重启后交易员已恢复数据库中的模板，因此进行中的灰度标记为中止
+func (tm *TraderManager) RecoverTemplateRollout(database *config.Database) {
+	value, err := database.GetSystemConfig(templateRolloutKey)
+	if err != nil || value == "" {
+		return
+	}
+	var r TemplateRollout
+	if err := json.Unmarshal([]byte(value), &r); err != nil {
+		log.Printf("⚠️  解析模板灰度记录失败: %v", err)
+		return
+	}
+
+	tm.rolloutMu.Lock()
+	defer tm.rolloutMu.Unlock()
+	tm.rollout = &r
+	if r.Status == RolloutRunning {
+		r.Status = RolloutAborted
+		r.FinishedAt = time.Now()
+		r.Reason = "服务重启，灰度中止（交易员已恢复数据库中的模板）"
+		log.Printf("⚠️  模板灰度 %s: %s", r.ID, r.Reason)
+		tm.saveTemplateRollout(database)
+	}
+}
+
+// saveTemplateRollout 持久化灰度状态（调用方持有 rolloutMu）
+func (tm *TraderManager) saveTemplateRollout(database *config.Database) {
+	if database == nil || tm.rollout == nil {
+		return
+	}
+	data, err := json.Marshal(tm.rollout)
+	if err != nil {
+		return
+	}
+	if err := database.SetSystemConfig(templateRolloutKey, string(data)); err != nil {
+		log.Printf("⚠️  保存模板灰度状态失败: %v", err)
+	}
+}
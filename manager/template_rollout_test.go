@@ -0,0 +1,53 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSelectCanaries(t *testing.T) {
+	eligible := []string{"t1", "t2", "t3", "t4", "t5", "t6", "t7", "t8", "t9", "t10"}
+
+	canary, control := selectCanaries(eligible, 20, "aggressive")
+	if len(canary) != 2 || len(control) != 8 {
+		t.Fatalf("20%% of 10 = %d canary / %d control, want 2/8", len(canary), len(control))
+	}
+	again, _ := selectCanaries(eligible, 20, "aggressive")
+	if !reflect.DeepEqual(canary, again) {
+		t.Errorf("selection not stable: %v vs %v", canary, again)
+	}
+	if one, _ := selectCanaries(eligible, 1, "aggressive"); len(one) != 1 {
+		t.Errorf("tiny percent = %d canaries, want at least 1", len(one))
+	}
+}
+
+func TestEvaluateRollout(t *testing.T) {
+	cfg := TemplateRolloutConfig{Template: "aggressive", MinCycles: 10, MaxHours: 48, MaxConformanceDropPct: 10, MaxReturnDropPct: 2}
+	member := func(responses, conformant int, ret float64) RolloutMember {
+		return RolloutMember{Responses: responses, Conformant: conformant, ReturnPct: ret, BaselineResponses: 20, BaselineConformant: 19}
+	}
+
+	tests := []struct {
+		name    string
+		canary  []RolloutMember
+		control []RolloutMember
+		elapsed time.Duration
+		want    string
+	}{
+		{"样本不足继续观察", []RolloutMember{member(5, 5, 0)}, []RolloutMember{member(5, 5, 0)}, time.Hour, RolloutVerdictContinue},
+		{"超时样本不足回滚", []RolloutMember{member(5, 5, 0)}, nil, 49 * time.Hour, RolloutVerdictRollback},
+		{"合规率下降回滚", []RolloutMember{member(10, 7, 1)}, []RolloutMember{member(10, 10, 0)}, time.Hour, RolloutVerdictRollback},
+		{"收益率落后回滚", []RolloutMember{member(10, 10, -3)}, []RolloutMember{member(10, 10, 0.5)}, time.Hour, RolloutVerdictRollback},
+		{"均在阈值内推广", []RolloutMember{member(12, 11, 0.5)}, []RolloutMember{member(12, 11, 1)}, time.Hour, RolloutVerdictPromote},
+		{"无对照组与原模板历史比较", []RolloutMember{member(10, 6, 0)}, nil, time.Hour, RolloutVerdictRollback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluateRollout(cfg, tt.canary, tt.control, tt.elapsed)
+			if got.Verdict != tt.want {
+				t.Errorf("verdict = %s (%s), want %s", got.Verdict, got.Reason, tt.want)
+			}
+		})
+	}
+}
@@ -26,6 +26,13 @@ type TraderManager struct {
 	traders          map[string]*trader.AutoTrader // key: trader ID
 	competitionCache *CompetitionCache
 	mu               sync.RWMutex
+
+	rollout     *TemplateRollout // 当前（或最近一次）模板灰度
+	rolloutStop chan struct{}    // 停止灰度评估协程
+	rolloutMu   sync.Mutex
+
+	rebalancePlans map[string]*CapitalRebalancePlan // 各用户的资金再平衡计划（key: user ID）
+	rebalanceMu    sync.Mutex
 }
 
 // NewTraderManager 创建trader管理器
@@ -478,8 +485,8 @@ func (tm *TraderManager) GetComparisonData() (map[string]interface{}, error) {
 			"total_pnl_pct":   account["total_pnl_pct"],
 			"position_count":  account["position_count"],
 			"margin_used_pct": account["margin_used_pct"],
-			"call_count":      status["call_count"],
-			"is_running":      status["is_running"],
+			"call_count":      status.CallCount,
+			"is_running":      status.IsRunning,
 		})
 	}
 
@@ -599,7 +606,7 @@ func (tm *TraderManager) getConcurrentTraderData(traders []*trader.AutoTrader) [
 					"total_pnl_pct":   account["total_pnl_pct"],
 					"position_count":  account["position_count"],
 					"margin_used_pct": account["margin_used_pct"],
-					"is_running":      status["is_running"],
+					"is_running":      status.IsRunning,
 				}
 			case err := <-errorChan:
 				// 获取账户信息失败
@@ -614,7 +621,7 @@ func (tm *TraderManager) getConcurrentTraderData(traders []*trader.AutoTrader) [
 					"total_pnl_pct":   0.0,
 					"position_count":  0,
 					"margin_used_pct": 0.0,
-					"is_running":      status["is_running"],
+					"is_running":      status.IsRunning,
 					"error":           "账户数据获取失败",
 				}
 			case <-ctx.Done():
@@ -630,7 +637,7 @@ func (tm *TraderManager) getConcurrentTraderData(traders []*trader.AutoTrader) [
 					"total_pnl_pct":   0.0,
 					"position_count":  0,
 					"margin_used_pct": 0.0,
-					"is_running":      status["is_running"],
+					"is_running":      status.IsRunning,
 					"error":           "获取超时",
 				}
 			}
@@ -649,6 +656,28 @@ func (tm *TraderManager) getConcurrentTraderData(traders []*trader.AutoTrader) [
 	return results
 }
 
+// GetTraderSummaries 并发获取指定交易员的汇总（单个交易员账户数据最多等待3秒，结果按传入顺序返回）
+func (tm *TraderManager) GetTraderSummaries(traderIDs []string) []trader.TraderSummary {
+	summaries := make([]trader.TraderSummary, len(traderIDs))
+	var wg sync.WaitGroup
+	for i, id := range traderIDs {
+		t, err := tm.GetTrader(id)
+		if err != nil {
+			summaries[i] = trader.TraderSummary{TraderID: id, HealthFlags: []string{trader.HealthAccountUnavailable}}
+			continue
+		}
+		wg.Add(1)
+		go func(index int, t *trader.AutoTrader) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			summaries[index] = t.GetSummary(ctx)
+		}(i, t)
+	}
+	wg.Wait()
+	return summaries
+}
+
 // GetTopTradersData 获取前5名交易员数据（用于表现对比）
 func (tm *TraderManager) GetTopTradersData() (map[string]interface{}, error) {
 	// 复用竞赛数据缓存，因为前5名是从全部数据中筛选出来的
@@ -0,0 +1,62 @@
+package market
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// analysisCacheEntry 已计算的市场数据（按币种+分析周期缓存）
+type analysisCacheEntry struct {
+	data       *Data
+	computedAt time.Time
+}
+
+var analysisCache sync.Map // map[string]*analysisCacheEntry
+
+func analysisCacheKey(symbol string, timeframes []string) string {
+	return Normalize(symbol) + "|" + strings.Join(timeframes, ",")
+}
+
+// CandleDuration 最短分析周期的K线时长（即"一根K线"的缓存上限，周期无效时返回0）
+func CandleDuration(timeframes []string) time.Duration {
+	if len(timeframes) == 0 {
+		timeframes = DefaultTimeframes
+	}
+	d, err := IntervalDuration(timeframes[0])
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// GetFreshWithTimeframes 重新计算市场数据（持仓币种使用），结果写入缓存供候选币种复用
+func GetFreshWithTimeframes(symbol string, timeframes []string) (*Data, error) {
+	data, err := GetWithTimeframes(symbol, timeframes)
+	if err != nil {
+		return nil, err
+	}
+	analysisCache.Store(analysisCacheKey(symbol, timeframes), &analysisCacheEntry{data: data, computedAt: now()})
+	return cloneData(data), nil
+}
+
+// GetCachedWithTimeframes 获取不超过 maxAge 的缓存市场数据（候选币种使用），缓存过期或 maxAge<=0 时重新计算
+// 返回值 cached 表示是否命中缓存
+func GetCachedWithTimeframes(symbol string, timeframes []string, maxAge time.Duration) (data *Data, cached bool, err error) {
+	if maxAge > 0 {
+		if value, ok := analysisCache.Load(analysisCacheKey(symbol, timeframes)); ok {
+			entry := value.(*analysisCacheEntry)
+			if since(entry.computedAt) < maxAge {
+				return cloneData(entry.data), true, nil
+			}
+		}
+	}
+	data, err = GetFreshWithTimeframes(symbol, timeframes)
+	return data, false, err
+}
+
+// cloneData 浅拷贝：缓存被多个交易员共享，调用方会按各自指标集改写 Indicators 等顶层字段
+func cloneData(data *Data) *Data {
+	c := *data
+	return &c
+}
@@ -0,0 +1,59 @@
+package market
+
+import (
+	"nofx/clock"
+	"testing"
+	"time"
+)
+
+// countingProvider 记录计算次数的市场数据来源
+type countingProvider struct{ calls int }
+
+func (p *countingProvider) Get(symbol string) (*Data, error) {
+	p.calls++
+	return &Data{Symbol: symbol, CurrentPrice: float64(100 + p.calls)}, nil
+}
+
+func TestGetCachedWithTimeframes(t *testing.T) {
+	manual := clock.NewManual(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	SetClock(manual)
+	defer SetClock(clock.System)
+	p := &countingProvider{}
+	SetProvider(p)
+	defer SetProvider(nil)
+	analysisCache.Delete(analysisCacheKey("CACHEUSDT", DefaultTimeframes))
+
+	maxAge := CandleDuration(DefaultTimeframes)
+	if maxAge != 3*time.Minute {
+		t.Fatalf("CandleDuration = %s, want 3m", maxAge)
+	}
+
+	data, cached, err := GetCachedWithTimeframes("CACHEUSDT", DefaultTimeframes, maxAge)
+	if err != nil || cached || p.calls != 1 {
+		t.Fatalf("首次获取应重新计算: cached=%v calls=%d err=%v", cached, p.calls, err)
+	}
+	data.CurrentPrice = 0 // 调用方改写返回值不应影响缓存
+
+	manual.Advance(2 * time.Minute)
+	data, cached, _ = GetCachedWithTimeframes("CACHEUSDT", DefaultTimeframes, maxAge)
+	if !cached || p.calls != 1 || data.CurrentPrice != 101 {
+		t.Errorf("一根K线内应复用缓存: cached=%v calls=%d price=%v", cached, p.calls, data.CurrentPrice)
+	}
+
+	if _, cached, _ = GetCachedWithTimeframes("CACHEUSDT", DefaultTimeframes, 0); cached || p.calls != 2 {
+		t.Errorf("maxAge=0 时应重新计算: cached=%v calls=%d", cached, p.calls)
+	}
+
+	manual.Advance(2 * time.Minute)
+	if _, cached, _ = GetCachedWithTimeframes("CACHEUSDT", DefaultTimeframes, maxAge); !cached {
+		t.Error("maxAge=0 的重新计算应刷新缓存时间")
+	}
+	manual.Advance(maxAge)
+	if _, cached, _ = GetCachedWithTimeframes("CACHEUSDT", DefaultTimeframes, maxAge); cached || p.calls != 3 {
+		t.Errorf("超过一根K线应重新计算: cached=%v calls=%d", cached, p.calls)
+	}
+
+	if _, err := GetFreshWithTimeframes("CACHEUSDT", DefaultTimeframes); err != nil || p.calls != 4 {
+		t.Errorf("持仓币种应始终重新计算: calls=%d err=%v", p.calls, err)
+	}
+}
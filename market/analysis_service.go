@@ -0,0 +1,112 @@
+package market
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// maxBatchSymbols 单次批量请求的最多币种数
+const maxBatchSymbols = 100
+
+type analysisBatchRequest struct {
+	Symbols []string `json:"symbols"`
+}
+
+type analysisBatchResponse struct {
+	Data   map[string]dataEnvelope `json:"data"`
+	Errors map[string]string       `json:"errors,omitempty"`
+}
+
+// NewAnalysisHandler 市场分析服务的HTTP接口（在独立进程中运行，交易进程通过 RemoteProvider 调用）
+//
+//	GET  /healthz                    - 存活检查
+//	GET  /market/data?symbol=BTCUSDT - 单币种市场数据
+//	POST /market/batch               - 批量（{"symbols": [...]}，并行计算）
+//
+// token 非空时要求 Authorization: Bearer <token>
+func NewAnalysisHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeAnalysisJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("/market/data", func(w http.ResponseWriter, r *http.Request) {
+		symbol := r.URL.Query().Get("symbol")
+		if symbol == "" {
+			writeAnalysisError(w, http.StatusBadRequest, "缺少 symbol 参数")
+			return
+		}
+		data, err := analyzeLocal(symbol)
+		if err != nil {
+			writeAnalysisError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		writeAnalysisJSON(w, http.StatusOK, newDataEnvelope(data))
+	})
+	mux.HandleFunc("/market/batch", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeAnalysisError(w, http.StatusMethodNotAllowed, "仅支持 POST")
+			return
+		}
+		var req analysisBatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAnalysisError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if len(req.Symbols) == 0 || len(req.Symbols) > maxBatchSymbols {
+			writeAnalysisError(w, http.StatusBadRequest, "symbols 数量必须在 1~100 之间")
+			return
+		}
+		writeAnalysisJSON(w, http.StatusOK, analyzeBatch(req.Symbols))
+	})
+	return requireAnalysisToken(token, mux)
+}
+
+// analyzeBatch 并行计算多个币种
+func analyzeBatch(symbols []string) analysisBatchResponse {
+	resp := analysisBatchResponse{Data: make(map[string]dataEnvelope), Errors: make(map[string]string)}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, symbol := range symbols {
+		wg.Add(1)
+		go func(symbol string) {
+			defer wg.Done()
+			data, err := analyzeLocal(symbol)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				resp.Errors[symbol] = err.Error()
+				return
+			}
+			resp.Data[data.Symbol] = newDataEnvelope(data)
+		}(Normalize(symbol))
+	}
+	wg.Wait()
+	return resp
+}
+
+func requireAnalysisToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if r.URL.Path != "/healthz" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			writeAnalysisError(w, http.StatusUnauthorized, "未授权")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeAnalysisJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAnalysisError(w http.ResponseWriter, status int, msg string) {
+	writeAnalysisJSON(w, status, map[string]string{"error": msg})
+}
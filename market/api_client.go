@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"nofx/hook"
+	"nofx/loglevel"
 	"strconv"
 	"time"
 )
@@ -83,7 +84,7 @@ func (c *APIClient) GetKlines(symbol, interval string, limit int) ([]Kline, erro
 	var klineResponses []KlineResponse
 	err = json.Unmarshal(body, &klineResponses)
 	if err != nil {
-		log.Printf("获取K线数据失败,响应内容: %s", string(body))
+		loglevel.Warnf(loglevel.Market, "获取K线数据失败,响应内容: %s", string(body))
 		return nil, err
 	}
 
@@ -91,7 +92,53 @@ func (c *APIClient) GetKlines(symbol, interval string, limit int) ([]Kline, erro
 	for _, kr := range klineResponses {
 		kline, err := parseKline(kr)
 		if err != nil {
-			log.Printf("解析K线数据失败: %v", err)
+			loglevel.Warnf(loglevel.Market, "解析K线数据失败: %v", err)
+			continue
+		}
+		klines = append(klines, kline)
+	}
+
+	return klines, nil
+}
+
+// GetKlinesRange 获取指定时间区间的K线（时间为毫秒时间戳，单次最多1500根）
+func (c *APIClient) GetKlinesRange(symbol, interval string, startTime, endTime int64) ([]Kline, error) {
+	url := fmt.Sprintf("%s/fapi/v1/klines", baseURL)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	q.Add("symbol", symbol)
+	q.Add("interval", interval)
+	q.Add("startTime", strconv.FormatInt(startTime, 10))
+	q.Add("endTime", strconv.FormatInt(endTime, 10))
+	q.Add("limit", "1500")
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var klineResponses []KlineResponse
+	if err := json.Unmarshal(body, &klineResponses); err != nil {
+		loglevel.Warnf(loglevel.Market, "获取K线数据失败,响应内容: %s", string(body))
+		return nil, err
+	}
+
+	klines := make([]Kline, 0, len(klineResponses))
+	for _, kr := range klineResponses {
+		kline, err := parseKline(kr)
+		if err != nil {
+			loglevel.Warnf(loglevel.Market, "解析K线数据失败: %v", err)
 			continue
 		}
 		klines = append(klines, kline)
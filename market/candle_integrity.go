@@ -0,0 +1,165 @@
+package market
+
+import (
+	"fmt"
+	"sort"
+)
+
+// spikeATRMultiple 影线/收盘偏离前收盘超过该倍数的典型波幅视为异常尖刺
+const spikeATRMultiple = 8.0
+
+// CandleQualityReport K线数据质量检查结果
+type CandleQualityReport struct {
+	Interval      string   `json:"interval"`
+	Total         int      `json:"total"`            // 检查后的K线数
+	Reordered     bool     `json:"reordered"`        // 时间戳非单调，已重新排序
+	Duplicates    int      `json:"duplicates"`       // 重复时间戳（保留最新一根）
+	Gaps          int      `json:"gaps"`             // 缺失的K线根数
+	ZeroVolume    int      `json:"zero_volume"`      // 零成交量K线
+	InvalidOHLC   int      `json:"invalid_ohlc"`     // 高低价与开收不一致（已修正）
+	SpikesClamped int      `json:"spikes_clamped"`   // 异常影线（已截断）
+	SpikesFlagged int      `json:"spikes_flagged"`   // 异常收盘价（仅标记）
+	Issues        []string `json:"issues,omitempty"` // 问题描述
+}
+
+// Clean 是否没有任何问题
+func (r *CandleQualityReport) Clean() bool {
+	return len(r.Issues) == 0
+}
+
+// SanitizeKlines 检查并修正K线数据：
+// 1. 时间戳非单调 → 按开盘时间排序，重复时间戳保留最后一根
+// 2. 高低价与开收不一致 → 修正高低价
+// 3. 影线偏离前收盘超过 spikeATRMultiple 倍典型波幅 → 截断影线
+// 4. 收盘价异常跳变、零成交量、缺失K线 → 仅标记（无法可靠修正）
+// 典型波幅取高低价差的中位数，避免被尖刺本身放大
+func SanitizeKlines(klines []Kline, interval string) ([]Kline, *CandleQualityReport) {
+	report := &CandleQualityReport{Interval: interval}
+	if len(klines) == 0 {
+		return klines, report
+	}
+
+	result := make([]Kline, len(klines))
+	copy(result, klines)
+
+	// 1. 时间戳排序与去重
+	if !sort.SliceIsSorted(result, func(i, j int) bool { return result[i].OpenTime < result[j].OpenTime }) {
+		report.Reordered = true
+		sort.SliceStable(result, func(i, j int) bool { return result[i].OpenTime < result[j].OpenTime })
+	}
+	deduped := result[:0]
+	for _, k := range result {
+		if n := len(deduped); n > 0 && deduped[n-1].OpenTime == k.OpenTime {
+			deduped[n-1] = k
+			report.Duplicates++
+			continue
+		}
+		deduped = append(deduped, k)
+	}
+	result = deduped
+
+	// 缺失K线
+	if step, err := IntervalDuration(interval); err == nil {
+		stepMs := step.Milliseconds()
+		for i := 1; i < len(result); i++ {
+			if missing := (result[i].OpenTime-result[i-1].OpenTime)/stepMs - 1; missing > 0 {
+				report.Gaps += int(missing)
+			}
+		}
+	}
+
+	// 2. 高低价一致性
+	for i := range result {
+		k := &result[i]
+		if k.Volume == 0 {
+			report.ZeroVolume++
+		}
+		hi, lo := maxFloat(k.Open, k.Close), minFloat(k.Open, k.Close)
+		if k.High < hi || k.Low > lo || k.Low <= 0 {
+			report.InvalidOHLC++
+			if k.High < hi {
+				k.High = hi
+			}
+			if k.Low > lo || k.Low <= 0 {
+				k.Low = lo
+			}
+		}
+	}
+
+	// 3. 尖刺检测
+	typicalRange := medianRange(result)
+	if typicalRange > 0 {
+		limit := spikeATRMultiple * typicalRange
+		for i := 1; i < len(result); i++ {
+			k := &result[i]
+			prevClose := result[i-1].Close
+			if k.High-maxFloat(prevClose, maxFloat(k.Open, k.Close)) > limit {
+				k.High = maxFloat(k.Open, k.Close) + typicalRange
+				report.SpikesClamped++
+			}
+			if minFloat(prevClose, minFloat(k.Open, k.Close))-k.Low > limit {
+				k.Low = minFloat(k.Open, k.Close) - typicalRange
+				if k.Low <= 0 {
+					k.Low = minFloat(k.Open, k.Close)
+				}
+				report.SpikesClamped++
+			}
+			if diff := k.Close - prevClose; diff > limit || -diff > limit {
+				report.SpikesFlagged++
+			}
+		}
+	}
+
+	report.Total = len(result)
+	if report.Reordered {
+		report.Issues = append(report.Issues, "时间戳非单调，已重新排序")
+	}
+	if report.Duplicates > 0 {
+		report.Issues = append(report.Issues, fmt.Sprintf("重复K线 %d 根", report.Duplicates))
+	}
+	if report.Gaps > 0 {
+		report.Issues = append(report.Issues, fmt.Sprintf("缺失K线 %d 根", report.Gaps))
+	}
+	if report.ZeroVolume > 0 {
+		report.Issues = append(report.Issues, fmt.Sprintf("零成交量K线 %d 根", report.ZeroVolume))
+	}
+	if report.InvalidOHLC > 0 {
+		report.Issues = append(report.Issues, fmt.Sprintf("高低价异常 %d 根（已修正）", report.InvalidOHLC))
+	}
+	if report.SpikesClamped > 0 {
+		report.Issues = append(report.Issues, fmt.Sprintf("异常影线 %d 处（已截断）", report.SpikesClamped))
+	}
+	if report.SpikesFlagged > 0 {
+		report.Issues = append(report.Issues, fmt.Sprintf("收盘价异常跳变 %d 根", report.SpikesFlagged))
+	}
+	return result, report
+}
+
+// medianRange 高低价差的中位数
+func medianRange(klines []Kline) float64 {
+	ranges := make([]float64, 0, len(klines))
+	for _, k := range klines {
+		if r := k.High - k.Low; r > 0 {
+			ranges = append(ranges, r)
+		}
+	}
+	if len(ranges) == 0 {
+		return 0
+	}
+	sort.Float64s(ranges)
+	return ranges[len(ranges)/2]
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
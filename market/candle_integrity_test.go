@@ -0,0 +1,76 @@
+package market
+
+import "testing"
+
+func TestSanitizeKlines(t *testing.T) {
+	const step = int64(3 * 60 * 1000)
+	base := func(i int64, price float64) Kline {
+		return Kline{OpenTime: i * step, Open: price, High: price + 1, Low: price - 1, Close: price, Volume: 10}
+	}
+
+	var clean []Kline
+	for i := int64(0); i < 20; i++ {
+		clean = append(clean, base(i, 100))
+	}
+
+	tests := []struct {
+		name   string
+		mutate func([]Kline) []Kline
+		check  func(t *testing.T, out []Kline, r *CandleQualityReport)
+	}{
+		{
+			name:   "干净数据不报告问题",
+			mutate: func(k []Kline) []Kline { return k },
+			check: func(t *testing.T, out []Kline, r *CandleQualityReport) {
+				if !r.Clean() {
+					t.Errorf("issues = %v", r.Issues)
+				}
+			},
+		},
+		{
+			name: "乱序和重复时间戳",
+			mutate: func(k []Kline) []Kline {
+				k[3], k[4] = k[4], k[3]
+				return append(k, k[len(k)-1])
+			},
+			check: func(t *testing.T, out []Kline, r *CandleQualityReport) {
+				if !r.Reordered || r.Duplicates != 1 || len(out) != 20 {
+					t.Errorf("reordered=%v duplicates=%d len=%d", r.Reordered, r.Duplicates, len(out))
+				}
+			},
+		},
+		{
+			name: "异常影线被截断",
+			mutate: func(k []Kline) []Kline {
+				k[10].High = 1000
+				return k
+			},
+			check: func(t *testing.T, out []Kline, r *CandleQualityReport) {
+				if r.SpikesClamped != 1 || out[10].High > 110 {
+					t.Errorf("clamped=%d high=%.2f", r.SpikesClamped, out[10].High)
+				}
+			},
+		},
+		{
+			name: "缺失K线、零成交量和高低价异常",
+			mutate: func(k []Kline) []Kline {
+				k[5].Volume = 0
+				k[6].High = 50
+				return append(k[:12], k[14:]...)
+			},
+			check: func(t *testing.T, out []Kline, r *CandleQualityReport) {
+				if r.Gaps != 2 || r.ZeroVolume != 1 || r.InvalidOHLC != 1 || out[6].High < out[6].Close {
+					t.Errorf("report = %+v", r)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := tt.mutate(append([]Kline(nil), clean...))
+			out, report := SanitizeKlines(input, "3m")
+			tt.check(t, out, report)
+		})
+	}
+}
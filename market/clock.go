@@ -0,0 +1,29 @@
+package market
+
+import (
+	"nofx/clock"
+	"time"
+)
+
+var (
+	marketClock clock.Clock       = clock.System
+	requestIDs  clock.IDGenerator = clock.NewNanoIDs(clock.System)
+)
+
+// SetClock 替换market包使用的时钟（缓存过期、连接健康、预热耗时等）
+func SetClock(c clock.Clock) {
+	marketClock = c
+}
+
+// SetIDGenerator 替换WebSocket订阅请求的ID生成器
+func SetIDGenerator(g clock.IDGenerator) {
+	requestIDs = g
+}
+
+func now() time.Time {
+	return marketClock.Now()
+}
+
+func since(t time.Time) time.Duration {
+	return clock.Since(marketClock, t)
+}
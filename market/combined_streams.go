@@ -3,7 +3,7 @@ package market
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"nofx/loglevel"
 	"strings"
 	"sync"
 	"time"
@@ -15,14 +15,23 @@ type CombinedStreamsClient struct {
 	conn        *websocket.Conn
 	mu          sync.RWMutex
 	subscribers map[string]chan []byte
+	subscribed  map[string]bool // 已订阅的流（重连后重新订阅）
 	reconnect   bool
 	done        chan struct{}
 	batchSize   int // 每批订阅的流数量
+
+	// 健康指标
+	shardID        int
+	messageCount   int64
+	lastMessageAt  time.Time
+	reconnectCount int
+	connectedAt    time.Time
 }
 
 func NewCombinedStreamsClient(batchSize int) *CombinedStreamsClient {
 	return &CombinedStreamsClient{
 		subscribers: make(map[string]chan []byte),
+		subscribed:  make(map[string]bool),
 		reconnect:   true,
 		done:        make(chan struct{}),
 		batchSize:   batchSize,
@@ -42,28 +51,45 @@ func (c *CombinedStreamsClient) Connect() error {
 
 	c.mu.Lock()
 	c.conn = conn
+	c.connectedAt = now()
+	streams := make([]string, 0, len(c.subscribed))
+	for stream := range c.subscribed {
+		streams = append(streams, stream)
+	}
 	c.mu.Unlock()
 
-	log.Println("组合流WebSocket连接成功")
+	loglevel.Infof(loglevel.WS, "组合流WebSocket连接成功 [shard %d]", c.shardID)
 	go c.readMessages()
 
+	// 重连后恢复之前的订阅
+	if len(streams) > 0 {
+		loglevel.Infof(loglevel.WS, "[shard %d] 恢复 %d 个流订阅", c.shardID, len(streams))
+		if err := c.BatchSubscribeStreams(streams); err != nil {
+			loglevel.Warnf(loglevel.WS, "[shard %d] 恢复订阅失败: %v", c.shardID, err)
+		}
+	}
+
 	return nil
 }
 
 // BatchSubscribeKlines 批量订阅K线
 func (c *CombinedStreamsClient) BatchSubscribeKlines(symbols []string, interval string) error {
-	// 将symbols分批处理
-	batches := c.splitIntoBatches(symbols, c.batchSize)
+	streams := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		streams[i] = fmt.Sprintf("%s@kline_%s", strings.ToLower(symbol), interval)
+	}
+	return c.BatchSubscribeStreams(streams)
+}
 
-	for i, batch := range batches {
-		log.Printf("订阅第 %d 批, 数量: %d", i+1, len(batch))
+// BatchSubscribeStreams 分批订阅流
+func (c *CombinedStreamsClient) BatchSubscribeStreams(streams []string) error {
+	// 将streams分批处理
+	batches := c.splitIntoBatches(streams, c.batchSize)
 
-		streams := make([]string, len(batch))
-		for j, symbol := range batch {
-			streams[j] = fmt.Sprintf("%s@kline_%s", strings.ToLower(symbol), interval)
-		}
+	for i, batch := range batches {
+		loglevel.Debugf(loglevel.WS, "订阅第 %d 批, 数量: %d", i+1, len(batch))
 
-		if err := c.subscribeStreams(streams); err != nil {
+		if err := c.subscribeStreams(batch); err != nil {
 			return fmt.Errorf("第 %d 批订阅失败: %v", i+1, err)
 		}
 
@@ -96,20 +122,32 @@ func (c *CombinedStreamsClient) subscribeStreams(streams []string) error {
 	subscribeMsg := map[string]interface{}{
 		"method": "SUBSCRIBE",
 		"params": streams,
-		"id":     time.Now().UnixNano(),
+		"id":     requestIDs.NextID(),
 	}
 
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	// 写锁：gorilla/websocket 不支持并发写
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, stream := range streams {
+		c.subscribed[stream] = true
+	}
 
 	if c.conn == nil {
 		return fmt.Errorf("WebSocket未连接")
 	}
 
-	log.Printf("订阅流: %v", streams)
+	loglevel.Debugf(loglevel.WS, "订阅流: %v", streams)
 	return c.conn.WriteJSON(subscribeMsg)
 }
 
+// StreamCount 当前连接承载的流数量
+func (c *CombinedStreamsClient) StreamCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.subscribed)
+}
+
 func (c *CombinedStreamsClient) readMessages() {
 	for {
 		select {
@@ -127,7 +165,7 @@ func (c *CombinedStreamsClient) readMessages() {
 
 			_, message, err := conn.ReadMessage()
 			if err != nil {
-				log.Printf("读取组合流消息失败: %v", err)
+				loglevel.Warnf(loglevel.WS, "读取组合流消息失败: %v", err)
 				c.handleReconnect()
 				return
 			}
@@ -144,19 +182,21 @@ func (c *CombinedStreamsClient) handleCombinedMessage(message []byte) {
 	}
 
 	if err := json.Unmarshal(message, &combinedMsg); err != nil {
-		log.Printf("解析组合消息失败: %v", err)
+		loglevel.Sampledf(loglevel.WS, loglevel.LevelWarn, "parse_combined", time.Minute, "解析组合消息失败: %v", err)
 		return
 	}
 
-	c.mu.RLock()
+	c.mu.Lock()
+	c.messageCount++
+	c.lastMessageAt = now()
 	ch, exists := c.subscribers[combinedMsg.Stream]
-	c.mu.RUnlock()
+	c.mu.Unlock()
 
 	if exists {
 		select {
 		case ch <- combinedMsg.Data:
 		default:
-			log.Printf("订阅者通道已满: %s", combinedMsg.Stream)
+			loglevel.Sampledf(loglevel.WS, loglevel.LevelWarn, "channel_full:"+combinedMsg.Stream, time.Minute, "订阅者通道已满: %s", combinedMsg.Stream)
 		}
 	}
 }
@@ -174,11 +214,19 @@ func (c *CombinedStreamsClient) handleReconnect() {
 		return
 	}
 
-	log.Println("组合流尝试重新连接...")
+	loglevel.Infof(loglevel.WS, "组合流尝试重新连接... [shard %d]", c.shardID)
 	time.Sleep(3 * time.Second)
 
+	c.mu.Lock()
+	c.reconnectCount++
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	c.mu.Unlock()
+
 	if err := c.Connect(); err != nil {
-		log.Printf("组合流重新连接失败: %v", err)
+		loglevel.Warnf(loglevel.WS, "组合流重新连接失败: %v", err)
 		go c.handleReconnect()
 	}
 }
@@ -200,3 +248,35 @@ func (c *CombinedStreamsClient) Close() {
 		delete(c.subscribers, stream)
 	}
 }
+
+// ShardHealth 单个WS连接（分片）的健康指标
+type ShardHealth struct {
+	ShardID         int       `json:"shard_id"`
+	Connected       bool      `json:"connected"`
+	StreamCount     int       `json:"stream_count"`
+	MessageCount    int64     `json:"message_count"`
+	LastMessageAt   time.Time `json:"last_message_at"`
+	SecondsSinceMsg float64   `json:"seconds_since_msg"`
+	ReconnectCount  int       `json:"reconnect_count"`
+	ConnectedAt     time.Time `json:"connected_at"`
+}
+
+// Health 获取连接健康指标
+func (c *CombinedStreamsClient) Health() ShardHealth {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	health := ShardHealth{
+		ShardID:        c.shardID,
+		Connected:      c.conn != nil,
+		StreamCount:    len(c.subscribed),
+		MessageCount:   c.messageCount,
+		LastMessageAt:  c.lastMessageAt,
+		ReconnectCount: c.reconnectCount,
+		ConnectedAt:    c.connectedAt,
+	}
+	if !c.lastMessageAt.IsZero() {
+		health.SecondsSinceMsg = since(c.lastMessageAt).Seconds()
+	}
+	return health
+}
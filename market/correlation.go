@@ -0,0 +1,191 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 相关性矩阵支持的收益率周期及默认回看K线数
+var correlationLookbacks = map[string]int{
+	"1h": 72, // 3天
+	"4h": 42, // 7天
+	"1d": 30, // 30天
+}
+
+// correlationCacheTTL 各周期K线缓存时间（不需要每个周期都重新拉取）
+var correlationCacheTTL = map[string]time.Duration{
+	"1h": 5 * time.Minute,
+	"4h": 15 * time.Minute,
+	"1d": time.Hour,
+}
+
+type correlationKlineCache struct {
+	Klines    []Kline
+	UpdatedAt time.Time
+}
+
+var correlationCacheMap sync.Map // map["symbol_interval"]*correlationKlineCache
+
+// CorrelationMatrix 收益率相关性矩阵（Matrix[i][j] 为 Symbols[i] 与 Symbols[j] 的皮尔逊相关系数）
+type CorrelationMatrix struct {
+	Interval  string      `json:"interval"`
+	Lookback  int         `json:"lookback"`
+	Symbols   []string    `json:"symbols"`
+	Matrix    [][]float64 `json:"matrix"`
+	Samples   [][]int     `json:"samples"` // 每对币种参与计算的共同收益率样本数
+	Skipped   []string    `json:"skipped"` // 获取K线失败的币种
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// CorrelatedPair 一对币种的相关系数
+type CorrelatedPair struct {
+	A           string  `json:"a"`
+	B           string  `json:"b"`
+	Correlation float64 `json:"correlation"`
+}
+
+// minCorrelationSamples 计算相关系数所需的最少共同样本数
+const minCorrelationSamples = 10
+
+// ValidCorrelationInterval 是否为支持的相关性周期
+func ValidCorrelationInterval(interval string) bool {
+	_, ok := correlationLookbacks[interval]
+	return ok
+}
+
+// GetCorrelationMatrix 计算一组币种在指定周期上的滚动收益率相关性（lookback<=0 使用该周期默认回看）
+func GetCorrelationMatrix(symbols []string, interval string, lookback int) (*CorrelationMatrix, error) {
+	if !ValidCorrelationInterval(interval) {
+		return nil, fmt.Errorf("不支持的相关性周期: %s（可选 1h / 4h / 1d）", interval)
+	}
+	if lookback <= 0 {
+		lookback = correlationLookbacks[interval]
+	}
+
+	result := &CorrelationMatrix{Interval: interval, Lookback: lookback, Skipped: []string{}, UpdatedAt: now()}
+	var series []map[int64]float64
+	for _, symbol := range symbols {
+		klines, err := getCorrelationKlines(symbol, interval, lookback+1)
+		if err != nil || len(klines) < 2 {
+			result.Skipped = append(result.Skipped, symbol)
+			continue
+		}
+		result.Symbols = append(result.Symbols, symbol)
+		series = append(series, timedLogReturns(klines))
+	}
+
+	n := len(result.Symbols)
+	result.Matrix = make([][]float64, n)
+	result.Samples = make([][]int, n)
+	for i := range result.Matrix {
+		result.Matrix[i] = make([]float64, n)
+		result.Samples[i] = make([]int, n)
+	}
+	for i := 0; i < n; i++ {
+		result.Matrix[i][i] = 1
+		result.Samples[i][i] = len(series[i])
+		for j := i + 1; j < n; j++ {
+			corr, samples := pearson(series[i], series[j])
+			result.Matrix[i][j], result.Matrix[j][i] = corr, corr
+			result.Samples[i][j], result.Samples[j][i] = samples, samples
+		}
+	}
+	return result, nil
+}
+
+// HighlyCorrelated 相关系数绝对值不低于阈值的币种对（按绝对值从高到低）
+func (m *CorrelationMatrix) HighlyCorrelated(threshold float64) []CorrelatedPair {
+	pairs := []CorrelatedPair{}
+	for i := range m.Symbols {
+		for j := i + 1; j < len(m.Symbols); j++ {
+			if m.Samples[i][j] >= minCorrelationSamples && math.Abs(m.Matrix[i][j]) >= threshold {
+				pairs = append(pairs, CorrelatedPair{A: m.Symbols[i], B: m.Symbols[j], Correlation: m.Matrix[i][j]})
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return math.Abs(pairs[i].Correlation) > math.Abs(pairs[j].Correlation)
+	})
+	return pairs
+}
+
+// getCorrelationKlines 获取计算相关性用的K线（按周期缓存；4h优先使用WebSocket缓存）
+func getCorrelationKlines(symbol, interval string, limit int) ([]Kline, error) {
+	key := symbol + "_" + interval
+	if cached, ok := correlationCacheMap.Load(key); ok {
+		cache := cached.(*correlationKlineCache)
+		if since(cache.UpdatedAt) < correlationCacheTTL[interval] && len(cache.Klines) >= limit {
+			return cache.Klines[len(cache.Klines)-limit:], nil
+		}
+	}
+
+	var klines []Kline
+	if interval == "4h" && WSMonitorCli != nil {
+		if wsKlines, err := WSMonitorCli.GetCurrentKlines(symbol, "4h"); err == nil && len(wsKlines) >= limit {
+			klines = wsKlines
+		}
+	}
+	if klines == nil {
+		var err error
+		klines, err = NewAPIClient().GetKlines(symbol, interval, limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	correlationCacheMap.Store(key, &correlationKlineCache{Klines: klines, UpdatedAt: now()})
+	if len(klines) > limit {
+		klines = klines[len(klines)-limit:]
+	}
+	return klines, nil
+}
+
+// timedLogReturns 对数收益率（以K线开盘时间为键，便于不同币种按时间对齐）
+func timedLogReturns(klines []Kline) map[int64]float64 {
+	returns := make(map[int64]float64, len(klines))
+	for i := 1; i < len(klines); i++ {
+		prev, cur := klines[i-1].Close, klines[i].Close
+		if prev > 0 && cur > 0 {
+			returns[klines[i].OpenTime] = math.Log(cur / prev)
+		}
+	}
+	return returns
+}
+
+// pearson 按共同时间点计算皮尔逊相关系数，返回系数和样本数（样本不足或无波动时系数为0）
+func pearson(a, b map[int64]float64) (float64, int) {
+	var xs, ys []float64
+	for t, x := range a {
+		if y, ok := b[t]; ok {
+			xs = append(xs, x)
+			ys = append(ys, y)
+		}
+	}
+	n := len(xs)
+	if n < 2 {
+		return 0, n
+	}
+
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(n)
+	meanY /= float64(n)
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0, n
+	}
+	return cov / math.Sqrt(varX*varY), n
+}
@@ -0,0 +1,63 @@
+package market
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPearson(t *testing.T) {
+	base := map[int64]float64{}
+	inverse := map[int64]float64{}
+	flat := map[int64]float64{}
+	for i := int64(0); i < 20; i++ {
+		r := math.Sin(float64(i))
+		base[i] = r
+		inverse[i] = -2 * r
+		flat[i] = 0.01
+	}
+	partial := map[int64]float64{0: base[0], 1: base[1], 100: 0.5}
+
+	tests := []struct {
+		name        string
+		a, b        map[int64]float64
+		want        float64
+		wantSamples int
+	}{
+		{"完全正相关", base, base, 1, 20},
+		{"完全负相关", base, inverse, -1, 20},
+		{"无波动系数为0", base, flat, 0, 20},
+		{"只按共同时间点计算", base, partial, 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, samples := pearson(tt.a, tt.b)
+			if math.Abs(got-tt.want) > 1e-9 || samples != tt.wantSamples {
+				t.Errorf("pearson() = %.4f (%d), want %.4f (%d)", got, samples, tt.want, tt.wantSamples)
+			}
+		})
+	}
+}
+
+func TestHighlyCorrelated(t *testing.T) {
+	m := &CorrelationMatrix{
+		Symbols: []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"},
+		Matrix: [][]float64{
+			{1, 0.85, -0.9},
+			{0.85, 1, 0.3},
+			{-0.9, 0.3, 1},
+		},
+		Samples: [][]int{
+			{40, 40, 40},
+			{40, 40, 40},
+			{40, 40, 5},
+		},
+	}
+
+	pairs := m.HighlyCorrelated(0.8)
+	if len(pairs) != 2 {
+		t.Fatalf("got %d pairs, want 2: %+v", len(pairs), pairs)
+	}
+	if pairs[0].B != "SOLUSDT" || pairs[1].B != "ETHUSDT" {
+		t.Errorf("pairs not sorted by |correlation|: %+v", pairs)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math"
+	"nofx/loglevel"
 	"strconv"
 	"strings"
 	"sync"
@@ -14,65 +15,66 @@ import (
 // FundingRateCache 资金费率缓存结构
 // Binance Funding Rate 每 8 小时才更新一次，使用 1 小时缓存可显著减少 API 调用
 type FundingRateCache struct {
-	Rate      float64
+	Rate            float64
+	NextFundingTime time.Time // 下次资金费结算时间（过了结算时间缓存失效）
+	UpdatedAt       time.Time
+}
+
+// OICache 持仓量缓存结构（短TTL，主要让预热数据被首个决策周期复用）
+type OICache struct {
+	Data      *OIData
 	UpdatedAt time.Time
 }
 
 var (
 	fundingRateMap sync.Map // map[string]*FundingRateCache
 	frCacheTTL     = 1 * time.Hour
+	oiCacheMap     sync.Map // map[string]*OICache
+	oiCacheTTL     = 1 * time.Minute
 )
 
-// Get 获取指定代币的市场数据
-func Get(symbol string) (*Data, error) {
-	var klines3m, klines4h []Kline
-	var err error
+// analyzeLocal 在本进程内获取K线并计算指定代币的市场数据（默认周期）
+func analyzeLocal(symbol string) (*Data, error) {
+	return analyzeTimeframes(symbol, DefaultTimeframes)
+}
+
+// analyzeTimeframes 按分析周期（已排序，至少2个）获取K线并计算市场数据
+// 最短周期作为日内序列，最长周期作为长期背景，其余周期输出摘要
+func analyzeTimeframes(symbol string, timeframes []string) (*Data, error) {
 	// 标准化symbol
 	symbol = Normalize(symbol)
-	// 获取3分钟K线数据 (最近10个)
-	klines3m, err = WSMonitorCli.GetCurrentKlines(symbol, "3m") // 多获取一些用于计算
-	if err != nil {
-		return nil, fmt.Errorf("获取3分钟K线失败: %v", err)
-	}
-
-	// 获取4小时K线数据 (最近10个)
-	klines4h, err = WSMonitorCli.GetCurrentKlines(symbol, "4h") // 多获取用于计算指标
-	if err != nil {
-		return nil, fmt.Errorf("获取4小时K线失败: %v", err)
-	}
-
-	// 检查数据是否为空
-	if len(klines3m) == 0 {
-		return nil, fmt.Errorf("3分钟K线数据为空")
-	}
-	if len(klines4h) == 0 {
-		return nil, fmt.Errorf("4小时K线数据为空")
-	}
-
-	// 计算当前指标 (基于3分钟最新数据)
-	currentPrice := klines3m[len(klines3m)-1].Close
-	currentEMA20 := calculateEMA(klines3m, 20)
-	currentMACD := calculateMACD(klines3m)
-	currentRSI7 := calculateRSI(klines3m, 7)
-
-	// 计算价格变化百分比
-	// 1小时价格变化 = 20个3分钟K线前的价格
-	priceChange1h := 0.0
-	if len(klines3m) >= 21 { // 至少需要21根K线 (当前 + 20根前)
-		price1hAgo := klines3m[len(klines3m)-21].Close
-		if price1hAgo > 0 {
-			priceChange1h = ((currentPrice - price1hAgo) / price1hAgo) * 100
+	shortTF, longTF := timeframes[0], timeframes[len(timeframes)-1]
+
+	// K线数据质量检查（排序去重、修正高低价、截断异常影线），避免单根坏K线污染ATR/区间计算
+	var candleQuality []*CandleQualityReport
+	series := make(map[string][]Kline, len(timeframes))
+	for _, tf := range timeframes {
+		klines, err := currentKlines(symbol, tf)
+		if err != nil {
+			return nil, fmt.Errorf("获取%s K线失败: %v", tf, err)
 		}
-	}
-
-	// 4小时价格变化 = 1个4小时K线前的价格
-	priceChange4h := 0.0
-	if len(klines4h) >= 2 {
-		price4hAgo := klines4h[len(klines4h)-2].Close
-		if price4hAgo > 0 {
-			priceChange4h = ((currentPrice - price4hAgo) / price4hAgo) * 100
+		if len(klines) == 0 {
+			return nil, fmt.Errorf("%s K线数据为空", tf)
 		}
+		klines, q := SanitizeKlines(klines, tf)
+		if !q.Clean() {
+			loglevel.Sampledf(loglevel.Market, loglevel.LevelWarn, "quality:"+symbol+":"+q.Interval, loglevel.DefaultSampleInterval,
+				"⚠️  %s %s K线数据质量问题: %v", symbol, q.Interval, q.Issues)
+			candleQuality = append(candleQuality, q)
+		}
+		series[tf] = klines
 	}
+	klinesShort, klinesLong := series[shortTF], series[longTF]
+
+	// 计算当前指标 (基于最短周期最新数据)
+	currentPrice := klinesShort[len(klinesShort)-1].Close
+	currentEMA20 := calculateEMA(klinesShort, 20)
+	currentMACD := calculateMACD(klinesShort)
+	currentRSI7 := calculateRSI(klinesShort, 7)
+
+	// 计算价格变化百分比（默认周期下：1小时 = 20根3分钟K线前，4小时 = 上一根4小时K线）
+	priceChange1h := priceChangeOver(series, timeframes, currentPrice, time.Hour)
+	priceChange4h := priceChangeOver(series, timeframes, currentPrice, 4*time.Hour)
 
 	// 获取OI数据
 	oiData, err := getOpenInterestData(symbol)
@@ -81,14 +83,27 @@ func Get(symbol string) (*Data, error) {
 		oiData = &OIData{Latest: 0, Average: 0}
 	}
 
-	// 获取Funding Rate
-	fundingRate, _ := getFundingRate(symbol)
+	// 获取Funding Rate 及下次结算时间
+	var fundingRate float64
+	var nextFundingTime time.Time
+	if funding, err := getFundingInfo(symbol); err == nil {
+		fundingRate, nextFundingTime = funding.Rate, funding.NextFundingTime
+	}
 
 	// 计算日内系列数据
-	intradayData := calculateIntradaySeries(klines3m)
+	intradayData := calculateIntradaySeries(klinesShort)
 
 	// 计算长期数据
-	longerTermData := calculateLongerTermData(klines4h)
+	longerTermData := calculateLongerTermData(klinesLong)
+
+	// 中间周期摘要
+	var summaries []TimeframeSummary
+	for _, tf := range timeframes[1 : len(timeframes)-1] {
+		summaries = append(summaries, summarizeTimeframe(series[tf], tf))
+	}
+
+	levels := calculatePriceLevels(klinesShort, klinesLong)
+	levels.ShortInterval, levels.LongInterval = shortTF, longTF
 
 	return &Data{
 		Symbol:            symbol,
@@ -100,11 +115,50 @@ func Get(symbol string) (*Data, error) {
 		CurrentRSI7:       currentRSI7,
 		OpenInterest:      oiData,
 		FundingRate:       fundingRate,
+		NextFundingTime:   nextFundingTime,
+		FundingInterval:   getFundingInterval(symbol),
+		QuoteVolume24h:    quoteVolume24h(series, timeframes),
 		IntradaySeries:    intradayData,
 		LongerTermContext: longerTermData,
+		Volatility3m:      ForecastVolatility(klinesShort, shortTF),
+		Volatility4h:      ForecastVolatility(klinesLong, longTF),
+		CandleQuality:     candleQuality,
+		Levels:            levels,
+		IntradayInterval:  shortTF,
+		ContextInterval:   longTF,
+		Timeframes:        summaries,
+		klines:            series,
 	}, nil
 }
 
+// currentKlines 获取K线（优先WS缓存，未启动WS监控时直接走REST）
+func currentKlines(symbol, interval string) ([]Kline, error) {
+	if WSMonitorCli == nil {
+		return NewAPIClient().GetKlines(symbol, interval, 100)
+	}
+	return WSMonitorCli.GetCurrentKlines(symbol, interval)
+}
+
+// priceChangeOver 当前价格相对 window 之前的涨跌幅，使用能整除 window 的最长周期（没有可用周期时返回0）
+func priceChangeOver(series map[string][]Kline, timeframes []string, currentPrice float64, window time.Duration) float64 {
+	for i := len(timeframes) - 1; i >= 0; i-- {
+		step := time.Duration(timeframeDuration(timeframes[i]))
+		if step <= 0 || step > window || window%step != 0 {
+			continue
+		}
+		klines := series[timeframes[i]]
+		back := int(window / step)
+		if len(klines) < back+1 {
+			continue
+		}
+		if ref := klines[len(klines)-1-back].Close; ref > 0 {
+			return (currentPrice - ref) / ref * 100
+		}
+		return 0
+	}
+	return 0
+}
+
 // calculateEMA 计算EMA
 func calculateEMA(klines []Kline, period int) float64 {
 	if len(klines) < period {
@@ -264,6 +318,32 @@ func calculateIntradaySeries(klines []Kline) *IntradayData {
 	return data
 }
 
+// quoteVolume24h 最近24小时成交额：用能整除24小时的最长日内周期累加（默认最近6根4小时K线）
+// 只有日线及以上周期时使用最新一根K线的成交额
+func quoteVolume24h(series map[string][]Kline, timeframes []string) float64 {
+	const day = 24 * time.Hour
+	for i := len(timeframes) - 1; i >= 0; i-- {
+		step := time.Duration(timeframeDuration(timeframes[i]))
+		if step <= 0 || step >= day || day%step != 0 {
+			continue
+		}
+		klines := series[timeframes[i]]
+		start := len(klines) - int(day/step)
+		if start < 0 {
+			start = 0
+		}
+		total := 0.0
+		for _, k := range klines[start:] {
+			total += k.QuoteVolume
+		}
+		return total
+	}
+	if klines := series[timeframes[len(timeframes)-1]]; len(klines) > 0 {
+		return klines[len(klines)-1].QuoteVolume
+	}
+	return 0
+}
+
 // calculateLongerTermData 计算长期数据
 func calculateLongerTermData(klines []Kline) *LongerTermData {
 	data := &LongerTermData{
@@ -312,6 +392,13 @@ func calculateLongerTermData(klines []Kline) *LongerTermData {
 
 // getOpenInterestData 获取OI数据
 func getOpenInterestData(symbol string) (*OIData, error) {
+	if cached, ok := oiCacheMap.Load(symbol); ok {
+		cache := cached.(*OICache)
+		if since(cache.UpdatedAt) < oiCacheTTL {
+			return cache.Data, nil
+		}
+	}
+
 	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/openInterest?symbol=%s", symbol)
 
 	apiClient := NewAPIClient()
@@ -338,21 +425,32 @@ func getOpenInterestData(symbol string) (*OIData, error) {
 
 	oi, _ := strconv.ParseFloat(result.OpenInterest, 64)
 
-	return &OIData{
+	data := &OIData{
 		Latest:  oi,
 		Average: oi * 0.999, // 近似平均值
-	}, nil
+	}
+	oiCacheMap.Store(symbol, &OICache{Data: data, UpdatedAt: now()})
+	return data, nil
 }
 
 // getFundingRate 获取资金费率（优化：使用 1 小时缓存）
 func getFundingRate(symbol string) (float64, error) {
-	// 检查缓存（有效期 1 小时）
+	funding, err := getFundingInfo(symbol)
+	if err != nil {
+		return 0, err
+	}
+	return funding.Rate, nil
+}
+
+// getFundingInfo 获取资金费率和下次结算时间
+func getFundingInfo(symbol string) (*FundingRateCache, error) {
+	// 检查缓存（有效期 1 小时，且未过下次结算时间）
 	// Funding Rate 每 8 小时才更新，1 小时缓存非常合理
 	if cached, ok := fundingRateMap.Load(symbol); ok {
 		cache := cached.(*FundingRateCache)
-		if time.Since(cache.UpdatedAt) < frCacheTTL {
+		if since(cache.UpdatedAt) < frCacheTTL && (cache.NextFundingTime.IsZero() || now().Before(cache.NextFundingTime)) {
 			// 缓存命中，直接返回
-			return cache.Rate, nil
+			return cache, nil
 		}
 	}
 
@@ -362,13 +460,13 @@ func getFundingRate(symbol string) (float64, error) {
 	apiClient := NewAPIClient()
 	resp, err := apiClient.client.Get(url)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	var result struct {
@@ -382,18 +480,22 @@ func getFundingRate(symbol string) (float64, error) {
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	rate, _ := strconv.ParseFloat(result.LastFundingRate, 64)
+	cache := &FundingRateCache{
+		Rate:      rate,
+		UpdatedAt: now(),
+	}
+	if result.NextFundingTime > 0 {
+		cache.NextFundingTime = time.UnixMilli(result.NextFundingTime)
+	}
 
 	// 更新缓存
-	fundingRateMap.Store(symbol, &FundingRateCache{
-		Rate:      rate,
-		UpdatedAt: time.Now(),
-	})
+	fundingRateMap.Store(symbol, cache)
 
-	return rate, nil
+	return cache, nil
 }
 
 // Format 格式化输出市场数据
@@ -418,8 +520,22 @@ func Format(data *Data) string {
 
 	sb.WriteString(fmt.Sprintf("Funding Rate: %.2e\n\n", data.FundingRate))
 
+	if data.Volatility4h != nil {
+		line := fmt.Sprintf("Volatility forecast (%s): next %s: %.2f%% (realized %.2f%%), daily ≈ %.2f%%",
+			data.Volatility4h.Model, data.LongInterval(), data.Volatility4h.NextPeriodPct, data.Volatility4h.RealizedPct, data.Volatility4h.DailyPct)
+		if data.Volatility3m != nil {
+			line += fmt.Sprintf(" | next %s: %.3f%% (realized %.3f%%)", data.ShortInterval(), data.Volatility3m.NextPeriodPct, data.Volatility3m.RealizedPct)
+		}
+		sb.WriteString(line + "\n\n")
+	}
+
+	for _, q := range data.CandleQuality {
+		sb.WriteString(fmt.Sprintf("Data quality warning (%s): gaps=%d, zero-volume=%d, corrected OHLC=%d, clamped spikes=%d, abnormal closes=%d — treat %s indicators with caution\n\n",
+			q.Interval, q.Gaps, q.ZeroVolume, q.InvalidOHLC, q.SpikesClamped, q.SpikesFlagged, q.Interval))
+	}
+
 	if data.IntradaySeries != nil {
-		sb.WriteString("Intraday series (3‑minute intervals, oldest → latest):\n\n")
+		sb.WriteString(fmt.Sprintf("Intraday series (%s intervals, oldest → latest):\n\n", intervalLabel(data.ShortInterval())))
 
 		if len(data.IntradaySeries.MidPrices) > 0 {
 			sb.WriteString(fmt.Sprintf("Mid prices: %s\n\n", formatFloatSlice(data.IntradaySeries.MidPrices)))
@@ -443,7 +559,7 @@ func Format(data *Data) string {
 	}
 
 	if data.LongerTermContext != nil {
-		sb.WriteString("Longer‑term context (4‑hour timeframe):\n\n")
+		sb.WriteString(fmt.Sprintf("Longer‑term context (%s timeframe):\n\n", intervalLabel(data.LongInterval())))
 
 		sb.WriteString(fmt.Sprintf("20‑Period EMA: %.3f vs. 50‑Period EMA: %.3f\n\n",
 			data.LongerTermContext.EMA20, data.LongerTermContext.EMA50))
@@ -463,6 +579,15 @@ func Format(data *Data) string {
 		}
 	}
 
+	for _, tf := range data.Timeframes {
+		sb.WriteString(fmt.Sprintf("%s timeframe: change %+.2f%%, EMA20 %.3f vs. EMA50 %.3f, MACD %.3f, RSI (14‑Period) %.3f, ATR (14‑Period) %.3f\n\n",
+			intervalLabel(tf.Interval), tf.ChangePct, tf.EMA20, tf.EMA50, tf.MACD, tf.RSI14, tf.ATR14))
+	}
+
+	if line := formatIndicators(data.Indicators); line != "" {
+		sb.WriteString(line + "\n")
+	}
+
 	return sb.String()
 }
 
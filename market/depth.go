@@ -0,0 +1,125 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DepthSnapshotLevels 执行时快照的盘口档数
+	DepthSnapshotLevels = 20
+	// depthRequestTimeout 盘口请求超时（下单前同步获取，不能拖慢下单）
+	depthRequestTimeout = 2 * time.Second
+)
+
+// OrderBookLevel 盘口价位
+type OrderBookLevel struct {
+	Price    float64
+	Quantity float64
+}
+
+// OrderBook 盘口快照（买盘价格从高到低，卖盘价格从低到高）
+type OrderBook struct {
+	Symbol string
+	Time   time.Time
+	Bids   []OrderBookLevel
+	Asks   []OrderBookLevel
+}
+
+// depthResponse /fapi/v1/depth 返回结构
+type depthResponse struct {
+	Bids [][]string `json:"bids"`
+	Asks [][]string `json:"asks"`
+}
+
+// GetOrderBook 获取合约盘口（limit 取 5/10/20/50/100/500/1000）
+func (c *APIClient) GetOrderBook(symbol string, limit int) (*OrderBook, error) {
+	url := fmt.Sprintf("%s/fapi/v1/depth", baseURL)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	q.Add("symbol", symbol)
+	q.Add("limit", strconv.Itoa(limit))
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取盘口失败 (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var raw depthResponse
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	return &OrderBook{
+		Symbol: symbol,
+		Time:   time.Now(),
+		Bids:   parseDepthLevels(raw.Bids),
+		Asks:   parseDepthLevels(raw.Asks),
+	}, nil
+}
+
+func parseDepthLevels(raw [][]string) []OrderBookLevel {
+	levels := make([]OrderBookLevel, 0, len(raw))
+	for _, item := range raw {
+		if len(item) < 2 {
+			continue
+		}
+		price, err1 := strconv.ParseFloat(item[0], 64)
+		qty, err2 := strconv.ParseFloat(item[1], 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		levels = append(levels, OrderBookLevel{Price: price, Quantity: qty})
+	}
+	return levels
+}
+
+// GetDepthSnapshot 获取执行时的前20档盘口（使用较短超时）
+func GetDepthSnapshot(symbol string) (*OrderBook, error) {
+	client := NewAPIClient()
+	client.client = &http.Client{Transport: client.client.Transport, Timeout: depthRequestTimeout}
+	return client.GetOrderBook(Normalize(symbol), DepthSnapshotLevels)
+}
+
+// SpreadBps 买一卖一价差（基点，相对中间价）
+func (b *OrderBook) SpreadBps() float64 {
+	if len(b.Bids) == 0 || len(b.Asks) == 0 {
+		return 0
+	}
+	bid, ask := b.Bids[0].Price, b.Asks[0].Price
+	mid := (bid + ask) / 2
+	if mid <= 0 {
+		return 0
+	}
+	return (ask - bid) / mid * 10000
+}
+
+// DepthUSD 单侧盘口名义价值合计（side: bid / ask）
+func (b *OrderBook) DepthUSD(side string) float64 {
+	levels := b.Asks
+	if side == "bid" {
+		levels = b.Bids
+	}
+	total := 0.0
+	for _, l := range levels {
+		total += l.Price * l.Quantity
+	}
+	return total
+}
@@ -0,0 +1,78 @@
+package market
+
+import (
+	"fmt"
+	"time"
+)
+
+// flowInterval 成交量差使用的K线周期（与WS默认订阅周期一致，读取缓存不额外请求）
+const flowInterval = "3m"
+
+// FlowSnapshot 持仓量与主动买卖成交量的即时快照
+type FlowSnapshot struct {
+	Symbol       string    `json:"symbol"`
+	Price        float64   `json:"price"`
+	OpenInterest float64   `json:"open_interest"`
+	BuyVolume    float64   `json:"buy_volume"`  // 窗口内主动买入量
+	SellVolume   float64   `json:"sell_volume"` // 窗口内主动卖出量
+	Time         time.Time `json:"time"`
+}
+
+// DeltaRatio 净主动成交占比：(买 - 卖) / (买 + 卖)，范围 [-1, 1]
+func (s *FlowSnapshot) DeltaRatio() float64 {
+	total := s.BuyVolume + s.SellVolume
+	if total <= 0 {
+		return 0
+	}
+	return (s.BuyVolume - s.SellVolume) / total
+}
+
+// GetFlowSnapshot 获取币种当前持仓量和最近 window 内的主动买卖量（OI 走短TTL缓存，K线优先读WS缓存）
+func GetFlowSnapshot(symbol string, window time.Duration) (*FlowSnapshot, error) {
+	symbol = Normalize(symbol)
+	klines, err := currentKlines(symbol, flowInterval)
+	if err != nil {
+		return nil, fmt.Errorf("获取%s K线失败: %w", flowInterval, err)
+	}
+	if len(klines) == 0 {
+		return nil, fmt.Errorf("%s 没有K线数据", symbol)
+	}
+	oi, err := getOpenInterestData(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓量失败: %w", err)
+	}
+
+	snapshot := &FlowSnapshot{
+		Symbol:       symbol,
+		Price:        klines[len(klines)-1].Close,
+		OpenInterest: oi.Latest,
+		Time:         now(),
+	}
+	snapshot.BuyVolume, snapshot.SellVolume = takerVolumes(klines, flowCandles(window))
+	return snapshot, nil
+}
+
+// flowCandles 覆盖 window 所需的K线根数（至少1根）
+func flowCandles(window time.Duration) int {
+	step := time.Duration(timeframeDuration(flowInterval))
+	if step <= 0 {
+		return 1
+	}
+	n := int((window + step - 1) / step)
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// takerVolumes 最近 n 根K线的主动买入量和主动卖出量（含未收盘K线）
+func takerVolumes(klines []Kline, n int) (buy, sell float64) {
+	if n > len(klines) {
+		n = len(klines)
+	}
+	for _, k := range klines[len(klines)-n:] {
+		buy += k.TakerBuyBaseVolume
+		sell += k.Volume - k.TakerBuyBaseVolume
+	}
+	return buy, sell
+}
@@ -0,0 +1,51 @@
+package market
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTakerVolumes(t *testing.T) {
+	klines := []Kline{
+		{Volume: 100, TakerBuyBaseVolume: 90},
+		{Volume: 10, TakerBuyBaseVolume: 2},
+		{Volume: 20, TakerBuyBaseVolume: 5},
+	}
+
+	buy, sell := takerVolumes(klines, 2)
+	if buy != 7 || sell != 23 {
+		t.Errorf("最近2根 buy/sell = %v/%v, want 7/23", buy, sell)
+	}
+	buy, sell = takerVolumes(klines, 10)
+	if buy != 97 || sell != 33 {
+		t.Errorf("根数超过K线数量时应取全部: buy/sell = %v/%v, want 97/33", buy, sell)
+	}
+}
+
+func TestFlowCandles(t *testing.T) {
+	tests := []struct {
+		window time.Duration
+		want   int
+	}{
+		{window: 0, want: 1},
+		{window: 3 * time.Minute, want: 1},
+		{window: 10 * time.Minute, want: 4},
+		{window: 15 * time.Minute, want: 5},
+	}
+	for _, tt := range tests {
+		if got := flowCandles(tt.window); got != tt.want {
+			t.Errorf("flowCandles(%v) = %d, want %d", tt.window, got, tt.want)
+		}
+	}
+}
+
+func TestFlowSnapshotDeltaRatio(t *testing.T) {
+	s := &FlowSnapshot{BuyVolume: 20, SellVolume: 80}
+	if got := s.DeltaRatio(); math.Abs(got+0.6) > 1e-9 {
+		t.Errorf("DeltaRatio = %v, want -0.6", got)
+	}
+	if got := (&FlowSnapshot{}).DeltaRatio(); got != 0 {
+		t.Errorf("无成交量时 DeltaRatio = %v, want 0", got)
+	}
+}
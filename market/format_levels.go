@@ -0,0 +1,143 @@
+package market
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 市场数据输出详细程度（按模型上下文长度和成本选择）
+const (
+	VerbosityBrief    = "brief"    // 每个周期一行摘要
+	VerbosityStandard = "standard" // 当前默认格式（含指标序列）
+	VerbosityFull     = "full"     // 标准格式 + 原始价格位（支撑/阻力、近期K线）
+)
+
+const (
+	levelLookback3m   = 20 // 日内周期支撑/阻力回看K线数（3分钟周期下为1小时）
+	levelLookback4h   = 30 // 长期周期支撑/阻力回看K线数（4小时周期下为5天）
+	recentRawKlines4h = 6  // full 模式输出的最近长期周期K线数
+)
+
+// PriceLevels 原始价格位（full 模式使用；3m/4h 字段在自定义分析周期下对应最短/最长周期）
+type PriceLevels struct {
+	Support3m     float64
+	Resistance3m  float64
+	Support4h     float64
+	Resistance4h  float64
+	Recent4h      []Kline // 最近几根长期周期K线（旧 → 新）
+	ShortInterval string  // 日内周期（为空表示3m）
+	LongInterval  string  // 长期周期（为空表示4h）
+}
+
+// NormalizeVerbosity 校验详细程度，无效时使用 standard
+func NormalizeVerbosity(verbosity string) string {
+	switch verbosity {
+	case VerbosityBrief, VerbosityFull:
+		return verbosity
+	}
+	return VerbosityStandard
+}
+
+// calculatePriceLevels 计算支撑/阻力和近期原始K线
+func calculatePriceLevels(klines3m, klines4h []Kline) *PriceLevels {
+	levels := &PriceLevels{}
+	if n := len(klines3m); n > 0 {
+		levels.Resistance3m, levels.Support3m = highLow(klines3m, n-1, levelLookback3m)
+	}
+	if n := len(klines4h); n > 0 {
+		levels.Resistance4h, levels.Support4h = highLow(klines4h, n-1, levelLookback4h)
+		start := n - recentRawKlines4h
+		if start < 0 {
+			start = 0
+		}
+		levels.Recent4h = append([]Kline(nil), klines4h[start:]...)
+	}
+	return levels
+}
+
+// FormatWithVerbosity 按详细程度格式化市场数据
+func FormatWithVerbosity(data *Data, verbosity string) string {
+	switch NormalizeVerbosity(verbosity) {
+	case VerbosityBrief:
+		return formatBrief(data)
+	case VerbosityFull:
+		return Format(data) + formatLevels(data.Levels)
+	}
+	return Format(data)
+}
+
+// formatBrief 每个周期一行摘要
+func formatBrief(data *Data) string {
+	var sb strings.Builder
+	price := formatPriceWithDynamicPrecision(data.CurrentPrice)
+
+	sb.WriteString(fmt.Sprintf("%s: price %s, ema20 %.3f, macd %.3f, rsi7 %.1f, 1h %+.2f%%\n",
+		data.ShortInterval(), price, data.CurrentEMA20, data.CurrentMACD, data.CurrentRSI7, data.PriceChange1h))
+
+	if lt := data.LongerTermContext; lt != nil {
+		line := fmt.Sprintf("%s: ema20 %.3f / ema50 %.3f, atr14 %.3f, 4h %+.2f%%",
+			data.LongInterval(), lt.EMA20, lt.EMA50, lt.ATR14, data.PriceChange4h)
+		if n := len(lt.MACDValues); n > 0 {
+			line += fmt.Sprintf(", macd %.3f", lt.MACDValues[n-1])
+		}
+		if n := len(lt.RSI14Values); n > 0 {
+			line += fmt.Sprintf(", rsi14 %.1f", lt.RSI14Values[n-1])
+		}
+		sb.WriteString(line + "\n")
+	}
+	for _, tf := range data.Timeframes {
+		sb.WriteString(fmt.Sprintf("%s: ema20 %.3f / ema50 %.3f, macd %.3f, rsi14 %.1f, change %+.2f%%\n",
+			tf.Interval, tf.EMA20, tf.EMA50, tf.MACD, tf.RSI14, tf.ChangePct))
+	}
+
+	line := fmt.Sprintf("perp: funding %.2e", data.FundingRate)
+	if schedule := formatFundingSchedule(data); schedule != "" {
+		line += " (" + schedule + ")"
+	}
+	if data.OpenInterest != nil {
+		line += fmt.Sprintf(", OI %s", formatPriceWithDynamicPrecision(data.OpenInterest.Latest))
+	}
+	if data.Volatility4h != nil {
+		line += fmt.Sprintf(", daily vol ≈ %.2f%%", data.Volatility4h.DailyPct)
+	}
+	sb.WriteString(line + "\n")
+	sb.WriteString(formatIndicators(data.Indicators))
+
+	for _, q := range data.CandleQuality {
+		sb.WriteString(fmt.Sprintf("data warning (%s): %d gaps, %d clamped spikes, %d abnormal closes\n",
+			q.Interval, q.Gaps, q.SpikesClamped, q.SpikesFlagged))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// formatLevels 原始价格位
+func formatLevels(levels *PriceLevels) string {
+	if levels == nil {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Raw price levels:\n\n")
+	short, long := levels.ShortInterval, levels.LongInterval
+	if short == "" {
+		short = "3m"
+	}
+	if long == "" {
+		long = "4h"
+	}
+	sb.WriteString(fmt.Sprintf("%s range (last %d candles): support %s / resistance %s\n\n",
+		short, levelLookback3m, formatPriceWithDynamicPrecision(levels.Support3m), formatPriceWithDynamicPrecision(levels.Resistance3m)))
+	sb.WriteString(fmt.Sprintf("%s range (last %d candles): support %s / resistance %s\n\n",
+		long, levelLookback4h, formatPriceWithDynamicPrecision(levels.Support4h), formatPriceWithDynamicPrecision(levels.Resistance4h)))
+
+	if len(levels.Recent4h) > 0 {
+		sb.WriteString(fmt.Sprintf("Recent %s candles (open/high/low/close, oldest → latest):\n\n", long))
+		for _, k := range levels.Recent4h {
+			sb.WriteString(fmt.Sprintf("%s / %s / %s / %s\n",
+				formatPriceWithDynamicPrecision(k.Open), formatPriceWithDynamicPrecision(k.High),
+				formatPriceWithDynamicPrecision(k.Low), formatPriceWithDynamicPrecision(k.Close)))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,35 @@
+package market
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatWithVerbosity(t *testing.T) {
+	var klines []Kline
+	for i := 0; i < 40; i++ {
+		price := 100 + float64(i%5)
+		klines = append(klines, Kline{Open: price, High: price + 2, Low: price - 2, Close: price + 1})
+	}
+	data := &Data{
+		Symbol:            "BTCUSDT",
+		CurrentPrice:      101,
+		IntradaySeries:    calculateIntradaySeries(klines),
+		LongerTermContext: calculateLongerTermData(klines),
+		Levels:            calculatePriceLevels(klines, klines),
+	}
+
+	brief := FormatWithVerbosity(data, VerbosityBrief)
+	standard := FormatWithVerbosity(data, "")
+	full := FormatWithVerbosity(data, VerbosityFull)
+
+	if len(brief) >= len(standard) || len(standard) >= len(full) {
+		t.Errorf("长度应递增: brief=%d standard=%d full=%d", len(brief), len(standard), len(full))
+	}
+	if standard != Format(data) {
+		t.Error("standard 应与 Format 输出一致")
+	}
+	if !strings.Contains(full, "support 98.0000 / resistance 106.00") {
+		t.Errorf("full 输出缺少支撑/阻力:\n%s", full)
+	}
+}
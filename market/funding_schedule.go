@@ -0,0 +1,102 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+// DefaultFundingInterval 标准资金费结算间隔
+const DefaultFundingInterval = 8 * time.Hour
+
+// fundingIntervalCacheTTL 结算间隔列表缓存时长（交易所很少调整）
+const fundingIntervalCacheTTL = 6 * time.Hour
+
+// fundingIntervalCache 非标准结算间隔的币种（/fapi/v1/fundingInfo 只返回调整过参数的币种）
+var fundingIntervalCache = struct {
+	sync.RWMutex
+	intervals map[string]time.Duration
+	updatedAt time.Time
+}{}
+
+// getFundingInterval 获取币种资金费结算间隔（获取失败或未调整时返回8小时）
+func getFundingInterval(symbol string) time.Duration {
+	fundingIntervalCache.RLock()
+	fresh := fundingIntervalCache.intervals != nil && since(fundingIntervalCache.updatedAt) < fundingIntervalCacheTTL
+	interval, ok := fundingIntervalCache.intervals[symbol]
+	fundingIntervalCache.RUnlock()
+
+	if !fresh {
+		intervals, err := fetchFundingIntervals()
+		if err != nil {
+			return DefaultFundingInterval
+		}
+		fundingIntervalCache.Lock()
+		fundingIntervalCache.intervals = intervals
+		fundingIntervalCache.updatedAt = now()
+		fundingIntervalCache.Unlock()
+		interval, ok = intervals[symbol]
+	}
+	if !ok {
+		return DefaultFundingInterval
+	}
+	return interval
+}
+
+// fetchFundingIntervals 获取调整过资金费结算间隔的币种列表
+func fetchFundingIntervals() (map[string]time.Duration, error) {
+	apiClient := NewAPIClient()
+	resp, err := apiClient.client.Get("https://fapi.binance.com/fapi/v1/fundingInfo")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []struct {
+		Symbol               string `json:"symbol"`
+		FundingIntervalHours int    `json:"fundingIntervalHours"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析资金费结算间隔失败: %w", err)
+	}
+
+	intervals := make(map[string]time.Duration, len(result))
+	for _, r := range result {
+		if r.FundingIntervalHours > 0 {
+			intervals[r.Symbol] = time.Duration(r.FundingIntervalHours) * time.Hour
+		}
+	}
+	return intervals, nil
+}
+
+// FundingCountdown 距下次资金费结算的时长（未知时返回 false）
+func (d *Data) FundingCountdown() (time.Duration, bool) {
+	if d == nil || d.NextFundingTime.IsZero() {
+		return 0, false
+	}
+	remaining := d.NextFundingTime.Sub(now())
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, true
+}
+
+// formatFundingSchedule 资金费结算倒计时（非标准间隔时注明间隔），如 "next in 1h05m, every 4h"
+func formatFundingSchedule(data *Data) string {
+	countdown, ok := data.FundingCountdown()
+	if !ok {
+		return ""
+	}
+	s := fmt.Sprintf("next in %dh%02dm", int(countdown.Hours()), int(countdown.Minutes())%60)
+	if data.FundingInterval > 0 && data.FundingInterval != DefaultFundingInterval {
+		s += fmt.Sprintf(", every %dh", int(data.FundingInterval.Hours()))
+	}
+	return s
+}
@@ -0,0 +1,159 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"nofx/loglevel"
+	"strconv"
+	"time"
+)
+
+// FundingPoint 历史资金费率结算点
+type FundingPoint struct {
+	Time int64   `json:"time"` // 结算时间（毫秒）
+	Rate float64 `json:"rate"`
+}
+
+// OIPoint 历史持仓量
+type OIPoint struct {
+	Time     int64   `json:"time"`      // 毫秒
+	Value    float64 `json:"value"`     // 持仓量（币）
+	ValueUSD float64 `json:"value_usd"` // 持仓价值（USDT）
+}
+
+// GetFundingRateHistory 获取时间区间内的资金费率结算记录（单次最多1000条）
+func (c *APIClient) GetFundingRateHistory(symbol string, startTime, endTime int64) ([]FundingPoint, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/fapi/v1/fundingRate", baseURL), nil)
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+	q.Add("symbol", symbol)
+	q.Add("startTime", strconv.FormatInt(startTime, 10))
+	q.Add("endTime", strconv.FormatInt(endTime, 10))
+	q.Add("limit", "1000")
+	req.URL.RawQuery = q.Encode()
+
+	body, err := c.doGet(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []struct {
+		FundingTime int64  `json:"fundingTime"`
+		FundingRate string `json:"fundingRate"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		loglevel.Warnf(loglevel.Market, "获取资金费率历史失败,响应内容: %s", string(body))
+		return nil, err
+	}
+
+	points := make([]FundingPoint, 0, len(raw))
+	for _, r := range raw {
+		rate, err := strconv.ParseFloat(r.FundingRate, 64)
+		if err != nil {
+			continue
+		}
+		points = append(points, FundingPoint{Time: r.FundingTime, Rate: rate})
+	}
+	return points, nil
+}
+
+// GetOpenInterestHistory 获取历史持仓量（period: 5m/15m/30m/1h/2h/4h/6h/12h/1d，单次最多500条，币安仅保留近30天）
+func (c *APIClient) GetOpenInterestHistory(symbol, period string, startTime, endTime int64) ([]OIPoint, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/futures/data/openInterestHist", baseURL), nil)
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+	q.Add("symbol", symbol)
+	q.Add("period", period)
+	q.Add("startTime", strconv.FormatInt(startTime, 10))
+	q.Add("endTime", strconv.FormatInt(endTime, 10))
+	q.Add("limit", "500")
+	req.URL.RawQuery = q.Encode()
+
+	body, err := c.doGet(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []struct {
+		SumOpenInterest      string `json:"sumOpenInterest"`
+		SumOpenInterestValue string `json:"sumOpenInterestValue"`
+		Timestamp            int64  `json:"timestamp"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		loglevel.Warnf(loglevel.Market, "获取持仓量历史失败,响应内容: %s", string(body))
+		return nil, err
+	}
+
+	points := make([]OIPoint, 0, len(raw))
+	for _, r := range raw {
+		value, _ := strconv.ParseFloat(r.SumOpenInterest, 64)
+		valueUSD, _ := strconv.ParseFloat(r.SumOpenInterestValue, 64)
+		points = append(points, OIPoint{Time: r.Timestamp, Value: value, ValueUSD: valueUSD})
+	}
+	return points, nil
+}
+
+func (c *APIClient) doGet(req *http.Request) ([]byte, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// FetchFundingBetween 分页获取时间区间内的全部资金费率结算记录
+func FetchFundingBetween(symbol string, start, end time.Time) ([]FundingPoint, error) {
+	client := NewAPIClient()
+	var points []FundingPoint
+	cursor, endMs := start.UnixMilli(), end.UnixMilli()
+	for cursor < endMs {
+		batch, err := client.GetFundingRateHistory(symbol, cursor, endMs)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		points = append(points, batch...)
+		next := batch[len(batch)-1].Time + 1
+		if next <= cursor {
+			break
+		}
+		cursor = next
+	}
+	return points, nil
+}
+
+// FetchOpenInterestBetween 分页获取时间区间内的历史持仓量
+func FetchOpenInterestBetween(symbol, period string, start, end time.Time) ([]OIPoint, error) {
+	step, err := IntervalDuration(period)
+	if err != nil {
+		return nil, err
+	}
+	client := NewAPIClient()
+	var points []OIPoint
+	cursor, endMs := start.UnixMilli(), end.UnixMilli()
+	for cursor < endMs {
+		batch, err := client.GetOpenInterestHistory(symbol, period, cursor, endMs)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		points = append(points, batch...)
+		next := batch[len(batch)-1].Time + step.Milliseconds()
+		if next <= cursor {
+			break
+		}
+		cursor = next
+	}
+	return points, nil
+}
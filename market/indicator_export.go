@@ -0,0 +1,301 @@
+package market
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	indicatorWarmupCandles = 100   // 导出区间前额外获取的K线数（让指标在区间起点已收敛）
+	maxExportCandles       = 20000 // 单次导出的K线上限
+	fibLookback            = 50    // 斐波那契回撤的波段回看K线数
+	zoneLookback           = 20    // 支撑/阻力区间的回看K线数
+)
+
+// IndicatorRow 单根K线的指标/分析器输出（用于离线研究）
+type IndicatorRow struct {
+	OpenTime   time.Time
+	Open       float64
+	High       float64
+	Low        float64
+	Close      float64
+	Volume     float64
+	EMA20      float64
+	EMA50      float64
+	MACD       float64
+	RSI7       float64
+	RSI14      float64
+	ATR14      float64
+	Support    float64 // 近 zoneLookback 根K线最低价
+	Resistance float64 // 近 zoneLookback 根K线最高价
+	ZonePct    float64 // 收盘价在支撑/阻力区间中的位置（0=支撑，100=阻力）
+	SwingHigh  float64 // 近 fibLookback 根K线波段高点
+	SwingLow   float64 // 近 fibLookback 根K线波段低点
+	Fib382     float64 // 从波段高点回撤38.2%的价格
+	Fib500     float64
+	Fib618     float64
+}
+
+// indicatorCSVHeader CSV表头（与 IndicatorRow 字段一一对应）
+var indicatorCSVHeader = []string{
+	"open_time", "open", "high", "low", "close", "volume",
+	"ema20", "ema50", "macd", "rsi7", "rsi14", "atr14",
+	"support", "resistance", "zone_pct",
+	"swing_high", "swing_low", "fib_382", "fib_500", "fib_618",
+}
+
+// IntervalDuration 解析K线周期（如 3m / 1h / 4h / 1d）
+func IntervalDuration(interval string) (time.Duration, error) {
+	if len(interval) < 2 {
+		return 0, fmt.Errorf("无效的K线周期: %s", interval)
+	}
+	n, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("无效的K线周期: %s", interval)
+	}
+	switch interval[len(interval)-1] {
+	case 'm':
+		return time.Duration(n) * time.Minute, nil
+	case 'h':
+		return time.Duration(n) * time.Hour, nil
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, nil
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("无效的K线周期: %s", interval)
+}
+
+// FetchKlinesBetween 分页获取时间区间内的全部K线（自动处理单次1500根的限制）
+func FetchKlinesBetween(symbol, interval string, start, end time.Time) ([]Kline, error) {
+	step, err := IntervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("结束时间必须晚于开始时间")
+	}
+	if end.Sub(start)/step > maxExportCandles {
+		return nil, fmt.Errorf("区间过长: 超过%d根K线，请缩小时间范围或使用更大的周期", maxExportCandles)
+	}
+
+	client := NewAPIClient()
+	var klines []Kline
+	cursor := start.UnixMilli()
+	endMs := end.UnixMilli()
+	for cursor < endMs {
+		batch, err := client.GetKlinesRange(symbol, interval, cursor, endMs)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		klines = append(klines, batch...)
+		next := batch[len(batch)-1].OpenTime + step.Milliseconds()
+		if next <= cursor {
+			break
+		}
+		cursor = next
+	}
+	return klines, nil
+}
+
+// ExportIndicators 获取 [start, end) 区间的K线并计算每根K线的指标（区间前额外获取预热K线）
+func ExportIndicators(symbol, interval string, start, end time.Time) ([]IndicatorRow, error) {
+	step, err := IntervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+	klines, err := FetchKlinesBetween(Normalize(symbol), interval, start.Add(-indicatorWarmupCandles*step), end)
+	if err != nil {
+		return nil, err
+	}
+	klines, quality := SanitizeKlines(klines, interval)
+	if !quality.Clean() {
+		log.Printf("⚠️  %s %s K线数据质量问题: %v", symbol, interval, quality.Issues)
+	}
+	return BuildIndicatorRows(klines, start), nil
+}
+
+// BuildIndicatorRows 逐根K线计算指标，只输出开盘时间不早于 from 的行
+// 指标计算方式与 calculateEMA / calculateRSI / calculateATR 保持一致（SMA起点 + Wilder平滑）
+func BuildIndicatorRows(klines []Kline, from time.Time) []IndicatorRow {
+	ema12 := emaSeries(klines, 12)
+	ema26 := emaSeries(klines, 26)
+	ema20 := emaSeries(klines, 20)
+	ema50 := emaSeries(klines, 50)
+	rsi7 := rsiSeries(klines, 7)
+	rsi14 := rsiSeries(klines, 14)
+	atr14 := atrSeries(klines, 14)
+
+	fromMs := from.UnixMilli()
+	rows := make([]IndicatorRow, 0, len(klines))
+	for i, k := range klines {
+		if k.OpenTime < fromMs {
+			continue
+		}
+		row := IndicatorRow{
+			OpenTime: time.UnixMilli(k.OpenTime).UTC(),
+			Open:     k.Open,
+			High:     k.High,
+			Low:      k.Low,
+			Close:    k.Close,
+			Volume:   k.Volume,
+			EMA20:    ema20[i],
+			EMA50:    ema50[i],
+			RSI7:     rsi7[i],
+			RSI14:    rsi14[i],
+			ATR14:    atr14[i],
+		}
+		if i+1 >= 26 {
+			row.MACD = ema12[i] - ema26[i]
+		}
+
+		row.Resistance, row.Support = highLow(klines, i, zoneLookback)
+		if row.Resistance > row.Support {
+			row.ZonePct = (k.Close - row.Support) / (row.Resistance - row.Support) * 100
+		}
+
+		row.SwingHigh, row.SwingLow = highLow(klines, i, fibLookback)
+		swing := row.SwingHigh - row.SwingLow
+		row.Fib382 = row.SwingHigh - swing*0.382
+		row.Fib500 = row.SwingHigh - swing*0.5
+		row.Fib618 = row.SwingHigh - swing*0.618
+
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// WriteIndicatorCSV 以CSV格式写出指标
+func WriteIndicatorCSV(w io.Writer, rows []IndicatorRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(indicatorCSVHeader); err != nil {
+		return err
+	}
+	f := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	for _, r := range rows {
+		record := []string{
+			r.OpenTime.Format(time.RFC3339), f(r.Open), f(r.High), f(r.Low), f(r.Close), f(r.Volume),
+			f(r.EMA20), f(r.EMA50), f(r.MACD), f(r.RSI7), f(r.RSI14), f(r.ATR14),
+			f(r.Support), f(r.Resistance), f(r.ZonePct),
+			f(r.SwingHigh), f(r.SwingLow), f(r.Fib382), f(r.Fib500), f(r.Fib618),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// emaSeries 计算每根K线处的EMA（数据不足时为0）
+func emaSeries(klines []Kline, period int) []float64 {
+	series := make([]float64, len(klines))
+	if len(klines) < period {
+		return series
+	}
+	sum := 0.0
+	for i := 0; i < period; i++ {
+		sum += klines[i].Close
+	}
+	ema := sum / float64(period)
+	series[period-1] = ema
+
+	multiplier := 2.0 / float64(period+1)
+	for i := period; i < len(klines); i++ {
+		ema = (klines[i].Close-ema)*multiplier + ema
+		series[i] = ema
+	}
+	return series
+}
+
+// rsiSeries 计算每根K线处的RSI（数据不足时为0）
+func rsiSeries(klines []Kline, period int) []float64 {
+	series := make([]float64, len(klines))
+	if len(klines) <= period {
+		return series
+	}
+	rsi := func(avgGain, avgLoss float64) float64 {
+		if avgLoss == 0 {
+			return 100
+		}
+		return 100 - 100/(1+avgGain/avgLoss)
+	}
+
+	gains, losses := 0.0, 0.0
+	for i := 1; i <= period; i++ {
+		change := klines[i].Close - klines[i-1].Close
+		if change > 0 {
+			gains += change
+		} else {
+			losses += -change
+		}
+	}
+	avgGain := gains / float64(period)
+	avgLoss := losses / float64(period)
+	series[period] = rsi(avgGain, avgLoss)
+
+	for i := period + 1; i < len(klines); i++ {
+		change := klines[i].Close - klines[i-1].Close
+		gain, loss := 0.0, 0.0
+		if change > 0 {
+			gain = change
+		} else {
+			loss = -change
+		}
+		avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+		series[i] = rsi(avgGain, avgLoss)
+	}
+	return series
+}
+
+// atrSeries 计算每根K线处的ATR（数据不足时为0）
+func atrSeries(klines []Kline, period int) []float64 {
+	series := make([]float64, len(klines))
+	if len(klines) <= period {
+		return series
+	}
+	trs := make([]float64, len(klines))
+	for i := 1; i < len(klines); i++ {
+		prevClose := klines[i-1].Close
+		trs[i] = math.Max(klines[i].High-klines[i].Low,
+			math.Max(math.Abs(klines[i].High-prevClose), math.Abs(klines[i].Low-prevClose)))
+	}
+
+	sum := 0.0
+	for i := 1; i <= period; i++ {
+		sum += trs[i]
+	}
+	atr := sum / float64(period)
+	series[period] = atr
+
+	for i := period + 1; i < len(klines); i++ {
+		atr = (atr*float64(period-1) + trs[i]) / float64(period)
+		series[i] = atr
+	}
+	return series
+}
+
+// highLow 计算截至第 i 根K线（含）的最近 lookback 根K线最高价和最低价
+func highLow(klines []Kline, i, lookback int) (float64, float64) {
+	start := i - lookback + 1
+	if start < 0 {
+		start = 0
+	}
+	high, low := klines[start].High, klines[start].Low
+	for j := start + 1; j <= i; j++ {
+		high = math.Max(high, klines[j].High)
+		low = math.Min(low, klines[j].Low)
+	}
+	return high, low
+}
@@ -0,0 +1,98 @@
+package market
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testKlines(n int) []Kline {
+	klines := make([]Kline, n)
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range klines {
+		price := 100 + 10*math.Sin(float64(i)/5) + float64(i)*0.1
+		klines[i] = Kline{
+			OpenTime: base.Add(time.Duration(i) * time.Hour).UnixMilli(),
+			Open:     price - 0.5,
+			High:     price + 1,
+			Low:      price - 1,
+			Close:    price,
+			Volume:   1000 + float64(i),
+		}
+	}
+	return klines
+}
+
+func TestBuildIndicatorRowsMatchesSingleValueIndicators(t *testing.T) {
+	klines := testKlines(120)
+	rows := BuildIndicatorRows(klines, time.UnixMilli(klines[0].OpenTime))
+	if len(rows) != len(klines) {
+		t.Fatalf("len(rows) = %d, want %d", len(rows), len(klines))
+	}
+
+	tests := []struct {
+		name string
+		got  func(IndicatorRow) float64
+		want func([]Kline) float64
+	}{
+		{"EMA20", func(r IndicatorRow) float64 { return r.EMA20 }, func(k []Kline) float64 { return calculateEMA(k, 20) }},
+		{"EMA50", func(r IndicatorRow) float64 { return r.EMA50 }, func(k []Kline) float64 { return calculateEMA(k, 50) }},
+		{"MACD", func(r IndicatorRow) float64 { return r.MACD }, calculateMACD},
+		{"RSI14", func(r IndicatorRow) float64 { return r.RSI14 }, func(k []Kline) float64 { return calculateRSI(k, 14) }},
+		{"ATR14", func(r IndicatorRow) float64 { return r.ATR14 }, func(k []Kline) float64 { return calculateATR(k, 14) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, i := range []int{10, 30, 60, 119} {
+				got, want := tt.got(rows[i]), tt.want(klines[:i+1])
+				if math.Abs(got-want) > 1e-9 {
+					t.Errorf("第%d根K线 %s = %v, want %v", i, tt.name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildIndicatorRowsSkipsWarmup(t *testing.T) {
+	klines := testKlines(50)
+	from := time.UnixMilli(klines[40].OpenTime)
+	rows := BuildIndicatorRows(klines, from)
+	if len(rows) != 10 || !rows[0].OpenTime.Equal(from) {
+		t.Fatalf("预热K线未被跳过: len=%d first=%v", len(rows), rows[0].OpenTime)
+	}
+	if rows[0].Fib618 >= rows[0].Fib382 || rows[0].SwingLow >= rows[0].SwingHigh {
+		t.Errorf("斐波那契水平顺序错误: %+v", rows[0])
+	}
+
+	var buf bytes.Buffer
+	if err := WriteIndicatorCSV(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 11 || !strings.HasPrefix(lines[0], "open_time,") {
+		t.Errorf("CSV行数 = %d, 表头 = %s", len(lines), lines[0])
+	}
+}
+
+func TestIntervalDuration(t *testing.T) {
+	tests := []struct {
+		interval string
+		want     time.Duration
+		wantErr  bool
+	}{
+		{"3m", 3 * time.Minute, false},
+		{"4h", 4 * time.Hour, false},
+		{"1d", 24 * time.Hour, false},
+		{"h", 0, true},
+		{"5x", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := IntervalDuration(tt.interval)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("IntervalDuration(%q) = %v, %v", tt.interval, got, err)
+		}
+	}
+}
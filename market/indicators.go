@@ -0,0 +1,259 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// 指标数据来源
+const (
+	SourceClose  = "close"
+	SourceOpen   = "open"
+	SourceHigh   = "high"
+	SourceLow    = "low"
+	SourceHL2    = "hl2"  // (high+low)/2
+	SourceHLC3   = "hlc3" // (high+low+close)/3
+	SourceVolume = "volume"
+)
+
+// IndicatorDef 指标定义（类型、周期、数据来源、K线周期）
+type IndicatorDef struct {
+	Type      string `json:"type"`      // ema / sma / rsi / atr / macd 或自定义注册的指标
+	Period    int    `json:"period"`    // 计算周期（macd 忽略）
+	Source    string `json:"source"`    // 数据来源，默认 close（atr 固定使用高低收）
+	Timeframe string `json:"timeframe"` // K线周期（需在交易员分析周期内，否则不输出）
+}
+
+// IndicatorValue 计算结果（Key 为稳定键，如 3m_ema20、4h_sma50_volume）
+type IndicatorValue struct {
+	Key   string  `json:"key"`
+	Value float64 `json:"value"`
+}
+
+// IndicatorFunc 指标计算函数（K线按时间旧→新）
+type IndicatorFunc func(klines []Kline, period int, source string) float64
+
+var (
+	indicatorRegistryMu sync.RWMutex
+	indicatorRegistry   = map[string]IndicatorFunc{
+		"ema": func(klines []Kline, period int, source string) float64 {
+			return emaOf(sourceValues(klines, source), period)
+		},
+		"sma": func(klines []Kline, period int, source string) float64 {
+			return smaOf(sourceValues(klines, source), period)
+		},
+		"rsi": func(klines []Kline, period int, source string) float64 {
+			return rsiOf(sourceValues(klines, source), period)
+		},
+		"atr": func(klines []Kline, period int, _ string) float64 {
+			return calculateATR(klines, period)
+		},
+		"macd": func(klines []Kline, _ int, source string) float64 {
+			values := sourceValues(klines, source)
+			if len(values) < 26 {
+				return 0
+			}
+			return emaOf(values, 12) - emaOf(values, 26)
+		},
+	}
+)
+
+// RegisterIndicator 注册自定义指标（同名覆盖）
+func RegisterIndicator(name string, fn IndicatorFunc) {
+	indicatorRegistryMu.Lock()
+	defer indicatorRegistryMu.Unlock()
+	indicatorRegistry[strings.ToLower(name)] = fn
+}
+
+func lookupIndicator(name string) (IndicatorFunc, bool) {
+	indicatorRegistryMu.RLock()
+	defer indicatorRegistryMu.RUnlock()
+	fn, ok := indicatorRegistry[name]
+	return fn, ok
+}
+
+// DefaultIndicatorSet 默认指标集（与标准输出中固定的 EMA20/RSI7/RSI14/ATR14 一致）
+func DefaultIndicatorSet() []IndicatorDef {
+	return []IndicatorDef{
+		{Type: "ema", Period: 20, Source: SourceClose, Timeframe: "3m"},
+		{Type: "rsi", Period: 7, Source: SourceClose, Timeframe: "3m"},
+		{Type: "rsi", Period: 14, Source: SourceClose, Timeframe: "4h"},
+		{Type: "atr", Period: 14, Source: SourceClose, Timeframe: "4h"},
+	}
+}
+
+// Normalize 校验并补全默认值
+func (d *IndicatorDef) Normalize() error {
+	d.Type = strings.ToLower(strings.TrimSpace(d.Type))
+	if _, ok := lookupIndicator(d.Type); !ok {
+		return fmt.Errorf("未知的指标类型: %s", d.Type)
+	}
+	if d.Source == "" {
+		d.Source = SourceClose
+	}
+	switch d.Source {
+	case SourceClose, SourceOpen, SourceHigh, SourceLow, SourceHL2, SourceHLC3, SourceVolume:
+	default:
+		return fmt.Errorf("未知的数据来源: %s", d.Source)
+	}
+	if !IsSupportedTimeframe(d.Timeframe) {
+		return fmt.Errorf("不支持的指标周期: %s（可选 %s）", d.Timeframe, strings.Join(SupportedTimeframes, " / "))
+	}
+	if d.Type != "macd" && (d.Period <= 0 || d.Period > 200) {
+		return fmt.Errorf("%s 周期必须在1-200之间: %d", d.Type, d.Period)
+	}
+	return nil
+}
+
+// Key 稳定输出键：<周期>_<类型><参数>[_<来源>]，来源为 close 时省略
+func (d IndicatorDef) Key() string {
+	key := d.Timeframe + "_" + d.Type
+	if d.Type != "macd" {
+		key += fmt.Sprintf("%d", d.Period)
+	}
+	if d.Source != "" && d.Source != SourceClose && d.Type != "atr" {
+		key += "_" + d.Source
+	}
+	return key
+}
+
+// NormalizeIndicatorSet 校验指标集并去除重复键
+func NormalizeIndicatorSet(defs []IndicatorDef) ([]IndicatorDef, error) {
+	seen := make(map[string]bool)
+	result := make([]IndicatorDef, 0, len(defs))
+	for i := range defs {
+		d := defs[i]
+		if err := d.Normalize(); err != nil {
+			return nil, fmt.Errorf("指标 #%d: %w", i+1, err)
+		}
+		if seen[d.Key()] {
+			continue
+		}
+		seen[d.Key()] = true
+		result = append(result, d)
+	}
+	return result, nil
+}
+
+// ComputeIndicators 按指标定义计算市场数据中各周期K线的指标值（顺序与定义一致）
+func ComputeIndicators(data *Data, defs []IndicatorDef) []IndicatorValue {
+	values := make([]IndicatorValue, 0, len(defs))
+	for _, d := range defs {
+		fn, ok := lookupIndicator(d.Type)
+		if !ok {
+			continue
+		}
+		klines := data.klines[d.Timeframe]
+		if len(klines) == 0 {
+			continue
+		}
+		values = append(values, IndicatorValue{Key: d.Key(), Value: fn(klines, d.Period, d.Source)})
+	}
+	return values
+}
+
+// formatIndicators 紧凑输出自定义指标（一行，键稳定）
+func formatIndicators(values []IndicatorValue) string {
+	if len(values) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		parts = append(parts, fmt.Sprintf("%s %s", v.Key, formatIndicatorValue(v.Value)))
+	}
+	return "indicators: " + strings.Join(parts, ", ") + "\n"
+}
+
+func formatIndicatorValue(v float64) string {
+	if math.Abs(v) >= 1000 {
+		return fmt.Sprintf("%.2f", v)
+	}
+	return fmt.Sprintf("%.4g", v)
+}
+
+// sourceValues 按数据来源提取序列
+func sourceValues(klines []Kline, source string) []float64 {
+	values := make([]float64, len(klines))
+	for i, k := range klines {
+		switch source {
+		case SourceOpen:
+			values[i] = k.Open
+		case SourceHigh:
+			values[i] = k.High
+		case SourceLow:
+			values[i] = k.Low
+		case SourceHL2:
+			values[i] = (k.High + k.Low) / 2
+		case SourceHLC3:
+			values[i] = (k.High + k.Low + k.Close) / 3
+		case SourceVolume:
+			values[i] = k.Volume
+		default:
+			values[i] = k.Close
+		}
+	}
+	return values
+}
+
+// smaOf 最近 period 个值的简单平均
+func smaOf(values []float64, period int) float64 {
+	if period <= 0 || len(values) < period {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values[len(values)-period:] {
+		sum += v
+	}
+	return sum / float64(period)
+}
+
+// emaOf EMA（以前 period 个值的SMA为初始值，与 calculateEMA 一致）
+func emaOf(values []float64, period int) float64 {
+	if period <= 0 || len(values) < period {
+		return 0
+	}
+	ema := 0.0
+	for _, v := range values[:period] {
+		ema += v
+	}
+	ema /= float64(period)
+	multiplier := 2.0 / float64(period+1)
+	for _, v := range values[period:] {
+		ema = (v-ema)*multiplier + ema
+	}
+	return ema
+}
+
+// rsiOf Wilder平滑RSI（与 calculateRSI 一致）
+func rsiOf(values []float64, period int) float64 {
+	if period <= 0 || len(values) <= period {
+		return 0
+	}
+	gains, losses := 0.0, 0.0
+	for i := 1; i <= period; i++ {
+		if change := values[i] - values[i-1]; change > 0 {
+			gains += change
+		} else {
+			losses -= change
+		}
+	}
+	avgGain := gains / float64(period)
+	avgLoss := losses / float64(period)
+	for i := period + 1; i < len(values); i++ {
+		change := values[i] - values[i-1]
+		gain, loss := 0.0, 0.0
+		if change > 0 {
+			gain = change
+		} else {
+			loss = -change
+		}
+		avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+	}
+	if avgLoss == 0 {
+		return 100
+	}
+	return 100 - 100/(1+avgGain/avgLoss)
+}
@@ -0,0 +1,91 @@
+package market
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestIndicatorDefKey(t *testing.T) {
+	tests := []struct {
+		name string
+		def  IndicatorDef
+		want string
+	}{
+		{"收盘价省略来源", IndicatorDef{Type: "ema", Period: 20, Timeframe: "3m"}, "3m_ema20"},
+		{"非收盘价带来源", IndicatorDef{Type: "sma", Period: 50, Source: SourceVolume, Timeframe: "4h"}, "4h_sma50_volume"},
+		{"ATR不带来源", IndicatorDef{Type: "atr", Period: 14, Source: SourceHigh, Timeframe: "4h"}, "4h_atr14"},
+		{"MACD不带周期", IndicatorDef{Type: "macd", Timeframe: "3m"}, "3m_macd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := tt.def
+			if err := def.Normalize(); err != nil {
+				t.Fatalf("Normalize() error: %v", err)
+			}
+			if got := def.Key(); got != tt.want {
+				t.Errorf("Key() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeIndicatorSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		defs    []IndicatorDef
+		wantLen int
+		wantErr bool
+	}{
+		{"默认指标集有效", DefaultIndicatorSet(), 4, false},
+		{"重复键去重", []IndicatorDef{{Type: "EMA", Period: 20, Timeframe: "3m"}, {Type: "ema", Period: 20, Source: "close", Timeframe: "3m"}}, 1, false},
+		{"未知类型", []IndicatorDef{{Type: "vwap", Period: 20, Timeframe: "3m"}}, 0, true},
+		{"不支持的K线周期", []IndicatorDef{{Type: "ema", Period: 20, Timeframe: "1w"}}, 0, true},
+		{"周期无效", []IndicatorDef{{Type: "rsi", Period: 0, Timeframe: "4h"}}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeIndicatorSet(tt.defs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("len = %d, want %d", len(got), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestComputeIndicatorsMatchesFixedIndicators(t *testing.T) {
+	klines := make([]Kline, 120)
+	for i := range klines {
+		c := 100 + 10*math.Sin(float64(i)/5) + float64(i)*0.1
+		klines[i] = Kline{Open: c - 0.5, High: c + 1, Low: c - 1, Close: c, Volume: float64(100 + i)}
+	}
+	data := &Data{klines: map[string][]Kline{"3m": klines, "4h": klines}}
+
+	defs, err := NormalizeIndicatorSet(append(DefaultIndicatorSet(), IndicatorDef{Type: "macd", Timeframe: "3m"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := ComputeIndicators(data, defs)
+	want := map[string]float64{
+		"3m_ema20": calculateEMA(klines, 20),
+		"3m_rsi7":  calculateRSI(klines, 7),
+		"4h_rsi14": calculateRSI(klines, 14),
+		"4h_atr14": calculateATR(klines, 14),
+		"3m_macd":  calculateMACD(klines),
+	}
+	if len(values) != len(want) {
+		t.Fatalf("got %d values, want %d", len(values), len(want))
+	}
+	for _, v := range values {
+		if math.Abs(v.Value-want[v.Key]) > 1e-9 {
+			t.Errorf("%s = %f, want %f", v.Key, v.Value, want[v.Key])
+		}
+	}
+
+	if out := formatIndicators(values); !strings.HasPrefix(out, "indicators: 3m_ema20 ") {
+		t.Errorf("unexpected compact output: %q", out)
+	}
+}
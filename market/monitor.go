@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"nofx/events"
+	"nofx/loglevel"
 	"strings"
 	"sync"
 	"time"
@@ -11,12 +13,11 @@ import (
 
 type WSMonitor struct {
 	wsClient       *WSClient
-	combinedClient *CombinedStreamsClient
+	combinedClient *ShardedStreamsClient // 多连接分片，币种较多时自动拆分到多个WS连接
 	symbols        []string
 	featuresMap    sync.Map
 	alertsChan     chan Alert
-	klineDataMap3m sync.Map // 存储每个交易对的K线历史数据
-	klineDataMap4h sync.Map // 存储每个交易对的K线历史数据
+	klineDataMaps  sync.Map // 周期 -> *sync.Map（每个交易对的K线历史数据）
 	tickerDataMap  sync.Map // 存储每个交易对的ticker数据
 	batchSize      int
 	filterSymbols  sync.Map // 使用sync.Map来存储需要监控的币种和其状态
@@ -32,12 +33,40 @@ type SymbolStats struct {
 }
 
 var WSMonitorCli *WSMonitor
-var subKlineTime = []string{"3m", "4h"} // 管理订阅流的K线周期
+var subKlineTime = []string{"3m", "4h"} // 管理订阅流的K线周期（交易员选择的分析周期通过 EnsureTimeframes 追加）
+var subKlineMu sync.RWMutex
+
+// subscribedTimeframes 当前订阅/预热的K线周期
+func subscribedTimeframes() []string {
+	subKlineMu.RLock()
+	defer subKlineMu.RUnlock()
+	return append([]string(nil), subKlineTime...)
+}
+
+// EnsureTimeframes 把交易员选择的分析周期加入订阅周期（启动前调用时随全部交易对一起订阅，
+// 启动后新增的周期在首次获取K线时按币种动态订阅）
+func EnsureTimeframes(timeframes []string) {
+	subKlineMu.Lock()
+	defer subKlineMu.Unlock()
+	for _, tf := range timeframes {
+		exists := false
+		for _, st := range subKlineTime {
+			if st == tf {
+				exists = true
+				break
+			}
+		}
+		if !exists && IsSupportedTimeframe(tf) {
+			subKlineTime = append(subKlineTime, tf)
+			log.Printf("📈 新增K线订阅周期: %s", tf)
+		}
+	}
+}
 
 func NewWSMonitor(batchSize int) *WSMonitor {
 	WSMonitorCli = &WSMonitor{
 		wsClient:       NewWSClient(),
-		combinedClient: NewCombinedStreamsClient(batchSize),
+		combinedClient: NewShardedStreamsClient(batchSize, defaultMaxStreamsPerShard),
 		alertsChan:     make(chan Alert, 1000),
 		batchSize:      batchSize,
 	}
@@ -80,6 +109,7 @@ func (m *WSMonitor) initializeHistoricalData() error {
 
 	var wg sync.WaitGroup
 	semaphore := make(chan struct{}, 5) // 限制并发数
+	timeframes := subscribedTimeframes()
 
 	for _, symbol := range m.symbols {
 		wg.Add(1)
@@ -89,25 +119,18 @@ func (m *WSMonitor) initializeHistoricalData() error {
 			defer wg.Done()
 			defer func() { <-semaphore }()
 
-			// 获取历史K线数据
-			klines, err := apiClient.GetKlines(s, "3m", 100)
-			if err != nil {
-				log.Printf("获取 %s 历史数据失败: %v", s, err)
-				return
-			}
-			if len(klines) > 0 {
-				m.klineDataMap3m.Store(s, klines)
-				log.Printf("已加载 %s 的历史K线数据-3m: %d 条", s, len(klines))
-			}
-			// 获取历史K线数据
-			klines4h, err := apiClient.GetKlines(s, "4h", 100)
-			if err != nil {
-				log.Printf("获取 %s 历史数据失败: %v", s, err)
-				return
-			}
-			if len(klines4h) > 0 {
-				m.klineDataMap4h.Store(s, klines4h)
-				log.Printf("已加载 %s 的历史K线数据-4h: %d 条", s, len(klines4h))
+			// 获取各订阅周期的历史K线数据
+			for _, st := range timeframes {
+				klines, err := apiClient.GetKlines(s, st, 100)
+				if err != nil {
+					// 单个周期失败不影响该币种其余周期的加载
+					loglevel.Warnf(loglevel.WS, "获取 %s 历史数据失败-%s: %v", s, st, err)
+					continue
+				}
+				if len(klines) > 0 {
+					m.getKlineDataMap(st).Store(s, klines)
+					loglevel.Debugf(loglevel.WS, "已加载 %s 的历史K线数据-%s: %d 条", s, st, len(klines))
+				}
 			}
 		}(symbol)
 	}
@@ -151,12 +174,13 @@ func (m *WSMonitor) subscribeSymbol(symbol, st string) []string {
 func (m *WSMonitor) subscribeAll() error {
 	// 执行批量订阅
 	log.Println("开始订阅所有交易对...")
+	timeframes := subscribedTimeframes()
 	for _, symbol := range m.symbols {
-		for _, st := range subKlineTime {
+		for _, st := range timeframes {
 			m.subscribeSymbol(symbol, st)
 		}
 	}
-	for _, st := range subKlineTime {
+	for _, st := range timeframes {
 		err := m.combinedClient.BatchSubscribeKlines(m.symbols, st)
 		if err != nil {
 			log.Printf("❌ 订阅 %s K线失败: %v", st, err)
@@ -171,7 +195,7 @@ func (m *WSMonitor) handleKlineData(symbol string, ch <-chan []byte, _time strin
 	for data := range ch {
 		var klineData KlineWSData
 		if err := json.Unmarshal(data, &klineData); err != nil {
-			log.Printf("解析Kline数据失败: %v", err)
+			loglevel.Sampledf(loglevel.WS, loglevel.LevelWarn, "parse_kline", time.Minute, "解析Kline数据失败: %v", err)
 			continue
 		}
 		m.processKlineUpdate(symbol, klineData, _time)
@@ -179,15 +203,8 @@ func (m *WSMonitor) handleKlineData(symbol string, ch <-chan []byte, _time strin
 }
 
 func (m *WSMonitor) getKlineDataMap(_time string) *sync.Map {
-	var klineDataMap *sync.Map
-	if _time == "3m" {
-		klineDataMap = &m.klineDataMap3m
-	} else if _time == "4h" {
-		klineDataMap = &m.klineDataMap4h
-	} else {
-		klineDataMap = &sync.Map{}
-	}
-	return klineDataMap
+	klineDataMap, _ := m.klineDataMaps.LoadOrStore(_time, &sync.Map{})
+	return klineDataMap.(*sync.Map)
 }
 func (m *WSMonitor) processKlineUpdate(symbol string, wsData KlineWSData, _time string) {
 	// 转换WebSocket数据为Kline结构
@@ -230,6 +247,20 @@ func (m *WSMonitor) processKlineUpdate(symbol string, wsData KlineWSData, _time
 	}
 
 	klineDataMap.Store(symbol, klines)
+
+	if wsData.Kline.IsFinal {
+		events.Publish(events.KlineClosed, events.KlineClosedEvent{
+			Symbol:    symbol,
+			Interval:  _time,
+			OpenTime:  kline.OpenTime,
+			CloseTime: kline.CloseTime,
+			Open:      kline.Open,
+			High:      kline.High,
+			Low:       kline.Low,
+			Close:     kline.Close,
+			Volume:    kline.Volume,
+		})
+	}
 }
 
 func (m *WSMonitor) GetCurrentKlines(symbol string, _time string) ([]Kline, error) {
@@ -249,9 +280,9 @@ func (m *WSMonitor) GetCurrentKlines(symbol string, _time string) ([]Kline, erro
 		// 订阅 WebSocket 流
 		subStr := m.subscribeSymbol(symbol, _time)
 		subErr := m.combinedClient.subscribeStreams(subStr)
-		log.Printf("动态订阅流: %v", subStr)
+		loglevel.Infof(loglevel.WS, "动态订阅流: %v", subStr)
 		if subErr != nil {
-			log.Printf("警告: 动态订阅%v分钟K线失败: %v (使用API数据)", _time, subErr)
+			loglevel.Warnf(loglevel.WS, "警告: 动态订阅%v分钟K线失败: %v (使用API数据)", _time, subErr)
 		}
 
 		// ✅ FIX: 返回深拷贝而非引用
@@ -267,6 +298,28 @@ func (m *WSMonitor) GetCurrentKlines(symbol string, _time string) ([]Kline, erro
 	return result, nil
 }
 
+// seedKlines 用完整历史K线覆盖缓存（预热时缓存K线不足的情况）
+func (m *WSMonitor) seedKlines(symbol, _time string, klines []Kline) {
+	if len(klines) == 0 {
+		return
+	}
+	dataMap := m.getKlineDataMap(_time)
+	if value, exists := dataMap.Load(symbol); exists && len(value.([]Kline)) >= len(klines) {
+		return
+	}
+	dataMap.Store(symbol, klines)
+}
+
+// GetSymbols 获取监控中的交易对列表
+func (m *WSMonitor) GetSymbols() []string {
+	return append([]string(nil), m.symbols...)
+}
+
+// GetShardHealth 获取各WS分片的健康指标
+func (m *WSMonitor) GetShardHealth() []ShardHealth {
+	return m.combinedClient.Health()
+}
+
 func (m *WSMonitor) Close() {
 	m.wsClient.Close()
 	close(m.alertsChan)
@@ -0,0 +1,156 @@
+package market
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MarketDataProvider 市场数据来源：本进程计算，或由独立的分析服务计算后通过HTTP获取
+type MarketDataProvider interface {
+	Get(symbol string) (*Data, error)
+}
+
+// localProvider 本进程内计算（默认）
+type localProvider struct{}
+
+func (localProvider) Get(symbol string) (*Data, error) {
+	return analyzeLocal(symbol)
+}
+
+var (
+	providerMu sync.RWMutex
+	provider   MarketDataProvider = localProvider{}
+)
+
+// SetProvider 替换市场数据来源（nil 恢复本进程计算）
+func SetProvider(p MarketDataProvider) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	if p == nil {
+		p = localProvider{}
+	}
+	provider = p
+}
+
+// Get 获取指定代币的市场数据（通过当前配置的 MarketDataProvider）
+func Get(symbol string) (*Data, error) {
+	providerMu.RLock()
+	p := provider
+	providerMu.RUnlock()
+	return p.Get(symbol)
+}
+
+// dataEnvelope 分析服务传输格式（附带K线，使调用方可以按自己的指标集计算指标；分析服务只提供默认周期）
+type dataEnvelope struct {
+	Data     *Data   `json:"data"`
+	Klines3m []Kline `json:"klines_3m"`
+	Klines4h []Kline `json:"klines_4h"`
+}
+
+func newDataEnvelope(data *Data) dataEnvelope {
+	return dataEnvelope{Data: data, Klines3m: data.klines["3m"], Klines4h: data.klines["4h"]}
+}
+
+func (e dataEnvelope) unwrap() (*Data, error) {
+	if e.Data == nil {
+		return nil, fmt.Errorf("分析服务返回数据为空")
+	}
+	e.Data.klines = map[string][]Kline{"3m": e.Klines3m, "4h": e.Klines4h}
+	return e.Data, nil
+}
+
+// RemoteProvider 从独立的市场分析服务获取数据（服务端见 NewAnalysisHandler）
+type RemoteProvider struct {
+	baseURL  string
+	token    string
+	client   *http.Client
+	fallback bool // 服务不可用时退回本进程计算
+}
+
+// NewRemoteProvider 创建分析服务客户端（fallback=true 时请求失败改用本进程计算）
+func NewRemoteProvider(baseURL, token string, timeout time.Duration, fallback bool) *RemoteProvider {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &RemoteProvider{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		token:    token,
+		client:   &http.Client{Timeout: timeout},
+		fallback: fallback,
+	}
+}
+
+// Get 请求分析服务计算指定代币的市场数据
+func (r *RemoteProvider) Get(symbol string) (*Data, error) {
+	data, err := r.fetch(Normalize(symbol))
+	if err != nil && r.fallback {
+		log.Printf("⚠️  市场分析服务请求 %s 失败，改用本地计算: %v", symbol, err)
+		return analyzeLocal(symbol)
+	}
+	return data, err
+}
+
+// GetBatch 一次请求多个币种（分析服务端并行计算），返回成功的币种和失败原因
+func (r *RemoteProvider) GetBatch(symbols []string) (map[string]*Data, map[string]string, error) {
+	body, err := json.Marshal(analysisBatchRequest{Symbols: symbols})
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, r.baseURL+"/market/batch", bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var resp analysisBatchResponse
+	if err := r.do(req, &resp); err != nil {
+		return nil, nil, err
+	}
+	result := make(map[string]*Data, len(resp.Data))
+	for symbol, envelope := range resp.Data {
+		if data, err := envelope.unwrap(); err == nil {
+			result[symbol] = data
+		}
+	}
+	return result, resp.Errors, nil
+}
+
+func (r *RemoteProvider) fetch(symbol string) (*Data, error) {
+	req, err := http.NewRequest(http.MethodGet, r.baseURL+"/market/data?symbol="+url.QueryEscape(symbol), nil)
+	if err != nil {
+		return nil, err
+	}
+	var envelope dataEnvelope
+	if err := r.do(req, &envelope); err != nil {
+		return nil, err
+	}
+	return envelope.unwrap()
+}
+
+func (r *RemoteProvider) do(req *http.Request, out interface{}) error {
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("分析服务返回 %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,56 @@
+package market
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRemoteProviderRoundTrip(t *testing.T) {
+	klines := make([]Kline, 30)
+	for i := range klines {
+		price := 100 + float64(i)
+		klines[i] = Kline{OpenTime: int64(i) * 180000, Open: price, High: price + 1, Low: price - 1, Close: price, Volume: 10}
+	}
+	served := &Data{
+		Symbol:          "SOLUSDT",
+		CurrentPrice:    129,
+		FundingRate:     0.0001,
+		FundingInterval: 4 * time.Hour,
+		klines:          map[string][]Kline{"3m": klines, "4h": klines},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/market/data", func(w http.ResponseWriter, r *http.Request) {
+		writeAnalysisJSON(w, http.StatusOK, newDataEnvelope(served))
+	})
+	server := httptest.NewServer(requireAnalysisToken("secret", mux))
+	defer server.Close()
+
+	// 令牌错误时返回401，不允许退回本地计算时直接报错
+	if _, err := NewRemoteProvider(server.URL, "wrong", time.Second, false).Get("SOLUSDT"); err == nil {
+		t.Fatal("令牌错误时应返回错误")
+	}
+
+	data, err := NewRemoteProvider(server.URL, "secret", time.Second, false).Get("SOLUSDT")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"币种", data.Symbol, "SOLUSDT"},
+		{"价格", data.CurrentPrice, 129.0},
+		{"资金费间隔", data.FundingInterval, 4 * time.Hour},
+		{"3m K线随数据传输", len(data.klines["3m"]), 30},
+		{"可按指标集计算指标", len(ComputeIndicators(data, []IndicatorDef{{Type: "ema", Period: 20, Timeframe: "3m"}})), 1},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
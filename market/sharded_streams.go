@@ -0,0 +1,186 @@
+package market
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// defaultMaxStreamsPerShard 单个WS连接承载的最大流数量
+// Binance 单连接上限 1024 个流，且每秒消息数有限制，这里保守取 400（约200个币种 × 2个K线周期）
+const defaultMaxStreamsPerShard = 400
+
+// ShardedStreamsClient 多连接分片的组合流客户端
+// 订阅数超过单连接容量时自动新建连接，新流总是分配到负载最小的分片，
+// 所有分片的数据都回调到同一批订阅者（WSMonitor 共享的K线存储）
+type ShardedStreamsClient struct {
+	mu                 sync.RWMutex
+	shards             []*CombinedStreamsClient
+	streamShard        map[string]*CombinedStreamsClient // stream -> 所属分片
+	batchSize          int
+	maxStreamsPerShard int
+	connected          bool
+}
+
+// NewShardedStreamsClient 创建分片组合流客户端
+func NewShardedStreamsClient(batchSize, maxStreamsPerShard int) *ShardedStreamsClient {
+	if maxStreamsPerShard <= 0 {
+		maxStreamsPerShard = defaultMaxStreamsPerShard
+	}
+	return &ShardedStreamsClient{
+		streamShard:        make(map[string]*CombinedStreamsClient),
+		batchSize:          batchSize,
+		maxStreamsPerShard: maxStreamsPerShard,
+	}
+}
+
+// Connect 连接所有已有分片（没有分片时先创建一个）
+func (s *ShardedStreamsClient) Connect() error {
+	s.mu.Lock()
+	if len(s.shards) == 0 {
+		s.newShardLocked()
+	}
+	shards := append([]*CombinedStreamsClient(nil), s.shards...)
+	s.connected = true
+	s.mu.Unlock()
+
+	for _, shard := range shards {
+		if err := shard.Connect(); err != nil {
+			return fmt.Errorf("shard %d: %w", shard.shardID, err)
+		}
+	}
+	return nil
+}
+
+// newShardLocked 新建分片（调用方需持有写锁）
+func (s *ShardedStreamsClient) newShardLocked() *CombinedStreamsClient {
+	shard := NewCombinedStreamsClient(s.batchSize)
+	shard.shardID = len(s.shards)
+	s.shards = append(s.shards, shard)
+	log.Printf("📡 新建WS分片 #%d（当前共 %d 个连接）", shard.shardID, len(s.shards))
+	return shard
+}
+
+// assignLocked 为流分配分片：已分配的直接返回，否则选负载最小且未满的分片，全部满了就新建
+// 返回值 created 表示是否新建了分片（需要在释放锁后连接）
+func (s *ShardedStreamsClient) assignLocked(stream string) (shard *CombinedStreamsClient, created bool) {
+	if shard, ok := s.streamShard[stream]; ok {
+		return shard, false
+	}
+
+	load := make(map[*CombinedStreamsClient]int, len(s.shards))
+	for _, sh := range s.streamShard {
+		load[sh]++
+	}
+
+	for _, sh := range s.shards {
+		if load[sh] >= s.maxStreamsPerShard {
+			continue
+		}
+		if shard == nil || load[sh] < load[shard] {
+			shard = sh
+		}
+	}
+	if shard == nil {
+		shard = s.newShardLocked()
+		created = true
+	}
+
+	s.streamShard[stream] = shard
+	return shard, created
+}
+
+// AddSubscriber 注册流的订阅者（同时确定该流所在分片）
+func (s *ShardedStreamsClient) AddSubscriber(stream string, bufferSize int) <-chan []byte {
+	s.mu.Lock()
+	shard, created := s.assignLocked(stream)
+	connected := s.connected
+	s.mu.Unlock()
+
+	if created && connected {
+		if err := shard.Connect(); err != nil {
+			log.Printf("❌ WS分片 #%d 连接失败: %v", shard.shardID, err)
+		}
+	}
+	return shard.AddSubscriber(stream, bufferSize)
+}
+
+// subscribeStreams 按分片分组后订阅
+func (s *ShardedStreamsClient) subscribeStreams(streams []string) error {
+	for shard, group := range s.groupByShard(streams) {
+		if err := shard.subscribeStreams(group); err != nil {
+			return fmt.Errorf("shard %d: %w", shard.shardID, err)
+		}
+	}
+	return nil
+}
+
+// BatchSubscribeKlines 批量订阅K线（按分片分组，各分片内部再分批）
+func (s *ShardedStreamsClient) BatchSubscribeKlines(symbols []string, interval string) error {
+	streams := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		streams[i] = fmt.Sprintf("%s@kline_%s", strings.ToLower(symbol), interval)
+	}
+
+	for shard, group := range s.groupByShard(streams) {
+		log.Printf("📡 WS分片 #%d 订阅 %d 个 %s K线流", shard.shardID, len(group), interval)
+		if err := shard.BatchSubscribeStreams(group); err != nil {
+			return fmt.Errorf("shard %d: %w", shard.shardID, err)
+		}
+	}
+	return nil
+}
+
+// groupByShard 把流按所属分片分组（未分配的流会先分配）
+func (s *ShardedStreamsClient) groupByShard(streams []string) map[*CombinedStreamsClient][]string {
+	groups := make(map[*CombinedStreamsClient][]string)
+	var created []*CombinedStreamsClient
+
+	s.mu.Lock()
+	for _, stream := range streams {
+		shard, isNew := s.assignLocked(stream)
+		if isNew {
+			created = append(created, shard)
+		}
+		groups[shard] = append(groups[shard], stream)
+	}
+	connected := s.connected
+	s.mu.Unlock()
+
+	if connected {
+		for _, shard := range created {
+			if err := shard.Connect(); err != nil {
+				log.Printf("❌ WS分片 #%d 连接失败: %v", shard.shardID, err)
+			}
+		}
+	}
+	return groups
+}
+
+// Health 获取所有分片的健康指标
+func (s *ShardedStreamsClient) Health() []ShardHealth {
+	s.mu.RLock()
+	shards := append([]*CombinedStreamsClient(nil), s.shards...)
+	s.mu.RUnlock()
+
+	result := make([]ShardHealth, 0, len(shards))
+	for _, shard := range shards {
+		result = append(result, shard.Health())
+	}
+	return result
+}
+
+// Close 关闭所有分片
+func (s *ShardedStreamsClient) Close() {
+	s.mu.Lock()
+	shards := s.shards
+	s.shards = nil
+	s.streamShard = make(map[string]*CombinedStreamsClient)
+	s.connected = false
+	s.mu.Unlock()
+
+	for _, shard := range shards {
+		shard.Close()
+	}
+}
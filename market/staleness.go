@@ -0,0 +1,57 @@
+package market
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// staleCandleFactor 最新K线开盘时间距今超过该倍数的周期时长时视为数据陈旧（正常情况下最新K线是正在形成的K线，距今不超过1个周期）
+const staleCandleFactor = 2
+
+// StaleTimeframe 最新K线落后于预期的分析周期（WS延迟、交易所故障等导致行情冻结）
+type StaleTimeframe struct {
+	Interval   string        `json:"interval"`
+	LastCandle time.Time     `json:"last_candle"` // 最新K线开盘时间
+	Age        time.Duration `json:"age"`         // 最新K线开盘距今
+}
+
+// String 陈旧周期描述（用于提示词和执行日志）
+func (s StaleTimeframe) String() string {
+	expected, _ := IntervalDuration(s.Interval)
+	return fmt.Sprintf("%s 最新K线开盘于 %s 前（正常 ≤%s）", s.Interval, formatAge(s.Age), formatAge(expected))
+}
+
+// StaleTimeframes 各分析周期中最新K线落后于预期的周期（短 → 长），数据正常时返回空
+func (d *Data) StaleTimeframes() []StaleTimeframe {
+	return staleTimeframes(d.klines, now())
+}
+
+func staleTimeframes(series map[string][]Kline, at time.Time) []StaleTimeframe {
+	var stale []StaleTimeframe
+	for tf, klines := range series {
+		interval, err := IntervalDuration(tf)
+		if err != nil || len(klines) == 0 {
+			continue
+		}
+		last := time.UnixMilli(klines[len(klines)-1].OpenTime)
+		if age := at.Sub(last); age > staleCandleFactor*interval {
+			stale = append(stale, StaleTimeframe{Interval: tf, LastCandle: last, Age: age})
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		return timeframeDuration(stale[i].Interval) < timeframeDuration(stale[j].Interval)
+	})
+	return stale
+}
+
+// formatAge 时长描述（分钟 / 小时 / 天）
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%.0f天", d.Hours()/24)
+	case d >= 2*time.Hour:
+		return fmt.Sprintf("%.0f小时", d.Hours())
+	}
+	return fmt.Sprintf("%.0f分钟", d.Minutes())
+}
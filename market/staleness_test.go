@@ -0,0 +1,37 @@
+package market
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStaleTimeframes(t *testing.T) {
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	candle := func(age time.Duration) []Kline {
+		return []Kline{{OpenTime: at.Add(-age).UnixMilli()}}
+	}
+	tests := []struct {
+		name   string
+		series map[string][]Kline
+		want   []string
+	}{
+		{"数据正常", map[string][]Kline{"3m": candle(2 * time.Minute), "4h": candle(3 * time.Hour)}, nil},
+		{"刚好两个周期不算陈旧", map[string][]Kline{"3m": candle(6 * time.Minute)}, nil},
+		{"短周期冻结", map[string][]Kline{"3m": candle(15 * time.Minute), "4h": candle(time.Hour)}, []string{"3m"}},
+		{"按周期时长排序", map[string][]Kline{"4h": candle(9 * time.Hour), "1m": candle(5 * time.Minute)}, []string{"1m", "4h"}},
+		{"空K线跳过", map[string][]Kline{"3m": nil}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := staleTimeframes(tt.series, at)
+			if len(got) != len(tt.want) {
+				t.Fatalf("staleTimeframes() = %v, want %v", got, tt.want)
+			}
+			for i, tf := range tt.want {
+				if got[i].Interval != tf {
+					t.Errorf("staleTimeframes()[%d] = %s, want %s", i, got[i].Interval, tf)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package market
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SupportedTimeframes 可分析的K线周期（短 → 长）
+var SupportedTimeframes = []string{"1m", "3m", "5m", "15m", "30m", "1h", "2h", "4h", "6h", "12h", "1d"}
+
+// DefaultTimeframes 默认分析周期：3m 日内序列 + 4h 长期背景
+var DefaultTimeframes = []string{"3m", "4h"}
+
+// TimeframePresets 按交易风格预设的分析周期
+var TimeframePresets = map[string][]string{
+	"scalper":  {"1m", "3m", "15m"},
+	"intraday": {"3m", "4h"},
+	"swing":    {"1h", "4h", "1d"},
+}
+
+const maxTimeframes = 4 // 最多分析的周期数（控制prompt长度和K线请求数）
+
+// TimeframeSummary 中间周期摘要（自定义周期多于2个时，最短/最长以外的周期）
+type TimeframeSummary struct {
+	Interval  string  `json:"interval"`
+	ChangePct float64 `json:"change_pct"` // 相对上一根K线收盘价的涨跌幅
+	EMA20     float64 `json:"ema20"`
+	EMA50     float64 `json:"ema50"`
+	MACD      float64 `json:"macd"`
+	RSI14     float64 `json:"rsi14"`
+	ATR14     float64 `json:"atr14"`
+}
+
+// IsSupportedTimeframe 是否为可分析的K线周期
+func IsSupportedTimeframe(interval string) bool {
+	for _, tf := range SupportedTimeframes {
+		if tf == interval {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTimeframes 校验分析周期：去重、按时长排序，至少2个（最短为日内序列，最长为长期背景）
+func NormalizeTimeframes(timeframes []string) ([]string, error) {
+	seen := make(map[string]bool, len(timeframes))
+	result := make([]string, 0, len(timeframes))
+	for _, tf := range timeframes {
+		tf = strings.ToLower(strings.TrimSpace(tf))
+		if tf == "" || seen[tf] {
+			continue
+		}
+		if !IsSupportedTimeframe(tf) {
+			return nil, fmt.Errorf("不支持的K线周期: %s（可选 %s）", tf, strings.Join(SupportedTimeframes, " / "))
+		}
+		seen[tf] = true
+		result = append(result, tf)
+	}
+	if len(result) < 2 {
+		return nil, fmt.Errorf("至少需要2个不同的K线周期（短周期 + 长周期）")
+	}
+	if len(result) > maxTimeframes {
+		return nil, fmt.Errorf("最多选择%d个K线周期", maxTimeframes)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return timeframeDuration(result[i]) < timeframeDuration(result[j])
+	})
+	return result, nil
+}
+
+// ParseTimeframes 解析预设名称（scalper/intraday/swing）或逗号分隔的周期列表
+func ParseTimeframes(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if preset, ok := TimeframePresets[strings.ToLower(value)]; ok {
+		return append([]string(nil), preset...), nil
+	}
+	return NormalizeTimeframes(strings.Split(value, ","))
+}
+
+// IsDefaultTimeframes 是否为默认分析周期（可走 MarketDataProvider，包括远程分析服务）
+func IsDefaultTimeframes(timeframes []string) bool {
+	if len(timeframes) == 0 {
+		return true
+	}
+	if len(timeframes) != len(DefaultTimeframes) {
+		return false
+	}
+	for i, tf := range timeframes {
+		if tf != DefaultTimeframes[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// GetWithTimeframes 按指定周期获取市场数据（默认周期走 Get，自定义周期在本进程内计算）
+func GetWithTimeframes(symbol string, timeframes []string) (*Data, error) {
+	if IsDefaultTimeframes(timeframes) {
+		return Get(symbol)
+	}
+	return analyzeTimeframes(symbol, timeframes)
+}
+
+// timeframeDuration 周期时长（无效周期返回0）
+func timeframeDuration(interval string) int64 {
+	d, err := IntervalDuration(interval)
+	if err != nil {
+		return 0
+	}
+	return int64(d)
+}
+
+// summarizeTimeframe 计算中间周期摘要
+func summarizeTimeframe(klines []Kline, interval string) TimeframeSummary {
+	summary := TimeframeSummary{
+		Interval: interval,
+		EMA20:    calculateEMA(klines, 20),
+		EMA50:    calculateEMA(klines, 50),
+		MACD:     calculateMACD(klines),
+		RSI14:    calculateRSI(klines, 14),
+		ATR14:    calculateATR(klines, 14),
+	}
+	if n := len(klines); n >= 2 && klines[n-2].Close > 0 {
+		summary.ChangePct = (klines[n-1].Close - klines[n-2].Close) / klines[n-2].Close * 100
+	}
+	return summary
+}
+
+// intervalLabel prompt中的周期描述（3m → 3‑minute，4h → 4‑hour，1d → 1‑day）
+func intervalLabel(interval string) string {
+	if len(interval) < 2 {
+		return interval
+	}
+	n := interval[:len(interval)-1]
+	switch interval[len(interval)-1] {
+	case 'm':
+		return n + "‑minute"
+	case 'h':
+		return n + "‑hour"
+	case 'd':
+		return n + "‑day"
+	case 'w':
+		return n + "‑week"
+	}
+	return interval
+}
+
+// TimeframeTrends 各分析周期的趋势方向（1=多头：EMA20>EMA50 且 MACD>0；-1=空头；0=不明确），K线不足50根的周期不输出
+func (d *Data) TimeframeTrends() map[string]int {
+	trends := make(map[string]int, len(d.klines))
+	for tf, klines := range d.klines {
+		if len(klines) < 50 {
+			continue
+		}
+		trends[tf] = trendDirection(klines)
+	}
+	return trends
+}
+
+// trendDirection 单个周期的趋势方向
+func trendDirection(klines []Kline) int {
+	ema20, ema50, macd := calculateEMA(klines, 20), calculateEMA(klines, 50), calculateMACD(klines)
+	switch {
+	case ema20 > ema50 && macd > 0:
+		return 1
+	case ema20 < ema50 && macd < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,64 @@
+package market
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimeframes(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"预设名称", "swing", "1h,4h,1d", false},
+		{"预设大小写不敏感", "Scalper", "1m,3m,15m", false},
+		{"按时长排序并去重", "1d, 4h,1m,4h", "1m,4h,1d", false},
+		{"默认周期", "3m,4h", "3m,4h", false},
+		{"不支持的周期", "3m,1w", "", true},
+		{"少于2个周期", "4h,4h", "", true},
+		{"超过最大周期数", "1m,3m,5m,15m,1h", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTimeframes(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTimeframes(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && strings.Join(got, ",") != tt.want {
+				t.Errorf("ParseTimeframes(%q) = %v, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceChangeOver(t *testing.T) {
+	closes := func(values ...float64) []Kline {
+		klines := make([]Kline, len(values))
+		for i, v := range values {
+			klines[i] = Kline{Close: v}
+		}
+		return klines
+	}
+	series := map[string][]Kline{
+		"15m": closes(100, 101, 102, 103, 110),
+		"4h":  closes(90, 100),
+	}
+	tests := []struct {
+		name       string
+		timeframes []string
+		want       float64
+	}{
+		{"1小时用4根15分钟K线", []string{"15m", "4h"}, 10},
+		{"没有能整除的周期", []string{"4h"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := priceChangeOver(series, tt.timeframes, 110, time.Hour); got != tt.want {
+				t.Errorf("priceChangeOver() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
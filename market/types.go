@@ -13,8 +13,37 @@ type Data struct {
 	CurrentRSI7       float64
 	OpenInterest      *OIData
 	FundingRate       float64
+	NextFundingTime   time.Time     // 下次资金费结算时间（获取失败时为零值）
+	FundingInterval   time.Duration // 资金费结算间隔（默认8小时，部分币种为4小时/1小时）
+	QuoteVolume24h    float64       // 最近24小时成交额（USDT，由覆盖24小时的K线累加）
 	IntradaySeries    *IntradayData
 	LongerTermContext *LongerTermData
+	Volatility3m      *VolatilityForecast    // 日内周期波动率预测（默认3分钟）
+	Volatility4h      *VolatilityForecast    // 长期周期波动率预测（默认4小时）
+	CandleQuality     []*CandleQualityReport // K线数据质量检查（仅保存有问题的周期）
+	Levels            *PriceLevels           // 原始价格位（支撑/阻力、近期K线，full 输出使用）
+	Indicators        []IndicatorValue       // 按交易员指标集计算的指标（为空时不输出）
+	IntradayInterval  string                 // 日内序列周期（为空表示3m）
+	ContextInterval   string                 // 长期背景周期（为空表示4h）
+	Timeframes        []TimeframeSummary     // 中间周期摘要（分析周期多于2个时）
+
+	klines map[string][]Kline // 各周期K线，用于按指标集计算指标
+}
+
+// ShortInterval 日内序列周期
+func (d *Data) ShortInterval() string {
+	if d.IntradayInterval == "" {
+		return "3m"
+	}
+	return d.IntradayInterval
+}
+
+// LongInterval 长期背景周期
+func (d *Data) LongInterval() string {
+	if d.ContextInterval == "" {
+		return "4h"
+	}
+	return d.ContextInterval
 }
 
 // OIData Open Interest数据
@@ -23,7 +52,7 @@ type OIData struct {
 	Average float64
 }
 
-// IntradayData 日内数据(3分钟间隔)
+// IntradayData 日内数据(默认3分钟间隔)
 type IntradayData struct {
 	MidPrices   []float64
 	EMA20Values []float64
@@ -32,7 +61,7 @@ type IntradayData struct {
 	RSI14Values []float64
 }
 
-// LongerTermData 长期数据(4小时时间框架)
+// LongerTermData 长期数据(默认4小时时间框架)
 type LongerTermData struct {
 	EMA20         float64
 	EMA50         float64
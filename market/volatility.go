@@ -0,0 +1,80 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	ewmaLambda           = 0.94 // RiskMetrics 衰减系数
+	realizedVolLookback  = 20   // 实现波动率的回看收益数
+	minVolatilityReturns = 10   // 预测所需的最少收益数
+)
+
+// VolatilityForecast 下一周期波动率预测（EWMA模型，基于对数收益率）
+type VolatilityForecast struct {
+	Interval      string  `json:"interval"`
+	Model         string  `json:"model"`
+	NextPeriodPct float64 `json:"next_period_pct"` // 下一根K线收益率标准差预测（%）
+	RealizedPct   float64 `json:"realized_pct"`    // 最近 realizedVolLookback 根K线实现波动率（%，每根K线）
+	DailyPct      float64 `json:"daily_pct"`       // 换算为日波动率（%）
+}
+
+// ForecastVolatility 用EWMA估计下一周期波动率：σ²(t+1) = λσ²(t) + (1-λ)r²(t)
+// 以前 realizedVolLookback 个收益的样本方差作为初始方差；数据不足时返回 nil
+func ForecastVolatility(klines []Kline, interval string) *VolatilityForecast {
+	returns := logReturns(klines)
+	if len(returns) < minVolatilityReturns {
+		return nil
+	}
+
+	seedN := realizedVolLookback
+	if seedN > len(returns) {
+		seedN = len(returns)
+	}
+	variance := sampleVariance(returns[:seedN])
+	for _, r := range returns[seedN:] {
+		variance = ewmaLambda*variance + (1-ewmaLambda)*r*r
+	}
+
+	recent := returns
+	if len(recent) > realizedVolLookback {
+		recent = recent[len(recent)-realizedVolLookback:]
+	}
+
+	forecast := &VolatilityForecast{
+		Interval:      interval,
+		Model:         fmt.Sprintf("EWMA(λ=%.2f)", ewmaLambda),
+		NextPeriodPct: math.Sqrt(variance) * 100,
+		RealizedPct:   math.Sqrt(sampleVariance(recent)) * 100,
+	}
+	if step, err := IntervalDuration(interval); err == nil && step > 0 {
+		forecast.DailyPct = forecast.NextPeriodPct * math.Sqrt(float64(24*time.Hour)/float64(step))
+	}
+	return forecast
+}
+
+// logReturns 计算收盘价对数收益率
+func logReturns(klines []Kline) []float64 {
+	returns := make([]float64, 0, len(klines))
+	for i := 1; i < len(klines); i++ {
+		if klines[i-1].Close <= 0 || klines[i].Close <= 0 {
+			continue
+		}
+		returns = append(returns, math.Log(klines[i].Close/klines[i-1].Close))
+	}
+	return returns
+}
+
+// sampleVariance 样本方差（均值取0，短周期收益率的均值可忽略）
+func sampleVariance(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v * v
+	}
+	return sum / float64(len(values))
+}
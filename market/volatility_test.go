@@ -0,0 +1,38 @@
+package market
+
+import (
+	"math"
+	"testing"
+)
+
+func TestForecastVolatility(t *testing.T) {
+	flat := make([]Kline, 5)
+	for i := range flat {
+		flat[i] = Kline{Close: 100}
+	}
+	if f := ForecastVolatility(flat, "4h"); f != nil {
+		t.Errorf("数据不足时应返回nil: %+v", f)
+	}
+
+	// 收益率在 ±1% 之间交替，EWMA 应收敛到约1%
+	klines := make([]Kline, 200)
+	price := 100.0
+	for i := range klines {
+		if i%2 == 0 {
+			price *= math.Exp(0.01)
+		} else {
+			price *= math.Exp(-0.01)
+		}
+		klines[i] = Kline{Close: price}
+	}
+	f := ForecastVolatility(klines, "4h")
+	if f == nil {
+		t.Fatal("预测结果为nil")
+	}
+	if math.Abs(f.NextPeriodPct-1) > 1e-6 || math.Abs(f.RealizedPct-1) > 1e-6 {
+		t.Errorf("NextPeriodPct = %v, RealizedPct = %v, want 1", f.NextPeriodPct, f.RealizedPct)
+	}
+	if math.Abs(f.DailyPct-math.Sqrt(6)) > 1e-6 {
+		t.Errorf("DailyPct = %v, want %v", f.DailyPct, math.Sqrt(6))
+	}
+}
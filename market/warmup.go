@@ -0,0 +1,135 @@
+package market
+
+import (
+	"fmt"
+	"nofx/loglevel"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MinHistory 各K线周期计算指标所需的最少K线数（未列出的周期使用 defaultMinHistory）
+// 3m: EMA20/MACD(26)/RSI14 + 1小时涨跌幅；4h: EMA50 + ATR14 + 10根序列
+var MinHistory = map[string]int{
+	"3m": 60,
+	"4h": 60,
+}
+
+const defaultMinHistory = 60
+
+// minHistory 周期所需的最少K线数
+func minHistory(interval string) int {
+	if n, ok := MinHistory[interval]; ok {
+		return n
+	}
+	return defaultMinHistory
+}
+
+// WarmupSymbolStatus 单个币种的预热结果
+type WarmupSymbolStatus struct {
+	Symbol       string         `json:"symbol"`
+	Klines       map[string]int `json:"klines"`        // 各周期已缓存的K线数
+	OpenInterest bool           `json:"open_interest"` // OI是否已缓存
+	FundingRate  bool           `json:"funding_rate"`  // 资金费率是否已缓存
+	Ready        bool           `json:"ready"`         // 历史长度满足全部周期要求
+	Issues       []string       `json:"issues,omitempty"`
+}
+
+// WarmupReport 行情数据预热报告
+type WarmupReport struct {
+	StartedAt  time.Time            `json:"started_at"`
+	DurationMs int64                `json:"duration_ms"`
+	Total      int                  `json:"total"`
+	Ready      int                  `json:"ready"`
+	Symbols    []WarmupSymbolStatus `json:"symbols"`
+}
+
+// Preload 并行预取并缓存币种的K线/OI/资金费率，校验各周期历史长度
+// 缓存中K线不足时用REST重新拉取完整历史覆盖（WS先于历史数据写入时可能只有几根）
+func Preload(symbols []string, concurrency int) *WarmupReport {
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+	report := &WarmupReport{StartedAt: now(), Total: len(symbols)}
+	statuses := make([]WarmupSymbolStatus, len(symbols))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, concurrency)
+	for i, symbol := range symbols {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, symbol string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			statuses[i] = preloadSymbol(Normalize(symbol))
+		}(i, symbol)
+	}
+	wg.Wait()
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Symbol < statuses[j].Symbol })
+	for _, s := range statuses {
+		if s.Ready {
+			report.Ready++
+		}
+	}
+	report.Symbols = statuses
+	report.DurationMs = since(report.StartedAt).Milliseconds()
+	return report
+}
+
+// preloadSymbol 预热单个币种
+func preloadSymbol(symbol string) WarmupSymbolStatus {
+	timeframes := subscribedTimeframes()
+	status := WarmupSymbolStatus{Symbol: symbol, Klines: make(map[string]int, len(timeframes))}
+	apiClient := NewAPIClient()
+
+	for _, interval := range timeframes {
+		count := 0
+		if WSMonitorCli != nil {
+			if klines, err := WSMonitorCli.GetCurrentKlines(symbol, interval); err == nil {
+				count = len(klines)
+			}
+		}
+		if count < minHistory(interval) {
+			klines, err := apiClient.GetKlines(symbol, interval, 100)
+			if err != nil {
+				status.Issues = append(status.Issues, fmt.Sprintf("获取%s K线失败: %v", interval, err))
+			} else {
+				count = len(klines)
+				if WSMonitorCli != nil {
+					WSMonitorCli.seedKlines(symbol, interval, klines)
+				}
+			}
+		}
+		status.Klines[interval] = count
+	}
+	status.Issues = append(status.Issues, missingHistory(status.Klines)...)
+	status.Ready = len(status.Issues) == 0
+
+	if _, err := getOpenInterestData(symbol); err == nil {
+		status.OpenInterest = true
+	} else {
+		status.Issues = append(status.Issues, fmt.Sprintf("获取OI失败: %v", err))
+	}
+	if _, err := getFundingRate(symbol); err == nil {
+		status.FundingRate = true
+	} else {
+		status.Issues = append(status.Issues, fmt.Sprintf("获取资金费率失败: %v", err))
+	}
+
+	if !status.Ready {
+		loglevel.Warnf(loglevel.Market, "⚠️  %s 行情预热不完整: %v", symbol, status.Issues)
+	}
+	return status
+}
+
+// missingHistory 检查各周期K线数是否满足最少历史要求
+func missingHistory(counts map[string]int) []string {
+	var issues []string
+	for _, interval := range subscribedTimeframes() {
+		if counts[interval] < minHistory(interval) {
+			issues = append(issues, fmt.Sprintf("%s K线不足: %d/%d", interval, counts[interval], minHistory(interval)))
+		}
+	}
+	return issues
+}
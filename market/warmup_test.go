@@ -0,0 +1,22 @@
+package market
+
+import "testing"
+
+func TestMissingHistory(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts map[string]int
+		want   int
+	}{
+		{name: "历史充足", counts: map[string]int{"3m": 100, "4h": 100}, want: 0},
+		{name: "3m不足", counts: map[string]int{"3m": 5, "4h": 100}, want: 1},
+		{name: "全部缺失", counts: map[string]int{}, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := missingHistory(tt.counts); len(got) != tt.want {
+				t.Errorf("missingHistory() = %v, want %d issues", got, tt.want)
+			}
+		})
+	}
+}
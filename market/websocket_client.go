@@ -3,7 +3,7 @@ package market
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"nofx/loglevel"
 	"sync"
 	"time"
 
@@ -90,7 +90,7 @@ func (w *WSClient) Connect() error {
 	w.conn = conn
 	w.mu.Unlock()
 
-	log.Println("WebSocket连接成功")
+	loglevel.Infof(loglevel.WS, "WebSocket连接成功")
 
 	// 启动消息读取循环
 	go w.readMessages()
@@ -117,7 +117,7 @@ func (w *WSClient) subscribe(stream string) error {
 	subscribeMsg := map[string]interface{}{
 		"method": "SUBSCRIBE",
 		"params": []string{stream},
-		"id":     time.Now().Unix(),
+		"id":     requestIDs.NextID(),
 	}
 
 	w.mu.RLock()
@@ -132,7 +132,7 @@ func (w *WSClient) subscribe(stream string) error {
 		return err
 	}
 
-	log.Printf("订阅流: %s", stream)
+	loglevel.Debugf(loglevel.WS, "订阅流: %s", stream)
 	return nil
 }
 
@@ -153,7 +153,7 @@ func (w *WSClient) readMessages() {
 
 			_, message, err := conn.ReadMessage()
 			if err != nil {
-				log.Printf("读取WebSocket消息失败: %v", err)
+				loglevel.Warnf(loglevel.WS, "读取WebSocket消息失败: %v", err)
 				w.handleReconnect()
 				return
 			}
@@ -178,7 +178,7 @@ func (w *WSClient) handleMessage(message []byte) {
 		select {
 		case ch <- wsMsg.Data:
 		default:
-			log.Printf("订阅者通道已满: %s", wsMsg.Stream)
+			loglevel.Sampledf(loglevel.WS, loglevel.LevelWarn, "channel_full:"+wsMsg.Stream, time.Minute, "订阅者通道已满: %s", wsMsg.Stream)
 		}
 	}
 }
@@ -188,11 +188,11 @@ func (w *WSClient) handleReconnect() {
 		return
 	}
 
-	log.Println("尝试重新连接...")
+	loglevel.Infof(loglevel.WS, "尝试重新连接...")
 	time.Sleep(3 * time.Second)
 
 	if err := w.Connect(); err != nil {
-		log.Printf("重新连接失败: %v", err)
+		loglevel.Warnf(loglevel.WS, "重新连接失败: %v", err)
 		go w.handleReconnect()
 	}
 }
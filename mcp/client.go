@@ -3,10 +3,12 @@ package mcp
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"nofx/metrics"
 	"os"
 	"strconv"
 	"strings"
@@ -22,6 +24,22 @@ const (
 	ProviderCustom   Provider = "custom"
 )
 
+// ErrContextOverflow 请求超出模型上下文窗口（prompt过长），重试同样的请求没有意义
+var ErrContextOverflow = errors.New("prompt超出模型上下文长度")
+
+// contextOverflowMarkers 各提供商表示上下文超限的错误信息片段（小写匹配）
+var contextOverflowMarkers = []string{
+	"context_length_exceeded",
+	"maximum context length",
+	"context length",
+	"context window",
+	"prompt is too long",
+	"input is too long",
+	"too many tokens",
+	"range of input length", // Qwen
+	"reduce the length",
+}
+
 // Client AI API配置
 type Client struct {
 	Provider   Provider
@@ -29,8 +47,11 @@ type Client struct {
 	BaseURL    string
 	Model      string
 	Timeout    time.Duration
-	UseFullURL bool // 是否使用完整URL（不添加/chat/completions）
-	MaxTokens  int  // AI响应的最大token数
+	UseFullURL bool           // 是否使用完整URL（不添加/chat/completions）
+	MaxTokens  int            // AI响应的最大token数
+	Sampling   SamplingParams // 交易员级采样参数（温度、top_p、max_tokens、seed）
+	// MetricsLabel 运行指标标签（交易员ID，看板按用户过滤），为空时不记录AI耗时/token指标
+	MetricsLabel string
 }
 
 func New() *Client {
@@ -131,8 +152,19 @@ func (client *Client) SetClient(Client Client) {
 	client = &Client
 }
 
+// Message 多轮对话中的一条消息（role 为 user / assistant）
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
 // CallWithMessages 使用 system + user prompt 调用AI API（推荐）
 func (client *Client) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	return client.CallWithConversation(systemPrompt, []Message{{Role: "user", Content: userPrompt}})
+}
+
+// CallWithConversation 使用 system prompt + 多轮对话历史调用AI API（如工具调用循环）
+func (client *Client) CallWithConversation(systemPrompt string, conversation []Message) (string, error) {
 	if client.APIKey == "" {
 		return "", fmt.Errorf("AI API密钥未设置，请先调用 SetDeepSeekAPIKey() 或 SetQwenAPIKey()")
 	}
@@ -146,7 +178,7 @@ func (client *Client) CallWithMessages(systemPrompt, userPrompt string) (string,
 			fmt.Printf("⚠️  AI API调用失败，正在重试 (%d/%d)...\n", attempt, maxRetries)
 		}
 
-		result, err := client.callOnce(systemPrompt, userPrompt)
+		result, err := client.callOnce(systemPrompt, conversation)
 		if err == nil {
 			if attempt > 1 {
 				fmt.Printf("✓ AI API重试成功\n")
@@ -172,7 +204,7 @@ func (client *Client) CallWithMessages(systemPrompt, userPrompt string) (string,
 }
 
 // callOnce 单次调用AI API（内部使用）
-func (client *Client) callOnce(systemPrompt, userPrompt string) (string, error) {
+func (client *Client) callOnce(systemPrompt string, conversation []Message) (string, error) {
 	// 打印当前 AI 配置
 	log.Printf("📡 [MCP] AI 请求配置:")
 	log.Printf("   Provider: %s", client.Provider)
@@ -194,18 +226,27 @@ func (client *Client) callOnce(systemPrompt, userPrompt string) (string, error)
 		})
 	}
 
-	// 添加 user message
-	messages = append(messages, map[string]string{
-		"role":    "user",
-		"content": userPrompt,
-	})
+	// 添加对话消息（单轮调用时只有一条 user message）
+	for _, m := range conversation {
+		messages = append(messages, map[string]string{
+			"role":    m.Role,
+			"content": m.Content,
+		})
+	}
 
-	// 构建请求体
+	// 构建请求体（默认 temperature=0.5 以提高JSON格式稳定性，可按交易员配置覆盖）
+	sampling := client.EffectiveSampling()
 	requestBody := map[string]interface{}{
 		"model":       client.Model,
 		"messages":    messages,
-		"temperature": 0.5, // 降低temperature以提高JSON格式稳定性
-		"max_tokens":  client.MaxTokens,
+		"temperature": *sampling.Temperature,
+		"max_tokens":  sampling.MaxTokens,
+	}
+	if sampling.TopP != nil {
+		requestBody["top_p"] = *sampling.TopP
+	}
+	if sampling.Seed != nil {
+		requestBody["seed"] = *sampling.Seed
 	}
 
 	// 注意：response_format 参数仅 OpenAI 支持，DeepSeek/Qwen 不支持
@@ -248,6 +289,7 @@ func (client *Client) callOnce(systemPrompt, userPrompt string) (string, error)
 
 	// 发送请求
 	httpClient := &http.Client{Timeout: client.Timeout}
+	start := time.Now()
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("发送请求失败: %w", err)
@@ -261,6 +303,9 @@ func (client *Client) callOnce(systemPrompt, userPrompt string) (string, error)
 	}
 
 	if resp.StatusCode != http.StatusOK {
+		if isContextOverflowMessage(string(body)) {
+			return "", fmt.Errorf("%w (status %d): %s", ErrContextOverflow, resp.StatusCode, string(body))
+		}
 		return "", fmt.Errorf("API返回错误 (status %d): %s", resp.StatusCode, string(body))
 	}
 
@@ -271,12 +316,24 @@ func (client *Client) callOnce(systemPrompt, userPrompt string) (string, error)
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
 		return "", fmt.Errorf("解析响应失败: %w", err)
 	}
 
+	if client.MetricsLabel != "" {
+		metrics.ObserveAll(map[string]float64{
+			metrics.Key(metrics.AILatency, client.MetricsLabel):          time.Since(start).Seconds(),
+			metrics.Key(metrics.AIPromptTokens, client.MetricsLabel):     float64(result.Usage.PromptTokens),
+			metrics.Key(metrics.AICompletionTokens, client.MetricsLabel): float64(result.Usage.CompletionTokens),
+		})
+	}
+
 	if len(result.Choices) == 0 {
 		return "", fmt.Errorf("API返回空响应")
 	}
@@ -284,8 +341,22 @@ func (client *Client) callOnce(systemPrompt, userPrompt string) (string, error)
 	return result.Choices[0].Message.Content, nil
 }
 
+// isContextOverflowMessage 判断错误响应是否表示上下文超限
+func isContextOverflowMessage(body string) bool {
+	lower := strings.ToLower(body)
+	for _, marker := range contextOverflowMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
 // isRetryableError 判断错误是否可重试
 func isRetryableError(err error) bool {
+	if errors.Is(err, ErrContextOverflow) {
+		return false
+	}
 	errStr := err.Error()
 	// 网络错误、超时、EOF等可以重试
 	retryableErrors := []string{
@@ -0,0 +1,81 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsContextOverflowMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{"OpenAI兼容错误码", `{"error":{"code":"context_length_exceeded"}}`, true},
+		{"DeepSeek最大上下文", `This model's maximum context length is 65536 tokens`, true},
+		{"Qwen输入长度范围", `Range of input length should be [1, 30720]`, true},
+		{"普通鉴权错误", `{"error":"invalid api key"}`, false},
+		{"限流错误", `rate limit exceeded`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isContextOverflowMessage(tt.body); got != tt.want {
+				t.Errorf("isContextOverflowMessage(%q) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextOverflowNotRetryable(t *testing.T) {
+	err := fmt.Errorf("%w (status 400): timeout reading prompt", ErrContextOverflow)
+	if isRetryableError(err) {
+		t.Error("context overflow should not be retried with the same prompt")
+	}
+	wrapped := fmt.Errorf("调用AI API失败: %w", err)
+	if !errors.Is(wrapped, ErrContextOverflow) {
+		t.Error("wrapped error should still match ErrContextOverflow")
+	}
+}
+
+func TestSamplingParamsValidate(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name    string
+		params  SamplingParams
+		wantErr bool
+	}{
+		{"零值使用默认", SamplingParams{}, false},
+		{"温度为0（确定性输出）", SamplingParams{Temperature: f(0)}, false},
+		{"温度超过2", SamplingParams{Temperature: f(2.5)}, true},
+		{"top_p为0", SamplingParams{TopP: f(0)}, true},
+		{"top_p为1", SamplingParams{TopP: f(1)}, false},
+		{"max_tokens为负", SamplingParams{MaxTokens: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.params.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEffectiveSampling(t *testing.T) {
+	seed := int64(42)
+	temperature := 0.2
+
+	client := &Client{Provider: ProviderDeepSeek, MaxTokens: 2000}
+	client.SetSampling(SamplingParams{Seed: &seed})
+	got := client.EffectiveSampling()
+	if got.Temperature == nil || *got.Temperature != DefaultTemperature || got.MaxTokens != 2000 || got.Seed != nil {
+		t.Errorf("DeepSeek effective = %+v, want 默认温度、客户端max_tokens、不发送seed", got)
+	}
+
+	client.Provider = ProviderQwen
+	client.SetSampling(SamplingParams{Temperature: &temperature, MaxTokens: 800, Seed: &seed})
+	got = client.EffectiveSampling()
+	if *got.Temperature != 0.2 || got.MaxTokens != 800 || got.Seed == nil || *got.Seed != 42 {
+		t.Errorf("Qwen effective = %+v", got)
+	}
+}
@@ -0,0 +1,54 @@
+package mcp
+
+import "fmt"
+
+// DefaultTemperature 未配置时使用的温度（较低的温度提高JSON格式稳定性）
+const DefaultTemperature = 0.5
+
+// SamplingParams 模型采样参数（指针字段为 nil 表示使用默认值/不发送）
+type SamplingParams struct {
+	Temperature *float64 `json:"temperature,omitempty"` // 0~2，默认 0.5
+	TopP        *float64 `json:"top_p,omitempty"`       // (0,1]，默认不发送
+	MaxTokens   int      `json:"max_tokens,omitempty"`  // 0=使用客户端默认（AI_MAX_TOKENS）
+	Seed        *int64   `json:"seed,omitempty"`        // 固定随机种子（仅部分提供商支持）
+}
+
+// Validate 校验采样参数范围
+func (p SamplingParams) Validate() error {
+	if p.Temperature != nil && (*p.Temperature < 0 || *p.Temperature > 2) {
+		return fmt.Errorf("temperature 必须在 0~2 之间: %v", *p.Temperature)
+	}
+	if p.TopP != nil && (*p.TopP <= 0 || *p.TopP > 1) {
+		return fmt.Errorf("top_p 必须在 (0,1] 之间: %v", *p.TopP)
+	}
+	if p.MaxTokens < 0 || p.MaxTokens > 65536 {
+		return fmt.Errorf("max_tokens 必须在 0~65536 之间: %d", p.MaxTokens)
+	}
+	return nil
+}
+
+// supportsSeed 提供商是否支持 seed 参数（DeepSeek 官方接口不支持）
+func (client *Client) supportsSeed() bool {
+	return client.Provider == ProviderQwen || client.Provider == ProviderCustom
+}
+
+// SetSampling 设置采样参数（由交易员配置下发）
+func (client *Client) SetSampling(params SamplingParams) {
+	client.Sampling = params
+}
+
+// EffectiveSampling 实际发送的采样参数（填充默认值，去掉提供商不支持的字段），用于记录和复现
+func (client *Client) EffectiveSampling() SamplingParams {
+	params := client.Sampling
+	if params.Temperature == nil {
+		temperature := DefaultTemperature
+		params.Temperature = &temperature
+	}
+	if params.MaxTokens <= 0 {
+		params.MaxTokens = client.MaxTokens
+	}
+	if !client.supportsSeed() {
+		params.Seed = nil
+	}
+	return params
+}
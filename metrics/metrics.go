@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"nofx/clock"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 指标名称（按交易员区分的指标用 Key 附加交易员ID标签）
+const (
+	DecisionLatency    = "decision_latency_seconds" // 决策耗时：行情获取+AI调用+解析（按交易员）
+	AILatency          = "ai_latency_seconds"       // 单次AI请求耗时（按交易员）
+	AIPromptTokens     = "ai_prompt_tokens"         // 单次AI请求输入token数（按交易员）
+	AICompletionTokens = "ai_completion_tokens"     // 单次AI请求输出token数（按交易员）
+	Equity             = "equity_usdt"              // 账户净值（按交易员）
+	PnL                = "pnl_usdt"                 // 总盈亏（按交易员）
+	WSConnectedShards  = "ws_connected_shards"      // 已连接的行情WS分片数
+	WSShards           = "ws_shards"                // 行情WS分片总数
+	WSMaxMessageLag    = "ws_max_message_lag_seconds"
+)
+
+// seriesCapacity 每条序列保留的采样数（按分钟采样约12小时，按3分钟周期约36小时）
+const seriesCapacity = 720
+
+// Point 时间序列上的一个采样
+type Point struct {
+	Time  time.Time `json:"t"`
+	Value float64   `json:"v"`
+}
+
+// Series 一条指标序列的快照
+type Series struct {
+	Name   string  `json:"name"`
+	Label  string  `json:"label,omitempty"` // 交易员ID（为空表示全局指标）
+	Points []Point `json:"points"`
+}
+
+// ring 定长环形缓冲
+type ring struct {
+	points []Point
+	next   int
+	full   bool
+}
+
+func (r *ring) add(p Point) {
+	if len(r.points) < seriesCapacity {
+		r.points = append(r.points, p)
+		return
+	}
+	r.points[r.next] = p
+	r.next = (r.next + 1) % seriesCapacity
+	r.full = true
+}
+
+// ordered 按时间顺序返回副本
+func (r *ring) ordered() []Point {
+	out := make([]Point, 0, len(r.points))
+	if r.full {
+		out = append(out, r.points[r.next:]...)
+		out = append(out, r.points[:r.next]...)
+		return out
+	}
+	return append(out, r.points...)
+}
+
+// sampler 定时采样的指标（无事件可挂钩的状态类指标，如WS连接数）
+type sampler struct {
+	key string
+	fn  func() (float64, bool)
+}
+
+var (
+	mu           sync.Mutex
+	series       = map[string]*ring{}
+	samplers     []sampler
+	samplingOnce sync.Once
+	metricsClock clock.Clock = clock.System
+)
+
+// SetClock 替换采样时间使用的时钟
+func SetClock(c clock.Clock) {
+	mu.Lock()
+	defer mu.Unlock()
+	metricsClock = c
+}
+
+// Key 带标签的序列键（name:label）
+func Key(name, label string) string {
+	if label == "" {
+		return name
+	}
+	return name + ":" + label
+}
+
+// Observe 记录一个采样
+func Observe(key string, value float64) {
+	mu.Lock()
+	defer mu.Unlock()
+	observeLocked(key, metricsClock.Now(), value)
+}
+
+// ObserveAll 以同一时间戳记录多个采样（同一事件的多个指标，便于按时间对齐）
+func ObserveAll(values map[string]float64) {
+	mu.Lock()
+	defer mu.Unlock()
+	now := metricsClock.Now()
+	for key, value := range values {
+		observeLocked(key, now, value)
+	}
+}
+
+func observeLocked(key string, t time.Time, value float64) {
+	r, ok := series[key]
+	if !ok {
+		r = &ring{}
+		series[key] = r
+	}
+	r.add(Point{Time: t, Value: value})
+}
+
+// Snapshot 所有序列的快照（按名称、标签排序）
+func Snapshot() []Series {
+	mu.Lock()
+	defer mu.Unlock()
+	result := make([]Series, 0, len(series))
+	for key, r := range series {
+		name, label, _ := strings.Cut(key, ":")
+		result = append(result, Series{Name: name, Label: label, Points: r.ordered()})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Label < result[j].Label
+	})
+	return result
+}
+
+// RegisterSampler 注册定时采样的指标（fn 返回 false 表示本次无数据），需调用 StartSampling 开始采样
+func RegisterSampler(key string, fn func() (float64, bool)) {
+	mu.Lock()
+	defer mu.Unlock()
+	samplers = append(samplers, sampler{key: key, fn: fn})
+}
+
+// StartSampling 启动后台定时采样（多次调用只启动一次）
+func StartSampling(interval time.Duration) {
+	samplingOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for range ticker.C {
+				sampleOnce()
+			}
+		}()
+	})
+}
+
+// sampleOnce 执行一轮采样（采样函数在锁外调用，避免与被采样模块互相等待）
+func sampleOnce() {
+	mu.Lock()
+	current := append([]sampler(nil), samplers...)
+	mu.Unlock()
+
+	values := make(map[string]float64, len(current))
+	for _, s := range current {
+		if v, ok := s.fn(); ok {
+			values[s.key] = v
+		}
+	}
+	if len(values) > 0 {
+		ObserveAll(values)
+	}
+}
+
+// reset 清空所有序列和采样器（测试用）
+func reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	series = map[string]*ring{}
+	samplers = nil
+}
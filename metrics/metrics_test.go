@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"nofx/clock"
+	"testing"
+	"time"
+)
+
+func TestRingKeepsLatestPoints(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := &ring{}
+	for i := 0; i < seriesCapacity+5; i++ {
+		r.add(Point{Time: start.Add(time.Duration(i) * time.Minute), Value: float64(i)})
+	}
+	points := r.ordered()
+	if len(points) != seriesCapacity {
+		t.Fatalf("保留采样数 = %d, want %d", len(points), seriesCapacity)
+	}
+	if points[0].Value != 5 || points[len(points)-1].Value != float64(seriesCapacity+4) {
+		t.Errorf("应按时间顺序保留最新的采样: first=%v last=%v", points[0].Value, points[len(points)-1].Value)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	reset()
+	defer reset()
+	manual := clock.NewManual(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	SetClock(manual)
+	defer SetClock(clock.System)
+
+	Observe(Key(PnL, "t2"), 5)
+	ObserveAll(map[string]float64{Key(AIPromptTokens, "deepseek-chat"): 1000, Key(AICompletionTokens, "deepseek-chat"): 200})
+	manual.Advance(time.Minute)
+	Observe(Key(PnL, "t1"), -3)
+	Observe(WSShards, 2)
+
+	snapshot := Snapshot()
+	var names []string
+	for _, s := range snapshot {
+		names = append(names, Key(s.Name, s.Label))
+	}
+	want := []string{"ai_completion_tokens:deepseek-chat", "ai_prompt_tokens:deepseek-chat", "pnl_usdt:t1", "pnl_usdt:t2", "ws_shards"}
+	if len(names) != len(want) {
+		t.Fatalf("序列 = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("序列 = %v, want %v", names, want)
+		}
+	}
+	if !snapshot[0].Points[0].Time.Equal(snapshot[1].Points[0].Time) {
+		t.Error("ObserveAll 的采样应使用同一时间戳")
+	}
+	if snapshot[4].Label != "" {
+		t.Errorf("全局指标不应有标签: %+v", snapshot[4])
+	}
+}
+
+func TestSampleOnce(t *testing.T) {
+	reset()
+	defer reset()
+	RegisterSampler(WSConnectedShards, func() (float64, bool) { return 3, true })
+	RegisterSampler(WSMaxMessageLag, func() (float64, bool) { return 0, false })
+	sampleOnce()
+
+	snapshot := Snapshot()
+	if len(snapshot) != 1 || snapshot[0].Name != WSConnectedShards || snapshot[0].Points[0].Value != 3 {
+		t.Errorf("无数据的采样器不应产生采样: %+v", snapshot)
+	}
+}
@@ -0,0 +1,124 @@
+// Package analysis 市场分析稳定API：按指定K线周期获取单币种的价格、资金费率、持仓量和技术指标
+//
+// 分析器封装 nofx/market 的进程级全局状态（数据源、行情缓存、WS监控订阅的周期），与同一进程中的交易员共享。
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"nofx/market"
+	"strings"
+)
+
+// Indicator 自定义指标（与交易员指标集的定义一致）
+type Indicator struct {
+	Type      string `json:"type"`      // ema / sma / rsi / atr / macd
+	Period    int    `json:"period"`    // 计算周期（macd 忽略）
+	Source    string `json:"source"`    // 数据来源，默认 close
+	Timeframe string `json:"timeframe"` // K线周期（需在 Options.Timeframes 内）
+}
+
+// Options 分析选项（零值为默认 3m/4h 周期、standard 输出、不计算自定义指标）
+type Options struct {
+	Timeframes []string    `json:"timeframes"` // 预设名称或周期列表，见 market.SupportedTimeframes（为空使用 3m/4h）
+	Indicators []Indicator `json:"indicators"`
+	Verbosity  string      `json:"verbosity"` // brief / standard / full，影响 Snapshot.Text
+}
+
+// Snapshot 单币种市场分析结果
+type Snapshot struct {
+	Symbol         string             `json:"symbol"`
+	Price          float64            `json:"price"`
+	Change1hPct    float64            `json:"change_1h_pct"`
+	Change4hPct    float64            `json:"change_4h_pct"`
+	FundingRate    float64            `json:"funding_rate"`
+	OpenInterest   float64            `json:"open_interest"`    // 最新持仓量（币）
+	QuoteVolume24h float64            `json:"quote_volume_24h"` // 24小时成交额（USDT）
+	EMA20          float64            `json:"ema20"`            // 日内周期 EMA20
+	MACD           float64            `json:"macd"`
+	RSI7           float64            `json:"rsi7"`
+	Timeframes     []string           `json:"timeframes"`
+	Indicators     map[string]float64 `json:"indicators,omitempty"` // 自定义指标（键如 4h_ema50）
+	Text           string             `json:"text"`                 // 与交易员 prompt 中格式一致的文本
+}
+
+// Analyzer 市场分析器
+type Analyzer interface {
+	Analyze(ctx context.Context, symbol string) (*Snapshot, error)
+}
+
+type analyzer struct {
+	timeframes []string
+	indicators []market.IndicatorDef
+	verbosity  string
+}
+
+// New 校验选项并创建分析器
+func New(opts Options) (Analyzer, error) {
+	a := &analyzer{verbosity: market.NormalizeVerbosity(opts.Verbosity)}
+	if len(opts.Timeframes) > 0 {
+		timeframes, err := market.ParseTimeframes(strings.Join(opts.Timeframes, ","))
+		if err != nil {
+			return nil, err
+		}
+		a.timeframes = timeframes
+	}
+	if len(opts.Indicators) > 0 {
+		defs := make([]market.IndicatorDef, len(opts.Indicators))
+		for i, ind := range opts.Indicators {
+			defs[i] = market.IndicatorDef(ind)
+		}
+		indicators, err := market.NormalizeIndicatorSet(defs)
+		if err != nil {
+			return nil, err
+		}
+		a.indicators = indicators
+	}
+	market.EnsureTimeframes(a.timeframes)
+	return a, nil
+}
+
+// Analyze 获取并分析单个币种（symbol 可省略 USDT 后缀）
+func (a *analyzer) Analyze(ctx context.Context, symbol string) (*Snapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	symbol = market.Normalize(strings.TrimSpace(symbol))
+	data, err := market.GetWithTimeframes(symbol, a.timeframes)
+	if err != nil {
+		return nil, fmt.Errorf("分析 %s 失败: %w", symbol, err)
+	}
+	if len(a.indicators) > 0 {
+		data.Indicators = market.ComputeIndicators(data, a.indicators)
+	}
+	return newSnapshot(data, a.timeframes, a.verbosity), nil
+}
+
+func newSnapshot(data *market.Data, timeframes []string, verbosity string) *Snapshot {
+	if len(timeframes) == 0 {
+		timeframes = market.DefaultTimeframes
+	}
+	snapshot := &Snapshot{
+		Symbol:         data.Symbol,
+		Price:          data.CurrentPrice,
+		Change1hPct:    data.PriceChange1h,
+		Change4hPct:    data.PriceChange4h,
+		FundingRate:    data.FundingRate,
+		QuoteVolume24h: data.QuoteVolume24h,
+		EMA20:          data.CurrentEMA20,
+		MACD:           data.CurrentMACD,
+		RSI7:           data.CurrentRSI7,
+		Timeframes:     append([]string(nil), timeframes...),
+		Text:           market.FormatWithVerbosity(data, verbosity),
+	}
+	if data.OpenInterest != nil {
+		snapshot.OpenInterest = data.OpenInterest.Latest
+	}
+	if len(data.Indicators) > 0 {
+		snapshot.Indicators = make(map[string]float64, len(data.Indicators))
+		for _, v := range data.Indicators {
+			snapshot.Indicators[v.Key] = v.Value
+		}
+	}
+	return snapshot
+}
@@ -0,0 +1,164 @@
+// Package backtest 回测稳定API：按交易信号在历史K线上模拟成交（手续费、挂单成交、资金费、持仓量流动性上限）
+package backtest
+
+import (
+	"context"
+	"fmt"
+	bt "nofx/backtest"
+	"nofx/market"
+	"strings"
+	"time"
+)
+
+// Signal 回测信号（action 为 open_long / open_short / close）
+type Signal struct {
+	Time       int64   `json:"time"` // 信号时间（毫秒），在之后第一根K线开盘执行
+	Action     string  `json:"action"`
+	SizeUSD    float64 `json:"size_usd"`
+	StopLoss   float64 `json:"stop_loss"`
+	TakeProfit float64 `json:"take_profit"`
+	LimitPrice float64 `json:"limit_price"` // 挂单价格（0=信号K线开盘价）
+}
+
+// Options 回测真实性选项（零值为全部市价成交、无资金费、无流动性限制）
+type Options struct {
+	TakerFeeRate     float64 `json:"taker_fee_rate"`     // 默认 0.04%
+	MakerFeeRate     float64 `json:"maker_fee_rate"`     // 默认 0.02%
+	Liquidity        string  `json:"liquidity"`          // taker / maker / auto
+	MakerWaitCandles int     `json:"maker_wait_candles"` // 限价单最多等待K线数（默认3）
+	ModelFunding     bool    `json:"model_funding"`      // 计入持仓期间的资金费
+	OICapPct         float64 `json:"oi_cap_pct"`         // 单笔成交最多占持仓价值的百分比（0=不限制）
+	IntrabarTieBreak string  `json:"intrabar_tie_break"` // worst_case / ohlc_path
+	StopFill         string  `json:"stop_fill"`          // touch / cross
+}
+
+// Kline 离线回测使用的K线
+type Kline struct {
+	OpenTime  int64   `json:"open_time"` // 毫秒
+	Open      float64 `json:"open"`
+	High      float64 `json:"high"`
+	Low       float64 `json:"low"`
+	Close     float64 `json:"close"`
+	Volume    float64 `json:"volume"`
+	CloseTime int64   `json:"close_time"`
+}
+
+// Trade 回测成交记录
+type Trade struct {
+	Side           string  `json:"side"`
+	EntryTime      int64   `json:"entry_time"`
+	ExitTime       int64   `json:"exit_time"`
+	EntryPrice     float64 `json:"entry_price"`
+	ExitPrice      float64 `json:"exit_price"`
+	Quantity       float64 `json:"quantity"`
+	Liquidity      string  `json:"liquidity"`
+	Capped         bool    `json:"capped"`
+	ExitReason     string  `json:"exit_reason"`
+	ExitAssumption string  `json:"exit_assumption,omitempty"`
+	GrossPnL       float64 `json:"gross_pnl"`
+	Fees           float64 `json:"fees"`
+	Funding        float64 `json:"funding"`
+	NetPnL         float64 `json:"net_pnl"`
+}
+
+// Result 回测结果
+type Result struct {
+	Symbol         string  `json:"symbol"`
+	Trades         []Trade `json:"trades"`
+	GrossPnL       float64 `json:"gross_pnl"`
+	Fees           float64 `json:"fees"`
+	Funding        float64 `json:"funding"`
+	NetPnL         float64 `json:"net_pnl"`
+	MakerFills     int     `json:"maker_fills"`
+	TakerFills     int     `json:"taker_fills"`
+	MissedFills    int     `json:"missed_fills"`
+	CappedFills    int     `json:"capped_fills"`
+	SkippedCount   int     `json:"skipped_count"`
+	AmbiguousExits int     `json:"ambiguous_exits"`
+	TouchExits     int     `json:"touch_exits"`
+}
+
+// Request 回测请求（从交易所下载 Start~End 的历史数据）
+type Request struct {
+	Symbol   string    `json:"symbol"`
+	Interval string    `json:"interval"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Signals  []Signal  `json:"signals"`
+	Options  Options   `json:"options"`
+}
+
+// Backtester 回测器
+type Backtester interface {
+	// Run 下载历史K线（及按选项需要的资金费率、持仓量）后回测
+	Run(ctx context.Context, req Request) (*Result, error)
+	// RunOnKlines 使用调用方提供的K线离线回测（不支持 ModelFunding 和 OICapPct）
+	RunOnKlines(symbol string, klines []Kline, signals []Signal, opts Options) (*Result, error)
+}
+
+type backtester struct{}
+
+// New 创建回测器
+func New() Backtester {
+	return backtester{}
+}
+
+func (backtester) Run(ctx context.Context, req Request) (*Result, error) {
+	if !req.End.After(req.Start) {
+		return nil, fmt.Errorf("结束时间必须晚于开始时间")
+	}
+	if !market.IsSupportedTimeframe(req.Interval) {
+		return nil, fmt.Errorf("不支持的K线周期: %s", req.Interval)
+	}
+	symbol := market.Normalize(strings.TrimSpace(req.Symbol))
+	opts := bt.Options(req.Options)
+	data, err := bt.LoadData(symbol, req.Interval, req.Start, req.End, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return run(data, req.Signals, opts)
+}
+
+func (backtester) RunOnKlines(symbol string, klines []Kline, signals []Signal, opts Options) (*Result, error) {
+	if opts.ModelFunding || opts.OICapPct > 0 {
+		return nil, fmt.Errorf("离线回测不支持资金费和持仓量流动性上限")
+	}
+	data := bt.Data{Symbol: market.Normalize(strings.TrimSpace(symbol)), Klines: make([]market.Kline, len(klines))}
+	for i, k := range klines {
+		data.Klines[i] = market.Kline{OpenTime: k.OpenTime, Open: k.Open, High: k.High, Low: k.Low, Close: k.Close, Volume: k.Volume, CloseTime: k.CloseTime}
+	}
+	return run(data, signals, bt.Options(opts))
+}
+
+func run(data bt.Data, signals []Signal, opts bt.Options) (*Result, error) {
+	internal := make([]bt.Signal, len(signals))
+	for i, s := range signals {
+		internal[i] = bt.Signal(s)
+	}
+	r, err := bt.Run(data, internal, opts)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{
+		Symbol:         r.Symbol,
+		Trades:         make([]Trade, len(r.Trades)),
+		GrossPnL:       r.GrossPnL,
+		Fees:           r.Fees,
+		Funding:        r.Funding,
+		NetPnL:         r.NetPnL,
+		MakerFills:     r.MakerFills,
+		TakerFills:     r.TakerFills,
+		MissedFills:    r.MissedFills,
+		CappedFills:    r.CappedFills,
+		SkippedCount:   r.SkippedCount,
+		AmbiguousExits: r.AmbiguousExits,
+		TouchExits:     r.TouchExits,
+	}
+	for i, t := range r.Trades {
+		result.Trades[i] = Trade(t)
+	}
+	return result, nil
+}
@@ -0,0 +1,45 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+)
+
+const hourMs = int64(3600 * 1000)
+
+func TestRunOnKlines(t *testing.T) {
+	klines := make([]Kline, 12)
+	for i := range klines {
+		open := int64(i) * hourMs
+		klines[i] = Kline{OpenTime: open, CloseTime: open + hourMs - 1, Open: 100, High: 101, Low: 99, Close: 100, Volume: 100}
+	}
+	signals := []Signal{{Time: 0, Action: "open_long", SizeUSD: 1000}, {Time: 10 * hourMs, Action: "close"}}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		wantErr  bool
+		wantFees float64
+	}{
+		{"默认市价成交", Options{}, false, 0.8},
+		{"挂单成交按Maker计费", Options{Liquidity: "maker"}, false, 0.6},
+		{"离线回测不支持资金费", Options{ModelFunding: true}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := New().RunOnKlines("btc", klines, signals, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RunOnKlines() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result.Symbol != "BTCUSDT" || len(result.Trades) != 1 {
+				t.Fatalf("result = %+v, want 1 BTCUSDT trade", result)
+			}
+			if math.Abs(result.Fees-tt.wantFees) > 1e-9 {
+				t.Errorf("fees = %v, want %v", result.Fees, tt.wantFees)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+// Package engine 决策引擎稳定API：把账户、持仓和候选币种交给AI模型，返回经过校验的交易决策
+//
+// 引擎是对 nofx 内部包进程级全局状态的薄封装，不持有独立实例：市场数据来自 nofx/market 的全局数据源、
+// 行情缓存和WS监控，提示词模板来自 nofx/decision 的全局模板表，市场数据获取和AI调用走进程内的公平调度。
+// 同一进程中的多个引擎以及 nofx 交易员共享这些状态。引擎不会下单，执行决策由调用方负责。
+package engine
+
+import (
+	"context"
+	"fmt"
+	"nofx/decision"
+	"nofx/market"
+	"nofx/mcp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// 模型提供方
+const (
+	ProviderDeepSeek = "deepseek"
+	ProviderQwen     = "qwen"
+	ProviderCustom   = "custom" // OpenAI 兼容接口（BaseURL 以 # 结尾时按完整URL调用）
+)
+
+// Model AI模型配置
+type Model struct {
+	Provider string `json:"provider"`
+	APIKey   string `json:"api_key"`
+	BaseURL  string `json:"base_url"` // deepseek / qwen 为空时使用官方地址
+	Name     string `json:"name"`     // deepseek / qwen 为空时使用默认模型
+}
+
+// Config 引擎配置（零值字段使用 nofx 交易员的默认值）
+type Config struct {
+	Model              Model    `json:"model"`
+	Template           string   `json:"template"`      // 系统提示词模板（prompts 目录下的文件名，为空使用 default）
+	CustomPrompt       string   `json:"custom_prompt"` // 追加的自定义策略
+	OverrideBasePrompt bool     `json:"override_base_prompt"`
+	BTCETHLeverage     int      `json:"btc_eth_leverage"` // 默认5倍
+	AltcoinLeverage    int      `json:"altcoin_leverage"` // 默认5倍
+	Timeframes         []string `json:"timeframes"`       // 分析周期（为空使用 3m/4h）
+	RiskPersona        string   `json:"risk_persona"`     // 风险偏好档位（为空使用模板默认规则）
+	ToolBudget         int      `json:"tool_budget"`      // 模型每次决策可调用数据工具的次数（0=关闭）
+}
+
+// Account 账户状态
+type Account struct {
+	TotalEquity      float64 `json:"total_equity"`
+	AvailableBalance float64 `json:"available_balance"`
+	TotalPnL         float64 `json:"total_pnl"`
+	TotalPnLPct      float64 `json:"total_pnl_pct"`
+	MarginUsed       float64 `json:"margin_used"`
+	MarginUsedPct    float64 `json:"margin_used_pct"`
+}
+
+// Position 当前持仓
+type Position struct {
+	Symbol           string  `json:"symbol"`
+	Side             string  `json:"side"` // long / short
+	EntryPrice       float64 `json:"entry_price"`
+	MarkPrice        float64 `json:"mark_price"`
+	Quantity         float64 `json:"quantity"`
+	Leverage         int     `json:"leverage"`
+	UnrealizedPnL    float64 `json:"unrealized_pnl"`
+	LiquidationPrice float64 `json:"liquidation_price"`
+	MarginUsed       float64 `json:"margin_used"`
+}
+
+// Request 一次决策的输入
+type Request struct {
+	Account    Account    `json:"account"`
+	Positions  []Position `json:"positions"`
+	Candidates []string   `json:"candidates"` // 候选币种（可省略 USDT 后缀）
+}
+
+// Decision 交易决策（action 与 nofx 交易员一致）
+type Decision struct {
+	Symbol          string  `json:"symbol"`
+	Action          string  `json:"action"` // open_long / open_short / close_long / close_short / update_stop_loss / update_take_profit / partial_close / hold / wait
+	Leverage        int     `json:"leverage,omitempty"`
+	PositionSizeUSD float64 `json:"position_size_usd,omitempty"`
+	StopLoss        float64 `json:"stop_loss,omitempty"`
+	TakeProfit      float64 `json:"take_profit,omitempty"`
+	NewStopLoss     float64 `json:"new_stop_loss,omitempty"`
+	NewTakeProfit   float64 `json:"new_take_profit,omitempty"`
+	ClosePercentage float64 `json:"close_percentage,omitempty"` // partial_close 按持仓数量的百分比
+	SizeUSD         float64 `json:"size_usd,omitempty"`         // partial_close 减仓名义价值（USDT）
+	QtyContracts    float64 `json:"qty_contracts,omitempty"`    // partial_close 减仓合约数量（币数）
+	Confidence      int     `json:"confidence,omitempty"`
+	Reasoning       string  `json:"reasoning"`
+}
+
+// Rejection 未通过校验的决策
+type Rejection struct {
+	Decision Decision `json:"decision"`
+	Reason   string   `json:"reason"`
+}
+
+// Result 决策结果
+type Result struct {
+	Decisions    []Decision  `json:"decisions"` // 通过校验的决策
+	Rejected     []Rejection `json:"rejected,omitempty"`
+	Reasoning    string      `json:"reasoning"` // 模型思维链
+	SystemPrompt string      `json:"system_prompt"`
+	UserPrompt   string      `json:"user_prompt"`
+	Timestamp    time.Time   `json:"timestamp"`
+}
+
+// Engine 决策引擎
+type Engine interface {
+	Decide(ctx context.Context, req Request) (*Result, error)
+}
+
+type engine struct {
+	id      string
+	cfg     Config
+	client  *mcp.Client
+	persona *decision.RiskPersona
+}
+
+var engineSeq atomic.Int64
+
+// New 校验配置并创建决策引擎
+func New(cfg Config) (Engine, error) {
+	if cfg.Model.APIKey == "" {
+		return nil, fmt.Errorf("缺少模型 API Key")
+	}
+	client := mcp.New()
+	switch cfg.Model.Provider {
+	case ProviderDeepSeek:
+		client.SetDeepSeekAPIKey(cfg.Model.APIKey, cfg.Model.BaseURL, cfg.Model.Name)
+	case ProviderQwen:
+		client.SetQwenAPIKey(cfg.Model.APIKey, cfg.Model.BaseURL, cfg.Model.Name)
+	case ProviderCustom:
+		if cfg.Model.BaseURL == "" || cfg.Model.Name == "" {
+			return nil, fmt.Errorf("custom 模型需要 base_url 和 name")
+		}
+		client.SetCustomAPI(cfg.Model.BaseURL, cfg.Model.APIKey, cfg.Model.Name)
+	default:
+		return nil, fmt.Errorf("不支持的模型提供方: %q（可选 deepseek / qwen / custom）", cfg.Model.Provider)
+	}
+
+	// 指定的模板必须存在；未指定时使用 default（prompts 目录不存在时退回内置的简化提示词）
+	if cfg.Template != "" {
+		if _, err := decision.GetPromptTemplate(cfg.Template); err != nil {
+			return nil, err
+		}
+	} else {
+		cfg.Template = "default"
+	}
+	if cfg.BTCETHLeverage <= 0 {
+		cfg.BTCETHLeverage = 5
+	}
+	if cfg.AltcoinLeverage <= 0 {
+		cfg.AltcoinLeverage = 5
+	}
+	if len(cfg.Timeframes) > 0 {
+		timeframes, err := market.ParseTimeframes(strings.Join(cfg.Timeframes, ","))
+		if err != nil {
+			return nil, err
+		}
+		cfg.Timeframes = timeframes
+	}
+	if cfg.ToolBudget < 0 || cfg.ToolBudget > decision.MaxToolCallBudget {
+		return nil, fmt.Errorf("工具调用次数必须在 0~%d 之间", decision.MaxToolCallBudget)
+	}
+
+	e := &engine{
+		id:     fmt.Sprintf("embedded-%d", engineSeq.Add(1)),
+		cfg:    cfg,
+		client: client,
+	}
+	if cfg.RiskPersona != "" {
+		persona, ok := decision.GetRiskPersona(cfg.RiskPersona)
+		if !ok {
+			return nil, fmt.Errorf("未知的风险偏好档位: %s", cfg.RiskPersona)
+		}
+		e.persona = &persona
+	}
+	return e, nil
+}
+
+// Decide 获取市场数据并调用模型生成决策（ctx 取消时立即返回，进行中的模型调用在后台结束）
+func (e *engine) Decide(ctx context.Context, req Request) (*Result, error) {
+	if req.Account.TotalEquity <= 0 {
+		return nil, fmt.Errorf("账户净值必须大于0")
+	}
+	dctx := e.buildContext(req)
+
+	type outcome struct {
+		full *decision.FullDecision
+		err  error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		full, err := decision.GetFullDecisionWithCustomPrompt(dctx, e.client, e.cfg.CustomPrompt, e.cfg.OverrideBasePrompt, e.cfg.Template)
+		done <- outcome{full, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case out := <-done:
+		if out.full == nil {
+			return nil, out.err
+		}
+		return newResult(out.full), out.err
+	}
+}
+
+func (e *engine) buildContext(req Request) *decision.Context {
+	dctx := &decision.Context{
+		CurrentTime:     time.Now().Format("2006-01-02 15:04:05"),
+		BTCETHLeverage:  e.cfg.BTCETHLeverage,
+		AltcoinLeverage: e.cfg.AltcoinLeverage,
+		TraderID:        e.id,
+		Timeframes:      e.cfg.Timeframes,
+		Persona:         e.persona,
+		ToolBudget:      e.cfg.ToolBudget,
+		Account: decision.AccountInfo{
+			TotalEquity:      req.Account.TotalEquity,
+			AvailableBalance: req.Account.AvailableBalance,
+			TotalPnL:         req.Account.TotalPnL,
+			TotalPnLPct:      req.Account.TotalPnLPct,
+			MarginUsed:       req.Account.MarginUsed,
+			MarginUsedPct:    req.Account.MarginUsedPct,
+			PositionCount:    len(req.Positions),
+		},
+	}
+	for _, p := range req.Positions {
+		info := decision.PositionInfo{
+			Symbol:           market.Normalize(p.Symbol),
+			Side:             p.Side,
+			EntryPrice:       p.EntryPrice,
+			MarkPrice:        p.MarkPrice,
+			Quantity:         p.Quantity,
+			Leverage:         p.Leverage,
+			UnrealizedPnL:    p.UnrealizedPnL,
+			LiquidationPrice: p.LiquidationPrice,
+			MarginUsed:       p.MarginUsed,
+		}
+		if p.MarginUsed > 0 {
+			info.UnrealizedPnLPct = p.UnrealizedPnL / p.MarginUsed * 100
+		}
+		dctx.Positions = append(dctx.Positions, info)
+	}
+	for _, symbol := range req.Candidates {
+		dctx.CandidateCoins = append(dctx.CandidateCoins, decision.CandidateCoin{Symbol: market.Normalize(strings.TrimSpace(symbol)), Sources: []string{"embedded"}})
+	}
+	return dctx
+}
+
+func newResult(full *decision.FullDecision) *Result {
+	result := &Result{
+		Reasoning:    full.CoTTrace,
+		SystemPrompt: full.SystemPrompt,
+		UserPrompt:   full.UserPrompt,
+		Timestamp:    full.Timestamp,
+	}
+	for _, d := range full.Decisions {
+		result.Decisions = append(result.Decisions, newDecision(d))
+	}
+	for _, r := range full.Rejected {
+		result.Rejected = append(result.Rejected, Rejection{Decision: newDecision(r.Decision), Reason: r.Reason})
+	}
+	return result
+}
+
+func newDecision(d decision.Decision) Decision {
+	return Decision{
+		Symbol:          d.Symbol,
+		Action:          d.Action,
+		Leverage:        d.Leverage,
+		PositionSizeUSD: d.PositionSizeUSD,
+		StopLoss:        d.StopLoss,
+		TakeProfit:      d.TakeProfit,
+		NewStopLoss:     d.NewStopLoss,
+		NewTakeProfit:   d.NewTakeProfit,
+		ClosePercentage: d.ClosePercentage,
+		SizeUSD:         d.SizeUSD,
+		QtyContracts:    d.QtyContracts,
+		Confidence:      d.Confidence,
+		Reasoning:       d.Reasoning,
+	}
+}
@@ -0,0 +1,16 @@
+// Package nofx 对外稳定API的版本信息
+//
+// /pkg 下的包（analysis、engine、risk、backtest）是供其他Go程序嵌入 nofx 组件的稳定接口，
+// 只暴露本目录中定义的类型，不直接暴露 market / decision / backtest 等内部包的结构。
+// 这些包只是稳定的类型外观，实现仍直接调用内部包，并共享其进程级全局状态（市场数据源与缓存、WS监控、
+// 提示词模板、公平调度），不是彼此隔离的实例。
+// 版本遵循语义化版本：
+//   - 主版本号：删除或修改已导出的类型、字段、函数签名
+//   - 次版本号：新增导出的类型、字段、函数或选项（零值保持原有行为）
+//   - 修订号：不改变API的问题修复
+//
+// 内部包（nofx/market、nofx/decision 等）不在兼容性承诺范围内，随时可能调整。
+package nofx
+
+// Version /pkg 稳定API版本
+const Version = "1.0.0"
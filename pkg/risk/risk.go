@@ -0,0 +1,77 @@
+// Package risk 风控校验稳定API：按 nofx 交易员的规则检查开仓/调仓指令（杠杆上限、最小名义价值、净值倍数和流动性上限、止损止盈方向）
+package risk
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/market"
+	"strings"
+)
+
+// Limits 风控上限
+type Limits struct {
+	BTCETHLeverage  int `json:"btc_eth_leverage"` // BTC/ETH 最大杠杆（默认5倍）
+	AltcoinLeverage int `json:"altcoin_leverage"` // 其他币种最大杠杆（默认5倍）
+}
+
+// Order 待校验的交易指令（action 与决策引擎一致）
+type Order struct {
+	Symbol          string  `json:"symbol"`
+	Action          string  `json:"action"`
+	Leverage        int     `json:"leverage,omitempty"`
+	PositionSizeUSD float64 `json:"position_size_usd,omitempty"`
+	StopLoss        float64 `json:"stop_loss,omitempty"`
+	TakeProfit      float64 `json:"take_profit,omitempty"`
+	NewStopLoss     float64 `json:"new_stop_loss,omitempty"`
+	NewTakeProfit   float64 `json:"new_take_profit,omitempty"`
+	ClosePercentage float64 `json:"close_percentage,omitempty"`
+	ReducePct       float64 `json:"reduce_pct,omitempty"`    // close_percentage 的同义字段
+	SizeUSD         float64 `json:"size_usd,omitempty"`      // 开仓同 position_size_usd；partial_close 为减仓名义价值
+	QtyContracts    float64 `json:"qty_contracts,omitempty"` // partial_close 减仓合约数量（币数）
+	Confidence      int     `json:"confidence,omitempty"`
+	RiskUSD         float64 `json:"risk_usd,omitempty"`
+}
+
+// Checker 风控校验器
+type Checker interface {
+	// Check 校验指令；liquidityCapUSD 为该币种的流动性仓位上限（0=不限制）
+	Check(order Order, accountEquity, liquidityCapUSD float64) error
+}
+
+type checker struct {
+	limits Limits
+}
+
+// New 创建风控校验器（未设置的杠杆上限使用默认5倍）
+func New(limits Limits) Checker {
+	if limits.BTCETHLeverage <= 0 {
+		limits.BTCETHLeverage = 5
+	}
+	if limits.AltcoinLeverage <= 0 {
+		limits.AltcoinLeverage = 5
+	}
+	return &checker{limits: limits}
+}
+
+func (c *checker) Check(order Order, accountEquity, liquidityCapUSD float64) error {
+	if accountEquity <= 0 {
+		return fmt.Errorf("账户净值必须大于0")
+	}
+	d := &decision.Decision{
+		Symbol:          market.Normalize(strings.TrimSpace(order.Symbol)),
+		Action:          order.Action,
+		Leverage:        order.Leverage,
+		PositionSizeUSD: order.PositionSizeUSD,
+		StopLoss:        order.StopLoss,
+		TakeProfit:      order.TakeProfit,
+		NewStopLoss:     order.NewStopLoss,
+		NewTakeProfit:   order.NewTakeProfit,
+		ClosePercentage: order.ClosePercentage,
+		ReducePct:       order.ReducePct,
+		SizeUSD:         order.SizeUSD,
+		QtyContracts:    order.QtyContracts,
+		Confidence:      order.Confidence,
+		RiskUSD:         order.RiskUSD,
+	}
+	return decision.ValidateDecision(d, accountEquity, c.limits.BTCETHLeverage, c.limits.AltcoinLeverage, liquidityCapUSD)
+}
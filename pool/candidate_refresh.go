@@ -0,0 +1,262 @@
+package pool
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// CandidateSnapshot 候选币种池快照（AI500 + OI Top 合并结果）
+type CandidateSnapshot struct {
+	Symbols       []string            `json:"symbols"`         // 候选币种
+	SymbolSources map[string][]string `json:"symbol_sources"`  // 每个币种的来源（"ai500"/"oi_top"）
+	AI500Limit    int                 `json:"ai500_limit"`     // AI500取前N个
+	RefreshedAt   time.Time           `json:"refreshed_at"`    // 上次刷新时间
+	RefreshReason string              `json:"refresh_reason"`  // 刷新原因：initial / scheduled / anomaly / manual
+	NextRefreshAt time.Time           `json:"next_refresh_at"` // 下次定时刷新时间
+	RefreshCount  int                 `json:"refresh_count"`   // 累计刷新次数
+	Degraded      bool                `json:"degraded"`        // 数据源长时间不可用，候选币种来自本地评分/默认币种
+}
+
+// CandidateAnomaly 触发候选池刷新的行情异动
+type CandidateAnomaly struct {
+	Symbol     string    `json:"symbol"`
+	Type       string    `json:"type"`  // "volume" 或 "oi"
+	Value      float64   `json:"value"` // 成交量倍数 或 OI变化百分比
+	DetectedAt time.Time `json:"detected_at"`
+	Triggered  bool      `json:"triggered_refresh"` // 是否实际触发了刷新（冷却期内只记录）
+}
+
+// candidateRefreshConfig 候选池刷新配置
+var candidateRefreshConfig = struct {
+	Interval         time.Duration // 定时刷新间隔
+	MinEventInterval time.Duration // 异动触发刷新的最小间隔（防止频繁刷新）
+	VolumeSpikeRatio float64       // 成交量 / 均量 超过该倍数视为异动
+	OIChangePct      float64       // 两次观测间OI变化超过该百分比视为异动
+	MaxSnapshotAge   time.Duration // 数据源不可用时快照最长沿用时长，超过后降级为本地评分
+}{
+	Interval:         10 * time.Minute,
+	MinEventInterval: 3 * time.Minute,
+	VolumeSpikeRatio: 3.0,
+	OIChangePct:      5.0,
+	MaxSnapshotAge:   time.Hour,
+}
+
+// candidateRefresher 候选池刷新器：定时刷新 + 行情异动触发刷新，多个trader共享同一份快照
+type candidateRefresher struct {
+	mu            sync.Mutex
+	snapshot      *CandidateSnapshot
+	pendingReason string             // 待处理的异动刷新原因
+	lastOI        map[string]float64 // 每个币种上次观测到的OI
+	anomalies     []CandidateAnomaly // 最近的异动记录
+}
+
+var refresher = &candidateRefresher{
+	lastOI: make(map[string]float64),
+}
+
+// maxAnomalyHistory 保留的异动记录条数
+const maxAnomalyHistory = 50
+
+// SetCandidateRefreshInterval 设置候选池定时刷新间隔
+func SetCandidateRefreshInterval(interval time.Duration) {
+	if interval > 0 {
+		candidateRefreshConfig.Interval = interval
+	}
+}
+
+// SetCandidateMaxSnapshotAge 设置数据源不可用时候选池快照的最长沿用时长
+func SetCandidateMaxSnapshotAge(d time.Duration) {
+	if d > 0 {
+		candidateRefreshConfig.MaxSnapshotAge = d
+	}
+}
+
+// GetCandidatePool 获取候选币种池（定时或异动触发时才重新请求AI500/OI Top，否则复用快照）
+func GetCandidatePool(ai500Limit int) (*CandidateSnapshot, error) {
+	refresher.mu.Lock()
+	defer refresher.mu.Unlock()
+
+	now := time.Now()
+	snapshot := refresher.snapshot
+	reason := ""
+	switch {
+	case snapshot == nil:
+		reason = "initial"
+	case snapshot.AI500Limit != ai500Limit:
+		reason = "config_changed"
+	case !now.Before(snapshot.NextRefreshAt):
+		reason = "scheduled"
+	case refresher.pendingReason != "" && now.Sub(snapshot.RefreshedAt) >= candidateRefreshConfig.MinEventInterval:
+		reason = refresher.pendingReason
+	}
+
+	if reason == "" {
+		return snapshot.copy(), nil
+	}
+
+	checkSourceStaleness()
+
+	merged, err := GetMergedCoinPool(ai500Limit)
+	failed := err != nil || len(merged.AllSymbols) == 0
+	maxAge := candidateRefreshConfig.MaxSnapshotAge
+	degraded := false
+	switch {
+	case failed && snapshot != nil && !snapshot.Degraded && now.Sub(snapshot.RefreshedAt) < maxAge:
+		log.Printf("⚠️  候选池刷新失败（%s），继续使用 %s 的快照: %v",
+			reason, snapshot.RefreshedAt.Format("15:04:05"), err)
+		return snapshot.copy(), nil
+	case failed || sourcesOutdated(maxAge):
+		// 没有可用快照、快照超过最长沿用时长，或数据源长时间只能返回历史缓存时降级：
+		// 本地评分引擎 → 默认主流币种，保证决策周期不会拿到空候选列表或过期的候选币种
+		log.Printf("🚨 候选池数据源超过 %s 没有成功更新（%v），降级使用本地评分/默认币种", maxAge, err)
+		merged = fallbackMergedPool(ai500Limit)
+		degraded = true
+	}
+
+	refreshCount := 1
+	if snapshot != nil {
+		refreshCount = snapshot.RefreshCount + 1
+		logCandidateDiff(snapshot.Symbols, merged.AllSymbols)
+	}
+	refresher.snapshot = &CandidateSnapshot{
+		Symbols:       merged.AllSymbols,
+		SymbolSources: merged.SymbolSources,
+		AI500Limit:    ai500Limit,
+		RefreshedAt:   now,
+		RefreshReason: reason,
+		NextRefreshAt: now.Add(candidateRefreshConfig.Interval),
+		RefreshCount:  refreshCount,
+		Degraded:      degraded,
+	}
+	refresher.pendingReason = ""
+
+	log.Printf("🔄 候选池已刷新（原因: %s，共%d个币种，下次定时刷新: %s）",
+		reason, len(merged.AllSymbols), refresher.snapshot.NextRefreshAt.Format("15:04:05"))
+	return refresher.snapshot.copy(), nil
+}
+
+// fallbackMergedPool 外部数据源全部不可用时的候选池：优先本地评分，其次默认主流币种
+func fallbackMergedPool(limit int) *MergedCoinPool {
+	merged := &MergedCoinPool{SymbolSources: make(map[string][]string)}
+
+	if coins, err := GetLocalScoredCoins(limit); err == nil {
+		for _, coin := range coins {
+			merged.AllSymbols = append(merged.AllSymbols, coin.Pair)
+			merged.SymbolSources[coin.Pair] = []string{"local_scoring"}
+		}
+		return merged
+	}
+
+	for _, symbol := range defaultMainstreamCoins {
+		symbol = normalizeSymbol(symbol)
+		merged.AllSymbols = append(merged.AllSymbols, symbol)
+		merged.SymbolSources[symbol] = []string{"default"}
+	}
+	return merged
+}
+
+// RequestCandidateRefresh 请求在下一次获取候选池时刷新（受最小刷新间隔限制）
+func RequestCandidateRefresh(reason string) {
+	refresher.mu.Lock()
+	defer refresher.mu.Unlock()
+
+	if refresher.pendingReason == "" {
+		refresher.pendingReason = reason
+	}
+}
+
+// ReportMarketActivity 上报币种的成交量/OI观测值，出现异动时请求刷新候选池
+func ReportMarketActivity(symbol string, currentVolume, averageVolume, openInterest float64) {
+	refresher.mu.Lock()
+	defer refresher.mu.Unlock()
+
+	now := time.Now()
+	var detected []CandidateAnomaly
+
+	if averageVolume > 0 && currentVolume/averageVolume >= candidateRefreshConfig.VolumeSpikeRatio {
+		detected = append(detected, CandidateAnomaly{
+			Symbol:     symbol,
+			Type:       "volume",
+			Value:      currentVolume / averageVolume,
+			DetectedAt: now,
+		})
+	}
+
+	if openInterest > 0 {
+		if lastOI, ok := refresher.lastOI[symbol]; ok && lastOI > 0 {
+			changePct := (openInterest - lastOI) / lastOI * 100
+			if math.Abs(changePct) >= candidateRefreshConfig.OIChangePct {
+				detected = append(detected, CandidateAnomaly{
+					Symbol:     symbol,
+					Type:       "oi",
+					Value:      changePct,
+					DetectedAt: now,
+				})
+			}
+		}
+		refresher.lastOI[symbol] = openInterest
+	}
+
+	for _, anomaly := range detected {
+		if refresher.pendingReason == "" {
+			refresher.pendingReason = fmt.Sprintf("anomaly:%s_%s", anomaly.Symbol, anomaly.Type)
+			anomaly.Triggered = true
+			log.Printf("📢 %s 行情异动（%s: %.2f），将提前刷新候选池", anomaly.Symbol, anomaly.Type, anomaly.Value)
+		}
+		refresher.anomalies = append(refresher.anomalies, anomaly)
+	}
+	if len(refresher.anomalies) > maxAnomalyHistory {
+		refresher.anomalies = refresher.anomalies[len(refresher.anomalies)-maxAnomalyHistory:]
+	}
+}
+
+// GetCandidateSnapshot 获取当前候选池快照和最近的异动记录（用于API）
+func GetCandidateSnapshot() (*CandidateSnapshot, []CandidateAnomaly) {
+	refresher.mu.Lock()
+	defer refresher.mu.Unlock()
+
+	anomalies := make([]CandidateAnomaly, len(refresher.anomalies))
+	copy(anomalies, refresher.anomalies)
+	return refresher.snapshot.copy(), anomalies
+}
+
+// copy 复制快照，避免调用方修改共享数据
+func (s *CandidateSnapshot) copy() *CandidateSnapshot {
+	if s == nil {
+		return nil
+	}
+	cp := *s
+	cp.Symbols = append([]string(nil), s.Symbols...)
+	cp.SymbolSources = make(map[string][]string, len(s.SymbolSources))
+	for symbol, sources := range s.SymbolSources {
+		cp.SymbolSources[symbol] = append([]string(nil), sources...)
+	}
+	return &cp
+}
+
+// logCandidateDiff 打印候选池的新增/移除币种
+func logCandidateDiff(oldSymbols, newSymbols []string) {
+	oldSet := make(map[string]bool, len(oldSymbols))
+	for _, s := range oldSymbols {
+		oldSet[s] = true
+	}
+	newSet := make(map[string]bool, len(newSymbols))
+	var added, removed []string
+	for _, s := range newSymbols {
+		newSet[s] = true
+		if !oldSet[s] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range oldSymbols {
+		if !newSet[s] {
+			removed = append(removed, s)
+		}
+	}
+	if len(added) > 0 || len(removed) > 0 {
+		log.Printf("📋 候选池变化: 新增 %v, 移除 %v", added, removed)
+	}
+}
@@ -2,6 +2,7 @@ package pool
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -115,8 +116,10 @@ func GetCoinPool() ([]CoinInfo, error) {
 			time.Sleep(2 * time.Second) // 重试前等待2秒
 		}
 
+		start := time.Now()
 		coins, err := fetchCoinPool()
 		if err == nil {
+			sourceHealth.recordSuccess(SourceAI500, time.Since(start), len(coins))
 			if attempt > 1 {
 				log.Printf("✓ 第%d次重试成功", attempt)
 			}
@@ -127,8 +130,13 @@ func GetCoinPool() ([]CoinInfo, error) {
 			return coins, nil
 		}
 
+		var schemaErr *schemaError
+		sourceHealth.recordFailure(SourceAI500, time.Since(start), err, errors.As(err, &schemaErr))
 		lastErr = err
 		log.Printf("❌ 第%d次请求失败: %v", attempt, err)
+		if schemaErr != nil {
+			break // 结构校验失败重试也没有意义
+		}
 	}
 
 	// API获取失败，尝试使用缓存
@@ -136,11 +144,22 @@ func GetCoinPool() ([]CoinInfo, error) {
 	cachedCoins, err := loadCoinPoolCache()
 	if err == nil {
 		log.Printf("✓ 使用历史缓存数据（共%d个币种）", len(cachedCoins))
+		sourceHealth.setFallback(SourceAI500, "cache")
 		return cachedCoins, nil
 	}
 
-	// 缓存也失败，使用默认主流币种
-	log.Printf("⚠️  无法加载缓存数据（最后错误: %v），使用默认主流币种列表", lastErr)
+	// 缓存也失败，使用本地评分引擎
+	log.Printf("⚠️  无法加载缓存数据（最后错误: %v），尝试使用本地评分引擎", lastErr)
+	localCoins, err := GetLocalScoredCoins(0)
+	if err == nil {
+		sourceHealth.setFallback(SourceAI500, "local_scoring")
+		return localCoins, nil
+	}
+	log.Printf("⚠️  本地评分引擎不可用: %v", err)
+
+	// 本地评分也失败，使用默认主流币种
+	log.Printf("⚠️  使用默认主流币种列表")
+	sourceHealth.setFallback(SourceAI500, "default")
 	return convertSymbolsToCoins(defaultMainstreamCoins), nil
 }
 
@@ -181,6 +200,10 @@ func fetchCoinPool() ([]CoinInfo, error) {
 		return nil, fmt.Errorf("币种列表为空")
 	}
 
+	if err := validateCoinPool(response.Data.Coins); err != nil {
+		return nil, &schemaError{err: err}
+	}
+
 	// 设置IsAvailable标志
 	coins := response.Data.Coins
 	for i := range coins {
@@ -436,8 +459,10 @@ func GetOITopPositions() ([]OIPosition, error) {
 			time.Sleep(2 * time.Second)
 		}
 
+		start := time.Now()
 		positions, err := fetchOITop()
 		if err == nil {
+			sourceHealth.recordSuccess(SourceOITop, time.Since(start), len(positions))
 			if attempt > 1 {
 				log.Printf("✓ 第%d次重试成功", attempt)
 			}
@@ -448,8 +473,13 @@ func GetOITopPositions() ([]OIPosition, error) {
 			return positions, nil
 		}
 
+		var schemaErr *schemaError
+		sourceHealth.recordFailure(SourceOITop, time.Since(start), err, errors.As(err, &schemaErr))
 		lastErr = err
 		log.Printf("❌ 第%d次请求OI Top失败: %v", attempt, err)
+		if schemaErr != nil {
+			break // 结构校验失败重试也没有意义
+		}
 	}
 
 	// API获取失败，尝试使用缓存
@@ -457,11 +487,13 @@ func GetOITopPositions() ([]OIPosition, error) {
 	cachedPositions, err := loadOITopCache()
 	if err == nil {
 		log.Printf("✓ 使用历史OI Top缓存数据（共%d个币种）", len(cachedPositions))
+		sourceHealth.setFallback(SourceOITop, "cache")
 		return cachedPositions, nil
 	}
 
 	// 缓存也失败，返回空列表（OI Top是可选的）
 	log.Printf("⚠️  无法加载OI Top缓存数据（最后错误: %v），跳过OI Top数据", lastErr)
+	sourceHealth.setFallback(SourceOITop, "none")
 	return []OIPosition{}, nil
 }
 
@@ -502,6 +534,10 @@ func fetchOITop() ([]OIPosition, error) {
 		return nil, fmt.Errorf("OI Top持仓列表为空")
 	}
 
+	if err := validateOITop(response.Data.Positions); err != nil {
+		return nil, &schemaError{err: err}
+	}
+
 	log.Printf("✓ 成功获取%d个OI Top币种（时间范围: %s）",
 		len(response.Data.Positions), response.Data.TimeRange)
 	return response.Data.Positions, nil
@@ -607,10 +643,14 @@ func GetMergedCoinPool(ai500Limit int) (*MergedCoinPool, error) {
 	symbolSet := make(map[string]bool)
 	symbolSources := make(map[string][]string)
 
-	// 添加AI500币种
+	// 添加AI500币种（AI500不可用、由本地评分引擎代替时标记为 local_scoring）
+	ai500Label := SourceAI500
+	if currentFallback(SourceAI500) == "local_scoring" {
+		ai500Label = "local_scoring"
+	}
 	for _, symbol := range ai500TopSymbols {
 		symbolSet[symbol] = true
-		symbolSources[symbol] = append(symbolSources[symbol], "ai500")
+		symbolSources[symbol] = append(symbolSources[symbol], ai500Label)
 	}
 
 	// 添加OI Top币种
@@ -0,0 +1,88 @@
+package pool
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"nofx/market"
+)
+
+// localScoringMinKlines 本地评分所需的最少4小时K线数量（24小时 + 基准）
+const localScoringMinKlines = 12
+
+// GetLocalScoredCoins 本地评分引擎：外部币种池不可用时，基于已订阅的行情数据为币种打分
+// 评分 = |24小时涨跌幅| × 成交量放大倍数，波动越大、放量越明显的币种排名越靠前
+func GetLocalScoredCoins(limit int) ([]CoinInfo, error) {
+	if market.WSMonitorCli == nil {
+		return nil, fmt.Errorf("行情监控未启动")
+	}
+
+	// 评分范围：行情监控已订阅的币种 + 默认主流币种
+	universe := make(map[string]bool)
+	for _, symbol := range market.WSMonitorCli.GetSymbols() {
+		universe[normalizeSymbol(symbol)] = true
+	}
+	for _, symbol := range defaultMainstreamCoins {
+		universe[normalizeSymbol(symbol)] = true
+	}
+
+	var coins []CoinInfo
+	for symbol := range universe {
+		klines, err := market.WSMonitorCli.GetCurrentKlines(symbol, "4h")
+		if err != nil || len(klines) < localScoringMinKlines {
+			continue
+		}
+
+		score := scoreKlines(klines)
+		if score <= 0 {
+			continue
+		}
+		coins = append(coins, CoinInfo{
+			Pair:        symbol,
+			Score:       score,
+			LastScore:   score,
+			IsAvailable: true,
+		})
+	}
+
+	if len(coins) == 0 {
+		return nil, fmt.Errorf("没有可评分的币种")
+	}
+
+	sortCoinsByScore(coins)
+	if limit > 0 && len(coins) > limit {
+		coins = coins[:limit]
+	}
+
+	log.Printf("🧮 本地评分引擎生成 %d 个候选币种（评分范围 %d 个币种）", len(coins), len(universe))
+	return coins, nil
+}
+
+// scoreKlines 计算单个币种的本地评分（基于4小时K线）
+func scoreKlines(klines []market.Kline) float64 {
+	n := len(klines)
+	last := klines[n-1]
+	dayAgo := klines[n-7] // 6根4小时K线 = 24小时
+	if dayAgo.Close <= 0 {
+		return 0
+	}
+	change24h := math.Abs((last.Close - dayAgo.Close) / dayAgo.Close * 100)
+
+	// 最近6根的平均成交量 / 之前的平均成交量
+	var recentVolume, baseVolume float64
+	for _, k := range klines[n-6:] {
+		recentVolume += k.QuoteVolume
+	}
+	recentVolume /= 6
+	for _, k := range klines[:n-6] {
+		baseVolume += k.QuoteVolume
+	}
+	baseVolume /= float64(n - 6)
+
+	volumeRatio := 1.0
+	if baseVolume > 0 {
+		volumeRatio = recentVolume / baseVolume
+	}
+
+	return change24h * volumeRatio
+}
@@ -0,0 +1,257 @@
+package pool
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 币种池数据源名称
+const (
+	SourceAI500 = "ai500"
+	SourceOITop = "oi_top"
+)
+
+// 数据源健康状态
+const (
+	SourceStatusHealthy      = "healthy"      // 最近一次请求成功
+	SourceStatusDegraded     = "degraded"     // 最近请求失败，但上次成功未超过过期阈值
+	SourceStatusStale        = "stale"        // 超过过期阈值没有成功请求
+	SourceStatusUnconfigured = "unconfigured" // 未配置API URL
+)
+
+// sourceStaleAfter 数据源超过该时长没有成功请求即视为过期
+var sourceStaleAfter = 30 * time.Minute
+
+// SourceHealth 数据源健康指标
+type SourceHealth struct {
+	Source              string    `json:"source"`
+	Status              string    `json:"status"`
+	LastAttempt         time.Time `json:"last_attempt"`
+	LastSuccess         time.Time `json:"last_success"`
+	LastLatencyMs       int64     `json:"last_latency_ms"`
+	LastError           string    `json:"last_error,omitempty"`
+	SchemaValid         bool      `json:"schema_valid"`         // 最近一次响应是否通过结构校验
+	ItemCount           int       `json:"item_count"`           // 最近一次成功返回的币种数量
+	ConsecutiveFailures int       `json:"consecutive_failures"` // 连续失败次数
+	TotalRequests       int       `json:"total_requests"`
+	TotalFailures       int       `json:"total_failures"`
+	Fallback            string    `json:"fallback,omitempty"` // 当前使用的降级数据："cache" / "local_scoring" / "default"
+}
+
+// sourceHealthRegistry 数据源健康记录
+type sourceHealthRegistry struct {
+	mu      sync.Mutex
+	sources map[string]*SourceHealth
+	alerted map[string]bool // 已发出过期告警的数据源（恢复后重置）
+}
+
+var sourceHealth = &sourceHealthRegistry{
+	sources: make(map[string]*SourceHealth),
+	alerted: make(map[string]bool),
+}
+
+// SetSourceStaleAfter 设置数据源过期阈值
+func SetSourceStaleAfter(d time.Duration) {
+	if d > 0 {
+		sourceStaleAfter = d
+	}
+}
+
+// get 获取数据源记录（调用方需持有锁）
+func (r *sourceHealthRegistry) get(source string) *SourceHealth {
+	h, ok := r.sources[source]
+	if !ok {
+		h = &SourceHealth{Source: source}
+		r.sources[source] = h
+	}
+	return h
+}
+
+// recordSuccess 记录一次成功请求
+func (r *sourceHealthRegistry) recordSuccess(source string, latency time.Duration, count int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h := r.get(source)
+	now := time.Now()
+	h.LastAttempt = now
+	h.LastSuccess = now
+	h.LastLatencyMs = latency.Milliseconds()
+	h.LastError = ""
+	h.SchemaValid = true
+	h.ItemCount = count
+	h.ConsecutiveFailures = 0
+	h.TotalRequests++
+	h.Fallback = ""
+
+	if r.alerted[source] {
+		delete(r.alerted, source)
+		log.Printf("✅ 数据源 %s 已恢复（%d个币种，延迟 %dms）", source, count, h.LastLatencyMs)
+	}
+}
+
+// recordFailure 记录一次失败请求（schemaErr 表示响应结构校验失败）
+func (r *sourceHealthRegistry) recordFailure(source string, latency time.Duration, err error, schemaErr bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h := r.get(source)
+	h.LastAttempt = time.Now()
+	h.LastLatencyMs = latency.Milliseconds()
+	h.LastError = err.Error()
+	h.SchemaValid = !schemaErr
+	h.ConsecutiveFailures++
+	h.TotalRequests++
+	h.TotalFailures++
+}
+
+// setFallback 记录数据源当前使用的降级数据
+func (r *sourceHealthRegistry) setFallback(source, fallback string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.get(source).Fallback = fallback
+}
+
+// currentFallback 获取数据源当前使用的降级数据（正常时为空）
+func currentFallback(source string) string {
+	sourceHealth.mu.Lock()
+	defer sourceHealth.mu.Unlock()
+
+	return sourceHealth.get(source).Fallback
+}
+
+// status 计算数据源状态（调用方需持有锁）
+func (h *SourceHealth) status(configured bool) string {
+	switch {
+	case !configured:
+		return SourceStatusUnconfigured
+	case h.LastSuccess.IsZero() && h.LastAttempt.IsZero():
+		return SourceStatusHealthy // 尚未请求过
+	case h.LastSuccess.IsZero() || time.Since(h.LastSuccess) > sourceStaleAfter:
+		return SourceStatusStale
+	case h.ConsecutiveFailures > 0:
+		return SourceStatusDegraded
+	default:
+		return SourceStatusHealthy
+	}
+}
+
+// isSourceConfigured 数据源是否配置了API URL
+func isSourceConfigured(source string) bool {
+	switch source {
+	case SourceAI500:
+		return !coinPoolConfig.UseDefaultCoins && strings.TrimSpace(coinPoolConfig.APIURL) != ""
+	case SourceOITop:
+		return strings.TrimSpace(oiTopConfig.APIURL) != ""
+	}
+	return false
+}
+
+// checkSourceStaleness 检查数据源是否过期，过期时告警一次（恢复后重新计数）
+func checkSourceStaleness() {
+	sourceHealth.mu.Lock()
+	defer sourceHealth.mu.Unlock()
+
+	for _, source := range []string{SourceAI500, SourceOITop} {
+		h := sourceHealth.get(source)
+		if h.status(isSourceConfigured(source)) != SourceStatusStale || sourceHealth.alerted[source] {
+			continue
+		}
+		sourceHealth.alerted[source] = true
+
+		lastSuccess := "从未成功"
+		if !h.LastSuccess.IsZero() {
+			lastSuccess = fmt.Sprintf("%.0f分钟前", time.Since(h.LastSuccess).Minutes())
+		}
+		log.Printf("🚨 数据源 %s 已过期（上次成功: %s，连续失败 %d 次，最后错误: %s），候选币种将使用降级数据",
+			source, lastSuccess, h.ConsecutiveFailures, h.LastError)
+	}
+}
+
+// sourcesOutdated 已配置的数据源是否都超过 maxAge 没有成功请求（没有配置数据源时返回 false）
+func sourcesOutdated(maxAge time.Duration) bool {
+	sourceHealth.mu.Lock()
+	defer sourceHealth.mu.Unlock()
+
+	configured := false
+	for _, source := range []string{SourceAI500, SourceOITop} {
+		if !isSourceConfigured(source) {
+			continue
+		}
+		configured = true
+		if h := sourceHealth.get(source); !h.LastSuccess.IsZero() && time.Since(h.LastSuccess) <= maxAge {
+			return false
+		}
+	}
+	return configured
+}
+
+// GetSourceHealth 获取所有数据源的健康指标
+func GetSourceHealth() []SourceHealth {
+	sourceHealth.mu.Lock()
+	defer sourceHealth.mu.Unlock()
+
+	result := make([]SourceHealth, 0, 2)
+	for _, source := range []string{SourceAI500, SourceOITop} {
+		h := *sourceHealth.get(source)
+		h.Status = h.status(isSourceConfigured(source))
+		result = append(result, h)
+	}
+	return result
+}
+
+// validateCoinPool 校验AI500响应结构：币种非空、不重复、至少有一个有效评分
+func validateCoinPool(coins []CoinInfo) error {
+	seen := make(map[string]bool, len(coins))
+	hasScore := false
+	for i, coin := range coins {
+		if strings.TrimSpace(coin.Pair) == "" {
+			return fmt.Errorf("第%d个币种缺少pair字段", i+1)
+		}
+		if seen[coin.Pair] {
+			return fmt.Errorf("币种重复: %s", coin.Pair)
+		}
+		seen[coin.Pair] = true
+		if coin.Score != 0 {
+			hasScore = true
+		}
+	}
+	if !hasScore {
+		return fmt.Errorf("所有币种评分均为0，疑似接口字段变更")
+	}
+	return nil
+}
+
+// validateOITop 校验OI Top响应结构：币种非空、排名有效
+func validateOITop(positions []OIPosition) error {
+	for i, pos := range positions {
+		if strings.TrimSpace(pos.Symbol) == "" {
+			return fmt.Errorf("第%d条持仓数据缺少symbol字段", i+1)
+		}
+		if pos.Rank <= 0 {
+			return fmt.Errorf("%s 排名无效: %d", pos.Symbol, pos.Rank)
+		}
+	}
+	return nil
+}
+
+// schemaError 响应结构校验失败
+type schemaError struct {
+	err error
+}
+
+func (e *schemaError) Error() string {
+	return "响应结构校验失败: " + e.err.Error()
+}
+
+// sortCoinsByScore 按评分降序排序
+func sortCoinsByScore(coins []CoinInfo) {
+	sort.SliceStable(coins, func(i, j int) bool {
+		return coins[i].Score > coins[j].Score
+	})
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"nofx/market"
+	"os"
+	"strings"
+)
+
+// runAnalysisService 独立运行市场分析服务（行情订阅与多币种指标计算），交易进程通过 market_analysis_url 调用
+// 用法: nofx analysis-service -addr :8090 [-coins BTCUSDT,ETHUSDT] （令牌读取环境变量 MARKET_ANALYSIS_TOKEN）
+func runAnalysisService(args []string) error {
+	fs := flag.NewFlagSet("analysis-service", flag.ContinueOnError)
+	addr := fs.String("addr", ":8090", "监听地址")
+	coins := fs.String("coins", "", "预先订阅的币种（逗号分隔，为空订阅全部永续合约）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var symbols []string
+	for _, coin := range strings.Split(*coins, ",") {
+		if coin = strings.TrimSpace(coin); coin != "" {
+			symbols = append(symbols, market.Normalize(coin))
+		}
+	}
+	go market.NewWSMonitor(150).Start(symbols)
+
+	token := strings.TrimSpace(os.Getenv("MARKET_ANALYSIS_TOKEN"))
+	if token == "" {
+		log.Printf("⚠️  未设置 MARKET_ANALYSIS_TOKEN，分析服务不校验调用方")
+	}
+	log.Printf("📡 市场分析服务启动: %s", *addr)
+	log.Printf("  • GET  /market/data?symbol=BTCUSDT - 单币种市场数据")
+	log.Printf("  • POST /market/batch               - 批量市场数据")
+	return http.ListenAndServe(*addr, market.NewAnalysisHandler(token))
+}
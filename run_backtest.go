@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"nofx/backtest"
+	"nofx/market"
+	"os"
+	"time"
+)
+
+// runBacktest 命令行回测：读取信号文件（JSON数组），在历史K线上模拟执行
+// 用法: nofx backtest -symbol BTCUSDT -interval 15m -start 2025-01-01 -end 2025-02-01 -signals signals.json
+//
+//	[-funding] [-oi-cap 1] [-liquidity taker|maker|auto] [-maker-wait 3] [-tie-break worst_case|ohlc_path] [-stop-fill touch|cross]
+func runBacktest(args []string) error {
+	fs := flag.NewFlagSet("backtest", flag.ContinueOnError)
+	symbol := fs.String("symbol", "", "币种（如 BTCUSDT）")
+	interval := fs.String("interval", "15m", "K线周期（如 3m / 15m / 1h / 4h）")
+	startStr := fs.String("start", "", "开始时间（2006-01-02 或 RFC3339）")
+	endStr := fs.String("end", "", "结束时间（2006-01-02 或 RFC3339，默认当前时间）")
+	signalsPath := fs.String("signals", "", "信号文件（JSON数组：time/action/size_usd/stop_loss/take_profit/limit_price）")
+	funding := fs.Bool("funding", false, "计入持仓期间的资金费")
+	oiCap := fs.Float64("oi-cap", 0, "单笔成交最多占持仓价值的百分比（0=不限制）")
+	liquidity := fs.String("liquidity", backtest.LiquidityTaker, "入场成交方式：taker / maker / auto")
+	makerWait := fs.Int("maker-wait", 3, "限价单最多等待K线数")
+	tieBreak := fs.String("tie-break", backtest.TieBreakWorstCase, "同一根K线同时触及止损止盈：worst_case（按止损）/ ohlc_path（按K线路径）")
+	stopFill := fs.String("stop-fill", backtest.FillOnTouch, "止损止盈成交假设：touch（触及成交）/ cross（穿过才成交）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *symbol == "" || *startStr == "" || *signalsPath == "" {
+		fs.Usage()
+		return fmt.Errorf("必须指定 -symbol、-start 和 -signals")
+	}
+
+	start, err := parseExportTime(*startStr)
+	if err != nil {
+		return fmt.Errorf("-start 无效: %w", err)
+	}
+	end := time.Now()
+	if *endStr != "" {
+		if end, err = parseExportTime(*endStr); err != nil {
+			return fmt.Errorf("-end 无效: %w", err)
+		}
+	}
+
+	raw, err := os.ReadFile(*signalsPath)
+	if err != nil {
+		return err
+	}
+	var signals []backtest.Signal
+	if err := json.Unmarshal(raw, &signals); err != nil {
+		return fmt.Errorf("解析信号文件失败: %w", err)
+	}
+
+	opts := backtest.Options{
+		Liquidity:        *liquidity,
+		MakerWaitCandles: *makerWait,
+		ModelFunding:     *funding,
+		OICapPct:         *oiCap,
+		IntrabarTieBreak: *tieBreak,
+		StopFill:         *stopFill,
+	}
+	data, err := backtest.LoadData(market.Normalize(*symbol), *interval, start, end, opts)
+	if err != nil {
+		return err
+	}
+	result, err := backtest.Run(data, signals, opts)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("✓ 回测完成 %s %s: %d笔交易，毛利 %.2f，手续费 %.2f，资金费 %+.2f，净利 %.2f USDT",
+		result.Symbol, *interval, len(result.Trades), result.GrossPnL, result.Fees, result.Funding, result.NetPnL)
+	if result.AmbiguousExits > 0 || result.TouchExits > 0 {
+		log.Printf("⚠️  成交假设: %d 笔同K线同时触及止损止盈（按 %s 判定），%d 笔仅触及价位即成交（%s 模式）",
+			result.AmbiguousExits, result.Options.IntrabarTieBreak, result.TouchExits, result.Options.StopFill)
+	}
+	out, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(out))
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"nofx/backtest"
+	"nofx/config"
+	"nofx/market"
+	"strings"
+	"time"
+)
+
+// runOptimize 命令行滚动前推优化分析器参数，并把每个币种选出的参数写入数据库
+// 用法: nofx optimize -symbols BTCUSDT,ETHUSDT -interval 1h -start 2025-01-01 -end 2025-04-01
+//
+//	[-train 500] [-test 100] [-funding] [-db config.db] [-dry-run]
+func runOptimize(args []string) error {
+	fs := flag.NewFlagSet("optimize", flag.ContinueOnError)
+	symbols := fs.String("symbols", "", "币种，逗号分隔（如 BTCUSDT,ETHUSDT）")
+	interval := fs.String("interval", "1h", "K线周期（如 15m / 1h / 4h）")
+	startStr := fs.String("start", "", "开始时间（2006-01-02 或 RFC3339）")
+	endStr := fs.String("end", "", "结束时间（2006-01-02 或 RFC3339，默认当前时间）")
+	train := fs.Int("train", 500, "样本内窗口K线数")
+	test := fs.Int("test", 100, "样本外窗口K线数")
+	funding := fs.Bool("funding", false, "回测计入资金费")
+	dbPath := fs.String("db", "config.db", "数据库文件")
+	dryRun := fs.Bool("dry-run", false, "只输出报告，不写入数据库")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *symbols == "" || *startStr == "" {
+		fs.Usage()
+		return fmt.Errorf("必须指定 -symbols 和 -start")
+	}
+
+	start, err := parseExportTime(*startStr)
+	if err != nil {
+		return fmt.Errorf("-start 无效: %w", err)
+	}
+	end := time.Now()
+	if *endStr != "" {
+		if end, err = parseExportTime(*endStr); err != nil {
+			return fmt.Errorf("-end 无效: %w", err)
+		}
+	}
+
+	var database *config.Database
+	if !*dryRun {
+		if database, err = config.NewDatabase(*dbPath); err != nil {
+			return err
+		}
+		defer database.Close()
+	}
+
+	cfg := backtest.WalkForwardConfig{
+		TrainCandles: *train,
+		TestCandles:  *test,
+		Options:      backtest.Options{ModelFunding: *funding},
+	}
+	for _, symbol := range strings.Split(*symbols, ",") {
+		symbol = market.Normalize(strings.TrimSpace(symbol))
+		if symbol == "" {
+			continue
+		}
+		data, err := backtest.LoadData(symbol, *interval, start, end, cfg.Options)
+		if err != nil {
+			return fmt.Errorf("%s: %w", symbol, err)
+		}
+		report, err := backtest.WalkForward(data, backtest.DefaultParamGrid(), cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", symbol, err)
+		}
+
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+		log.Printf("✓ %s: %d折，选用参数 %+v，稳定性 %.0f%%，样本外净利 %.2f USDT（前推效率 %.2f）",
+			symbol, len(report.Folds), report.Selected, report.Stability*100, report.OutOfSamplePnL, report.Efficiency)
+
+		if database == nil {
+			continue
+		}
+		params, _ := json.Marshal(report.Selected)
+		if err := database.SaveAnalyzerConfig(&config.AnalyzerConfig{
+			Symbol:         symbol,
+			Params:         string(params),
+			Stability:      report.Stability,
+			OutOfSamplePnL: report.OutOfSamplePnL,
+		}); err != nil {
+			return fmt.Errorf("%s: 保存参数失败: %w", symbol, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+	"nofx/mcp"
+	"nofx/trader"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// runSmoke 在币安合约测试网上跑一遍端到端冒烟测试：获取行情 → 用桩模型生成决策 → 挂/撤一笔小额Maker单 → 校验决策日志
+// 用法: nofx smoke [-symbol ETHUSDT] [-notional 30] [-log-dir 目录] [-skip-order]
+//
+//	API Key 从 -api-key/-secret-key 或环境变量 BINANCE_TESTNET_API_KEY / BINANCE_TESTNET_SECRET_KEY 读取
+func runSmoke(args []string) error {
+	fs := flag.NewFlagSet("smoke", flag.ContinueOnError)
+	symbol := fs.String("symbol", "ETHUSDT", "测试币种")
+	notional := fs.Float64("notional", 30, "测试挂单名义价值（USDT，需不低于交易所最小名义价值）")
+	logDir := fs.String("log-dir", "", "决策日志目录（默认临时目录，测试结束后删除）")
+	skipOrder := fs.Bool("skip-order", false, "跳过挂单/撤单步骤")
+	apiKey := fs.String("api-key", os.Getenv("BINANCE_TESTNET_API_KEY"), "测试网 API Key")
+	secretKey := fs.String("secret-key", os.Getenv("BINANCE_TESTNET_SECRET_KEY"), "测试网 Secret Key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *apiKey == "" || *secretKey == "" {
+		fs.Usage()
+		return fmt.Errorf("必须提供测试网 API Key（-api-key/-secret-key 或环境变量 BINANCE_TESTNET_API_KEY/BINANCE_TESTNET_SECRET_KEY）")
+	}
+	*symbol = strings.ToUpper(*symbol)
+
+	dir := *logDir
+	if dir == "" {
+		tmp, err := os.MkdirTemp("", "nofx-smoke-")
+		if err != nil {
+			return fmt.Errorf("创建临时日志目录失败: %w", err)
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+	}
+
+	// 只连接测试网（必须在创建客户端之前设置）
+	futures.UseTestnet = true
+	log.Printf("🧪 冒烟测试开始：币安合约测试网 %s", *symbol)
+
+	// 1. 账户
+	ft := trader.NewFuturesTrader(*apiKey, *secretKey, "smoke")
+	balance, err := ft.GetBalance()
+	if err != nil {
+		return smokeFail("查询测试网账户", err)
+	}
+	equity, _ := balance["totalWalletBalance"].(float64)
+	available, _ := balance["availableBalance"].(float64)
+	smokePass("查询测试网账户", "余额 %.2f USDT，可用 %.2f USDT", equity, available)
+
+	// 2. 行情
+	data, err := market.Get(*symbol)
+	if err != nil {
+		return smokeFail("获取行情数据", err)
+	}
+	if data.CurrentPrice <= 0 {
+		return smokeFail("获取行情数据", fmt.Errorf("%s 当前价格为 0", *symbol))
+	}
+	smokePass("获取行情数据", "%s 价格 %.4f", *symbol, data.CurrentPrice)
+
+	// 3. 构建prompt并调用桩模型
+	stub := newSmokeModel(*symbol)
+	defer stub.Close()
+	client := mcp.New()
+	client.SetCustomAPI(stub.URL, "smoke-test-key", "smoke-stub")
+
+	ctx := &decision.Context{
+		CurrentTime:     time.Now().Format("2006-01-02 15:04:05"),
+		Account:         decision.AccountInfo{TotalEquity: equity, AvailableBalance: available},
+		CandidateCoins:  []decision.CandidateCoin{{Symbol: *symbol, Sources: []string{"smoke"}}},
+		BTCETHLeverage:  5,
+		AltcoinLeverage: 5,
+		TraderID:        "smoke",
+	}
+	full, err := decision.GetFullDecision(ctx, client)
+	if err != nil {
+		return smokeFail("生成决策", err)
+	}
+	if !strings.Contains(full.UserPrompt, *symbol) {
+		return smokeFail("生成决策", fmt.Errorf("输入prompt中缺少 %s", *symbol))
+	}
+	if len(full.Decisions) != 1 || full.Decisions[0].Symbol != *symbol || full.Decisions[0].Action != "wait" {
+		return smokeFail("生成决策", fmt.Errorf("桩模型决策解析结果不符: %+v", full.Decisions))
+	}
+	smokePass("生成决策", "prompt %d 字符，解析出 %s %s", len(full.UserPrompt), full.Decisions[0].Symbol, full.Decisions[0].Action)
+
+	record := &logger.DecisionRecord{
+		SystemPrompt:   full.SystemPrompt,
+		InputPrompt:    full.UserPrompt,
+		CoTTrace:       full.CoTTrace,
+		SchemaVersion:  full.SchemaVersion,
+		AccountState:   logger.AccountSnapshot{TotalBalance: equity, AvailableBalance: available},
+		CandidateCoins: []string{*symbol},
+		Success:        true,
+	}
+	if decisionJSON, err := json.Marshal(full.Decisions); err == nil {
+		record.DecisionJSON = string(decisionJSON)
+	}
+
+	// 4. 挂单/撤单（远离市价的Maker买单，不会成交）
+	if *skipOrder {
+		smokePass("挂单/撤单", "已跳过")
+	} else {
+		action, err := smokeOrder(ft, *symbol, *notional)
+		if action != nil {
+			record.Decisions = append(record.Decisions, *action)
+		}
+		if err != nil {
+			return smokeFail("挂单/撤单", err)
+		}
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ %s 测试挂单 %d 已撤销", *symbol, action.OrderID))
+		smokePass("挂单/撤单", "订单 %d 数量 %.4f @ %.4f 已撤销", action.OrderID, action.Quantity, action.Price)
+	}
+
+	// 5. 决策日志
+	decisionLogger := logger.NewDecisionLogger(dir)
+	if err := decisionLogger.LogDecision(record); err != nil {
+		return smokeFail("写入决策日志", err)
+	}
+	records, err := decisionLogger.GetLatestRecords(1)
+	if err != nil {
+		return smokeFail("读取决策日志", err)
+	}
+	if len(records) != 1 || records[0].InputPrompt != record.InputPrompt || len(records[0].Decisions) != len(record.Decisions) {
+		return smokeFail("读取决策日志", fmt.Errorf("读回的决策日志与写入内容不一致"))
+	}
+	if len(record.Decisions) > 0 && records[0].Decisions[0].OrderID != record.Decisions[0].OrderID {
+		return smokeFail("读取决策日志", fmt.Errorf("订单ID不一致: %d != %d", records[0].Decisions[0].OrderID, record.Decisions[0].OrderID))
+	}
+	smokePass("决策日志", "周期 #%d 已写入并读回（%s）", records[0].CycleNumber, dir)
+
+	log.Printf("✅ 冒烟测试全部通过")
+	return nil
+}
+
+// smokeOrder 以市价97%挂一笔Maker买单，确认挂单后撤单并确认已撤销
+func smokeOrder(ft *trader.FuturesTrader, symbol string, notional float64) (*logger.DecisionAction, error) {
+	price, err := ft.GetMarketPrice(symbol)
+	if err != nil {
+		return nil, err
+	}
+	limitPrice := price * 0.97
+	quantity := notional / limitPrice
+
+	action := &logger.DecisionAction{
+		Action:    "open_long",
+		Symbol:    symbol,
+		Quantity:  quantity,
+		Price:     limitPrice,
+		Timestamp: time.Now(),
+		Reasoning: "smoke test",
+	}
+	orderID, err := ft.PlacePostOnlyOrder(symbol, "LONG", quantity, limitPrice)
+	if err != nil {
+		return nil, err
+	}
+	action.OrderID = orderID
+
+	status, _, _, err := ft.GetOrderFill(symbol, orderID)
+	if err != nil {
+		return action, err
+	}
+	if status != string(futures.OrderStatusTypeNew) {
+		return action, fmt.Errorf("挂单后状态为 %s，期望 NEW", status)
+	}
+
+	if err := ft.CancelOrder(symbol, orderID); err != nil {
+		return action, err
+	}
+	if status, _, _, err = ft.GetOrderFill(symbol, orderID); err != nil {
+		return action, err
+	}
+	if status != string(futures.OrderStatusTypeCanceled) {
+		return action, fmt.Errorf("撤单后状态为 %s，期望 CANCELED", status)
+	}
+
+	action.Success = true
+	action.Status = logger.DecisionStatusExecuted
+	return action, nil
+}
+
+// newSmokeModel 启动OpenAI兼容的桩模型服务，固定返回对测试币种的 wait 决策
+func newSmokeModel(symbol string) *httptest.Server {
+	content := fmt.Sprintf("<reasoning>smoke test</reasoning>\n<decision>\n[{\"symbol\": %q, \"action\": \"wait\", \"reasoning\": \"smoke test\"}]\n</decision>", symbol)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"role": "assistant", "content": content}},
+			},
+		})
+	}))
+}
+
+func smokePass(step, format string, args ...interface{}) {
+	log.Printf("  ✓ %s: %s", step, fmt.Sprintf(format, args...))
+}
+
+func smokeFail(step string, err error) error {
+	log.Printf("  ✗ %s: %v", step, err)
+	return fmt.Errorf("%s: %w", step, err)
+}
@@ -0,0 +1,148 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/logger"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	narrativeWindow        = 24 * time.Hour   // 回顾的时间窗口
+	narrativeMaxEvents     = 8                // prompt中展示的最近事件数
+	narrativeEventCapacity = 50               // 内存中保留的事件数
+	equitySampleInterval   = 15 * time.Minute // 净值采样间隔
+)
+
+// activityEvent 交易员近期活动事件
+type activityEvent struct {
+	Time time.Time
+	Text string
+}
+
+// equitySample 净值采样点
+type equitySample struct {
+	Time   time.Time
+	Equity float64
+}
+
+// activityNarrative 增量维护的近期活动回顾（开平仓、净值轨迹、风控事件）
+type activityNarrative struct {
+	mu      sync.Mutex
+	events  []activityEvent
+	samples []equitySample
+}
+
+// add 追加事件
+func (n *activityNarrative) add(text string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.events = append(n.events, activityEvent{Time: time.Now(), Text: text})
+	if len(n.events) > narrativeEventCapacity {
+		n.events = n.events[len(n.events)-narrativeEventCapacity:]
+	}
+}
+
+// sampleEquity 按采样间隔记录净值，并丢弃窗口外的采样点
+func (n *activityNarrative) sampleEquity(equity float64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now()
+	if len(n.samples) > 0 && now.Sub(n.samples[len(n.samples)-1].Time) < equitySampleInterval {
+		return
+	}
+	n.samples = append(n.samples, equitySample{Time: now, Equity: equity})
+
+	cutoff := now.Add(-narrativeWindow)
+	for len(n.samples) > 1 && n.samples[0].Time.Before(cutoff) {
+		n.samples = n.samples[1:]
+	}
+}
+
+// summary 生成近期回顾文本
+func (n *activityNarrative) summary(currentEquity float64, runtime time.Duration, cycles int) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("已运行%s，共%d个决策周期\n", formatRuntime(runtime), cycles))
+
+	if len(n.samples) > 0 {
+		start := n.samples[0]
+		high, low := currentEquity, currentEquity
+		for _, s := range n.samples {
+			high = math.Max(high, s.Equity)
+			low = math.Min(low, s.Equity)
+		}
+		changePct := 0.0
+		if start.Equity > 0 {
+			changePct = (currentEquity - start.Equity) / start.Equity * 100
+		}
+		sb.WriteString(fmt.Sprintf("净值轨迹(近%s): %.2f → %.2f (%+.2f%%) | 最高%.2f 最低%.2f\n",
+			formatRuntime(time.Since(start.Time)), start.Equity, currentEquity, changePct, high, low))
+	}
+
+	cutoff := time.Now().Add(-narrativeWindow)
+	var recent []activityEvent
+	for i := len(n.events) - 1; i >= 0 && len(recent) < narrativeMaxEvents; i-- {
+		if n.events[i].Time.Before(cutoff) {
+			break
+		}
+		recent = append(recent, n.events[i])
+	}
+	if len(recent) == 0 {
+		sb.WriteString("近期事件: 无\n")
+		return sb.String()
+	}
+	sb.WriteString("近期事件(新→旧):\n")
+	for _, e := range recent {
+		sb.WriteString(fmt.Sprintf("- [%s] %s\n", e.Time.Format("01-02 15:04"), e.Text))
+	}
+	return sb.String()
+}
+
+// formatRuntime 格式化时长
+func formatRuntime(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("%d分钟", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%d小时%d分钟", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// noteActivity 记录一条近期活动事件
+func (at *AutoTrader) noteActivity(format string, args ...interface{}) {
+	at.narrative.add(fmt.Sprintf(format, args...))
+}
+
+// noteDecisionActivity 把执行成功的决策动作记录为近期活动
+func (at *AutoTrader) noteDecisionActivity(action *logger.DecisionAction) {
+	if !action.Success {
+		return
+	}
+	switch action.Action {
+	case "open_long", "open_short":
+		side := "多"
+		if action.Action == "open_short" {
+			side = "空"
+		}
+		at.noteActivity("开%s %s @%.4g %dx", side, action.Symbol, action.Price, action.Leverage)
+	case "close_long", "close_short":
+		side := "多"
+		if action.Action == "close_short" {
+			side = "空"
+		}
+		at.noteActivity("平%s %s @%.4g", side, action.Symbol, action.Price)
+	case "partial_close":
+		at.noteActivity("部分平仓 %s 数量%.4g @%.4g", action.Symbol, action.Quantity, action.Price)
+	}
+}
+
+// buildRecentHistory 生成注入prompt的近期回顾
+func (at *AutoTrader) buildRecentHistory(currentEquity float64) string {
+	at.narrative.sampleEquity(currentEquity)
+	return at.narrative.summary(currentEquity, time.Since(at.startTime), at.callCount)
+}
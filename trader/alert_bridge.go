@@ -0,0 +1,147 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// alertCycleQueueSize 等待执行的警报定向周期上限（主循环空闲时依次执行）
+	alertCycleQueueSize = 8
+	// defaultAlertCycleCooldown 同一币种两次警报定向周期的最小间隔
+	defaultAlertCycleCooldown = 5 * time.Minute
+)
+
+// AlertCycleStatus 警报定向周期统计
+type AlertCycleStatus struct {
+	Queued     int                    `json:"queued"`    // 当前排队数
+	Triggered  int                    `json:"triggered"` // 已执行的定向周期数
+	Throttled  int                    `json:"throttled"` // 冷却期内被忽略的警报数
+	CooldownMs int64                  `json:"cooldown_ms"`
+	LastAlert  *decision.AlertTrigger `json:"last_alert,omitempty"`
+	LastRunAt  time.Time              `json:"last_run_at,omitempty"`
+	LastBySym  map[string]time.Time   `json:"last_by_symbol"`
+}
+
+// alertCycleState 警报到决策周期的桥接状态（主循环消费队列，active 仅在主循环内读写）
+type alertCycleState struct {
+	queue  chan decision.AlertTrigger
+	active *decision.AlertTrigger
+
+	mu        sync.Mutex
+	lastBySym map[string]time.Time
+	triggered int
+	throttled int
+	lastAlert *decision.AlertTrigger
+	lastRunAt time.Time
+}
+
+func newAlertCycleState() alertCycleState {
+	return alertCycleState{
+		queue:     make(chan decision.AlertTrigger, alertCycleQueueSize),
+		lastBySym: make(map[string]time.Time),
+	}
+}
+
+// getAlertCycleCooldown 同币种警报定向周期冷却时间（系统配置 alert_cooldown_minutes，默认5分钟）
+func (at *AutoTrader) getAlertCycleCooldown() time.Duration {
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		if value, err := db.GetSystemConfig("alert_cooldown_minutes"); err == nil && value != "" {
+			if minutes, err := strconv.Atoi(value); err == nil && minutes >= 0 {
+				return time.Duration(minutes) * time.Minute
+			}
+		}
+	}
+	return defaultAlertCycleCooldown
+}
+
+// TriggerAlertCycle 警报触发后排队一个只针对该币种的定向决策周期（不等待下一个定时周期）
+// 同币种冷却期内的重复警报被忽略并返回错误
+func (at *AutoTrader) TriggerAlertCycle(alert decision.AlertTrigger) error {
+	if !at.isRunning {
+		return fmt.Errorf("交易员未运行")
+	}
+	alert.Symbol = market.Normalize(alert.Symbol)
+	if alert.Symbol == "" || alert.Rule == "" {
+		return fmt.Errorf("警报必须包含 rule 和 symbol")
+	}
+	if alert.FiredAt.IsZero() {
+		alert.FiredAt = time.Now()
+	}
+
+	state := &at.alertCycles
+	cooldown := at.getAlertCycleCooldown()
+	state.mu.Lock()
+	if last, ok := state.lastBySym[alert.Symbol]; ok && time.Since(last) < cooldown {
+		state.throttled++
+		state.mu.Unlock()
+		return fmt.Errorf("%s 警报定向周期冷却中（间隔 %v）", alert.Symbol, cooldown)
+	}
+	state.lastBySym[alert.Symbol] = time.Now()
+	state.mu.Unlock()
+
+	select {
+	case state.queue <- alert:
+		log.Printf("⚡ [%s] 警报 %s 触发 %s 定向决策周期: %s", at.name, alert.Rule, alert.Symbol, alert.Message)
+		return nil
+	default:
+		return fmt.Errorf("警报定向周期队列已满（%d）", alertCycleQueueSize)
+	}
+}
+
+// runAlertCycle 执行一次警报定向周期（由主循环调用，与定时周期串行）
+func (at *AutoTrader) runAlertCycle(alert decision.AlertTrigger) {
+	state := &at.alertCycles
+	state.active = &alert
+	defer func() { state.active = nil }()
+
+	state.mu.Lock()
+	state.triggered++
+	state.lastAlert = &alert
+	state.lastRunAt = time.Now()
+	state.mu.Unlock()
+
+	if err := at.runCycle(); err != nil {
+		log.Printf("❌ 警报定向周期执行失败: %v", err)
+		at.notify(logger.EventError, logger.SeverityWarning, "警报 %s (%s) 定向周期执行失败: %v", alert.Rule, alert.Symbol, err)
+	}
+}
+
+// alertCycleCandidates 定向周期只分析警报币种（持仓仍完整提供给AI用于风险判断）
+func (at *AutoTrader) alertCycleCandidates() ([]decision.CandidateCoin, bool) {
+	alert := at.alertCycles.active
+	if alert == nil {
+		return nil, false
+	}
+	return []decision.CandidateCoin{{Symbol: alert.Symbol, Sources: []string{"alert"}}}, true
+}
+
+// GetAlertCycleStatus 警报定向周期统计
+func (at *AutoTrader) GetAlertCycleStatus() AlertCycleStatus {
+	state := &at.alertCycles
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	lastBySym := make(map[string]time.Time, len(state.lastBySym))
+	for k, v := range state.lastBySym {
+		lastBySym[k] = v
+	}
+	return AlertCycleStatus{
+		Queued:     len(state.queue),
+		Triggered:  state.triggered,
+		Throttled:  state.throttled,
+		CooldownMs: at.getAlertCycleCooldown().Milliseconds(),
+		LastAlert:  state.lastAlert,
+		LastRunAt:  state.lastRunAt,
+		LastBySym:  lastBySym,
+	}
+}
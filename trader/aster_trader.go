@@ -18,6 +18,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -33,6 +34,7 @@ type AsterTrader struct {
 	privateKey *ecdsa.PrivateKey // API钱包私钥
 	client     *http.Client
 	baseURL    string
+	timeOffset int64 // 本地时间 - 服务器时间（毫秒，原子读写）
 
 	// 缓存交易对精度信息
 	symbolPrecision map[string]SymbolPrecision
@@ -81,9 +83,33 @@ func NewAsterTrader(user, signer, privateKeyHex string) (*AsterTrader, error) {
 	}, nil
 }
 
-// genNonce 生成微秒时间戳
+// genNonce 生成微秒时间戳（已按服务器时间校正）
 func (t *AsterTrader) genNonce() uint64 {
-	return uint64(time.Now().UnixMicro())
+	return uint64(time.Now().UnixMicro() - atomic.LoadInt64(&t.timeOffset)*1000)
+}
+
+// SyncServerTime 同步Aster服务器时间，后续签名请求的时间戳按偏移校正
+func (t *AsterTrader) SyncServerTime() (int64, error) {
+	before := time.Now().UnixMilli()
+	resp, err := t.client.Get(t.baseURL + "/fapi/v1/time")
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		ServerTime int64 `json:"serverTime"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("解析服务器时间失败: %w", err)
+	}
+	if result.ServerTime <= 0 {
+		return 0, fmt.Errorf("服务器时间无效")
+	}
+
+	offset := (before+time.Now().UnixMilli())/2 - result.ServerTime
+	atomic.StoreInt64(&t.timeOffset, offset)
+	return offset, nil
 }
 
 // getPrecision 获取交易对精度信息
@@ -270,7 +296,7 @@ func (t *AsterTrader) normalize(v interface{}) (interface{}, error) {
 func (t *AsterTrader) sign(params map[string]interface{}, nonce uint64) error {
 	// 添加时间戳和接收窗口
 	params["recvWindow"] = "50000"
-	params["timestamp"] = strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+	params["timestamp"] = strconv.FormatInt(time.Now().UnixMilli()-atomic.LoadInt64(&t.timeOffset), 10)
 
 	// 规范化参数为JSON字符串
 	jsonStr, err := t.normalizeAndStringify(params)
@@ -767,6 +793,7 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 		"timeInForce":  "GTC",
 		"quantity":     qtyStr,
 		"price":        priceStr,
+		"reduceOnly":   "true", // 只减仓，持仓不符时交易所拒单而不是反向开仓
 	}
 
 	body, err := t.request("POST", "/fapi/v3/order", params)
@@ -850,6 +877,7 @@ func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]in
 		"timeInForce":  "GTC",
 		"quantity":     qtyStr,
 		"price":        priceStr,
+		"reduceOnly":   "true", // 只减仓，持仓不符时交易所拒单而不是反向开仓
 	}
 
 	body, err := t.request("POST", "/fapi/v3/order", params)
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"nofx/config"
 	"nofx/decision"
 	"nofx/logger"
 	"nofx/market"
 	"nofx/mcp"
+	"nofx/metrics"
 	"nofx/pool"
 	"strings"
 	"sync"
@@ -61,8 +63,8 @@ type AutoTraderConfig struct {
 	BTCETHLeverage  int // BTC和ETH的杠杆倍数
 	AltcoinLeverage int // 山寨币的杠杆倍数
 
-	// 风险控制（仅作为提示，AI可自主决定）
-	MaxDailyLoss    float64       // 最大日亏损百分比（提示）
+	// 风险控制（最大回撤仅作为提示，AI可自主决定）
+	MaxDailyLoss    float64       // 最大日亏损百分比（达到后熔断，暂停 StopTradingTime）
 	MaxDrawdown     float64       // 最大回撤百分比（提示）
 	StopTradingTime time.Duration // 触发风控后暂停时长
 
@@ -75,6 +77,44 @@ type AutoTraderConfig struct {
 
 	// 系统提示词模板
 	SystemPromptTemplate string // 系统提示词模板名称（如 "default", "aggressive"）
+
+	// 执行错误处理矩阵（为空的错误分类使用 DefaultExecRetryPolicies）
+	ExecRetryPolicies map[ExecErrorClass]ExecRetryPolicy
+
+	// 总开放风险上限（占净值百分比，0=使用系统配置 max_open_risk_pct）
+	MaxOpenRiskPct float64
+
+	// 决策输出格式版本（为空=使用系统配置 decision_schema_version，用于新旧prompt格式灰度切换）
+	DecisionSchemaVersion string
+
+	// 波动率目标（单仓位预测日波动金额占净值百分比，0=使用系统配置 vol_target_pct，均为0时不启用）
+	VolTargetPct float64
+	// 仓位分配模式：ai / risk_parity（为空=使用系统配置 allocation_mode）
+	AllocationMode string
+	// 资金费结算前N分钟内不逆费率方向开仓（0=使用系统配置 funding_block_minutes）
+	FundingEntryBlockMinutes int
+	// 风险偏好档位：conservative / balanced / aggressive（为空=使用系统配置 risk_persona）
+	RiskPersona string
+	// 模型采样参数（温度、top_p、max_tokens、seed；零值=使用系统配置 sampling_params）
+	Sampling mcp.SamplingParams
+	// 开仓下单策略：market / hint（默认，仅AI给出 routing.post_only 时挂单）/ maker_preferred
+	ExecutionPolicy  string
+	MakerTimeout     time.Duration // Maker挂单等待成交时长（默认30秒）
+	MakerMaxChaseBps float64       // 超时后允许市价补齐的最大不利偏离（基点，默认15）
+
+	// 连续输出不合规时自动切换到更合规的模板（false=使用系统配置 template_auto_switch）
+	TemplateAutoSwitch bool
+
+	// 市场数据输出详细程度：brief / standard / full（为空=使用系统配置 prompt_verbosity）
+	PromptVerbosity string
+
+	// 开仓后核验止损单的等待时间（默认10秒）
+	StopVerifyDelay time.Duration
+	// 止损无法补挂时紧急平仓（false=使用系统配置 stop_watchdog_emergency_close）
+	StopEmergencyClose bool
+
+	// 决策需人工审批后执行（false=使用系统配置 decision_approval_required）
+	RequireApproval bool
 }
 
 // AutoTrader 自动交易器
@@ -95,18 +135,56 @@ type AutoTrader struct {
 	defaultCoins          []string // 默认币种列表（从数据库获取）
 	tradingCoins          []string // 实际交易币种列表
 	lastResetTime         time.Time
-	stopUntil             time.Time
+	breakerMu             sync.Mutex
+	breaker               *config.CircuitBreakerState // 最近一次熔断（持久化到数据库，冷却到期时间即暂停交易截止时间）
 	isRunning             bool
-	startTime             time.Time          // 系统启动时间
-	callCount             int                // AI调用次数
-	positionFirstSeenTime map[string]int64   // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
-	stopMonitorCh         chan struct{}      // 用于停止监控goroutine
-	monitorWg             sync.WaitGroup     // 用于等待监控goroutine结束
-	peakPnLCache          map[string]float64 // 最高收益缓存 (symbol -> 峰值盈亏百分比)
-	peakPnLCacheMutex     sync.RWMutex       // 缓存读写锁
-	lastBalanceSyncTime   time.Time          // 上次余额同步时间
-	database              interface{}        // 数据库引用（用于自动更新余额）
-	userID                string             // 用户ID
+	startTime             time.Time                        // 系统启动时间
+	callCount             int                              // AI调用次数
+	positionFirstSeenTime map[string]int64                 // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
+	stopMonitorCh         chan struct{}                    // 用于停止监控goroutine
+	monitorWg             sync.WaitGroup                   // 用于等待监控goroutine结束
+	peakPnLCache          map[string]float64               // 最高收益缓存 (symbol -> 峰值盈亏百分比)
+	peakPnLCacheMutex     sync.RWMutex                     // 缓存读写锁
+	lastBalanceSyncTime   time.Time                        // 上次余额同步时间
+	database              interface{}                      // 数据库引用（用于自动更新余额）
+	userID                string                           // 用户ID
+	execErrors            *execErrorMetrics                // 执行错误统计
+	clockSync             clockSyncState                   // 交易所时间同步状态
+	stopLossPrices        map[string]float64               // 持仓当前止损价 (symbol_side -> 价格)
+	stopLossMutex         sync.RWMutex                     // 止损价读写锁
+	lastCandidates        []decision.CandidateCoin         // 最近一次决策使用的候选币种
+	lastCandidatesAt      time.Time                        // 最近一次获取候选币种的时间
+	lastCandidatesMutex   sync.RWMutex                     // 候选币种读写锁
+	symbolMemory          *logger.SymbolMemoryStore        // 币种策略记忆
+	timeframeReliability  *logger.TimeframeHitStore        // 各周期趋势信号的实际命中率
+	decisionTrends        map[string]map[string]int        // 本周期决策时各币种各周期的趋势方向（开仓时记录）
+	positionSnapshot      map[string]decision.PositionInfo // 上一周期的持仓快照（用于识别交易所侧平仓）
+	positionSnapshotMutex sync.Mutex                       // 持仓快照锁
+	narrative             *activityNarrative               // 近期活动回顾
+	executionQuality      *logger.ExecutionQualityStore    // 成交滑点记录
+	decisionPrices        map[string]float64               // 本周期AI决策时的币种价格（执行质量的预期价格）
+	makerStats            makerStatsTracker                // Maker挂单统计
+	modelQuality          modelQualityTracker              // 模型质量问题统计
+	warmup                warmStartState                   // 启动行情预热结果
+	stopWatchdog          stopWatchdogTracker              // 开仓后止损核验统计
+	decisionQueue         decisionQueue                    // 待执行/待审批决策队列
+	overrideJournal       *logger.OverrideJournal          // 决策队列人工操作日志
+	promptAudit           *logger.PromptAuditLog           // System Prompt 变更审计
+	settlements           *logger.SettlementStore          // 每日结算快照
+	fxRates               *logger.FXRateCache              // 报告货币汇率（按日缓存）
+	tradeIdeas            *logger.TradeIdeaStore           // 外部交易想法收件箱
+	postMortems           *logger.PostMortemStore          // 亏损复盘包
+	notifier              *logger.Notifier                 // 按偏好过滤/限频/汇总的事件通知
+	alertCycles           alertCycleState                  // 警报触发的定向决策周期
+	positionWatch         positionWatchState               // 持仓优先刷新（决策周期之间）
+	equityGuard           equityGuardState                 // 净值异常跳变保护（确认前冻结新开仓）
+	maintenance           maintenanceState                 // 交易所维护窗口（暂停下单，结束后对账）
+	pause                 pauseState                       // 人工暂停（全局/交易员级，暂停开仓或完全冻结）
+	setbacks              entrySetbacks                    // 近期开仓被拒绝/被止损记录（重复开仓抑制）
+	lastCycleAt           time.Time                        // 最近一次决策周期开始时间
+	lastCycleMu           sync.Mutex                       // 最近决策时间锁（同时保护最近周期错误）
+	lastCycleErr          string                           // 最近一次决策周期失败的错误
+	lastCycleErrAt        time.Time                        // 最近一次决策周期失败时间
 }
 
 // NewAutoTrader 创建自动交易器
@@ -208,7 +286,7 @@ func NewAutoTrader(config AutoTraderConfig, database interface{}, userID string)
 		systemPromptTemplate = "adaptive"
 	}
 
-	return &AutoTrader{
+	at := &AutoTrader{
 		id:                    config.ID,
 		name:                  config.Name,
 		aiModel:               config.AIModel,
@@ -233,7 +311,28 @@ func NewAutoTrader(config AutoTraderConfig, database interface{}, userID string)
 		lastBalanceSyncTime:   time.Now(), // 初始化为当前时间
 		database:              database,
 		userID:                userID,
-	}, nil
+		execErrors:            newExecErrorMetrics(),
+		stopLossPrices:        make(map[string]float64),
+		symbolMemory:          logger.NewSymbolMemoryStore(logDir),
+		timeframeReliability:  logger.NewTimeframeHitStore(logDir),
+		positionSnapshot:      make(map[string]decision.PositionInfo),
+		narrative:             &activityNarrative{},
+		executionQuality:      logger.NewExecutionQualityStore(logDir),
+		overrideJournal:       logger.NewOverrideJournal(logDir),
+		promptAudit:           logger.NewPromptAuditLog(logDir),
+		settlements:           logger.NewSettlementStore(logDir),
+		fxRates:               logger.NewFXRateCache(logDir),
+		tradeIdeas:            logger.NewTradeIdeaStore(logDir),
+		postMortems:           logger.NewPostMortemStore(logDir),
+		alertCycles:           newAlertCycleState(),
+		positionWatch:         newPositionWatchState(),
+	}
+	at.notifier = logger.NewNotifier(config.Name, at.loadNotificationPrefs())
+	at.restoreCircuitBreaker()
+	at.restoreEquityAnomaly()
+	at.mcpClient.SetSampling(at.loadSamplingParams())
+	at.mcpClient.MetricsLabel = at.id
+	return at, nil
 }
 
 // Run 运行自动交易主循环
@@ -241,7 +340,7 @@ func (at *AutoTrader) Run() error {
 	at.isRunning = true
 	at.stopMonitorCh = make(chan struct{})
 	at.startTime = time.Now()
-	
+
 	log.Println("🚀 AI驱动自动交易系统启动")
 	log.Printf("💰 初始余额: %.2f USDT", at.initialBalance)
 	log.Printf("⚙️  扫描间隔: %v", at.config.ScanInterval)
@@ -249,23 +348,36 @@ func (at *AutoTrader) Run() error {
 	at.monitorWg.Add(1)
 	defer at.monitorWg.Done()
 
+	// 成交/风控事件经事件总线转发到通知渠道
+	unsubscribe := at.subscribeEvents()
+	defer unsubscribe()
+
 	// 启动回撤监控
 	at.startDrawdownMonitor()
 
+	// 启动粉尘仓位清理
+	at.startDustCleanup()
+
+	// 决策周期之间优先刷新持仓状态，逆向波动过大时触发紧急持仓管理周期
+	at.startPositionWatch()
+
+	// 首个决策周期前预热行情数据，避免指标基于不完整的K线计算
+	at.warmStart()
+
 	ticker := time.NewTicker(at.config.ScanInterval)
 	defer ticker.Stop()
 
-	// 首次立即执行
-	if err := at.runCycle(); err != nil {
-		log.Printf("❌ 执行失败: %v", err)
+	// 首次立即执行（当前K线在重启前已执行过决策时跳过）
+	if at.startupCycleDue(time.Now()) {
+		at.runScheduledCycle()
 	}
 
 	for at.isRunning {
 		select {
 		case <-ticker.C:
-			if err := at.runCycle(); err != nil {
-				log.Printf("❌ 执行失败: %v", err)
-			}
+			at.runScheduledCycle()
+		case alert := <-at.alertCycles.queue:
+			at.runAlertCycle(alert)
 		case <-at.stopMonitorCh:
 			log.Printf("[%s] ⏹ 收到停止信号，退出自动交易主循环", at.name)
 			return nil
@@ -293,6 +405,12 @@ func (at *AutoTrader) autoSyncBalanceIfNeeded() {
 		return
 	}
 
+	// 资金已由再平衡分配（初始余额 = 分配到的资金），账户余额变化不代表本交易员的资金变化
+	if at.GetCapitalWeight() > 0 {
+		at.lastBalanceSyncTime = time.Now()
+		return
+	}
+
 	log.Printf("🔄 [%s] 开始自动检查余额变化...", at.name)
 
 	// 查询实际余额
@@ -384,6 +502,10 @@ func (at *AutoTrader) autoSyncBalanceIfNeeded() {
 // runCycle 运行一个交易周期（使用AI全权决策）
 func (at *AutoTrader) runCycle() error {
 	at.callCount++
+	at.markCycleStart()
+	if at.notifier != nil {
+		at.notifier.FlushDue() // 汇总周期已到时推送积压的通知
+	}
 
 	log.Print("\n" + strings.Repeat("=", 70) + "\n")
 	log.Printf("⏰ %s - AI决策周期 #%d", time.Now().Format("2006-01-02 15:04:05"), at.callCount)
@@ -394,10 +516,13 @@ func (at *AutoTrader) runCycle() error {
 		ExecutionLog: []string{},
 		Success:      true,
 	}
+	if alert := at.alertCycles.active; alert != nil {
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("⚡ 警报定向周期: %s %s - %s", alert.Rule, alert.Symbol, alert.Message))
+	}
 
 	// 1. 检查是否需要停止交易
-	if time.Now().Before(at.stopUntil) {
-		remaining := at.stopUntil.Sub(time.Now())
+	if stopUntil := at.stopUntil(); time.Now().Before(stopUntil) {
+		remaining := stopUntil.Sub(time.Now())
 		log.Printf("⏸ 风险控制：暂停交易中，剩余 %.0f 分钟", remaining.Minutes())
 		record.Success = false
 		record.ErrorMessage = fmt.Sprintf("风险控制暂停中，剩余 %.0f 分钟", remaining.Minutes())
@@ -405,6 +530,22 @@ func (at *AutoTrader) runCycle() error {
 		return nil
 	}
 
+	// 交易所维护中：暂停下单并跳过本周期（维护结束后先对账、执行暂缓的管理操作，再继续本周期）
+	if at.checkMaintenance() {
+		record.Success = false
+		record.ErrorMessage = "交易所维护中，暂停下单"
+		at.decisionLogger.LogDecision(record)
+		return nil
+	}
+
+	// 人工暂停：完全冻结时跳过本周期；暂停开仓时照常管理持仓，开仓决策在下方转为 wait
+	if pause := at.checkPause(); pause != nil && pause.Mode == PauseModeFreeze {
+		record.Success = false
+		record.ErrorMessage = "交易已暂停: " + pause.describe()
+		at.decisionLogger.LogDecision(record)
+		return nil
+	}
+
 	// 2. 重置日盈亏（每天重置）
 	if time.Since(at.lastResetTime) > 24*time.Hour {
 		at.dailyPnL = 0
@@ -412,6 +553,12 @@ func (at *AutoTrader) runCycle() error {
 		log.Println("📅 日盈亏已重置")
 	}
 
+	// 定期同步交易所服务器时间（签名请求时间戳校正，偏差过大时告警）
+	at.syncExchangeTime(false)
+
+	// 跨日后生成前一日的结算快照（已实现盈亏、手续费、资金费、收盘净值）
+	at.settlePendingDays()
+
 	// 3. 自动同步余额（每10分钟检查一次，充值/提现后自动更新）
 	at.autoSyncBalanceIfNeeded()
 
@@ -433,6 +580,19 @@ func (at *AutoTrader) runCycle() error {
 		MarginUsedPct:         ctx.Account.MarginUsedPct,
 	}
 
+	metrics.ObserveAll(map[string]float64{
+		metrics.Key(metrics.Equity, at.id): ctx.Account.TotalEquity,
+		metrics.Key(metrics.PnL, at.id):    ctx.Account.TotalPnL,
+	})
+
+	// 当日亏损达到上限时熔断（冷却到期时间持久化，重启后继续生效）
+	if at.checkCircuitBreaker(ctx.Account.TotalEquity) {
+		record.Success = false
+		record.ErrorMessage = fmt.Sprintf("触发熔断，暂停交易至 %s", at.stopUntil().Format("15:04:05"))
+		at.decisionLogger.LogDecision(record)
+		return nil
+	}
+
 	// 保存持仓快照
 	for _, pos := range ctx.Positions {
 		record.Positions = append(record.Positions, logger.PositionSnapshot{
@@ -456,14 +616,49 @@ func (at *AutoTrader) runCycle() error {
 		ctx.Account.TotalEquity, ctx.Account.AvailableBalance, ctx.Account.PositionCount)
 
 	// 5. 调用AI获取完整决策
+	at.auditSystemPrompt(ctx)
 	log.Printf("🤖 正在请求AI分析并决策... [模板: %s]", at.systemPromptTemplate)
+	decisionStart := time.Now()
 	decision, err := decision.GetFullDecisionWithCustomPrompt(ctx, at.mcpClient, at.customPrompt, at.overrideBasePrompt, at.systemPromptTemplate)
+	metrics.Observe(metrics.Key(metrics.DecisionLatency, at.id), time.Since(decisionStart).Seconds())
+
+	// 记录决策时各币种价格（作为执行质量统计的预期价格）
+	at.decisionPrices = make(map[string]float64, len(ctx.MarketDataMap))
+	at.decisionTrends = make(map[string]map[string]int, len(ctx.MarketDataMap))
+	for symbol, data := range ctx.MarketDataMap {
+		at.decisionPrices[symbol] = data.CurrentPrice
+		at.decisionTrends[symbol] = data.TimeframeTrends()
+	}
 
 	// 即使有错误，也保存思维链、决策和输入prompt（用于debug）
 	if decision != nil {
 		record.SystemPrompt = decision.SystemPrompt // 保存系统提示词
 		record.InputPrompt = decision.UserPrompt
 		record.CoTTrace = decision.CoTTrace
+		record.SchemaVersion = decision.SchemaVersion
+		record.Sampling = &decision.Sampling
+		if decision.Degradation != nil {
+			record.PromptDegradation = decision.Degradation.String()
+			record.ExecutionLog = append(record.ExecutionLog, "⚠️ "+record.PromptDegradation)
+		}
+		for _, call := range decision.ToolCalls {
+			record.ToolCalls = append(record.ToolCalls, call.String())
+		}
+		at.recordModelIncidents(record, decision.Decisions, decision.Incidents)
+		for _, r := range decision.Rejected {
+			rejectedRecord := logger.DecisionAction{
+				Action:    r.Decision.Action,
+				Symbol:    r.Decision.Symbol,
+				Leverage:  r.Decision.Leverage,
+				Timestamp: time.Now(),
+				Status:    logger.DecisionStatusRejected,
+				Error:     r.Reason,
+			}
+			tagActionRecord(&rejectedRecord, &r.Decision)
+			record.Decisions = append(record.Decisions, rejectedRecord)
+			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("🚫 %s %s 被拒绝: %s", r.Decision.Symbol, r.Decision.Action, r.Reason))
+		}
+		at.recordConformance(record, decision.Conformance)
 		if len(decision.Decisions) > 0 {
 			decisionJSON, _ := json.MarshalIndent(decision.Decisions, "", "  ")
 			record.DecisionJSON = string(decisionJSON)
@@ -523,49 +718,223 @@ func (at *AutoTrader) runCycle() error {
 	// 8. 对决策排序：确保先平仓后开仓（防止仓位叠加超限）
 	log.Print(strings.Repeat("-", 70))
 
+	// 发布AI决策事件（执行前，保留模型原始动作）
+	at.publishDecisions(decision.Decisions, at.callCount)
+
+	// 仅观察币种的开仓决策转为 wait
+	for _, note := range convertWatchOnlyOpens(decision.Decisions, ctx.WatchOnly) {
+		log.Print(note)
+		record.ExecutionLog = append(record.ExecutionLog, note)
+	}
+
+	// 行情数据陈旧的币种禁止新开仓（避免基于冻结的数据交易）
+	for _, note := range convertStaleOpens(decision.Decisions, ctx.StaleData) {
+		log.Print(note)
+		record.ExecutionLog = append(record.ExecutionLog, note)
+		at.noteActivity("%s", note)
+	}
+
+	// 人工暂停开仓：重新读取暂停状态（AI调用期间设置的暂停也生效），开仓决策转为 wait
+	if pause := at.activePause(); pause != nil {
+		for _, note := range convertPausedOpens(decision.Decisions, pause) {
+			log.Print(note)
+			record.ExecutionLog = append(record.ExecutionLog, note)
+		}
+	}
+
+	// 近期被拒绝或被止损后在相近价格同向重复开仓：转为 wait，避免报复性交易循环
+	for _, note := range at.suppressRepeatEntries(decision.Decisions, ctx.MarketDataMap, time.Now()) {
+		log.Print(note)
+		record.ExecutionLog = append(record.ExecutionLog, note)
+		at.noteActivity("%s", note)
+	}
+
+	// 净值异常未确认：开仓决策转为 wait，平仓和止损调整照常执行
+	if at.equityFrozen() {
+		for _, note := range convertFrozenOpens(decision.Decisions) {
+			log.Print(note)
+			record.ExecutionLog = append(record.ExecutionLog, note)
+		}
+	}
+
 	// 8. 对决策排序：确保先平仓后开仓（防止仓位叠加超限）
 	sortedDecisions := sortDecisionsByPriority(decision.Decisions)
 
+	// 风险平价模式：按ATR估算风险重新分配本周期开仓信号的仓位
+	for _, note := range at.applyRiskParity(sortedDecisions, ctx) {
+		record.ExecutionLog = append(record.ExecutionLog, note)
+	}
+
 	log.Println("🔄 执行顺序（已优化）: 先平仓→后开仓")
 	for i, d := range sortedDecisions {
 		log.Printf("  [%d] %s %s", i+1, d.Symbol, d.Action)
 	}
 	log.Println()
 
+	// 上一周期未审批的决策失效；审批模式下本周期决策只入队，等待人工批准/修改/取消
+	at.supersedePendingDecisions()
+	if at.IsApprovalRequired() {
+		for _, d := range sortedDecisions {
+			if d.Action == "hold" || d.Action == "wait" {
+				continue
+			}
+			item := at.enqueueDecision(d, at.callCount, QueueStatusPending)
+			log.Printf("⏸ %s %s 等待人工审批 (id=%s)", d.Symbol, d.Action, item.ID)
+			at.notify(logger.EventApproval, logger.SeverityWarning, "%s %s 等待人工审批 (id=%s)", d.Symbol, d.Action, item.ID)
+			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("⏸ %s %s 等待人工审批 (id=%s)", d.Symbol, d.Action, item.ID))
+		}
+		sortedDecisions = nil
+	}
+
 	// 执行决策并记录结果
 	for _, d := range sortedDecisions {
-		actionRecord := logger.DecisionAction{
-			Action:    d.Action,
-			Symbol:    d.Symbol,
-			Quantity:  0,
-			Leverage:  d.Leverage,
-			Price:     0,
-			Timestamp: time.Now(),
-			Success:   false,
-		}
+		at.executeCycleDecision(ctx, record, d)
+	}
 
-		if err := at.executeDecisionWithRecord(&d, &actionRecord); err != nil {
-			log.Printf("❌ 执行决策失败 (%s %s): %v", d.Symbol, d.Action, err)
-			actionRecord.Error = err.Error()
-			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s %s 失败: %v", d.Symbol, d.Action, err))
-		} else {
-			actionRecord.Success = true
-			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ %s %s 成功", d.Symbol, d.Action))
-			// 成功执行后短暂延迟
-			time.Sleep(1 * time.Second)
-		}
+	// 更新本周期评估的交易想法
+	at.resolveTradeIdeas(ctx.TradeIdeas, decision.Decisions, record.Decisions, at.callCount)
 
-		record.Decisions = append(record.Decisions, actionRecord)
-	}
+	// 连续亏损/当日亏损超限时生成复盘包
+	at.checkPostMortemTriggers(ctx)
+
+	// 持仓已重新评估，持仓优先刷新从当前价格重新计算逆向波动
+	at.positionWatch.resetAnchors()
 
 	// 9. 保存决策记录
 	if err := at.decisionLogger.LogDecision(record); err != nil {
 		log.Printf("⚠ 保存决策记录失败: %v", err)
+	} else {
+		at.saveDecisionOrders(record)
 	}
 
 	return nil
 }
 
+// executeCycleDecision 执行本周期的单条决策并把结果写入决策记录
+func (at *AutoTrader) executeCycleDecision(ctx *decision.Context, record *logger.DecisionRecord, d decision.Decision) {
+	actionRecord := logger.DecisionAction{
+		Action:    d.Action,
+		Symbol:    d.Symbol,
+		Quantity:  0,
+		Leverage:  d.Leverage,
+		Price:     0,
+		Timestamp: time.Now(),
+		Success:   false,
+	}
+	tagActionRecord(&actionRecord, &d)
+
+	// 开仓前置检查（资金费时机、风险偏好）必须在翻仓平仓之前完成：
+	// 先平掉反向仓位再被拒绝开仓，会让账户变成空仓
+	if reason := at.checkFundingEntryTiming(&d, ctx.MarketDataMap[d.Symbol]); reason != "" {
+		log.Printf("⏳ %s %s 延迟开仓: %s", d.Symbol, d.Action, reason)
+		actionRecord.Status = logger.DecisionStatusSkipped
+		actionRecord.Error = reason
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("⏳ %s %s 延迟: %s", d.Symbol, d.Action, reason))
+		record.Decisions = append(record.Decisions, actionRecord)
+		return
+	}
+
+	// 翻仓会先平掉反向仓位，不占用风险偏好档位的持仓数
+	flipped := 0
+	if hasOppositePosition(ctx.Positions, &d) {
+		flipped = 1
+	}
+	price := 0.0
+	if data := ctx.MarketDataMap[d.Symbol]; data != nil {
+		price = data.CurrentPrice
+	}
+	if reason := checkPersonaEntry(ctx.Persona, &d, price, len(ctx.Positions)+openedThisCycle(record)-flipped); reason != "" {
+		log.Printf("🎚 %s %s 未开仓: %s", d.Symbol, d.Action, reason)
+		at.publishRiskBreach(d.Symbol, d.Action, "persona", fmt.Sprintf("风险偏好拒绝 %s %s: %s", d.Symbol, d.Action, reason), false)
+		at.recordSetback(d.Symbol, entrySide(d.Action), SetbackRejected, price, "风险偏好拒绝")
+		actionRecord.Status = logger.DecisionStatusRejected
+		actionRecord.Error = reason
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("🎚 %s %s 风险偏好拒绝: %s", d.Symbol, d.Action, reason))
+		record.Decisions = append(record.Decisions, actionRecord)
+		return
+	}
+
+	// 🔁 翻仓：开仓方向与现有持仓相反时，开仓检查通过后先平掉反向仓位（同一周期内完成，平仓记录排在开仓记录之前）
+	// 平仓前失败（获取持仓失败、开仓前置检查未通过）时反向仓位保留，按开仓失败记录
+	var entryErr error
+	if d.Action == "open_long" || d.Action == "open_short" {
+		flipRecord, err := at.closeOppositePositionForFlip(&d)
+		if flipRecord == nil {
+			entryErr = err
+		}
+		if flipRecord != nil {
+			if err != nil {
+				record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s %s（翻仓）失败: %v", flipRecord.Symbol, flipRecord.Action, err))
+			} else {
+				record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ %s %s（翻仓）成功", flipRecord.Symbol, flipRecord.Action))
+			}
+			record.Decisions = append(record.Decisions, *flipRecord)
+			at.noteDecisionActivity(flipRecord)
+		}
+		if err != nil && flipRecord != nil {
+			log.Printf("❌ 翻仓平仓失败，跳过开仓 (%s %s): %v", d.Symbol, d.Action, err)
+			skippedRecord := logger.DecisionAction{
+				Action:    d.Action,
+				Symbol:    d.Symbol,
+				Leverage:  d.Leverage,
+				Timestamp: time.Now(),
+				Success:   false,
+				Status:    logger.DecisionStatusSkipped,
+				Error:     fmt.Sprintf("翻仓平仓失败: %v", err),
+			}
+			tagActionRecord(&skippedRecord, &d)
+			record.Decisions = append(record.Decisions, skippedRecord)
+			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s %s 失败: 翻仓平仓失败", d.Symbol, d.Action))
+			return
+		}
+		if flipRecord != nil {
+			actionRecord.Timestamp = time.Now()
+		}
+	}
+
+	err := entryErr
+	if err == nil {
+		var queued *QueuedDecision
+		if d.Action != "hold" && d.Action != "wait" {
+			item := at.enqueueDecision(d, at.callCount, QueueStatusExecuting)
+			queued = &item
+		}
+
+		err = at.executeDecisionWithRetry(&d, &actionRecord)
+		if queued != nil {
+			at.finishQueuedDecision(queued.ID, err)
+		}
+	}
+	if err != nil {
+		log.Printf("❌ 执行决策失败 (%s %s): %v", d.Symbol, d.Action, err)
+		actionRecord.Status = logger.DecisionStatusFailed
+		actionRecord.Error = err.Error()
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s %s 失败: %v", d.Symbol, d.Action, err))
+		if strings.Contains(err.Error(), "总开放风险超限") {
+			at.noteActivity("风控拒绝 %s %s（总开放风险超限）", d.Symbol, d.Action)
+			at.publishRiskBreach(d.Symbol, d.Action, "open_risk", fmt.Sprintf("风控拒绝 %s %s（总开放风险超限）", d.Symbol, d.Action), false)
+			at.recordSetback(d.Symbol, entrySide(d.Action), SetbackRejected, price, "总开放风险超限")
+		} else if d.Action != "hold" && d.Action != "wait" {
+			at.notify(logger.EventError, logger.SeverityWarning, "%s %s 执行失败: %v", d.Symbol, d.Action, err)
+		}
+	} else {
+		actionRecord.Success = true
+		actionRecord.Status = logger.DecisionStatusExecuted
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ %s %s 成功", d.Symbol, d.Action))
+		at.annotateRealizedPnL(ctx, &d, &actionRecord)
+		at.attachTradeChart(ctx, &d, &actionRecord)
+		// 成交类动作由 order.filled 事件通知
+		if d.Action != "hold" && d.Action != "wait" && !isFillAction(d.Action) {
+			at.notify(logger.EventTrade, logger.SeverityInfo, "%s %s 成功", d.Symbol, d.Action)
+		}
+		// 成功执行后短暂延迟
+		time.Sleep(1 * time.Second)
+	}
+
+	record.Decisions = append(record.Decisions, actionRecord)
+	at.noteDecisionActivity(&actionRecord)
+}
+
 // buildTradingContext 构建交易上下文
 func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	// 1. 获取账户信息
@@ -592,11 +961,19 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	// Total Equity = 钱包余额 + 未实现盈亏
 	totalEquity := totalWalletBalance + totalUnrealizedProfit
 
+	// 与上一次快照比较，交易所上报的净值异常跳变时冻结新开仓（按整个账户比较，不受资金分配比例调整影响）
+	at.checkEquityAnomaly(totalEquity)
+
+	// 多个交易员共用账户时按资金分配比例缩放仓位计算基数
+	totalEquity, availableBalance = at.applyCapitalWeight(totalEquity, availableBalance)
+
 	// 2. 获取持仓信息
 	positions, err := at.trader.GetPositions()
 	if err != nil {
 		return nil, fmt.Errorf("获取持仓失败: %w", err)
 	}
+	// 补齐重启后丢失的止损价（开放风险、交易所侧止损识别都依赖已知止损）
+	at.syncStopLosses(positions)
 
 	var positionInfos []decision.PositionInfo
 	totalMarginUsed := 0.0
@@ -669,10 +1046,21 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		}
 	}
 
-	// 3. 获取交易员的候选币种池
-	candidateCoins, err := at.getCandidateCoins()
-	if err != nil {
-		return nil, fmt.Errorf("获取候选币种失败: %w", err)
+	// 记录交易所侧止损/止盈平掉的持仓到币种记忆
+	at.rememberPositions(positionInfos)
+
+	// 3. 获取交易员的候选币种池（警报定向周期只分析警报币种，常规周期优先分析交易想法和仅观察币种）
+	var tradeIdeas []decision.TradeIdeaBrief
+	watchOnly := at.GetWatchOnlySymbols()
+	candidateCoins, alertCycle := at.alertCycleCandidates()
+	if !alertCycle {
+		candidateCoins, err = at.getCandidateCoins(positionInfos)
+		if err != nil {
+			return nil, fmt.Errorf("获取候选币种失败: %w", err)
+		}
+		candidateCoins = prependCandidates(candidateCoins, watchOnly, "watch")
+		tradeIdeas = at.pendingIdeasForCycle()
+		candidateCoins = withIdeaCandidates(candidateCoins, tradeIdeas)
 	}
 
 	// 4. 计算总盈亏
@@ -696,13 +1084,26 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		performance = nil
 	}
 
-	// 6. 构建上下文
+	// 6. 构建上下文（风险偏好档位同时缩放杠杆上限）
+	persona := at.getRiskPersona()
+	btcEthLeverage, altcoinLeverage := at.leverageCaps(persona)
+	timeframes := at.GetTimeframes()
 	ctx := &decision.Context{
 		CurrentTime:     time.Now().Format("2006-01-02 15:04:05"),
-		RuntimeMinutes:  int(time.Since(at.startTime).Minutes()),
-		CallCount:       at.callCount,
-		BTCETHLeverage:  at.config.BTCETHLeverage,  // 使用配置的杠杆倍数
-		AltcoinLeverage: at.config.AltcoinLeverage, // 使用配置的杠杆倍数
+		RecentHistory:   at.buildRecentHistory(totalEquity),
+		BTCETHLeverage:  btcEthLeverage,  // 使用配置的杠杆倍数
+		AltcoinLeverage: altcoinLeverage, // 使用配置的杠杆倍数
+		Persona:         persona,
+		TraderID:        at.id,
+		SchemaVersion:   at.getDecisionSchemaVersion(),
+		Verbosity:       at.getPromptVerbosity(),
+		Liquidity:       at.getLiquidityLimits(),
+		Indicators:      at.GetIndicatorSet(),
+		Timeframes:      timeframes,
+		CandidateMaxAge: at.candidateDataMaxAge(timeframes),
+		ToolBudget:      at.GetToolCallBudget(),
+
+		TimeframeReliability: at.timeframeReliabilityContext(),
 		Account: decision.AccountInfo{
 			TotalEquity:      totalEquity,
 			AvailableBalance: availableBalance,
@@ -715,6 +1116,10 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		Positions:      positionInfos,
 		CandidateCoins: candidateCoins,
 		Performance:    performance, // 添加历史表现分析
+		SymbolMemories: at.buildSymbolMemories(positionInfos, candidateCoins),
+		Trigger:        at.alertCycles.active,
+		TradeIdeas:     tradeIdeas,
+		WatchOnly:      watchOnly,
 	}
 
 	return ctx, nil
@@ -745,41 +1150,171 @@ func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, act
 	}
 }
 
-// executeOpenLongWithRecord 执行开多仓并记录详细信息
-func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
-	log.Printf("  📈 开多仓: %s", decision.Symbol)
+// hasOppositePosition 上下文持仓中是否有与开仓方向相反的同币种仓位（开仓决策会触发翻仓）
+func hasOppositePosition(positions []decision.PositionInfo, d *decision.Decision) bool {
+	oppositeSide := ""
+	switch d.Action {
+	case "open_long":
+		oppositeSide = "short"
+	case "open_short":
+		oppositeSide = "long"
+	default:
+		return false
+	}
+	for _, pos := range positions {
+		if pos.Symbol == d.Symbol && pos.Side == oppositeSide {
+			return true
+		}
+	}
+	return false
+}
+
+// closeOppositePositionForFlip 翻仓前置步骤：如果该币种持有与开仓方向相反的仓位，先对开仓做完整的前置检查，通过后再全部平掉反向仓位
+// 返回平仓动作记录（无反向持仓时返回 nil），调用方负责把它写入同一条决策记录，保证 平仓→开仓 的顺序
+// 返回错误但平仓记录为 nil 时表示尚未平仓（获取持仓失败或开仓前置检查未通过），反向仓位保持不变
+func (at *AutoTrader) closeOppositePositionForFlip(d *decision.Decision) (*logger.DecisionAction, error) {
+	side, oppositeSide := "long", "short"
+	if d.Action == "open_short" {
+		side, oppositeSide = "short", "long"
+	}
+
+	// 交易所维护中不平反向仓位（开仓本身也会被拒绝）
+	if at.inMaintenance() {
+		return nil, at.holdForMaintenance(d)
+	}
 
-	// ⚠️ 关键：检查是否已有同币种同方向持仓，如果有则拒绝开仓（防止仓位叠加超限）
 	positions, err := at.trader.GetPositions()
-	if err == nil {
-		for _, pos := range positions {
-			if pos["symbol"] == decision.Symbol && pos["side"] == "long" {
-				return fmt.Errorf("❌ %s 已有多仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_long 决策", decision.Symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	var oppositeAmt, releasedMargin float64
+	remaining := make([]map[string]interface{}, 0, len(positions))
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		posSide, _ := pos["side"].(string)
+		posAmt, _ := pos["positionAmt"].(float64)
+		if symbol == d.Symbol && posSide == oppositeSide && posAmt != 0 {
+			oppositeAmt = math.Abs(posAmt)
+			releasedMargin = flipReleasedMargin(pos)
+			continue
+		}
+		remaining = append(remaining, pos)
+	}
+	if oppositeAmt == 0 {
+		return nil, nil
+	}
+
+	// 先按平仓后的持仓和可用保证金跑一遍开仓检查（同方向持仓、杠杆、保证金、总开放风险），
+	// 避免平掉反向仓位后开仓被拒，账户变成空仓
+	check := *d
+	if _, err := at.checkEntry(&check, side, remaining, releasedMargin, &logger.DecisionAction{}); err != nil {
+		log.Printf("  🔁 翻仓: %s 开仓前置检查未通过，保留反向仓位(%s): %v", d.Symbol, oppositeSide, err)
+		return nil, err
+	}
+
+	log.Printf("  🔁 翻仓: %s 持有反向仓位(%s) %.4f，先平仓再执行 %s", d.Symbol, oppositeSide, oppositeAmt, d.Action)
+
+	closeRecord := &logger.DecisionAction{
+		Action:    "close_" + oppositeSide,
+		Symbol:    d.Symbol,
+		Quantity:  oppositeAmt,
+		Leverage:  d.Leverage,
+		Timestamp: time.Now(),
+	}
+
+	// 翻仓时旧方向的止盈止损单已无意义，先撤掉，避免误触发到新仓位
+	if err := at.trader.CancelStopOrders(d.Symbol); err != nil {
+		log.Printf("  ⚠ 翻仓撤销旧止盈止损单失败: %v", err)
+	}
+
+	closeDecision := &decision.Decision{Symbol: d.Symbol, Action: closeRecord.Action}
+	if oppositeSide == "long" {
+		err = at.executeCloseLongWithRecord(closeDecision, closeRecord)
+	} else {
+		err = at.executeCloseShortWithRecord(closeDecision, closeRecord)
+	}
+	if err != nil {
+		closeRecord.Status = logger.DecisionStatusFailed
+		closeRecord.Error = err.Error()
+		return closeRecord, err
+	}
+	closeRecord.Success = true
+	closeRecord.Status = logger.DecisionStatusExecuted
+
+	// 清理旧方向的持仓追踪数据
+	delete(at.positionFirstSeenTime, d.Symbol+"_"+oppositeSide)
+	at.ClearPeakPnLCache(d.Symbol, oppositeSide)
+	at.recordStopLoss(d.Symbol, oppositeSide, 0)
+
+	// 等待交易所结算释放保证金，再进入开仓的保证金校验
+	time.Sleep(1 * time.Second)
+	return closeRecord, nil
+}
+
+// flipReleasedMargin 估算平掉持仓后释放回可用余额的资金（保证金 + 未实现盈亏）
+func flipReleasedMargin(pos map[string]interface{}) float64 {
+	posAmt, _ := pos["positionAmt"].(float64)
+	markPrice, _ := pos["markPrice"].(float64)
+	unrealized, _ := pos["unRealizedProfit"].(float64)
+	leverage, _ := pos["leverage"].(float64)
+	if leverage <= 0 {
+		leverage = 1
+	}
+	return math.Max(math.Abs(posAmt)*markPrice/leverage+unrealized, 0)
+}
+
+// entryCheck 开仓前置检查通过后的下单参数
+type entryCheck struct {
+	marketData *market.Data
+	quantity   float64
+	leverage   int
+}
+
+// checkEntry 开仓前置检查（不下单）：同方向持仓、杠杆同步、保证金、总开放风险
+// releasedMargin 为翻仓时即将平掉的反向仓位释放的资金，此时 positions 应已排除该反向仓位
+func (at *AutoTrader) checkEntry(decision *decision.Decision, side string, positions []map[string]interface{}, releasedMargin float64, actionRecord *logger.DecisionAction) (*entryCheck, error) {
+	// ⚠️ 关键：检查是否已有同币种同方向持仓，如果有则拒绝开仓（防止仓位叠加超限）
+	for _, pos := range positions {
+		if pos["symbol"] == decision.Symbol && pos["side"] == side {
+			if side == "long" {
+				return nil, fmt.Errorf("❌ %s 已有多仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_long 决策", decision.Symbol)
 			}
+			return nil, fmt.Errorf("❌ %s 已有空仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_short 决策", decision.Symbol)
 		}
 	}
 
 	// 获取当前价格
 	marketData, err := market.Get(decision.Symbol)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	// 波动率目标仓位（按预测波动率缩小仓位）
+	at.applyVolatilityTarget(decision, marketData)
+
 	// 计算数量
 	quantity := decision.PositionSizeUSD / marketData.CurrentPrice
 	actionRecord.Quantity = quantity
 	actionRecord.Price = marketData.CurrentPrice
 
+	// 同步仓位模式和杠杆到交易所，后续按实际生效杠杆计算保证金
+	leverage, err := at.applyEntryLeverage(decision.Symbol, decision.Leverage)
+	if err != nil {
+		return nil, fmt.Errorf("设置杠杆失败: %w", err)
+	}
+	actionRecord.Leverage = leverage
+
 	// ⚠️ 保证金验证：防止保证金不足错误（code=-2019）
-	requiredMargin := decision.PositionSizeUSD / float64(decision.Leverage)
+	requiredMargin := decision.PositionSizeUSD / float64(leverage)
 
 	balance, err := at.trader.GetBalance()
 	if err != nil {
-		return fmt.Errorf("获取账户余额失败: %w", err)
+		return nil, fmt.Errorf("获取账户余额失败: %w", err)
 	}
-	availableBalance := 0.0
+	availableBalance := releasedMargin
 	if avail, ok := balance["availableBalance"].(float64); ok {
-		availableBalance = avail
+		availableBalance += avail
 	}
 
 	// 手续费估算（Taker费率 0.04%）
@@ -787,37 +1322,116 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 	totalRequired := requiredMargin + estimatedFee
 
 	if totalRequired > availableBalance {
-		return fmt.Errorf("❌ 保证金不足: 需要 %.2f USDT（保证金 %.2f + 手续费 %.2f），可用 %.2f USDT",
+		return nil, fmt.Errorf("❌ 保证金不足: 需要 %.2f USDT（保证金 %.2f + 手续费 %.2f），可用 %.2f USDT",
 			totalRequired, requiredMargin, estimatedFee, availableBalance)
 	}
 
+	// ⚠️ 总开放风险验证：所有持仓同时止损的合计亏损不能超过净值上限
+	if err := at.checkOpenRiskCap(positions, marketData.CurrentPrice, decision.StopLoss, quantity); err != nil {
+		return nil, err
+	}
+
+	return &entryCheck{marketData: marketData, quantity: quantity, leverage: leverage}, nil
+}
+
+// applyEntryLeverage 开仓前把仓位模式和杠杆同步到交易所，返回实际生效的杠杆
+// 杠杆超出交易所分档（bracket）上限时逐级降低杠杆重试，而不是直接放弃开仓
+func (at *AutoTrader) applyEntryLeverage(symbol string, leverage int) (int, error) {
 	// 设置仓位模式
-	if err := at.trader.SetMarginMode(decision.Symbol, at.config.IsCrossMargin); err != nil {
+	if err := at.trader.SetMarginMode(symbol, at.config.IsCrossMargin); err != nil {
 		log.Printf("  ⚠️ 设置仓位模式失败: %v", err)
 		// 继续执行，不影响交易
 	}
 
+	if leverage <= 0 {
+		leverage = 1
+	}
+
+	effective := leverage
+	for {
+		err := at.trader.SetLeverage(symbol, effective)
+		if err == nil || isLeverageNotModifiedError(err) {
+			break
+		}
+		if !isLeverageBracketError(err) || effective <= 1 {
+			return 0, err
+		}
+
+		next := effective / 2
+		if next < 1 {
+			next = 1
+		}
+		log.Printf("  ⚠️ %s 杠杆 %dx 超出交易所限制，降为 %dx 重试: %v", symbol, effective, next, err)
+		effective = next
+	}
+
+	if effective != leverage {
+		log.Printf("  ⚠️ %s 实际生效杠杆 %dx（AI建议 %dx）", symbol, effective, leverage)
+	}
+	return effective, nil
+}
+
+// isLeverageNotModifiedError 杠杆已是目标值（交易所返回"无需修改"）
+func isLeverageNotModifiedError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "No need to change") || strings.Contains(msg, "not modified")
+}
+
+// isLeverageBracketError SetLeverage 返回的杠杆超出该币种分档上限错误
+// Binance/Aster: -4028 Leverage is not valid
+// -2027（Exceeded the maximum allowable position at current leverage）只在下单时返回，不属于设置杠杆的错误
+func isLeverageBracketError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "-4028") ||
+		strings.Contains(msg, "leverage is not valid") ||
+		strings.Contains(msg, "invalid leverage") ||
+		strings.Contains(msg, "max leverage")
+}
+
+// executeOpenLongWithRecord 执行开多仓并记录详细信息
+func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
+	log.Printf("  📈 开多仓: %s", decision.Symbol)
+
+	// 获取持仓失败时跳过同方向检查，由开放风险检查按已知持仓估算
+	positions, _ := at.trader.GetPositions()
+	entry, err := at.checkEntry(decision, "long", positions, 0, actionRecord)
+	if err != nil {
+		return err
+	}
+	leverage := entry.leverage
+
 	// 开仓
-	order, err := at.trader.OpenLong(decision.Symbol, quantity, decision.Leverage)
+	// 按下单策略选择Maker挂单或市价（Maker部分成交时以实际成交数量设置止损止盈）
+	at.captureDepthSnapshot(actionRecord)
+	order, quantity, err := at.placeEntryOrder(decision, "long", entry.quantity, leverage, entry.marketData.CurrentPrice)
 	if err != nil {
 		return err
 	}
+	actionRecord.Quantity = quantity
+	at.recordExecution(actionRecord, order, "buy")
 
 	// 记录订单ID
 	if orderID, ok := order["orderId"].(int64); ok {
 		actionRecord.OrderID = orderID
 	}
 
-	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f, 杠杆: %dx", order["orderId"], quantity, leverage)
 
 	// 记录开仓时间
 	posKey := decision.Symbol + "_long"
 	at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+	at.recordTimeframeEntry(decision.Symbol, "long")
 
 	// 设置止损止盈
-	if err := at.trader.SetStopLoss(decision.Symbol, "LONG", quantity, decision.StopLoss); err != nil {
-		log.Printf("  ⚠ 设置止损失败: %v", err)
+	stopErr := at.trader.SetStopLoss(decision.Symbol, "LONG", quantity, decision.StopLoss)
+	if stopErr != nil {
+		log.Printf("  ⚠ 设置止损失败: %v", stopErr)
+		at.recordStopLoss(decision.Symbol, "long", 0)
+	} else {
+		at.recordStopLoss(decision.Symbol, "long", decision.StopLoss)
 	}
+	// 后台核验止损单是否存在且价格正确，失败时补挂/告警
+	at.startStopWatchdog(decision.Symbol, "long", quantity, decision.StopLoss, stopErr == nil)
 	if err := at.trader.SetTakeProfit(decision.Symbol, "LONG", quantity, decision.TakeProfit); err != nil {
 		log.Printf("  ⚠ 设置止盈失败: %v", err)
 	}
@@ -829,75 +1443,46 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
 	log.Printf("  📉 开空仓: %s", decision.Symbol)
 
-	// ⚠️ 关键：检查是否已有同币种同方向持仓，如果有则拒绝开仓（防止仓位叠加超限）
-	positions, err := at.trader.GetPositions()
-	if err == nil {
-		for _, pos := range positions {
-			if pos["symbol"] == decision.Symbol && pos["side"] == "short" {
-				return fmt.Errorf("❌ %s 已有空仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_short 决策", decision.Symbol)
-			}
-		}
-	}
-
-	// 获取当前价格
-	marketData, err := market.Get(decision.Symbol)
+	// 获取持仓失败时跳过同方向检查，由开放风险检查按已知持仓估算
+	positions, _ := at.trader.GetPositions()
+	entry, err := at.checkEntry(decision, "short", positions, 0, actionRecord)
 	if err != nil {
 		return err
 	}
-
-	// 计算数量
-	quantity := decision.PositionSizeUSD / marketData.CurrentPrice
-	actionRecord.Quantity = quantity
-	actionRecord.Price = marketData.CurrentPrice
-
-	// ⚠️ 保证金验证：防止保证金不足错误（code=-2019）
-	requiredMargin := decision.PositionSizeUSD / float64(decision.Leverage)
-
-	balance, err := at.trader.GetBalance()
-	if err != nil {
-		return fmt.Errorf("获取账户余额失败: %w", err)
-	}
-	availableBalance := 0.0
-	if avail, ok := balance["availableBalance"].(float64); ok {
-		availableBalance = avail
-	}
-
-	// 手续费估算（Taker费率 0.04%）
-	estimatedFee := decision.PositionSizeUSD * 0.0004
-	totalRequired := requiredMargin + estimatedFee
-
-	if totalRequired > availableBalance {
-		return fmt.Errorf("❌ 保证金不足: 需要 %.2f USDT（保证金 %.2f + 手续费 %.2f），可用 %.2f USDT",
-			totalRequired, requiredMargin, estimatedFee, availableBalance)
-	}
-
-	// 设置仓位模式
-	if err := at.trader.SetMarginMode(decision.Symbol, at.config.IsCrossMargin); err != nil {
-		log.Printf("  ⚠️ 设置仓位模式失败: %v", err)
-		// 继续执行，不影响交易
-	}
+	leverage := entry.leverage
 
 	// 开仓
-	order, err := at.trader.OpenShort(decision.Symbol, quantity, decision.Leverage)
+	// 按下单策略选择Maker挂单或市价（Maker部分成交时以实际成交数量设置止损止盈）
+	at.captureDepthSnapshot(actionRecord)
+	order, quantity, err := at.placeEntryOrder(decision, "short", entry.quantity, leverage, entry.marketData.CurrentPrice)
 	if err != nil {
 		return err
 	}
+	actionRecord.Quantity = quantity
+	at.recordExecution(actionRecord, order, "sell")
 
 	// 记录订单ID
 	if orderID, ok := order["orderId"].(int64); ok {
 		actionRecord.OrderID = orderID
 	}
 
-	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f, 杠杆: %dx", order["orderId"], quantity, leverage)
 
 	// 记录开仓时间
 	posKey := decision.Symbol + "_short"
 	at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+	at.recordTimeframeEntry(decision.Symbol, "short")
 
 	// 设置止损止盈
-	if err := at.trader.SetStopLoss(decision.Symbol, "SHORT", quantity, decision.StopLoss); err != nil {
-		log.Printf("  ⚠ 设置止损失败: %v", err)
+	stopErr := at.trader.SetStopLoss(decision.Symbol, "SHORT", quantity, decision.StopLoss)
+	if stopErr != nil {
+		log.Printf("  ⚠ 设置止损失败: %v", stopErr)
+		at.recordStopLoss(decision.Symbol, "short", 0)
+	} else {
+		at.recordStopLoss(decision.Symbol, "short", decision.StopLoss)
 	}
+	// 后台核验止损单是否存在且价格正确，失败时补挂/告警
+	at.startStopWatchdog(decision.Symbol, "short", quantity, decision.StopLoss, stopErr == nil)
 	if err := at.trader.SetTakeProfit(decision.Symbol, "SHORT", quantity, decision.TakeProfit); err != nil {
 		log.Printf("  ⚠ 设置止盈失败: %v", err)
 	}
@@ -916,11 +1501,22 @@ func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, ac
 	}
 	actionRecord.Price = marketData.CurrentPrice
 
+	// 校验实时持仓方向，数量以实时持仓为准
+	liveQuantity, err := at.verifyCloseSide(decision.Symbol, "long")
+	if err != nil {
+		return err
+	}
+	actionRecord.Quantity = liveQuantity
+
 	// 平仓
+	at.captureDepthSnapshot(actionRecord)
 	order, err := at.trader.CloseLong(decision.Symbol, 0) // 0 = 全部平仓
 	if err != nil {
 		return err
 	}
+	at.recordExecution(actionRecord, order, "sell")
+	at.recordStopLoss(decision.Symbol, "long", 0)
+	at.rememberClose(decision.Symbol, "long", marketData.CurrentPrice, "ai_close")
 
 	// 记录订单ID
 	if orderID, ok := order["orderId"].(int64); ok {
@@ -942,11 +1538,22 @@ func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, a
 	}
 	actionRecord.Price = marketData.CurrentPrice
 
+	// 校验实时持仓方向，数量以实时持仓为准
+	liveQuantity, err := at.verifyCloseSide(decision.Symbol, "short")
+	if err != nil {
+		return err
+	}
+	actionRecord.Quantity = liveQuantity
+
 	// 平仓
+	at.captureDepthSnapshot(actionRecord)
 	order, err := at.trader.CloseShort(decision.Symbol, 0) // 0 = 全部平仓
 	if err != nil {
 		return err
 	}
+	at.recordExecution(actionRecord, order, "buy")
+	at.recordStopLoss(decision.Symbol, "short", 0)
+	at.rememberClose(decision.Symbol, "short", marketData.CurrentPrice, "ai_close")
 
 	// 记录订单ID
 	if orderID, ok := order["orderId"].(int64); ok {
@@ -1036,6 +1643,7 @@ func (at *AutoTrader) executeUpdateStopLossWithRecord(decision *decision.Decisio
 	if err != nil {
 		return fmt.Errorf("修改止损失败: %w", err)
 	}
+	at.recordStopLoss(decision.Symbol, side, decision.NewStopLoss)
 
 	log.Printf("  ✓ 止损已调整: %.2f (当前价格: %.2f)", decision.NewStopLoss, marketData.CurrentPrice)
 	return nil
@@ -1127,12 +1735,7 @@ func (at *AutoTrader) executeUpdateTakeProfitWithRecord(decision *decision.Decis
 
 // executePartialCloseWithRecord 执行部分平仓并记录详细信息
 func (at *AutoTrader) executePartialCloseWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
-	log.Printf("  📊 部分平仓: %s %.1f%%", decision.Symbol, decision.ClosePercentage)
-
-	// 验证百分比范围
-	if decision.ClosePercentage <= 0 || decision.ClosePercentage > 100 {
-		return fmt.Errorf("平仓百分比必须在 0-100 之间，当前: %.1f", decision.ClosePercentage)
-	}
+	log.Printf("  📊 部分平仓: %s %s", decision.Symbol, decision.ReduceDescription())
 
 	// 获取当前价格
 	marketData, err := market.Get(decision.Symbol)
@@ -1147,42 +1750,68 @@ func (at *AutoTrader) executePartialCloseWithRecord(decision *decision.Decision,
 		return fmt.Errorf("获取持仓失败: %w", err)
 	}
 
-	// 查找目标持仓
+	// 查找目标持仓（方向和数量以实时持仓为准）
 	var targetPosition map[string]interface{}
+	matched := 0
 	for _, pos := range positions {
 		symbol, _ := pos["symbol"].(string)
 		posAmt, _ := pos["positionAmt"].(float64)
 		if symbol == decision.Symbol && posAmt != 0 {
-			targetPosition = pos
-			break
+			if targetPosition == nil {
+				targetPosition = pos
+			}
+			matched++
 		}
 	}
 
 	if targetPosition == nil {
 		return fmt.Errorf("持仓不存在: %s", decision.Symbol)
 	}
+	if matched > 1 {
+		return fmt.Errorf("❌ %s 同时持有多仓和空仓，部分平仓方向不明确，请使用 close_long / close_short", decision.Symbol)
+	}
 
 	// 获取持仓方向和数量
 	side, _ := targetPosition["side"].(string)
 	positionSide := strings.ToUpper(side)
 	positionAmt, _ := targetPosition["positionAmt"].(float64)
 
-	// 计算平仓数量
+	// 按实时持仓数量计算平仓数量（百分比 / 名义价值 / 合约数量）
 	totalQuantity := math.Abs(positionAmt)
-	closeQuantity := totalQuantity * (decision.ClosePercentage / 100.0)
+	closeQuantity, err := decision.ReduceQuantity(totalQuantity, marketData.CurrentPrice)
+	if err != nil {
+		return err
+	}
+
+	// 剩余仓位低于最小名义价值会变成无法单独平掉的粉尘，直接转为全部平仓
+	if closeQuantity < totalQuantity && at.isDustQuantity(decision.Symbol, totalQuantity-closeQuantity, marketData.CurrentPrice) {
+		log.Printf("  ⚠️ 部分平仓后剩余 %.4f (价值 %.2f USDT) 低于最小名义价值 %.2f USDT，转为全部平仓",
+			totalQuantity-closeQuantity, (totalQuantity-closeQuantity)*marketData.CurrentPrice, at.getMinNotional(decision.Symbol))
+		closeQuantity = totalQuantity
+	}
 	actionRecord.Quantity = closeQuantity
 
-	// 执行平仓
+	// 执行平仓（全部平仓时传 0，由交易所按实际持仓数量平掉，避免精度残留）
+	orderQuantity := closeQuantity
+	if closeQuantity >= totalQuantity {
+		orderQuantity = 0
+	}
+	at.captureDepthSnapshot(actionRecord)
 	var order map[string]interface{}
 	if positionSide == "LONG" {
-		order, err = at.trader.CloseLong(decision.Symbol, closeQuantity)
+		order, err = at.trader.CloseLong(decision.Symbol, orderQuantity)
 	} else {
-		order, err = at.trader.CloseShort(decision.Symbol, closeQuantity)
+		order, err = at.trader.CloseShort(decision.Symbol, orderQuantity)
 	}
 
 	if err != nil {
 		return fmt.Errorf("部分平仓失败: %w", err)
 	}
+	if positionSide == "LONG" {
+		at.recordExecution(actionRecord, order, "sell")
+	} else {
+		at.recordExecution(actionRecord, order, "buy")
+	}
 
 	// 记录订单ID
 	if orderID, ok := order["orderId"].(int64); ok {
@@ -1191,7 +1820,7 @@ func (at *AutoTrader) executePartialCloseWithRecord(decision *decision.Decision,
 
 	remainingQuantity := totalQuantity - closeQuantity
 	log.Printf("  ✓ 部分平仓成功: 平仓 %.4f (%.1f%%), 剩余 %.4f",
-		closeQuantity, decision.ClosePercentage, remainingQuantity)
+		closeQuantity, closeQuantity/totalQuantity*100, remainingQuantity)
 
 	return nil
 }
@@ -1201,6 +1830,11 @@ func (at *AutoTrader) GetID() string {
 	return at.id
 }
 
+// IsRunning 是否正在运行
+func (at *AutoTrader) IsRunning() bool {
+	return at.isRunning
+}
+
 // GetName 获取trader名称
 func (at *AutoTrader) GetName() string {
 	return at.name
@@ -1236,33 +1870,44 @@ func (at *AutoTrader) GetSystemPromptTemplate() string {
 	return at.systemPromptTemplate
 }
 
-// GetDecisionLogger 获取决策日志记录器
-func (at *AutoTrader) GetDecisionLogger() *logger.DecisionLogger {
-	return at.decisionLogger
+// getPromptVerbosity 获取市场数据输出详细程度（交易员配置 > 系统配置 prompt_verbosity > standard）
+func (at *AutoTrader) getPromptVerbosity() string {
+	if at.config.PromptVerbosity != "" {
+		return market.NormalizeVerbosity(at.config.PromptVerbosity)
+	}
+
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		if verbosity, err := db.GetSystemConfig("prompt_verbosity"); err == nil && verbosity != "" {
+			return market.NormalizeVerbosity(verbosity)
+		}
+	}
+	return market.VerbosityStandard
 }
 
-// GetStatus 获取系统状态（用于API）
-func (at *AutoTrader) GetStatus() map[string]interface{} {
-	aiProvider := "DeepSeek"
-	if at.config.UseQwen {
-		aiProvider = "Qwen"
+// getDecisionSchemaVersion 获取要求AI使用的决策输出版本（交易员配置 > 系统配置 > 当前版本）
+func (at *AutoTrader) getDecisionSchemaVersion() string {
+	if at.config.DecisionSchemaVersion != "" {
+		return at.config.DecisionSchemaVersion
 	}
 
-	return map[string]interface{}{
-		"trader_id":       at.id,
-		"trader_name":     at.name,
-		"ai_model":        at.aiModel,
-		"exchange":        at.exchange,
-		"is_running":      at.isRunning,
-		"start_time":      at.startTime.Format(time.RFC3339),
-		"runtime_minutes": int(time.Since(at.startTime).Minutes()),
-		"call_count":      at.callCount,
-		"initial_balance": at.initialBalance,
-		"scan_interval":   at.config.ScanInterval.String(),
-		"stop_until":      at.stopUntil.Format(time.RFC3339),
-		"last_reset_time": at.lastResetTime.Format(time.RFC3339),
-		"ai_provider":     aiProvider,
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		if value, err := db.GetSystemConfig("decision_schema_version"); err == nil && value != "" {
+			return value
+		}
 	}
+
+	return decision.CurrentSchemaVersion
+}
+
+// GetDecisionLogger 获取决策日志记录器
+func (at *AutoTrader) GetDecisionLogger() *logger.DecisionLogger {
+	return at.decisionLogger
 }
 
 // GetAccountInfo 获取账户信息（用于API）
@@ -1326,6 +1971,8 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 		marginUsedPct = (totalMarginUsed / totalEquity) * 100
 	}
 
+	openRisk := at.calculateOpenRisk(positions, totalEquity)
+
 	return map[string]interface{}{
 		// 核心字段
 		"total_equity":      totalEquity,           // 账户净值 = wallet + unrealized
@@ -1344,6 +1991,11 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 		"position_count":  len(positions),  // 持仓数量
 		"margin_used":     totalMarginUsed, // 保证金占用
 		"margin_used_pct": marginUsedPct,   // 保证金使用率
+
+		// 开放风险（所有持仓同时止损的合计亏损）
+		"open_risk_usd":     openRisk.TotalRiskUSD, // 开放风险金额
+		"open_risk_pct":     openRisk.TotalRiskPct, // 开放风险占净值百分比
+		"max_open_risk_pct": openRisk.MaxRiskPct,   // 开放风险上限
 	}, nil
 }
 
@@ -1439,7 +2091,7 @@ func sortDecisionsByPriority(decisions []decision.Decision) []decision.Decision
 }
 
 // getCandidateCoins 获取交易员的候选币种列表
-func (at *AutoTrader) getCandidateCoins() ([]decision.CandidateCoin, error) {
+func (at *AutoTrader) getCandidateCoins(positions []decision.PositionInfo) ([]decision.CandidateCoin, error) {
 	if len(at.tradingCoins) == 0 {
 		// 使用数据库配置的默认币种列表
 		var candidateCoins []decision.CandidateCoin
@@ -1455,27 +2107,46 @@ func (at *AutoTrader) getCandidateCoins() ([]decision.CandidateCoin, error) {
 			}
 			log.Printf("📋 [%s] 使用数据库默认币种: %d个币种 %v",
 				at.name, len(candidateCoins), at.defaultCoins)
+			at.setLastCandidates(candidateCoins)
 			return candidateCoins, nil
 		} else {
 			// 如果数据库中没有配置默认币种，则使用AI500+OI Top作为fallback
 			const ai500Limit = 20 // AI500取前20个评分最高的币种
 
-			mergedPool, err := pool.GetMergedCoinPool(ai500Limit)
+			// 候选池按定时/行情异动刷新，其余周期复用快照
+			candidatePool, err := pool.GetCandidatePool(ai500Limit)
 			if err != nil {
 				return nil, fmt.Errorf("获取合并币种池失败: %w", err)
 			}
 
 			// 构建候选币种列表（包含来源信息）
-			for _, symbol := range mergedPool.AllSymbols {
-				sources := mergedPool.SymbolSources[symbol]
+			inPool := make(map[string]bool, len(candidatePool.Symbols))
+			for _, symbol := range candidatePool.Symbols {
+				inPool[symbol] = true
 				candidateCoins = append(candidateCoins, decision.CandidateCoin{
 					Symbol:  symbol,
-					Sources: sources, // "ai500" 和/或 "oi_top"
+					Sources: candidatePool.SymbolSources[symbol], // "ai500" 和/或 "oi_top"
 				})
 			}
 
-			log.Printf("📋 [%s] 数据库无默认币种配置，使用AI500+OI Top: AI500前%d + OI_Top20 = 总计%d个候选币种",
-				at.name, ai500Limit, len(candidateCoins))
+			// 保留持仓币种：刷新后即使已移出候选池，仍需要AI继续管理
+			for _, pos := range positions {
+				if !inPool[pos.Symbol] {
+					inPool[pos.Symbol] = true
+					candidateCoins = append(candidateCoins, decision.CandidateCoin{
+						Symbol:  pos.Symbol,
+						Sources: []string{"position"},
+					})
+				}
+			}
+
+			log.Printf("📋 [%s] 数据库无默认币种配置，使用AI500+OI Top: AI500前%d + OI_Top20 = 总计%d个候选币种（候选池刷新于 %s，原因: %s）",
+				at.name, ai500Limit, len(candidateCoins),
+				candidatePool.RefreshedAt.Format("15:04:05"), candidatePool.RefreshReason)
+			if candidatePool.Degraded {
+				log.Printf("⚠️  [%s] 候选池数据源长时间不可用，当前候选币种来自本地评分/默认币种", at.name)
+			}
+			at.setLastCandidates(candidateCoins)
 			return candidateCoins, nil
 		}
 	} else {
@@ -1492,10 +2163,30 @@ func (at *AutoTrader) getCandidateCoins() ([]decision.CandidateCoin, error) {
 
 		log.Printf("📋 [%s] 使用自定义币种: %d个币种 %v",
 			at.name, len(candidateCoins), at.tradingCoins)
+		at.setLastCandidates(candidateCoins)
 		return candidateCoins, nil
 	}
 }
 
+// setLastCandidates 记录最近一次使用的候选币种（用于API展示）
+func (at *AutoTrader) setLastCandidates(candidates []decision.CandidateCoin) {
+	at.lastCandidatesMutex.Lock()
+	defer at.lastCandidatesMutex.Unlock()
+
+	at.lastCandidates = candidates
+	at.lastCandidatesAt = time.Now()
+}
+
+// GetCandidates 获取最近一次决策使用的候选币种及其来源
+func (at *AutoTrader) GetCandidates() ([]decision.CandidateCoin, time.Time) {
+	at.lastCandidatesMutex.RLock()
+	defer at.lastCandidatesMutex.RUnlock()
+
+	candidates := make([]decision.CandidateCoin, len(at.lastCandidates))
+	copy(candidates, at.lastCandidates)
+	return candidates, at.lastCandidatesAt
+}
+
 // normalizeSymbol 标准化币种符号（确保以USDT结尾）
 func normalizeSymbol(symbol string) string {
 	// 转为大写
@@ -1597,6 +2288,8 @@ func (at *AutoTrader) checkPositionDrawdown() {
 				log.Printf("❌ 回撤平仓失败 (%s %s): %v", symbol, side, err)
 			} else {
 				log.Printf("✅ 回撤平仓成功: %s %s", symbol, side)
+				at.noteActivity("🚨 回撤保护平仓 %s %s（最高收益%.1f%%，回撤%.0f%%）", symbol, side, peakPnLPct, drawdownPct)
+				at.publishRiskBreach(symbol, "close_"+side, "drawdown", fmt.Sprintf("回撤保护平仓 %s %s（最高收益%.1f%%，回撤%.0f%%）", symbol, side, peakPnLPct, drawdownPct), true)
 				// 平仓后清理该持仓的缓存
 				at.ClearPeakPnLCache(symbol, side)
 			}
@@ -1608,8 +2301,11 @@ func (at *AutoTrader) checkPositionDrawdown() {
 	}
 }
 
-// 紧急平仓函数
+// 紧急平仓函数（回撤保护、粉尘清理共用；交易所维护中不下单，由下一次检查在维护结束后重新触发）
 func (at *AutoTrader) emergencyClosePosition(symbol, side string) error {
+	if at.inMaintenance() {
+		return fmt.Errorf("交易所维护中，暂停下单")
+	}
 	switch side {
 	case "long":
 		order, err := at.trader.CloseLong(symbol, 0) // 0 = 全部平仓
@@ -1626,6 +2322,8 @@ func (at *AutoTrader) emergencyClosePosition(symbol, side string) error {
 	default:
 		return fmt.Errorf("未知的持仓方向: %s", side)
 	}
+	at.recordStopLoss(symbol, side, 0)
+	at.rememberClose(symbol, side, 0, "auto_close")
 
 	return nil
 }
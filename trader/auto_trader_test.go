@@ -0,0 +1,222 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTrader 记录调用的交易器（未覆盖的方法调用时 panic，提示测试路径超出预期）
+type fakeTrader struct {
+	Trader
+	positions []map[string]interface{}
+	closed    []string
+}
+
+func (f *fakeTrader) GetPositions() ([]map[string]interface{}, error) { return f.positions, nil }
+func (f *fakeTrader) CancelStopOrders(symbol string) error            { return nil }
+func (f *fakeTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	f.closed = append(f.closed, "close_long")
+	return map[string]interface{}{}, nil
+}
+func (f *fakeTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	f.closed = append(f.closed, "close_short")
+	return map[string]interface{}{}, nil
+}
+
+func TestExecuteCycleDecisionGatesBeforeFlip(t *testing.T) {
+	shortPosition := []map[string]interface{}{{"symbol": "BTCUSDT", "side": "short", "positionAmt": -0.5}}
+	openLong := decision.Decision{Symbol: "BTCUSDT", Action: "open_long", Leverage: 5, PositionSizeUSD: 100, Confidence: 60}
+	persona := &decision.RiskPersona{Label: "保守", MinConfidence: 80}
+
+	tests := []struct {
+		name       string
+		config     AutoTraderConfig
+		ctx        *decision.Context
+		wantStatus string
+	}{
+		{
+			name:   "风险偏好拒绝时不平掉反向仓位",
+			config: AutoTraderConfig{},
+			ctx: &decision.Context{
+				Positions: []decision.PositionInfo{{Symbol: "BTCUSDT", Side: "short"}},
+				Persona:   persona,
+			},
+			wantStatus: logger.DecisionStatusRejected,
+		},
+		{
+			name:   "资金费时机延迟时不平掉反向仓位",
+			config: AutoTraderConfig{FundingEntryBlockMinutes: 30},
+			ctx: &decision.Context{
+				Positions: []decision.PositionInfo{{Symbol: "BTCUSDT", Side: "short"}},
+				MarketDataMap: map[string]*market.Data{
+					"BTCUSDT": {Symbol: "BTCUSDT", FundingRate: 0.001, NextFundingTime: time.Now().Add(10 * time.Minute)},
+				},
+			},
+			wantStatus: logger.DecisionStatusSkipped,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeTrader{positions: shortPosition}
+			at := &AutoTrader{trader: fake, config: tt.config}
+			record := &logger.DecisionRecord{}
+
+			at.executeCycleDecision(tt.ctx, record, openLong)
+
+			if len(fake.closed) != 0 {
+				t.Errorf("closed = %v, want opposite position untouched", fake.closed)
+			}
+			if len(record.Decisions) != 1 || record.Decisions[0].Action != "open_long" || record.Decisions[0].Status != tt.wantStatus {
+				t.Errorf("decisions = %+v, want single open_long %s", record.Decisions, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// flipTrader 翻仓场景的交易器：可配置可用余额、杠杆设置错误和持仓查询错误
+type flipTrader struct {
+	fakeTrader
+	available    float64
+	leverageErr  error
+	positionsErr error
+}
+
+func (f *flipTrader) GetPositions() ([]map[string]interface{}, error) {
+	if f.positionsErr != nil {
+		return nil, f.positionsErr
+	}
+	return f.positions, nil
+}
+func (f *flipTrader) SetMarginMode(symbol string, isCrossMargin bool) error { return nil }
+func (f *flipTrader) SetLeverage(symbol string, leverage int) error         { return f.leverageErr }
+func (f *flipTrader) GetBalance() (map[string]interface{}, error) {
+	return map[string]interface{}{"availableBalance": f.available, "totalWalletBalance": 1000.0, "totalUnrealizedProfit": 0.0}, nil
+}
+
+// flipPriceProvider 固定价格的行情来源
+type flipPriceProvider struct{}
+
+func (flipPriceProvider) Get(symbol string) (*market.Data, error) {
+	return &market.Data{Symbol: symbol, CurrentPrice: 100}, nil
+}
+
+func TestCloseOppositePositionForFlipChecksEntryFirst(t *testing.T) {
+	market.SetProvider(flipPriceProvider{})
+	defer market.SetProvider(nil)
+
+	shortPosition := []map[string]interface{}{{"symbol": "BTCUSDT", "side": "short", "positionAmt": -1.0, "markPrice": 100.0, "leverage": 10.0}}
+	hedged := append([]map[string]interface{}{{"symbol": "BTCUSDT", "side": "long", "positionAmt": 1.0, "markPrice": 100.0, "leverage": 10.0}}, shortPosition...)
+	openLong := decision.Decision{Symbol: "BTCUSDT", Action: "open_long", Leverage: 5, PositionSizeUSD: 1000, StopLoss: 95}
+
+	tests := []struct {
+		name    string
+		trader  *flipTrader
+		wantErr string
+	}{
+		{"获取持仓失败返回错误", &flipTrader{fakeTrader: fakeTrader{positions: shortPosition}, positionsErr: errors.New("timeout")}, "获取持仓失败"},
+		{"已有同方向持仓", &flipTrader{fakeTrader: fakeTrader{positions: hedged}, available: 1000}, "已有多仓"},
+		{"设置杠杆失败", &flipTrader{fakeTrader: fakeTrader{positions: shortPosition}, available: 1000, leverageErr: errors.New("connection reset by peer")}, "设置杠杆失败"},
+		// 需要 200+0.4 USDT，可用 100 + 反向仓位释放 10
+		{"保证金不足（计入反向仓位释放的保证金）", &flipTrader{fakeTrader: fakeTrader{positions: shortPosition}, available: 100}, "保证金不足"},
+		{"总开放风险超限", &flipTrader{fakeTrader: fakeTrader{positions: shortPosition}, available: 1000}, "总开放风险超限"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := &AutoTrader{trader: tt.trader, config: AutoTraderConfig{MaxOpenRiskPct: 1}, stopLossPrices: make(map[string]float64)}
+			d := openLong
+
+			flipRecord, err := at.closeOppositePositionForFlip(&d)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			if flipRecord != nil || len(tt.trader.closed) != 0 {
+				t.Errorf("开仓检查未通过时不应平掉反向仓位: record=%+v closed=%v", flipRecord, tt.trader.closed)
+			}
+			if d.PositionSizeUSD != openLong.PositionSizeUSD {
+				t.Errorf("前置检查不应修改原决策: size=%v", d.PositionSizeUSD)
+			}
+		})
+	}
+}
+
+func TestHasOppositePosition(t *testing.T) {
+	positions := []decision.PositionInfo{{Symbol: "BTCUSDT", Side: "short"}, {Symbol: "ETHUSDT", Side: "long"}}
+	tests := []struct {
+		name string
+		d    decision.Decision
+		want bool
+	}{
+		{"开多遇空仓翻仓", decision.Decision{Symbol: "BTCUSDT", Action: "open_long"}, true},
+		{"开空遇多仓翻仓", decision.Decision{Symbol: "ETHUSDT", Action: "open_short"}, true},
+		{"同向不翻仓", decision.Decision{Symbol: "BTCUSDT", Action: "open_short"}, false},
+		{"其他币种", decision.Decision{Symbol: "SOLUSDT", Action: "open_long"}, false},
+		{"非开仓动作", decision.Decision{Symbol: "BTCUSDT", Action: "close_short"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasOppositePosition(positions, &tt.d); got != tt.want {
+				t.Errorf("hasOppositePosition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// leverageTrader 按分档上限拒绝杠杆的交易器
+type leverageTrader struct {
+	Trader
+	maxLeverage int
+	err         error // 非空时 SetLeverage 直接返回该错误
+	attempts    []int
+}
+
+func (l *leverageTrader) SetMarginMode(symbol string, isCrossMargin bool) error { return nil }
+func (l *leverageTrader) SetLeverage(symbol string, leverage int) error {
+	l.attempts = append(l.attempts, leverage)
+	if l.err != nil {
+		return l.err
+	}
+	if leverage > l.maxLeverage {
+		return fmt.Errorf("设置杠杆失败: <APIError> code=-4028, msg=Leverage %d is not valid", leverage)
+	}
+	return nil
+}
+
+func TestApplyEntryLeverage(t *testing.T) {
+	tests := []struct {
+		name         string
+		trader       *leverageTrader
+		leverage     int
+		want         int
+		wantAttempts []int
+		wantErr      bool
+	}{
+		{"未超上限直接生效", &leverageTrader{maxLeverage: 20}, 10, 10, []int{10}, false},
+		{"超出分档上限逐级减半", &leverageTrader{maxLeverage: 5}, 20, 5, []int{20, 10, 5}, false},
+		{"减到1倍仍失败返回错误", &leverageTrader{maxLeverage: 0}, 2, 0, []int{2, 1}, true},
+		{"非正杠杆按1倍设置", &leverageTrader{maxLeverage: 20}, 0, 1, []int{1}, false},
+		{"无需修改视为成功", &leverageTrader{err: errors.New("No need to change leverage")}, 10, 10, []int{10}, false},
+		{"-2027 是下单错误不降杠杆", &leverageTrader{err: errors.New("<APIError> code=-2027, msg=Exceeded the maximum allowable position at current leverage.")}, 10, 0, []int{10}, true},
+		{"其他错误直接返回", &leverageTrader{err: errors.New("connection reset by peer")}, 10, 0, []int{10}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := &AutoTrader{trader: tt.trader}
+			got, err := at.applyEntryLeverage("BTCUSDT", tt.leverage)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("leverage = %d, want %d", got, tt.want)
+			}
+			if fmt.Sprint(tt.trader.attempts) != fmt.Sprint(tt.wantAttempts) {
+				t.Errorf("attempts = %v, want %v", tt.trader.attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
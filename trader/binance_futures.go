@@ -7,12 +7,16 @@ import (
 	"fmt"
 	"log"
 	"nofx/hook"
+	"nofx/logger"
+	"nofx/loglevel"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
 )
 
 // getBrOrderID 生成唯一订单ID（合约专用）
@@ -45,7 +49,8 @@ func getBrOrderID() string {
 
 // FuturesTrader 币安合约交易器
 type FuturesTrader struct {
-	client *futures.Client
+	// 币安客户端（签名请求会读取 TimeOffset，同步服务器时间时替换为新副本而不是原地修改，避免与并发请求竞争）
+	client atomic.Pointer[futures.Client]
 
 	// 余额缓存
 	cachedBalance     map[string]interface{}
@@ -57,6 +62,15 @@ type FuturesTrader struct {
 	positionsCacheTime  time.Time
 	positionsCacheMutex sync.RWMutex
 
+	// 杠杆缓存（symbol -> 已成功设置的杠杆，避免重复切换触发5秒冷却）
+	leverageCache      map[string]int
+	leverageCacheMutex sync.RWMutex
+
+	// 最小名义价值缓存（symbol -> MIN_NOTIONAL，来自交易规则，按 minNotionalCacheTTL 整体刷新）
+	minNotionals      map[string]float64
+	minNotionalsTime  time.Time
+	minNotionalsMutex sync.Mutex
+
 	// 缓存有效期（15秒）
 	cacheDuration time.Duration
 }
@@ -70,12 +84,18 @@ func NewFuturesTrader(apiKey, secretKey string, userId string) *FuturesTrader {
 		client = hookRes.GetResult()
 	}
 
-	// 同步时间，避免 Timestamp ahead 错误
-	syncBinanceServerTime(client)
+	// 同步时间，避免 Timestamp ahead 错误（客户端尚未共享，可以直接写入偏移）
+	if offset, err := binanceServerTimeOffset(client); err != nil {
+		log.Printf("⚠️ 同步币安服务器时间失败: %v", err)
+	} else {
+		client.TimeOffset = offset
+		log.Printf("⏱ 已同步币安服务器时间，偏移 %dms", offset)
+	}
 	trader := &FuturesTrader{
-		client:        client,
+		leverageCache: make(map[string]int),
 		cacheDuration: 15 * time.Second, // 15秒缓存
 	}
+	trader.client.Store(client)
 
 	// 设置双向持仓模式（Hedge Mode）
 	// 这是必需的，因为代码中使用了 PositionSide (LONG/SHORT)
@@ -89,7 +109,7 @@ func NewFuturesTrader(apiKey, secretKey string, userId string) *FuturesTrader {
 // setDualSidePosition 设置双向持仓模式（初始化时调用）
 func (t *FuturesTrader) setDualSidePosition() error {
 	// 尝试设置双向持仓模式
-	err := t.client.NewChangePositionModeService().
+	err := t.api().NewChangePositionModeService().
 		DualSide(true). // true = 双向持仓（Hedge Mode）
 		Do(context.Background())
 
@@ -108,18 +128,37 @@ func (t *FuturesTrader) setDualSidePosition() error {
 	return nil
 }
 
-// syncBinanceServerTime 同步币安服务器时间，确保请求时间戳合法
-func syncBinanceServerTime(client *futures.Client) {
+// api 当前使用的币安客户端
+func (t *FuturesTrader) api() *futures.Client {
+	return t.client.Load()
+}
+
+// binanceServerTimeOffset 查询币安服务器时间，返回 本地 - 服务器 的毫秒偏移
+func binanceServerTimeOffset(client *futures.Client) (int64, error) {
+	before := time.Now().UnixMilli()
 	serverTime, err := client.NewServerTimeService().Do(context.Background())
 	if err != nil {
-		log.Printf("⚠️ 同步币安服务器时间失败: %v", err)
-		return
+		return 0, err
 	}
 
-	now := time.Now().UnixMilli()
-	offset := now - serverTime
-	client.TimeOffset = offset
-	log.Printf("⏱ 已同步币安服务器时间，偏移 %dms", offset)
+	// 以请求往返的中点作为服务器时间对应的本地时间，减小网络延迟带来的误差
+	now := (before + time.Now().UnixMilli()) / 2
+	return now - serverTime, nil
+}
+
+// SyncServerTime 重新同步服务器时间（定期调用，时钟漂移后校正签名时间戳）
+// 持仓监控、止损看门狗等协程会同时用客户端签名请求，因此复制一份写入新偏移后原子替换
+func (t *FuturesTrader) SyncServerTime() (int64, error) {
+	current := t.api()
+	offset, err := binanceServerTimeOffset(current)
+	if err != nil {
+		return 0, err
+	}
+	clone := *current
+	clone.TimeOffset = offset
+	t.client.Store(&clone)
+	loglevel.Debugf(loglevel.Executor, "⏱ 已同步币安服务器时间，偏移 %dms", offset)
+	return offset, nil
 }
 
 // GetBalance 获取账户余额（带缓存）
@@ -129,14 +168,14 @@ func (t *FuturesTrader) GetBalance() (map[string]interface{}, error) {
 	if t.cachedBalance != nil && time.Since(t.balanceCacheTime) < t.cacheDuration {
 		cacheAge := time.Since(t.balanceCacheTime)
 		t.balanceCacheMutex.RUnlock()
-		log.Printf("✓ 使用缓存的账户余额（缓存时间: %.1f秒前）", cacheAge.Seconds())
+		loglevel.Debugf(loglevel.Executor, "✓ 使用缓存的账户余额（缓存时间: %.1f秒前）", cacheAge.Seconds())
 		return t.cachedBalance, nil
 	}
 	t.balanceCacheMutex.RUnlock()
 
 	// 缓存过期或不存在，调用API
-	log.Printf("🔄 缓存过期，正在调用币安API获取账户余额...")
-	account, err := t.client.NewGetAccountService().Do(context.Background())
+	loglevel.Debugf(loglevel.Executor, "🔄 缓存过期，正在调用币安API获取账户余额...")
+	account, err := t.api().NewGetAccountService().Do(context.Background())
 	if err != nil {
 		log.Printf("❌ 币安API调用失败: %v", err)
 		return nil, fmt.Errorf("获取账户信息失败: %w", err)
@@ -147,7 +186,7 @@ func (t *FuturesTrader) GetBalance() (map[string]interface{}, error) {
 	result["availableBalance"], _ = strconv.ParseFloat(account.AvailableBalance, 64)
 	result["totalUnrealizedProfit"], _ = strconv.ParseFloat(account.TotalUnrealizedProfit, 64)
 
-	log.Printf("✓ 币安API返回: 总余额=%s, 可用=%s, 未实现盈亏=%s",
+	loglevel.Debugf(loglevel.Executor, "✓ 币安API返回: 总余额=%s, 可用=%s, 未实现盈亏=%s",
 		account.TotalWalletBalance,
 		account.AvailableBalance,
 		account.TotalUnrealizedProfit)
@@ -168,14 +207,14 @@ func (t *FuturesTrader) GetPositions() ([]map[string]interface{}, error) {
 	if t.cachedPositions != nil && time.Since(t.positionsCacheTime) < t.cacheDuration {
 		cacheAge := time.Since(t.positionsCacheTime)
 		t.positionsCacheMutex.RUnlock()
-		log.Printf("✓ 使用缓存的持仓信息（缓存时间: %.1f秒前）", cacheAge.Seconds())
+		loglevel.Debugf(loglevel.Executor, "✓ 使用缓存的持仓信息（缓存时间: %.1f秒前）", cacheAge.Seconds())
 		return t.cachedPositions, nil
 	}
 	t.positionsCacheMutex.RUnlock()
 
 	// 缓存过期或不存在，调用API
-	log.Printf("🔄 缓存过期，正在调用币安API获取持仓信息...")
-	positions, err := t.client.NewGetPositionRiskService().Do(context.Background())
+	loglevel.Debugf(loglevel.Executor, "🔄 缓存过期，正在调用币安API获取持仓信息...")
+	positions, err := t.api().NewGetPositionRiskService().Do(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf("获取持仓失败: %w", err)
 	}
@@ -225,7 +264,7 @@ func (t *FuturesTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
 	}
 
 	// 尝试设置仓位模式
-	err := t.client.NewChangeMarginTypeService().
+	err := t.api().NewChangeMarginTypeService().
 		Symbol(symbol).
 		MarginType(marginType).
 		Do(context.Background())
@@ -238,7 +277,7 @@ func (t *FuturesTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
 	if err != nil {
 		// 如果错误信息包含"No need to change"，说明仓位模式已经是目标值
 		if contains(err.Error(), "No need to change margin type") {
-			log.Printf("  ✓ %s 仓位模式已是 %s", symbol, marginModeStr)
+			loglevel.Debugf(loglevel.Executor, "  ✓ %s 仓位模式已是 %s", symbol, marginModeStr)
 			return nil
 		}
 		// 如果有持仓，无法更改仓位模式，但不影响交易
@@ -262,12 +301,21 @@ func (t *FuturesTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
 		return nil
 	}
 
-	log.Printf("  ✓ %s 仓位模式已设置为 %s", symbol, marginModeStr)
+	loglevel.Debugf(loglevel.Executor, "  ✓ %s 仓位模式已设置为 %s", symbol, marginModeStr)
 	return nil
 }
 
 // SetLeverage 设置杠杆（智能判断+冷却期）
 func (t *FuturesTrader) SetLeverage(symbol string, leverage int) error {
+	// 本进程内已成功设置过相同杠杆，直接跳过
+	t.leverageCacheMutex.RLock()
+	cachedLeverage := t.leverageCache[symbol]
+	t.leverageCacheMutex.RUnlock()
+	if cachedLeverage == leverage {
+		loglevel.Debugf(loglevel.Executor, "  ✓ %s 杠杆已是 %dx（缓存）", symbol, leverage)
+		return nil
+	}
+
 	// 先尝试获取当前杠杆（从持仓信息）
 	currentLeverage := 0
 	positions, err := t.GetPositions()
@@ -284,12 +332,13 @@ func (t *FuturesTrader) SetLeverage(symbol string, leverage int) error {
 
 	// 如果当前杠杆已经是目标杠杆，跳过
 	if currentLeverage == leverage && currentLeverage > 0 {
-		log.Printf("  ✓ %s 杠杆已是 %dx，无需切换", symbol, leverage)
+		loglevel.Debugf(loglevel.Executor, "  ✓ %s 杠杆已是 %dx，无需切换", symbol, leverage)
+		t.setCachedLeverage(symbol, leverage)
 		return nil
 	}
 
 	// 切换杠杆
-	_, err = t.client.NewChangeLeverageService().
+	_, err = t.api().NewChangeLeverageService().
 		Symbol(symbol).
 		Leverage(leverage).
 		Do(context.Background())
@@ -297,13 +346,16 @@ func (t *FuturesTrader) SetLeverage(symbol string, leverage int) error {
 	if err != nil {
 		// 如果错误信息包含"No need to change"，说明杠杆已经是目标值
 		if contains(err.Error(), "No need to change") {
-			log.Printf("  ✓ %s 杠杆已是 %dx", symbol, leverage)
+			loglevel.Debugf(loglevel.Executor, "  ✓ %s 杠杆已是 %dx", symbol, leverage)
+			t.setCachedLeverage(symbol, leverage)
 			return nil
 		}
+		t.invalidateCachedLeverage(symbol)
 		return fmt.Errorf("设置杠杆失败: %w", err)
 	}
 
-	log.Printf("  ✓ %s 杠杆已切换为 %dx", symbol, leverage)
+	loglevel.Infof(loglevel.Executor, "  ✓ %s 杠杆已切换为 %dx", symbol, leverage)
+	t.setCachedLeverage(symbol, leverage)
 
 	// 切换杠杆后等待5秒（避免冷却期错误）
 	log.Printf("  ⏱ 等待5秒冷却期...")
@@ -312,11 +364,26 @@ func (t *FuturesTrader) SetLeverage(symbol string, leverage int) error {
 	return nil
 }
 
+// setCachedLeverage 记录已生效的杠杆
+func (t *FuturesTrader) setCachedLeverage(symbol string, leverage int) {
+	t.leverageCacheMutex.Lock()
+	t.leverageCache[symbol] = leverage
+	t.leverageCacheMutex.Unlock()
+}
+
+// invalidateCachedLeverage 清除该币种的杠杆缓存（设置杠杆或下单失败时调用，
+// 杠杆可能已在机器人之外被修改，下次按交易所实际状态重新设置）
+func (t *FuturesTrader) invalidateCachedLeverage(symbol string) {
+	t.leverageCacheMutex.Lock()
+	delete(t.leverageCache, symbol)
+	t.leverageCacheMutex.Unlock()
+}
+
 // OpenLong 开多仓
 func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 先取消该币种的所有委托单（清理旧的止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
+		loglevel.Debugf(loglevel.Executor, "  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
 	}
 
 	// 设置杠杆
@@ -344,16 +411,18 @@ func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int)
 	}
 
 	// 创建市价买入订单（使用br ID）
-	order, err := t.client.NewCreateOrderService().
+	order, err := t.api().NewCreateOrderService().
 		Symbol(symbol).
 		Side(futures.SideTypeBuy).
 		PositionSide(futures.PositionSideTypeLong).
 		Type(futures.OrderTypeMarket).
 		Quantity(quantityStr).
 		NewClientOrderID(getBrOrderID()).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT). // 返回成交均价，用于滑点统计
 		Do(context.Background())
 
 	if err != nil {
+		t.invalidateCachedLeverage(symbol)
 		return nil, fmt.Errorf("开多仓失败: %w", err)
 	}
 
@@ -362,8 +431,11 @@ func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int)
 
 	result := make(map[string]interface{})
 	result["orderId"] = order.OrderID
+	result["clientOrderId"] = order.ClientOrderID
 	result["symbol"] = order.Symbol
 	result["status"] = order.Status
+	result["avgPrice"] = order.AvgPrice
+	result["executedQty"] = order.ExecutedQuantity
 	return result, nil
 }
 
@@ -371,7 +443,7 @@ func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int)
 func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 先取消该币种的所有委托单（清理旧的止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
+		loglevel.Debugf(loglevel.Executor, "  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
 	}
 
 	// 设置杠杆
@@ -399,16 +471,18 @@ func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int)
 	}
 
 	// 创建市价卖出订单（使用br ID）
-	order, err := t.client.NewCreateOrderService().
+	order, err := t.api().NewCreateOrderService().
 		Symbol(symbol).
 		Side(futures.SideTypeSell).
 		PositionSide(futures.PositionSideTypeShort).
 		Type(futures.OrderTypeMarket).
 		Quantity(quantityStr).
 		NewClientOrderID(getBrOrderID()).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT). // 返回成交均价，用于滑点统计
 		Do(context.Background())
 
 	if err != nil {
+		t.invalidateCachedLeverage(symbol)
 		return nil, fmt.Errorf("开空仓失败: %w", err)
 	}
 
@@ -417,8 +491,11 @@ func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int)
 
 	result := make(map[string]interface{})
 	result["orderId"] = order.OrderID
+	result["clientOrderId"] = order.ClientOrderID
 	result["symbol"] = order.Symbol
 	result["status"] = order.Status
+	result["avgPrice"] = order.AvgPrice
+	result["executedQty"] = order.ExecutedQuantity
 	return result, nil
 }
 
@@ -450,16 +527,18 @@ func (t *FuturesTrader) CloseLong(symbol string, quantity float64) (map[string]i
 	}
 
 	// 创建市价卖出订单（平多，使用br ID）
-	order, err := t.client.NewCreateOrderService().
+	order, err := t.api().NewCreateOrderService().
 		Symbol(symbol).
 		Side(futures.SideTypeSell).
 		PositionSide(futures.PositionSideTypeLong).
 		Type(futures.OrderTypeMarket).
 		Quantity(quantityStr).
 		NewClientOrderID(getBrOrderID()).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT). // 返回成交均价，用于滑点统计
 		Do(context.Background())
 
 	if err != nil {
+		t.invalidateCachedLeverage(symbol)
 		return nil, fmt.Errorf("平多仓失败: %w", err)
 	}
 
@@ -467,13 +546,16 @@ func (t *FuturesTrader) CloseLong(symbol string, quantity float64) (map[string]i
 
 	// 平仓后取消该币种的所有挂单（止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+		loglevel.Warnf(loglevel.Executor, "  ⚠ 取消挂单失败: %v", err)
 	}
 
 	result := make(map[string]interface{})
 	result["orderId"] = order.OrderID
+	result["clientOrderId"] = order.ClientOrderID
 	result["symbol"] = order.Symbol
 	result["status"] = order.Status
+	result["avgPrice"] = order.AvgPrice
+	result["executedQty"] = order.ExecutedQuantity
 	return result, nil
 }
 
@@ -505,16 +587,18 @@ func (t *FuturesTrader) CloseShort(symbol string, quantity float64) (map[string]
 	}
 
 	// 创建市价买入订单（平空，使用br ID）
-	order, err := t.client.NewCreateOrderService().
+	order, err := t.api().NewCreateOrderService().
 		Symbol(symbol).
 		Side(futures.SideTypeBuy).
 		PositionSide(futures.PositionSideTypeShort).
 		Type(futures.OrderTypeMarket).
 		Quantity(quantityStr).
 		NewClientOrderID(getBrOrderID()).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT). // 返回成交均价，用于滑点统计
 		Do(context.Background())
 
 	if err != nil {
+		t.invalidateCachedLeverage(symbol)
 		return nil, fmt.Errorf("平空仓失败: %w", err)
 	}
 
@@ -522,20 +606,81 @@ func (t *FuturesTrader) CloseShort(symbol string, quantity float64) (map[string]
 
 	// 平仓后取消该币种的所有挂单（止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+		loglevel.Warnf(loglevel.Executor, "  ⚠ 取消挂单失败: %v", err)
 	}
 
 	result := make(map[string]interface{})
 	result["orderId"] = order.OrderID
+	result["clientOrderId"] = order.ClientOrderID
 	result["symbol"] = order.Symbol
 	result["status"] = order.Status
+	result["avgPrice"] = order.AvgPrice
+	result["executedQty"] = order.ExecutedQuantity
 	return result, nil
 }
 
+// GetStopOrders 获取该币种当前挂着的止损单（用于开仓后核验止损是否存在且价格正确）
+func (t *FuturesTrader) GetStopOrders(symbol string) ([]StopOrderInfo, error) {
+	orders, err := t.api().NewListOpenOrdersService().
+		Symbol(symbol).
+		Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("获取未完成订单失败: %w", err)
+	}
+
+	var stops []StopOrderInfo
+	for _, order := range orders {
+		if order.Type != futures.OrderTypeStopMarket && order.Type != futures.OrderTypeStop {
+			continue
+		}
+		stopPrice, _ := strconv.ParseFloat(order.StopPrice, 64)
+		stops = append(stops, StopOrderInfo{
+			OrderID:      order.OrderID,
+			PositionSide: string(order.PositionSide),
+			StopPrice:    stopPrice,
+		})
+	}
+	return stops, nil
+}
+
+// GetIncomeSummary 汇总时间区间内的已实现盈亏、手续费（正数）和资金费（正=收入），按页拉取资金流水
+func (t *FuturesTrader) GetIncomeSummary(start, end time.Time) (realizedPnL, fees, funding decimal.Decimal, err error) {
+	const pageLimit = 1000
+	from := start.UnixMilli()
+	for {
+		incomes, err := t.api().NewGetIncomeHistoryService().
+			StartTime(from).
+			EndTime(end.UnixMilli()).
+			Limit(pageLimit).
+			Do(context.Background())
+		if err != nil {
+			return decimal.Zero, decimal.Zero, decimal.Zero, fmt.Errorf("获取资金流水失败: %w", err)
+		}
+		for _, income := range incomes {
+			amount, err := logger.ParseMoney(income.Income)
+			if err != nil {
+				continue
+			}
+			switch income.IncomeType {
+			case "REALIZED_PNL":
+				realizedPnL = realizedPnL.Add(amount)
+			case "COMMISSION":
+				fees = fees.Sub(amount) // 手续费流水为负数
+			case "FUNDING_FEE":
+				funding = funding.Add(amount)
+			}
+		}
+		if len(incomes) < pageLimit {
+			return realizedPnL, fees, funding, nil
+		}
+		from = incomes[len(incomes)-1].Time + 1
+	}
+}
+
 // CancelStopLossOrders 仅取消止损单（不影响止盈单）
 func (t *FuturesTrader) CancelStopLossOrders(symbol string) error {
 	// 获取该币种的所有未完成订单
-	orders, err := t.client.NewListOpenOrdersService().
+	orders, err := t.api().NewListOpenOrdersService().
 		Symbol(symbol).
 		Do(context.Background())
 
@@ -551,7 +696,7 @@ func (t *FuturesTrader) CancelStopLossOrders(symbol string) error {
 
 		// 只取消止损订单（不取消止盈订单）
 		if orderType == futures.OrderTypeStopMarket || orderType == futures.OrderTypeStop {
-			_, err := t.client.NewCancelOrderService().
+			_, err := t.api().NewCancelOrderService().
 				Symbol(symbol).
 				OrderID(order.OrderID).
 				Do(context.Background())
@@ -559,19 +704,19 @@ func (t *FuturesTrader) CancelStopLossOrders(symbol string) error {
 			if err != nil {
 				errMsg := fmt.Sprintf("订单ID %d: %v", order.OrderID, err)
 				cancelErrors = append(cancelErrors, fmt.Errorf("%s", errMsg))
-				log.Printf("  ⚠ 取消止损单失败: %s", errMsg)
+				loglevel.Warnf(loglevel.Executor, "  ⚠ 取消止损单失败: %s", errMsg)
 				continue
 			}
 
 			canceledCount++
-			log.Printf("  ✓ 已取消止损单 (订单ID: %d, 类型: %s, 方向: %s)", order.OrderID, orderType, order.PositionSide)
+			loglevel.Debugf(loglevel.Executor, "  ✓ 已取消止损单 (订单ID: %d, 类型: %s, 方向: %s)", order.OrderID, orderType, order.PositionSide)
 		}
 	}
 
 	if canceledCount == 0 && len(cancelErrors) == 0 {
-		log.Printf("  ℹ %s 没有止损单需要取消", symbol)
+		loglevel.Debugf(loglevel.Executor, "  ℹ %s 没有止损单需要取消", symbol)
 	} else if canceledCount > 0 {
-		log.Printf("  ✓ 已取消 %s 的 %d 个止损单", symbol, canceledCount)
+		loglevel.Infof(loglevel.Executor, "  ✓ 已取消 %s 的 %d 个止损单", symbol, canceledCount)
 	}
 
 	// 如果所有取消都失败了，返回错误
@@ -585,7 +730,7 @@ func (t *FuturesTrader) CancelStopLossOrders(symbol string) error {
 // CancelTakeProfitOrders 仅取消止盈单（不影响止损单）
 func (t *FuturesTrader) CancelTakeProfitOrders(symbol string) error {
 	// 获取该币种的所有未完成订单
-	orders, err := t.client.NewListOpenOrdersService().
+	orders, err := t.api().NewListOpenOrdersService().
 		Symbol(symbol).
 		Do(context.Background())
 
@@ -601,7 +746,7 @@ func (t *FuturesTrader) CancelTakeProfitOrders(symbol string) error {
 
 		// 只取消止盈订单（不取消止损订单）
 		if orderType == futures.OrderTypeTakeProfitMarket || orderType == futures.OrderTypeTakeProfit {
-			_, err := t.client.NewCancelOrderService().
+			_, err := t.api().NewCancelOrderService().
 				Symbol(symbol).
 				OrderID(order.OrderID).
 				Do(context.Background())
@@ -609,19 +754,19 @@ func (t *FuturesTrader) CancelTakeProfitOrders(symbol string) error {
 			if err != nil {
 				errMsg := fmt.Sprintf("订单ID %d: %v", order.OrderID, err)
 				cancelErrors = append(cancelErrors, fmt.Errorf("%s", errMsg))
-				log.Printf("  ⚠ 取消止盈单失败: %s", errMsg)
+				loglevel.Warnf(loglevel.Executor, "  ⚠ 取消止盈单失败: %s", errMsg)
 				continue
 			}
 
 			canceledCount++
-			log.Printf("  ✓ 已取消止盈单 (订单ID: %d, 类型: %s, 方向: %s)", order.OrderID, orderType, order.PositionSide)
+			loglevel.Debugf(loglevel.Executor, "  ✓ 已取消止盈单 (订单ID: %d, 类型: %s, 方向: %s)", order.OrderID, orderType, order.PositionSide)
 		}
 	}
 
 	if canceledCount == 0 && len(cancelErrors) == 0 {
-		log.Printf("  ℹ %s 没有止盈单需要取消", symbol)
+		loglevel.Debugf(loglevel.Executor, "  ℹ %s 没有止盈单需要取消", symbol)
 	} else if canceledCount > 0 {
-		log.Printf("  ✓ 已取消 %s 的 %d 个止盈单", symbol, canceledCount)
+		loglevel.Infof(loglevel.Executor, "  ✓ 已取消 %s 的 %d 个止盈单", symbol, canceledCount)
 	}
 
 	// 如果所有取消都失败了，返回错误
@@ -634,7 +779,7 @@ func (t *FuturesTrader) CancelTakeProfitOrders(symbol string) error {
 
 // CancelAllOrders 取消该币种的所有挂单
 func (t *FuturesTrader) CancelAllOrders(symbol string) error {
-	err := t.client.NewCancelAllOpenOrdersService().
+	err := t.api().NewCancelAllOpenOrdersService().
 		Symbol(symbol).
 		Do(context.Background())
 
@@ -642,14 +787,14 @@ func (t *FuturesTrader) CancelAllOrders(symbol string) error {
 		return fmt.Errorf("取消挂单失败: %w", err)
 	}
 
-	log.Printf("  ✓ 已取消 %s 的所有挂单", symbol)
+	loglevel.Debugf(loglevel.Executor, "  ✓ 已取消 %s 的所有挂单", symbol)
 	return nil
 }
 
 // CancelStopOrders 取消该币种的止盈/止损单（用于调整止盈止损位置）
 func (t *FuturesTrader) CancelStopOrders(symbol string) error {
 	// 获取该币种的所有未完成订单
-	orders, err := t.client.NewListOpenOrdersService().
+	orders, err := t.api().NewListOpenOrdersService().
 		Symbol(symbol).
 		Do(context.Background())
 
@@ -668,26 +813,26 @@ func (t *FuturesTrader) CancelStopOrders(symbol string) error {
 			orderType == futures.OrderTypeStop ||
 			orderType == futures.OrderTypeTakeProfit {
 
-			_, err := t.client.NewCancelOrderService().
+			_, err := t.api().NewCancelOrderService().
 				Symbol(symbol).
 				OrderID(order.OrderID).
 				Do(context.Background())
 
 			if err != nil {
-				log.Printf("  ⚠ 取消订单 %d 失败: %v", order.OrderID, err)
+				loglevel.Warnf(loglevel.Executor, "  ⚠ 取消订单 %d 失败: %v", order.OrderID, err)
 				continue
 			}
 
 			canceledCount++
-			log.Printf("  ✓ 已取消 %s 的止盈/止损单 (订单ID: %d, 类型: %s)",
+			loglevel.Debugf(loglevel.Executor, "  ✓ 已取消 %s 的止盈/止损单 (订单ID: %d, 类型: %s)",
 				symbol, order.OrderID, orderType)
 		}
 	}
 
 	if canceledCount == 0 {
-		log.Printf("  ℹ %s 没有止盈/止损单需要取消", symbol)
+		loglevel.Debugf(loglevel.Executor, "  ℹ %s 没有止盈/止损单需要取消", symbol)
 	} else {
-		log.Printf("  ✓ 已取消 %s 的 %d 个止盈/止损单", symbol, canceledCount)
+		loglevel.Infof(loglevel.Executor, "  ✓ 已取消 %s 的 %d 个止盈/止损单", symbol, canceledCount)
 	}
 
 	return nil
@@ -695,7 +840,7 @@ func (t *FuturesTrader) CancelStopOrders(symbol string) error {
 
 // GetMarketPrice 获取市场价格
 func (t *FuturesTrader) GetMarketPrice(symbol string) (float64, error) {
-	prices, err := t.client.NewListPricesService().Symbol(symbol).Do(context.Background())
+	prices, err := t.api().NewListPricesService().Symbol(symbol).Do(context.Background())
 	if err != nil {
 		return 0, fmt.Errorf("获取价格失败: %w", err)
 	}
@@ -739,7 +884,7 @@ func (t *FuturesTrader) SetStopLoss(symbol string, positionSide string, quantity
 		return err
 	}
 
-	_, err = t.client.NewCreateOrderService().
+	_, err = t.api().NewCreateOrderService().
 		Symbol(symbol).
 		Side(side).
 		PositionSide(posSide).
@@ -751,6 +896,7 @@ func (t *FuturesTrader) SetStopLoss(symbol string, positionSide string, quantity
 		Do(context.Background())
 
 	if err != nil {
+		t.invalidateCachedLeverage(symbol)
 		return fmt.Errorf("设置止损失败: %w", err)
 	}
 
@@ -777,7 +923,7 @@ func (t *FuturesTrader) SetTakeProfit(symbol string, positionSide string, quanti
 		return err
 	}
 
-	_, err = t.client.NewCreateOrderService().
+	_, err = t.api().NewCreateOrderService().
 		Symbol(symbol).
 		Side(side).
 		PositionSide(posSide).
@@ -789,6 +935,7 @@ func (t *FuturesTrader) SetTakeProfit(symbol string, positionSide string, quanti
 		Do(context.Background())
 
 	if err != nil {
+		t.invalidateCachedLeverage(symbol)
 		return fmt.Errorf("设置止盈失败: %w", err)
 	}
 
@@ -796,10 +943,32 @@ func (t *FuturesTrader) SetTakeProfit(symbol string, positionSide string, quanti
 	return nil
 }
 
-// GetMinNotional 获取最小名义价值（Binance要求）
+// minNotionalCacheTTL 交易规则中最小名义价值的缓存时长
+const minNotionalCacheTTL = time.Hour
+
+// GetMinNotional 获取币种最小名义价值（交易规则 MIN_NOTIONAL 过滤器），查询失败或币种没有该过滤器时返回0
 func (t *FuturesTrader) GetMinNotional(symbol string) float64 {
-	// 使用保守的默认值 10 USDT，确保订单能够通过交易所验证
-	return 10.0
+	t.minNotionalsMutex.Lock()
+	defer t.minNotionalsMutex.Unlock()
+
+	if t.minNotionals == nil || time.Since(t.minNotionalsTime) > minNotionalCacheTTL {
+		exchangeInfo, err := t.api().NewExchangeInfoService().Do(context.Background())
+		if err != nil {
+			loglevel.Warnf(loglevel.Executor, "  ⚠ 获取交易规则失败: %v", err)
+			return t.minNotionals[symbol] // 沿用上次结果（从未成功时为0）
+		}
+		minNotionals := make(map[string]float64, len(exchangeInfo.Symbols))
+		for i := range exchangeInfo.Symbols {
+			if filter := exchangeInfo.Symbols[i].MinNotionalFilter(); filter != nil {
+				if value, err := strconv.ParseFloat(filter.Notional, 64); err == nil && value > 0 {
+					minNotionals[exchangeInfo.Symbols[i].Symbol] = value
+				}
+			}
+		}
+		t.minNotionals = minNotionals
+		t.minNotionalsTime = time.Now()
+	}
+	return t.minNotionals[symbol]
 }
 
 // CheckMinNotional 检查订单是否满足最小名义价值要求
@@ -811,6 +980,9 @@ func (t *FuturesTrader) CheckMinNotional(symbol string, quantity float64) error
 
 	notionalValue := quantity * price
 	minNotional := t.GetMinNotional(symbol)
+	if minNotional <= 0 {
+		minNotional = 10.0 // 交易规则不可用时使用保守的默认值，确保订单能够通过交易所验证
+	}
 
 	if notionalValue < minNotional {
 		return fmt.Errorf(
@@ -824,7 +996,7 @@ func (t *FuturesTrader) CheckMinNotional(symbol string, quantity float64) error
 
 // GetSymbolPrecision 获取交易对的数量精度
 func (t *FuturesTrader) GetSymbolPrecision(symbol string) (int, error) {
-	exchangeInfo, err := t.client.NewExchangeInfoService().Do(context.Background())
+	exchangeInfo, err := t.api().NewExchangeInfoService().Do(context.Background())
 	if err != nil {
 		return 0, fmt.Errorf("获取交易规则失败: %w", err)
 	}
@@ -836,7 +1008,7 @@ func (t *FuturesTrader) GetSymbolPrecision(symbol string) (int, error) {
 				if filter["filterType"] == "LOT_SIZE" {
 					stepSize := filter["stepSize"].(string)
 					precision := calculatePrecision(stepSize)
-					log.Printf("  %s 数量精度: %d (stepSize: %s)", symbol, precision, stepSize)
+					loglevel.Debugf(loglevel.Executor, "  %s 数量精度: %d (stepSize: %s)", symbol, precision, stepSize)
 					return precision, nil
 				}
 			}
@@ -0,0 +1,128 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// getTickSize 获取交易对的价格步长（PRICE_FILTER tickSize）
+func (t *FuturesTrader) getTickSize(symbol string) (float64, error) {
+	exchangeInfo, err := t.api().NewExchangeInfoService().Do(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("获取交易规则失败: %w", err)
+	}
+
+	for _, s := range exchangeInfo.Symbols {
+		if s.Symbol != symbol {
+			continue
+		}
+		for _, filter := range s.Filters {
+			if filter["filterType"] == "PRICE_FILTER" {
+				tickSize, _ := strconv.ParseFloat(filter["tickSize"].(string), 64)
+				if tickSize > 0 {
+					return tickSize, nil
+				}
+			}
+		}
+	}
+	return 0, fmt.Errorf("%s 未找到价格精度信息", symbol)
+}
+
+// formatMakerPrice 按价格步长格式化挂单价格（买单向下取整、卖单向上取整，保证不越过盘口）
+func (t *FuturesTrader) formatMakerPrice(symbol string, price float64, isBuy bool) (string, error) {
+	tickSize, err := t.getTickSize(symbol)
+	if err != nil {
+		return "", err
+	}
+	ticks := price / tickSize
+	if isBuy {
+		ticks = math.Floor(ticks + 1e-9)
+	} else {
+		ticks = math.Ceil(ticks - 1e-9)
+	}
+	precision := calculatePrecision(strconv.FormatFloat(tickSize, 'f', -1, 64))
+	return strconv.FormatFloat(ticks*tickSize, 'f', precision, 64), nil
+}
+
+// PlacePostOnlyOrder 下只做Maker的限价开仓单（GTX，会吃单时交易所直接拒绝）
+// positionSide: LONG=买入开多, SHORT=卖出开空
+func (t *FuturesTrader) PlacePostOnlyOrder(symbol, positionSide string, quantity, price float64) (int64, error) {
+	// 与市价开仓保持一致：先清理旧的止损止盈单
+	if err := t.CancelAllOrders(symbol); err != nil {
+		log.Printf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
+	}
+
+	side := futures.SideTypeBuy
+	posSide := futures.PositionSideTypeLong
+	if positionSide == "SHORT" {
+		side = futures.SideTypeSell
+		posSide = futures.PositionSideTypeShort
+	}
+
+	quantityStr, err := t.FormatQuantity(symbol, quantity)
+	if err != nil {
+		return 0, err
+	}
+	quantityFloat, parseErr := strconv.ParseFloat(quantityStr, 64)
+	if parseErr != nil || quantityFloat <= 0 {
+		return 0, fmt.Errorf("挂单数量过小，格式化后为 0 (原始: %.8f → 格式化: %s)", quantity, quantityStr)
+	}
+	if err := t.CheckMinNotional(symbol, quantityFloat); err != nil {
+		return 0, err
+	}
+
+	priceStr, err := t.formatMakerPrice(symbol, price, side == futures.SideTypeBuy)
+	if err != nil {
+		return 0, err
+	}
+
+	order, err := t.api().NewCreateOrderService().
+		Symbol(symbol).
+		Side(side).
+		PositionSide(posSide).
+		Type(futures.OrderTypeLimit).
+		TimeInForce(futures.TimeInForceTypeGTX).
+		Price(priceStr).
+		Quantity(quantityStr).
+		NewClientOrderID(getBrOrderID()).
+		Do(context.Background())
+	if err != nil {
+		t.invalidateCachedLeverage(symbol)
+		return 0, fmt.Errorf("Maker挂单失败: %w", err)
+	}
+
+	log.Printf("  📌 Maker挂单: %s %s 数量: %s 价格: %s 订单ID: %d", symbol, positionSide, quantityStr, priceStr, order.OrderID)
+	return order.OrderID, nil
+}
+
+// GetOrderFill 查询订单状态、已成交数量和成交均价
+func (t *FuturesTrader) GetOrderFill(symbol string, orderID int64) (string, float64, float64, error) {
+	order, err := t.api().NewGetOrderService().
+		Symbol(symbol).
+		OrderID(orderID).
+		Do(context.Background())
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("查询订单失败: %w", err)
+	}
+
+	executedQty, _ := strconv.ParseFloat(order.ExecutedQuantity, 64)
+	avgPrice, _ := strconv.ParseFloat(order.AvgPrice, 64)
+	return string(order.Status), executedQty, avgPrice, nil
+}
+
+// CancelOrder 取消指定订单
+func (t *FuturesTrader) CancelOrder(symbol string, orderID int64) error {
+	_, err := t.api().NewCancelOrderService().
+		Symbol(symbol).
+		OrderID(orderID).
+		Do(context.Background())
+	if err != nil {
+		return fmt.Errorf("取消订单失败: %w", err)
+	}
+	return nil
+}
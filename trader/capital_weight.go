@@ -0,0 +1,88 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// capitalWeightKey 交易员资金分配比例在系统配置中的键（多个交易员共用一个账户时由资金再平衡写入）
+func capitalWeightKey(traderID string) string {
+	return "capital_weight:" + traderID
+}
+
+// GetCapitalWeight 交易员分配到的账户资金比例（百分比，0=未分配，使用整个账户）
+func (at *AutoTrader) GetCapitalWeight() float64 {
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	db, ok := at.database.(SystemConfigGetter)
+	if !ok {
+		return 0
+	}
+	value, err := db.GetSystemConfig(capitalWeightKey(at.id))
+	if err != nil || value == "" {
+		return 0
+	}
+	weight, err := strconv.ParseFloat(value, 64)
+	if err != nil || weight <= 0 || weight > 100 {
+		return 0
+	}
+	return weight
+}
+
+// SetCapitalAllocation 保存资金分配比例，并把分配到的资金作为新的初始余额（盈亏从分配时起算）
+func (at *AutoTrader) SetCapitalAllocation(weightPct, capital float64) error {
+	if weightPct <= 0 || weightPct > 100 {
+		return fmt.Errorf("资金分配比例必须在 (0,100] 之间: %.2f", weightPct)
+	}
+	type CapitalAllocationStore interface {
+		SetSystemConfig(key, value string) error
+		UpdateTraderInitialBalance(userID, id string, newBalance float64) error
+	}
+	db, ok := at.database.(CapitalAllocationStore)
+	if !ok {
+		return fmt.Errorf("当前数据库不支持保存资金分配")
+	}
+	if err := db.SetSystemConfig(capitalWeightKey(at.id), strconv.FormatFloat(weightPct, 'f', 4, 64)); err != nil {
+		return fmt.Errorf("保存资金分配比例失败: %w", err)
+	}
+	if capital > 0 {
+		if err := db.UpdateTraderInitialBalance(at.userID, at.id, capital); err != nil {
+			return fmt.Errorf("更新初始余额失败: %w", err)
+		}
+		at.initialBalance = capital
+	}
+	log.Printf("⚖️  [%s] 资金分配已更新: %.2f%%（%.2f USDT）", at.name, weightPct, capital)
+	return nil
+}
+
+// applyCapitalWeight 按资金分配比例缩放账户净值和可用余额，作为本交易员的仓位计算基数
+func (at *AutoTrader) applyCapitalWeight(totalEquity, availableBalance float64) (float64, float64) {
+	weight := at.GetCapitalWeight()
+	if weight <= 0 || weight >= 100 {
+		return totalEquity, availableBalance
+	}
+	return totalEquity * weight / 100, availableBalance * weight / 100
+}
+
+// RealizedPnLWithin 回看期内已平仓交易的已实现盈亏（按扫描间隔换算为周期数）
+func (at *AutoTrader) RealizedPnLWithin(lookback time.Duration) (float64, error) {
+	cycles := 100
+	if at.config.ScanInterval > 0 {
+		cycles = int(lookback / at.config.ScanInterval)
+	}
+	if cycles <= 0 {
+		cycles = 1
+	}
+	analysis, err := at.decisionLogger.AnalyzePerformance(cycles)
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	for _, stats := range analysis.SymbolStats {
+		total += stats.TotalPnL
+	}
+	return total, nil
+}
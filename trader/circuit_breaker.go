@@ -0,0 +1,170 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"nofx/config"
+	"time"
+)
+
+// defaultStopTradingTime 未配置 stop_trading_minutes 时的熔断冷却时长
+const defaultStopTradingTime = 60 * time.Minute
+
+// CircuitBreakerStatus 熔断状态（供前端显示"交易将在 42 分钟后恢复"）
+type CircuitBreakerStatus struct {
+	Active           bool      `json:"active"`
+	TrippedAt        time.Time `json:"tripped_at,omitempty"`
+	CooldownUntil    time.Time `json:"cooldown_until,omitempty"`
+	RemainingMinutes int       `json:"remaining_minutes"`
+	Reason           string    `json:"reason,omitempty"`
+	TripCount        int       `json:"trip_count"`
+	DailyLossLimit   float64   `json:"daily_loss_limit"` // 触发熔断的当日亏损百分比（0=不启用）
+	CooldownMinutes  int       `json:"cooldown_minutes"`
+	Message          string    `json:"message"`
+}
+
+// stopTradingTime 熔断冷却时长
+func (at *AutoTrader) stopTradingTime() time.Duration {
+	if at.config.StopTradingTime > 0 {
+		return at.config.StopTradingTime
+	}
+	return defaultStopTradingTime
+}
+
+// stopUntil 熔断冷却到期时间（未熔断时返回零值）
+func (at *AutoTrader) stopUntil() time.Time {
+	at.breakerMu.Lock()
+	defer at.breakerMu.Unlock()
+	if at.breaker == nil {
+		return time.Time{}
+	}
+	return at.breaker.CooldownUntil
+}
+
+// restoreCircuitBreaker 启动时从数据库恢复熔断冷却（冷却未到期时继续暂停交易）
+func (at *AutoTrader) restoreCircuitBreaker() {
+	type CircuitBreakerGetter interface {
+		GetCircuitBreaker(traderID string) (*config.CircuitBreakerState, error)
+	}
+	db, ok := at.database.(CircuitBreakerGetter)
+	if !ok {
+		return
+	}
+	state, err := db.GetCircuitBreaker(at.id)
+	if err != nil {
+		log.Printf("⚠️  [%s] 读取熔断状态失败: %v", at.name, err)
+		return
+	}
+	if state == nil {
+		return
+	}
+
+	at.breakerMu.Lock()
+	at.breaker = state
+	at.breakerMu.Unlock()
+	if time.Now().Before(state.CooldownUntil) {
+		log.Printf("⏸ [%s] 恢复熔断冷却：%s，剩余 %.0f 分钟", at.name, state.Reason, time.Until(state.CooldownUntil).Minutes())
+	}
+}
+
+// checkCircuitBreaker 当日亏损达到上限时触发熔断（每天最多触发一次），返回是否触发
+func (at *AutoTrader) checkCircuitBreaker(equity float64) bool {
+	limit := at.config.MaxDailyLoss
+	if limit <= 0 {
+		return false
+	}
+	opening := at.todayOpeningEquity()
+	if opening <= 0 {
+		return false
+	}
+	pnlPct := (equity - opening) / opening * 100
+	if pnlPct > -limit {
+		return false
+	}
+
+	at.breakerMu.Lock()
+	trippedToday := at.breaker != nil && !at.breaker.TrippedAt.Before(startOfDay(time.Now()))
+	at.breakerMu.Unlock()
+	if trippedToday {
+		return false
+	}
+
+	at.tripCircuitBreaker(fmt.Sprintf("当日亏损 %.2f%% 达到上限 %.2f%%", -pnlPct, limit))
+	return true
+}
+
+// tripCircuitBreaker 触发熔断：暂停交易 stop_trading_minutes 并持久化冷却到期时间
+func (at *AutoTrader) tripCircuitBreaker(reason string) {
+	now := time.Now()
+	cooldown := at.stopTradingTime()
+
+	at.breakerMu.Lock()
+	state := &config.CircuitBreakerState{TraderID: at.id, TrippedAt: now, CooldownUntil: now.Add(cooldown), Reason: reason, TripCount: 1}
+	if at.breaker != nil {
+		state.TripCount = at.breaker.TripCount + 1
+	}
+	at.breaker = state
+	at.breakerMu.Unlock()
+
+	at.saveCircuitBreaker(state)
+	log.Printf("🛑 [%s] 触发熔断：%s，暂停交易 %.0f 分钟", at.name, reason, cooldown.Minutes())
+	at.noteActivity("🛑 触发熔断：%s，暂停交易 %.0f 分钟", reason, cooldown.Minutes())
+	at.publishRiskBreach("", "", "circuit_breaker", fmt.Sprintf("触发熔断：%s，暂停交易 %.0f 分钟", reason, cooldown.Minutes()), true)
+}
+
+// ResetCircuitBreaker 人工解除熔断冷却（保留触发记录）
+func (at *AutoTrader) ResetCircuitBreaker(user string) error {
+	at.breakerMu.Lock()
+	state := at.breaker
+	if state == nil || !time.Now().Before(state.CooldownUntil) {
+		at.breakerMu.Unlock()
+		return fmt.Errorf("当前未处于熔断冷却中")
+	}
+	state.CooldownUntil = time.Now()
+	at.breakerMu.Unlock()
+
+	at.saveCircuitBreaker(state)
+	log.Printf("▶️ [%s] 熔断冷却已由 %s 人工解除", at.name, user)
+	at.noteActivity("熔断冷却已由 %s 人工解除", user)
+	return nil
+}
+
+// saveCircuitBreaker 持久化熔断状态（失败只记录日志，内存中的冷却照常生效）
+func (at *AutoTrader) saveCircuitBreaker(state *config.CircuitBreakerState) {
+	type CircuitBreakerSaver interface {
+		SaveCircuitBreaker(state *config.CircuitBreakerState) error
+	}
+	db, ok := at.database.(CircuitBreakerSaver)
+	if !ok {
+		return
+	}
+	if err := db.SaveCircuitBreaker(state); err != nil {
+		log.Printf("⚠️  [%s] 保存熔断状态失败: %v", at.name, err)
+	}
+}
+
+// GetCircuitBreakerStatus 熔断状态及剩余冷却时间
+func (at *AutoTrader) GetCircuitBreakerStatus() CircuitBreakerStatus {
+	status := CircuitBreakerStatus{
+		DailyLossLimit:  at.config.MaxDailyLoss,
+		CooldownMinutes: int(at.stopTradingTime().Minutes()),
+		Message:         "交易正常",
+	}
+
+	at.breakerMu.Lock()
+	defer at.breakerMu.Unlock()
+	if at.breaker == nil {
+		return status
+	}
+	status.TrippedAt = at.breaker.TrippedAt
+	status.CooldownUntil = at.breaker.CooldownUntil
+	status.Reason = at.breaker.Reason
+	status.TripCount = at.breaker.TripCount
+	if remaining := time.Until(at.breaker.CooldownUntil); remaining > 0 {
+		status.Active = true
+		status.RemainingMinutes = int(math.Ceil(remaining.Minutes()))
+		status.Message = fmt.Sprintf("交易将在 %d 分钟后恢复", status.RemainingMinutes)
+	}
+	return status
+}
@@ -0,0 +1,111 @@
+package trader
+
+import (
+	"log"
+	"nofx/logger"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// clockSyncInterval 定期与交易所同步服务器时间的间隔
+	clockSyncInterval = 10 * time.Minute
+	// defaultClockDriftAlertMs 本地时钟与交易所偏差的默认告警阈值（毫秒）
+	defaultClockDriftAlertMs = 1000
+)
+
+// serverTimeSyncer 需要签名时间戳的交易器：同步交易所服务器时间并在后续请求中应用偏移
+// 返回 本地时间 - 服务器时间（毫秒）；Hyperliquid 使用nonce签名，不实现该接口
+type serverTimeSyncer interface {
+	SyncServerTime() (offsetMs int64, err error)
+}
+
+// ClockSyncStatus 交易所时间同步状态
+type ClockSyncStatus struct {
+	Supported   bool      `json:"supported"`    // 交易器是否支持时间同步
+	OffsetMs    int64     `json:"offset_ms"`    // 本地时间 - 服务器时间（毫秒）
+	ThresholdMs int64     `json:"threshold_ms"` // 告警阈值
+	LastSync    time.Time `json:"last_sync"`
+	LastError   string    `json:"last_error,omitempty"`
+	Syncs       int       `json:"syncs"`
+	Failures    int       `json:"failures"`
+	DriftAlerts int       `json:"drift_alerts"` // 偏差超过阈值的次数
+}
+
+// clockSyncState 线程安全的时间同步状态
+type clockSyncState struct {
+	mu     sync.Mutex
+	status ClockSyncStatus
+}
+
+// getClockDriftAlertMs 时钟偏差告警阈值（系统配置 clock_drift_alert_ms，默认1000ms）
+func (at *AutoTrader) getClockDriftAlertMs() int64 {
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		if value, err := db.GetSystemConfig("clock_drift_alert_ms"); err == nil && value != "" {
+			if ms, err := strconv.ParseInt(value, 10, 64); err == nil && ms > 0 {
+				return ms
+			}
+		}
+	}
+	return defaultClockDriftAlertMs
+}
+
+// syncExchangeTime 与交易所同步服务器时间（force=false 时按间隔同步），偏差超过阈值时告警
+// 偏移已应用到后续签名请求的时间戳，告警用于提示修复本机时钟（NTP）
+func (at *AutoTrader) syncExchangeTime(force bool) {
+	syncer, ok := at.trader.(serverTimeSyncer)
+	if !ok {
+		return
+	}
+
+	at.clockSync.mu.Lock()
+	if !force && time.Since(at.clockSync.status.LastSync) < clockSyncInterval {
+		at.clockSync.mu.Unlock()
+		return
+	}
+	at.clockSync.mu.Unlock()
+
+	offset, err := syncer.SyncServerTime()
+	threshold := at.getClockDriftAlertMs()
+
+	at.clockSync.mu.Lock()
+	s := &at.clockSync.status
+	s.Supported = true
+	s.ThresholdMs = threshold
+	s.LastSync = time.Now()
+	if err != nil {
+		s.Failures++
+		s.LastError = err.Error()
+		at.clockSync.mu.Unlock()
+		log.Printf("⚠️  [%s] 同步交易所服务器时间失败: %v", at.name, err)
+		return
+	}
+	s.Syncs++
+	s.LastError = ""
+	s.OffsetMs = offset
+	drifted := offset > threshold || offset < -threshold
+	if drifted {
+		s.DriftAlerts++
+	}
+	at.clockSync.mu.Unlock()
+
+	if drifted {
+		log.Printf("⏱ [%s] 本地时钟与交易所偏差 %dms，超过阈值 %dms（已在签名时间戳中校正，请检查NTP）", at.name, offset, threshold)
+		at.notify(logger.EventSystem, logger.SeverityWarning, "本地时钟与交易所偏差 %dms（阈值 %dms），已自动校正请求时间戳，请检查服务器NTP", offset, threshold)
+	}
+}
+
+// GetClockSyncStatus 获取交易所时间同步状态
+func (at *AutoTrader) GetClockSyncStatus() ClockSyncStatus {
+	at.clockSync.mu.Lock()
+	defer at.clockSync.mu.Unlock()
+	status := at.clockSync.status
+	if _, ok := at.trader.(serverTimeSyncer); ok {
+		status.Supported = true
+	}
+	return status
+}
@@ -0,0 +1,50 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+)
+
+// verifyCloseSide 平仓前用实时持仓校验方向：AI要平的方向必须真实存在，防止误判持仓导致反向开仓
+// 返回实际持仓数量（绝对值），平仓数量一律以实时持仓为准，不使用AI给出的数量
+func (at *AutoTrader) verifyCloseSide(symbol, side string) (float64, error) {
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return 0, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	var quantity float64
+	var otherSides []string
+	for _, pos := range positions {
+		if pos["symbol"] != symbol {
+			continue
+		}
+		posSide, _ := pos["side"].(string)
+		posAmt, _ := pos["positionAmt"].(float64)
+		if posAmt == 0 {
+			continue
+		}
+		if posSide == side {
+			quantity = math.Abs(posAmt)
+		} else {
+			otherSides = append(otherSides, posSide)
+		}
+	}
+
+	if quantity > 0 {
+		return quantity, nil
+	}
+	if len(otherSides) > 0 {
+		return 0, fmt.Errorf("❌ 平仓方向与实际持仓不符: %s 没有%s仓，实际持有%s仓，拒绝执行以防止反向开仓",
+			symbol, sideName(side), sideName(otherSides[0]))
+	}
+	return 0, fmt.Errorf("❌ %s 没有%s仓，拒绝执行平仓（AI可能误判了持仓）", symbol, sideName(side))
+}
+
+// sideName 持仓方向中文名
+func sideName(side string) string {
+	if side == "short" {
+		return "空"
+	}
+	return "多"
+}
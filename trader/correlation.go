@@ -0,0 +1,39 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/market"
+)
+
+// maxCorrelationSymbols 相关性矩阵最多包含的币种数（持仓优先，其余按候选顺序）
+const maxCorrelationSymbols = 30
+
+// GetCorrelationMatrix 当前持仓与最近一次候选币种的收益率相关性矩阵
+func (at *AutoTrader) GetCorrelationMatrix(interval string, lookback int) (*market.CorrelationMatrix, error) {
+	seen := make(map[string]bool)
+	var symbols []string
+	add := func(symbol string) {
+		if symbol != "" && !seen[symbol] && len(symbols) < maxCorrelationSymbols {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		add(symbol)
+	}
+	candidates, _ := at.GetCandidates()
+	for _, coin := range candidates {
+		add(coin.Symbol)
+	}
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("暂无持仓和候选币种（trader尚未运行决策周期）")
+	}
+
+	return market.GetCorrelationMatrix(symbols, interval, lookback)
+}
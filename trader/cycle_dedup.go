@@ -0,0 +1,88 @@
+package trader
+
+import (
+	"log"
+	"nofx/logger"
+	"strconv"
+	"time"
+)
+
+// cycleBarKey 交易员最近一次完成的定时决策周期所在K线在系统配置中的键（重启后去重）
+func cycleBarKey(traderID string) string {
+	return "cycle_bar:" + traderID
+}
+
+// cycleBar 时间所在的扫描周期K线开盘时间（按扫描间隔对齐到Unix纪元，与交易所K线边界一致）
+func cycleBar(t time.Time, interval time.Duration) time.Time {
+	step := interval.Milliseconds()
+	if step <= 0 {
+		return t
+	}
+	return time.UnixMilli(t.UnixMilli() / step * step)
+}
+
+// lastCycleBar 最近一次完成的定时决策周期所在K线（未记录时返回 false）
+func (at *AutoTrader) lastCycleBar() (time.Time, bool) {
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	db, ok := at.database.(SystemConfigGetter)
+	if !ok {
+		return time.Time{}, false
+	}
+	value, err := db.GetSystemConfig(cycleBarKey(at.id))
+	if err != nil || value == "" {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
+
+// saveCycleBar 记录定时决策周期已处理的K线
+func (at *AutoTrader) saveCycleBar(bar time.Time) {
+	type SystemConfigSetter interface {
+		SetSystemConfig(key, value string) error
+	}
+	db, ok := at.database.(SystemConfigSetter)
+	if !ok {
+		return
+	}
+	if err := db.SetSystemConfig(cycleBarKey(at.id), strconv.FormatInt(bar.UnixMilli(), 10)); err != nil {
+		log.Printf("⚠️  [%s] 保存决策周期K线失败: %v", at.name, err)
+	}
+}
+
+// startupCycleDue 启动时是否立即执行首个决策周期（当前K线在重启前已执行过决策时跳过，等待下一次定时触发，避免重复开仓）
+func (at *AutoTrader) startupCycleDue(now time.Time) bool {
+	last, ok := at.lastCycleBar()
+	if !ok {
+		return true
+	}
+	bar := cycleBar(now, at.config.ScanInterval)
+	if !last.Equal(bar) {
+		return true
+	}
+	log.Printf("⏭ [%s] 当前K线（%s 开盘）在重启前已执行过决策周期，跳过启动时的立即执行", at.name, bar.Format("15:04:05"))
+	at.noteActivity("重启后跳过已执行过决策的K线（%s 开盘）", bar.Format("15:04:05"))
+	return false
+}
+
+// runScheduledCycle 执行定时决策周期，成功后记录所在K线
+func (at *AutoTrader) runScheduledCycle() {
+	at.runBarCycle(time.Now(), at.runCycle)
+}
+
+// runBarCycle 执行 now 所在K线的决策周期，只有执行成功才记录该K线（失败的周期重启后仍会补执行）
+func (at *AutoTrader) runBarCycle(now time.Time, run func() error) {
+	bar := cycleBar(now, at.config.ScanInterval)
+	if err := run(); err != nil {
+		log.Printf("❌ 执行失败: %v", err)
+		at.recordCycleError(err)
+		at.notify(logger.EventError, logger.SeverityWarning, "决策周期执行失败: %v", err)
+		return
+	}
+	at.saveCycleBar(bar)
+}
@@ -0,0 +1,79 @@
+package trader
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCycleBar(t *testing.T) {
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		at       time.Time
+		interval time.Duration
+		want     time.Time
+	}{
+		{"K线内任意时刻对齐到开盘", base.Add(2*time.Minute + 30*time.Second), 3 * time.Minute, base},
+		{"边界时刻属于新K线", base.Add(3 * time.Minute), 3 * time.Minute, base.Add(3 * time.Minute)},
+		{"小时周期", base.Add(59 * time.Minute), time.Hour, base},
+		{"无效间隔原样返回", base.Add(time.Second), 0, base.Add(time.Second)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cycleBar(tt.at, tt.interval); !got.Equal(tt.want) {
+				t.Errorf("cycleBar() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartupCycleDue(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 1, 0, 0, time.UTC)
+	bar := cycleBar(now, 3*time.Minute)
+	tests := []struct {
+		name   string
+		config memoryConfig
+		want   bool
+	}{
+		{"未记录过K线时执行", memoryConfig{}, true},
+		{"当前K线已执行过时跳过", memoryConfig{cycleBarKey("t1"): strconv.FormatInt(bar.UnixMilli(), 10)}, false},
+		{"上一根K线已执行时执行", memoryConfig{cycleBarKey("t1"): strconv.FormatInt(bar.Add(-3*time.Minute).UnixMilli(), 10)}, true},
+		{"记录无法解析时执行", memoryConfig{cycleBarKey("t1"): "bad"}, true},
+		{"其他交易员的记录不影响", memoryConfig{cycleBarKey("t2"): strconv.FormatInt(bar.UnixMilli(), 10)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := &AutoTrader{id: "t1", database: tt.config, config: AutoTraderConfig{ScanInterval: 3 * time.Minute}, narrative: &activityNarrative{}}
+			if got := at.startupCycleDue(now); got != tt.want {
+				t.Errorf("startupCycleDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunBarCycleSavesBarOnSuccess(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 1, 0, 0, time.UTC)
+	key := cycleBarKey("t1")
+
+	config := memoryConfig{}
+	at := &AutoTrader{id: "t1", database: config, config: AutoTraderConfig{ScanInterval: 3 * time.Minute}}
+
+	at.runBarCycle(now, func() error { return errors.New("AI调用失败") })
+	if _, ok := config[key]; ok {
+		t.Fatalf("failed cycle saved bar %q", config[key])
+	}
+	if !at.startupCycleDue(now) {
+		t.Error("failed cycle should be retried after restart")
+	}
+
+	ran := false
+	at.runBarCycle(now, func() error { ran = true; return nil })
+	if !ran {
+		t.Fatal("cycle not run")
+	}
+	if want := strconv.FormatInt(cycleBar(now, 3*time.Minute).UnixMilli(), 10); config[key] != want {
+		t.Errorf("saved bar = %q, want %q", config[key], want)
+	}
+}
@@ -0,0 +1,147 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/config"
+	"nofx/logger"
+)
+
+// orderRefs 从下单结果提取订单引用（Maker挂单+市价补齐时结果中已带多笔订单）
+func orderRefs(order map[string]interface{}) []logger.OrderRef {
+	if refs, ok := order["orders"].([]logger.OrderRef); ok {
+		return refs
+	}
+	ref := newOrderRef(order, "market")
+	if ref.ExchangeOrderID == 0 && ref.ClientOrderID == "" {
+		return nil // 交易所未返回订单ID（如Hyperliquid），无法映射
+	}
+	return []logger.OrderRef{ref}
+}
+
+// newOrderRef 单笔下单结果转换为订单引用
+func newOrderRef(order map[string]interface{}, orderType string) logger.OrderRef {
+	avgPrice, filledQty := orderFill(order)
+	ref := logger.OrderRef{Type: orderType, FilledQty: filledQty, AvgPrice: avgPrice}
+	ref.ExchangeOrderID, _ = order["orderId"].(int64)
+	ref.ClientOrderID, _ = order["clientOrderId"].(string)
+	if status, ok := order["status"]; ok && status != nil {
+		ref.Status = fmt.Sprint(status)
+	}
+	return ref
+}
+
+// saveDecisionOrders 保存决策记录中各决策产生的订单映射（需在 LogDecision 之后调用以获得记录ID）
+func (at *AutoTrader) saveDecisionOrders(record *logger.DecisionRecord) {
+	var orders []config.DecisionOrder
+	for _, action := range record.Decisions {
+		for _, ref := range action.Orders {
+			orders = append(orders, config.DecisionOrder{
+				TraderID:        at.id,
+				DecisionID:      record.ID,
+				CycleNumber:     record.CycleNumber,
+				Symbol:          action.Symbol,
+				Action:          action.Action,
+				ClientOrderID:   ref.ClientOrderID,
+				ExchangeOrderID: ref.ExchangeOrderID,
+				OrderType:       ref.Type,
+				Status:          ref.Status,
+				FilledQty:       ref.FilledQty,
+				AvgPrice:        ref.AvgPrice,
+			})
+		}
+	}
+	if len(orders) == 0 {
+		return
+	}
+
+	type DecisionOrderSaver interface {
+		SaveDecisionOrders(orders []config.DecisionOrder) error
+	}
+	db, ok := at.database.(DecisionOrderSaver)
+	if !ok {
+		return
+	}
+	if err := db.SaveDecisionOrders(orders); err != nil {
+		log.Printf("⚠️  [%s] 保存决策-订单映射失败: %v", at.name, err)
+	}
+}
+
+// DecisionDetail 决策记录及其产生的订单
+type DecisionDetail struct {
+	Record *logger.DecisionRecord `json:"record"`
+	Orders []config.DecisionOrder `json:"orders"`
+}
+
+// GetDecisionDetail 按记录ID获取决策详情（含订单映射）
+func (at *AutoTrader) GetDecisionDetail(decisionID string) (*DecisionDetail, error) {
+	record, err := at.decisionLogger.GetRecord(decisionID)
+	if err != nil {
+		return nil, err
+	}
+	detail := &DecisionDetail{Record: record, Orders: []config.DecisionOrder{}}
+
+	type DecisionOrderGetter interface {
+		GetDecisionOrders(traderID, decisionID string) ([]config.DecisionOrder, error)
+	}
+	if db, ok := at.database.(DecisionOrderGetter); ok {
+		orders, err := db.GetDecisionOrders(at.id, decisionID)
+		if err != nil {
+			return nil, fmt.Errorf("读取订单映射失败: %w", err)
+		}
+		detail.Orders = orders
+	}
+	return detail, nil
+}
+
+// OrderOrigin 订单及产生它的AI决策（含理由）
+type OrderOrigin struct {
+	Order    config.DecisionOrder   `json:"order"`
+	Decision *logger.DecisionAction `json:"decision,omitempty"` // 决策记录中对应的决策（含AI理由）
+	Record   *logger.DecisionRecord `json:"record,omitempty"`
+}
+
+// FindOrderOrigin 按交易所订单ID或客户端订单ID追溯产生该订单的决策
+func (at *AutoTrader) FindOrderOrigin(exchangeOrderID int64, clientOrderID string) (*OrderOrigin, error) {
+	type DecisionOrderFinder interface {
+		FindDecisionOrder(traderID string, exchangeOrderID int64, clientOrderID string) (*config.DecisionOrder, error)
+	}
+	db, ok := at.database.(DecisionOrderFinder)
+	if !ok {
+		return nil, fmt.Errorf("数据库不支持订单映射")
+	}
+	order, err := db.FindDecisionOrder(at.id, exchangeOrderID, clientOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单映射失败: %w", err)
+	}
+	if order == nil {
+		return nil, fmt.Errorf("未找到该订单对应的决策")
+	}
+
+	origin := &OrderOrigin{Order: *order}
+	record, err := at.decisionLogger.GetRecord(order.DecisionID)
+	if err != nil {
+		// 决策日志可能已被清理，仍返回映射本身
+		log.Printf("⚠️  [%s] 读取决策记录 %s 失败: %v", at.name, order.DecisionID, err)
+		return origin, nil
+	}
+	origin.Record = record
+	for i := range record.Decisions {
+		if matchesOrder(record.Decisions[i].Orders, order) {
+			origin.Decision = &record.Decisions[i]
+			break
+		}
+	}
+	return origin, nil
+}
+
+// matchesOrder 订单引用列表中是否包含该订单
+func matchesOrder(refs []logger.OrderRef, order *config.DecisionOrder) bool {
+	for _, ref := range refs {
+		if (order.ClientOrderID != "" && ref.ClientOrderID == order.ClientOrderID) ||
+			(order.ExchangeOrderID != 0 && ref.ExchangeOrderID == order.ExchangeOrderID) {
+			return true
+		}
+	}
+	return false
+}
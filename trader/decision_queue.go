@@ -0,0 +1,369 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 队列中决策的状态
+const (
+	QueueStatusPending    = "pending"    // 等待人工审批
+	QueueStatusExecuting  = "executing"  // 执行中
+	QueueStatusExecuted   = "executed"   // 已执行成功
+	QueueStatusFailed     = "failed"     // 执行失败
+	QueueStatusCancelled  = "cancelled"  // 人工取消
+	QueueStatusSuperseded = "superseded" // 新周期决策产生后失效
+	QueueStatusHeld       = "held"       // 交易所维护中，暂缓到维护结束后执行
+)
+
+const (
+	maxQueueHistory = 100      // 保留的已结束决策数
+	systemOperator  = "system" // 系统自动操作的操作人
+	queueIDPrefix   = "q"
+)
+
+// QueuedDecision 决策队列条目
+type QueuedDecision struct {
+	ID          string            `json:"id"`
+	CycleNumber int               `json:"cycle_number"`
+	Decision    decision.Decision `json:"decision"`
+	Status      string            `json:"status"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// DecisionEdit 人工修改仓位参数（为空的字段保持不变）
+type DecisionEdit struct {
+	PositionSizeUSD *float64 `json:"position_size_usd"`
+	Leverage        *int     `json:"leverage"`
+}
+
+// decisionQueue 待执行/待审批决策队列
+type decisionQueue struct {
+	mu    sync.Mutex
+	seq   int
+	items []*QueuedDecision
+}
+
+// IsApprovalRequired 决策是否需要人工审批后执行（交易员配置 > 系统配置 decision_approval_required）
+func (at *AutoTrader) IsApprovalRequired() bool {
+	if at.config.RequireApproval {
+		return true
+	}
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		value, _ := db.GetSystemConfig("decision_approval_required")
+		return value == "true"
+	}
+	return false
+}
+
+// enqueueDecision 决策入队，返回条目副本
+func (at *AutoTrader) enqueueDecision(d decision.Decision, cycle int, status string) QueuedDecision {
+	q := &at.decisionQueue
+	q.mu.Lock()
+	q.seq++
+	now := time.Now()
+	item := &QueuedDecision{
+		ID:          fmt.Sprintf("%s%d-%d", queueIDPrefix, now.Unix(), q.seq),
+		CycleNumber: cycle,
+		Decision:    d,
+		Status:      status,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	q.items = append(q.items, item)
+	q.pruneLocked()
+	snapshot := *item
+	q.mu.Unlock()
+
+	if status == QueueStatusPending {
+		at.journal(snapshot, "queued", systemOperator, "等待人工审批", true)
+	}
+	return snapshot
+}
+
+// finishQueuedDecision 记录执行结果
+func (at *AutoTrader) finishQueuedDecision(id string, err error) {
+	q := &at.decisionQueue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, item := range q.items {
+		if item.ID != id {
+			continue
+		}
+		item.Status = QueueStatusExecuted
+		if err != nil {
+			item.Status = QueueStatusFailed
+			item.Error = err.Error()
+		}
+		item.UpdatedAt = time.Now()
+		return
+	}
+}
+
+// supersedePendingDecisions 新周期开始时让上一周期未审批的决策失效（行情已变化，AI会重新给出决策）
+func (at *AutoTrader) supersedePendingDecisions() {
+	q := &at.decisionQueue
+	q.mu.Lock()
+	var superseded []QueuedDecision
+	for _, item := range q.items {
+		if item.Status == QueueStatusPending {
+			item.Status = QueueStatusSuperseded
+			item.UpdatedAt = time.Now()
+			superseded = append(superseded, *item)
+		}
+	}
+	q.mu.Unlock()
+
+	for _, item := range superseded {
+		at.journal(item, "superseded", systemOperator, "新周期决策产生，未审批的决策失效", true)
+	}
+}
+
+// finished 条目是否已结束（待审批/执行中/维护暂缓的条目未结束）
+func (item *QueuedDecision) finished() bool {
+	return item.Status != QueueStatusPending && item.Status != QueueStatusExecuting && item.Status != QueueStatusHeld
+}
+
+// pruneLocked 只保留未结束的条目和最近 maxQueueHistory 条已结束条目（调用方需持有锁）
+func (q *decisionQueue) pruneLocked() {
+	finished := 0
+	for _, item := range q.items {
+		if item.finished() {
+			finished++
+		}
+	}
+	if finished <= maxQueueHistory {
+		return
+	}
+	drop := finished - maxQueueHistory
+	kept := q.items[:0]
+	for _, item := range q.items {
+		if drop > 0 && item.finished() {
+			drop--
+			continue
+		}
+		kept = append(kept, item)
+	}
+	q.items = kept
+}
+
+// find 查找条目（调用方需持有锁）
+func (q *decisionQueue) find(id string) (*QueuedDecision, error) {
+	for _, item := range q.items {
+		if item.ID == id {
+			return item, nil
+		}
+	}
+	return nil, fmt.Errorf("决策 %s 不存在", id)
+}
+
+// journal 写入操作日志
+func (at *AutoTrader) journal(item QueuedDecision, operation, user, detail string, success bool) {
+	if at.overrideJournal == nil {
+		return
+	}
+	err := at.overrideJournal.Append(logger.OverrideEntry{
+		DecisionID: item.ID,
+		Symbol:     item.Decision.Symbol,
+		Action:     item.Decision.Action,
+		Operation:  operation,
+		User:       user,
+		Detail:     detail,
+		Success:    success,
+	})
+	if err != nil {
+		log.Printf("⚠ [%s] 写入决策操作日志失败: %v", at.name, err)
+	}
+}
+
+// GetDecisionQueue 获取决策队列（新→旧）
+func (at *AutoTrader) GetDecisionQueue() []QueuedDecision {
+	q := &at.decisionQueue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	result := make([]QueuedDecision, 0, len(q.items))
+	for i := len(q.items) - 1; i >= 0; i-- {
+		result = append(result, *q.items[i])
+	}
+	return result
+}
+
+// GetDecisionJournal 最近N条决策队列操作记录
+func (at *AutoTrader) GetDecisionJournal(n int) []logger.OverrideEntry {
+	if at.overrideJournal == nil {
+		return []logger.OverrideEntry{}
+	}
+	return at.overrideJournal.Recent(n)
+}
+
+// CancelQueuedDecision 取消待审批决策
+func (at *AutoTrader) CancelQueuedDecision(id, user string) error {
+	q := &at.decisionQueue
+	q.mu.Lock()
+	item, err := q.find(id)
+	if err == nil && item.Status != QueueStatusPending {
+		err = fmt.Errorf("决策 %s 当前状态为 %s，只能取消待审批的决策", id, item.Status)
+	}
+	if err != nil {
+		q.mu.Unlock()
+		return err
+	}
+	item.Status = QueueStatusCancelled
+	item.UpdatedAt = time.Now()
+	snapshot := *item
+	q.mu.Unlock()
+
+	log.Printf("🚫 [%s] %s 取消决策 %s (%s %s)", at.name, user, id, snapshot.Decision.Symbol, snapshot.Decision.Action)
+	at.journal(snapshot, "cancel", user, "", true)
+	return nil
+}
+
+// EditQueuedDecision 修改待审批开仓决策的仓位大小/杠杆，修改后必须通过风险预览的全部规则检查
+func (at *AutoTrader) EditQueuedDecision(id, user string, edit DecisionEdit) (*QueuedDecision, *RiskPreview, error) {
+	q := &at.decisionQueue
+	q.mu.Lock()
+	item, err := q.find(id)
+	if err == nil && item.Status != QueueStatusPending {
+		err = fmt.Errorf("决策 %s 当前状态为 %s，只能修改待审批的决策", id, item.Status)
+	}
+	if err == nil && item.Decision.Action != "open_long" && item.Decision.Action != "open_short" {
+		err = fmt.Errorf("只能修改开仓决策的仓位参数")
+	}
+	if err != nil {
+		q.mu.Unlock()
+		return nil, nil, err
+	}
+	edited := item.Decision
+	q.mu.Unlock()
+
+	var changes []string
+	if edit.PositionSizeUSD != nil {
+		changes = append(changes, fmt.Sprintf("仓位 %.2f → %.2f USDT", edited.PositionSizeUSD, *edit.PositionSizeUSD))
+		edited.PositionSizeUSD = *edit.PositionSizeUSD
+	}
+	if edit.Leverage != nil {
+		changes = append(changes, fmt.Sprintf("杠杆 %dx → %dx", edited.Leverage, *edit.Leverage))
+		edited.Leverage = *edit.Leverage
+	}
+	if len(changes) == 0 {
+		return nil, nil, fmt.Errorf("没有需要修改的字段")
+	}
+	detail := strings.Join(changes, "，")
+
+	// 风险预览在锁外执行（需要查询交易所）
+	preview, err := at.PreviewDecisionRisk(edited)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !preview.Approvable {
+		var failed []string
+		for _, c := range preview.Checks {
+			if !c.Passed {
+				failed = append(failed, fmt.Sprintf("%s: %s", c.Name, c.Detail))
+			}
+		}
+		err := fmt.Errorf("修改后超出风控范围: %s", strings.Join(failed, "; "))
+		q.mu.Lock()
+		snapshot := *item
+		q.mu.Unlock()
+		at.journal(snapshot, "edit", user, detail+"（拒绝: "+err.Error()+"）", false)
+		return nil, preview, err
+	}
+
+	q.mu.Lock()
+	if item.Status != QueueStatusPending {
+		q.mu.Unlock()
+		return nil, nil, fmt.Errorf("决策 %s 已不是待审批状态", id)
+	}
+	item.Decision = edited
+	item.UpdatedAt = time.Now()
+	snapshot := *item
+	q.mu.Unlock()
+
+	log.Printf("✏️ [%s] %s 修改决策 %s (%s %s): %s", at.name, user, id, edited.Symbol, edited.Action, detail)
+	at.journal(snapshot, "edit", user, detail, true)
+	return &snapshot, preview, nil
+}
+
+// ExecuteQueuedDecision 人工批准/强制执行待审批决策
+func (at *AutoTrader) ExecuteQueuedDecision(id, user string) (*QueuedDecision, *logger.DecisionAction, error) {
+	q := &at.decisionQueue
+	q.mu.Lock()
+	item, err := q.find(id)
+	if err == nil && item.Status != QueueStatusPending {
+		err = fmt.Errorf("决策 %s 当前状态为 %s，只能执行待审批的决策", id, item.Status)
+	}
+	if err != nil {
+		q.mu.Unlock()
+		return nil, nil, err
+	}
+	item.Status = QueueStatusExecuting
+	item.UpdatedAt = time.Now()
+	d := item.Decision
+	q.mu.Unlock()
+
+	log.Printf("▶️ [%s] %s 强制执行决策 %s (%s %s)", at.name, user, id, d.Symbol, d.Action)
+	actionRecord, err := at.executeQueuedDecision(&d)
+	at.finishQueuedDecision(id, err)
+
+	q.mu.Lock()
+	snapshot := *item
+	q.mu.Unlock()
+
+	detail := "执行成功"
+	if err != nil {
+		detail = "执行失败: " + err.Error()
+	}
+	at.journal(snapshot, "execute", user, detail, err == nil)
+	return &snapshot, actionRecord, err
+}
+
+// executeQueuedDecision 执行单个决策（含翻仓前置平仓），与周期内执行流程一致
+func (at *AutoTrader) executeQueuedDecision(d *decision.Decision) (*logger.DecisionAction, error) {
+	if err := at.checkPausedExecution(d); err != nil {
+		return nil, err
+	}
+	if d.Action == "open_long" || d.Action == "open_short" {
+		if at.equityFrozen() {
+			return nil, fmt.Errorf("账户净值异常未确认，新开仓已冻结")
+		}
+		flipRecord, err := at.closeOppositePositionForFlip(d)
+		if flipRecord != nil {
+			at.noteDecisionActivity(flipRecord)
+		}
+		if err != nil && flipRecord == nil {
+			return nil, err // 尚未平仓：开仓前置检查未通过或获取持仓失败
+		}
+		if err != nil {
+			return flipRecord, fmt.Errorf("翻仓平仓失败: %w", err)
+		}
+	}
+
+	actionRecord := &logger.DecisionAction{
+		Action:    d.Action,
+		Symbol:    d.Symbol,
+		Leverage:  d.Leverage,
+		Timestamp: time.Now(),
+	}
+	tagActionRecord(actionRecord, d)
+	err := at.executeDecisionWithRetry(d, actionRecord)
+	if err != nil {
+		actionRecord.Status = logger.DecisionStatusFailed
+		actionRecord.Error = err.Error()
+	} else {
+		actionRecord.Success = true
+		actionRecord.Status = logger.DecisionStatusExecuted
+	}
+	at.noteDecisionActivity(actionRecord)
+	return actionRecord, err
+}
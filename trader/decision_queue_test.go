@@ -0,0 +1,66 @@
+package trader
+
+import (
+	"nofx/decision"
+	"testing"
+)
+
+func TestDecisionQueueTransitions(t *testing.T) {
+	at := &AutoTrader{name: "test"}
+	open := at.enqueueDecision(decision.Decision{Symbol: "BTCUSDT", Action: "open_long"}, 1, QueueStatusPending)
+	closing := at.enqueueDecision(decision.Decision{Symbol: "ETHUSDT", Action: "close_short"}, 1, QueueStatusPending)
+
+	tests := []struct {
+		name    string
+		op      func() error
+		wantErr bool
+	}{
+		{"取消待审批决策", func() error { return at.CancelQueuedDecision(open.ID, "alice") }, false},
+		{"重复取消被拒绝", func() error { return at.CancelQueuedDecision(open.ID, "alice") }, true},
+		{"不存在的决策", func() error { return at.CancelQueuedDecision("missing", "alice") }, true},
+		{"平仓决策不能修改仓位", func() error {
+			size := 100.0
+			_, _, err := at.EditQueuedDecision(closing.ID, "alice", DecisionEdit{PositionSizeUSD: &size})
+			return err
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(); (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	at.supersedePendingDecisions()
+	statuses := map[string]string{}
+	for _, item := range at.GetDecisionQueue() {
+		statuses[item.ID] = item.Status
+	}
+	if statuses[open.ID] != QueueStatusCancelled || statuses[closing.ID] != QueueStatusSuperseded {
+		t.Errorf("statuses = %v", statuses)
+	}
+}
+
+func TestDecisionQueuePruneKeepsPending(t *testing.T) {
+	at := &AutoTrader{name: "test"}
+	pending := at.enqueueDecision(decision.Decision{Symbol: "BTCUSDT", Action: "open_long"}, 1, QueueStatusPending)
+	for i := 0; i < maxQueueHistory+20; i++ {
+		item := at.enqueueDecision(decision.Decision{Symbol: "ETHUSDT", Action: "close_long"}, 2, QueueStatusExecuting)
+		at.finishQueuedDecision(item.ID, nil)
+	}
+
+	queue := at.GetDecisionQueue()
+	if len(queue) > maxQueueHistory+2 {
+		t.Errorf("len(queue) = %d, want <= %d", len(queue), maxQueueHistory+2)
+	}
+	found := false
+	for _, item := range queue {
+		if item.ID == pending.ID {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("pending decision was pruned")
+	}
+}
@@ -0,0 +1,117 @@
+package trader
+
+import (
+	"log"
+	"math"
+	"time"
+)
+
+// defaultMinNotional 交易所未提供最小名义价值时使用的保守默认值（USDT），仅用于部分平仓是否会留下粉尘的判断
+const defaultMinNotional = 10.0
+
+// dustCleanupInterval 粉尘仓位清理周期
+const dustCleanupInterval = 30 * time.Minute
+
+// minNotionalProvider 可从交易规则读取币种最小名义价值的交易器（目前仅币安实现，未知时返回0）
+type minNotionalProvider interface {
+	GetMinNotional(symbol string) float64
+}
+
+// exchangeMinNotional 交易所报告的币种最小名义价值（交易器不支持或查询失败时返回 false）
+func (at *AutoTrader) exchangeMinNotional(symbol string) (float64, bool) {
+	if provider, ok := at.trader.(minNotionalProvider); ok {
+		if minNotional := provider.GetMinNotional(symbol); minNotional > 0 {
+			return minNotional, true
+		}
+	}
+	return 0, false
+}
+
+// getMinNotional 获取币种最小名义价值（交易所未报告时使用保守默认值）
+func (at *AutoTrader) getMinNotional(symbol string) float64 {
+	if minNotional, ok := at.exchangeMinNotional(symbol); ok {
+		return minNotional
+	}
+	return defaultMinNotional
+}
+
+// isDustQuantity 判断数量在给定价格下是否低于最小名义价值（粉尘仓位无法再单独下单平掉）
+func (at *AutoTrader) isDustQuantity(symbol string, quantity, price float64) bool {
+	if quantity <= 0 || price <= 0 {
+		return false
+	}
+	return quantity*price < at.getMinNotional(symbol)
+}
+
+// startDustCleanup 启动粉尘仓位定期清理
+// 交易所不提供最小名义价值时不自动清理：按默认值判断可能把可正常交易的小仓位误当作粉尘平掉
+func (at *AutoTrader) startDustCleanup() {
+	if _, ok := at.trader.(minNotionalProvider); !ok {
+		log.Printf("🧹 [%s] 交易所不提供最小名义价值，不启动粉尘仓位自动清理", at.name)
+		return
+	}
+	at.monitorWg.Add(1)
+	go func() {
+		defer at.monitorWg.Done()
+
+		ticker := time.NewTicker(dustCleanupInterval)
+		defer ticker.Stop()
+
+		log.Printf("🧹 启动粉尘仓位清理（每%.0f分钟检查一次）", dustCleanupInterval.Minutes())
+
+		for {
+			select {
+			case <-ticker.C:
+				at.cleanupDustPositions()
+			case <-at.stopMonitorCh:
+				log.Println("⏹ 停止粉尘仓位清理")
+				return
+			}
+		}
+	}()
+}
+
+// cleanupDustPositions 汇总所有币种的粉尘仓位并全部平掉
+func (at *AutoTrader) cleanupDustPositions() {
+	if at.inMaintenance() {
+		return // 维护期间不下单，下一次检查再清理
+	}
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		log.Printf("❌ 粉尘清理：获取持仓失败: %v", err)
+		return
+	}
+
+	dustCount := 0
+	dustValue := 0.0
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		markPrice, _ := pos["markPrice"].(float64)
+		posAmt, _ := pos["positionAmt"].(float64)
+		quantity := math.Abs(posAmt)
+
+		// 只按交易所报告的最小名义价值判断，查询不到时跳过该币种
+		minNotional, ok := at.exchangeMinNotional(symbol)
+		notional := quantity * markPrice
+		if !ok || quantity <= 0 || markPrice <= 0 || notional >= minNotional {
+			continue
+		}
+
+		log.Printf("🧹 发现粉尘仓位: %s %s | 数量: %.6f | 价值: %.4f USDT (最小 %.2f USDT)",
+			symbol, side, quantity, notional, minNotional)
+
+		if err := at.emergencyClosePosition(symbol, side); err != nil {
+			log.Printf("❌ 粉尘仓位平仓失败 (%s %s): %v", symbol, side, err)
+			continue
+		}
+
+		at.ClearPeakPnLCache(symbol, side)
+		dustCount++
+		dustValue += notional
+	}
+
+	if dustCount > 0 {
+		log.Printf("🧹 粉尘清理完成: 平掉 %d 个仓位，合计 %.4f USDT", dustCount, dustValue)
+	}
+}
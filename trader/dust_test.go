@@ -0,0 +1,41 @@
+package trader
+
+import (
+	"fmt"
+	"testing"
+)
+
+// minNotionalTrader 报告最小名义价值的交易器
+type minNotionalTrader struct {
+	fakeTrader
+	minNotional map[string]float64
+}
+
+func (m *minNotionalTrader) GetMinNotional(symbol string) float64 { return m.minNotional[symbol] }
+
+func TestCleanupDustPositions(t *testing.T) {
+	positions := []map[string]interface{}{
+		{"symbol": "BTCUSDT", "side": "long", "positionAmt": 0.00001, "markPrice": 100000.0}, // 1 USDT
+		{"symbol": "ETHUSDT", "side": "short", "positionAmt": -0.001, "markPrice": 4000.0},   // 4 USDT
+		{"symbol": "SOLUSDT", "side": "long", "positionAmt": 0.5, "markPrice": 200.0},        // 100 USDT
+	}
+
+	t.Run("交易所不报告最小名义价值时不清理", func(t *testing.T) {
+		fake := &fakeTrader{positions: positions}
+		at := &AutoTrader{trader: fake}
+		at.cleanupDustPositions()
+		if len(fake.closed) != 0 {
+			t.Errorf("closed = %v, want none", fake.closed)
+		}
+	})
+
+	t.Run("按交易所报告的最小名义价值清理", func(t *testing.T) {
+		// ETHUSDT 查询不到最小名义价值，跳过而不是按默认值判断
+		exchange := &minNotionalTrader{fakeTrader: fakeTrader{positions: positions}, minNotional: map[string]float64{"BTCUSDT": 5, "SOLUSDT": 5}}
+		at := &AutoTrader{trader: exchange}
+		at.cleanupDustPositions()
+		if fmt.Sprint(exchange.closed) != "[close_long]" {
+			t.Errorf("closed = %v, want only BTCUSDT close_long", exchange.closed)
+		}
+	})
+}
@@ -0,0 +1,229 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"nofx/decision"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultEquityJumpPct 未配置 equity_jump_pct 时的净值跳变告警阈值（相邻两个周期）
+const defaultEquityJumpPct = 30.0
+
+// EquityAnomaly 交易所上报净值异常跳变（API故障、划转等），确认前禁止新开仓
+type EquityAnomaly struct {
+	DetectedAt     time.Time `json:"detected_at"`
+	PreviousEquity float64   `json:"previous_equity"` // 上一次正常快照的账户净值
+	ReportedEquity float64   `json:"reported_equity"` // 触发告警时交易所上报的净值
+	DeviationPct   float64   `json:"deviation_pct"`   // 相对上一次快照的偏差（带符号）
+	ThresholdPct   float64   `json:"threshold_pct"`
+}
+
+// EquityGuardStatus 净值异常保护状态
+type EquityGuardStatus struct {
+	ThresholdPct float64        `json:"threshold_pct"` // 0=不启用
+	LastEquity   float64        `json:"last_equity"`   // 最近一次正常快照（比较基准）
+	Frozen       bool           `json:"frozen"`        // 是否冻结新开仓
+	Anomaly      *EquityAnomaly `json:"anomaly,omitempty"`
+}
+
+// equityGuardState 净值基准与未确认的异常（异常持久化，重启后继续冻结）
+type equityGuardState struct {
+	mu         sync.Mutex
+	lastEquity float64
+	anomaly    *EquityAnomaly
+}
+
+// equityAnomalyKey 未确认的净值异常在系统配置中的键
+func equityAnomalyKey(traderID string) string {
+	return "equity_anomaly:" + traderID
+}
+
+// equityJumpKey 交易员净值跳变阈值在系统配置中的键（未设置时使用全局 equity_jump_pct）
+func equityJumpKey(traderID string) string {
+	return "equity_jump_pct:" + traderID
+}
+
+// equityDeviationPct 当前净值相对基准的偏差百分比（基准无效时返回0）
+func equityDeviationPct(previous, current float64) float64 {
+	if previous <= 0 {
+		return 0
+	}
+	return (current - previous) / previous * 100
+}
+
+// GetEquityJumpPct 净值跳变告警阈值（%，0=不启用）
+// 优先级：系统配置 equity_jump_pct:<trader_id> > 全局 equity_jump_pct > 默认30%
+func (at *AutoTrader) GetEquityJumpPct() float64 {
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		for _, key := range []string{equityJumpKey(at.id), "equity_jump_pct"} {
+			value, err := db.GetSystemConfig(key)
+			if err != nil || value == "" {
+				continue
+			}
+			if pct, err := strconv.ParseFloat(value, 64); err == nil && pct >= 0 {
+				return pct
+			}
+		}
+	}
+	return defaultEquityJumpPct
+}
+
+// SetEquityJumpPct 设置交易员的净值跳变告警阈值（0=关闭）
+func (at *AutoTrader) SetEquityJumpPct(pct float64) error {
+	if pct < 0 || pct > 1000 {
+		return fmt.Errorf("阈值必须在 0~1000%% 之间: %.2f", pct)
+	}
+	type SystemConfigSetter interface {
+		SetSystemConfig(key, value string) error
+	}
+	db, ok := at.database.(SystemConfigSetter)
+	if !ok {
+		return fmt.Errorf("数据库不支持系统配置")
+	}
+	if err := db.SetSystemConfig(equityJumpKey(at.id), strconv.FormatFloat(pct, 'f', -1, 64)); err != nil {
+		return fmt.Errorf("保存净值跳变阈值失败: %w", err)
+	}
+	return nil
+}
+
+// restoreEquityAnomaly 启动时恢复未确认的净值异常（继续冻结新开仓）
+func (at *AutoTrader) restoreEquityAnomaly() {
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	db, ok := at.database.(SystemConfigGetter)
+	if !ok {
+		return
+	}
+	value, err := db.GetSystemConfig(equityAnomalyKey(at.id))
+	if err != nil || value == "" {
+		return
+	}
+	var anomaly EquityAnomaly
+	if err := json.Unmarshal([]byte(value), &anomaly); err != nil {
+		log.Printf("⚠️  [%s] 解析净值异常记录失败: %v", at.name, err)
+		return
+	}
+	at.equityGuard.mu.Lock()
+	at.equityGuard.anomaly = &anomaly
+	at.equityGuard.lastEquity = anomaly.PreviousEquity
+	at.equityGuard.mu.Unlock()
+	log.Printf("🧊 [%s] 恢复未确认的净值异常（%.2f → %.2f，%+.1f%%），新开仓保持冻结", at.name, anomaly.PreviousEquity, anomaly.ReportedEquity, anomaly.DeviationPct)
+}
+
+// saveEquityAnomaly 持久化净值异常（nil 表示已确认，写入空值）
+func (at *AutoTrader) saveEquityAnomaly(anomaly *EquityAnomaly) {
+	type SystemConfigSetter interface {
+		SetSystemConfig(key, value string) error
+	}
+	db, ok := at.database.(SystemConfigSetter)
+	if !ok {
+		return
+	}
+	value := ""
+	if anomaly != nil {
+		data, _ := json.Marshal(anomaly)
+		value = string(data)
+	}
+	if err := db.SetSystemConfig(equityAnomalyKey(at.id), value); err != nil {
+		log.Printf("⚠️  [%s] 保存净值异常记录失败: %v", at.name, err)
+	}
+}
+
+// checkEquityAnomaly 比较交易所上报的账户净值与上一次快照，偏差超过阈值时冻结新开仓并告警
+// 冻结期间不更新基准，直到人工确认（确认后以下一周期的净值作为新基准）
+func (at *AutoTrader) checkEquityAnomaly(equity float64) {
+	threshold := at.GetEquityJumpPct()
+
+	at.equityGuard.mu.Lock()
+	if at.equityGuard.anomaly != nil {
+		at.equityGuard.mu.Unlock()
+		return
+	}
+	previous := at.equityGuard.lastEquity
+	deviation := equityDeviationPct(previous, equity)
+	if threshold <= 0 || previous <= 0 || math.Abs(deviation) < threshold {
+		if equity > 0 {
+			at.equityGuard.lastEquity = equity
+		}
+		at.equityGuard.mu.Unlock()
+		return
+	}
+	anomaly := &EquityAnomaly{
+		DetectedAt:     time.Now(),
+		PreviousEquity: previous,
+		ReportedEquity: equity,
+		DeviationPct:   deviation,
+		ThresholdPct:   threshold,
+	}
+	at.equityGuard.anomaly = anomaly
+	at.equityGuard.mu.Unlock()
+
+	at.saveEquityAnomaly(anomaly)
+	detail := fmt.Sprintf("账户净值异常跳变 %.2f → %.2f USDT（%+.1f%%，阈值 %.0f%%），已冻结新开仓，请核实后确认", previous, equity, deviation, threshold)
+	log.Printf("🧊 [%s] %s", at.name, detail)
+	at.noteActivity("🧊 %s", detail)
+	at.publishRiskBreach("", "", "equity_anomaly", detail, true)
+}
+
+// equityFrozen 是否因未确认的净值异常冻结新开仓
+func (at *AutoTrader) equityFrozen() bool {
+	at.equityGuard.mu.Lock()
+	defer at.equityGuard.mu.Unlock()
+	return at.equityGuard.anomaly != nil
+}
+
+// AcknowledgeEquityAnomaly 人工确认净值异常（如确为划转），解除冻结并以下一周期净值作为新基准
+func (at *AutoTrader) AcknowledgeEquityAnomaly(user string) error {
+	at.equityGuard.mu.Lock()
+	anomaly := at.equityGuard.anomaly
+	if anomaly == nil {
+		at.equityGuard.mu.Unlock()
+		return fmt.Errorf("当前没有未确认的净值异常")
+	}
+	at.equityGuard.anomaly = nil
+	at.equityGuard.lastEquity = 0
+	at.equityGuard.mu.Unlock()
+
+	at.saveEquityAnomaly(nil)
+	log.Printf("▶️ [%s] 净值异常（%+.1f%%）已由 %s 确认，恢复开仓", at.name, anomaly.DeviationPct, user)
+	at.noteActivity("净值异常（%.2f → %.2f USDT）已由 %s 确认，恢复开仓", anomaly.PreviousEquity, anomaly.ReportedEquity, user)
+	return nil
+}
+
+// GetEquityGuardStatus 净值异常保护状态
+func (at *AutoTrader) GetEquityGuardStatus() EquityGuardStatus {
+	status := EquityGuardStatus{ThresholdPct: at.GetEquityJumpPct()}
+	at.equityGuard.mu.Lock()
+	defer at.equityGuard.mu.Unlock()
+	status.LastEquity = at.equityGuard.lastEquity
+	if at.equityGuard.anomaly != nil {
+		anomaly := *at.equityGuard.anomaly
+		status.Anomaly = &anomaly
+		status.Frozen = true
+	}
+	return status
+}
+
+// convertFrozenOpens 净值异常未确认时将开仓决策转为 wait（平仓照常执行），返回执行日志
+func convertFrozenOpens(decisions []decision.Decision) []string {
+	var notes []string
+	for i := range decisions {
+		d := &decisions[i]
+		if d.Action != "open_long" && d.Action != "open_short" {
+			continue
+		}
+		notes = append(notes, fmt.Sprintf("🧊 净值异常未确认，%s %s 已转为 wait", d.Symbol, d.Action))
+		d.Reasoning = fmt.Sprintf("[净值异常冻结，原决策 %s] %s", d.Action, d.Reasoning)
+		d.Action = "wait"
+	}
+	return notes
+}
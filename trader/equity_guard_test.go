@@ -0,0 +1,44 @@
+package trader
+
+import (
+	"nofx/decision"
+	"testing"
+)
+
+func TestEquityDeviationPct(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous float64
+		current  float64
+		want     float64
+	}{
+		{"净值翻倍", 1000, 2000, 100},
+		{"净值腰斩", 1000, 500, -50},
+		{"没有基准", 0, 500, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := equityDeviationPct(tt.previous, tt.current); got != tt.want {
+				t.Errorf("equityDeviationPct(%v, %v) = %v, want %v", tt.previous, tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertFrozenOpens(t *testing.T) {
+	decisions := []decision.Decision{
+		{Symbol: "BTCUSDT", Action: "open_long"},
+		{Symbol: "ETHUSDT", Action: "close_short"},
+		{Symbol: "SOLUSDT", Action: "open_short"},
+	}
+	notes := convertFrozenOpens(decisions)
+	if len(notes) != 2 {
+		t.Fatalf("notes = %v, want 2", notes)
+	}
+	want := []string{"wait", "close_short", "wait"}
+	for i, d := range decisions {
+		if d.Action != want[i] {
+			t.Errorf("decisions[%d].Action = %s, want %s", i, d.Action, want[i])
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/events"
+	"nofx/logger"
+	"time"
+)
+
+// isFillAction 会产生成交的决策动作（成交通知通过 order.filled 事件发送）
+func isFillAction(action string) bool {
+	switch action {
+	case "open_long", "open_short", "close_long", "close_short", "partial_close":
+		return true
+	}
+	return false
+}
+
+// subscribeEvents 订阅本交易员的成交/风控事件并转发到通知渠道，返回取消订阅函数
+func (at *AutoTrader) subscribeEvents() func() {
+	unsubFilled := events.Subscribe(events.OrderFilled, "notifier:"+at.id, func(e events.OrderFilledEvent) {
+		if e.TraderID != at.id {
+			return
+		}
+		at.notify(logger.EventTrade, logger.SeverityInfo, "%s %s 成功（数量 %.4f @ %.4f）", e.Symbol, e.Action, e.Quantity, e.Price)
+	})
+	unsubRisk := events.Subscribe(events.RiskBreached, "notifier:"+at.id, func(e events.RiskBreachedEvent) {
+		if e.TraderID != at.id {
+			return
+		}
+		severity := logger.SeverityWarning
+		if e.Critical {
+			severity = logger.SeverityCritical
+		}
+		at.notify(logger.EventRisk, severity, "%s", e.Detail)
+	})
+	return func() {
+		unsubFilled()
+		unsubRisk()
+	}
+}
+
+// publishDecisions 发布AI本周期输出的决策
+func (at *AutoTrader) publishDecisions(decisions []decision.Decision, cycle int) {
+	now := time.Now()
+	for _, d := range decisions {
+		events.Publish(events.DecisionCreated, events.DecisionCreatedEvent{
+			TraderID:   at.id,
+			Cycle:      cycle,
+			Symbol:     d.Symbol,
+			Action:     d.Action,
+			Confidence: d.Confidence,
+			Reasoning:  d.Reasoning,
+			Time:       now,
+		})
+	}
+}
+
+// publishOrderFilled 发布成交事件
+func (at *AutoTrader) publishOrderFilled(actionRecord *logger.DecisionAction, side string, quantity, price float64) {
+	events.Publish(events.OrderFilled, events.OrderFilledEvent{
+		TraderID: at.id,
+		Symbol:   actionRecord.Symbol,
+		Action:   actionRecord.Action,
+		Side:     side,
+		Quantity: quantity,
+		Price:    price,
+		OrderID:  actionRecord.OrderID,
+		Time:     time.Now(),
+	})
+}
+
+// publishRiskBreach 发布风控事件（detail 为完整的通知文案）
+func (at *AutoTrader) publishRiskBreach(symbol, action, rule, detail string, critical bool) {
+	events.Publish(events.RiskBreached, events.RiskBreachedEvent{
+		TraderID: at.id,
+		Symbol:   symbol,
+		Action:   action,
+		Rule:     rule,
+		Detail:   detail,
+		Critical: critical,
+		Time:     time.Now(),
+	})
+}
@@ -0,0 +1,211 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExecErrorClass 交易所执行错误分类
+type ExecErrorClass string
+
+const (
+	ExecErrInsufficientMargin ExecErrorClass = "insufficient_margin"  // 保证金不足
+	ExecErrPriceOutOfBounds   ExecErrorClass = "price_out_of_bounds"  // 价格超出限制（偏离标记价格/触发价无效）
+	ExecErrRateLimit          ExecErrorClass = "rate_limit"           // 请求频率限制
+	ExecErrReduceOnlyRejected ExecErrorClass = "reduce_only_rejected" // 只减仓单被拒（通常是仓位已不存在）
+	ExecErrTimestamp          ExecErrorClass = "timestamp"            // 请求时间戳超出接收窗口（本地时钟偏差）
+	ExecErrUnknown            ExecErrorClass = "unknown"              // 未识别的错误
+)
+
+// RetryStrategy 错误处理策略
+type RetryStrategy string
+
+const (
+	StrategyRetry  RetryStrategy = "retry"  // 原样重试
+	StrategyAdjust RetryStrategy = "adjust" // 调整参数后重试
+	StrategyAbort  RetryStrategy = "abort"  // 放弃该决策
+)
+
+// ExecRetryPolicy 单类错误的处理策略
+type ExecRetryPolicy struct {
+	Strategy     RetryStrategy `json:"strategy"`
+	MaxRetries   int           `json:"max_retries"`   // 最大重试次数
+	Backoff      time.Duration `json:"backoff"`       // 重试间隔（按次数线性递增）
+	AdjustFactor float64       `json:"adjust_factor"` // adjust 策略下仓位缩放系数
+}
+
+// DefaultExecRetryPolicies 默认错误处理矩阵
+func DefaultExecRetryPolicies() map[ExecErrorClass]ExecRetryPolicy {
+	return map[ExecErrorClass]ExecRetryPolicy{
+		ExecErrInsufficientMargin: {Strategy: StrategyAdjust, MaxRetries: 2, Backoff: 500 * time.Millisecond, AdjustFactor: 0.8},
+		ExecErrPriceOutOfBounds:   {Strategy: StrategyRetry, MaxRetries: 1, Backoff: 2 * time.Second},
+		ExecErrRateLimit:          {Strategy: StrategyRetry, MaxRetries: 3, Backoff: 3 * time.Second},
+		ExecErrReduceOnlyRejected: {Strategy: StrategyAbort},
+		ExecErrTimestamp:          {Strategy: StrategyRetry, MaxRetries: 1, Backoff: 500 * time.Millisecond},
+		ExecErrUnknown:            {Strategy: StrategyAbort},
+	}
+}
+
+// ClassifyExecError 根据交易所返回的错误码/错误信息分类
+// 覆盖 Binance/Aster 错误码和 Hyperliquid 的错误文本（Hyperliquid 只返回英文描述，没有错误码）
+func ClassifyExecError(err error) ExecErrorClass {
+	if err == nil {
+		return ""
+	}
+	msg := strings.ToLower(err.Error())
+
+	switch {
+	case containsAny(msg, "-2019", "margin is insufficient", "insufficient margin", "保证金不足", "insufficient balance"):
+		return ExecErrInsufficientMargin
+	case containsAny(msg, "-1003", "-1015", "too many requests", "rate limit", "http 429", "频率", "too many cumulative requests"):
+		return ExecErrRateLimit
+	case containsAny(msg, "-1021", "outside of the recvwindow", "timestamp for this request", "timestamp ahead"):
+		return ExecErrTimestamp
+	case containsAny(msg, "-2022", "-4118", "reduceonly", "reduce only", "reduce-only"):
+		return ExecErrReduceOnlyRejected
+	case containsAny(msg, "-4131", "-4016", "-4024", "-2021", "percent_price", "price too far", "immediately trigger", "price greater than max", "price less than min",
+		"away from the reference price", "could not immediately match", "price must be divisible by tick size"):
+		return ExecErrPriceOutOfBounds
+	default:
+		return ExecErrUnknown
+	}
+}
+
+// containsAny 判断字符串是否包含任一子串
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExecErrorStats 执行错误统计（按错误分类）
+type ExecErrorStats struct {
+	Occurrences map[ExecErrorClass]int `json:"occurrences"` // 出现次数
+	Retries     map[ExecErrorClass]int `json:"retries"`     // 重试次数
+	Recovered   map[ExecErrorClass]int `json:"recovered"`   // 重试/调整后成功次数
+	Aborted     map[ExecErrorClass]int `json:"aborted"`     // 最终放弃次数
+}
+
+// execErrorMetrics 执行错误计数器
+type execErrorMetrics struct {
+	mu    sync.Mutex
+	stats ExecErrorStats
+}
+
+func newExecErrorMetrics() *execErrorMetrics {
+	return &execErrorMetrics{
+		stats: ExecErrorStats{
+			Occurrences: make(map[ExecErrorClass]int),
+			Retries:     make(map[ExecErrorClass]int),
+			Recovered:   make(map[ExecErrorClass]int),
+			Aborted:     make(map[ExecErrorClass]int),
+		},
+	}
+}
+
+func (m *execErrorMetrics) inc(counter map[ExecErrorClass]int, class ExecErrorClass) {
+	m.mu.Lock()
+	counter[class]++
+	m.mu.Unlock()
+}
+
+// snapshot 返回统计副本
+func (m *execErrorMetrics) snapshot() ExecErrorStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	copyMap := func(src map[ExecErrorClass]int) map[ExecErrorClass]int {
+		dst := make(map[ExecErrorClass]int, len(src))
+		for k, v := range src {
+			dst[k] = v
+		}
+		return dst
+	}
+	return ExecErrorStats{
+		Occurrences: copyMap(m.stats.Occurrences),
+		Retries:     copyMap(m.stats.Retries),
+		Recovered:   copyMap(m.stats.Recovered),
+		Aborted:     copyMap(m.stats.Aborted),
+	}
+}
+
+// getExecRetryPolicy 获取某类错误的处理策略（配置优先，缺省使用默认矩阵）
+func (at *AutoTrader) getExecRetryPolicy(class ExecErrorClass) ExecRetryPolicy {
+	if policy, ok := at.config.ExecRetryPolicies[class]; ok {
+		return policy
+	}
+	return DefaultExecRetryPolicies()[class]
+}
+
+// executeDecisionWithRetry 按错误处理矩阵执行决策：可重试的错误重试，可调整的错误缩小仓位后重试，其余放弃
+func (at *AutoTrader) executeDecisionWithRetry(d *decision.Decision, actionRecord *logger.DecisionAction) error {
+	// 交易所维护中不下单（管理类操作暂缓到维护结束后执行）
+	if at.inMaintenance() {
+		return at.holdForMaintenance(d)
+	}
+
+	attempts := make(map[ExecErrorClass]int)
+	var lastClass ExecErrorClass
+
+	// 每次重试都基于原始决策的副本执行：开仓路径会就地修改仓位（波动率目标等），
+	// 直接复用 d 会让仓位在每次重试时被重复缩小；adjust 策略只在上次实际下单仓位上缩放
+	original := *d
+	size := original.PositionSizeUSD
+
+	for {
+		attempt := original
+		attempt.PositionSizeUSD = size
+		err := at.executeDecisionWithRecord(&attempt, actionRecord)
+		*d = attempt
+		if err == nil {
+			if lastClass != "" {
+				at.execErrors.inc(at.execErrors.stats.Recovered, lastClass)
+			}
+			return nil
+		}
+
+		class := ClassifyExecError(err)
+		lastClass = class
+		at.execErrors.inc(at.execErrors.stats.Occurrences, class)
+		policy := at.getExecRetryPolicy(class)
+
+		if policy.Strategy == StrategyAbort || attempts[class] >= policy.MaxRetries {
+			at.execErrors.inc(at.execErrors.stats.Aborted, class)
+			return fmt.Errorf("[%s] %w", class, err)
+		}
+
+		if policy.Strategy == StrategyAdjust {
+			// 只有开仓决策有可调整的仓位参数
+			if (d.Action != "open_long" && d.Action != "open_short") || policy.AdjustFactor <= 0 || policy.AdjustFactor >= 1 {
+				at.execErrors.inc(at.execErrors.stats.Aborted, class)
+				return fmt.Errorf("[%s] %w", class, err)
+			}
+			size = d.PositionSizeUSD * policy.AdjustFactor
+			log.Printf("  🔧 %s %s 遇到 %s，仓位 %.2f → %.2f USDT 后重试", d.Symbol, d.Action, class, d.PositionSizeUSD, size)
+		}
+
+		if class == ExecErrTimestamp {
+			// 时间戳错误说明时钟偏移已变化，重新同步后再重试
+			at.syncExchangeTime(true)
+		}
+
+		attempts[class]++
+		at.execErrors.inc(at.execErrors.stats.Retries, class)
+		wait := policy.Backoff * time.Duration(attempts[class])
+		log.Printf("  🔁 %s %s 遇到 %s，%v 后第 %d 次重试: %v", d.Symbol, d.Action, class, wait, attempts[class], err)
+		time.Sleep(wait)
+	}
+}
+
+// GetExecErrorStats 获取执行错误统计
+func (at *AutoTrader) GetExecErrorStats() ExecErrorStats {
+	return at.execErrors.snapshot()
+}
@@ -0,0 +1,84 @@
+package trader
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClassifyExecError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected ExecErrorClass
+	}{
+		{
+			name:     "币安保证金不足",
+			err:      errors.New("开多仓失败: <APIError> code=-2019, msg=Margin is insufficient."),
+			expected: ExecErrInsufficientMargin,
+		},
+		{
+			name:     "本地保证金校验",
+			err:      errors.New("❌ 保证金不足: 需要 20.00 USDT（保证金 19.99 + 手续费 0.01），可用 10.00 USDT"),
+			expected: ExecErrInsufficientMargin,
+		},
+		{
+			name:     "频率限制",
+			err:      errors.New("<APIError> code=-1003, msg=Too many requests"),
+			expected: ExecErrRateLimit,
+		},
+		{
+			name:     "只减仓单被拒",
+			err:      errors.New("平多仓失败: <APIError> code=-2022, msg=ReduceOnly Order is rejected."),
+			expected: ExecErrReduceOnlyRejected,
+		},
+		{
+			name:     "价格超出限制",
+			err:      errors.New("<APIError> code=-4131, msg=The counterparty's best price does not meet the PERCENT_PRICE filter limit."),
+			expected: ExecErrPriceOutOfBounds,
+		},
+		{
+			name:     "时间戳超出接收窗口",
+			err:      errors.New("<APIError> code=-1021, msg=Timestamp for this request is outside of the recvWindow."),
+			expected: ExecErrTimestamp,
+		},
+		{
+			name:     "Hyperliquid保证金不足",
+			err:      errors.New("开多仓失败: Insufficient margin to place order. asset=0"),
+			expected: ExecErrInsufficientMargin,
+		},
+		{
+			name:     "Hyperliquid价格偏离参考价",
+			err:      errors.New("开空仓失败: Order price cannot be more than 80% away from the reference price"),
+			expected: ExecErrPriceOutOfBounds,
+		},
+		{
+			name:     "Hyperliquid频率限制",
+			err:      errors.New("Too many cumulative requests sent"),
+			expected: ExecErrRateLimit,
+		},
+		{
+			name:     "Hyperliquid只减仓单被拒",
+			err:      errors.New("平多仓失败: Reduce only order would increase position"),
+			expected: ExecErrReduceOnlyRejected,
+		},
+		{
+			name:     "未知错误",
+			err:      errors.New("connection reset by peer"),
+			expected: ExecErrUnknown,
+		},
+		{
+			name:     "nil错误",
+			err:      nil,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ClassifyExecError(tt.err)
+			if result != tt.expected {
+				t.Errorf("ClassifyExecError(%v) = %q, want %q", tt.err, result, tt.expected)
+			}
+		})
+	}
+}
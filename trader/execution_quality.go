@@ -0,0 +1,99 @@
+package trader
+
+import (
+	"log"
+	"nofx/logger"
+	"nofx/market"
+	"strconv"
+	"time"
+)
+
+// orderFill 从下单返回中解析成交均价和成交数量（币安/Aster返回 avgPrice/executedQty，Hyperliquid不返回）
+func orderFill(order map[string]interface{}) (price, quantity float64) {
+	parse := func(v interface{}) float64 {
+		switch val := v.(type) {
+		case float64:
+			return val
+		case string:
+			f, _ := strconv.ParseFloat(val, 64)
+			return f
+		}
+		return 0
+	}
+	return parse(order["avgPrice"]), parse(order["executedQty"])
+}
+
+// captureDepthSnapshot 下单前快照前20档盘口并附加到决策记录（获取失败只记日志，不影响下单）
+func (at *AutoTrader) captureDepthSnapshot(actionRecord *logger.DecisionAction) {
+	book, err := market.GetDepthSnapshot(actionRecord.Symbol)
+	if err != nil {
+		log.Printf("  ⚠️ 获取 %s 盘口快照失败: %v", actionRecord.Symbol, err)
+		return
+	}
+	actionRecord.Depth = newDepthSnapshot(book)
+}
+
+// newDepthSnapshot 盘口转换为决策记录中的快照格式
+func newDepthSnapshot(book *market.OrderBook) *logger.DepthSnapshot {
+	toPairs := func(levels []market.OrderBookLevel) [][2]float64 {
+		pairs := make([][2]float64, 0, len(levels))
+		for _, l := range levels {
+			pairs = append(pairs, [2]float64{l.Price, l.Quantity})
+		}
+		return pairs
+	}
+	return &logger.DepthSnapshot{
+		Time:        book.Time,
+		Bids:        toPairs(book.Bids),
+		Asks:        toPairs(book.Asks),
+		SpreadBps:   book.SpreadBps(),
+		BidDepthUSD: book.DepthUSD("bid"),
+		AskDepthUSD: book.DepthUSD("ask"),
+	}
+}
+
+// recordExecution 下单成功后记录执行质量（side: buy/sell）
+// 预期价格=AI决策时价格；交易所未返回成交均价时用下单后市价近似
+func (at *AutoTrader) recordExecution(actionRecord *logger.DecisionAction, order map[string]interface{}, side string) {
+	actionRecord.Orders = orderRefs(order)
+	fillPrice, quantity := orderFill(order)
+	if quantity <= 0 {
+		quantity = actionRecord.Quantity
+	}
+	fillSource := "exchange"
+	if fillPrice <= 0 {
+		price, err := at.trader.GetMarketPrice(actionRecord.Symbol)
+		if err != nil {
+			at.publishOrderFilled(actionRecord, side, quantity, actionRecord.Price)
+			return
+		}
+		fillPrice = price
+		fillSource = "market_price"
+	}
+	actionRecord.FillPrice = fillPrice
+	at.publishOrderFilled(actionRecord, side, quantity, fillPrice)
+
+	rec := logger.ExecutionRecord{
+		Time:          time.Now(),
+		Symbol:        actionRecord.Symbol,
+		Action:        actionRecord.Action,
+		Side:          side,
+		Quantity:      quantity,
+		DecisionPrice: at.decisionPrices[actionRecord.Symbol],
+		ArrivalPrice:  actionRecord.Price,
+		FillPrice:     fillPrice,
+		FillSource:    fillSource,
+	}
+	if depth := actionRecord.Depth; depth != nil {
+		rec.SpreadBps = depth.SpreadBps
+		rec.BookDepthUSD = depth.SideDepthUSD(side)
+	}
+	if err := at.executionQuality.Record(rec); err != nil {
+		log.Printf("⚠️  记录执行质量失败: %v", err)
+	}
+}
+
+// GetExecutionQualityReport 获取执行质量报告（滑点按币种/仓位大小/时段汇总）
+func (at *AutoTrader) GetExecutionQualityReport(since time.Time) logger.ExecutionQualityReport {
+	return at.executionQuality.Report(since)
+}
@@ -0,0 +1,161 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/loglevel"
+	"nofx/market"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFlowWindow       = 15 * time.Minute // 资金流变化的观察窗口
+	defaultFlowOITriggerPct = 3.0              // 窗口内持仓量上升超过 N% 且价格逆向时触发
+	defaultFlowDeltaTrigger = 0.3              // 窗口内逆向净主动成交占比超过该值且价格逆向时触发
+)
+
+// flowSample 持仓量和价格采样
+type flowSample struct {
+	at    time.Time
+	oi    float64
+	price float64
+}
+
+// flowTracker 单个持仓的资金流滚动采样
+type flowTracker struct {
+	samples   []flowSample
+	triggered bool // 本锚定期内已触发过紧急周期
+}
+
+// observe 记录一次采样，返回相对窗口内最早采样的持仓量和价格变化（采样跨度不足半个窗口时 ready=false）
+func (t *flowTracker) observe(sample flowSample, window time.Duration) (oiPct, pricePct float64, ready bool) {
+	t.samples = append(t.samples, sample)
+	cutoff := sample.at.Add(-window)
+	i := 0
+	for i < len(t.samples)-1 && t.samples[i].at.Before(cutoff) {
+		i++
+	}
+	t.samples = t.samples[i:]
+
+	base := t.samples[0]
+	if sample.at.Sub(base.at) < window/2 || base.oi <= 0 || base.price <= 0 {
+		return 0, 0, false
+	}
+	return (sample.oi - base.oi) / base.oi * 100, (sample.price - base.price) / base.price * 100, true
+}
+
+// flowWatchConfig 观察窗口（系统配置 flow_window_minutes）、持仓量触发阈值（flow_oi_trigger_pct，0=关闭）
+// 和净主动成交占比触发阈值（flow_delta_trigger，0=关闭）
+func (at *AutoTrader) flowWatchConfig() (time.Duration, float64, float64) {
+	window, oiTrigger, deltaTrigger := defaultFlowWindow, defaultFlowOITriggerPct, defaultFlowDeltaTrigger
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	db, ok := at.database.(SystemConfigGetter)
+	if !ok {
+		return window, oiTrigger, deltaTrigger
+	}
+	if value, err := db.GetSystemConfig("flow_window_minutes"); err == nil && value != "" {
+		if minutes, err := strconv.Atoi(value); err == nil && minutes > 0 {
+			window = time.Duration(minutes) * time.Minute
+		}
+	}
+	if value, err := db.GetSystemConfig("flow_oi_trigger_pct"); err == nil && value != "" {
+		if n, err := strconv.ParseFloat(value, 64); err == nil && n >= 0 {
+			oiTrigger = n
+		}
+	}
+	if value, err := db.GetSystemConfig("flow_delta_trigger"); err == nil && value != "" {
+		if n, err := strconv.ParseFloat(value, 64); err == nil && n >= 0 && n <= 1 {
+			deltaTrigger = n
+		}
+	}
+	return window, oiTrigger, deltaTrigger
+}
+
+// adverseFlow 资金流是否与持仓方向相反：价格逆向的同时持仓量明显上升（对手方加仓），
+// 或主动成交明显偏向对手方。返回触发原因，未触发返回空字符串
+func adverseFlow(side string, oiPct, pricePct, delta, oiTrigger, deltaTrigger float64) string {
+	against, deltaAgainst, aggressor := pricePct < 0, -delta, "卖出"
+	if side == "short" {
+		against, deltaAgainst, aggressor = pricePct > 0, delta, "买入"
+	}
+	if !against {
+		return ""
+	}
+
+	var reasons []string
+	if oiTrigger > 0 && oiPct >= oiTrigger {
+		reasons = append(reasons, fmt.Sprintf("持仓量上升 %.2f%% 而价格逆向 %+.2f%%", oiPct, pricePct))
+	}
+	if deltaTrigger > 0 && deltaAgainst >= deltaTrigger {
+		reasons = append(reasons, fmt.Sprintf("主动%s净占比 %.0f%%", aggressor, deltaAgainst*100))
+	}
+	return strings.Join(reasons, "，")
+}
+
+// watchPositionFlow 采样持仓币种的持仓量和主动成交量，资金流转为逆向时排队紧急持仓管理周期
+func (at *AutoTrader) watchPositionFlow(watch *PositionWatch, key string) {
+	window, oiTrigger, deltaTrigger := at.flowWatchConfig()
+	if oiTrigger <= 0 && deltaTrigger <= 0 {
+		return
+	}
+	snapshot, err := market.GetFlowSnapshot(watch.Symbol, window)
+	if err != nil {
+		loglevel.Sampledf(loglevel.Market, loglevel.LevelWarn, "flow_"+watch.Symbol, loglevel.DefaultSampleInterval,
+			"⚠️  [%s] %s 资金流采样失败: %v", at.name, watch.Symbol, err)
+		return
+	}
+
+	at.positionWatch.mu.Lock()
+	tracker, ok := at.positionWatch.flows[key]
+	if !ok {
+		tracker = &flowTracker{}
+		at.positionWatch.flows[key] = tracker
+	}
+	oiPct, pricePct, ready := tracker.observe(flowSample{at: snapshot.Time, oi: snapshot.OpenInterest, price: snapshot.Price}, window)
+	watch.OIChangePct, watch.PriceChangePct, watch.VolumeDelta = oiPct, pricePct, snapshot.DeltaRatio()
+	if ready {
+		watch.AdverseFlow = adverseFlow(watch.Side, oiPct, pricePct, watch.VolumeDelta, oiTrigger, deltaTrigger)
+	}
+	fire := watch.AdverseFlow != "" && !tracker.triggered
+	if fire {
+		tracker.triggered = true
+	}
+	at.positionWatch.mu.Unlock()
+
+	if fire {
+		at.triggerFlowEmergency(*watch, window)
+	}
+}
+
+// triggerFlowEmergency 排队一个只针对该持仓币种的紧急管理周期（复用警报定向周期）
+func (at *AutoTrader) triggerFlowEmergency(watch PositionWatch, window time.Duration) {
+	message := fmt.Sprintf("%s %s 资金流逆向（近 %v）：%s", watch.Symbol, watch.Side, window, watch.AdverseFlow)
+	err := at.TriggerAlertCycle(decision.AlertTrigger{
+		Rule:    "position_adverse_flow",
+		Symbol:  watch.Symbol,
+		Message: message,
+		Price:   watch.MarkPrice,
+		Value:   watch.OIChangePct,
+		Details: map[string]string{
+			"side":             watch.Side,
+			"oi_change_pct":    strconv.FormatFloat(watch.OIChangePct, 'f', 2, 64),
+			"price_change_pct": strconv.FormatFloat(watch.PriceChangePct, 'f', 2, 64),
+			"volume_delta":     strconv.FormatFloat(watch.VolumeDelta, 'f', 2, 64),
+		},
+	})
+	if err != nil {
+		log.Printf("⚠️  [%s] 资金流紧急持仓管理周期未排队: %v", at.name, err)
+		return
+	}
+
+	at.positionWatch.mu.Lock()
+	at.positionWatch.flowAlerts++
+	at.positionWatch.mu.Unlock()
+	at.noteActivity("🌊 %s，触发紧急持仓管理周期", message)
+	at.publishRiskBreach(watch.Symbol, "", "position_adverse_flow", "🌊 "+message+"，已触发紧急持仓管理周期", false)
+}
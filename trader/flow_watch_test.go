@@ -0,0 +1,67 @@
+package trader
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlowTrackerObserve(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	window := 10 * time.Minute
+	tracker := &flowTracker{}
+
+	if _, _, ready := tracker.observe(flowSample{at: start, oi: 1000, price: 100}, window); ready {
+		t.Fatal("只有一个采样时不应就绪")
+	}
+	if _, _, ready := tracker.observe(flowSample{at: start.Add(4 * time.Minute), oi: 1010, price: 99}, window); ready {
+		t.Fatal("采样跨度不足半个窗口时不应就绪")
+	}
+	oiPct, pricePct, ready := tracker.observe(flowSample{at: start.Add(6 * time.Minute), oi: 1050, price: 98}, window)
+	if !ready || math.Abs(oiPct-5) > 1e-9 || math.Abs(pricePct+2) > 1e-9 {
+		t.Errorf("observe = %v, %v, %v, want 5, -2, true", oiPct, pricePct, ready)
+	}
+
+	// 超出窗口的采样被丢弃，变化改为相对窗口内最早采样计算
+	oiPct, _, ready = tracker.observe(flowSample{at: start.Add(12 * time.Minute), oi: 1111, price: 98}, window)
+	if !ready || math.Abs(oiPct-10) > 1e-9 {
+		t.Errorf("窗口滚动后 oiPct = %v, ready = %v, want 10, true", oiPct, ready)
+	}
+	if len(tracker.samples) != 3 {
+		t.Errorf("保留采样数 = %d, want 3", len(tracker.samples))
+	}
+}
+
+func TestAdverseFlow(t *testing.T) {
+	tests := []struct {
+		name     string
+		side     string
+		oiPct    float64
+		pricePct float64
+		delta    float64
+		want     string // 触发原因需包含的内容，空=不触发
+	}{
+		{name: "多仓：价格下跌且OI上升", side: "long", oiPct: 4, pricePct: -1, want: "持仓量上升"},
+		{name: "多仓：主动卖出主导", side: "long", oiPct: 0, pricePct: -0.5, delta: -0.5, want: "主动卖出"},
+		{name: "多仓：价格上涨时OI上升不触发", side: "long", oiPct: 10, pricePct: 1, delta: -0.9},
+		{name: "多仓：OI上升不足", side: "long", oiPct: 2, pricePct: -1, delta: -0.1},
+		{name: "空仓：价格上涨且主动买入主导", side: "short", oiPct: 0, pricePct: 1, delta: 0.4, want: "主动买入"},
+		{name: "空仓：价格下跌不触发", side: "short", oiPct: 8, pricePct: -1, delta: 0.9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := adverseFlow(tt.side, tt.oiPct, tt.pricePct, tt.delta, defaultFlowOITriggerPct, defaultFlowDeltaTrigger)
+			if tt.want == "" && got != "" {
+				t.Errorf("不应触发，得到 %q", got)
+			}
+			if tt.want != "" && !strings.Contains(got, tt.want) {
+				t.Errorf("adverseFlow = %q, want 包含 %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := adverseFlow("long", 10, -5, -0.9, 0, 0); got != "" {
+		t.Errorf("阈值为0时应关闭，得到 %q", got)
+	}
+}
@@ -0,0 +1,87 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/market"
+	"strconv"
+	"time"
+)
+
+// fundingEntryBlockKey 交易员"资金费结算前不逆费率开仓"窗口在系统配置中的键（未设置时使用全局 funding_block_minutes）
+func fundingEntryBlockKey(traderID string) string {
+	return "funding_block_minutes:" + traderID
+}
+
+// GetFundingEntryBlockMinutes 获取资金费结算前禁止逆费率开仓的分钟数（0=不启用）
+// 优先级：交易员配置 > 系统配置 funding_block_minutes:<trader_id> > 全局 funding_block_minutes
+func (at *AutoTrader) GetFundingEntryBlockMinutes() int {
+	if at.config.FundingEntryBlockMinutes > 0 {
+		return at.config.FundingEntryBlockMinutes
+	}
+
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		for _, key := range []string{fundingEntryBlockKey(at.id), "funding_block_minutes"} {
+			value, err := db.GetSystemConfig(key)
+			if err != nil || value == "" {
+				continue
+			}
+			if minutes, err := strconv.Atoi(value); err == nil && minutes >= 0 {
+				return minutes
+			}
+		}
+	}
+	return 0
+}
+
+// SetFundingEntryBlockMinutes 设置交易员的资金费结算前开仓限制窗口（0=关闭）
+func (at *AutoTrader) SetFundingEntryBlockMinutes(minutes int) error {
+	if minutes < 0 || minutes > 8*60 {
+		return fmt.Errorf("窗口必须在 0~480 分钟之间: %d", minutes)
+	}
+	type SystemConfigSetter interface {
+		SetSystemConfig(key, value string) error
+	}
+	db, ok := at.database.(SystemConfigSetter)
+	if !ok {
+		return fmt.Errorf("数据库不支持系统配置")
+	}
+	if err := db.SetSystemConfig(fundingEntryBlockKey(at.id), strconv.Itoa(minutes)); err != nil {
+		return fmt.Errorf("保存资金费开仓窗口失败: %w", err)
+	}
+	return nil
+}
+
+// fundingAgainstPosition 持仓方向是否需要支付资金费（费率为正多头付费，为负空头付费）
+func fundingAgainstPosition(action string, fundingRate float64) bool {
+	switch action {
+	case "open_long":
+		return fundingRate > 0
+	case "open_short":
+		return fundingRate < 0
+	}
+	return false
+}
+
+// fundingEntryBlocked 开仓是否落在结算前窗口内且方向需要付费
+func fundingEntryBlocked(action string, fundingRate float64, untilFunding, window time.Duration) bool {
+	return window > 0 && untilFunding <= window && fundingAgainstPosition(action, fundingRate)
+}
+
+// checkFundingEntryTiming 资金费结算前不逆费率开仓：返回延迟原因（为空表示允许开仓）
+// 结算后的周期重新评估，避免刚开仓就支付一次资金费
+func (at *AutoTrader) checkFundingEntryTiming(d *decision.Decision, data *market.Data) string {
+	minutes := at.GetFundingEntryBlockMinutes()
+	if minutes <= 0 || data == nil {
+		return ""
+	}
+	countdown, ok := data.FundingCountdown()
+	if !ok || !fundingEntryBlocked(d.Action, data.FundingRate, countdown, time.Duration(minutes)*time.Minute) {
+		return ""
+	}
+	return fmt.Sprintf("距资金费结算 %d 分钟（费率 %.4f%% 对该方向不利，窗口 %d 分钟），延迟到结算后再开仓",
+		int(countdown.Minutes()), data.FundingRate*100, minutes)
+}
@@ -0,0 +1,32 @@
+package trader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFundingEntryBlocked(t *testing.T) {
+	window := 30 * time.Minute
+	tests := []struct {
+		name    string
+		action  string
+		rate    float64
+		until   time.Duration
+		window  time.Duration
+		blocked bool
+	}{
+		{"正费率结算前开多", "open_long", 0.0005, 10 * time.Minute, window, true},
+		{"正费率结算前开空收取资金费", "open_short", 0.0005, 10 * time.Minute, window, false},
+		{"负费率结算前开空", "open_short", -0.0003, 29 * time.Minute, window, true},
+		{"窗口之外", "open_long", 0.0005, 45 * time.Minute, window, false},
+		{"未启用", "open_long", 0.0005, time.Minute, 0, false},
+		{"平仓不受限制", "close_long", 0.0005, time.Minute, window, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fundingEntryBlocked(tt.action, tt.rate, tt.until, tt.window); got != tt.blocked {
+				t.Errorf("fundingEntryBlocked = %v, want %v", got, tt.blocked)
+			}
+		})
+	}
+}
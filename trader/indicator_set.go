@@ -0,0 +1,67 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"nofx/market"
+	"strings"
+)
+
+// indicatorSetKey 交易员指标集在系统配置中的键（未设置时使用全局 indicator_set）
+func indicatorSetKey(traderID string) string {
+	return "indicator_set:" + traderID
+}
+
+// GetIndicatorSet 获取交易员指标集：交易员配置 > 全局 indicator_set > 空（只输出固定指标）
+func (at *AutoTrader) GetIndicatorSet() []market.IndicatorDef {
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	db, ok := at.database.(SystemConfigGetter)
+	if !ok {
+		return nil
+	}
+	for _, key := range []string{indicatorSetKey(at.id), "indicator_set"} {
+		value, err := db.GetSystemConfig(key)
+		if err != nil || value == "" {
+			continue
+		}
+		var defs []market.IndicatorDef
+		if err := json.Unmarshal([]byte(value), &defs); err != nil {
+			log.Printf("⚠️  [%s] 解析指标集 %s 失败: %v", at.name, key, err)
+			continue
+		}
+		normalized, err := market.NormalizeIndicatorSet(defs)
+		if err != nil {
+			log.Printf("⚠️  [%s] 指标集 %s 无效: %v", at.name, key, err)
+			continue
+		}
+		return normalized
+	}
+	return nil
+}
+
+// UpdateIndicatorSet 校验并保存交易员指标集（空列表表示只输出固定指标）
+func (at *AutoTrader) UpdateIndicatorSet(defs []market.IndicatorDef) ([]market.IndicatorDef, error) {
+	normalized, err := market.NormalizeIndicatorSet(defs)
+	if err != nil {
+		return nil, err
+	}
+	if outside := indicatorsOutside(normalized, at.GetTimeframes()); len(outside) > 0 {
+		return nil, fmt.Errorf("指标 %s 的K线周期不在交易员分析周期（%s）内", strings.Join(outside, ", "), strings.Join(at.GetTimeframes(), " / "))
+	}
+	type SystemConfigSetter interface {
+		SetSystemConfig(key, value string) error
+	}
+	db, ok := at.database.(SystemConfigSetter)
+	if !ok {
+		return nil, fmt.Errorf("当前数据库不支持保存指标集")
+	}
+	data, _ := json.Marshal(normalized)
+	if err := db.SetSystemConfig(indicatorSetKey(at.id), string(data)); err != nil {
+		return nil, fmt.Errorf("保存指标集失败: %w", err)
+	}
+	log.Printf("📐 [%s] 指标集已更新: %d 个指标", at.name, len(normalized))
+	return normalized, nil
+}
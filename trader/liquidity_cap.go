@@ -0,0 +1,38 @@
+package trader
+
+import (
+	"nofx/decision"
+	"strconv"
+)
+
+// 默认流动性仓位上限：单币种仓位不超过持仓量价值的0.1%、24小时成交额的0.1%
+const (
+	defaultLiquidityMaxOIPct     = 0.1
+	defaultLiquidityMaxVolumePct = 0.1
+)
+
+// getLiquidityLimits 获取流动性仓位上限（系统配置 liquidity_max_oi_pct / liquidity_max_volume_pct，设为0关闭对应维度）
+func (at *AutoTrader) getLiquidityLimits() decision.LiquidityLimits {
+	limits := decision.LiquidityLimits{
+		MaxOIPct:     defaultLiquidityMaxOIPct,
+		MaxVolumePct: defaultLiquidityMaxVolumePct,
+	}
+
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		if value, err := db.GetSystemConfig("liquidity_max_oi_pct"); err == nil && value != "" {
+			if pct, err := strconv.ParseFloat(value, 64); err == nil && pct >= 0 {
+				limits.MaxOIPct = pct
+			}
+		}
+		if value, err := db.GetSystemConfig("liquidity_max_volume_pct"); err == nil && value != "" {
+			if pct, err := strconv.ParseFloat(value, 64); err == nil && pct >= 0 {
+				limits.MaxVolumePct = pct
+			}
+		}
+	}
+
+	return limits
+}
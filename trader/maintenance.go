@@ -0,0 +1,383 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"nofx/decision"
+	"nofx/logger"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maintenanceWindowsKey  = "maintenance_windows" // 维护窗口列表（全局，按交易所区分）
+	maintenanceNoticeAhead = 30 * time.Minute      // 维护开始前提前通知
+	maintenanceHistory     = 7 * 24 * time.Hour    // 已结束的维护窗口保留时长
+	exchangeStatusTTL      = time.Minute           // 交易所状态接口缓存时长
+	// binanceSystemStatusURL 币安系统状态接口（现货/钱包 SAPI）。币安合约没有公开的系统状态接口，
+	// 该接口只在全站升级时与合约维护一致，因此默认不查询（maintenance_check=true 时启用），合约维护以录入的公告窗口为准
+	binanceSystemStatusURL = "https://api.binance.com/sapi/v1/system/status"
+)
+
+// 维护窗口来源
+const (
+	MaintenanceSourceAnnouncement = "announcement" // 人工录入的交易所维护公告
+	MaintenanceSourceStatus       = "status"       // 交易所状态接口检测到维护
+)
+
+// MaintenanceWindow 交易所维护窗口（期间暂停下单，管理类操作暂缓到维护结束后执行）
+type MaintenanceWindow struct {
+	ID       string    `json:"id"`
+	Exchange string    `json:"exchange"` // binance / hyperliquid / aster（为空表示所有交易所）
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"` // 状态接口检测到的维护没有预计结束时间（零值）
+	Source   string    `json:"source"`
+	Note     string    `json:"note,omitempty"`
+}
+
+// activeAt 时间是否处于维护窗口内
+func (w MaintenanceWindow) activeAt(t time.Time) bool {
+	return !t.Before(w.Start) && (w.End.IsZero() || t.Before(w.End))
+}
+
+// appliesTo 维护窗口是否影响该交易所
+func (w MaintenanceWindow) appliesTo(exchange string) bool {
+	return w.Exchange == "" || w.Exchange == exchange
+}
+
+// MaintenanceStatus 交易员的维护状态
+type MaintenanceStatus struct {
+	Exchange string              `json:"exchange"`
+	Active   *MaintenanceWindow  `json:"active,omitempty"` // 当前生效的维护窗口
+	Upcoming []MaintenanceWindow `json:"upcoming"`         // 尚未开始的维护窗口
+	Held     []QueuedDecision    `json:"held"`             // 维护期间暂缓执行的管理操作
+}
+
+// maintenanceState 维护状态跟踪（进入/结束维护的通知和对账）
+type maintenanceState struct {
+	mu      sync.Mutex
+	active  *MaintenanceWindow
+	noticed map[string]bool // 已发送预告通知的窗口
+}
+
+// current 最近一个决策周期检测到的维护窗口（健康检查使用，不查询交易所）
+func (s *maintenanceState) current() *MaintenanceWindow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active
+}
+
+// MaintenanceConfigStore 维护窗口的存储（系统配置）
+type MaintenanceConfigStore interface {
+	GetSystemConfig(key string) (string, error)
+	SetSystemConfig(key, value string) error
+}
+
+// ListMaintenanceWindows 已录入的维护窗口（按开始时间排序）
+func ListMaintenanceWindows(db MaintenanceConfigStore) ([]MaintenanceWindow, error) {
+	value, err := db.GetSystemConfig(maintenanceWindowsKey)
+	if err != nil || value == "" {
+		return []MaintenanceWindow{}, nil
+	}
+	var windows []MaintenanceWindow
+	if err := json.Unmarshal([]byte(value), &windows); err != nil {
+		return nil, fmt.Errorf("解析维护窗口失败: %w", err)
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i].Start.Before(windows[j].Start) })
+	return windows, nil
+}
+
+// AddMaintenanceWindow 录入维护公告（同时清理结束超过7天的窗口）
+func AddMaintenanceWindow(db MaintenanceConfigStore, w MaintenanceWindow) (MaintenanceWindow, error) {
+	w.Exchange = strings.ToLower(strings.TrimSpace(w.Exchange))
+	switch w.Exchange {
+	case "", "binance", "hyperliquid", "aster":
+	default:
+		return w, fmt.Errorf("不支持的交易所: %s", w.Exchange)
+	}
+	if w.Start.IsZero() || w.End.IsZero() || !w.End.After(w.Start) {
+		return w, fmt.Errorf("维护窗口必须指定开始和结束时间，且结束晚于开始")
+	}
+	if w.End.Before(time.Now()) {
+		return w, fmt.Errorf("维护窗口已结束")
+	}
+	windows, err := ListMaintenanceWindows(db)
+	if err != nil {
+		return w, err
+	}
+	w.ID = fmt.Sprintf("mw%d", time.Now().UnixNano())
+	w.Source = MaintenanceSourceAnnouncement
+
+	kept := []MaintenanceWindow{w}
+	for _, existing := range windows {
+		if time.Since(existing.End) < maintenanceHistory {
+			kept = append(kept, existing)
+		}
+	}
+	if err := saveMaintenanceWindows(db, kept); err != nil {
+		return w, err
+	}
+	log.Printf("🛠 录入维护窗口 %s: %s %s ~ %s %s", w.ID, exchangeLabel(w.Exchange), w.Start.Format("01-02 15:04"), w.End.Format("01-02 15:04"), w.Note)
+	return w, nil
+}
+
+// RemoveMaintenanceWindow 删除维护窗口（公告取消或录入错误）
+func RemoveMaintenanceWindow(db MaintenanceConfigStore, id string) error {
+	windows, err := ListMaintenanceWindows(db)
+	if err != nil {
+		return err
+	}
+	kept := windows[:0]
+	for _, w := range windows {
+		if w.ID != id {
+			kept = append(kept, w)
+		}
+	}
+	if len(kept) == len(windows) {
+		return fmt.Errorf("维护窗口 %s 不存在", id)
+	}
+	return saveMaintenanceWindows(db, kept)
+}
+
+func saveMaintenanceWindows(db MaintenanceConfigStore, windows []MaintenanceWindow) error {
+	data, _ := json.Marshal(windows)
+	if err := db.SetSystemConfig(maintenanceWindowsKey, string(data)); err != nil {
+		return fmt.Errorf("保存维护窗口失败: %w", err)
+	}
+	return nil
+}
+
+func exchangeLabel(exchange string) string {
+	if exchange == "" {
+		return "所有交易所"
+	}
+	return exchange
+}
+
+// exchangeStatusCache 交易所状态接口检测结果（进程内共享）
+var exchangeStatusCache = struct {
+	sync.Mutex
+	checkedAt time.Time
+	since     time.Time // 检测到维护的时间（零值=正常）
+}{}
+
+// binanceMaintenanceSince 币安系统状态接口检测到的维护开始时间（正常或查询失败时返回零值，缓存1分钟）
+func binanceMaintenanceSince() time.Time {
+	c := &exchangeStatusCache
+	c.Lock()
+	defer c.Unlock()
+	if time.Since(c.checkedAt) < exchangeStatusTTL {
+		return c.since
+	}
+	c.checkedAt = time.Now()
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(binanceSystemStatusURL)
+	if err != nil {
+		return c.since // 查询失败时沿用上次结果
+	}
+	defer resp.Body.Close()
+	var status struct {
+		Status int    `json:"status"` // 0=正常 1=维护中
+		Msg    string `json:"msg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return c.since
+	}
+	switch {
+	case status.Status == 1 && c.since.IsZero():
+		c.since = time.Now()
+	case status.Status == 0:
+		c.since = time.Time{}
+	}
+	return c.since
+}
+
+// maintenanceStatusCheckEnabled 是否查询币安系统状态接口（系统配置 maintenance_check，默认关闭：该接口反映的是现货系统状态）
+func (at *AutoTrader) maintenanceStatusCheckEnabled() bool {
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		if value, err := db.GetSystemConfig("maintenance_check"); err == nil && value == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// maintenanceWindows 当前生效的维护窗口和即将开始的维护窗口（本交易员所在交易所）
+func (at *AutoTrader) maintenanceWindows(now time.Time) (*MaintenanceWindow, []MaintenanceWindow) {
+	var active *MaintenanceWindow
+	upcoming := []MaintenanceWindow{}
+	if db, ok := at.database.(MaintenanceConfigStore); ok {
+		windows, err := ListMaintenanceWindows(db)
+		if err != nil {
+			log.Printf("⚠️  [%s] %v", at.name, err)
+		}
+		for i := range windows {
+			w := windows[i]
+			switch {
+			case !w.appliesTo(at.exchange):
+			case w.activeAt(now):
+				if active == nil {
+					active = &w
+				}
+			case w.Start.After(now):
+				upcoming = append(upcoming, w)
+			}
+		}
+	}
+	if active == nil && at.exchange == "binance" && at.maintenanceStatusCheckEnabled() {
+		if since := binanceMaintenanceSince(); !since.IsZero() {
+			active = &MaintenanceWindow{ID: "binance-status", Exchange: "binance", Start: since, Source: MaintenanceSourceStatus, Note: "系统状态接口返回维护中"}
+		}
+	}
+	return active, upcoming
+}
+
+// inMaintenance 交易所是否处于维护中（下单前检查）
+func (at *AutoTrader) inMaintenance() bool {
+	active, _ := at.maintenanceWindows(time.Now())
+	return active != nil
+}
+
+// checkMaintenance 决策周期开始时检查维护状态：预告/进入维护时通知，维护结束后先对账再执行暂缓的操作
+// 返回 true 表示维护中，本周期跳过
+func (at *AutoTrader) checkMaintenance() bool {
+	now := time.Now()
+	active, upcoming := at.maintenanceWindows(now)
+
+	state := &at.maintenance
+	state.mu.Lock()
+	if state.noticed == nil {
+		state.noticed = make(map[string]bool)
+	}
+	var notices []MaintenanceWindow
+	for _, w := range upcoming {
+		if w.Start.Sub(now) <= maintenanceNoticeAhead && !state.noticed[w.ID] {
+			state.noticed[w.ID] = true
+			notices = append(notices, w)
+		}
+	}
+	previous := state.active
+	state.active = active
+	state.mu.Unlock()
+
+	for _, w := range notices {
+		at.notify(logger.EventSystem, logger.SeverityWarning, "🛠 %s 将于 %s 开始维护（预计 %s 结束），维护期间暂停下单 %s",
+			exchangeLabel(w.Exchange), w.Start.Format("15:04"), w.End.Format("15:04"), w.Note)
+	}
+
+	switch {
+	case active != nil && previous == nil:
+		until := "结束时间未知"
+		if !active.End.IsZero() {
+			until = "预计 " + active.End.Format("01-02 15:04") + " 结束"
+		}
+		log.Printf("🛠 [%s] 交易所维护中（%s），暂停下单，平仓/止损调整将暂缓到维护结束后执行", at.name, until)
+		at.noteActivity("交易所维护开始（%s），暂停下单", until)
+		at.notify(logger.EventSystem, logger.SeverityWarning, "🛠 %s 维护开始（%s），暂停下单；管理类操作将在维护结束后执行", exchangeLabel(active.Exchange), until)
+		return true
+	case active != nil:
+		log.Printf("🛠 [%s] 交易所维护中，跳过本周期", at.name)
+		return true
+	case previous != nil:
+		at.resumeAfterMaintenance(*previous)
+	}
+	return false
+}
+
+// holdForMaintenance 维护期间的下单请求：开仓拒绝，平仓/止损止盈调整暂缓入队，维护结束后执行
+func (at *AutoTrader) holdForMaintenance(d *decision.Decision) error {
+	switch d.Action {
+	case "hold", "wait":
+		return nil
+	case "open_long", "open_short":
+		return fmt.Errorf("交易所维护中，暂停开仓")
+	}
+	item := at.enqueueDecision(*d, at.callCount, QueueStatusHeld)
+	log.Printf("🛠 [%s] 交易所维护中，%s %s 暂缓到维护结束后执行 (id=%s)", at.name, d.Symbol, d.Action, item.ID)
+	at.journal(item, "held", systemOperator, "交易所维护中，暂缓执行", true)
+	return fmt.Errorf("交易所维护中，已暂缓到维护结束后执行 (id=%s)", item.ID)
+}
+
+// resumeAfterMaintenance 维护结束：核验持仓止损（维护期间可能被撤单），再按入队顺序执行暂缓的管理操作
+func (at *AutoTrader) resumeAfterMaintenance(w MaintenanceWindow) {
+	log.Printf("✅ [%s] 交易所维护结束，开始对账", at.name)
+
+	// 1. 对账：持仓仍在且有已知止损的，重新核验止损单（缺失时补挂）
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		log.Printf("⚠️  [%s] 维护结束后获取持仓失败: %v", at.name, err)
+	}
+	at.syncStopLosses(positions)
+	verified := 0
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		quantity, _ := pos["positionAmt"].(float64)
+		if quantity < 0 {
+			quantity = -quantity
+		}
+		if stopPrice, ok := at.getStopLoss(symbol, side); ok && quantity > 0 {
+			at.startStopWatchdog(symbol, side, quantity, stopPrice, true)
+			verified++
+		}
+	}
+
+	// 2. 执行暂缓的管理操作（持仓已不存在的平仓会在执行时校验失败）
+	held := at.takeHeldDecisions()
+	var results []string
+	for _, item := range held {
+		d := item.Decision
+		_, err := at.executeQueuedDecision(&d)
+		at.finishQueuedDecision(item.ID, err)
+		detail, mark := "维护结束后执行成功", "✓"
+		if err != nil {
+			detail, mark = "维护结束后执行失败: "+err.Error(), "❌"
+		}
+		at.journal(item, "execute", systemOperator, detail, err == nil)
+		results = append(results, fmt.Sprintf("%s %s %s", d.Symbol, d.Action, mark))
+	}
+
+	summary := fmt.Sprintf("核验 %d 个持仓止损，执行暂缓操作 %d 个", verified, len(held))
+	if len(results) > 0 {
+		summary += "（" + strings.Join(results, "，") + "）"
+	}
+	log.Printf("✅ [%s] 维护结束对账完成: %s", at.name, summary)
+	at.noteActivity("交易所维护结束，%s", summary)
+	at.notify(logger.EventSystem, logger.SeverityWarning, "✅ %s 维护结束（%s 开始），恢复下单；%s", exchangeLabel(w.Exchange), w.Start.Format("15:04"), summary)
+}
+
+// takeHeldDecisions 取出维护期间暂缓的决策（状态改为执行中）
+func (at *AutoTrader) takeHeldDecisions() []QueuedDecision {
+	q := &at.decisionQueue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var held []QueuedDecision
+	for _, item := range q.items {
+		if item.Status == QueueStatusHeld {
+			item.Status = QueueStatusExecuting
+			item.UpdatedAt = time.Now()
+			held = append(held, *item)
+		}
+	}
+	return held
+}
+
+// GetMaintenanceStatus 交易员所在交易所的维护状态及暂缓的操作
+func (at *AutoTrader) GetMaintenanceStatus() MaintenanceStatus {
+	active, upcoming := at.maintenanceWindows(time.Now())
+	status := MaintenanceStatus{Exchange: at.exchange, Active: active, Upcoming: upcoming, Held: []QueuedDecision{}}
+	for _, item := range at.GetDecisionQueue() {
+		if item.Status == QueueStatusHeld {
+			status.Held = append(status.Held, item)
+		}
+	}
+	return status
+}
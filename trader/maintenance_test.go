@@ -0,0 +1,107 @@
+package trader
+
+import (
+	"nofx/decision"
+	"testing"
+	"time"
+)
+
+// memoryConfig 内存系统配置
+type memoryConfig map[string]string
+
+func (m memoryConfig) GetSystemConfig(key string) (string, error) { return m[key], nil }
+func (m memoryConfig) SetSystemConfig(key, value string) error {
+	m[key] = value
+	return nil
+}
+
+func TestMaintenanceWindowActiveAt(t *testing.T) {
+	start := time.Date(2025, 6, 1, 2, 0, 0, 0, time.UTC)
+	w := MaintenanceWindow{Start: start, End: start.Add(time.Hour)}
+	open := MaintenanceWindow{Start: start}
+	tests := []struct {
+		name   string
+		window MaintenanceWindow
+		at     time.Time
+		want   bool
+	}{
+		{"开始前", w, start.Add(-time.Minute), false},
+		{"开始时刻", w, start, true},
+		{"窗口内", w, start.Add(30 * time.Minute), true},
+		{"结束时刻已恢复", w, start.Add(time.Hour), false},
+		{"无结束时间持续生效", open, start.Add(24 * time.Hour), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.activeAt(tt.at); got != tt.want {
+				t.Errorf("activeAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddMaintenanceWindow(t *testing.T) {
+	now := time.Now()
+	db := memoryConfig{}
+	old := MaintenanceWindow{ID: "old", Start: now.Add(-10 * 24 * time.Hour), End: now.Add(-9 * 24 * time.Hour)}
+	if err := saveMaintenanceWindows(db, []MaintenanceWindow{old}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		window  MaintenanceWindow
+		wantErr bool
+	}{
+		{"有效公告", MaintenanceWindow{Exchange: "Binance", Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)}, false},
+		{"缺少结束时间", MaintenanceWindow{Start: now.Add(time.Hour)}, true},
+		{"结束早于开始", MaintenanceWindow{Start: now.Add(2 * time.Hour), End: now.Add(time.Hour)}, true},
+		{"已结束", MaintenanceWindow{Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)}, true},
+		{"不支持的交易所", MaintenanceWindow{Exchange: "okx", Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := AddMaintenanceWindow(db, tt.window)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddMaintenanceWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (w.ID == "" || w.Exchange != "binance" || w.Source != MaintenanceSourceAnnouncement) {
+				t.Errorf("AddMaintenanceWindow() = %+v", w)
+			}
+		})
+	}
+
+	windows, _ := ListMaintenanceWindows(db)
+	if len(windows) != 1 || windows[0].ID == "old" {
+		t.Errorf("结束超过7天的窗口应被清理, got %+v", windows)
+	}
+	if err := RemoveMaintenanceWindow(db, windows[0].ID); err != nil {
+		t.Errorf("RemoveMaintenanceWindow() error = %v", err)
+	}
+	if err := RemoveMaintenanceWindow(db, "missing"); err == nil {
+		t.Errorf("删除不存在的窗口应返回错误")
+	}
+}
+
+func TestMaintenanceBlocksDirectCloses(t *testing.T) {
+	now := time.Now()
+	db := memoryConfig{}
+	active := MaintenanceWindow{ID: "mw1", Exchange: "binance", Start: now.Add(-time.Minute), End: now.Add(time.Hour)}
+	if err := saveMaintenanceWindows(db, []MaintenanceWindow{active}); err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakeTrader{positions: []map[string]interface{}{{"symbol": "BTCUSDT", "side": "short", "positionAmt": -0.5}}}
+	at := &AutoTrader{trader: fake, exchange: "binance", database: db}
+
+	openLong := decision.Decision{Symbol: "BTCUSDT", Action: "open_long", Leverage: 5, PositionSizeUSD: 100}
+	if record, err := at.closeOppositePositionForFlip(&openLong); err == nil || record != nil {
+		t.Errorf("维护中翻仓应直接拒绝: record=%+v err=%v", record, err)
+	}
+	if err := at.emergencyClosePosition("BTCUSDT", "short"); err == nil {
+		t.Error("维护中紧急平仓应返回错误")
+	}
+	at.cleanupDustPositions()
+	if len(fake.closed) != 0 {
+		t.Errorf("维护中不应下单平仓, closed = %v", fake.closed)
+	}
+}
@@ -0,0 +1,272 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+	"sync"
+	"time"
+)
+
+// 开仓下单策略
+const (
+	ExecutionPolicyMarket         = "market"          // 始终市价开仓
+	ExecutionPolicyHint           = "hint"            // 仅当AI给出 routing.post_only 时挂Maker单（默认）
+	ExecutionPolicyMakerPreferred = "maker_preferred" // 除 routing.urgency=high 外都优先挂Maker单
+)
+
+const (
+	defaultMakerTimeout     = 30 * time.Second
+	defaultMakerMaxChaseBps = 15.0
+	makerPollInterval       = 2 * time.Second
+	takerFeeRate            = 0.0004 // 标准Taker费率 0.04%
+	makerFeeRate            = 0.0002 // 标准Maker费率 0.02%
+)
+
+// makerOrderPlacer 支持Maker挂单的交易器（目前仅币安实现，其他平台回退市价）
+type makerOrderPlacer interface {
+	PlacePostOnlyOrder(symbol, positionSide string, quantity, price float64) (int64, error)
+	GetOrderFill(symbol string, orderID int64) (status string, executedQty, avgPrice float64, err error)
+	CancelOrder(symbol string, orderID int64) error
+}
+
+// MakerStats Maker挂单统计（成交率、回退次数、手续费节省）
+type MakerStats struct {
+	Attempts        int     `json:"attempts"`         // Maker挂单次数
+	FullFills       int     `json:"full_fills"`       // 全部成交
+	PartialFills    int     `json:"partial_fills"`    // 部分成交
+	Unfilled        int     `json:"unfilled"`         // 完全未成交
+	MarketFallbacks int     `json:"market_fallbacks"` // 未成交部分回退市价
+	Aborted         int     `json:"aborted"`          // 价格偏离过大放弃开仓
+	Rejected        int     `json:"rejected"`         // 挂单被拒（如会立即吃单），直接市价
+	MakerNotional   float64 `json:"maker_notional"`   // Maker成交名义价值
+	FeeSavingsUSD   float64 `json:"fee_savings_usd"`  // 相对Taker的手续费节省估算
+	FillRatePct     float64 `json:"fill_rate_pct"`    // Maker成交数量占挂单数量比例
+	requestedQtySum float64 // 挂单总数量（计算成交率）
+	filledQtySum    float64 // Maker成交总数量
+}
+
+// makerStatsTracker 线程安全的Maker统计
+type makerStatsTracker struct {
+	mu    sync.Mutex
+	stats MakerStats
+}
+
+// snapshot 获取统计副本
+func (m *makerStatsTracker) snapshot() MakerStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.stats
+	if s.requestedQtySum > 0 {
+		s.FillRatePct = s.filledQtySum / s.requestedQtySum * 100
+	}
+	return s
+}
+
+// update 修改统计
+func (m *makerStatsTracker) update(fn func(s *MakerStats)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn(&m.stats)
+}
+
+// getExecutionPolicy 获取开仓下单策略（交易员配置 > 系统配置 execution_policy > hint）
+func (at *AutoTrader) getExecutionPolicy() string {
+	policy := at.config.ExecutionPolicy
+	if policy == "" {
+		type SystemConfigGetter interface {
+			GetSystemConfig(key string) (string, error)
+		}
+		if db, ok := at.database.(SystemConfigGetter); ok {
+			policy, _ = db.GetSystemConfig("execution_policy")
+		}
+	}
+
+	switch policy {
+	case ExecutionPolicyMarket, ExecutionPolicyMakerPreferred:
+		return policy
+	}
+	return ExecutionPolicyHint
+}
+
+// shouldUseMakerEntry 是否以Maker挂单开仓
+func (at *AutoTrader) shouldUseMakerEntry(d *decision.Decision) bool {
+	if _, ok := at.trader.(makerOrderPlacer); !ok {
+		return false
+	}
+	if d.Routing != nil && d.Routing.Urgency == "high" {
+		return false
+	}
+	switch at.getExecutionPolicy() {
+	case ExecutionPolicyMakerPreferred:
+		return true
+	case ExecutionPolicyHint:
+		return d.Routing != nil && d.Routing.PostOnly
+	}
+	return false
+}
+
+// placeEntryOrder 开仓下单：按策略选择Maker挂单或市价，返回订单信息和实际成交数量
+// side: long/short
+func (at *AutoTrader) placeEntryOrder(d *decision.Decision, side string, quantity float64, leverage int, arrivalPrice float64) (map[string]interface{}, float64, error) {
+	if !at.shouldUseMakerEntry(d) {
+		order, err := at.marketEntry(d.Symbol, side, quantity, leverage)
+		return order, quantity, err
+	}
+	return at.makerEntry(d, side, quantity, leverage, arrivalPrice)
+}
+
+// marketEntry 市价开仓
+func (at *AutoTrader) marketEntry(symbol, side string, quantity float64, leverage int) (map[string]interface{}, error) {
+	if side == "long" {
+		return at.trader.OpenLong(symbol, quantity, leverage)
+	}
+	return at.trader.OpenShort(symbol, quantity, leverage)
+}
+
+// makerEntry Maker挂单开仓，超时未成交时按回退规则处理：
+// 1. 挂单被拒（会立即吃单）→ 直接市价
+// 2. 超时后价格相对挂单价的不利偏离 ≤ MakerMaxChaseBps → 剩余数量市价补齐
+// 3. 偏离过大 → 放弃剩余数量（已部分成交则按实际成交数量继续，完全未成交则放弃开仓）
+func (at *AutoTrader) makerEntry(d *decision.Decision, side string, quantity float64, leverage int, arrivalPrice float64) (map[string]interface{}, float64, error) {
+	placer := at.trader.(makerOrderPlacer)
+	positionSide := "LONG"
+	if side == "short" {
+		positionSide = "SHORT"
+	}
+
+	// 挂单价：AI给出的限价在被动方向时使用，否则使用当前价
+	limitPrice := arrivalPrice
+	if d.Routing != nil && d.Routing.LimitPrice > 0 {
+		if (side == "long" && d.Routing.LimitPrice <= arrivalPrice) || (side == "short" && d.Routing.LimitPrice >= arrivalPrice) {
+			limitPrice = d.Routing.LimitPrice
+		}
+	}
+
+	timeout := at.config.MakerTimeout
+	if timeout <= 0 {
+		timeout = defaultMakerTimeout
+	}
+	maxChaseBps := at.config.MakerMaxChaseBps
+	if maxChaseBps <= 0 {
+		maxChaseBps = defaultMakerMaxChaseBps
+	}
+
+	orderID, err := placer.PlacePostOnlyOrder(d.Symbol, positionSide, quantity, limitPrice)
+	if err != nil {
+		log.Printf("  ⚠ Maker挂单失败，回退市价: %v", err)
+		at.makerStats.update(func(s *MakerStats) { s.Rejected++ })
+		order, err := at.marketEntry(d.Symbol, side, quantity, leverage)
+		return order, quantity, err
+	}
+
+	// 轮询成交状态
+	status, filledQty, makerAvgPrice := "", 0.0, 0.0
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(makerPollInterval)
+		status, filledQty, makerAvgPrice, err = placer.GetOrderFill(d.Symbol, orderID)
+		if err == nil && status == "FILLED" {
+			break
+		}
+	}
+
+	if status != "FILLED" {
+		if err := placer.CancelOrder(d.Symbol, orderID); err != nil {
+			log.Printf("  ⚠ 取消未成交Maker挂单失败: %v", err)
+		}
+		// 取消后再查一次，避免漏掉取消前的成交
+		if s, q, p, err := placer.GetOrderFill(d.Symbol, orderID); err == nil {
+			status, filledQty, makerAvgPrice = s, q, p
+		}
+	}
+
+	at.makerStats.update(func(s *MakerStats) {
+		s.Attempts++
+		s.requestedQtySum += quantity
+		s.filledQtySum += filledQty
+		s.MakerNotional += filledQty * makerAvgPrice
+		s.FeeSavingsUSD += filledQty * makerAvgPrice * (takerFeeRate - makerFeeRate)
+		switch {
+		case filledQty >= quantity || status == "FILLED":
+			s.FullFills++
+		case filledQty > 0:
+			s.PartialFills++
+		default:
+			s.Unfilled++
+		}
+	})
+
+	result := map[string]interface{}{
+		"orderId":     orderID,
+		"symbol":      d.Symbol,
+		"status":      status,
+		"avgPrice":    makerAvgPrice,
+		"executedQty": filledQty,
+	}
+	makerRef := newOrderRef(result, "maker")
+	result["orders"] = []logger.OrderRef{makerRef}
+	if status == "FILLED" || filledQty >= quantity {
+		log.Printf("  ✓ Maker挂单全部成交: 数量 %.4f 均价 %.4f", filledQty, makerAvgPrice)
+		return result, filledQty, nil
+	}
+
+	// 回退规则：比较当前价与挂单价的不利偏离
+	remaining := quantity - filledQty
+	currentPrice, err := at.trader.GetMarketPrice(d.Symbol)
+	if err != nil {
+		currentPrice = arrivalPrice
+	}
+	driftBps := (currentPrice - limitPrice) / limitPrice * 10000
+	if side == "short" {
+		driftBps = -driftBps
+	}
+
+	if driftBps <= maxChaseBps && remaining*currentPrice >= 10 {
+		log.Printf("  ↪ Maker挂单成交 %.4f/%.4f，价格偏离 %.1fbps ≤ %.1fbps，剩余 %.4f 市价补齐",
+			filledQty, quantity, driftBps, maxChaseBps, remaining)
+		at.makerStats.update(func(s *MakerStats) { s.MarketFallbacks++ })
+		order, err := at.marketEntry(d.Symbol, side, remaining, leverage)
+		if err != nil {
+			if filledQty > 0 {
+				log.Printf("  ⚠ 市价补齐失败，按已成交数量继续: %v", err)
+				return result, filledQty, nil
+			}
+			return nil, 0, err
+		}
+		takerPrice, takerQty := orderFill(order)
+		if takerQty <= 0 {
+			takerQty = remaining
+		}
+		if takerPrice <= 0 {
+			takerPrice = currentPrice
+		}
+		totalQty := filledQty + takerQty
+		result["orderId"] = order["orderId"]
+		result["orders"] = []logger.OrderRef{makerRef, newOrderRef(order, "market")}
+		result["status"] = "FILLED"
+		result["avgPrice"] = (filledQty*makerAvgPrice + takerQty*takerPrice) / totalQty
+		result["executedQty"] = totalQty
+		return result, totalQty, nil
+	}
+
+	if filledQty > 0 {
+		log.Printf("  ⚠ Maker挂单部分成交 %.4f/%.4f，价格偏离 %.1fbps，放弃剩余数量", filledQty, quantity, driftBps)
+		return result, filledQty, nil
+	}
+
+	at.makerStats.update(func(s *MakerStats) { s.Aborted++ })
+	return nil, 0, fmt.Errorf("❌ Maker挂单 %s 内未成交且价格已偏离 %.1fbps（上限 %.1fbps），放弃开仓", timeout, driftBps, maxChaseBps)
+}
+
+// GetExecutionPolicy 获取当前生效的开仓下单策略
+func (at *AutoTrader) GetExecutionPolicy() string {
+	return at.getExecutionPolicy()
+}
+
+// GetMakerStats 获取Maker挂单统计
+func (at *AutoTrader) GetMakerStats() MakerStats {
+	return at.makerStats.snapshot()
+}
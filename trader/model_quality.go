@@ -0,0 +1,180 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+	"sync"
+	"time"
+)
+
+const (
+	maxRecentModelIncidents  = 50
+	templateSwitchFailures   = 3 // 连续不合规次数达到该值时自动切换模板
+	templateSwitchMinSamples = 5 // 候选模板至少需要的样本数
+)
+
+// ModelQualityReport 模型质量问题统计（决策与实际持仓/prompt不符）
+type ModelQualityReport struct {
+	TotalDecisions     int                  `json:"total_decisions"`      // 已校验的决策数
+	TotalIncidents     int                  `json:"total_incidents"`      // 问题总数
+	IncidentRatePct    float64              `json:"incident_rate_pct"`    // 问题决策占比
+	CyclesWithIncident int                  `json:"cycles_with_incident"` // 出现问题的周期数
+	ByType             map[string]int       `json:"by_type"`              // 按问题类型统计
+	Recent             []ModelIncidentEntry `json:"recent"`               // 最近的问题（新的在前）
+}
+
+// ModelIncidentEntry 带周期信息的模型质量问题
+type ModelIncidentEntry struct {
+	Time        time.Time `json:"time"`
+	CycleNumber int       `json:"cycle_number"`
+	logger.ModelIncident
+}
+
+// TemplateConformance 交易员在某个模板下的输出合规计数（用于模板灰度与对照组比较）
+type TemplateConformance struct {
+	Responses  int `json:"responses"`
+	Conformant int `json:"conformant"`
+}
+
+// modelQualityTracker 线程安全的模型质量问题统计
+type modelQualityTracker struct {
+	mu         sync.Mutex
+	report     ModelQualityReport
+	byTemplate map[string]TemplateConformance
+}
+
+// recordTemplate 累计本交易员在模板下的合规计数
+func (m *modelQualityTracker) recordTemplate(template string, conformant bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.byTemplate == nil {
+		m.byTemplate = make(map[string]TemplateConformance)
+	}
+	counts := m.byTemplate[template]
+	counts.Responses++
+	if conformant {
+		counts.Conformant++
+	}
+	m.byTemplate[template] = counts
+}
+
+// templateCounts 本交易员在模板下的合规计数
+func (m *modelQualityTracker) templateCounts(template string) TemplateConformance {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.byTemplate[template]
+}
+
+// record 记录一个周期的校验结果
+func (m *modelQualityTracker) record(cycle, decisionCount int, incidents []logger.ModelIncident) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r := &m.report
+	if r.ByType == nil {
+		r.ByType = make(map[string]int)
+	}
+	r.TotalDecisions += decisionCount
+	r.TotalIncidents += len(incidents)
+	if len(incidents) > 0 {
+		r.CyclesWithIncident++
+	}
+	now := time.Now()
+	for _, incident := range incidents {
+		r.ByType[incident.Type]++
+		r.Recent = append([]ModelIncidentEntry{{Time: now, CycleNumber: cycle, ModelIncident: incident}}, r.Recent...)
+	}
+	if len(r.Recent) > maxRecentModelIncidents {
+		r.Recent = r.Recent[:maxRecentModelIncidents]
+	}
+}
+
+// snapshot 获取统计副本
+func (m *modelQualityTracker) snapshot() ModelQualityReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r := m.report
+	r.ByType = make(map[string]int, len(m.report.ByType))
+	for k, v := range m.report.ByType {
+		r.ByType[k] = v
+	}
+	r.Recent = append([]ModelIncidentEntry(nil), m.report.Recent...)
+	if r.TotalDecisions > 0 {
+		r.IncidentRatePct = float64(r.TotalIncidents) / float64(r.TotalDecisions) * 100
+	}
+	return r
+}
+
+// recordModelIncidents 将决策校验发现的问题写入决策记录并累计统计
+func (at *AutoTrader) recordModelIncidents(record *logger.DecisionRecord, decisions []decision.Decision, incidents []decision.DecisionIncident) {
+	for _, incident := range incidents {
+		record.ModelIncidents = append(record.ModelIncidents, logger.ModelIncident{
+			Symbol: incident.Symbol,
+			Action: incident.Action,
+			Type:   incident.Type,
+			Detail: incident.Detail,
+		})
+	}
+	at.modelQuality.record(record.CycleNumber, len(decisions), record.ModelIncidents)
+}
+
+// GetModelQualityReport 获取模型质量问题统计
+func (at *AutoTrader) GetModelQualityReport() ModelQualityReport {
+	return at.modelQuality.snapshot()
+}
+
+// GetTemplateConformance 获取本交易员启动以来在指定模板下的合规计数
+func (at *AutoTrader) GetTemplateConformance(template string) TemplateConformance {
+	return at.modelQuality.templateCounts(template)
+}
+
+// conformanceModel 合规统计使用的模型标识（自定义API使用具体模型名）
+func (at *AutoTrader) conformanceModel() string {
+	if at.aiModel == "custom" && at.config.CustomModelName != "" {
+		return at.config.CustomModelName
+	}
+	return at.aiModel
+}
+
+// isTemplateAutoSwitchEnabled 是否在连续输出不合规时自动切换模板（交易员配置 > 系统配置 template_auto_switch）
+func (at *AutoTrader) isTemplateAutoSwitchEnabled() bool {
+	if at.config.TemplateAutoSwitch {
+		return true
+	}
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		value, _ := db.GetSystemConfig("template_auto_switch")
+		return value == "true"
+	}
+	return false
+}
+
+// recordConformance 记录AI输出格式合规情况，连续不合规时按需切换到更合规的模板
+func (at *AutoTrader) recordConformance(record *logger.DecisionRecord, c decision.Conformance) {
+	record.ConformanceIssues = c.Issues
+	template := at.systemPromptTemplate
+	stats := decision.RecordConformance(at.conformanceModel(), template, c)
+	at.modelQuality.recordTemplate(template, c.Conformant())
+	if c.Conformant() {
+		return
+	}
+	log.Printf("⚠️  AI输出格式不合规 [模型: %s, 模板: %s]: %v（连续 %d 次，合规率 %.1f%%）",
+		stats.Model, template, c.Issues, stats.ConsecutiveFailures, stats.ConformancePct)
+
+	if stats.ConsecutiveFailures < templateSwitchFailures || !at.isTemplateAutoSwitchEnabled() {
+		return
+	}
+	next := decision.SelectConformantTemplate(stats.Model, template, decision.GetAllPromptTemplateNames(), templateSwitchMinSamples)
+	if next == "" {
+		return
+	}
+	at.SetSystemPromptTemplate(next)
+	log.Printf("🔀 [%s] 模板 %s 连续 %d 次输出不合规，自动切换到模板 %s", at.name, template, stats.ConsecutiveFailures, next)
+	record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("模板 %s 连续输出不合规，自动切换到 %s", template, next))
+}
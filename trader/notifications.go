@@ -0,0 +1,71 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"nofx/logger"
+)
+
+// notificationPrefsKey 交易员通知偏好在系统配置中的键（未设置时使用全局默认 notification_prefs）
+func notificationPrefsKey(traderID string) string {
+	return "notification_prefs:" + traderID
+}
+
+// loadNotificationPrefs 从系统配置加载通知偏好：交易员配置 > 全局 notification_prefs > 内置默认
+func (at *AutoTrader) loadNotificationPrefs() logger.NotificationPrefs {
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	db, ok := at.database.(SystemConfigGetter)
+	if !ok {
+		return logger.DefaultNotificationPrefs()
+	}
+	for _, key := range []string{notificationPrefsKey(at.id), "notification_prefs"} {
+		value, err := db.GetSystemConfig(key)
+		if err != nil || value == "" {
+			continue
+		}
+		prefs := logger.DefaultNotificationPrefs()
+		if err := json.Unmarshal([]byte(value), &prefs); err != nil {
+			log.Printf("⚠️  [%s] 解析通知偏好 %s 失败: %v", at.name, key, err)
+			continue
+		}
+		if err := prefs.Normalize(); err != nil {
+			log.Printf("⚠️  [%s] 通知偏好 %s 无效: %v", at.name, key, err)
+			continue
+		}
+		return prefs
+	}
+	return logger.DefaultNotificationPrefs()
+}
+
+// GetNotificationPrefs 获取通知偏好和推送统计
+func (at *AutoTrader) GetNotificationPrefs() (logger.NotificationPrefs, logger.NotifierStats) {
+	return at.notifier.Prefs(), at.notifier.Stats()
+}
+
+// UpdateNotificationPrefs 更新并持久化通知偏好
+func (at *AutoTrader) UpdateNotificationPrefs(prefs logger.NotificationPrefs) error {
+	if err := prefs.Normalize(); err != nil {
+		return err
+	}
+	type SystemConfigSetter interface {
+		SetSystemConfig(key, value string) error
+	}
+	if db, ok := at.database.(SystemConfigSetter); ok {
+		data, _ := json.Marshal(prefs)
+		if err := db.SetSystemConfig(notificationPrefsKey(at.id), string(data)); err != nil {
+			return fmt.Errorf("保存通知偏好失败: %w", err)
+		}
+	}
+	return at.notifier.SetPrefs(prefs)
+}
+
+// notify 按通知偏好推送事件
+func (at *AutoTrader) notify(event, severity, format string, args ...interface{}) {
+	if at.notifier == nil {
+		return
+	}
+	at.notifier.Notify(event, severity, format, args...)
+}
@@ -0,0 +1,204 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxOpenRiskPct 默认总开放风险上限（占净值百分比）
+const defaultMaxOpenRiskPct = 5.0
+
+// OpenRisk 账户总开放风险：所有持仓同时打到止损时的合计亏损
+type OpenRisk struct {
+	TotalRiskUSD float64            `json:"total_risk_usd"` // 合计风险（USDT）
+	TotalRiskPct float64            `json:"total_risk_pct"` // 占净值百分比
+	MaxRiskPct   float64            `json:"max_risk_pct"`   // 上限（占净值百分比）
+	PositionRisk map[string]float64 `json:"position_risk"`  // 各持仓风险 (symbol_side -> USDT)
+	Unprotected  []string           `json:"unprotected"`    // 没有已知止损、按强平价/保证金估算的持仓
+}
+
+// recordStopLoss 记录持仓当前止损价（开仓/调整止损时调用）
+func (at *AutoTrader) recordStopLoss(symbol, side string, stopPrice float64) {
+	at.stopLossMutex.Lock()
+	defer at.stopLossMutex.Unlock()
+
+	if stopPrice > 0 {
+		at.stopLossPrices[symbol+"_"+side] = stopPrice
+	} else {
+		delete(at.stopLossPrices, symbol+"_"+side)
+	}
+}
+
+// getStopLoss 获取持仓已知的止损价
+func (at *AutoTrader) getStopLoss(symbol, side string) (float64, bool) {
+	at.stopLossMutex.RLock()
+	defer at.stopLossMutex.RUnlock()
+
+	price, ok := at.stopLossPrices[symbol+"_"+side]
+	return price, ok
+}
+
+// syncStopLosses 为没有已知止损的持仓从交易所挂着的止损单补齐止损价
+// 止损价只保存在内存中，重启后（或共用账户中其他交易员开的仓）需从交易所恢复，否则开放风险按强平价估算
+func (at *AutoTrader) syncStopLosses(positions []map[string]interface{}) {
+	reader, ok := at.trader.(stopOrderReader)
+	if !ok {
+		return
+	}
+
+	missing := make(map[string][]string) // symbol -> 缺少止损的持仓方向
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		if symbol == "" || side == "" {
+			continue
+		}
+		if _, known := at.getStopLoss(symbol, side); !known {
+			missing[symbol] = append(missing[symbol], side)
+		}
+	}
+
+	for symbol, sides := range missing {
+		stops, err := reader.GetStopOrders(symbol)
+		if err != nil {
+			log.Printf("⚠️  [%s] 获取 %s 止损单失败: %v", at.name, symbol, err)
+			continue
+		}
+		for _, side := range sides {
+			if stopPrice := exchangeStopPrice(stops, side, len(sides) == 1); stopPrice > 0 {
+				at.recordStopLoss(symbol, side, stopPrice)
+				log.Printf("  🛡 [%s] 从交易所恢复 %s %s 止损价 %.4f", at.name, symbol, side, stopPrice)
+			}
+		}
+	}
+}
+
+// exchangeStopPrice 交易所止损单中对应方向最先触发的止损价（多单取最高、空单取最低，没有时返回0）
+// 单向持仓模式下止损单方向为 BOTH，该币种只有一个持仓时归属该持仓
+func exchangeStopPrice(stops []StopOrderInfo, side string, onlySide bool) float64 {
+	best := 0.0
+	for _, stop := range stops {
+		if stop.StopPrice <= 0 {
+			continue
+		}
+		if !strings.EqualFold(stop.PositionSide, side) && !(onlySide && stop.PositionSide == "BOTH") {
+			continue
+		}
+		if best == 0 || (side == "long" && stop.StopPrice > best) || (side == "short" && stop.StopPrice < best) {
+			best = stop.StopPrice
+		}
+	}
+	return best
+}
+
+// getMaxOpenRiskPct 总开放风险上限：配置优先，其次风险偏好档位，再次 system_config 的 max_open_risk_pct，最后默认 5%
+func (at *AutoTrader) getMaxOpenRiskPct() float64 {
+	if at.config.MaxOpenRiskPct > 0 {
+		return at.config.MaxOpenRiskPct
+	}
+	if persona := at.getRiskPersona(); persona != nil {
+		return persona.MaxOpenRiskPct
+	}
+
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		if value, err := db.GetSystemConfig("max_open_risk_pct"); err == nil && value != "" {
+			if pct, err := strconv.ParseFloat(value, 64); err == nil && pct > 0 {
+				return pct
+			}
+		}
+	}
+
+	return defaultMaxOpenRiskPct
+}
+
+// calculateOpenRisk 汇总所有持仓的止损风险：Σ |入场价 - 止损价| × 数量
+// 没有已知止损的持仓按强平价估算（没有强平价时按保证金估算），视为最坏情况
+func (at *AutoTrader) calculateOpenRisk(positions []map[string]interface{}, totalEquity float64) OpenRisk {
+	return at.calculateOpenRiskWith(positions, totalEquity, at.getStopLoss)
+}
+
+// calculateOpenRiskWith 按给定的止损价来源汇总开放风险（沙盒模拟使用假设持仓自带的止损价）
+func (at *AutoTrader) calculateOpenRiskWith(positions []map[string]interface{}, totalEquity float64, stopLoss func(symbol, side string) (float64, bool)) OpenRisk {
+	risk := OpenRisk{
+		MaxRiskPct:   at.getMaxOpenRiskPct(),
+		PositionRisk: make(map[string]float64),
+		Unprotected:  []string{},
+	}
+
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		entryPrice, _ := pos["entryPrice"].(float64)
+		markPrice, _ := pos["markPrice"].(float64)
+		liquidationPrice, _ := pos["liquidationPrice"].(float64)
+		posAmt, _ := pos["positionAmt"].(float64)
+		quantity := math.Abs(posAmt)
+		if quantity == 0 {
+			continue
+		}
+
+		posKey := symbol + "_" + side
+		var positionRisk float64
+		if stopPrice, ok := stopLoss(symbol, side); ok {
+			// 止损已越过入场价（保本/锁盈）时风险为0
+			if side == "long" {
+				positionRisk = math.Max(entryPrice-stopPrice, 0) * quantity
+			} else {
+				positionRisk = math.Max(stopPrice-entryPrice, 0) * quantity
+			}
+		} else {
+			risk.Unprotected = append(risk.Unprotected, posKey)
+			if liquidationPrice > 0 {
+				positionRisk = math.Abs(entryPrice-liquidationPrice) * quantity
+			} else {
+				leverage := 10.0
+				if lev, ok := pos["leverage"].(float64); ok && lev > 0 {
+					leverage = lev
+				}
+				positionRisk = quantity * markPrice / leverage
+			}
+		}
+
+		risk.PositionRisk[posKey] = positionRisk
+		risk.TotalRiskUSD += positionRisk
+	}
+
+	if totalEquity > 0 {
+		risk.TotalRiskPct = risk.TotalRiskUSD / totalEquity * 100
+	}
+	return risk
+}
+
+// checkOpenRiskCap 检查新开仓后总开放风险是否超过上限
+func (at *AutoTrader) checkOpenRiskCap(positions []map[string]interface{}, entryPrice, stopLoss, quantity float64) error {
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		return fmt.Errorf("获取账户余额失败: %w", err)
+	}
+	totalWalletBalance, _ := balance["totalWalletBalance"].(float64)
+	totalUnrealizedProfit, _ := balance["totalUnrealizedProfit"].(float64)
+	totalEquity := totalWalletBalance + totalUnrealizedProfit
+	if totalEquity <= 0 {
+		return nil
+	}
+
+	at.syncStopLosses(positions)
+	current := at.calculateOpenRisk(positions, totalEquity)
+	newRisk := math.Abs(entryPrice-stopLoss) * quantity
+	maxRiskUSD := totalEquity * current.MaxRiskPct / 100
+
+	if current.TotalRiskUSD+newRisk > maxRiskUSD {
+		return fmt.Errorf("❌ 总开放风险超限: 现有 %.2f + 新仓 %.2f = %.2f USDT，超过净值的 %.1f%% (%.2f USDT)",
+			current.TotalRiskUSD, newRisk, current.TotalRiskUSD+newRisk, current.MaxRiskPct, maxRiskUSD)
+	}
+
+	log.Printf("  🛡 开放风险: 现有 %.2f + 新仓 %.2f / 上限 %.2f USDT (%.1f%%)",
+		current.TotalRiskUSD, newRisk, maxRiskUSD, current.MaxRiskPct)
+	return nil
+}
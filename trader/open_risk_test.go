@@ -0,0 +1,76 @@
+package trader
+
+import (
+	"math"
+	"testing"
+)
+
+// stopBookTrader 带交易所止损单查询的交易器（模拟重启后内存中没有止损价、止损单仍挂在交易所）
+type stopBookTrader struct {
+	fakeTrader
+	equity float64
+	stops  map[string][]StopOrderInfo
+	reads  int
+}
+
+func (s *stopBookTrader) GetBalance() (map[string]interface{}, error) {
+	return map[string]interface{}{"totalWalletBalance": s.equity, "totalUnrealizedProfit": 0.0}, nil
+}
+func (s *stopBookTrader) GetStopOrders(symbol string) ([]StopOrderInfo, error) {
+	s.reads++
+	return s.stops[symbol], nil
+}
+func (s *stopBookTrader) CancelOrder(symbol string, orderID int64) error { return nil }
+
+func TestCheckOpenRiskCapAfterRestart(t *testing.T) {
+	positions := []map[string]interface{}{
+		{"symbol": "BTCUSDT", "side": "long", "entryPrice": 100000.0, "markPrice": 100000.0, "liquidationPrice": 80000.0, "positionAmt": 0.01},
+		{"symbol": "ETHUSDT", "side": "short", "entryPrice": 4000.0, "markPrice": 4000.0, "liquidationPrice": 4800.0, "positionAmt": -0.5},
+	}
+	exchange := &stopBookTrader{
+		fakeTrader: fakeTrader{positions: positions},
+		equity:     10000,
+		stops: map[string][]StopOrderInfo{
+			"BTCUSDT": {{OrderID: 1, PositionSide: "LONG", StopPrice: 98000}, {OrderID: 2, PositionSide: "LONG", StopPrice: 97000}},
+			"ETHUSDT": {{OrderID: 3, PositionSide: "BOTH", StopPrice: 4040}},
+		},
+	}
+	// 重启后的交易员：stopLossPrices 为空
+	at := &AutoTrader{trader: exchange, config: AutoTraderConfig{MaxOpenRiskPct: 5}, stopLossPrices: make(map[string]float64)}
+
+	// 按强平价估算时现有风险 200+400=600 USDT 已超过上限 500；恢复止损后为 20+20=40
+	if err := at.checkOpenRiskCap(positions, 50, 49, 100); err != nil {
+		t.Fatalf("恢复交易所止损后不应拒绝开仓: %v", err)
+	}
+	if stop, ok := at.getStopLoss("BTCUSDT", "long"); !ok || stop != 98000 {
+		t.Errorf("BTCUSDT long stop = %v/%v, want 98000（最先触发的止损）", stop, ok)
+	}
+	if stop, ok := at.getStopLoss("ETHUSDT", "short"); !ok || stop != 4040 {
+		t.Errorf("ETHUSDT short stop = %v/%v, want 4040（单向持仓模式 BOTH）", stop, ok)
+	}
+
+	risk := at.calculateOpenRisk(positions, exchange.equity)
+	if len(risk.Unprotected) != 0 || math.Abs(risk.TotalRiskUSD-40) > 1e-9 {
+		t.Errorf("risk = %.2f unprotected=%v, want 40 with none unprotected", risk.TotalRiskUSD, risk.Unprotected)
+	}
+
+	// 止损已知后不再重复查询交易所
+	reads := exchange.reads
+	at.syncStopLosses(positions)
+	if exchange.reads != reads {
+		t.Errorf("已知止损时仍查询交易所 %d 次", exchange.reads-reads)
+	}
+}
+
+func TestCheckOpenRiskCapWithoutExchangeStops(t *testing.T) {
+	positions := []map[string]interface{}{
+		{"symbol": "BTCUSDT", "side": "long", "entryPrice": 100000.0, "markPrice": 100000.0, "liquidationPrice": 80000.0, "positionAmt": 0.01},
+	}
+	exchange := &stopBookTrader{fakeTrader: fakeTrader{positions: positions}, equity: 10000}
+	at := &AutoTrader{trader: exchange, config: AutoTraderConfig{MaxOpenRiskPct: 1}, stopLossPrices: make(map[string]float64)}
+
+	// 交易所上确实没有止损单时仍按强平价估算（200 USDT > 上限 100）
+	if err := at.checkOpenRiskCap(positions, 50, 49, 1); err == nil {
+		t.Error("没有止损的持仓应按强平价计入开放风险")
+	}
+}
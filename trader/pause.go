@@ -0,0 +1,250 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const tradingPauseKey = "trading_pause" // 人工暂停状态（全局；交易员级为 trading_pause:<trader_id>）
+
+// 暂停模式
+const (
+	PauseModeEntries = "entries" // 暂停新开仓，平仓、止损止盈调整等持仓管理照常
+	PauseModeFreeze  = "freeze"  // 完全冻结：跳过决策周期，拒绝一切人工执行（交易所侧已挂的止损止盈不受影响）
+)
+
+// 暂停原因代码（必填，便于事后统计暂停原因）
+const (
+	PauseReasonNews          = "news"          // 重大新闻/宏观事件
+	PauseReasonVolatility    = "volatility"    // 行情异常波动
+	PauseReasonInvestigation = "investigation" // 排查异常（疑似bug、数据问题）
+	PauseReasonExchange      = "exchange"      // 交易所问题（未录入维护窗口的故障）
+	PauseReasonRiskReview    = "risk_review"   // 风控复核
+	PauseReasonManual        = "manual"        // 其他人工原因（需填写备注）
+)
+
+var pauseReasons = []string{PauseReasonNews, PauseReasonVolatility, PauseReasonInvestigation, PauseReasonExchange, PauseReasonRiskReview, PauseReasonManual}
+
+// TradingPause 人工暂停状态（全局或单个交易员）
+type TradingPause struct {
+	TraderID string    `json:"trader_id,omitempty"` // 为空表示全局暂停
+	Mode     string    `json:"mode"`
+	Reason   string    `json:"reason"`
+	Note     string    `json:"note,omitempty"`
+	By       string    `json:"by,omitempty"`
+	Since    time.Time `json:"since"`
+	Until    time.Time `json:"until,omitempty"` // 到期自动恢复（零值=需手动恢复）
+}
+
+// expiredAt 暂停是否已到期自动恢复
+func (p TradingPause) expiredAt(t time.Time) bool {
+	return !p.Until.IsZero() && !t.Before(p.Until)
+}
+
+// describe 暂停的可读描述（日志和通知）
+func (p TradingPause) describe() string {
+	scope := "全局"
+	if p.TraderID != "" {
+		scope = "交易员"
+	}
+	mode := "暂停开仓"
+	if p.Mode == PauseModeFreeze {
+		mode = "完全冻结"
+	}
+	text := fmt.Sprintf("%s%s（%s", scope, mode, p.Reason)
+	if p.Note != "" {
+		text += ": " + p.Note
+	}
+	text += "）"
+	if !p.Until.IsZero() {
+		text += "，" + p.Until.Format("01-02 15:04") + " 自动恢复"
+	}
+	return text
+}
+
+// PauseStatus 交易员的暂停状态
+type PauseStatus struct {
+	Global    *TradingPause `json:"global,omitempty"`
+	Trader    *TradingPause `json:"trader,omitempty"`
+	Effective *TradingPause `json:"effective,omitempty"` // 实际生效的暂停（完全冻结优先于暂停开仓）
+}
+
+// pauseState 暂停状态跟踪（暂停/恢复时通知一次）
+type pauseState struct {
+	mu     sync.Mutex
+	active *TradingPause
+}
+
+// current 最近一个决策周期检测到的暂停（健康检查使用）
+func (s *pauseState) current() *TradingPause {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active
+}
+
+func tradingPauseKeyFor(traderID string) string {
+	if traderID == "" {
+		return tradingPauseKey
+	}
+	return tradingPauseKey + ":" + traderID
+}
+
+// GetTradingPause 读取暂停状态（traderID 为空读取全局暂停；未暂停或已到期返回 nil）
+func GetTradingPause(db MaintenanceConfigStore, traderID string) (*TradingPause, error) {
+	value, err := db.GetSystemConfig(tradingPauseKeyFor(traderID))
+	if err != nil || value == "" {
+		return nil, nil
+	}
+	var pause TradingPause
+	if err := json.Unmarshal([]byte(value), &pause); err != nil {
+		return nil, fmt.Errorf("解析暂停状态失败: %w", err)
+	}
+	if pause.expiredAt(time.Now()) {
+		return nil, nil
+	}
+	return &pause, nil
+}
+
+// SetTradingPause 暂停交易（traderID 为空表示全局暂停，duration 为0表示需手动恢复）
+func SetTradingPause(db MaintenanceConfigStore, traderID string, pause TradingPause, duration time.Duration) (TradingPause, error) {
+	pause.Mode = strings.ToLower(strings.TrimSpace(pause.Mode))
+	if pause.Mode == "" {
+		pause.Mode = PauseModeEntries
+	}
+	if pause.Mode != PauseModeEntries && pause.Mode != PauseModeFreeze {
+		return pause, fmt.Errorf("暂停模式必须为 %s 或 %s", PauseModeEntries, PauseModeFreeze)
+	}
+	pause.Reason = strings.ToLower(strings.TrimSpace(pause.Reason))
+	if !slices.Contains(pauseReasons, pause.Reason) {
+		return pause, fmt.Errorf("暂停原因代码必须为 %s 之一", strings.Join(pauseReasons, " / "))
+	}
+	pause.Note = strings.TrimSpace(pause.Note)
+	if pause.Reason == PauseReasonManual && pause.Note == "" {
+		return pause, fmt.Errorf("原因代码为 %s 时必须填写备注", PauseReasonManual)
+	}
+	if duration < 0 {
+		return pause, fmt.Errorf("自动恢复时长不能为负")
+	}
+
+	pause.TraderID = traderID
+	pause.Since = time.Now()
+	pause.Until = time.Time{}
+	if duration > 0 {
+		pause.Until = pause.Since.Add(duration)
+	}
+	data, _ := json.Marshal(pause)
+	if err := db.SetSystemConfig(tradingPauseKeyFor(traderID), string(data)); err != nil {
+		return pause, fmt.Errorf("保存暂停状态失败: %w", err)
+	}
+	log.Printf("⏸ %s，操作人 %s", pause.describe(), pause.By)
+	return pause, nil
+}
+
+// ClearTradingPause 恢复交易（traderID 为空表示解除全局暂停）
+func ClearTradingPause(db MaintenanceConfigStore, traderID string) error {
+	if err := db.SetSystemConfig(tradingPauseKeyFor(traderID), ""); err != nil {
+		return fmt.Errorf("清除暂停状态失败: %w", err)
+	}
+	return nil
+}
+
+// stricterPause 合并全局和交易员暂停：完全冻结优先，同一模式取全局
+func stricterPause(global, own *TradingPause) *TradingPause {
+	switch {
+	case global == nil:
+		return own
+	case own == nil:
+		return global
+	case own.Mode == PauseModeFreeze && global.Mode != PauseModeFreeze:
+		return own
+	default:
+		return global
+	}
+}
+
+// GetPauseStatus 全局和本交易员的暂停状态
+func (at *AutoTrader) GetPauseStatus() PauseStatus {
+	var status PauseStatus
+	db, ok := at.database.(MaintenanceConfigStore)
+	if !ok {
+		return status
+	}
+	var err error
+	if status.Global, err = GetTradingPause(db, ""); err != nil {
+		log.Printf("⚠️  [%s] %v", at.name, err)
+	}
+	if status.Trader, err = GetTradingPause(db, at.id); err != nil {
+		log.Printf("⚠️  [%s] %v", at.name, err)
+	}
+	status.Effective = stricterPause(status.Global, status.Trader)
+	return status
+}
+
+// activePause 当前生效的暂停（每次读取存储，周期中途设置的暂停也能及时生效）
+func (at *AutoTrader) activePause() *TradingPause {
+	return at.GetPauseStatus().Effective
+}
+
+// checkPause 决策周期开始时检查暂停状态：进入暂停、切换模式或恢复（含到期自动恢复）时通知
+func (at *AutoTrader) checkPause() *TradingPause {
+	active := at.activePause()
+
+	state := &at.pause
+	state.mu.Lock()
+	previous := state.active
+	state.active = active
+	state.mu.Unlock()
+
+	switch {
+	case active != nil && (previous == nil || previous.Mode != active.Mode || !previous.Since.Equal(active.Since)):
+		log.Printf("⏸ [%s] %s", at.name, active.describe())
+		at.noteActivity("交易暂停: %s", active.describe())
+		at.notify(logger.EventSystem, logger.SeverityWarning, "⏸ %s", active.describe())
+	case active == nil && previous != nil:
+		how := "已手动恢复"
+		if previous.expiredAt(time.Now()) {
+			how = "到期自动恢复"
+		}
+		log.Printf("▶️ [%s] 交易暂停%s（原因 %s）", at.name, how, previous.Reason)
+		at.noteActivity("交易暂停%s", how)
+		at.notify(logger.EventSystem, logger.SeverityInfo, "▶️ 交易暂停%s（原因 %s）", how, previous.Reason)
+	}
+	return active
+}
+
+// checkPausedExecution 暂停期间的人工执行：完全冻结拒绝一切下单，暂停开仓只拒绝开仓
+func (at *AutoTrader) checkPausedExecution(d *decision.Decision) error {
+	pause := at.activePause()
+	if pause == nil {
+		return nil
+	}
+	if pause.Mode == PauseModeFreeze {
+		return fmt.Errorf("交易已完全冻结: %s", pause.describe())
+	}
+	if d.Action == "open_long" || d.Action == "open_short" {
+		return fmt.Errorf("新开仓已暂停: %s", pause.describe())
+	}
+	return nil
+}
+
+// convertPausedOpens 暂停开仓期间把开仓决策转为 wait，返回执行日志
+func convertPausedOpens(decisions []decision.Decision, pause *TradingPause) []string {
+	var notes []string
+	for i := range decisions {
+		d := &decisions[i]
+		if d.Action != "open_long" && d.Action != "open_short" {
+			continue
+		}
+		notes = append(notes, fmt.Sprintf("⏸ 交易暂停中（%s），%s %s 已转为 wait", pause.Reason, d.Symbol, d.Action))
+		d.Reasoning = fmt.Sprintf("[交易暂停，原决策 %s] %s", d.Action, d.Reasoning)
+		d.Action = "wait"
+	}
+	return notes
+}
@@ -0,0 +1,114 @@
+package trader
+
+import (
+	"encoding/json"
+	"nofx/decision"
+	"testing"
+	"time"
+)
+
+func TestSetTradingPauseValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		pause   TradingPause
+		wantErr bool
+	}{
+		{name: "默认暂停开仓", pause: TradingPause{Reason: "news"}},
+		{name: "完全冻结", pause: TradingPause{Mode: "Freeze", Reason: PauseReasonInvestigation}},
+		{name: "缺少原因代码", pause: TradingPause{Mode: PauseModeEntries}, wantErr: true},
+		{name: "未知原因代码", pause: TradingPause{Reason: "bored"}, wantErr: true},
+		{name: "未知模式", pause: TradingPause{Mode: "halt", Reason: PauseReasonNews}, wantErr: true},
+		{name: "manual 需要备注", pause: TradingPause{Reason: PauseReasonManual}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := memoryConfig{}
+			_, err := SetTradingPause(db, "", tt.pause, 0)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetTradingPause error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && db[tradingPauseKey] != "" {
+				t.Error("校验失败时不应保存暂停状态")
+			}
+		})
+	}
+}
+
+func TestTradingPauseAutoResume(t *testing.T) {
+	db := memoryConfig{}
+	if _, err := SetTradingPause(db, "t1", TradingPause{Reason: PauseReasonVolatility}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	pause, err := GetTradingPause(db, "t1")
+	if err != nil || pause == nil || pause.Until.IsZero() {
+		t.Fatalf("GetTradingPause = %+v, %v", pause, err)
+	}
+	if global, _ := GetTradingPause(db, ""); global != nil {
+		t.Error("交易员暂停不应影响全局暂停")
+	}
+
+	expired := *pause
+	expired.Until = time.Now().Add(-time.Minute)
+	data, _ := json.Marshal(expired)
+	db[tradingPauseKeyFor("t1")] = string(data)
+	if pause, _ := GetTradingPause(db, "t1"); pause != nil {
+		t.Errorf("到期后应自动恢复，得到 %+v", pause)
+	}
+}
+
+func TestPauseStatusEffective(t *testing.T) {
+	db := memoryConfig{}
+	at := &AutoTrader{id: "t1", name: "test", database: db}
+	if at.activePause() != nil {
+		t.Fatal("未暂停时不应有生效的暂停")
+	}
+
+	if _, err := SetTradingPause(db, "", TradingPause{Reason: PauseReasonNews}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SetTradingPause(db, "t2", TradingPause{Mode: PauseModeFreeze, Reason: PauseReasonRiskReview}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if pause := at.activePause(); pause == nil || pause.Mode != PauseModeEntries || pause.TraderID != "" {
+		t.Errorf("应生效全局暂停开仓，得到 %+v", pause)
+	}
+
+	if _, err := SetTradingPause(db, "t1", TradingPause{Mode: PauseModeFreeze, Reason: PauseReasonInvestigation}, 0); err != nil {
+		t.Fatal(err)
+	}
+	status := at.GetPauseStatus()
+	if status.Global == nil || status.Trader == nil || status.Effective != status.Trader {
+		t.Errorf("完全冻结应优先于暂停开仓: %+v", status)
+	}
+
+	open := decision.Decision{Symbol: "BTCUSDT", Action: "open_long"}
+	closing := decision.Decision{Symbol: "BTCUSDT", Action: "close_long"}
+	if err := at.checkPausedExecution(&closing); err == nil {
+		t.Error("完全冻结时应拒绝平仓执行")
+	}
+
+	if err := ClearTradingPause(db, "t1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := at.checkPausedExecution(&open); err == nil {
+		t.Error("暂停开仓时应拒绝开仓")
+	}
+	if err := at.checkPausedExecution(&closing); err != nil {
+		t.Errorf("暂停开仓时平仓应照常执行: %v", err)
+	}
+}
+
+func TestConvertPausedOpens(t *testing.T) {
+	decisions := []decision.Decision{
+		{Symbol: "BTCUSDT", Action: "open_long", Reasoning: "突破"},
+		{Symbol: "ETHUSDT", Action: "close_short"},
+		{Symbol: "SOLUSDT", Action: "update_stop_loss"},
+	}
+	notes := convertPausedOpens(decisions, &TradingPause{Mode: PauseModeEntries, Reason: PauseReasonNews})
+	if len(notes) != 1 || decisions[0].Action != "wait" {
+		t.Errorf("只有开仓应转为 wait: %v %+v", notes, decisions)
+	}
+	if decisions[1].Action != "close_short" || decisions[2].Action != "update_stop_loss" {
+		t.Errorf("持仓管理决策不应改变: %+v", decisions)
+	}
+}
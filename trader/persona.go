@@ -0,0 +1,112 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/decision"
+	"nofx/logger"
+)
+
+// riskPersonaKey 交易员风险偏好档位在系统配置中的键（未设置时使用全局 risk_persona）
+func riskPersonaKey(traderID string) string {
+	return "risk_persona:" + traderID
+}
+
+// getRiskPersona 获取风险偏好档位（交易员配置 > 系统配置 risk_persona:<trader_id> > 全局 risk_persona）
+// 未设置或名称无效时返回 nil，沿用模板规则和各项独立的风控配置
+func (at *AutoTrader) getRiskPersona() *decision.RiskPersona {
+	name := at.config.RiskPersona
+	if name == "" {
+		type SystemConfigGetter interface {
+			GetSystemConfig(key string) (string, error)
+		}
+		if db, ok := at.database.(SystemConfigGetter); ok {
+			for _, key := range []string{riskPersonaKey(at.id), "risk_persona"} {
+				if value, err := db.GetSystemConfig(key); err == nil && value != "" {
+					name = value
+					break
+				}
+			}
+		}
+	}
+	if persona, ok := decision.GetRiskPersona(name); ok {
+		return &persona
+	}
+	return nil
+}
+
+// GetRiskPersona 当前风险偏好档位（未设置时返回 nil）
+func (at *AutoTrader) GetRiskPersona() *decision.RiskPersona {
+	return at.getRiskPersona()
+}
+
+// SetRiskPersona 设置交易员的风险偏好档位（空字符串=清除，回到全局设置）
+func (at *AutoTrader) SetRiskPersona(name string) error {
+	if name != "" {
+		persona, ok := decision.GetRiskPersona(name)
+		if !ok {
+			return fmt.Errorf("未知的风险偏好档位: %s", name)
+		}
+		name = persona.Name
+	}
+	type SystemConfigSetter interface {
+		SetSystemConfig(key, value string) error
+	}
+	db, ok := at.database.(SystemConfigSetter)
+	if !ok {
+		return fmt.Errorf("数据库不支持系统配置")
+	}
+	if err := db.SetSystemConfig(riskPersonaKey(at.id), name); err != nil {
+		return fmt.Errorf("保存风险偏好档位失败: %w", err)
+	}
+	return nil
+}
+
+// leverageCaps 杠杆上限（配置值按风险偏好档位缩放）
+func (at *AutoTrader) leverageCaps(persona *decision.RiskPersona) (btcEth, altcoin int) {
+	btcEth, altcoin = at.config.BTCETHLeverage, at.config.AltcoinLeverage
+	if persona != nil {
+		btcEth, altcoin = persona.ScaleLeverage(btcEth), persona.ScaleLeverage(altcoin)
+	}
+	return btcEth, altcoin
+}
+
+// personaRiskReward 以当前价为入场价的风险回报比
+func personaRiskReward(d *decision.Decision, price float64) float64 {
+	risk := math.Abs(price - d.StopLoss)
+	if risk <= 0 {
+		return 0
+	}
+	return math.Abs(d.TakeProfit-price) / risk
+}
+
+// checkPersonaEntry 按风险偏好档位校验开仓（信心度、风险回报比、持仓数量）：返回拒绝原因（为空表示允许）
+// openPositions 为当前持仓数加上本周期已成功开仓数
+func checkPersonaEntry(persona *decision.RiskPersona, d *decision.Decision, price float64, openPositions int) string {
+	if persona == nil || (d.Action != "open_long" && d.Action != "open_short") {
+		return ""
+	}
+	if d.Confidence < persona.MinConfidence {
+		return fmt.Sprintf("信心度 %d 低于%s档门槛 %d", d.Confidence, persona.Label, persona.MinConfidence)
+	}
+	if price > 0 && d.StopLoss > 0 && d.TakeProfit > 0 {
+		if rr := personaRiskReward(d, price); rr < persona.MinRiskReward {
+			return fmt.Sprintf("风险回报比 %.2f 低于%s档要求 %g", rr, persona.Label, persona.MinRiskReward)
+		}
+	}
+	if persona.MaxPositions > 0 && openPositions >= persona.MaxPositions {
+		return fmt.Sprintf("持仓数 %d 已达%s档上限 %d", openPositions, persona.Label, persona.MaxPositions)
+	}
+	return ""
+}
+
+// openedThisCycle 本周期已成功执行的开仓数
+func openedThisCycle(record *logger.DecisionRecord) int {
+	count := 0
+	for _, a := range record.Decisions {
+		if a.Success && (a.Action == "open_long" || a.Action == "open_short") {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,36 @@
+package trader
+
+import (
+	"nofx/decision"
+	"strings"
+	"testing"
+)
+
+func TestCheckPersonaEntry(t *testing.T) {
+	conservative, _ := decision.GetRiskPersona(decision.PersonaConservative)
+	open := func(confidence int, stop, target float64) *decision.Decision {
+		return &decision.Decision{Symbol: "SOLUSDT", Action: "open_long", Confidence: confidence, StopLoss: stop, TakeProfit: target}
+	}
+	tests := []struct {
+		name      string
+		persona   *decision.RiskPersona
+		d         *decision.Decision
+		positions int
+		want      string // 期望拒绝原因包含的内容（空=允许）
+	}{
+		{"未设置档位不限制", nil, open(50, 99, 101), 10, ""},
+		{"满足保守档", &conservative, open(90, 95, 125), 0, ""},
+		{"信心度不足", &conservative, open(80, 95, 125), 0, "信心度"},
+		{"风险回报比不足", &conservative, open(90, 95, 115), 0, "风险回报比"},
+		{"持仓数已满", &conservative, open(90, 95, 125), 2, "持仓数"},
+		{"平仓不受限制", &conservative, &decision.Decision{Symbol: "SOLUSDT", Action: "close_long"}, 5, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkPersonaEntry(tt.persona, tt.d, 100, tt.positions)
+			if tt.want == "" && got != "" || tt.want != "" && !strings.Contains(got, tt.want) {
+				t.Errorf("checkPersonaEntry = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
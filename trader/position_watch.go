@@ -0,0 +1,299 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"nofx/decision"
+	"nofx/market"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPositionRefresh    = 10 * time.Second // 持仓优先刷新间隔
+	defaultPositionATRTrigger = 2.0              // 逆向波动超过 N×ATR 触发紧急持仓管理周期
+)
+
+// PositionWatch 持仓在两个决策周期之间的实时状态
+type PositionWatch struct {
+	Symbol           string    `json:"symbol"`
+	Side             string    `json:"side"`
+	MarkPrice        float64   `json:"mark_price"`
+	LiquidationPrice float64   `json:"liquidation_price"`
+	LiqDistancePct   float64   `json:"liq_distance_pct"` // 标记价距强平价的百分比（0=未知）
+	StopLoss         float64   `json:"stop_loss"`        // 已知止损价（0=未设置）
+	StopStatus       string    `json:"stop_status"`      // ok / missing / unknown（平台无法查询止损单）
+	AnchorPrice      float64   `json:"anchor_price"`     // 上个决策周期后首次刷新时的价格
+	ATR              float64   `json:"atr"`              // 4h ATR14
+	AdverseATR       float64   `json:"adverse_atr"`      // 相对锚定价的逆向波动（ATR倍数）
+	OIChangePct      float64   `json:"oi_change_pct"`    // 资金流窗口内持仓量变化
+	PriceChangePct   float64   `json:"price_change_pct"` // 资金流窗口内价格变化
+	VolumeDelta      float64   `json:"volume_delta"`     // 资金流窗口内净主动成交占比（-1~1，正=买方主导）
+	AdverseFlow      string    `json:"adverse_flow,omitempty"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// PositionWatchStatus 持仓优先刷新状态
+type PositionWatchStatus struct {
+	Enabled     bool            `json:"enabled"`
+	IntervalSec int             `json:"interval_sec"`
+	ATRTrigger  float64         `json:"atr_trigger"`
+	Emergencies int             `json:"emergencies"` // 已触发的紧急持仓管理周期数
+	Positions   []PositionWatch `json:"positions"`
+
+	FlowWindowMin    int     `json:"flow_window_min"`
+	FlowOITriggerPct float64 `json:"flow_oi_trigger_pct"`
+	FlowDeltaTrigger float64 `json:"flow_delta_trigger"`
+	FlowAlerts       int     `json:"flow_alerts"` // 资金流逆向触发的紧急持仓管理周期数
+}
+
+// positionAnchor 逆向波动的参考点（每个决策周期后重新锚定）
+type positionAnchor struct {
+	price     float64
+	atr       float64
+	triggered bool // 本锚定期内已触发过紧急周期
+}
+
+// positionWatchState 持仓优先刷新状态（刷新goroutine写，API读，决策周期重置锚定）
+type positionWatchState struct {
+	mu          sync.Mutex
+	anchors     map[string]*positionAnchor
+	positions   map[string]PositionWatch
+	emergencies int
+	flows       map[string]*flowTracker
+	flowAlerts  int
+}
+
+func newPositionWatchState() positionWatchState {
+	return positionWatchState{
+		anchors:   make(map[string]*positionAnchor),
+		positions: make(map[string]PositionWatch),
+		flows:     make(map[string]*flowTracker),
+	}
+}
+
+// resetAnchors 决策周期已重新评估持仓，逆向波动从当前价格重新计算，资金流逆向可再次触发（保留采样窗口）
+func (w *positionWatchState) resetAnchors() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.anchors = make(map[string]*positionAnchor)
+	for _, tracker := range w.flows {
+		tracker.triggered = false
+	}
+}
+
+// positionWatchConfig 刷新间隔（系统配置 position_refresh_secs，0=关闭）和ATR触发倍数（position_atr_trigger）
+func (at *AutoTrader) positionWatchConfig() (time.Duration, float64) {
+	interval, trigger := defaultPositionRefresh, defaultPositionATRTrigger
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	db, ok := at.database.(SystemConfigGetter)
+	if !ok {
+		return interval, trigger
+	}
+	if value, err := db.GetSystemConfig("position_refresh_secs"); err == nil && value != "" {
+		if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+			interval = time.Duration(secs) * time.Second
+		}
+	}
+	if value, err := db.GetSystemConfig("position_atr_trigger"); err == nil && value != "" {
+		if n, err := strconv.ParseFloat(value, 64); err == nil && n > 0 {
+			trigger = n
+		}
+	}
+	return interval, trigger
+}
+
+// startPositionWatch 两个决策周期之间按更快频率刷新持仓标记价、强平距离和止损状态
+func (at *AutoTrader) startPositionWatch() {
+	interval, _ := at.positionWatchConfig()
+	if interval <= 0 {
+		log.Printf("⏸ [%s] 持仓优先刷新已关闭", at.name)
+		return
+	}
+	at.monitorWg.Add(1)
+	go func() {
+		defer at.monitorWg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		log.Printf("🔭 [%s] 启动持仓优先刷新（每 %v）", at.name, interval)
+		for {
+			select {
+			case <-ticker.C:
+				at.refreshPositionWatch()
+			case <-at.stopMonitorCh:
+				log.Printf("⏹ [%s] 停止持仓优先刷新", at.name)
+				return
+			}
+		}
+	}()
+}
+
+// refreshPositionWatch 刷新一次持仓状态，逆向波动超过阈值时排队紧急持仓管理周期
+func (at *AutoTrader) refreshPositionWatch() {
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		log.Printf("⚠️  [%s] 持仓优先刷新：获取持仓失败: %v", at.name, err)
+		return
+	}
+	_, trigger := at.positionWatchConfig()
+	stopReader, canReadStops := at.trader.(stopOrderReader)
+
+	now := time.Now()
+	current := make(map[string]PositionWatch, len(positions))
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		markPrice, _ := pos["markPrice"].(float64)
+		liqPrice, _ := pos["liquidationPrice"].(float64)
+		if symbol == "" || markPrice <= 0 {
+			continue
+		}
+		key := symbol + "_" + side
+
+		watch := PositionWatch{Symbol: symbol, Side: side, MarkPrice: markPrice, LiquidationPrice: liqPrice, StopStatus: "unknown", UpdatedAt: now}
+		if liqPrice > 0 {
+			watch.LiqDistancePct = math.Abs(markPrice-liqPrice) / markPrice * 100
+		}
+		watch.StopLoss, _ = at.getStopLoss(symbol, side)
+		if canReadStops {
+			watch.StopStatus = stopStatus(stopReader, symbol, side)
+		}
+
+		anchor := at.positionAnchor(key, symbol, markPrice)
+		watch.AnchorPrice, watch.ATR = anchor.price, anchor.atr
+		watch.AdverseATR = adverseATR(side, anchor.price, markPrice, anchor.atr)
+
+		if watch.AdverseATR >= trigger && !anchor.triggered {
+			anchor.triggered = true
+			at.triggerPositionEmergency(watch, trigger)
+		}
+		at.watchPositionFlow(&watch, key)
+		current[key] = watch
+	}
+
+	at.positionWatch.mu.Lock()
+	at.positionWatch.positions = current
+	for key := range at.positionWatch.flows {
+		if _, held := current[key]; !held {
+			delete(at.positionWatch.flows, key)
+		}
+	}
+	at.positionWatch.mu.Unlock()
+}
+
+// positionAnchor 获取持仓的锚定点（不存在时以当前价格锚定，并获取一次ATR）
+func (at *AutoTrader) positionAnchor(key, symbol string, markPrice float64) *positionAnchor {
+	at.positionWatch.mu.Lock()
+	anchor, ok := at.positionWatch.anchors[key]
+	at.positionWatch.mu.Unlock()
+	if ok {
+		return anchor
+	}
+
+	anchor = &positionAnchor{price: markPrice}
+	if data, err := market.Get(symbol); err == nil && data.LongerTermContext != nil {
+		anchor.atr = data.LongerTermContext.ATR14
+	}
+	at.positionWatch.mu.Lock()
+	at.positionWatch.anchors[key] = anchor
+	at.positionWatch.mu.Unlock()
+	return anchor
+}
+
+// adverseATR 相对锚定价的逆向波动（ATR倍数，顺向波动返回0）
+func adverseATR(side string, anchorPrice, markPrice, atr float64) float64 {
+	if atr <= 0 {
+		return 0
+	}
+	move := anchorPrice - markPrice
+	if side == "short" {
+		move = -move
+	}
+	if move <= 0 {
+		return 0
+	}
+	return move / atr
+}
+
+// stopStatus 交易所上是否存在该方向的止损单
+func stopStatus(reader stopOrderReader, symbol, side string) string {
+	stops, err := reader.GetStopOrders(symbol)
+	if err != nil {
+		return "unknown"
+	}
+	positionSide := "LONG"
+	if side == "short" {
+		positionSide = "SHORT"
+	}
+	for _, stop := range stops {
+		if stop.PositionSide == positionSide {
+			return "ok"
+		}
+	}
+	return "missing"
+}
+
+// triggerPositionEmergency 排队一个只针对该持仓币种的紧急管理周期（复用警报定向周期）
+func (at *AutoTrader) triggerPositionEmergency(watch PositionWatch, trigger float64) {
+	message := fmt.Sprintf("%s %s 逆向波动 %.2f×ATR（锚定价 %.4f → 标记价 %.4f，ATR %.4f），超过 %.1f×ATR",
+		watch.Symbol, watch.Side, watch.AdverseATR, watch.AnchorPrice, watch.MarkPrice, watch.ATR, trigger)
+	err := at.TriggerAlertCycle(decision.AlertTrigger{
+		Rule:      "position_adverse_move",
+		Symbol:    watch.Symbol,
+		Message:   message,
+		Price:     watch.MarkPrice,
+		Value:     watch.AdverseATR,
+		Threshold: trigger,
+		Details: map[string]string{
+			"side":             watch.Side,
+			"anchor_price":     strconv.FormatFloat(watch.AnchorPrice, 'f', -1, 64),
+			"liq_distance_pct": strconv.FormatFloat(watch.LiqDistancePct, 'f', 2, 64),
+			"stop_status":      watch.StopStatus,
+		},
+	})
+	if err != nil {
+		log.Printf("⚠️  [%s] 紧急持仓管理周期未排队: %v", at.name, err)
+		return
+	}
+
+	at.positionWatch.mu.Lock()
+	at.positionWatch.emergencies++
+	at.positionWatch.mu.Unlock()
+	at.noteActivity("🚨 %s，触发紧急持仓管理周期", message)
+	at.publishRiskBreach(watch.Symbol, "", "position_adverse_move", "🚨 "+message+"，已触发紧急持仓管理周期", false)
+}
+
+// GetPositionWatchStatus 持仓优先刷新状态
+func (at *AutoTrader) GetPositionWatchStatus() PositionWatchStatus {
+	interval, trigger := at.positionWatchConfig()
+	flowWindow, flowOITrigger, flowDeltaTrigger := at.flowWatchConfig()
+	status := PositionWatchStatus{
+		Enabled:          interval > 0,
+		IntervalSec:      int(interval.Seconds()),
+		ATRTrigger:       trigger,
+		Positions:        []PositionWatch{},
+		FlowWindowMin:    int(flowWindow.Minutes()),
+		FlowOITriggerPct: flowOITrigger,
+		FlowDeltaTrigger: flowDeltaTrigger,
+	}
+
+	at.positionWatch.mu.Lock()
+	status.Emergencies = at.positionWatch.emergencies
+	status.FlowAlerts = at.positionWatch.flowAlerts
+	for _, watch := range at.positionWatch.positions {
+		status.Positions = append(status.Positions, watch)
+	}
+	at.positionWatch.mu.Unlock()
+
+	sort.Slice(status.Positions, func(i, j int) bool {
+		return status.Positions[i].AdverseATR > status.Positions[j].AdverseATR
+	})
+	return status
+}
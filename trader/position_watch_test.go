@@ -0,0 +1,30 @@
+package trader
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAdverseATR(t *testing.T) {
+	tests := []struct {
+		name   string
+		side   string
+		anchor float64
+		mark   float64
+		atr    float64
+		want   float64
+	}{
+		{"多单下跌", "long", 100, 96, 2, 2},
+		{"多单上涨不计", "long", 100, 104, 2, 0},
+		{"空单上涨", "short", 100, 103, 2, 1.5},
+		{"空单下跌不计", "short", 100, 97, 2, 0},
+		{"ATR未知", "long", 100, 90, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := adverseATR(tt.side, tt.anchor, tt.mark, tt.atr); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("adverseATR = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultPostMortemLossStreak 连续亏损多少笔触发复盘
+	defaultPostMortemLossStreak = 3
+	// postMortemCalibrationCycles 信心度校准统计回看的决策周期数
+	postMortemCalibrationCycles = 300
+)
+
+// postMortemSystemPrompt 复盘分析的系统提示词（输出供人工审核的调整建议，不会自动生效）
+const postMortemSystemPrompt = `你是一名量化交易风控复盘分析师。下面是一个AI交易员触发亏损复盘时的数据包（亏损交易、决策记录与思维链摘要、相关币种行情快照、信心度校准统计）。
+请用中文输出：
+1. 亏损的共同原因（入场时机、方向判断、止损设置、仓位、行情环境等），引用具体交易；
+2. 信心度校准是否失真（高信心度交易胜率是否更高）；
+3. 具体的规则/提示词模板调整建议（如提高某类信号的信心度门槛、收紧某币种的杠杆、调整止损距离），每条建议说明依据。
+建议仅供人工审核，不要输出任何交易指令。`
+
+// PostMortemRules 亏损复盘触发规则
+type PostMortemRules struct {
+	LossStreak   int     `json:"loss_streak"`    // 连续亏损笔数（0=关闭）
+	DailyLossPct float64 `json:"daily_loss_pct"` // 当日亏损百分比（0=关闭）
+	AIAnalysis   bool    `json:"ai_analysis"`    // 是否调用AI生成调整建议
+}
+
+// GetPostMortemRules 复盘触发规则（系统配置 postmortem_loss_streak / postmortem_daily_loss / postmortem_ai_analysis，日亏损默认取交易员的最大日亏损）
+func (at *AutoTrader) GetPostMortemRules() PostMortemRules {
+	rules := PostMortemRules{
+		LossStreak:   defaultPostMortemLossStreak,
+		DailyLossPct: at.config.MaxDailyLoss,
+	}
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	db, ok := at.database.(SystemConfigGetter)
+	if !ok {
+		return rules
+	}
+	if value, err := db.GetSystemConfig("postmortem_loss_streak"); err == nil && value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			rules.LossStreak = n
+		}
+	}
+	if value, err := db.GetSystemConfig("postmortem_daily_loss"); err == nil && value != "" {
+		if pct, err := strconv.ParseFloat(value, 64); err == nil && pct >= 0 {
+			rules.DailyLossPct = pct
+		}
+	}
+	if value, err := db.GetSystemConfig("postmortem_ai_analysis"); err == nil {
+		rules.AIAnalysis = value == "true"
+	}
+	return rules
+}
+
+// checkPostMortemTriggers 连续亏损或当日亏损超限时生成复盘包（同一次连亏/同一天只生成一次）
+func (at *AutoTrader) checkPostMortemTriggers(ctx *decision.Context) {
+	perf, ok := ctx.Performance.(*logger.PerformanceAnalysis)
+	if !ok || perf == nil {
+		return
+	}
+	rules := at.GetPostMortemRules()
+	now := time.Now()
+
+	if rules.LossStreak > 0 {
+		if streak := logger.LossStreak(perf.RecentTrades); streak >= rules.LossStreak {
+			trades := perf.RecentTrades[:streak]
+			key := fmt.Sprintf("%s:%d", logger.PostMortemLossStreak, trades[0].CloseTime.Unix())
+			windowStart := trades[len(trades)-1].OpenTime
+			detail := fmt.Sprintf("连续亏损 %d 笔（阈值 %d）", streak, rules.LossStreak)
+			at.generatePostMortem(logger.PostMortemLossStreak, key, detail, windowStart, trades, nil, rules.AIAnalysis)
+		}
+	}
+
+	if rules.DailyLossPct > 0 {
+		opening := at.todayOpeningEquity()
+		if opening <= 0 {
+			return
+		}
+		pnlPct := (ctx.Account.TotalEquity - opening) / opening * 100
+		if pnlPct > -rules.DailyLossPct {
+			return
+		}
+		dayStart := startOfDay(now)
+		var trades []logger.TradeOutcome
+		for _, trade := range perf.RecentTrades {
+			if !trade.CloseTime.Before(dayStart) {
+				trades = append(trades, trade)
+			}
+		}
+		key := fmt.Sprintf("%s:%s", logger.PostMortemDailyLoss, dayStart.Format(logger.SettlementDateLayout))
+		detail := fmt.Sprintf("当日亏损 %.2f%%（阈值 %.2f%%）", -pnlPct, rules.DailyLossPct)
+		account := &logger.PostMortemAccount{OpeningEquity: opening, Equity: ctx.Account.TotalEquity, DailyPnLPct: pnlPct}
+		at.generatePostMortem(logger.PostMortemDailyLoss, key, detail, dayStart, trades, account, rules.AIAnalysis)
+	}
+}
+
+// generatePostMortem 汇总复盘包并保存，AI分析在后台执行完成后写回
+func (at *AutoTrader) generatePostMortem(trigger, key, detail string, windowStart time.Time, trades []logger.TradeOutcome, account *logger.PostMortemAccount, aiAnalysis bool) {
+	if at.postMortems.HasKey(key) {
+		return
+	}
+	now := time.Now()
+	pm := &logger.PostMortem{
+		ID:          fmt.Sprintf("pm-%d", now.UnixNano()),
+		Key:         key,
+		Trigger:     trigger,
+		Detail:      detail,
+		CreatedAt:   now,
+		WindowStart: windowStart,
+		Account:     account,
+		Trades:      trades,
+		Market:      make(map[string]string),
+		Status:      logger.PostMortemPending,
+	}
+	if pm.Trades == nil {
+		pm.Trades = []logger.TradeOutcome{}
+	}
+
+	if records, err := at.decisionLogger.GetRecordsBetween(windowStart, now); err == nil {
+		pm.Decisions = logger.SummarizeDecisions(records)
+	} else {
+		log.Printf("⚠️  复盘读取决策记录失败: %v", err)
+	}
+	if records, err := at.decisionLogger.GetLatestRecords(postMortemCalibrationCycles); err == nil {
+		if perf, err := at.decisionLogger.AnalyzePerformance(postMortemCalibrationCycles); err == nil {
+			pm.Calibration = logger.ConfidenceCalibration(perf.RecentTrades, records)
+		}
+	}
+	for _, trade := range trades {
+		if _, ok := pm.Market[trade.Symbol]; ok {
+			continue
+		}
+		if data, err := market.Get(trade.Symbol); err == nil {
+			pm.Market[trade.Symbol] = market.Format(data)
+		}
+	}
+
+	if err := at.postMortems.Save(pm); err != nil {
+		log.Printf("⚠️  保存复盘包失败: %v", err)
+		return
+	}
+	log.Printf("🩺 [%s] 生成亏损复盘 %s: %s", at.name, pm.ID, detail)
+	at.noteActivity("生成亏损复盘（%s）", detail)
+	at.publishRiskBreach("", "", trigger, fmt.Sprintf("触发亏损复盘：%s（id=%s，等待人工审核）", detail, pm.ID), false)
+
+	if aiAnalysis {
+		go at.analyzePostMortem(pm)
+	}
+}
+
+// analyzePostMortem 调用AI分析复盘包并写回调整建议
+func (at *AutoTrader) analyzePostMortem(pm *logger.PostMortem) {
+	bundle, err := json.MarshalIndent(pm, "", "  ")
+	if err != nil {
+		return
+	}
+	analysis, err := at.mcpClient.CallWithMessages(postMortemSystemPrompt, string(bundle))
+
+	// 审核状态可能已被人工修改，重新读取后只写回分析结果
+	latest, getErr := at.postMortems.Get(pm.ID)
+	if getErr != nil {
+		return
+	}
+	if err != nil {
+		log.Printf("⚠️  复盘AI分析失败 (%s): %v", pm.ID, err)
+		latest.AnalysisErr = err.Error()
+	} else {
+		latest.Analysis = strings.TrimSpace(analysis)
+	}
+	if err := at.postMortems.Save(latest); err != nil {
+		log.Printf("⚠️  保存复盘分析失败: %v", err)
+	}
+}
+
+// GetPostMortems 复盘包列表（新→旧，不含决策明细）
+func (at *AutoTrader) GetPostMortems() []logger.PostMortem {
+	return at.postMortems.List()
+}
+
+// GetPostMortem 复盘包详情
+func (at *AutoTrader) GetPostMortem(id string) (*logger.PostMortem, error) {
+	return at.postMortems.Get(id)
+}
+
+// ReviewPostMortem 人工审核复盘建议（accepted / dismissed）
+func (at *AutoTrader) ReviewPostMortem(id, status, user, note string) (*logger.PostMortem, error) {
+	return at.postMortems.Review(id, status, user, note)
+}
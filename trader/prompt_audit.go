@@ -0,0 +1,58 @@
+package trader
+
+import (
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+	"time"
+)
+
+// promptIntentMaxAge 修改意图的有效期（超过后视为与检测到的变化无关，按系统变更记录）
+const promptIntentMaxAge = 24 * time.Hour
+
+// auditSystemPrompt 检测本周期 System Prompt 是否与上次记录不同，不同则记录差异、修改人和原因
+// 使用固定净值构建规范化提示词，避免净值浮动导致每个周期都产生差异
+func (at *AutoTrader) auditSystemPrompt(ctx *decision.Context) {
+	prompt := decision.CanonicalSystemPrompt(ctx.BTCETHLeverage, ctx.AltcoinLeverage, at.customPrompt, at.overrideBasePrompt, at.systemPromptTemplate, ctx.SchemaVersion, ctx.Persona)
+	if !at.promptAudit.Changed(prompt) {
+		return
+	}
+
+	change := logger.PromptChange{
+		CycleNumber: at.callCount,
+		Equity:      ctx.Account.TotalEquity,
+		Inputs: logger.PromptInputs{
+			Template:         at.systemPromptTemplate,
+			SchemaVersion:    ctx.SchemaVersion,
+			BTCETHLeverage:   ctx.BTCETHLeverage,
+			AltcoinLeverage:  ctx.AltcoinLeverage,
+			OverrideBase:     at.overrideBasePrompt,
+			CustomPromptHash: logger.PromptHash(at.customPrompt),
+		},
+	}
+	if ctx.Persona != nil {
+		change.Inputs.Persona = ctx.Persona.Name
+	}
+	type PromptIntentTaker interface {
+		TakePromptChangeIntent(traderID string, maxAge time.Duration) (user, reason string, ok bool)
+	}
+	if db, ok := at.database.(PromptIntentTaker); ok {
+		if user, reason, ok := db.TakePromptChangeIntent(at.id, promptIntentMaxAge); ok {
+			change.Author = user
+			change.Reason = reason
+		}
+	}
+
+	recorded, err := at.promptAudit.Record(prompt, change)
+	if err != nil {
+		log.Printf("⚠️  [%s] 记录提示词变更失败: %v", at.name, err)
+	}
+	if recorded != nil {
+		log.Printf("📝 [%s] System Prompt 已变更: %s (+%d/-%d 行, 修改人: %s)", at.name, recorded.Detected, recorded.Added, recorded.Removed, recorded.Author)
+	}
+}
+
+// GetPromptTimeline 获取 System Prompt 变更时间线（新→旧）
+func (at *AutoTrader) GetPromptTimeline(limit int) []logger.PromptChange {
+	return at.promptAudit.Timeline(limit)
+}
@@ -0,0 +1,23 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/logger"
+)
+
+// tagActionRecord 将决策理由、关联的交易想法及提取的结构化标签写入执行记录
+// 人工提交或队列重放的决策可能没有预先提取标签，此时现场提取
+func tagActionRecord(a *logger.DecisionAction, d *decision.Decision) {
+	a.Confidence = d.Confidence
+	a.Reasoning = d.Reasoning
+	a.IdeaID = d.IdeaID
+	tags := d.Tags
+	if tags == nil {
+		extracted := decision.ExtractReasoningTags(d.Reasoning)
+		tags = &extracted
+	}
+	a.Signals = tags.Signals
+	a.Timeframes = tags.Timeframes
+	a.Levels = tags.Levels
+	a.RiskNotes = tags.RiskNotes
+}
@@ -0,0 +1,152 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/decision"
+	"nofx/market"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReentrySuppressMinutes  = 30  // 未配置 reentry_suppress_minutes 时的抑制窗口
+	defaultReentrySuppressPricePct = 1.5 // 未配置 reentry_suppress_price_pct 时的相近价格范围（%）
+	maxEntrySetbacks               = 100 // 保留的受挫记录上限
+)
+
+// 开仓受挫类型
+const (
+	SetbackRejected       = "rejected"        // 开仓被风控拒绝
+	SetbackStopLoss       = "stop_loss"       // 持仓被止损
+	SetbackEmergencyClose = "emergency_close" // 止损缺失被紧急平仓
+)
+
+// entrySetback 一次开仓受挫（被拒绝或被止损），用于识别相近价格的同向重复开仓
+type entrySetback struct {
+	Symbol string
+	Side   string // long / short
+	Kind   string
+	Price  float64
+	Detail string
+	At     time.Time
+}
+
+// entrySetbacks 近期开仓受挫记录（进程内，重启后清空）
+type entrySetbacks struct {
+	mu    sync.Mutex
+	items []entrySetback
+}
+
+// add 记录一次受挫（超过上限时丢弃最早的记录）
+func (h *entrySetbacks) add(s entrySetback) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.items = append(h.items, s)
+	if len(h.items) > maxEntrySetbacks {
+		h.items = h.items[len(h.items)-maxEntrySetbacks:]
+	}
+}
+
+// match 窗口内同币种同方向、价格偏差不超过 pricePct 的最近一次受挫
+func (h *entrySetbacks) match(symbol, side string, price float64, now time.Time, window time.Duration, pricePct float64) *entrySetback {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := len(h.items) - 1; i >= 0; i-- {
+		s := h.items[i]
+		if now.Sub(s.At) > window {
+			break
+		}
+		if s.Symbol != symbol || s.Side != side || s.Price <= 0 {
+			continue
+		}
+		if math.Abs(price-s.Price)/s.Price*100 <= pricePct {
+			return &s
+		}
+	}
+	return nil
+}
+
+// recordSetback 记录开仓受挫（价格未知时无法判断"相近价格"，不记录）
+func (at *AutoTrader) recordSetback(symbol, side, kind string, price float64, detail string) {
+	if price <= 0 {
+		return
+	}
+	at.setbacks.add(entrySetback{Symbol: symbol, Side: side, Kind: kind, Price: price, Detail: detail, At: time.Now()})
+}
+
+// reentrySuppressConfig 抑制窗口（0=关闭）和相近价格范围
+// 优先级：系统配置 <key>:<trader_id> > 全局 <key> > 默认值
+func (at *AutoTrader) reentrySuppressConfig() (time.Duration, float64) {
+	window, pricePct := defaultReentrySuppressMinutes*time.Minute, defaultReentrySuppressPricePct
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	db, ok := at.database.(SystemConfigGetter)
+	if !ok {
+		return window, pricePct
+	}
+	lookup := func(key string) string {
+		for _, k := range []string{key + ":" + at.id, key} {
+			if value, err := db.GetSystemConfig(k); err == nil && value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+	if minutes, err := strconv.Atoi(lookup("reentry_suppress_minutes")); err == nil && minutes >= 0 {
+		window = time.Duration(minutes) * time.Minute
+	}
+	if pct, err := strconv.ParseFloat(lookup("reentry_suppress_price_pct"), 64); err == nil && pct >= 0 {
+		pricePct = pct
+	}
+	return window, pricePct
+}
+
+// entrySide 开仓动作对应的持仓方向（非开仓返回空）
+func entrySide(action string) string {
+	switch action {
+	case "open_long":
+		return "long"
+	case "open_short":
+		return "short"
+	}
+	return ""
+}
+
+// suppressRepeatEntries 近期被拒绝或被止损后，在相近价格同向重复开仓的决策转为 wait（避免报复性交易循环），返回执行日志
+func (at *AutoTrader) suppressRepeatEntries(decisions []decision.Decision, marketData map[string]*market.Data, now time.Time) []string {
+	window, pricePct := at.reentrySuppressConfig()
+	if window <= 0 {
+		return nil
+	}
+	var notes []string
+	for i := range decisions {
+		d := &decisions[i]
+		side := entrySide(d.Action)
+		data := marketData[d.Symbol]
+		if side == "" || data == nil || data.CurrentPrice <= 0 {
+			continue
+		}
+		s := at.setbacks.match(d.Symbol, side, data.CurrentPrice, now, window, pricePct)
+		if s == nil {
+			continue
+		}
+		what := "被止损"
+		switch s.Kind {
+		case SetbackRejected:
+			what = "被拒绝"
+		case SetbackEmergencyClose:
+			what = "被紧急平仓"
+		}
+		reason := fmt.Sprintf("%.0f分钟前同向开仓%s（@%.4g，当前 %.4g）", now.Sub(s.At).Minutes(), what, s.Price, data.CurrentPrice)
+		if s.Detail != "" {
+			reason += "：" + s.Detail
+		}
+		notes = append(notes, fmt.Sprintf("🔁 %s %s 重复开仓抑制，已转为 wait - %s", d.Symbol, d.Action, reason))
+		d.Reasoning = fmt.Sprintf("[重复开仓抑制，原决策 %s：%s] %s", d.Action, reason, d.Reasoning)
+		d.Action = "wait"
+	}
+	return notes
+}
@@ -0,0 +1,117 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEntrySetbacksMatch(t *testing.T) {
+	now := time.Now()
+	h := &entrySetbacks{}
+	h.add(entrySetback{Symbol: "BTCUSDT", Side: "long", Kind: SetbackStopLoss, Price: 100000, At: now.Add(-50 * time.Minute)})
+	h.add(entrySetback{Symbol: "BTCUSDT", Side: "long", Kind: SetbackRejected, Price: 60000, At: now.Add(-10 * time.Minute)})
+	h.add(entrySetback{Symbol: "ETHUSDT", Side: "short", Kind: SetbackStopLoss, Price: 3000, At: now.Add(-5 * time.Minute)})
+
+	tests := []struct {
+		name   string
+		symbol string
+		side   string
+		price  float64
+		want   string // 命中记录的类型，空=不命中
+	}{
+		{name: "相近价格同向", symbol: "BTCUSDT", side: "long", price: 60500, want: SetbackRejected},
+		{name: "价格偏离超出范围", symbol: "BTCUSDT", side: "long", price: 62000},
+		{name: "反方向不抑制", symbol: "BTCUSDT", side: "short", price: 60000},
+		{name: "超出时间窗口", symbol: "BTCUSDT", side: "long", price: 100000},
+		{name: "止损后同向", symbol: "ETHUSDT", side: "short", price: 2980, want: SetbackStopLoss},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := h.match(tt.symbol, tt.side, tt.price, now, 30*time.Minute, 1.5)
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("不应命中，得到 %+v", got)
+				}
+				return
+			}
+			if got == nil || got.Kind != tt.want {
+				t.Errorf("match = %+v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuppressRepeatEntries(t *testing.T) {
+	db := memoryConfig{}
+	at := &AutoTrader{id: "t1", name: "test", database: db, narrative: &activityNarrative{}}
+	at.recordSetback("SOLUSDT", "long", SetbackStopLoss, 150, "")
+	marketData := map[string]*market.Data{
+		"SOLUSDT": {Symbol: "SOLUSDT", CurrentPrice: 151},
+		"BTCUSDT": {Symbol: "BTCUSDT", CurrentPrice: 60000},
+	}
+	newDecisions := func() []decision.Decision {
+		return []decision.Decision{
+			{Symbol: "SOLUSDT", Action: "open_long", Reasoning: "反弹"},
+			{Symbol: "SOLUSDT", Action: "open_short"},
+			{Symbol: "BTCUSDT", Action: "open_long"},
+		}
+	}
+
+	decisions := newDecisions()
+	notes := at.suppressRepeatEntries(decisions, marketData, time.Now())
+	if len(notes) != 1 || decisions[0].Action != "wait" || !strings.Contains(decisions[0].Reasoning, "被止损") {
+		t.Errorf("止损后相近价格同向开仓应转为 wait: %v %+v", notes, decisions[0])
+	}
+	if decisions[1].Action != "open_short" || decisions[2].Action != "open_long" {
+		t.Errorf("其他开仓不应受影响: %+v", decisions)
+	}
+
+	db["reentry_suppress_minutes:t1"] = "0"
+	decisions = newDecisions()
+	if notes := at.suppressRepeatEntries(decisions, marketData, time.Now()); len(notes) != 0 || decisions[0].Action != "open_long" {
+		t.Errorf("交易员配置为0时应关闭抑制: %v", notes)
+	}
+
+	db["reentry_suppress_minutes:t1"] = ""
+	db["reentry_suppress_price_pct"] = "0.5"
+	decisions = newDecisions()
+	if notes := at.suppressRepeatEntries(decisions, marketData, time.Now()); len(notes) != 0 {
+		t.Errorf("价格偏差超过全局范围时不应抑制: %v", notes)
+	}
+}
+
+func TestStopOutAfterRestartRecordsSetback(t *testing.T) {
+	dir := t.TempDir()
+	positions := []map[string]interface{}{
+		{"symbol": "BTCUSDT", "side": "long", "entryPrice": 100000.0, "markPrice": 98500.0, "positionAmt": 0.01},
+	}
+	exchange := &stopBookTrader{
+		fakeTrader: fakeTrader{positions: positions},
+		stops:      map[string][]StopOrderInfo{"BTCUSDT": {{OrderID: 1, PositionSide: "LONG", StopPrice: 98000}}},
+	}
+	// 重启后的交易员：内存中没有止损价和持仓快照
+	at := &AutoTrader{
+		name:                 "test",
+		trader:               exchange,
+		narrative:            &activityNarrative{},
+		stopLossPrices:       make(map[string]float64),
+		symbolMemory:         logger.NewSymbolMemoryStore(dir),
+		timeframeReliability: logger.NewTimeframeHitStore(dir),
+	}
+
+	// 第一个周期：从交易所恢复止损价并记录持仓快照
+	at.syncStopLosses(positions)
+	at.rememberPositions([]decision.PositionInfo{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100000, MarkPrice: 98500, UnrealizedPnL: -15}})
+
+	// 第二个周期：持仓在交易所侧被止损平掉
+	at.rememberPositions(nil)
+
+	s := at.setbacks.match("BTCUSDT", "long", 98100, time.Now(), time.Hour, 1)
+	if s == nil || s.Kind != SetbackStopLoss || s.Price != 98000 {
+		t.Fatalf("重启后的止损平仓应记为止损受挫 @98000, got %+v", s)
+	}
+}
@@ -0,0 +1,69 @@
+package trader
+
+import (
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// GetReportingCurrency 报告货币（系统配置 reporting_currency，如 EUR / CNY；为空或USD/USDT时不换算）
+func (at *AutoTrader) GetReportingCurrency() string {
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		if value, err := db.GetSystemConfig("reporting_currency"); err == nil {
+			if currency := logger.NormalizeCurrency(value); currency != "USD" {
+				return currency
+			}
+		}
+	}
+	return ""
+}
+
+// toReporting 将USDT金额按 t 所在日期的汇率换算为报告货币（未配置或汇率获取失败时返回 nil）
+func (at *AutoTrader) toReporting(amount decimal.Decimal, t time.Time) *logger.ReportingAmount {
+	currency := at.GetReportingCurrency()
+	if currency == "" {
+		return nil
+	}
+	reporting, err := at.fxRates.Convert(amount, currency, t)
+	if err != nil {
+		log.Printf("⚠️  [%s] 换算报告货币失败: %v", at.name, err)
+		return nil
+	}
+	return reporting
+}
+
+// annotateRealizedPnL 平仓成交后在决策记录中写入已实现盈亏（按决策时的持仓均价估算）及报告货币金额
+func (at *AutoTrader) annotateRealizedPnL(ctx *decision.Context, d *decision.Decision, actionRecord *logger.DecisionAction) {
+	side := ""
+	switch d.Action {
+	case "close_long":
+		side = "long"
+	case "close_short":
+		side = "short"
+	case "partial_close":
+	default:
+		return
+	}
+	price := actionRecord.FillPrice
+	if price <= 0 {
+		price = actionRecord.Price
+	}
+	for _, pos := range ctx.Positions {
+		if pos.Symbol != d.Symbol || (side != "" && pos.Side != side) || pos.EntryPrice <= 0 || price <= 0 {
+			continue
+		}
+		pnl := (price - pos.EntryPrice) * actionRecord.Quantity
+		if pos.Side == "short" {
+			pnl = -pnl
+		}
+		actionRecord.RealizedPnL = pnl
+		actionRecord.Reporting = at.toReporting(logger.Money(pnl), actionRecord.Timestamp)
+		return
+	}
+}
@@ -0,0 +1,112 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/decision"
+)
+
+// 仓位分配模式
+const (
+	AllocationAI         = "ai"          // AI 自行决定每笔仓位大小（默认）
+	AllocationRiskParity = "risk_parity" // 按ATR估算风险，使同周期开仓信号与现有持仓的风险贡献大致相等
+)
+
+// getAllocationMode 获取仓位分配模式（交易员配置 > 系统配置 allocation_mode > ai）
+func (at *AutoTrader) getAllocationMode() string {
+	mode := at.config.AllocationMode
+	if mode == "" {
+		type SystemConfigGetter interface {
+			GetSystemConfig(key string) (string, error)
+		}
+		if db, ok := at.database.(SystemConfigGetter); ok {
+			mode, _ = db.GetSystemConfig("allocation_mode")
+		}
+	}
+	if mode == AllocationRiskParity {
+		return mode
+	}
+	return AllocationAI
+}
+
+// riskParityItem 参与风险平价分配的仓位（现有持仓或开仓信号）
+type riskParityItem struct {
+	SizeUSD float64 // 名义价值
+	RiskPct float64 // 单位名义价值的估算风险（4h ATR14 / 价格）
+}
+
+// riskParitySizes 计算开仓信号的风险平价仓位
+// 单位风险 = (现有持仓风险 + AI给出的信号风险) / 仓位数，每个信号仓位 = 单位风险 / 该币种风险率
+// 保持组合总风险与AI给出的一致，只在仓位之间重新分配
+func riskParitySizes(existing, signals []riskParityItem) []float64 {
+	totalRisk := 0.0
+	for _, item := range existing {
+		totalRisk += item.SizeUSD * item.RiskPct
+	}
+	for _, item := range signals {
+		totalRisk += item.SizeUSD * item.RiskPct
+	}
+
+	unitRisk := totalRisk / float64(len(existing)+len(signals))
+	sizes := make([]float64, len(signals))
+	for i, item := range signals {
+		sizes[i] = unitRisk / item.RiskPct
+	}
+	return sizes
+}
+
+// applyRiskParity 风险平价模式下重新分配本周期开仓信号的仓位
+// 缺少ATR的信号保持AI仓位不参与分配；调整后未通过验证（超出净值倍数或流动性上限）时保留原仓位
+func (at *AutoTrader) applyRiskParity(decisions []decision.Decision, ctx *decision.Context) []string {
+	if at.getAllocationMode() != AllocationRiskParity {
+		return nil
+	}
+
+	riskPct := func(symbol string) float64 {
+		data, ok := ctx.MarketDataMap[symbol]
+		if !ok || data.LongerTermContext == nil || data.CurrentPrice <= 0 {
+			return 0
+		}
+		return data.LongerTermContext.ATR14 / data.CurrentPrice
+	}
+
+	var existing []riskParityItem
+	for _, pos := range ctx.Positions {
+		if r := riskPct(pos.Symbol); r > 0 {
+			existing = append(existing, riskParityItem{SizeUSD: pos.Quantity * pos.MarkPrice, RiskPct: r})
+		}
+	}
+
+	var signals []riskParityItem
+	var indexes []int
+	for i, d := range decisions {
+		if d.Action != "open_long" && d.Action != "open_short" || d.PositionSizeUSD <= 0 {
+			continue
+		}
+		if r := riskPct(d.Symbol); r > 0 {
+			signals = append(signals, riskParityItem{SizeUSD: d.PositionSizeUSD, RiskPct: r})
+			indexes = append(indexes, i)
+		}
+	}
+	if len(signals) == 0 || len(existing)+len(signals) < 2 {
+		return nil
+	}
+
+	var notes []string
+	limits := at.getLiquidityLimits()
+	for j, size := range riskParitySizes(existing, signals) {
+		d := &decisions[indexes[j]]
+		adjusted := *d
+		adjusted.PositionSizeUSD = size
+		liquidityCap := decision.LiquidityMaxPositionUSD(ctx.MarketDataMap[d.Symbol], limits)
+		if err := decision.ValidateDecision(&adjusted, ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage, liquidityCap); err != nil {
+			log.Printf("  ⚖️ 风险平价: %s 调整为 %.2f USDT 未通过验证，保留AI仓位 %.2f: %v", d.Symbol, size, d.PositionSizeUSD, err)
+			continue
+		}
+		note := fmt.Sprintf("⚖️ 风险平价: %s 仓位 %.2f → %.2f USDT（ATR风险率 %.2f%%）", d.Symbol, d.PositionSizeUSD, size, signals[j].RiskPct*100)
+		log.Printf("  %s", note)
+		notes = append(notes, note)
+		d.PositionSizeUSD = size
+	}
+	return notes
+}
@@ -0,0 +1,44 @@
+package trader
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRiskParitySizes(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []riskParityItem
+		signals  []riskParityItem
+		want     []float64
+	}{
+		{
+			name:    "两个信号按风险率反比分配且总风险不变",
+			signals: []riskParityItem{{SizeUSD: 1000, RiskPct: 0.02}, {SizeUSD: 1000, RiskPct: 0.04}},
+			want:    []float64{1500, 750}, // 总风险 60，每个 30
+		},
+		{
+			name:     "新信号与现有持仓风险对齐",
+			existing: []riskParityItem{{SizeUSD: 2000, RiskPct: 0.01}},
+			signals:  []riskParityItem{{SizeUSD: 500, RiskPct: 0.04}},
+			want:     []float64{500}, // 总风险 40，每个 20
+		},
+		{
+			name:     "高波动信号被缩小",
+			existing: []riskParityItem{{SizeUSD: 1000, RiskPct: 0.01}},
+			signals:  []riskParityItem{{SizeUSD: 1000, RiskPct: 0.05}},
+			want:     []float64{600}, // 总风险 60，每个 30
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := riskParitySizes(tt.existing, tt.signals)
+			for i := range tt.want {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Errorf("sizes = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/decision"
+	"nofx/market"
+)
+
+// maintenanceMarginRate 估算强平价使用的维持保证金率（币安低档位约0.4%~0.5%）
+const maintenanceMarginRate = 0.005
+
+// RuleCheck 单条规则检查结果
+type RuleCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+// RiskPreview 待执行决策的风险预览（人工审批前展示完整后果，不会下单）
+type RiskPreview struct {
+	Symbol       string  `json:"symbol"`
+	Action       string  `json:"action"`
+	CurrentPrice float64 `json:"current_price"`
+	Quantity     float64 `json:"quantity"`
+
+	// 开仓
+	PositionSizeUSD       float64 `json:"position_size_usd,omitempty"`       // 波动率目标调整后的仓位价值
+	Leverage              int     `json:"leverage,omitempty"`                // 受配置上限约束后的杠杆
+	RequiredMargin        float64 `json:"required_margin,omitempty"`         // 所需保证金
+	EstimatedFee          float64 `json:"estimated_fee,omitempty"`           // 手续费估算（Taker）
+	LiquidationPrice      float64 `json:"liquidation_price,omitempty"`       // 强平价估算
+	StopDistancePct       float64 `json:"stop_distance_pct,omitempty"`       // 止损距离
+	RiskRewardRatio       float64 `json:"risk_reward_ratio,omitempty"`       // 风险回报比
+	StopBeyondLiquidation bool    `json:"stop_beyond_liquidation,omitempty"` // 止损在强平价之外（会先被强平）
+
+	// 平仓
+	ReleasedMargin   float64 `json:"released_margin,omitempty"`   // 释放的保证金
+	EstimatedPnL     float64 `json:"estimated_pnl,omitempty"`     // 按当前价估算的已实现盈亏
+	CloseFraction    float64 `json:"close_fraction,omitempty"`    // 平仓比例
+	ExistingPosition bool    `json:"existing_position,omitempty"` // 持仓存在
+
+	// 账户影响
+	TotalEquity         float64 `json:"total_equity"`
+	AvailableBefore     float64 `json:"available_before"`
+	AvailableAfter      float64 `json:"available_after"`
+	MarginUsedPctBefore float64 `json:"margin_used_pct_before"`
+	MarginUsedPctAfter  float64 `json:"margin_used_pct_after"`
+	OpenRiskBeforeUSD   float64 `json:"open_risk_before_usd"`
+	OpenRiskDeltaUSD    float64 `json:"open_risk_delta_usd"`
+	OpenRiskAfterPct    float64 `json:"open_risk_after_pct"`
+	MaxOpenRiskPct      float64 `json:"max_open_risk_pct"`
+	PositionCountBefore int     `json:"position_count_before"`
+	PositionCountAfter  int     `json:"position_count_after"`
+
+	Checks     []RuleCheck `json:"checks"`
+	Approvable bool        `json:"approvable"` // 全部规则检查通过
+}
+
+// PreviewDecisionRisk 计算决策执行后的保证金影响、强平价、开放风险变化和规则检查结果（只读，不下单）
+func (at *AutoTrader) PreviewDecisionRisk(d decision.Decision) (*RiskPreview, error) {
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		return nil, fmt.Errorf("获取账户余额失败: %w", err)
+	}
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	at.syncStopLosses(positions)
+	return at.previewDecisionRisk(d, balance, positions, at.getStopLoss)
+}
+
+// previewDecisionRisk 基于给定的账户余额、持仓和止损价来源计算风险预览（真实账户与沙盒模拟共用）
+func (at *AutoTrader) previewDecisionRisk(d decision.Decision, balance map[string]interface{}, positions []map[string]interface{}, stopLoss func(symbol, side string) (float64, bool)) (*RiskPreview, error) {
+	marketData, err := market.Get(d.Symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取行情失败: %w", err)
+	}
+
+	walletBalance, _ := balance["totalWalletBalance"].(float64)
+	unrealizedProfit, _ := balance["totalUnrealizedProfit"].(float64)
+	availableBalance, _ := balance["availableBalance"].(float64)
+	totalEquity := walletBalance + unrealizedProfit
+
+	marginUsed := 0.0
+	for _, pos := range positions {
+		marginUsed += positionMargin(pos)
+	}
+	openRisk := at.calculateOpenRiskWith(positions, totalEquity, stopLoss)
+
+	preview := &RiskPreview{
+		Symbol:              d.Symbol,
+		Action:              d.Action,
+		CurrentPrice:        marketData.CurrentPrice,
+		TotalEquity:         totalEquity,
+		AvailableBefore:     availableBalance,
+		AvailableAfter:      availableBalance,
+		OpenRiskBeforeUSD:   openRisk.TotalRiskUSD,
+		MaxOpenRiskPct:      openRisk.MaxRiskPct,
+		PositionCountBefore: len(positions),
+		PositionCountAfter:  len(positions),
+		Checks:              []RuleCheck{},
+	}
+	check := func(name string, passed bool, format string, args ...interface{}) {
+		preview.Checks = append(preview.Checks, RuleCheck{Name: name, Passed: passed, Detail: fmt.Sprintf(format, args...)})
+	}
+	marginAfter := marginUsed
+
+	switch d.Action {
+	case "open_long", "open_short":
+		side := "long"
+		if d.Action == "open_short" {
+			side = "short"
+		}
+
+		liquidityCap := decision.LiquidityMaxPositionUSD(marketData, at.getLiquidityLimits())
+		persona := at.getRiskPersona()
+		btcEthLeverage, altcoinLeverage := at.leverageCaps(persona)
+		err := decision.ValidateDecision(&d, totalEquity, btcEthLeverage, altcoinLeverage, liquidityCap)
+		check("decision_valid", err == nil, "%s", errString(err, "杠杆、仓位大小（含流动性上限）、止损止盈方向均合法"))
+		if persona != nil {
+			detail := checkPersonaEntry(persona, &d, marketData.CurrentPrice, len(positions))
+			passed := detail == ""
+			if passed {
+				detail = "满足" + persona.Label + "档的信心度、风险回报比和持仓数要求"
+			}
+			check("risk_persona", passed, "%s", detail)
+		}
+
+		at.applyVolatilityTarget(&d, marketData)
+		leverage := d.Leverage
+		if leverage <= 0 {
+			leverage = 1
+		}
+		preview.PositionSizeUSD = d.PositionSizeUSD
+		preview.Leverage = leverage
+		preview.Quantity = d.PositionSizeUSD / marketData.CurrentPrice
+		preview.RequiredMargin = d.PositionSizeUSD / float64(leverage)
+		preview.EstimatedFee = d.PositionSizeUSD * takerFeeRate
+		preview.AvailableAfter = availableBalance - preview.RequiredMargin - preview.EstimatedFee
+		preview.LiquidationPrice = estimateLiquidationPrice(side, marketData.CurrentPrice, leverage)
+		marginAfter += preview.RequiredMargin
+
+		if d.StopLoss > 0 {
+			preview.StopDistancePct = math.Abs(marketData.CurrentPrice-d.StopLoss) / marketData.CurrentPrice * 100
+			if risk := math.Abs(marketData.CurrentPrice - d.StopLoss); risk > 0 && d.TakeProfit > 0 {
+				preview.RiskRewardRatio = math.Abs(d.TakeProfit-marketData.CurrentPrice) / risk
+			}
+			preview.StopBeyondLiquidation = (side == "long" && d.StopLoss <= preview.LiquidationPrice) ||
+				(side == "short" && d.StopLoss >= preview.LiquidationPrice)
+		}
+		preview.OpenRiskDeltaUSD = math.Abs(marketData.CurrentPrice-d.StopLoss) * preview.Quantity
+
+		sameSide, symbolHeld := false, false
+		for _, pos := range positions {
+			if pos["symbol"] == d.Symbol {
+				symbolHeld = true
+				if pos["side"] == side {
+					sameSide = true
+				}
+			}
+		}
+		if !symbolHeld {
+			preview.PositionCountAfter++
+		}
+
+		if sameSide {
+			check("no_same_side_position", false, "%s 已有%s仓，开仓会被拒绝", d.Symbol, side)
+		} else {
+			check("no_same_side_position", true, "无同方向持仓")
+		}
+		check("position_slots", preview.PositionCountAfter <= decision.MaxPositions, "开仓后持仓 %d 个，上限 %d", preview.PositionCountAfter, decision.MaxPositions)
+		check("margin_sufficient", preview.AvailableAfter >= 0, "需要 %.2f USDT（保证金 %.2f + 手续费 %.2f），可用 %.2f USDT",
+			preview.RequiredMargin+preview.EstimatedFee, preview.RequiredMargin, preview.EstimatedFee, availableBalance)
+		maxRiskUSD := totalEquity * openRisk.MaxRiskPct / 100
+		check("open_risk_cap", openRisk.TotalRiskUSD+preview.OpenRiskDeltaUSD <= maxRiskUSD, "现有 %.2f + 新仓 %.2f，上限 %.2f USDT (%.1f%%)",
+			openRisk.TotalRiskUSD, preview.OpenRiskDeltaUSD, maxRiskUSD, openRisk.MaxRiskPct)
+		check("stop_before_liquidation", !preview.StopBeyondLiquidation, "止损 %.4f，强平价估算 %.4f", d.StopLoss, preview.LiquidationPrice)
+
+	case "close_long", "close_short", "partial_close":
+		var matched []map[string]interface{}
+		for _, pos := range positions {
+			if pos["symbol"] != d.Symbol {
+				continue
+			}
+			if (d.Action == "close_long" && pos["side"] != "long") || (d.Action == "close_short" && pos["side"] != "short") {
+				continue
+			}
+			matched = append(matched, pos)
+		}
+		preview.ExistingPosition = len(matched) > 0
+		check("position_exists", len(matched) > 0, "%s 匹配持仓 %d 个", d.Symbol, len(matched))
+		fraction := 1.0
+		if d.Action == "partial_close" {
+			check("single_side", len(matched) <= 1, "部分平仓要求该币种只有单向持仓")
+			fraction = 0
+			if len(matched) > 0 {
+				posAmt, _ := matched[0]["positionAmt"].(float64)
+				quantity, err := d.ReduceQuantity(math.Abs(posAmt), marketData.CurrentPrice)
+				if err == nil {
+					fraction = quantity / math.Abs(posAmt)
+				}
+				check("reduce_size", err == nil && fraction > 0, "%s", errString(err, fmt.Sprintf("减仓 %s，占持仓 %.1f%%", d.ReduceDescription(), fraction*100)))
+			}
+		}
+		preview.CloseFraction = fraction
+
+		for _, pos := range matched {
+			side, _ := pos["side"].(string)
+			entryPrice, _ := pos["entryPrice"].(float64)
+			posAmt, _ := pos["positionAmt"].(float64)
+			quantity := math.Abs(posAmt) * fraction
+			margin := positionMargin(pos) * fraction
+
+			pnl := (marketData.CurrentPrice - entryPrice) * quantity
+			if side == "short" {
+				pnl = -pnl
+			}
+			preview.Quantity += quantity
+			preview.ReleasedMargin += margin
+			preview.EstimatedPnL += pnl
+			preview.OpenRiskDeltaUSD -= openRisk.PositionRisk[d.Symbol+"_"+side] * fraction
+			if fraction >= 1 {
+				preview.PositionCountAfter--
+			}
+		}
+		preview.EstimatedFee = preview.Quantity * marketData.CurrentPrice * takerFeeRate
+		preview.AvailableAfter = availableBalance + preview.ReleasedMargin + preview.EstimatedPnL - preview.EstimatedFee
+		marginAfter -= preview.ReleasedMargin
+
+	default:
+		check("action_supported", false, "%s 不涉及保证金或持仓变化，无需预览", d.Action)
+	}
+
+	if totalEquity > 0 {
+		preview.MarginUsedPctBefore = marginUsed / totalEquity * 100
+		preview.MarginUsedPctAfter = marginAfter / totalEquity * 100
+		preview.OpenRiskAfterPct = (openRisk.TotalRiskUSD + preview.OpenRiskDeltaUSD) / totalEquity * 100
+	}
+
+	preview.Approvable = true
+	for _, c := range preview.Checks {
+		if !c.Passed {
+			preview.Approvable = false
+			break
+		}
+	}
+	return preview, nil
+}
+
+// positionMargin 持仓占用保证金（数量 × 标记价 / 杠杆）
+func positionMargin(pos map[string]interface{}) float64 {
+	markPrice, _ := pos["markPrice"].(float64)
+	posAmt, _ := pos["positionAmt"].(float64)
+	leverage := 10.0
+	if lev, ok := pos["leverage"].(float64); ok && lev > 0 {
+		leverage = lev
+	}
+	return math.Abs(posAmt) * markPrice / leverage
+}
+
+// estimateLiquidationPrice 逐仓强平价估算：多 = 入场价 × (1 - 1/杠杆 + 维持保证金率)，空反之
+func estimateLiquidationPrice(side string, entryPrice float64, leverage int) float64 {
+	if leverage <= 0 {
+		return 0
+	}
+	if side == "long" {
+		return entryPrice * (1 - 1/float64(leverage) + maintenanceMarginRate)
+	}
+	return entryPrice * (1 + 1/float64(leverage) - maintenanceMarginRate)
+}
+
+// errString 错误信息，无错误时返回默认描述
+func errString(err error, ok string) string {
+	if err != nil {
+		return err.Error()
+	}
+	return ok
+}
@@ -0,0 +1,79 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"nofx/mcp"
+)
+
+// samplingParamsKey 交易员模型采样参数在系统配置中的键（未设置时使用全局默认 sampling_params）
+func samplingParamsKey(traderID string) string {
+	return "sampling_params:" + traderID
+}
+
+// loadSamplingParams 加载模型采样参数：API设置的交易员配置 > 交易员启动配置 > 全局 sampling_params > 内置默认
+func (at *AutoTrader) loadSamplingParams() mcp.SamplingParams {
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	db, ok := at.database.(SystemConfigGetter)
+
+	parse := func(key string) (mcp.SamplingParams, bool) {
+		var params mcp.SamplingParams
+		if !ok {
+			return params, false
+		}
+		value, err := db.GetSystemConfig(key)
+		if err != nil || value == "" {
+			return params, false
+		}
+		if err := json.Unmarshal([]byte(value), &params); err != nil {
+			log.Printf("⚠️  [%s] 解析采样参数 %s 失败: %v", at.name, key, err)
+			return params, false
+		}
+		if err := params.Validate(); err != nil {
+			log.Printf("⚠️  [%s] 采样参数 %s 无效: %v", at.name, key, err)
+			return params, false
+		}
+		return params, true
+	}
+
+	if params, ok := parse(samplingParamsKey(at.id)); ok {
+		return params
+	}
+	if at.config.Sampling != (mcp.SamplingParams{}) {
+		if err := at.config.Sampling.Validate(); err == nil {
+			return at.config.Sampling
+		} else {
+			log.Printf("⚠️  [%s] 交易员采样参数无效，使用默认值: %v", at.name, err)
+		}
+	}
+	if params, ok := parse("sampling_params"); ok {
+		return params
+	}
+	return mcp.SamplingParams{}
+}
+
+// GetSamplingParams 获取模型采样参数（configured=配置值，effective=实际发送给提供商的值）
+func (at *AutoTrader) GetSamplingParams() (configured, effective mcp.SamplingParams) {
+	return at.mcpClient.Sampling, at.mcpClient.EffectiveSampling()
+}
+
+// UpdateSamplingParams 更新并持久化模型采样参数（下个决策周期生效）
+func (at *AutoTrader) UpdateSamplingParams(params mcp.SamplingParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	type SystemConfigSetter interface {
+		SetSystemConfig(key, value string) error
+	}
+	if db, ok := at.database.(SystemConfigSetter); ok {
+		data, _ := json.Marshal(params)
+		if err := db.SetSystemConfig(samplingParamsKey(at.id), string(data)); err != nil {
+			return fmt.Errorf("保存采样参数失败: %w", err)
+		}
+	}
+	at.mcpClient.SetSampling(params)
+	return nil
+}
@@ -0,0 +1,260 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/decision"
+	"nofx/market"
+	"strings"
+	"time"
+)
+
+// SandboxPosition 沙盒模拟的假设持仓
+type SandboxPosition struct {
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"` // long / short
+	EntryPrice float64 `json:"entry_price"`
+	MarkPrice  float64 `json:"mark_price,omitempty"` // 未提供时按入场价
+	Quantity   float64 `json:"quantity"`
+	Leverage   int     `json:"leverage,omitempty"`  // 未提供时按10倍
+	StopLoss   float64 `json:"stop_loss,omitempty"` // 未提供时按强平价估算风险
+}
+
+// SandboxAccount 沙盒模拟的假设账户状态
+type SandboxAccount struct {
+	TotalEquity      float64           `json:"total_equity"`
+	AvailableBalance float64           `json:"available_balance,omitempty"` // 未提供时按 净值 - 持仓保证金 估算
+	Positions        []SandboxPosition `json:"positions"`
+}
+
+// SandboxRecommendation 沙盒中AI给出的一条决策及其风险检查结果
+type SandboxRecommendation struct {
+	Decision   decision.Decision `json:"decision"`
+	Preview    *RiskPreview      `json:"preview,omitempty"` // 开平仓决策的风险预览（hold/wait 无预览）
+	PassesRisk bool              `json:"passes_risk"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// SandboxResult 沙盒模拟结果（不下单、不写决策日志、不影响真实交易员状态）
+type SandboxResult struct {
+	Symbol          string                      `json:"symbol"`
+	Account         decision.AccountInfo        `json:"account"`
+	CoTTrace        string                      `json:"cot_trace"`
+	Recommendations []SandboxRecommendation     `json:"recommendations"`
+	Rejected        []decision.RejectedDecision `json:"rejected,omitempty"`
+	PassesRisk      bool                        `json:"passes_risk"` // 全部开平仓建议都通过风险检查
+	Timestamp       time.Time                   `json:"timestamp"`
+}
+
+// Validate 校验假设账户
+func (a *SandboxAccount) Validate() error {
+	if a.TotalEquity <= 0 {
+		return fmt.Errorf("total_equity 必须大于0")
+	}
+	if a.AvailableBalance < 0 {
+		return fmt.Errorf("available_balance 不能为负")
+	}
+	for i, pos := range a.Positions {
+		if pos.Symbol == "" {
+			return fmt.Errorf("positions[%d].symbol 不能为空", i)
+		}
+		if pos.Side != "long" && pos.Side != "short" {
+			return fmt.Errorf("positions[%d].side 必须是 long 或 short", i)
+		}
+		if pos.EntryPrice <= 0 || pos.Quantity <= 0 {
+			return fmt.Errorf("positions[%d] 的 entry_price 和 quantity 必须大于0", i)
+		}
+		if pos.MarkPrice < 0 || pos.Leverage < 0 || pos.StopLoss < 0 {
+			return fmt.Errorf("positions[%d] 的 mark_price、leverage、stop_loss 不能为负", i)
+		}
+	}
+	return nil
+}
+
+// exchangeState 把假设账户转换为交易所接口格式的余额和持仓（与 GetBalance/GetPositions 返回结构一致）
+func (a *SandboxAccount) exchangeState() (map[string]interface{}, []map[string]interface{}) {
+	positions := make([]map[string]interface{}, 0, len(a.Positions))
+	unrealized, marginUsed := 0.0, 0.0
+	for _, pos := range a.Positions {
+		symbol := market.Normalize(pos.Symbol)
+		markPrice := pos.MarkPrice
+		if markPrice <= 0 {
+			markPrice = pos.EntryPrice
+		}
+		leverage := pos.Leverage
+		if leverage <= 0 {
+			leverage = 10
+		}
+		posAmt := pos.Quantity
+		pnl := (markPrice - pos.EntryPrice) * pos.Quantity
+		if pos.Side == "short" {
+			posAmt = -posAmt
+			pnl = -pnl
+		}
+		entry := map[string]interface{}{
+			"symbol":           symbol,
+			"side":             pos.Side,
+			"entryPrice":       pos.EntryPrice,
+			"markPrice":        markPrice,
+			"positionAmt":      posAmt,
+			"unRealizedProfit": pnl,
+			"liquidationPrice": estimateLiquidationPrice(pos.Side, pos.EntryPrice, leverage),
+			"leverage":         float64(leverage),
+		}
+		unrealized += pnl
+		marginUsed += positionMargin(entry)
+		positions = append(positions, entry)
+	}
+
+	available := a.AvailableBalance
+	if available == 0 {
+		available = math.Max(a.TotalEquity-marginUsed, 0)
+	}
+	balance := map[string]interface{}{
+		"totalWalletBalance":    a.TotalEquity - unrealized,
+		"totalUnrealizedProfit": unrealized,
+		"availableBalance":      available,
+	}
+	return balance, positions
+}
+
+// stopLossLookup 假设持仓自带的止损价（不读取真实交易员记录的止损）
+func (a *SandboxAccount) stopLossLookup() func(symbol, side string) (float64, bool) {
+	stops := make(map[string]float64)
+	for _, pos := range a.Positions {
+		if pos.StopLoss > 0 {
+			stops[market.Normalize(pos.Symbol)+"_"+pos.Side] = pos.StopLoss
+		}
+	}
+	return func(symbol, side string) (float64, bool) {
+		price, ok := stops[symbol+"_"+side]
+		return price, ok
+	}
+}
+
+// buildSandboxContext 以假设账户构建决策上下文：候选币种只有目标币种和假设持仓，
+// 沿用交易员的风险偏好、杠杆上限和提示词配置，不读取真实账户、历史表现和币种记忆
+func (at *AutoTrader) buildSandboxContext(account *SandboxAccount, symbol string, balance map[string]interface{}, positions []map[string]interface{}) *decision.Context {
+	totalEquity := account.TotalEquity
+	availableBalance, _ := balance["availableBalance"].(float64)
+
+	var positionInfos []decision.PositionInfo
+	totalMarginUsed := 0.0
+	now := time.Now().UnixMilli()
+	for _, pos := range positions {
+		side := pos["side"].(string)
+		entryPrice := pos["entryPrice"].(float64)
+		markPrice := pos["markPrice"].(float64)
+		pnlPct := (markPrice - entryPrice) / entryPrice * 100
+		if side == "short" {
+			pnlPct = -pnlPct
+		}
+		marginUsed := positionMargin(pos)
+		totalMarginUsed += marginUsed
+		positionInfos = append(positionInfos, decision.PositionInfo{
+			Symbol:           pos["symbol"].(string),
+			Side:             side,
+			EntryPrice:       entryPrice,
+			MarkPrice:        markPrice,
+			Quantity:         math.Abs(pos["positionAmt"].(float64)),
+			Leverage:         int(pos["leverage"].(float64)),
+			UnrealizedPnL:    pos["unRealizedProfit"].(float64),
+			UnrealizedPnLPct: pnlPct,
+			LiquidationPrice: pos["liquidationPrice"].(float64),
+			MarginUsed:       marginUsed,
+			UpdateTime:       now,
+		})
+	}
+
+	marginUsedPct := 0.0
+	if totalEquity > 0 {
+		marginUsedPct = totalMarginUsed / totalEquity * 100
+	}
+
+	persona := at.getRiskPersona()
+	btcEthLeverage, altcoinLeverage := at.leverageCaps(persona)
+	return &decision.Context{
+		CurrentTime:     time.Now().Format("2006-01-02 15:04:05"),
+		BTCETHLeverage:  btcEthLeverage,
+		AltcoinLeverage: altcoinLeverage,
+		Persona:         persona,
+		TraderID:        at.id,
+		SchemaVersion:   at.getDecisionSchemaVersion(),
+		Verbosity:       at.getPromptVerbosity(),
+		Liquidity:       at.getLiquidityLimits(),
+		Indicators:      at.GetIndicatorSet(),
+		Timeframes:      at.GetTimeframes(),
+		Account: decision.AccountInfo{
+			TotalEquity:      totalEquity,
+			AvailableBalance: availableBalance,
+			MarginUsed:       totalMarginUsed,
+			MarginUsedPct:    marginUsedPct,
+			PositionCount:    len(positionInfos),
+		},
+		Positions:      positionInfos,
+		CandidateCoins: []decision.CandidateCoin{{Symbol: symbol, Sources: []string{"sandbox"}}},
+	}
+}
+
+// SimulateDecision 沙盒模拟：假设账户为给定状态时，决策流程对目标币种的建议以及能否通过风险检查
+// （调用交易员配置的AI模型，但不下单、不写决策日志、不修改交易员状态）
+func (at *AutoTrader) SimulateDecision(account SandboxAccount, symbol string) (*SandboxResult, error) {
+	symbol = strings.TrimSpace(symbol)
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol 不能为空")
+	}
+	symbol = market.Normalize(symbol)
+	if err := account.Validate(); err != nil {
+		return nil, err
+	}
+
+	balance, positions := account.exchangeState()
+	ctx := at.buildSandboxContext(&account, symbol, balance, positions)
+
+	fullDecision, err := decision.GetFullDecisionWithCustomPrompt(ctx, at.mcpClient, at.customPrompt, at.overrideBasePrompt, at.systemPromptTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("获取AI决策失败: %w", err)
+	}
+
+	result := &SandboxResult{
+		Symbol:          symbol,
+		Account:         ctx.Account,
+		CoTTrace:        fullDecision.CoTTrace,
+		Recommendations: []SandboxRecommendation{},
+		Rejected:        fullDecision.Rejected,
+		PassesRisk:      true,
+		Timestamp:       time.Now(),
+	}
+	// 与真实周期一致：仅观察币种的开仓建议转为 wait
+	convertWatchOnlyOpens(fullDecision.Decisions, at.GetWatchOnlySymbols())
+	stopLoss := account.stopLossLookup()
+	for _, d := range fullDecision.Decisions {
+		result.Recommendations = append(result.Recommendations, at.sandboxRecommendation(d, balance, positions, stopLoss))
+	}
+	for _, rec := range result.Recommendations {
+		if !rec.PassesRisk {
+			result.PassesRisk = false
+			break
+		}
+	}
+	return result, nil
+}
+
+// sandboxRecommendation 对单条建议做风险预览；不涉及保证金或持仓变化的决策视为通过
+func (at *AutoTrader) sandboxRecommendation(d decision.Decision, balance map[string]interface{}, positions []map[string]interface{}, stopLoss func(symbol, side string) (float64, bool)) SandboxRecommendation {
+	rec := SandboxRecommendation{Decision: d, PassesRisk: true}
+	switch d.Action {
+	case "open_long", "open_short", "close_long", "close_short", "partial_close":
+	default:
+		return rec
+	}
+	preview, err := at.previewDecisionRisk(d, balance, positions, stopLoss)
+	if err != nil {
+		rec.PassesRisk = false
+		rec.Error = err.Error()
+		return rec
+	}
+	rec.Preview = preview
+	rec.PassesRisk = preview.Approvable
+	return rec
+}
@@ -0,0 +1,105 @@
+package trader
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSandboxAccountValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		account SandboxAccount
+		wantErr bool
+	}{
+		{name: "空仓", account: SandboxAccount{TotalEquity: 1000}},
+		{name: "净值为0", account: SandboxAccount{}, wantErr: true},
+		{name: "方向非法", account: SandboxAccount{TotalEquity: 1000, Positions: []SandboxPosition{{Symbol: "BTC", Side: "buy", EntryPrice: 1, Quantity: 1}}}, wantErr: true},
+		{name: "数量为0", account: SandboxAccount{TotalEquity: 1000, Positions: []SandboxPosition{{Symbol: "BTC", Side: "long", EntryPrice: 1}}}, wantErr: true},
+		{name: "止损为负", account: SandboxAccount{TotalEquity: 1000, Positions: []SandboxPosition{{Symbol: "BTC", Side: "long", EntryPrice: 1, Quantity: 1, StopLoss: -1}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.account.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSandboxExchangeState(t *testing.T) {
+	account := SandboxAccount{
+		TotalEquity: 10000,
+		Positions: []SandboxPosition{
+			{Symbol: "btc", Side: "short", EntryPrice: 100, MarkPrice: 90, Quantity: 2, Leverage: 5, StopLoss: 110},
+			{Symbol: "ETHUSDT", Side: "long", EntryPrice: 50, Quantity: 4},
+		},
+	}
+	balance, positions := account.exchangeState()
+
+	if len(positions) != 2 {
+		t.Fatalf("持仓数 = %d, want 2", len(positions))
+	}
+	short := positions[0]
+	if short["symbol"] != "BTCUSDT" || short["positionAmt"] != -2.0 || short["unRealizedProfit"] != 20.0 {
+		t.Errorf("空仓转换错误: %+v", short)
+	}
+	long := positions[1]
+	if long["markPrice"] != 50.0 || long["leverage"] != 10.0 {
+		t.Errorf("未提供标记价/杠杆时应按入场价和10倍: %+v", long)
+	}
+
+	// 保证金 = 2×90/5 + 4×50/10 = 56
+	if got := balance["availableBalance"].(float64); math.Abs(got-9944) > 1e-9 {
+		t.Errorf("可用余额 = %v, want 9944", got)
+	}
+	equity := balance["totalWalletBalance"].(float64) + balance["totalUnrealizedProfit"].(float64)
+	if math.Abs(equity-10000) > 1e-9 {
+		t.Errorf("钱包余额 + 未实现盈亏 = %v, want 10000", equity)
+	}
+
+	stopLoss := account.stopLossLookup()
+	if price, ok := stopLoss("BTCUSDT", "short"); !ok || price != 110 {
+		t.Errorf("假设止损 = %v, %v, want 110, true", price, ok)
+	}
+	if _, ok := stopLoss("ETHUSDT", "long"); ok {
+		t.Error("未设置止损的持仓不应有止损价")
+	}
+}
+
+func TestSandboxOpenRiskIgnoresRealStops(t *testing.T) {
+	at := &AutoTrader{stopLossPrices: map[string]float64{"BTCUSDT_long": 99}}
+	account := SandboxAccount{
+		TotalEquity: 10000,
+		Positions:   []SandboxPosition{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, Quantity: 10, StopLoss: 90}},
+	}
+	_, positions := account.exchangeState()
+
+	risk := at.calculateOpenRiskWith(positions, account.TotalEquity, account.stopLossLookup())
+	if got := risk.PositionRisk["BTCUSDT_long"]; got != 100 {
+		t.Errorf("沙盒开放风险 = %v, want 100（按假设止损 90 计算，不读取真实止损）", got)
+	}
+}
+
+func TestBuildSandboxContext(t *testing.T) {
+	at := &AutoTrader{id: "t1", database: memoryConfig{}, config: AutoTraderConfig{BTCETHLeverage: 5, AltcoinLeverage: 3}}
+	account := SandboxAccount{
+		TotalEquity: 2000,
+		Positions:   []SandboxPosition{{Symbol: "SOLUSDT", Side: "long", EntryPrice: 100, MarkPrice: 110, Quantity: 10, Leverage: 5}},
+	}
+	balance, positions := account.exchangeState()
+	ctx := at.buildSandboxContext(&account, "BTCUSDT", balance, positions)
+
+	if ctx.Account.TotalEquity != 2000 || ctx.Account.PositionCount != 1 {
+		t.Errorf("账户信息错误: %+v", ctx.Account)
+	}
+	if len(ctx.CandidateCoins) != 1 || ctx.CandidateCoins[0].Symbol != "BTCUSDT" {
+		t.Errorf("候选币种应只有目标币种: %+v", ctx.CandidateCoins)
+	}
+	pos := ctx.Positions[0]
+	if pos.UnrealizedPnLPct != 10 || pos.MarginUsed != 220 {
+		t.Errorf("持仓信息错误: %+v", pos)
+	}
+	if ctx.BTCETHLeverage != 5 || ctx.AltcoinLeverage != 3 {
+		t.Errorf("杠杆上限 = %d/%d, want 5/3", ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+	}
+}
@@ -0,0 +1,121 @@
+package trader
+
+import (
+	"log"
+	"nofx/logger"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// settlementLookback 配对税务批次时向前追溯开仓记录的时长
+	settlementLookback = 90 * 24 * time.Hour
+	// maxCatchUpSettlementDays 停机后补结算的最多天数
+	maxCatchUpSettlementDays = 7
+)
+
+// incomeSummaryProvider 可查询资金流水的交易器（目前仅币安实现；其他平台按决策记录估算）
+type incomeSummaryProvider interface {
+	GetIncomeSummary(start, end time.Time) (realizedPnL, fees, funding decimal.Decimal, err error)
+}
+
+// startOfDay 本地时区当日零点
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// settlePendingDays 补齐截至昨天的每日结算（每个周期调用，已结算时只做一次比较）
+func (at *AutoTrader) settlePendingDays() {
+	yesterday := startOfDay(time.Now()).AddDate(0, 0, -1)
+	day := yesterday
+	if latest, ok := at.settlements.Latest(); ok {
+		last, err := time.ParseInLocation(logger.SettlementDateLayout, latest.Date, time.Local)
+		if err == nil {
+			day = last.AddDate(0, 0, 1)
+		}
+	}
+	if earliest := yesterday.AddDate(0, 0, -(maxCatchUpSettlementDays - 1)); day.Before(earliest) {
+		day = earliest
+	}
+
+	for ; !day.After(yesterday); day = day.AddDate(0, 0, 1) {
+		settlement, err := at.SettleDay(day)
+		if err != nil {
+			log.Printf("⚠️  [%s] 每日结算 %s 失败: %v", at.name, day.Format(logger.SettlementDateLayout), err)
+			return
+		}
+		if settlement != nil {
+			log.Printf("📒 [%s] 每日结算 %s: 净值 %s → %s，已实现 %s，手续费 %s，资金费 %s (%s)",
+				at.name, settlement.Date, settlement.OpeningEquity.StringFixed(2), settlement.ClosingEquity.StringFixed(2),
+				settlement.RealizedPnL.StringFixed(2), settlement.Fees.StringFixed(2), settlement.Funding.StringFixed(2), settlement.Source)
+		}
+	}
+}
+
+// SettleDay 生成（或重新生成）指定日期的结算快照；当天没有任何记录且没有前一日结算时返回 nil
+func (at *AutoTrader) SettleDay(date time.Time) (*logger.DailySettlement, error) {
+	dayStart := startOfDay(date.In(time.Local))
+	dayEnd := dayStart.AddDate(0, 0, 1).Add(-time.Nanosecond)
+
+	records, err := at.decisionLogger.GetRecordsBetween(dayStart.Add(-settlementLookback), dayEnd)
+	if err != nil {
+		return nil, err
+	}
+	var dayRecords []*logger.DecisionRecord
+	for _, record := range records {
+		if !record.Timestamp.Before(dayStart) {
+			dayRecords = append(dayRecords, record)
+		}
+	}
+
+	prevDate := dayStart.AddDate(0, 0, -1).Format(logger.SettlementDateLayout)
+	prevClosing := decimal.Zero
+	if prev := at.settlements.Range(prevDate, prevDate); len(prev) > 0 {
+		prevClosing = prev[0].ClosingEquity
+	}
+	if len(dayRecords) == 0 && !prevClosing.IsPositive() {
+		return nil, nil
+	}
+
+	lots := logger.BuildTaxLots(records, takerFeeRate)
+	settlement := logger.BuildDailySettlement(dayStart, dayRecords, lots, prevClosing, takerFeeRate)
+	if provider, ok := at.trader.(incomeSummaryProvider); ok {
+		realized, fees, funding, err := provider.GetIncomeSummary(dayStart, dayEnd)
+		if err != nil {
+			log.Printf("⚠️  [%s] 获取 %s 资金流水失败，使用估算值: %v", at.name, settlement.Date, err)
+		} else {
+			settlement.ApplyExchangeIncome(realized, fees, funding)
+		}
+	}
+
+	settlement.Reporting = at.toReporting(settlement.RealizedPnL, dayStart)
+	if err := at.settlements.Upsert(settlement); err != nil {
+		return nil, err
+	}
+	return &settlement, nil
+}
+
+// GetSettlements 日期区间内的每日结算（YYYY-MM-DD，含两端）
+func (at *AutoTrader) GetSettlements(from, to string) []logger.DailySettlement {
+	return at.settlements.Range(from, to)
+}
+
+// GetTaxLots 处置时间在区间内的税务批次（先进先出配对，手续费按Taker费率估算；配置报告货币时按处置日汇率换算盈亏）
+func (at *AutoTrader) GetTaxLots(start, end time.Time) ([]logger.TaxLot, error) {
+	records, err := at.decisionLogger.GetRecordsBetween(start.Add(-settlementLookback), end)
+	if err != nil {
+		return nil, err
+	}
+
+	lots := []logger.TaxLot{}
+	for _, lot := range logger.BuildTaxLots(records, takerFeeRate) {
+		if lot.DisposedAt.Before(start) || lot.DisposedAt.After(end) {
+			continue
+		}
+		lot.Reporting = at.toReporting(lot.GainLoss, lot.DisposedAt)
+		lots = append(lots, lot)
+	}
+	return lots, nil
+}
@@ -0,0 +1,26 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/market"
+)
+
+// convertStaleOpens 行情数据陈旧（最新K线落后于预期）的币种上的开仓决策转为 wait，返回执行日志
+func convertStaleOpens(decisions []decision.Decision, stale map[string][]market.StaleTimeframe) []string {
+	if len(stale) == 0 {
+		return nil
+	}
+	var notes []string
+	for i := range decisions {
+		d := &decisions[i]
+		tfs := stale[d.Symbol]
+		if len(tfs) == 0 || (d.Action != "open_long" && d.Action != "open_short") {
+			continue
+		}
+		notes = append(notes, fmt.Sprintf("🧊 %s 行情数据延迟（%s），%s 已转为 wait", d.Symbol, tfs[0], d.Action))
+		d.Reasoning = fmt.Sprintf("[行情数据延迟，原决策 %s] %s", d.Action, d.Reasoning)
+		d.Action = "wait"
+	}
+	return notes
+}
@@ -0,0 +1,84 @@
+package trader
+
+import (
+	"nofx/market"
+	"time"
+)
+
+// TraderStatus 交易员运行状态（API /status 直接序列化，内部调用方按字段读取，不再做类型断言）
+type TraderStatus struct {
+	TraderID       string               `json:"trader_id"`
+	TraderName     string               `json:"trader_name"`
+	AIModel        string               `json:"ai_model"`
+	Exchange       string               `json:"exchange"`
+	AIProvider     string               `json:"ai_provider"`
+	IsRunning      bool                 `json:"is_running"`
+	StartTime      time.Time            `json:"start_time"`
+	RuntimeMinutes int                  `json:"runtime_minutes"`
+	CallCount      int                  `json:"call_count"`
+	InitialBalance float64              `json:"initial_balance"`
+	ScanInterval   string               `json:"scan_interval"`
+	StopUntil      time.Time            `json:"stop_until"`
+	LastResetTime  time.Time            `json:"last_reset_time"`
+	LastDecisionAt *time.Time           `json:"last_decision_at,omitempty"`
+	LastError      string               `json:"last_error,omitempty"`    // 最近一次决策周期失败的错误
+	LastErrorAt    *time.Time           `json:"last_error_at,omitempty"` // 最近一次决策周期失败时间
+	Healthy        bool                 `json:"healthy"`
+	HealthFlags    []string             `json:"health_flags"`
+	ExecErrors     ExecErrorStats       `json:"exec_errors"`
+	Warmup         *market.WarmupReport `json:"warmup"`
+	StopWatchdog   StopWatchdogStats    `json:"stop_watchdog"`
+	ClockSync      ClockSyncStatus      `json:"clock_sync"`
+	Pause          PauseStatus          `json:"pause"`
+}
+
+// GetStatus 获取系统状态（用于API，不请求交易所）
+func (at *AutoTrader) GetStatus() TraderStatus {
+	aiProvider := "DeepSeek"
+	if at.config.UseQwen {
+		aiProvider = "Qwen"
+	}
+
+	status := TraderStatus{
+		TraderID:       at.id,
+		TraderName:     at.name,
+		AIModel:        at.aiModel,
+		Exchange:       at.exchange,
+		AIProvider:     aiProvider,
+		IsRunning:      at.isRunning,
+		StartTime:      at.startTime,
+		RuntimeMinutes: int(time.Since(at.startTime).Minutes()),
+		CallCount:      at.callCount,
+		InitialBalance: at.initialBalance,
+		ScanInterval:   at.config.ScanInterval.String(),
+		StopUntil:      at.stopUntil(),
+		LastResetTime:  at.lastResetTime,
+		HealthFlags:    at.healthFlags(),
+		ExecErrors:     at.GetExecErrorStats(),
+		Warmup:         at.GetWarmupReport(),
+		StopWatchdog:   at.GetStopWatchdogStats(),
+		ClockSync:      at.GetClockSyncStatus(),
+		Pause:          at.GetPauseStatus(),
+	}
+	if last := at.lastDecisionTime(); !last.IsZero() {
+		status.LastDecisionAt = &last
+	}
+
+	at.lastCycleMu.Lock()
+	if !at.lastCycleErrAt.IsZero() {
+		errAt := at.lastCycleErrAt
+		status.LastError, status.LastErrorAt = at.lastCycleErr, &errAt
+	}
+	at.lastCycleMu.Unlock()
+
+	status.Healthy = len(status.HealthFlags) == 0
+	return status
+}
+
+// recordCycleError 记录最近一次决策周期失败（状态中展示）
+func (at *AutoTrader) recordCycleError(err error) {
+	at.lastCycleMu.Lock()
+	defer at.lastCycleMu.Unlock()
+	at.lastCycleErr = err.Error()
+	at.lastCycleErrAt = time.Now()
+}
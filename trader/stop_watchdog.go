@@ -0,0 +1,264 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"nofx/market"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultStopVerifyDelay = 10 * time.Second // 开仓后等待多久核验止损单
+	stopVerifyAttempts     = 3                // 止损补挂最多尝试次数
+	stopRetryBackoff       = 3 * time.Second  // 每次补挂之间的间隔
+	stopPriceTolerance     = 0.001            // 止损价允许的相对偏差（价格精度取整）
+	maxStopWatchdogAlerts  = 50
+)
+
+// StopOrderInfo 交易所上的止损单
+type StopOrderInfo struct {
+	OrderID      int64   `json:"order_id"`
+	PositionSide string  `json:"position_side"` // LONG / SHORT
+	StopPrice    float64 `json:"stop_price"`
+}
+
+// stopOrderReader 支持查询止损单的交易器（目前仅币安实现；其他平台只能根据下单结果判断）
+type stopOrderReader interface {
+	GetStopOrders(symbol string) ([]StopOrderInfo, error)
+	CancelOrder(symbol string, orderID int64) error
+}
+
+// StopWatchdogAlert 止损核验告警
+type StopWatchdogAlert struct {
+	Time           time.Time `json:"time"`
+	Symbol         string    `json:"symbol"`
+	Side           string    `json:"side"`
+	StopPrice      float64   `json:"stop_price"`
+	Detail         string    `json:"detail"`
+	EmergencyClose bool      `json:"emergency_close"` // 是否执行了紧急平仓
+}
+
+// StopWatchdogStats 止损核验统计
+type StopWatchdogStats struct {
+	Verified  int                 `json:"verified"`  // 核验通过（含补挂后通过）
+	Repaired  int                 `json:"repaired"`  // 缺失或价格错误后补挂成功
+	Escalated int                 `json:"escalated"` // 补挂失败升级告警
+	Emergency int                 `json:"emergency"` // 紧急平仓次数
+	Alerts    []StopWatchdogAlert `json:"alerts"`    // 最近告警（新的在前）
+}
+
+// stopWatchdogTracker 线程安全的止损核验统计
+type stopWatchdogTracker struct {
+	mu    sync.Mutex
+	stats StopWatchdogStats
+}
+
+func (w *stopWatchdogTracker) update(fn func(s *StopWatchdogStats)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(&w.stats)
+	if len(w.stats.Alerts) > maxStopWatchdogAlerts {
+		w.stats.Alerts = w.stats.Alerts[:maxStopWatchdogAlerts]
+	}
+}
+
+func (w *stopWatchdogTracker) snapshot() StopWatchdogStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s := w.stats
+	s.Alerts = append([]StopWatchdogAlert{}, w.stats.Alerts...)
+	return s
+}
+
+// isStopEmergencyCloseEnabled 止损无法补挂时是否紧急平仓（交易员配置 > 系统配置 stop_watchdog_emergency_close）
+func (at *AutoTrader) isStopEmergencyCloseEnabled() bool {
+	if at.config.StopEmergencyClose {
+		return true
+	}
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		value, _ := db.GetSystemConfig("stop_watchdog_emergency_close")
+		return value == "true"
+	}
+	return false
+}
+
+// startStopWatchdog 开仓后在后台核验止损单：等待 StopVerifyDelay 后检查止损是否存在且价格正确，
+// 缺失或价格错误时补挂，多次失败后升级告警，并按配置紧急平仓
+// side: long/short；placed: 开仓流程中止损是否下单成功（无法查询止损单的平台以此为准）
+func (at *AutoTrader) startStopWatchdog(symbol, side string, quantity, stopPrice float64, placed bool) {
+	if stopPrice <= 0 {
+		return
+	}
+	delay := at.config.StopVerifyDelay
+	if delay <= 0 {
+		delay = defaultStopVerifyDelay
+	}
+
+	at.monitorWg.Add(1)
+	go func() {
+		defer at.monitorWg.Done()
+
+		select {
+		case <-time.After(delay):
+		case <-at.stopMonitorCh:
+			return
+		}
+		at.verifyStop(symbol, side, quantity, stopPrice, placed)
+	}()
+}
+
+// verifyStop 核验并修复止损单
+func (at *AutoTrader) verifyStop(symbol, side string, quantity, stopPrice float64, placed bool) {
+	positionSide := strings.ToUpper(side)
+	repaired := false
+	var lastErr error
+
+	for attempt := 1; attempt <= stopVerifyAttempts; attempt++ {
+		if !at.positionExists(symbol, side) {
+			return // 已平仓（止损/止盈已触发或AI已平仓）
+		}
+
+		ok, err := at.checkStopOrder(symbol, positionSide, stopPrice, placed)
+		if ok {
+			at.stopWatchdog.update(func(s *StopWatchdogStats) {
+				s.Verified++
+				if repaired {
+					s.Repaired++
+				}
+			})
+			if repaired {
+				log.Printf("🛡 [%s] %s %s 止损已补挂并核验通过: %.4f", at.name, symbol, side, stopPrice)
+			}
+			return
+		}
+		lastErr = err
+		log.Printf("⚠️  [%s] %s %s 止损核验失败（第%d次）: %v，尝试补挂", at.name, symbol, side, attempt, err)
+
+		if err := at.trader.SetStopLoss(symbol, positionSide, quantity, stopPrice); err != nil {
+			lastErr = err
+			log.Printf("  ⚠ 补挂止损失败: %v", err)
+		} else {
+			placed = true
+			repaired = true
+			at.recordStopLoss(symbol, side, stopPrice)
+		}
+
+		select {
+		case <-time.After(stopRetryBackoff):
+		case <-at.stopMonitorCh:
+			return
+		}
+	}
+
+	at.escalateMissingStop(symbol, side, stopPrice, lastErr)
+}
+
+// checkStopOrder 检查交易所上是否有对应方向、价格正确的止损单；价格错误的止损单会被取消
+func (at *AutoTrader) checkStopOrder(symbol, positionSide string, stopPrice float64, placed bool) (bool, error) {
+	reader, ok := at.trader.(stopOrderReader)
+	if !ok {
+		if placed {
+			return true, nil
+		}
+		return false, fmt.Errorf("止损单下单失败")
+	}
+
+	stops, err := reader.GetStopOrders(symbol)
+	if err != nil {
+		return false, err
+	}
+	var mispriced []StopOrderInfo
+	for _, stop := range stops {
+		if stop.PositionSide != positionSide {
+			continue
+		}
+		if math.Abs(stop.StopPrice-stopPrice)/stopPrice <= stopPriceTolerance {
+			return true, nil
+		}
+		mispriced = append(mispriced, stop)
+	}
+
+	if len(mispriced) == 0 {
+		return false, fmt.Errorf("交易所上没有 %s 方向的止损单", positionSide)
+	}
+	for _, stop := range mispriced {
+		if err := reader.CancelOrder(symbol, stop.OrderID); err != nil {
+			log.Printf("  ⚠ 取消价格错误的止损单 %d 失败: %v", stop.OrderID, err)
+		}
+	}
+	return false, fmt.Errorf("止损价格错误: 交易所 %.4f，预期 %.4f", mispriced[0].StopPrice, stopPrice)
+}
+
+// positionExists 持仓是否仍然存在
+func (at *AutoTrader) positionExists(symbol, side string) bool {
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return true // 查询失败时按仍持仓处理，继续核验
+	}
+	for _, pos := range positions {
+		if pos["symbol"] == symbol && pos["side"] == side {
+			return true
+		}
+	}
+	return false
+}
+
+// escalateMissingStop 止损无法补挂：告警，并按配置紧急平仓
+func (at *AutoTrader) escalateMissingStop(symbol, side string, stopPrice float64, cause error) {
+	alert := StopWatchdogAlert{
+		Time:      time.Now(),
+		Symbol:    symbol,
+		Side:      side,
+		StopPrice: stopPrice,
+		Detail:    fmt.Sprintf("止损 %d 次补挂均失败: %v", stopVerifyAttempts, cause),
+	}
+	log.Printf("🚨 [%s] %s %s 持仓没有有效止损（%s）", at.name, symbol, side, alert.Detail)
+
+	if at.isStopEmergencyCloseEnabled() && at.inMaintenance() {
+		// 维护期间无法下单：维护结束对账时会重新核验止损，仍缺失时再紧急平仓
+		alert.Detail += "；交易所维护中，暂缓紧急平仓"
+		log.Printf("🚨 [%s] %s %s 交易所维护中，紧急平仓暂缓到维护结束后核验", at.name, symbol, side)
+	} else if at.isStopEmergencyCloseEnabled() {
+		var err error
+		if side == "long" {
+			_, err = at.trader.CloseLong(symbol, 0)
+		} else {
+			_, err = at.trader.CloseShort(symbol, 0)
+		}
+		if err != nil {
+			alert.Detail += fmt.Sprintf("；紧急平仓失败: %v", err)
+			log.Printf("🚨 [%s] %s %s 紧急平仓失败: %v，请立即人工处理", at.name, symbol, side, err)
+		} else {
+			alert.EmergencyClose = true
+			at.recordStopLoss(symbol, side, 0)
+			closePrice := stopPrice
+			if data, err := market.Get(symbol); err == nil && data.CurrentPrice > 0 {
+				closePrice = data.CurrentPrice
+			}
+			// 主动平仓：记入币种记忆并移出持仓快照（否则下一周期会被当作交易所侧平仓），同时作为开仓受挫抑制相近价格重复开仓
+			at.rememberClose(symbol, side, closePrice, "emergency_close")
+			at.recordSetback(symbol, side, SetbackEmergencyClose, closePrice, "止损缺失紧急平仓")
+			log.Printf("🚨 [%s] %s %s 已紧急平仓", at.name, symbol, side)
+		}
+	}
+
+	at.publishRiskBreach(symbol, "", "stop_missing", fmt.Sprintf("%s %s 持仓没有有效止损（%s）", symbol, side, alert.Detail), true)
+	at.stopWatchdog.update(func(s *StopWatchdogStats) {
+		s.Escalated++
+		if alert.EmergencyClose {
+			s.Emergency++
+		}
+		s.Alerts = append([]StopWatchdogAlert{alert}, s.Alerts...)
+	})
+}
+
+// GetStopWatchdogStats 获取止损核验统计
+func (at *AutoTrader) GetStopWatchdogStats() StopWatchdogStats {
+	return at.stopWatchdog.snapshot()
+}
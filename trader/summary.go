@@ -0,0 +1,156 @@
+package trader
+
+import (
+	"context"
+	"math"
+	"nofx/logger"
+	"time"
+)
+
+// 交易员健康标记
+const (
+	HealthAccountUnavailable = "account_unavailable" // 账户数据获取失败或超时
+	HealthRiskPaused         = "risk_paused"         // 风控暂停交易中
+	HealthStaleCycle         = "stale_cycle"         // 运行中但超过3个扫描间隔没有新的决策周期
+	HealthClockDrift         = "clock_drift"         // 本地时钟与交易所偏差超过告警阈值
+	HealthStopEscalated      = "stop_escalated"      // 止损核验补挂失败（需人工确认止损）
+	HealthWarmupIncomplete   = "warmup_incomplete"   // 启动预热有币种K线不足
+	HealthEquityFrozen       = "equity_frozen"       // 净值异常跳变未确认，新开仓冻结中
+	HealthMaintenance        = "maintenance"         // 交易所维护中，暂停下单
+	HealthPaused             = "paused"              // 人工暂停中（暂停开仓或完全冻结）
+)
+
+// TraderSummary 仪表盘用的交易员汇总（一次请求返回全部交易员，避免逐个调用 status/account）
+type TraderSummary struct {
+	TraderID       string     `json:"trader_id"`
+	TraderName     string     `json:"trader_name"`
+	AIModel        string     `json:"ai_model"`
+	Exchange       string     `json:"exchange"`
+	IsRunning      bool       `json:"is_running"`
+	TotalEquity    float64    `json:"total_equity"`
+	TotalPnL       float64    `json:"total_pnl"`
+	TotalPnLPct    float64    `json:"total_pnl_pct"`
+	DailyPnL       float64    `json:"daily_pnl"` // 净值 - 当日开盘净值（前一日结算收盘净值，缺失时为当日首条记录净值）
+	PositionCount  int        `json:"position_count"`
+	CallCount      int        `json:"call_count"`
+	LastDecisionAt *time.Time `json:"last_decision_at,omitempty"`
+	Healthy        bool       `json:"healthy"`
+	HealthFlags    []string   `json:"health_flags"`
+}
+
+// markCycleStart 记录决策周期开始时间（汇总中的最近决策时间）
+func (at *AutoTrader) markCycleStart() {
+	at.lastCycleMu.Lock()
+	at.lastCycleAt = time.Now()
+	at.lastCycleMu.Unlock()
+}
+
+// lastDecisionTime 最近决策时间（重启后尚未运行周期时读取最近一条决策记录）
+func (at *AutoTrader) lastDecisionTime() time.Time {
+	at.lastCycleMu.Lock()
+	defer at.lastCycleMu.Unlock()
+	if at.lastCycleAt.IsZero() {
+		if records, err := at.decisionLogger.GetLatestRecords(1); err == nil && len(records) > 0 {
+			at.lastCycleAt = records[0].Timestamp
+		}
+	}
+	return at.lastCycleAt
+}
+
+// todayOpeningEquity 当日开盘净值（前一日结算收盘净值 > 当日第一条决策记录净值）
+func (at *AutoTrader) todayOpeningEquity() float64 {
+	dayStart := startOfDay(time.Now())
+	if latest, ok := at.settlements.Latest(); ok && latest.Date == dayStart.AddDate(0, 0, -1).Format(logger.SettlementDateLayout) {
+		equity, _ := latest.ClosingEquity.Float64()
+		return equity
+	}
+	records, err := at.decisionLogger.GetRecordByDate(dayStart)
+	if err != nil {
+		return 0
+	}
+	for _, record := range records {
+		if record.AccountState.TotalBalance > 0 {
+			return record.AccountState.TotalBalance
+		}
+	}
+	return 0
+}
+
+// GetSummary 汇总交易员状态、净值、日盈亏、持仓数、最近决策时间和健康标记
+// 账户数据在 ctx 超时前未返回时标记 account_unavailable，其余字段照常返回
+func (at *AutoTrader) GetSummary(ctx context.Context) TraderSummary {
+	summary := TraderSummary{
+		TraderID:    at.id,
+		TraderName:  at.name,
+		AIModel:     at.aiModel,
+		Exchange:    at.exchange,
+		IsRunning:   at.isRunning,
+		CallCount:   at.callCount,
+		HealthFlags: []string{},
+	}
+
+	type accountResult struct {
+		account map[string]interface{}
+		err     error
+	}
+	accountCh := make(chan accountResult, 1)
+	go func() {
+		account, err := at.GetAccountInfo()
+		accountCh <- accountResult{account, err}
+	}()
+
+	select {
+	case res := <-accountCh:
+		if res.err != nil {
+			summary.HealthFlags = append(summary.HealthFlags, HealthAccountUnavailable)
+			break
+		}
+		summary.TotalEquity, _ = res.account["total_equity"].(float64)
+		summary.TotalPnL, _ = res.account["total_pnl"].(float64)
+		summary.TotalPnLPct, _ = res.account["total_pnl_pct"].(float64)
+		summary.PositionCount, _ = res.account["position_count"].(int)
+		if opening := at.todayOpeningEquity(); opening > 0 {
+			summary.DailyPnL = summary.TotalEquity - opening
+		}
+	case <-ctx.Done():
+		summary.HealthFlags = append(summary.HealthFlags, HealthAccountUnavailable)
+	}
+
+	if last := at.lastDecisionTime(); !last.IsZero() {
+		summary.LastDecisionAt = &last
+	}
+	summary.HealthFlags = append(summary.HealthFlags, at.healthFlags()...)
+
+	summary.Healthy = len(summary.HealthFlags) == 0
+	return summary
+}
+
+// healthFlags 不依赖账户数据的健康标记（汇总和状态共用）
+func (at *AutoTrader) healthFlags() []string {
+	flags := []string{}
+	if last := at.lastDecisionTime(); !last.IsZero() && at.isRunning && at.config.ScanInterval > 0 && time.Since(last) > 3*at.config.ScanInterval {
+		flags = append(flags, HealthStaleCycle)
+	}
+	if time.Now().Before(at.stopUntil()) {
+		flags = append(flags, HealthRiskPaused)
+	}
+	if clock := at.GetClockSyncStatus(); clock.Supported && clock.ThresholdMs > 0 && math.Abs(float64(clock.OffsetMs)) > float64(clock.ThresholdMs) {
+		flags = append(flags, HealthClockDrift)
+	}
+	if at.GetStopWatchdogStats().Escalated > 0 {
+		flags = append(flags, HealthStopEscalated)
+	}
+	if warmup := at.GetWarmupReport(); warmup != nil && warmup.Ready < warmup.Total {
+		flags = append(flags, HealthWarmupIncomplete)
+	}
+	if at.equityFrozen() {
+		flags = append(flags, HealthEquityFrozen)
+	}
+	if at.maintenance.current() != nil {
+		flags = append(flags, HealthMaintenance)
+	}
+	if at.pause.current() != nil {
+		flags = append(flags, HealthPaused)
+	}
+	return flags
+}
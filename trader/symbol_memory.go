@@ -0,0 +1,95 @@
+package trader
+
+import (
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+)
+
+// rememberPositions 对比上一周期的持仓快照，记录被交易所止损/止盈平掉的持仓，并更新快照
+// 主动平仓（AI/自动平仓）会在平仓时通过 rememberClose 记录并移出快照，不会重复记录
+func (at *AutoTrader) rememberPositions(positions []decision.PositionInfo) {
+	at.positionSnapshotMutex.Lock()
+	defer at.positionSnapshotMutex.Unlock()
+
+	current := make(map[string]decision.PositionInfo, len(positions))
+	for _, pos := range positions {
+		current[pos.Symbol+"_"+pos.Side] = pos
+	}
+
+	for key, last := range at.positionSnapshot {
+		if _, stillOpen := current[key]; stillOpen {
+			continue
+		}
+
+		// 持仓在交易所侧被平掉：最后标记价格处于亏损方向且有已知止损时视为止损，否则按盈亏方向推断
+		reason := "exchange_close"
+		closePrice := last.MarkPrice
+		if stopPrice, ok := at.getStopLoss(last.Symbol, last.Side); ok && last.UnrealizedPnL < 0 {
+			reason = "stop_loss"
+			closePrice = stopPrice
+		} else if last.UnrealizedPnL > 0 {
+			reason = "take_profit"
+		}
+		at.recordTradeMemory(last, closePrice, reason)
+		if reason == "stop_loss" {
+			at.recordSetback(last.Symbol, last.Side, SetbackStopLoss, closePrice, "")
+		}
+		at.recordStopLoss(last.Symbol, last.Side, 0)
+		at.noteActivity("交易所侧平仓 %s %s @%.4g（%s）", last.Symbol, last.Side, closePrice, reason)
+	}
+
+	at.positionSnapshot = current
+}
+
+// rememberClose 记录主动平仓的交易（AI平仓、自动平仓、翻仓）
+func (at *AutoTrader) rememberClose(symbol, side string, closePrice float64, reason string) {
+	at.positionSnapshotMutex.Lock()
+	defer at.positionSnapshotMutex.Unlock()
+
+	key := symbol + "_" + side
+	last, ok := at.positionSnapshot[key]
+	if !ok {
+		return
+	}
+	if closePrice <= 0 {
+		closePrice = last.MarkPrice
+	}
+	at.recordTradeMemory(last, closePrice, reason)
+	delete(at.positionSnapshot, key)
+}
+
+// recordTradeMemory 写入币种记忆，并结算开仓时各周期趋势信号的命中情况
+func (at *AutoTrader) recordTradeMemory(pos decision.PositionInfo, closePrice float64, reason string) {
+	at.recordTimeframeOutcome(pos, closePrice)
+	err := at.symbolMemory.RecordTrade(pos.Symbol, logger.SymbolTradeMemory{
+		Side:       pos.Side,
+		OpenPrice:  pos.EntryPrice,
+		ClosePrice: closePrice,
+		Reason:     reason,
+	})
+	if err != nil {
+		log.Printf("⚠️  记录 %s 币种记忆失败: %v", pos.Symbol, err)
+	}
+}
+
+// buildSymbolMemories 为持仓和候选币种生成记忆摘要（用于注入prompt）
+func (at *AutoTrader) buildSymbolMemories(positions []decision.PositionInfo, candidates []decision.CandidateCoin) map[string]string {
+	memories := make(map[string]string)
+	add := func(symbol string) {
+		if _, exists := memories[symbol]; exists {
+			return
+		}
+		if summary := at.symbolMemory.Summary(symbol); summary != "" {
+			memories[symbol] = summary
+		}
+	}
+
+	for _, pos := range positions {
+		add(pos.Symbol)
+	}
+	for _, coin := range candidates {
+		add(coin.Symbol)
+	}
+	return memories
+}
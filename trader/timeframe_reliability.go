@@ -0,0 +1,74 @@
+package trader
+
+import (
+	"log"
+	"nofx/decision"
+	"nofx/market"
+	"sort"
+)
+
+// TimeframeReliabilityEntry 某币种某周期趋势信号的命中统计
+type TimeframeReliabilityEntry struct {
+	Symbol      string  `json:"symbol"`
+	Timeframe   string  `json:"timeframe"`
+	Hits        int     `json:"hits"`
+	Total       int     `json:"total"`
+	Reliability float64 `json:"reliability"` // 平滑后的命中率
+}
+
+// timeframeReliabilityContext 各币种各周期的命中率（注入决策上下文，用于周期一致性加权）
+func (at *AutoTrader) timeframeReliabilityContext() map[string]map[string]decision.TimeframeReliability {
+	all := at.timeframeReliability.All()
+	result := make(map[string]map[string]decision.TimeframeReliability, len(all))
+	for symbol, stats := range all {
+		result[symbol] = make(map[string]decision.TimeframeReliability, len(stats))
+		for tf, st := range stats {
+			result[symbol][tf] = decision.TimeframeReliability{HitRate: st.Reliability(), Samples: st.Total}
+		}
+	}
+	return result
+}
+
+// recordTimeframeEntry 开仓成功后记录决策时各周期的趋势方向（平仓时按实际价格方向计分）
+func (at *AutoTrader) recordTimeframeEntry(symbol, side string) {
+	trends, ok := at.decisionTrends[symbol]
+	if !ok {
+		return
+	}
+	if err := at.timeframeReliability.RecordEntry(symbol, side, trends); err != nil {
+		log.Printf("⚠️  记录 %s 周期趋势失败: %v", symbol, err)
+	}
+}
+
+// recordTimeframeOutcome 平仓时结算各周期趋势信号是否命中
+func (at *AutoTrader) recordTimeframeOutcome(pos decision.PositionInfo, closePrice float64) {
+	if pos.EntryPrice <= 0 {
+		return
+	}
+	pnlPct := (closePrice - pos.EntryPrice) / pos.EntryPrice * 100
+	if pos.Side == "short" {
+		pnlPct = -pnlPct
+	}
+	if err := at.timeframeReliability.RecordOutcome(pos.Symbol, pos.Side, pnlPct); err != nil {
+		log.Printf("⚠️  记录 %s 周期命中率失败: %v", pos.Symbol, err)
+	}
+}
+
+// GetTimeframeReliability 各币种各周期趋势信号的实际命中率
+func (at *AutoTrader) GetTimeframeReliability() []TimeframeReliabilityEntry {
+	entries := []TimeframeReliabilityEntry{}
+	for symbol, stats := range at.timeframeReliability.All() {
+		for tf, st := range stats {
+			entries = append(entries, TimeframeReliabilityEntry{Symbol: symbol, Timeframe: tf, Hits: st.Hits, Total: st.Total, Reliability: st.Reliability()})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Symbol != entries[j].Symbol {
+			return entries[i].Symbol < entries[j].Symbol
+		}
+		di, _ := market.IntervalDuration(entries[i].Timeframe)
+		dj, _ := market.IntervalDuration(entries[j].Timeframe)
+		return di < dj
+	})
+	return entries
+}
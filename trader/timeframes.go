@@ -0,0 +1,91 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/market"
+	"strings"
+	"time"
+)
+
+// timeframesKey 交易员分析周期在系统配置中的键（未设置时使用全局 analysis_timeframes）
+func timeframesKey(traderID string) string {
+	return "timeframes:" + traderID
+}
+
+// GetTimeframes 获取交易员分析周期：交易员配置 > 全局 analysis_timeframes > 默认 3m/4h
+// 配置值可以是预设名称（scalper / intraday / swing）或逗号分隔的周期列表
+func (at *AutoTrader) GetTimeframes() []string {
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		for _, key := range []string{timeframesKey(at.id), "analysis_timeframes"} {
+			value, err := db.GetSystemConfig(key)
+			if err != nil || value == "" {
+				continue
+			}
+			timeframes, err := market.ParseTimeframes(value)
+			if err != nil {
+				log.Printf("⚠️  [%s] 分析周期 %s 无效: %v", at.name, key, err)
+				continue
+			}
+			return timeframes
+		}
+	}
+	return append([]string(nil), market.DefaultTimeframes...)
+}
+
+// candidateDataMaxAge 非持仓候选币种可复用的缓存分析时长：最短分析周期的一根K线
+// （系统配置 candidate_data_cache=false 时关闭，候选币种每周期重新计算）
+func (at *AutoTrader) candidateDataMaxAge(timeframes []string) time.Duration {
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		if value, err := db.GetSystemConfig("candidate_data_cache"); err == nil && value == "false" {
+			return 0
+		}
+	}
+	return market.CandleDuration(timeframes)
+}
+
+// UpdateTimeframes 校验并保存交易员分析周期（value 为预设名称或逗号分隔的周期列表），并加入WS订阅周期
+func (at *AutoTrader) UpdateTimeframes(value string) ([]string, error) {
+	timeframes, err := market.ParseTimeframes(value)
+	if err != nil {
+		return nil, err
+	}
+	type SystemConfigSetter interface {
+		SetSystemConfig(key, value string) error
+	}
+	db, ok := at.database.(SystemConfigSetter)
+	if !ok {
+		return nil, fmt.Errorf("当前数据库不支持保存分析周期")
+	}
+	if err := db.SetSystemConfig(timeframesKey(at.id), strings.Join(timeframes, ",")); err != nil {
+		return nil, fmt.Errorf("保存分析周期失败: %w", err)
+	}
+	market.EnsureTimeframes(timeframes)
+
+	if skipped := indicatorsOutside(at.GetIndicatorSet(), timeframes); len(skipped) > 0 {
+		log.Printf("⚠️  [%s] 指标 %v 的周期不在分析周期内，将不会输出", at.name, skipped)
+	}
+	log.Printf("📈 [%s] 分析周期已更新: %s", at.name, strings.Join(timeframes, " / "))
+	return timeframes, nil
+}
+
+// indicatorsOutside 周期不在分析周期内的指标键
+func indicatorsOutside(defs []market.IndicatorDef, timeframes []string) []string {
+	selected := make(map[string]bool, len(timeframes))
+	for _, tf := range timeframes {
+		selected[tf] = true
+	}
+	var keys []string
+	for _, d := range defs {
+		if !selected[d.Timeframe] {
+			keys = append(keys, d.Key())
+		}
+	}
+	return keys
+}
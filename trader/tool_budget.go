@@ -0,0 +1,53 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"strconv"
+)
+
+// toolBudgetKey 交易员数据工具调用次数在系统配置中的键（未设置时使用全局 tool_call_budget）
+func toolBudgetKey(traderID string) string {
+	return "tool_call_budget:" + traderID
+}
+
+// GetToolCallBudget 获取每个决策周期允许模型调用数据工具的次数（0=关闭工具调用）
+// 优先级：系统配置 tool_call_budget:<trader_id> > 全局 tool_call_budget
+func (at *AutoTrader) GetToolCallBudget() int {
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		for _, key := range []string{toolBudgetKey(at.id), "tool_call_budget"} {
+			value, err := db.GetSystemConfig(key)
+			if err != nil || value == "" {
+				continue
+			}
+			if budget, err := strconv.Atoi(value); err == nil && budget >= 0 {
+				if budget > decision.MaxToolCallBudget {
+					budget = decision.MaxToolCallBudget
+				}
+				return budget
+			}
+		}
+	}
+	return 0
+}
+
+// SetToolCallBudget 设置交易员每个周期的数据工具调用次数（0=关闭）
+func (at *AutoTrader) SetToolCallBudget(budget int) error {
+	if budget < 0 || budget > decision.MaxToolCallBudget {
+		return fmt.Errorf("工具调用次数必须在 0~%d 之间: %d", decision.MaxToolCallBudget, budget)
+	}
+	type SystemConfigSetter interface {
+		SetSystemConfig(key, value string) error
+	}
+	db, ok := at.database.(SystemConfigSetter)
+	if !ok {
+		return fmt.Errorf("数据库不支持系统配置")
+	}
+	if err := db.SetSystemConfig(toolBudgetKey(at.id), strconv.Itoa(budget)); err != nil {
+		return fmt.Errorf("保存工具调用次数失败: %w", err)
+	}
+	return nil
+}
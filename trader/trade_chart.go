@@ -0,0 +1,132 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/chart"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	tradeChartCandles   = 80     // 交易图表的K线根数（交易员最短分析周期）
+	tradeChartZoneWidth = 0.0015 // 支撑/阻力区域相对价位的半宽
+)
+
+// TradeChartEnabled 是否为成交的开仓/平仓生成交易图表（系统配置 trade_chart_enabled，默认开启）
+func (at *AutoTrader) TradeChartEnabled() bool {
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		if value, err := db.GetSystemConfig("trade_chart_enabled"); err == nil && value == "false" {
+			return false
+		}
+	}
+	return true
+}
+
+// tradeChartMarkers 由决策和成交记录生成图表标记（平仓图表的入场价取决策时的持仓）
+func tradeChartMarkers(d *decision.Decision, actionRecord *logger.DecisionAction, positions []decision.PositionInfo, data *market.Data) (chart.TradeMarkers, bool) {
+	price := actionRecord.FillPrice
+	if price <= 0 {
+		price = actionRecord.Price
+	}
+	var markers chart.TradeMarkers
+	switch d.Action {
+	case "open_long", "open_short":
+		markers = chart.TradeMarkers{Side: strings.TrimPrefix(d.Action, "open_"), Entry: price, StopLoss: d.StopLoss, TakeProfit: d.TakeProfit}
+	case "close_long", "close_short":
+		markers = chart.TradeMarkers{Side: strings.TrimPrefix(d.Action, "close_"), Exit: price}
+		for _, pos := range positions {
+			if pos.Symbol == d.Symbol && pos.Side == markers.Side {
+				markers.Entry = pos.EntryPrice
+			}
+		}
+	default:
+		return markers, false
+	}
+	if data != nil && data.Levels != nil {
+		lv := data.Levels
+		markers.Zones = []chart.Zone{
+			levelZone(lv.Support3m, chart.ZoneSupport),
+			levelZone(lv.Support4h, chart.ZoneSupport),
+			levelZone(lv.Resistance3m, chart.ZoneResistance),
+			levelZone(lv.Resistance4h, chart.ZoneResistance),
+		}
+	}
+	return markers, true
+}
+
+// levelZone 支撑/阻力价位上下 0.15% 的区域
+func levelZone(price float64, kind string) chart.Zone {
+	return chart.Zone{Low: price * (1 - tradeChartZoneWidth), High: price * (1 + tradeChartZoneWidth), Kind: kind}
+}
+
+// attachTradeChart 为成交的开仓/平仓生成交易图表：同步把图表文件名写入决策记录，后台拉取K线、绘制保存并附带图片推送通知
+func (at *AutoTrader) attachTradeChart(ctx *decision.Context, d *decision.Decision, actionRecord *logger.DecisionAction) {
+	if !at.TradeChartEnabled() {
+		return
+	}
+	markers, ok := tradeChartMarkers(d, actionRecord, ctx.Positions, ctx.MarketDataMap[d.Symbol])
+	if !ok {
+		return
+	}
+	name := fmt.Sprintf("%s_%s_%s.png", d.Symbol, d.Action, actionRecord.Timestamp.Format("20060102_150405"))
+	actionRecord.Chart = name
+	interval := at.GetTimeframes()[0]
+	caption := fmt.Sprintf("📊 %s %s 入场 %.4f", d.Symbol, d.Action, markers.Entry)
+	if markers.Exit > 0 {
+		caption += fmt.Sprintf(" → 平仓 %.4f", markers.Exit)
+	} else {
+		caption += fmt.Sprintf("（止损 %.4f / 止盈 %.4f）", markers.StopLoss, markers.TakeProfit)
+	}
+
+	go func() {
+		png, err := at.renderTradeChart(d.Symbol, interval, markers, name)
+		if err != nil {
+			log.Printf("⚠️  [%s] 生成 %s 交易图表失败: %v", at.name, d.Symbol, err)
+			return
+		}
+		if at.notifier != nil {
+			at.notifier.NotifyWithImage(png, logger.EventTrade, logger.SeverityInfo, "%s", caption)
+		}
+	}()
+}
+
+// renderTradeChart 拉取K线并绘制交易图表，保存到日志目录 charts/ 下
+func (at *AutoTrader) renderTradeChart(symbol, interval string, markers chart.TradeMarkers, name string) ([]byte, error) {
+	klines, err := market.NewAPIClient().GetKlines(symbol, interval, tradeChartCandles)
+	if err != nil {
+		return nil, fmt.Errorf("获取K线失败: %w", err)
+	}
+	png, err := chart.RenderTrade(klines, markers, chart.Options{})
+	if err != nil {
+		return nil, err
+	}
+	dir := at.decisionLogger.ChartDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建图表目录失败: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), png, 0600); err != nil {
+		return nil, fmt.Errorf("保存图表失败: %w", err)
+	}
+	return png, nil
+}
+
+// GetTradeChart 读取交易图表PNG（name 为决策记录中的图表文件名）
+func (at *AutoTrader) GetTradeChart(name string) ([]byte, error) {
+	name = filepath.Base(name)
+	if !strings.HasSuffix(name, ".png") {
+		return nil, fmt.Errorf("无效的图表文件名: %s", name)
+	}
+	data, err := os.ReadFile(filepath.Join(at.decisionLogger.ChartDir(), name))
+	if err != nil {
+		return nil, fmt.Errorf("读取交易图表失败: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,252 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+	"strings"
+	"time"
+)
+
+const (
+	// maxIdeasPerCycle 每个决策周期最多提交给AI评估的交易想法数
+	maxIdeasPerCycle = 5
+	// maxIdeaAttempts AI连续多个周期未给出评估时判定过期
+	maxIdeaAttempts = 3
+	// tradeIdeaTTL 交易想法有效期（超过后不再提交给AI）
+	tradeIdeaTTL = 24 * time.Hour
+)
+
+// ideaOutcome 单条交易想法在本周期的评估结果
+type ideaOutcome struct {
+	Status    string
+	Verdict   string
+	Reasoning string
+	Detail    string
+}
+
+// SubmitTradeIdea 提交外部交易想法，等待下一个决策周期由AI评估
+func (at *AutoTrader) SubmitTradeIdea(symbol, direction, thesis, user string) (logger.TradeIdea, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	direction = strings.ToLower(strings.TrimSpace(direction))
+	thesis = strings.TrimSpace(thesis)
+	if symbol == "" {
+		return logger.TradeIdea{}, fmt.Errorf("symbol 不能为空")
+	}
+	if !strings.HasSuffix(symbol, "USDT") {
+		symbol += "USDT"
+	}
+	if direction != "long" && direction != "short" {
+		return logger.TradeIdea{}, fmt.Errorf("direction 必须为 long 或 short")
+	}
+	if thesis == "" {
+		return logger.TradeIdea{}, fmt.Errorf("thesis 不能为空")
+	}
+
+	now := time.Now()
+	idea := logger.TradeIdea{
+		ID:          fmt.Sprintf("idea-%d", now.UnixNano()),
+		Symbol:      symbol,
+		Direction:   direction,
+		Thesis:      thesis,
+		SubmittedBy: user,
+		SubmittedAt: now,
+		Status:      logger.IdeaStatusPending,
+	}
+	if err := at.tradeIdeas.Add(idea); err != nil {
+		return logger.TradeIdea{}, err
+	}
+	log.Printf("📥 [%s] 收到交易想法 %s %s %s (提交人: %s)", at.name, idea.ID, symbol, direction, user)
+	return idea, nil
+}
+
+// CancelTradeIdea 撤回尚未评估的交易想法
+func (at *AutoTrader) CancelTradeIdea(id, user string) (logger.TradeIdea, error) {
+	var stateErr error
+	idea, err := at.tradeIdeas.Update(id, func(idea *logger.TradeIdea) bool {
+		if idea.Status != logger.IdeaStatusPending {
+			stateErr = fmt.Errorf("交易想法已处理（状态: %s），无法撤回", idea.Status)
+			return false
+		}
+		idea.Status = logger.IdeaStatusCancelled
+		idea.Detail = fmt.Sprintf("由 %s 撤回", user)
+		return true
+	})
+	if err != nil {
+		return idea, err
+	}
+	return idea, stateErr
+}
+
+// GetTradeIdeas 按状态查询交易想法（status 为空返回全部）
+func (at *AutoTrader) GetTradeIdeas(status string) []logger.TradeIdea {
+	return at.tradeIdeas.List(status)
+}
+
+// pendingIdeasForCycle 取出本周期待评估的交易想法（先将过期/多次未评估的想法标记为过期）
+func (at *AutoTrader) pendingIdeasForCycle() []decision.TradeIdeaBrief {
+	pending := at.tradeIdeas.List(logger.IdeaStatusPending)
+
+	var briefs []decision.TradeIdeaBrief
+	// List 为新→旧，倒序遍历使先提交的想法优先评估
+	for i := len(pending) - 1; i >= 0; i-- {
+		idea := pending[i]
+		if reason := ideaExpiryReason(idea, time.Now()); reason != "" {
+			at.tradeIdeas.Update(idea.ID, func(t *logger.TradeIdea) bool {
+				t.Status = logger.IdeaStatusExpired
+				t.Detail = reason
+				return true
+			})
+			continue
+		}
+		if len(briefs) < maxIdeasPerCycle {
+			briefs = append(briefs, decision.TradeIdeaBrief{
+				ID:        idea.ID,
+				Symbol:    idea.Symbol,
+				Direction: idea.Direction,
+				Thesis:    idea.Thesis,
+			})
+		}
+	}
+	return briefs
+}
+
+// ideaExpiryReason 交易想法过期原因（未过期返回空）
+func ideaExpiryReason(idea logger.TradeIdea, now time.Time) string {
+	if now.Sub(idea.SubmittedAt) > tradeIdeaTTL {
+		return fmt.Sprintf("超过有效期 %v 未执行", tradeIdeaTTL)
+	}
+	if idea.Attempts >= maxIdeaAttempts {
+		return fmt.Sprintf("连续 %d 个周期AI未给出评估", idea.Attempts)
+	}
+	return ""
+}
+
+// withIdeaCandidates 将交易想法的币种排到候选池最前面（避免被候选数量上限截掉）
+func withIdeaCandidates(candidates []decision.CandidateCoin, ideas []decision.TradeIdeaBrief) []decision.CandidateCoin {
+	symbols := make([]string, 0, len(ideas))
+	for _, idea := range ideas {
+		symbols = append(symbols, idea.Symbol)
+	}
+	return prependCandidates(candidates, symbols, "idea")
+}
+
+// prependCandidates 将指定币种排到候选池最前面（已在池中的币种移到前面，保留原来源）
+func prependCandidates(candidates []decision.CandidateCoin, symbols []string, source string) []decision.CandidateCoin {
+	if len(symbols) == 0 {
+		return candidates
+	}
+	existing := make(map[string]decision.CandidateCoin)
+	for _, coin := range candidates {
+		existing[coin.Symbol] = coin
+	}
+	seen := make(map[string]bool)
+	var front []decision.CandidateCoin
+	for _, symbol := range symbols {
+		if seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		coin, ok := existing[symbol]
+		if !ok {
+			coin = decision.CandidateCoin{Symbol: symbol, Sources: []string{source}}
+		}
+		front = append(front, coin)
+	}
+	for _, coin := range candidates {
+		if !seen[coin.Symbol] {
+			front = append(front, coin)
+		}
+	}
+	return front
+}
+
+// resolveTradeIdeas 根据AI决策与执行结果更新本周期评估的交易想法
+func (at *AutoTrader) resolveTradeIdeas(ideas []decision.TradeIdeaBrief, proposed []decision.Decision, actions []logger.DecisionAction, cycle int) {
+	if len(ideas) == 0 {
+		return
+	}
+	outcomes := resolveIdeaOutcomes(ideas, proposed, actions, at.IsApprovalRequired())
+	for _, idea := range ideas {
+		outcome, ok := outcomes[idea.ID]
+		at.tradeIdeas.Update(idea.ID, func(t *logger.TradeIdea) bool {
+			if t.Status != logger.IdeaStatusPending {
+				return false
+			}
+			t.Attempts++
+			if !ok {
+				return true
+			}
+			t.Status = outcome.Status
+			t.Verdict = outcome.Verdict
+			t.Reasoning = outcome.Reasoning
+			t.Detail = outcome.Detail
+			t.EvaluatedAt = time.Now()
+			t.Cycle = cycle
+			return true
+		})
+		if ok {
+			log.Printf("📥 [%s] 交易想法 %s %s %s → %s %s", at.name, idea.ID, idea.Symbol, idea.Direction, outcome.Status, outcome.Detail)
+		}
+	}
+}
+
+// resolveIdeaOutcomes 计算每条想法的评估结果（AI未给出评估的想法不在返回值中）
+//   - AI按想法方向开仓且执行成功 → executed
+//   - AI接受但处于人工审批模式 → accepted
+//   - AI接受但被验证/风控拒绝或执行失败 → blocked
+//   - AI输出 wait 或其他动作 → rejected
+func resolveIdeaOutcomes(ideas []decision.TradeIdeaBrief, proposed []decision.Decision, actions []logger.DecisionAction, approval bool) map[string]ideaOutcome {
+	outcomes := make(map[string]ideaOutcome)
+	for _, idea := range ideas {
+		openAction := "open_" + idea.Direction
+		matches := func(ideaID, symbol, action string) bool {
+			if ideaID != "" {
+				return ideaID == idea.ID
+			}
+			// 未标注 idea_id 时，同币种同方向开仓视为接受
+			return symbol == idea.Symbol && action == openAction
+		}
+
+		// 1. 按想法方向开仓的执行结果
+		var blocked *logger.DecisionAction
+		executed := false
+		for i := range actions {
+			a := &actions[i]
+			if a.Action != openAction || !matches(a.IdeaID, a.Symbol, a.Action) {
+				continue
+			}
+			if a.Status == logger.DecisionStatusExecuted || a.Success {
+				outcomes[idea.ID] = ideaOutcome{Status: logger.IdeaStatusExecuted, Verdict: a.Action, Reasoning: a.Reasoning}
+				executed = true
+				break
+			}
+			blocked = a
+		}
+		if executed {
+			continue
+		}
+		if blocked != nil {
+			outcomes[idea.ID] = ideaOutcome{Status: logger.IdeaStatusBlocked, Verdict: blocked.Action, Reasoning: blocked.Reasoning, Detail: blocked.Error}
+			continue
+		}
+
+		// 2. AI给出的决策（审批模式下开仓决策只入队）
+		for _, d := range proposed {
+			if !matches(d.IdeaID, d.Symbol, d.Action) {
+				continue
+			}
+			switch {
+			case d.Action == openAction && approval:
+				outcomes[idea.ID] = ideaOutcome{Status: logger.IdeaStatusAccepted, Verdict: d.Action, Reasoning: d.Reasoning, Detail: "等待人工审批"}
+			case d.Action == openAction:
+				outcomes[idea.ID] = ideaOutcome{Status: logger.IdeaStatusBlocked, Verdict: d.Action, Reasoning: d.Reasoning, Detail: "未执行"}
+			default:
+				outcomes[idea.ID] = ideaOutcome{Status: logger.IdeaStatusRejected, Verdict: d.Action, Reasoning: d.Reasoning}
+			}
+			break
+		}
+	}
+	return outcomes
+}
@@ -0,0 +1,39 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/logger"
+	"testing"
+)
+
+func TestResolveIdeaOutcomes(t *testing.T) {
+	idea := decision.TradeIdeaBrief{ID: "idea-1", Symbol: "SOLUSDT", Direction: "long", Thesis: "突破前高"}
+	open := decision.Decision{Symbol: "SOLUSDT", Action: "open_long", IdeaID: "idea-1"}
+	tests := []struct {
+		name     string
+		proposed []decision.Decision
+		actions  []logger.DecisionAction
+		approval bool
+		want     string // 期望状态（空=AI未评估）
+	}{
+		{"AI未评估", nil, nil, false, ""},
+		{"接受且执行成功", []decision.Decision{open},
+			[]logger.DecisionAction{{Symbol: "SOLUSDT", Action: "open_long", IdeaID: "idea-1", Status: logger.DecisionStatusExecuted, Success: true}}, false, logger.IdeaStatusExecuted},
+		{"未标注idea_id的同向开仓", []decision.Decision{{Symbol: "SOLUSDT", Action: "open_long"}},
+			[]logger.DecisionAction{{Symbol: "SOLUSDT", Action: "open_long", Status: logger.DecisionStatusExecuted, Success: true}}, false, logger.IdeaStatusExecuted},
+		{"接受但风控拒绝", []decision.Decision{open},
+			[]logger.DecisionAction{{Symbol: "SOLUSDT", Action: "open_long", IdeaID: "idea-1", Status: logger.DecisionStatusRejected, Error: "信心度不足"}}, false, logger.IdeaStatusBlocked},
+		{"接受但等待审批", []decision.Decision{open}, nil, true, logger.IdeaStatusAccepted},
+		{"AI拒绝", []decision.Decision{{Symbol: "SOLUSDT", Action: "wait", IdeaID: "idea-1", Reasoning: "量能不足"}},
+			[]logger.DecisionAction{{Symbol: "SOLUSDT", Action: "wait", IdeaID: "idea-1", Status: logger.DecisionStatusExecuted, Success: true}}, false, logger.IdeaStatusRejected},
+		{"反向开仓视为拒绝", []decision.Decision{{Symbol: "SOLUSDT", Action: "open_short", IdeaID: "idea-1"}}, nil, false, logger.IdeaStatusRejected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolveIdeaOutcomes([]decision.TradeIdeaBrief{idea}, tt.proposed, tt.actions, tt.approval)["idea-1"]
+			if got.Status != tt.want {
+				t.Errorf("status = %q, want %q", got.Status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package trader
+
+import (
+	"log"
+	"nofx/decision"
+	"nofx/market"
+	"strconv"
+)
+
+// getVolTargetPct 获取波动率目标（单仓位日波动金额占净值百分比，0=不启用）
+// 优先级：交易员配置 > 系统配置 vol_target_pct
+func (at *AutoTrader) getVolTargetPct() float64 {
+	if at.config.VolTargetPct > 0 {
+		return at.config.VolTargetPct
+	}
+
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	if db, ok := at.database.(SystemConfigGetter); ok {
+		if value, err := db.GetSystemConfig("vol_target_pct"); err == nil && value != "" {
+			if pct, err := strconv.ParseFloat(value, 64); err == nil && pct > 0 {
+				return pct
+			}
+		}
+	}
+
+	return 0
+}
+
+// applyVolatilityTarget 波动率目标仓位：仓位名义价值 × 预测日波动率 ≤ 净值 × 目标百分比
+// 只缩小AI给出的仓位，不放大；未启用或缺少波动率预测时不调整
+func (at *AutoTrader) applyVolatilityTarget(d *decision.Decision, marketData *market.Data) {
+	targetPct := at.getVolTargetPct()
+	if targetPct <= 0 || marketData.Volatility4h == nil || marketData.Volatility4h.DailyPct <= 0 {
+		return
+	}
+
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		log.Printf("  ⚠ 波动率目标仓位: 获取账户余额失败: %v", err)
+		return
+	}
+	totalWalletBalance, _ := balance["totalWalletBalance"].(float64)
+	totalUnrealizedProfit, _ := balance["totalUnrealizedProfit"].(float64)
+	totalEquity := totalWalletBalance + totalUnrealizedProfit
+	if totalEquity <= 0 {
+		return
+	}
+
+	dailyVolPct := marketData.Volatility4h.DailyPct
+	maxPositionUSD := totalEquity * targetPct / dailyVolPct
+	if d.PositionSizeUSD <= maxPositionUSD {
+		return
+	}
+
+	log.Printf("  📉 波动率目标: %s 预测日波动 %.2f%%，仓位 %.2f → %.2f USDT（目标 %.1f%% 净值/日）",
+		d.Symbol, dailyVolPct, d.PositionSizeUSD, maxPositionUSD, targetPct)
+	d.PositionSizeUSD = maxPositionUSD
+}
@@ -0,0 +1,61 @@
+package trader
+
+import (
+	"log"
+	"nofx/market"
+	"sync"
+)
+
+// warmStartState 启动预热结果
+type warmStartState struct {
+	mu     sync.RWMutex
+	report *market.WarmupReport
+}
+
+// warmStart 首个决策周期前并行预取交易币种的K线/OI/资金费率，并校验各周期历史长度
+// 币种范围 = 候选币种 + 当前持仓 + BTCUSDT（市场整体参考）
+func (at *AutoTrader) warmStart() {
+	symbols := []string{"BTCUSDT"}
+	seen := map[string]bool{"BTCUSDT": true}
+	add := func(symbol string) {
+		if !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	if positions, err := at.trader.GetPositions(); err == nil {
+		for _, pos := range positions {
+			if symbol, ok := pos["symbol"].(string); ok {
+				add(symbol)
+			}
+		}
+	} else {
+		log.Printf("⚠️  [%s] 预热时获取持仓失败: %v", at.name, err)
+	}
+	if candidates, err := at.getCandidateCoins(nil); err == nil {
+		for _, coin := range candidates {
+			add(coin.Symbol)
+		}
+	} else {
+		log.Printf("⚠️  [%s] 预热时获取候选币种失败: %v", at.name, err)
+	}
+
+	// 交易员选择的分析周期加入订阅/预热周期
+	market.EnsureTimeframes(at.GetTimeframes())
+
+	log.Printf("🔥 [%s] 预热行情数据: %d 个币种...", at.name, len(symbols))
+	report := market.Preload(symbols, 5)
+	at.warmup.mu.Lock()
+	at.warmup.report = report
+	at.warmup.mu.Unlock()
+
+	log.Printf("🔥 [%s] 行情预热完成: %d/%d 个币种历史数据充足，耗时 %dms", at.name, report.Ready, report.Total, report.DurationMs)
+}
+
+// GetWarmupReport 获取启动预热报告（未预热时返回nil）
+func (at *AutoTrader) GetWarmupReport() *market.WarmupReport {
+	at.warmup.mu.RLock()
+	defer at.warmup.mu.RUnlock()
+	return at.warmup.report
+}
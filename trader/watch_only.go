@@ -0,0 +1,98 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"sort"
+	"strings"
+)
+
+// watchOnlyKey 交易员仅观察币种在系统配置中的键（未设置时使用全局 watch_only_symbols）
+func watchOnlyKey(traderID string) string {
+	return "watch_only_symbols:" + traderID
+}
+
+// GetWatchOnlySymbols 仅观察币种（完整分析、AI可点评，但开仓决策自动转为 wait）
+// 优先级：系统配置 watch_only_symbols:<trader_id> > 全局 watch_only_symbols（逗号分隔）
+func (at *AutoTrader) GetWatchOnlySymbols() []string {
+	type SystemConfigGetter interface {
+		GetSystemConfig(key string) (string, error)
+	}
+	db, ok := at.database.(SystemConfigGetter)
+	if !ok {
+		return nil
+	}
+	for _, key := range []string{watchOnlyKey(at.id), "watch_only_symbols"} {
+		value, err := db.GetSystemConfig(key)
+		if err != nil || value == "" {
+			continue
+		}
+		return normalizeWatchOnly(strings.Split(value, ","))
+	}
+	return nil
+}
+
+// SetWatchOnlySymbols 设置交易员的仅观察币种（空列表=不限制）
+func (at *AutoTrader) SetWatchOnlySymbols(symbols []string) ([]string, error) {
+	normalized := normalizeWatchOnly(symbols)
+	type SystemConfigSetter interface {
+		SetSystemConfig(key, value string) error
+	}
+	db, ok := at.database.(SystemConfigSetter)
+	if !ok {
+		return nil, fmt.Errorf("数据库不支持系统配置")
+	}
+	// 空列表写入 "," 以覆盖全局设置（解析后为空）
+	value := strings.Join(normalized, ",")
+	if value == "" {
+		value = ","
+	}
+	if err := db.SetSystemConfig(watchOnlyKey(at.id), value); err != nil {
+		return nil, fmt.Errorf("保存仅观察币种失败: %w", err)
+	}
+	return normalized, nil
+}
+
+// normalizeWatchOnly 币种转大写、补全USDT后缀、去重排序
+func normalizeWatchOnly(symbols []string) []string {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" {
+			continue
+		}
+		if !strings.HasSuffix(symbol, "USDT") {
+			symbol += "USDT"
+		}
+		if !seen[symbol] {
+			seen[symbol] = true
+			result = append(result, symbol)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// convertWatchOnlyOpens 将仅观察币种上的开仓决策转为 wait（保留AI理由供复盘），返回执行日志
+func convertWatchOnlyOpens(decisions []decision.Decision, watchOnly []string) []string {
+	if len(watchOnly) == 0 {
+		return nil
+	}
+	watch := make(map[string]bool, len(watchOnly))
+	for _, symbol := range watchOnly {
+		watch[symbol] = true
+	}
+
+	var notes []string
+	for i := range decisions {
+		d := &decisions[i]
+		if !watch[d.Symbol] || (d.Action != "open_long" && d.Action != "open_short") {
+			continue
+		}
+		notes = append(notes, fmt.Sprintf("👀 %s 为仅观察币种，%s 已转为 wait", d.Symbol, d.Action))
+		d.Reasoning = fmt.Sprintf("[仅观察，原决策 %s] %s", d.Action, d.Reasoning)
+		d.Action = "wait"
+	}
+	return notes
+}
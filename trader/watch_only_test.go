@@ -0,0 +1,39 @@
+package trader
+
+import (
+	"nofx/decision"
+	"reflect"
+	"testing"
+)
+
+func TestConvertWatchOnlyOpens(t *testing.T) {
+	tests := []struct {
+		name      string
+		watchOnly []string
+		d         decision.Decision
+		want      string
+	}{
+		{"未设置仅观察币种", nil, decision.Decision{Symbol: "PEPEUSDT", Action: "open_long"}, "open_long"},
+		{"仅观察币种开多转为wait", []string{"PEPEUSDT"}, decision.Decision{Symbol: "PEPEUSDT", Action: "open_long"}, "wait"},
+		{"仅观察币种开空转为wait", []string{"PEPEUSDT"}, decision.Decision{Symbol: "PEPEUSDT", Action: "open_short"}, "wait"},
+		{"仅观察币种允许平仓", []string{"PEPEUSDT"}, decision.Decision{Symbol: "PEPEUSDT", Action: "close_long"}, "close_long"},
+		{"其他币种不受影响", []string{"PEPEUSDT"}, decision.Decision{Symbol: "BTCUSDT", Action: "open_long"}, "open_long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decisions := []decision.Decision{tt.d}
+			convertWatchOnlyOpens(decisions, tt.watchOnly)
+			if decisions[0].Action != tt.want {
+				t.Errorf("action = %s, want %s", decisions[0].Action, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeWatchOnly(t *testing.T) {
+	got := normalizeWatchOnly([]string{" pepe ", "WIFUSDT", "pepeusdt", ""})
+	want := []string{"PEPEUSDT", "WIFUSDT"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeWatchOnly = %v, want %v", got, want)
+	}
+}